@@ -19,6 +19,7 @@ var (
 	UintType    = arrow.PrimitiveTypes.Uint64
 	FloatType   = arrow.PrimitiveTypes.Float64
 	StringType  = arrow.BinaryTypes.String
+	BinaryType  = arrow.BinaryTypes.Binary
 	BooleanType = arrow.FixedWidthTypes.Boolean
 )
 
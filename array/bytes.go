@@ -0,0 +1,64 @@
+package array
+
+import (
+	"github.com/apache/arrow/go/v7/arrow/array"
+	"github.com/apache/arrow/go/v7/arrow/memory"
+)
+
+// Binary is an array of byte slices.
+//
+// Unlike String, a Binary array always holds its data in
+// an arrow buffer.
+type Binary = array.Binary
+
+type BinaryBuilder struct {
+	b *array.BinaryBuilder
+}
+
+func NewBinaryBuilder(mem memory.Allocator) *BinaryBuilder {
+	return &BinaryBuilder{
+		b: array.NewBinaryBuilder(mem, BinaryType),
+	}
+}
+func (b *BinaryBuilder) Retain() {
+	b.b.Retain()
+}
+func (b *BinaryBuilder) Release() {
+	b.b.Release()
+}
+func (b *BinaryBuilder) Len() int {
+	return b.b.Len()
+}
+func (b *BinaryBuilder) Cap() int {
+	return b.b.Cap()
+}
+func (b *BinaryBuilder) Append(v []byte) {
+	b.b.Append(v)
+}
+func (b *BinaryBuilder) AppendValues(v [][]byte, valid []bool) {
+	b.b.AppendValues(v, valid)
+}
+func (b *BinaryBuilder) NullN() int {
+	return b.b.NullN()
+}
+func (b *BinaryBuilder) AppendNull() {
+	b.b.AppendNull()
+}
+func (b *BinaryBuilder) UnsafeAppendBoolToBitmap(isValid bool) {
+	b.b.UnsafeAppendBoolToBitmap(isValid)
+}
+func (b *BinaryBuilder) Reserve(n int) {
+	b.b.Reserve(n)
+}
+func (b *BinaryBuilder) ReserveData(n int) {
+	b.b.ReserveData(n)
+}
+func (b *BinaryBuilder) Resize(n int) {
+	b.b.Resize(n)
+}
+func (b *BinaryBuilder) NewArray() Array {
+	return b.NewBinaryArray()
+}
+func (b *BinaryBuilder) NewBinaryArray() *Binary {
+	return b.b.NewBinaryArray()
+}
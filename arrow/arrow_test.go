@@ -3,11 +3,19 @@ package arrow_test
 import (
 	"testing"
 
+	"github.com/apache/arrow/go/v7/arrow/bitutil"
 	"github.com/apache/arrow/go/v7/arrow/math"
 	arrowmemory "github.com/apache/arrow/go/v7/arrow/memory"
 	"github.com/google/go-cmp/cmp"
+	"github.com/influxdata/flux"
 	"github.com/influxdata/flux/array"
 	"github.com/influxdata/flux/arrow"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/execute/executetest"
+	"github.com/influxdata/flux/execute/table"
+	"github.com/influxdata/flux/internal/arrowutil"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/values"
 )
 
 func TestSum_Float64_Empty(t *testing.T) {
@@ -750,3 +758,239 @@ func TestSlice_OutOfBounds_Bool(t *testing.T) {
 		})
 	}
 }
+
+func TestTableBuffer_ValidateKey(t *testing.T) {
+	defer arrow.SetValidateKeys(true)()
+
+	mem := memory.DefaultAllocator
+	key := execute.NewGroupKey(
+		[]flux.ColMeta{{Label: "t0", Type: flux.TString}},
+		[]values.Value{values.NewString("a")},
+	)
+	cols := []flux.ColMeta{
+		{Label: "t0", Type: flux.TString},
+		{Label: "_value", Type: flux.TFloat},
+	}
+
+	for _, tt := range []struct {
+		name        string
+		keyValues   []string
+		keyConstant bool
+		wantErr     bool
+	}{
+		{
+			name:      "constant",
+			keyValues: []string{"a", "a", "a"},
+		},
+		{
+			name:      "not constant",
+			keyValues: []string{"a", "b", "a"},
+			wantErr:   true,
+		},
+		{
+			name:      "wrong value",
+			keyValues: []string{"b", "b", "b"},
+			wantErr:   true,
+		},
+		{
+			// The buffer is not checked when the key is asserted to be constant.
+			name:        "not constant with flag",
+			keyValues:   []string{"a", "b", "a"},
+			keyConstant: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			buffer := &arrow.TableBuffer{
+				GroupKey: key,
+				Columns:  cols,
+				Values: []array.Array{
+					arrow.NewString(tt.keyValues, mem),
+					arrow.NewFloat([]float64{1, 2, 3}, mem),
+				},
+				KeyConstant: tt.keyConstant,
+			}
+			defer buffer.Release()
+
+			if err := buffer.Validate(); tt.wantErr && err == nil {
+				t.Error("expected error, got none")
+			} else if !tt.wantErr && err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+		})
+	}
+}
+
+func TestTableBuffer_ValidateKey_Violation(t *testing.T) {
+	newTable := func(typ flux.ColType, v0, v1 interface{}, violate bool) *executetest.Table {
+		return &executetest.Table{
+			KeyCols: []string{"t0"},
+			ColMeta: []flux.ColMeta{
+				{Label: "t0", Type: typ},
+				{Label: "_value", Type: flux.TFloat},
+			},
+			Data: [][]interface{}{
+				{v0, 1.0},
+				{v1, 2.0},
+			},
+			ViolateKey: violate,
+		}
+	}
+
+	for _, tt := range []struct {
+		name     string
+		tbl      *executetest.Table
+		validate bool
+		wantErr  bool
+	}{
+		{
+			name:     "string",
+			tbl:      newTable(flux.TString, "a", "a", false),
+			validate: true,
+		},
+		{
+			name:     "string violated",
+			tbl:      newTable(flux.TString, "a", "a", true),
+			validate: true,
+			wantErr:  true,
+		},
+		{
+			name:     "bytes",
+			tbl:      newTable(flux.TBytes, []byte("a"), []byte("a"), false),
+			validate: true,
+		},
+		{
+			name:     "bytes violated",
+			tbl:      newTable(flux.TBytes, []byte("a"), []byte("a"), true),
+			validate: true,
+			wantErr:  true,
+		},
+		{
+			name:     "time violated",
+			tbl:      newTable(flux.TTime, execute.Time(1), execute.Time(1), true),
+			validate: true,
+			wantErr:  true,
+		},
+		{
+			name:     "null violated",
+			tbl:      newTable(flux.TInt, nil, nil, true),
+			validate: true,
+			wantErr:  true,
+		},
+		{
+			// The group key columns are only checked in debug builds.
+			name: "violated without validation",
+			tbl:  newTable(flux.TString, "a", "a", true),
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			defer arrow.SetValidateKeys(tt.validate)()
+
+			err := tt.tbl.Do(func(cr flux.ColReader) error {
+				buffer := &arrow.TableBuffer{
+					GroupKey: cr.Key(),
+					Columns:  cr.Cols(),
+					Values:   make([]array.Array, len(cr.Cols())),
+				}
+				for j := range buffer.Values {
+					buffer.Values[j] = table.Values(cr, j)
+				}
+				return buffer.Validate()
+			})
+			if tt.wantErr && err == nil {
+				t.Error("expected error, got none")
+			} else if !tt.wantErr && err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+		})
+	}
+}
+
+// BenchmarkTableBuffer_FilterLimit filters every other row of
+// a table and then limits it, validating the buffer after each
+// step like the filter and limit transformations do.
+func BenchmarkTableBuffer_FilterLimit(b *testing.B) {
+	const n = 1000
+	mem := memory.DefaultAllocator
+	key := execute.NewGroupKey(
+		[]flux.ColMeta{
+			{Label: "_measurement", Type: flux.TString},
+			{Label: "host", Type: flux.TString},
+		},
+		[]values.Value{
+			values.NewString("cpu"),
+			values.NewString("server01"),
+		},
+	)
+	cols := []flux.ColMeta{
+		{Label: "_measurement", Type: flux.TString},
+		{Label: "host", Type: flux.TString},
+		{Label: "_value", Type: flux.TFloat},
+	}
+	vs := make([]float64, n)
+	for i := range vs {
+		vs[i] = float64(i)
+	}
+	bitset := make([]byte, n)
+	for i := 0; i < n; i += 2 {
+		bitutil.SetBit(bitset, i)
+	}
+
+	for _, bm := range []struct {
+		name        string
+		validate    bool
+		keyConstant bool
+	}{
+		{name: "Unchecked"},
+		{name: "Checked", validate: true},
+		{name: "KeyConstant", validate: true, keyConstant: true},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			defer arrow.SetValidateKeys(bm.validate)()
+
+			input := &arrow.TableBuffer{
+				GroupKey: key,
+				Columns:  cols,
+				Values: []array.Array{
+					arrow.Repeat(flux.TString, key.Value(0), n, mem),
+					arrow.Repeat(flux.TString, key.Value(1), n, mem),
+					arrow.NewFloat(vs, mem),
+				},
+				KeyConstant: bm.keyConstant,
+			}
+			defer input.Release()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				filtered := &arrow.TableBuffer{
+					GroupKey: key,
+					Columns:  cols,
+					Values: []array.Array{
+						arrow.Slice(input.Values[0], 0, n/2),
+						arrow.Slice(input.Values[1], 0, n/2),
+						arrowutil.Filter(input.Values[2], bitset, mem),
+					},
+					KeyConstant: input.KeyConstant,
+				}
+				if err := filtered.Validate(); err != nil {
+					b.Fatal(err)
+				}
+
+				limited := &arrow.TableBuffer{
+					GroupKey:    key,
+					Columns:     cols,
+					Values:      make([]array.Array, len(cols)),
+					KeyConstant: filtered.KeyConstant,
+				}
+				for j, arr := range filtered.Values {
+					limited.Values[j] = arrow.Slice(arr, 0, n/4)
+				}
+				if err := limited.Validate(); err != nil {
+					b.Fatal(err)
+				}
+				limited.Release()
+				filtered.Release()
+			}
+		})
+	}
+}
@@ -0,0 +1,11 @@
+package arrow
+
+// SetValidateKeys enables or disables checking the group key
+// columns in Validate and returns a function that restores it.
+func SetValidateKeys(enabled bool) (restore func()) {
+	prev := validateKeys
+	validateKeys = enabled
+	return func() {
+		validateKeys = prev
+	}
+}
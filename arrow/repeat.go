@@ -49,6 +49,21 @@ func Repeat(colType flux.ColType, v values.Value, n int, mem memory.Allocator) a
 			tval = v.Time()
 		}
 		return array.IntRepeat(int64(tval), v.IsNull(), n, mem)
+	case flux.TBytes:
+		b := array.NewBinaryBuilder(mem)
+		b.Resize(n)
+		if v.IsNull() {
+			for i := 0; i < n; i++ {
+				b.AppendNull()
+			}
+		} else {
+			bval := v.Bytes()
+			b.ReserveData(len(bval) * n)
+			for i := 0; i < n; i++ {
+				b.Append(bval)
+			}
+		}
+		return b.NewArray()
 	default:
 		panic(errors.Newf(codes.Internal, "invalid arrow primitive type: %T", colType))
 	}
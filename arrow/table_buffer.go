@@ -1,10 +1,14 @@
 package arrow
 
 import (
+	"bytes"
+
 	"github.com/influxdata/flux"
 	"github.com/influxdata/flux/array"
 	"github.com/influxdata/flux/codes"
 	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/semantic"
+	"github.com/influxdata/flux/values"
 )
 
 // TableBuffer represents the buffered component of an arrow table.
@@ -21,6 +25,21 @@ type TableBuffer struct {
 	GroupKey flux.GroupKey
 	Columns  []flux.ColMeta
 	Values   []array.Array
+
+	// KeyConstant asserts that every row of each group key column
+	// holds the group key value. Sources that build the key columns
+	// from the group key itself set this and transformations that
+	// preserve the group key columns carry it over from their input
+	// so Validate does not need to check every row in debug builds.
+	// Anything that cannot guarantee this must leave it unset.
+	KeyConstant bool
+}
+
+// IsKeyConstant reports whether the column reader is a TableBuffer
+// that asserts its group key columns are constant.
+func IsKeyConstant(cr flux.ColReader) bool {
+	buf, ok := cr.(*TableBuffer)
+	return ok && buf.KeyConstant
 }
 
 var _ flux.ColReader = (*TableBuffer)(nil)
@@ -50,6 +69,9 @@ func (t *TableBuffer) Strings(j int) *array.String {
 func (t *TableBuffer) Times(j int) *array.Int {
 	return t.Values[j].(*array.Int)
 }
+func (t *TableBuffer) Bytes(j int) *array.Binary {
+	return t.Values[j].(*array.Binary)
+}
 
 func (t *TableBuffer) Retain() {
 	for _, vs := range t.Values {
@@ -110,9 +132,108 @@ func (t *TableBuffer) Validate() error {
 			return errors.Newf(codes.Internal, "column %s of type %s is incompatible with data array %T", t.Columns[i].Label, t.Columns[i].Type, t.Values[i])
 		}
 	}
+
+	if validateKeys && !t.KeyConstant {
+		return t.validateKey()
+	}
+	return nil
+}
+
+// validateKey verifies that every row of each group key
+// column is equal to the value in the group key.
+// It visits every row so it is only used in debug builds.
+func (t *TableBuffer) validateKey() error {
+	if t.GroupKey == nil {
+		return nil
+	}
+	for j, c := range t.GroupKey.Cols() {
+		if c.Type == flux.TInvalid {
+			// Composite values such as records cannot be stored
+			// in a column so they are only part of the group key.
+			continue
+		}
+		idx := -1
+		for i, col := range t.Columns {
+			if col.Label == c.Label {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			return errors.Newf(codes.Internal, "group key column %s not found in table", c.Label)
+		}
+		if !isConstantValue(t.Values[idx], t.GroupKey.Value(j)) {
+			return errors.Newf(codes.Internal, "group key column %s does not match the group key value %v in every row", c.Label, t.GroupKey.Value(j))
+		}
+	}
 	return nil
 }
 
+// isConstantValue reports whether every value in the array is equal to v.
+func isConstantValue(arr array.Array, v values.Value) bool {
+	n := arr.Len()
+	if v.IsNull() {
+		return arr.NullN() == n
+	} else if arr.NullN() > 0 {
+		return false
+	}
+
+	switch arr := arr.(type) {
+	case *array.Int:
+		var want int64
+		if v.Type().Nature() == semantic.Time {
+			want = int64(v.Time())
+		} else {
+			want = v.Int()
+		}
+		for i := 0; i < n; i++ {
+			if arr.Value(i) != want {
+				return false
+			}
+		}
+	case *array.Uint:
+		want := v.UInt()
+		for i := 0; i < n; i++ {
+			if arr.Value(i) != want {
+				return false
+			}
+		}
+	case *array.Float:
+		want := v.Float()
+		for i := 0; i < n; i++ {
+			if arr.Value(i) != want {
+				return false
+			}
+		}
+	case *array.String:
+		want := v.Str()
+		for i := 0; i < n; i++ {
+			if arr.Value(i) != want {
+				return false
+			}
+		}
+	case *array.Boolean:
+		want := v.Bool()
+		for i := 0; i < n; i++ {
+			if arr.Value(i) != want {
+				return false
+			}
+		}
+	case *array.Binary:
+		want := v.Bytes()
+		for i := 0; i < n; i++ {
+			if !bytes.Equal(arr.Value(i), want) {
+				return false
+			}
+		}
+	default:
+		// The values of an unknown array type cannot be
+		// compared so it cannot be constant.
+		return false
+	}
+	return true
+}
+
 func (t *TableBuffer) checkCol(typ flux.ColType, arr array.Array) bool {
 	switch typ {
 	case flux.TInt, flux.TTime:
@@ -130,6 +251,9 @@ func (t *TableBuffer) checkCol(typ flux.ColType, arr array.Array) bool {
 	case flux.TBool:
 		_, ok := arr.(*array.Boolean)
 		return ok
+	case flux.TBytes:
+		_, ok := arr.(*array.Binary)
+		return ok
 	default:
 		return false
 	}
@@ -26,6 +26,8 @@ func NewBuilder(typ flux.ColType, mem memory.Allocator) array.Builder {
 		return array.NewStringBuilder(mem)
 	case flux.TBool:
 		return array.NewBooleanBuilder(mem)
+	case flux.TBytes:
+		return array.NewBinaryBuilder(mem)
 	default:
 		panic(fmt.Errorf("unknown builder for type: %s", typ))
 	}
@@ -55,6 +57,8 @@ func AppendValue(b array.Builder, v values.Value) error {
 		return AppendBool(b, v.Bool())
 	case semantic.Time:
 		return AppendTime(b, v.Time())
+	case semantic.Bytes:
+		return AppendBytes(b, v.Bytes())
 	default:
 		panic(fmt.Errorf("unknown builder for type: %s", v.Type()))
 	}
@@ -120,6 +124,16 @@ func AppendTime(b array.Builder, v values.Time) error {
 	return nil
 }
 
+// AppendBytes will append a byte slice to a compatible builder.
+func AppendBytes(b array.Builder, v []byte) error {
+	vb, ok := b.(*array.BinaryBuilder)
+	if !ok {
+		return errors.Newf(codes.Internal, "incompatible builder for type %s", flux.TBytes)
+	}
+	vb.Append(v)
+	return nil
+}
+
 // Slice will construct a new slice of the array using the given
 // start and stop index. The returned array must be released.
 //
@@ -0,0 +1,9 @@
+//go:build !debug
+// +build !debug
+
+package arrow
+
+// validateKeys enables checking that every row of each group key
+// column holds the group key value when a TableBuffer is validated.
+// The check visits every row so it is only enabled in debug builds.
+var validateKeys = false
@@ -365,7 +365,10 @@ func (a Arguments) GetRequiredTime(name string) (Time, error) {
 		return Time{}, err
 	}
 	if !ok {
-		return Time{}, errors.Newf(codes.Invalid, "missing required keyword argument %q", name)
+		// Use the error for a missing argument from the arguments
+		// so it identifies the function call.
+		_, err := a.GetRequired(name)
+		return Time{}, err
 	}
 	return qt, nil
 }
@@ -384,7 +387,8 @@ func (a Arguments) GetRequiredDuration(name string) (Duration, error) {
 		return ConvertDuration(0), err
 	}
 	if !ok {
-		return ConvertDuration(0), errors.Newf(codes.Invalid, "missing required keyword argument %q", name)
+		_, err := a.GetRequired(name)
+		return ConvertDuration(0), err
 	}
 	return d, nil
 }
@@ -1,6 +1,7 @@
 package compiler
 
 import (
+	"github.com/influxdata/flux/ast"
 	"github.com/influxdata/flux/codes"
 	"github.com/influxdata/flux/internal/errors"
 	"github.com/influxdata/flux/semantic"
@@ -67,6 +68,7 @@ func Compile(scope Scope, f *semantic.FunctionExpression, in semantic.MonoType)
 	return compiledFn{
 		root:        root,
 		parentScope: scope,
+		loc:         f.Location(),
 	}, nil
 }
 
@@ -601,6 +603,17 @@ func compile(n semantic.Node, subst semantic.Substitutor) (Evaluator, error) {
 			duration: v,
 		}, nil
 	case *semantic.UnaryExpression:
+		if m, ok := n.Argument.(*semantic.MemberExpression); ok && n.Operator == ast.ExistsOperator {
+			object, err := compile(m.Object, subst)
+			if err != nil {
+				return nil, err
+			}
+			return &existsMemberEvaluator{
+				t:        apply(subst, nil, n.TypeOf()),
+				object:   object,
+				property: m.Property.Name(),
+			}, nil
+		}
 		node, err := compile(n.Argument, subst)
 		if err != nil {
 			return nil, err
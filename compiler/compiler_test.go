@@ -260,6 +260,36 @@ func TestCompileAndEval(t *testing.T) {
 			}),
 			want: values.NewBool(false),
 		},
+		{
+			name: "unary logical operator - exists with missing member",
+			fn:   `(r) => exists r.region`,
+			inType: semantic.NewObjectType([]semantic.PropertyType{
+				{Key: []byte("r"), Value: semantic.NewObjectType([]semantic.PropertyType{
+					{Key: []byte("host"), Value: semantic.BasicString},
+				})},
+			}),
+			input: values.NewObjectWithValues(map[string]values.Value{
+				"r": values.NewObjectWithValues(map[string]values.Value{
+					"host": values.NewString("a"),
+				}),
+			}),
+			want: values.NewBool(false),
+		},
+		{
+			name: "unary logical operator - exists with missing record member",
+			fn:   `(r) => exists r.tags and r.tags.region == "east"`,
+			inType: semantic.NewObjectType([]semantic.PropertyType{
+				{Key: []byte("r"), Value: semantic.NewObjectType([]semantic.PropertyType{
+					{Key: []byte("host"), Value: semantic.BasicString},
+				})},
+			}),
+			input: values.NewObjectWithValues(map[string]values.Value{
+				"r": values.NewObjectWithValues(map[string]values.Value{
+					"host": values.NewString("a"),
+				}),
+			}),
+			want: values.NewBool(false),
+		},
 		{
 			name: "unary operator",
 			fn:   `(a) => if a < 0 then -a else +a`,
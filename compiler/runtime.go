@@ -7,6 +7,7 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/influxdata/flux/array"
 	"github.com/influxdata/flux/ast"
 	"github.com/influxdata/flux/codes"
 	"github.com/influxdata/flux/internal/errors"
@@ -29,6 +30,7 @@ type Evaluator interface {
 type compiledFn struct {
 	root        Evaluator
 	parentScope Scope
+	loc         ast.SourceLocation
 }
 
 // Type returns the return type of the compiled function.
@@ -37,7 +39,21 @@ func (c compiledFn) Type() semantic.MonoType {
 }
 
 func (c compiledFn) Eval(ctx context.Context, input values.Object) (values.Value, error) {
-	inputScope := nestScope(c.parentScope)
+	// The steps of a function that is called while another
+	// function is evaluated count against the same budget.
+	var steps *stepCounter
+	switch v := ctx.Value(stepBudgetKey{}).(type) {
+	case StepBudget:
+		steps = &stepCounter{remaining: int64(v), budget: v, loc: c.loc}
+		ctx = context.WithValue(ctx, stepBudgetKey{}, steps)
+	case *stepCounter:
+		steps = v
+	}
+	if err := steps.step(); err != nil {
+		return nil, err
+	}
+
+	inputScope := runtimeScope{Scope: c.parentScope.Nest(nil), steps: steps}
 	input.Range(func(k string, v values.Value) {
 		inputScope.Set(k, v)
 		v.Retain()
@@ -60,6 +76,9 @@ type Scope interface {
 
 type runtimeScope struct {
 	values.Scope
+	// steps counts the steps of the evaluation of the
+	// compiled function. It is nil if there is no budget.
+	steps *stepCounter
 }
 
 func (s runtimeScope) Get(name string) values.Value {
@@ -78,11 +97,53 @@ func ToScope(s values.Scope) Scope {
 	if s == nil {
 		return nil
 	}
-	return runtimeScope{s}
+	return runtimeScope{Scope: s}
 }
 
 func nestScope(scope Scope) Scope {
-	return runtimeScope{scope.Nest(nil)}
+	nested := runtimeScope{Scope: scope.Nest(nil)}
+	if s, ok := scope.(runtimeScope); ok {
+		nested.steps = s.steps
+	}
+	return nested
+}
+
+// stepBudgetKey is the context key of the StepBudget or, while
+// a compiled function is evaluated, of its stepCounter.
+type stepBudgetKey struct{}
+
+// StepBudget is a dependency that limits the number of steps of each
+// evaluation of a compiled function, such as the function of map()
+// for a single row. Every function call is a step, including the calls
+// made by the functions that are called. The evaluation fails with
+// codes.ResourceExhausted when the budget is exhausted.
+// There is no limit when the budget is not injected or not positive.
+type StepBudget int64
+
+func (b StepBudget) Inject(ctx context.Context) context.Context {
+	if b <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, stepBudgetKey{}, b)
+}
+
+// stepCounter counts the steps that remain in the evaluation
+// of a compiled function. The location is that of the function.
+type stepCounter struct {
+	remaining int64
+	budget    StepBudget
+	loc       ast.SourceLocation
+}
+
+// step takes a step from the budget. A nil counter has no budget.
+func (c *stepCounter) step() error {
+	if c == nil {
+		return nil
+	}
+	if c.remaining--; c.remaining < 0 {
+		return errors.Newf(codes.ResourceExhausted, "function @ %v exceeded the budget of %d evaluation steps", c.loc, c.budget)
+	}
+	return nil
 }
 
 func eval(ctx context.Context, e Evaluator, scope Scope) (values.Value, error) {
@@ -429,7 +490,7 @@ func (e *unaryEvaluator) Eval(ctx context.Context, scope Scope) (values.Value, e
 
 	ret, err := func(v values.Value) (values.Value, error) {
 		if e.op == ast.ExistsOperator {
-			return values.NewBool(!v.IsNull()), nil
+			return existsValue(ctx, v)
 		}
 
 		// If the value is null, return it immediately.
@@ -467,6 +528,79 @@ func (e *unaryEvaluator) Eval(ctx context.Context, scope Scope) (values.Value, e
 	return ret, nil
 }
 
+// existsMemberEvaluator evaluates the exists operator on a member of a record.
+// A member that is not a property of the record does not exist, regardless of
+// the type that was inferred for it, so it evaluates to false instead of failing
+// like the memberEvaluator would. In a row function, this is the case for a
+// column that is missing from the schema of a table.
+type existsMemberEvaluator struct {
+	t        semantic.MonoType
+	object   Evaluator
+	property string
+}
+
+func (e *existsMemberEvaluator) Type() semantic.MonoType {
+	return e.t
+}
+
+func (e *existsMemberEvaluator) Eval(ctx context.Context, scope Scope) (values.Value, error) {
+	o, err := e.object.Eval(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+	defer o.Release()
+	if o.IsNull() {
+		return nil, errors.Newf(codes.Invalid, "cannot access property of a null value; expected record")
+	}
+	if typ := o.Type().Nature(); typ != semantic.Object {
+		return nil, errors.Newf(codes.Invalid, "cannot access property of a value with type %s; expected record", typ)
+	}
+
+	obj := o.Object()
+	if v, ok := obj.Get(e.property); ok {
+		return existsValue(ctx, v)
+	}
+
+	// The properties of a vectorized record are vectors with one
+	// element for each row, so the missing member is false for
+	// as many rows as the other properties have.
+	n, vectorized := -1, false
+	obj.Range(func(name string, v values.Value) {
+		if !vectorized && v.Type().Nature() == semantic.Vector {
+			n, vectorized = v.(values.Vector).Arr().Len(), true
+		}
+	})
+	if !vectorized {
+		return values.NewBool(false), nil
+	}
+	return newBoolVector(ctx, n, func(i int) bool { return false })
+}
+
+// existsValue reports whether a value is not null.
+// A vector is checked for each of its elements.
+func existsValue(ctx context.Context, v values.Value) (values.Value, error) {
+	if v.Type().Nature() != semantic.Vector {
+		return values.NewBool(!v.IsNull()), nil
+	}
+	arr := v.(values.Vector).Arr()
+	return newBoolVector(ctx, arr.Len(), arr.IsValid)
+}
+
+// newBoolVector creates a vector of n booleans with the values of f.
+func newBoolVector(ctx context.Context, n int, f func(i int) bool) (values.Value, error) {
+	mem := memory.GetAllocator(ctx)
+	if mem == nil {
+		return nil, errors.Newf(codes.Invalid, "missing allocator, cannot use vectorized operators")
+	}
+	b := array.NewBooleanBuilder(mem)
+	defer b.Release()
+	b.Reserve(n)
+	for i := 0; i < n; i++ {
+		b.Append(f(i))
+	}
+	return values.NewBooleanVectorValue(b.NewBooleanArray()), nil
+}
+
 type integerEvaluator struct {
 	i int64
 }
@@ -664,6 +798,11 @@ func (e *callEvaluator) Type() semantic.MonoType {
 }
 
 func (e *callEvaluator) Eval(ctx context.Context, scope Scope) (values.Value, error) {
+	if s, ok := scope.(runtimeScope); ok {
+		if err := s.steps.step(); err != nil {
+			return nil, err
+		}
+	}
 	args, err := e.args.Eval(ctx, scope)
 	if err != nil {
 		return nil, err
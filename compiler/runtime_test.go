@@ -2,11 +2,18 @@ package compiler
 
 import (
 	"context"
+	"strings"
 	"testing"
 
+	arrowmem "github.com/apache/arrow/go/v7/arrow/memory"
 	"github.com/google/go-cmp/cmp"
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/array"
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/codes"
 	"github.com/influxdata/flux/interpreter"
 	"github.com/influxdata/flux/libflux/go/libflux"
+	"github.com/influxdata/flux/memory"
 	"github.com/influxdata/flux/runtime"
 	"github.com/influxdata/flux/semantic"
 	"github.com/influxdata/flux/semantic/semantictest"
@@ -102,3 +109,236 @@ func TestIndexExpr_TableObjectIsError(t *testing.T) {
 		t.Errorf("wanted error %q, got %q", want, got)
 	}
 }
+
+// newCallsFn returns a compiled function that builds an array
+// by calling the function named f with each of n integers.
+func newCallsFn(n int, f values.Function) compiledFn {
+	calls := make([]Evaluator, n)
+	for i := range calls {
+		calls[i] = &callEvaluator{
+			t:      semantic.BasicInt,
+			callee: &identifierEvaluator{t: f.Type(), name: "f"},
+			args: &objEvaluator{
+				properties: map[string]Evaluator{
+					"x": &integerEvaluator{i: int64(i)},
+				},
+			},
+		}
+	}
+	scope := NewScope()
+	scope.Set("f", f)
+	return compiledFn{
+		root:        &arrayEvaluator{t: semantic.NewArrayType(semantic.BasicInt), array: calls},
+		parentScope: scope,
+		loc: ast.SourceLocation{
+			Start: ast.Position{Line: 1, Column: 1},
+			End:   ast.Position{Line: 1, Column: 20},
+		},
+	}
+}
+
+var incType = semantic.NewFunctionType(semantic.BasicInt, []semantic.ArgumentType{
+	{Name: []byte("x"), Type: semantic.BasicInt},
+})
+
+// inc is a builtin function that adds one to x.
+var inc = values.NewFunction("inc", incType, func(ctx context.Context, args values.Object) (values.Value, error) {
+	x, _ := args.Get("x")
+	return values.NewInt(x.Int() + 1), nil
+}, false)
+
+func TestStepBudget(t *testing.T) {
+	// The function that is called evaluates a compiled function
+	// for each call, so those steps count against the budget of
+	// the function that calls it.
+	fn := newCallsFn(1, inc)
+	nested := values.NewFunction("nested", incType, func(ctx context.Context, args values.Object) (values.Value, error) {
+		for i := 0; i < 10; i++ {
+			if _, err := fn.Eval(ctx, values.NewObject(semantic.NewObjectType(nil))); err != nil {
+				return nil, err
+			}
+		}
+		return values.NewInt(0), nil
+	}, false)
+
+	for _, tc := range []struct {
+		name    string
+		fn      compiledFn
+		budget  StepBudget
+		wantErr bool
+	}{
+		{
+			name: "no budget",
+			fn:   newCallsFn(100, inc),
+		},
+		{
+			name:   "negative budget",
+			fn:     newCallsFn(100, inc),
+			budget: -1,
+		},
+		{
+			// One step for the evaluation and one for each call.
+			name:   "within budget",
+			fn:     newCallsFn(100, inc),
+			budget: 101,
+		},
+		{
+			name:    "exceeds budget",
+			fn:      newCallsFn(100, inc),
+			budget:  100,
+			wantErr: true,
+		},
+		{
+			// Each of the two calls evaluates the
+			// nested function 10 times with 2 steps.
+			name:   "nested within budget",
+			fn:     newCallsFn(2, nested),
+			budget: 43,
+		},
+		{
+			name:    "nested exceeds budget",
+			fn:      newCallsFn(2, nested),
+			budget:  42,
+			wantErr: true,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := tc.budget.Inject(context.Background())
+			input := values.NewObject(semantic.NewObjectType(nil))
+			// Each evaluation has its own budget.
+			for i := 0; i < 2; i++ {
+				_, err := tc.fn.Eval(ctx, input)
+				if !tc.wantErr {
+					if err != nil {
+						t.Fatalf("unexpected error: %s", err)
+					}
+					continue
+				}
+				if err == nil {
+					t.Fatal("expected an error, got none")
+				}
+				if want, got := codes.ResourceExhausted, flux.ErrorCode(err); want != got {
+					t.Errorf("unexpected error code -want/+got\n\t- %s\n\t+ %s", want, got)
+				}
+				if want := "function @ 1:1-1:20 exceeded the budget"; !strings.Contains(err.Error(), want) {
+					t.Errorf("expected error to contain %q, got %q", want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestStepBudget_UserFunctions(t *testing.T) {
+	// Every call of f and g is a step.
+	src := `(r) => {
+	f = (x) => x + 1
+	g = (x) => f(x: f(x: f(x: x)))
+	return g(x: g(x: g(x: r.v)))
+}`
+	pkg, err := runtime.AnalyzeSource(context.Background(), src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fnExpr := pkg.Files[0].Body[0].(*semantic.ExpressionStatement).Expression.(*semantic.FunctionExpression)
+	input := values.NewObjectWithValues(map[string]values.Value{
+		"v": values.NewInt(0),
+	})
+	fn, err := Compile(nil, fnExpr, input.Type())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The evaluation of fn, 3 calls of g, and each evaluation of g
+	// with its 3 calls of f that are evaluated in turn.
+	const steps = 1 + 3*(2+3*2)
+	if v, err := fn.Eval(StepBudget(steps).Inject(context.Background()), input); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if want, got := int64(9), v.Int(); want != got {
+		t.Fatalf("unexpected value -want/+got\n\t- %d\n\t+ %d", want, got)
+	}
+	_, err = fn.Eval(StepBudget(steps-1).Inject(context.Background()), input)
+	if err == nil {
+		t.Fatal("expected an error, got none")
+	}
+	if want, got := codes.ResourceExhausted, flux.ErrorCode(err); want != got {
+		t.Errorf("unexpected error code -want/+got\n\t- %s\n\t+ %s", want, got)
+	}
+}
+
+// BenchmarkStepBudget measures the cost of counting the steps of
+// a function that makes a few calls, like the function of a map().
+func BenchmarkStepBudget(b *testing.B) {
+	fn := newCallsFn(4, inc)
+	input := values.NewObject(semantic.NewObjectType(nil))
+	for _, bc := range []struct {
+		name   string
+		budget StepBudget
+	}{
+		{name: "none"},
+		{name: "budget", budget: 1000000},
+	} {
+		bc := bc
+		b.Run(bc.name, func(b *testing.B) {
+			ctx := bc.budget.Inject(context.Background())
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				v, err := fn.Eval(ctx, input)
+				if err != nil {
+					b.Fatal(err)
+				}
+				v.Release()
+			}
+		})
+	}
+}
+
+func TestExistsMemberEvaluator_Vectorized(t *testing.T) {
+	checked := arrowmem.NewCheckedAllocator(arrowmem.DefaultAllocator)
+	defer checked.AssertSize(t, 0)
+	mem := memory.NewResourceAllocator(checked)
+	ctx := memory.WithAllocator(context.Background(), mem)
+
+	b := array.NewFloatBuilder(mem)
+	b.Append(1)
+	b.AppendNull()
+	b.Append(3)
+	r := values.NewObjectWithValues(map[string]values.Value{
+		"_value": values.NewFloatVectorValue(b.NewFloatArray()),
+	})
+	b.Release()
+	defer r.Release()
+
+	scope := NewScope()
+	scope.Set("r", r)
+	for _, tt := range []struct {
+		property string
+		want     []bool
+	}{
+		{property: "_value", want: []bool{true, false, true}},
+		{property: "region", want: []bool{false, false, false}},
+	} {
+		t.Run(tt.property, func(t *testing.T) {
+			e := &existsMemberEvaluator{
+				t:        semantic.NewVectorType(semantic.BasicBool),
+				object:   &identifierEvaluator{t: r.Type(), name: "r"},
+				property: tt.property,
+			}
+			v, err := e.Eval(ctx, scope)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer v.Release()
+
+			arr := v.(values.Vector).Arr().(*array.Boolean)
+			got := make([]bool, arr.Len())
+			for i := range got {
+				got[i] = arr.Value(i)
+			}
+			if !cmp.Equal(tt.want, got) {
+				t.Errorf("unexpected values -want/+got:\n%s", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
@@ -2,11 +2,14 @@
 package csv
 
 import (
+	"bufio"
 	"context"
+	"encoding/base64"
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"strconv"
 	"strings"
 	"sync/atomic"
@@ -19,8 +22,10 @@ import (
 	"github.com/influxdata/flux/arrow"
 	"github.com/influxdata/flux/codes"
 	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/internal/decimal"
 	"github.com/influxdata/flux/internal/errors"
 	"github.com/influxdata/flux/iocounter"
+	"github.com/influxdata/flux/semantic"
 	"github.com/influxdata/flux/values"
 )
 
@@ -36,6 +41,7 @@ const (
 	datatypeAnnotation = "datatype"
 	groupAnnotation    = "group"
 	defaultAnnotation  = "default"
+	metadataAnnotation = "metadata"
 
 	resultLabel = "result"
 	tableLabel  = "table"
@@ -48,13 +54,19 @@ const (
 	boolDatatype   = "boolean"
 	intDatatype    = "long"
 	uintDatatype   = "unsignedLong"
+	bytesDatatype  = "base64Binary"
 
 	timeDataTypeWithFmt = "dateTime:RFC3339"
 
 	nullValue = ""
+
+	// utf8BOM is the byte order mark that programs such as
+	// Excel write at the start of a UTF-8 encoded CSV file.
+	utf8BOM = "\ufeff"
 )
 
 // ResultDecoder decodes a csv representation of a result.
+// A byte order mark at the start of the data is ignored.
 type ResultDecoder struct {
 	c ResultDecoderConfig
 }
@@ -87,10 +99,33 @@ type ResultDecoderConfig struct {
 	// When the context is canceled, the decoder will also be canceled.
 	// This defaults to context.Background.
 	Context context.Context
+	// ErrorTables indicates that an error that was serialized to the CSV data
+	// is decoded as a table whose Do method returns the error. The tables before
+	// the error can then be read, and the error is returned where it was written.
+	// Without it, the error fails the decoding of the result.
+	ErrorTables bool
+	// TrimSpace indicates that the leading and trailing white space
+	// of every cell, including the annotations and the header row,
+	// is removed before the cell is decoded.
+	TrimSpace bool
+	// NullValues are the cells that are decoded as null for any
+	// column type. A cell is compared after its white space
+	// is trimmed when TrimSpace is set.
+	NullValues []string
+}
+
+// isNullValue reports whether the cell is one of the NullValues.
+func (c ResultDecoderConfig) isNullValue(cell string) bool {
+	for _, v := range c.NullValues {
+		if cell == v {
+			return true
+		}
+	}
+	return false
 }
 
 func (d *ResultDecoder) Decode(r io.Reader) (flux.Result, error) {
-	return newResultDecoder(newCSVReader(r), d.c, nil)
+	return newResultDecoder(newCSVReader(r, d.c), d.c, nil)
 }
 
 // MultiResultDecoder reads multiple results from a single csv file.
@@ -113,7 +148,7 @@ func (d *MultiResultDecoder) Decode(r io.ReadCloser) (flux.ResultIterator, error
 	return &resultIterator{
 		c:  d.c,
 		r:  r,
-		cr: newCSVReader(r),
+		cr: newCSVReader(r, d.c),
 	}, nil
 }
 
@@ -179,6 +214,12 @@ type resultDecoder struct {
 	cr *bufferedCSVReader
 
 	extraMeta *tableMetadata
+	// metadata is the metadata of the result that
+	// was read with the first table of the result.
+	metadata map[string]string
+	// err is an error that was serialized in place of the
+	// metadata of the result when ErrorTables is set.
+	err error
 
 	eof bool
 }
@@ -196,32 +237,55 @@ func newResultDecoder(cr *bufferedCSVReader, c ResultDecoderConfig, extraMeta *t
 			if err == io.EOF {
 				return nil, err
 			} else if sfe, ok := err.(*serializedFluxError); ok {
-				return nil, sfe.err
+				if !c.ErrorTables {
+					return nil, sfe.err
+				}
+				// The error has no result ID, so it is decoded
+				// as the only table of an unnamed result.
+				d.err = sfe.err
+				return d, nil
 			}
 			return nil, errors.Wrap(err, codes.Inherit, "failed to read metadata")
 		}
 		d.extraMeta = &tm
 	}
 	d.id = d.extraMeta.ResultID
+	d.metadata = d.extraMeta.Metadata
 	return d, nil
 }
 
-func newCSVReader(r io.Reader) *bufferedCSVReader {
-	csvr := csv.NewReader(r)
+func newCSVReader(r io.Reader, c ResultDecoderConfig) *bufferedCSVReader {
+	csvr := csv.NewReader(skipBOM(r))
 	csvr.ReuseRecord = true
 	// Do not check record size
 	csvr.FieldsPerRecord = -1
 	csvr.LazyQuotes = true
 	return &bufferedCSVReader{
-		r:    csvr,
-		line: nil,
+		r:         csvr,
+		line:      nil,
+		trimSpace: c.TrimSpace,
 	}
 }
 
+// skipBOM removes the byte order mark at the start of r
+// so it is not read as part of the first cell.
+func skipBOM(r io.Reader) io.Reader {
+	br := bufio.NewReader(r)
+	if b, err := br.Peek(len(utf8BOM)); err == nil && string(b) == utf8BOM {
+		_, _ = br.Discard(len(utf8BOM))
+	}
+	return br
+}
+
 func (r *resultDecoder) Name() string {
 	return r.id
 }
 
+// Metadata implements flux.MetadataResult.
+func (r *resultDecoder) Metadata() map[string]string {
+	return r.metadata
+}
+
 func (r *resultDecoder) Tables() flux.TableIterator {
 	return r
 }
@@ -236,6 +300,14 @@ func (r *resultDecoder) Do(f func(flux.Table) error) error {
 		ctx = context.Background()
 	}
 
+	if r.err != nil {
+		err := r.err
+		r.err = nil
+		if err := f(&errorTable{err: err}); err != nil {
+			return err
+		}
+	}
+
 	var meta tableMetadata
 	newMeta := true
 	for !r.eof {
@@ -250,7 +322,13 @@ func (r *resultDecoder) Do(f func(flux.Table) error) error {
 						goto EOF
 					}
 					if sfe, ok := err.(*serializedFluxError); ok {
-						return sfe.err
+						if !r.c.ErrorTables {
+							return sfe.err
+						}
+						if err := f(&errorTable{err: sfe.err}); err != nil {
+							return err
+						}
+						continue
 					}
 					return errors.Wrap(err, codes.Inherit, "failed to read metadata")
 				}
@@ -310,6 +388,33 @@ type tableMetadata struct {
 	Defaults       []values.Value
 	NumFields      int
 	RecordStartIdx int
+	// Metadata is the metadata of the result
+	// that was annotated before the table.
+	Metadata map[string]string
+}
+
+// errorTable is a table that returns an error that was
+// serialized to CSV when it is read.
+type errorTable struct {
+	err error
+}
+
+func (t *errorTable) Key() flux.GroupKey {
+	return execute.NewGroupKey(nil, nil)
+}
+
+func (t *errorTable) Cols() []flux.ColMeta {
+	return nil
+}
+
+func (t *errorTable) Do(f func(flux.ColReader) error) error {
+	return t.err
+}
+
+func (t *errorTable) Done() {}
+
+func (t *errorTable) Empty() bool {
+	return false
 }
 
 // serializedFluxError represents an error that occurred during
@@ -332,6 +437,7 @@ func readMetadata(r *bufferedCSVReader, c ResultDecoderConfig) (tableMetadata, e
 	var recordStartIdx int
 	var resultID, tableID string
 	var datatypes, groups, defaults []string
+	var metadata map[string]string
 	if c.NoAnnotations {
 		// No annotations means that we are going to treat all rows as part of the same result with exactly one table
 		resultID = "_result"
@@ -404,6 +510,13 @@ func readMetadata(r *bufferedCSVReader, c ResultDecoderConfig) (tableMetadata, e
 					return tableMetadata{}, fmt.Errorf("default Table ID is not an integer")
 				}
 				defaults = copyLine(line[defaultRecordStartIdx:])
+			case metadataAnnotation:
+				if resultIdx >= len(line) {
+					return tableMetadata{}, errors.Wrap(csv.ErrFieldCount, codes.Invalid, "failed to read \"metadata\" annotation")
+				}
+				if err := json.Unmarshal([]byte(line[resultIdx]), &metadata); err != nil {
+					return tableMetadata{}, errors.Wrap(err, codes.Invalid, "failed to read \"metadata\" annotation")
+				}
 			default:
 				if !strings.HasPrefix(line[annotationIdx], commentPrefix) {
 					switch {
@@ -507,6 +620,7 @@ func readMetadata(r *bufferedCSVReader, c ResultDecoderConfig) (tableMetadata, e
 		Defaults:       defaultValues,
 		NumFields:      n,
 		RecordStartIdx: recordStartIdx,
+		Metadata:       metadata,
 	}, nil
 }
 
@@ -689,7 +803,9 @@ func (d *tableDecoder) init(line []string) error {
 	for j, c := range d.meta.Cols {
 		if d.meta.Groups[j] {
 			var value values.Value
-			if record != nil && record[j] != "" {
+			if record != nil && d.c.isNullValue(record[j]) {
+				value = values.NewNull(flux.SemanticType(c.Type))
+			} else if record != nil && record[j] != "" {
 				// TODO: consider treatment of nullValue here
 				v, err := decodeValue(record[j], c)
 				if err != nil {
@@ -724,7 +840,10 @@ func (d *tableDecoder) init(line []string) error {
 func (d *tableDecoder) appendRecord(record []string) error {
 	d.empty = false
 	for j, c := range d.meta.Cols {
-		if record[j] == "" {
+		if d.c.isNullValue(record[j]) {
+			d.cols[j].AppendNull()
+			continue
+		} else if record[j] == "" {
 			v := d.meta.Defaults[j]
 			if err := arrow.AppendValue(d.cols[j], v); err != nil {
 				return err
@@ -779,6 +898,18 @@ func (d *tableDecoder) release() {
 type colMeta struct {
 	flux.ColMeta
 	fmt string
+
+	// floatFormat and precision determine
+	// how the values of a float column are encoded.
+	floatFormat FloatFormat
+	precision   int
+	// nonFinite determines how NaN and ±Inf are encoded.
+	nonFinite NonFiniteFormat
+
+	// cell is the JSON encoding of the composite group key value
+	// of the column. It is set when jsonCell is true.
+	jsonCell bool
+	cell     string
 }
 
 type ResultEncoder struct {
@@ -797,6 +928,96 @@ type ResultEncoderConfig struct {
 	// Delimiter is the character to delimite columns.
 	// It must not be \r, \n, or the Unicode replacement character (0xFFFD).
 	Delimiter rune
+
+	// FloatFormat determines how FloatPrecision is applied
+	// to float columns. It defaults to FullPrecision.
+	FloatFormat FloatFormat
+
+	// FloatPrecision is the number of decimal places or significant
+	// digits that floats are rounded to before they are encoded.
+	// It is ignored when the FloatFormat is FullPrecision.
+	FloatPrecision int
+
+	// NonFiniteFloats determines how NaN and ±Inf floats
+	// are encoded. It defaults to NonFiniteAsString.
+	NonFiniteFloats NonFiniteFormat
+
+	// JSONCells encodes the composite group key values of a table,
+	// such as records and arrays, as JSON in string columns.
+	// A table with a composite group key value cannot be encoded
+	// when it is false.
+	JSONCells bool
+}
+
+// FloatFormat determines how floats are rounded when they are encoded.
+type FloatFormat int
+
+const (
+	// FullPrecision encodes a float with the fewest digits
+	// needed to decode the same float.
+	FullPrecision FloatFormat = iota
+	// DecimalPlaces rounds a float to FloatPrecision decimal places.
+	DecimalPlaces
+	// SignificantDigits rounds a float to FloatPrecision significant digits.
+	SignificantDigits
+)
+
+var floatFormatNames = map[FloatFormat]string{
+	FullPrecision:     "full",
+	DecimalPlaces:     "decimal",
+	SignificantDigits: "significant",
+}
+
+func (f FloatFormat) String() string {
+	return floatFormatNames[f]
+}
+
+func parseFloatFormat(s string) (FloatFormat, error) {
+	if s == "" {
+		return FullPrecision, nil
+	}
+	for f, name := range floatFormatNames {
+		if name == s {
+			return f, nil
+		}
+	}
+	return 0, errors.Newf(codes.Invalid, "invalid float format %q, must be %q, %q or %q", s,
+		FullPrecision, DecimalPlaces, SignificantDigits)
+}
+
+// NonFiniteFormat determines how NaN and ±Inf floats are encoded.
+type NonFiniteFormat int
+
+const (
+	// NonFiniteAsString encodes NaN and ±Inf as NaN, +Inf and -Inf.
+	NonFiniteAsString NonFiniteFormat = iota
+	// NonFiniteAsNull encodes NaN and ±Inf as null values.
+	NonFiniteAsNull
+	// NonFiniteAsError fails to encode a table with NaN or ±Inf.
+	NonFiniteAsError
+)
+
+var nonFiniteFormatNames = map[NonFiniteFormat]string{
+	NonFiniteAsString: "string",
+	NonFiniteAsNull:   "null",
+	NonFiniteAsError:  "error",
+}
+
+func (f NonFiniteFormat) String() string {
+	return nonFiniteFormatNames[f]
+}
+
+func parseNonFiniteFormat(s string) (NonFiniteFormat, error) {
+	if s == "" {
+		return NonFiniteAsString, nil
+	}
+	for f, name := range nonFiniteFormatNames {
+		if name == s {
+			return f, nil
+		}
+	}
+	return 0, errors.Newf(codes.Invalid, "invalid non-finite float format %q, must be %q, %q or %q", s,
+		NonFiniteAsString, NonFiniteAsNull, NonFiniteAsError)
 }
 
 func (c ResultEncoderConfig) MarshalJSON() ([]byte, error) {
@@ -804,10 +1025,23 @@ func (c ResultEncoderConfig) MarshalJSON() ([]byte, error) {
 		Header      bool     `json:"header,omitempty"`
 		Delimiter   string   `json:"delimiter"`
 		Annotations []string `json:"annotations,omitempty"`
+
+		FloatFormat     string `json:"floatFormat,omitempty"`
+		FloatPrecision  int    `json:"floatPrecision,omitempty"`
+		NonFiniteFloats string `json:"nonFiniteFloats,omitempty"`
+		JSONCells       bool   `json:"jsonCells,omitempty"`
 	}{
 		Delimiter:   string(c.Delimiter),
 		Annotations: c.Annotations,
 		Header:      !c.NoHeader,
+		JSONCells:   c.JSONCells,
+	}
+	if c.FloatFormat != FullPrecision {
+		request.FloatFormat = c.FloatFormat.String()
+		request.FloatPrecision = c.FloatPrecision
+	}
+	if c.NonFiniteFloats != NonFiniteAsString {
+		request.NonFiniteFloats = c.NonFiniteFloats.String()
 	}
 
 	return json.Marshal(request)
@@ -818,6 +1052,11 @@ func (c *ResultEncoderConfig) UnmarshalJSON(b []byte) error {
 		Header      *bool    `json:"header,omitempty"`
 		Delimiter   string   `json:"delimiter"`
 		Annotations []string `json:"annotations,omitempty"`
+
+		FloatFormat     string `json:"floatFormat,omitempty"`
+		FloatPrecision  int    `json:"floatPrecision,omitempty"`
+		NonFiniteFloats string `json:"nonFiniteFloats,omitempty"`
+		JSONCells       bool   `json:"jsonCells,omitempty"`
 	}{}
 
 	if err := json.Unmarshal(b, request); err != nil {
@@ -836,6 +1075,20 @@ func (c *ResultEncoderConfig) UnmarshalJSON(b []byte) error {
 
 	c.Annotations = request.Annotations
 
+	format, err := parseFloatFormat(request.FloatFormat)
+	if err != nil {
+		return err
+	}
+	c.FloatFormat = format
+	c.FloatPrecision = request.FloatPrecision
+
+	nonFinite, err := parseNonFiniteFormat(request.NonFiniteFloats)
+	if err != nil {
+		return err
+	}
+	c.NonFiniteFloats = nonFinite
+	c.JSONCells = request.JSONCells
+
 	return nil
 }
 
@@ -900,17 +1153,32 @@ func (e *ResultEncoder) Encode(w io.Writer, result flux.Result) (int64, error) {
 	var lastEmpty bool
 
 	resultName := result.Name()
+	var metadata map[string]string
+	if mr, ok := result.(flux.MetadataResult); ok && len(e.c.Annotations) > 0 {
+		metadata = mr.Metadata()
+	}
 	err := result.Tables().Do(func(tbl flux.Table) error {
+		jsonCols, err := e.jsonCells(tbl.Key())
+		if err != nil {
+			return err
+		}
+
 		e.written = true
 		// Update cols with table cols
 		cols := metaCols
 		for _, c := range tbl.Cols() {
 			cm := colMeta{ColMeta: c}
-			if c.Type == flux.TTime {
+			switch c.Type {
+			case flux.TTime:
 				cm.fmt = time.RFC3339Nano
+			case flux.TFloat:
+				cm.floatFormat = e.c.FloatFormat
+				cm.precision = e.c.FloatPrecision
+				cm.nonFinite = e.c.NonFiniteFloats
 			}
 			cols = append(cols, cm)
 		}
+		cols = append(cols, jsonCols...)
 		// pre-allocate row slice
 		row := make([]string, len(cols))
 
@@ -918,6 +1186,12 @@ func (e *ResultEncoder) Encode(w io.Writer, result flux.Result) (int64, error) {
 			if len(lastCols) > 0 {
 				// Write out empty line if not first table
 				writer.Write(nil)
+			} else if len(metadata) > 0 {
+				// The metadata of the result is annotated
+				// once, before the first table.
+				if err := writeMetadata(writer, row, metadata); err != nil {
+					return wrapEncodingError(err)
+				}
 			}
 
 			if err := writeSchema(writer, &e.c, row, cols, tbl.Empty(), tbl.Key(), resultName, tableIDStr); err != nil {
@@ -958,6 +1232,10 @@ func (e *ResultEncoder) Encode(w io.Writer, result flux.Result) (int64, error) {
 			l := cr.Len()
 			for i := 0; i < l; i++ {
 				for j, c := range cols[defaultRecordStartIdx:] {
+					if c.jsonCell {
+						record[j] = c.cell
+						continue
+					}
 					v, err := encodeValueFrom(i, j, c, cr)
 					if err != nil {
 						return wrapEncodingError(err)
@@ -983,6 +1261,88 @@ func (e *ResultEncoder) Encode(w io.Writer, result flux.Result) (int64, error) {
 	return writeCounter.Count(), err
 }
 
+// jsonCells returns the columns that encode the composite
+// values of the group key as JSON. Composite values such as
+// records cannot be stored in a column so they are only
+// part of the group key of a table.
+func (e *ResultEncoder) jsonCells(key flux.GroupKey) ([]colMeta, error) {
+	var cols []colMeta
+	for j, c := range key.Cols() {
+		if c.Type != flux.TInvalid {
+			continue
+		}
+		if !e.c.JSONCells {
+			return nil, errors.Newf(codes.Invalid, "cannot encode group key column %q: composite values are only supported as JSON cells", c.Label)
+		}
+		cell, err := encodeJSONCell(key.Value(j))
+		if err != nil {
+			return nil, errors.Wrapf(err, codes.Inherit, "cannot encode group key column %q", c.Label)
+		}
+		cols = append(cols, colMeta{
+			ColMeta:  flux.ColMeta{Label: c.Label, Type: flux.TString},
+			jsonCell: true,
+			cell:     cell,
+		})
+	}
+	return cols, nil
+}
+
+// encodeJSONCell encodes a composite value as JSON.
+// Records are encoded with their properties sorted by label
+// so equal records are encoded the same way.
+func encodeJSONCell(v values.Value) (string, error) {
+	if v.IsNull() {
+		return nullValue, nil
+	}
+	data, err := jsonValue(v)
+	if err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func jsonValue(v values.Value) (interface{}, error) {
+	if v.IsNull() {
+		return nil, nil
+	}
+	switch n := v.Type().Nature(); n {
+	case semantic.Array:
+		arr := v.Array()
+		a := make([]interface{}, arr.Len())
+		var err error
+		arr.Range(func(i int, v values.Value) {
+			if err == nil {
+				a[i], err = jsonValue(v)
+			}
+		})
+		return a, err
+	case semantic.Object:
+		obj := v.Object()
+		o := make(map[string]interface{}, obj.Len())
+		var err error
+		obj.Range(func(k string, v values.Value) {
+			if err == nil {
+				o[k], err = jsonValue(v)
+			}
+		})
+		return o, err
+	case semantic.Time:
+		return v.Time().Time().Format(time.RFC3339Nano), nil
+	case semantic.Duration:
+		return v.Duration().String(), nil
+	case semantic.Regexp:
+		return v.Regexp().String(), nil
+	case semantic.Dictionary, semantic.Function:
+		return nil, errors.Newf(codes.Invalid, "cannot encode a %v value as JSON", n)
+	default:
+		return values.Unwrap(v), nil
+	}
+}
+
 func (e *ResultEncoder) EncodeError(w io.Writer, err error) error {
 	writer := e.csvWriter(w)
 	if e.written {
@@ -1051,6 +1411,26 @@ func writeSchema(writer *csv.Writer, c *ResultEncoderConfig, row []string, cols
 	return writer.Error()
 }
 
+// writeMetadata writes the metadata annotation with the metadata
+// of the result encoded as a JSON object in the result column.
+func writeMetadata(writer *csv.Writer, row []string, metadata map[string]string) error {
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	for j := range row {
+		switch j {
+		case annotationIdx:
+			row[j] = commentPrefix + metadataAnnotation
+		case resultIdx:
+			row[j] = string(data)
+		default:
+			row[j] = ""
+		}
+	}
+	return writer.Write(row)
+}
+
 func writeAnnotations(writer *csv.Writer, annotations []string, row, defaults []string, cols []colMeta, key flux.GroupKey) error {
 	for _, annotation := range annotations {
 		switch annotation {
@@ -1092,6 +1472,8 @@ func writeDatatypes(writer *csv.Writer, row []string, cols []colMeta) error {
 			row[j] = stringDatatype
 		case flux.TTime:
 			row[j] = timeDataTypeWithFmt
+		case flux.TBytes:
+			row[j] = bytesDatatype
 		default:
 			return fmt.Errorf("unknown column type %v", c.Type)
 		}
@@ -1165,6 +1547,12 @@ func decodeValue(value string, c colMeta) (values.Value, error) {
 			return nil, err
 		}
 		val = values.NewTime(v)
+	case flux.TBytes:
+		v, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, err
+		}
+		val = values.NewBytes(v)
 	default:
 		return nil, fmt.Errorf("unsupported type %v", c.Type)
 	}
@@ -1205,13 +1593,21 @@ func decodeValueInto(c colMeta, value string, b array.Builder) error {
 			return err
 		}
 		return arrow.AppendTime(b, t)
+	case flux.TBytes:
+		v, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return err
+		}
+		return arrow.AppendBytes(b, v)
 	default:
 		return fmt.Errorf("unsupported type %v", c.Type)
 	}
 }
 
 func encodeValue(value values.Value, c colMeta) (string, error) {
-	if value.IsNull() {
+	if c.jsonCell {
+		return c.cell, nil
+	} else if value.IsNull() {
 		return nullValue, nil
 	}
 
@@ -1223,11 +1619,13 @@ func encodeValue(value values.Value, c colMeta) (string, error) {
 	case flux.TUInt:
 		return strconv.FormatUint(value.UInt(), 10), nil
 	case flux.TFloat:
-		return strconv.FormatFloat(value.Float(), 'f', -1, 64), nil
+		return encodeFloat(value.Float(), c)
 	case flux.TString:
 		return value.Str(), nil
 	case flux.TTime:
 		return encodeTime(value.Time(), c.fmt), nil
+	case flux.TBytes:
+		return base64.StdEncoding.EncodeToString(value.Bytes()), nil
 	default:
 		return "", fmt.Errorf("unknown type %v", c.Type)
 	}
@@ -1250,7 +1648,7 @@ func encodeValueFrom(i, j int, c colMeta, cr flux.ColReader) (string, error) {
 		}
 	case flux.TFloat:
 		if cr.Floats(j).IsValid(i) {
-			v = strconv.FormatFloat(cr.Floats(j).Value(i), 'f', -1, 64)
+			return encodeFloat(cr.Floats(j).Value(i), c)
 		}
 	case flux.TString:
 		if cr.Strings(j).IsValid(i) {
@@ -1260,6 +1658,10 @@ func encodeValueFrom(i, j int, c colMeta, cr flux.ColReader) (string, error) {
 		if cr.Times(j).IsValid(i) {
 			v = encodeTime(execute.Time(cr.Times(j).Value(i)), c.fmt)
 		}
+	case flux.TBytes:
+		if cr.Bytes(j).IsValid(i) {
+			v = base64.StdEncoding.EncodeToString(cr.Bytes(j).Value(i))
+		}
 	default:
 		return "", fmt.Errorf("unknown type %v", c.Type)
 	}
@@ -1267,6 +1669,28 @@ func encodeValueFrom(i, j int, c colMeta, cr flux.ColReader) (string, error) {
 	return v, nil
 }
 
+// encodeFloat rounds f as the column requests and encodes it with
+// the fewest digits needed to decode the rounded float.
+// NaN and ±Inf are not rounded and are encoded as the column requests.
+func encodeFloat(f float64, c colMeta) (string, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		switch c.nonFinite {
+		case NonFiniteAsNull:
+			return nullValue, nil
+		case NonFiniteAsError:
+			return "", errors.Newf(codes.Invalid, "cannot encode %s in float column %q", strconv.FormatFloat(f, 'f', -1, 64), c.Label)
+		}
+		return strconv.FormatFloat(f, 'f', -1, 64), nil
+	}
+	switch c.floatFormat {
+	case DecimalPlaces:
+		f = decimal.Round(f, c.precision)
+	case SignificantDigits:
+		f = decimal.RoundSignificant(f, c.precision)
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64), nil
+}
+
 func decodeTime(t string, fmt string) (execute.Time, error) {
 	v, err := time.Parse(fmt, t)
 	if err != nil {
@@ -1305,6 +1729,8 @@ func decodeType(datatype string) (t flux.ColType, desc string, err error) {
 		t = flux.TString
 	case timeDatatype:
 		t = flux.TTime
+	case bytesDatatype:
+		t = flux.TBytes
 	default:
 		err = fmt.Errorf("unsupported data type %q", typ)
 	}
@@ -1346,6 +1772,9 @@ func NewMultiResultEncoder(c ResultEncoderConfig) flux.MultiResultEncoder {
 type bufferedCSVReader struct {
 	r    *csv.Reader
 	line []string
+
+	// trimSpace removes the white space around each cell.
+	trimSpace bool
 }
 
 // Read returns the next line in the csv stream
@@ -1355,7 +1784,14 @@ func (b *bufferedCSVReader) Read() ([]string, error) {
 		b.line = nil
 		return line, nil
 	}
-	return b.r.Read()
+	line, err := b.r.Read()
+	if err != nil || !b.trimSpace {
+		return line, err
+	}
+	for i := range line {
+		line[i] = strings.TrimSpace(line[i])
+	}
+	return line, nil
 }
 
 // Unread places the provided line back on the buffer.
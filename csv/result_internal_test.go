@@ -1,7 +1,81 @@
 package csv
 
-import "sync/atomic"
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"sync/atomic"
+	"testing"
+)
 
 func (d *tableDecoder) IsDone() bool {
 	return d.empty || atomic.LoadInt32(&d.used) != 0
 }
+
+func TestSkipBOM(t *testing.T) {
+	testCases := []struct {
+		name string
+		in   io.Reader
+		want []byte
+		err  error
+	}{
+		{
+			name: "no BOM",
+			in:   bytes.NewReader([]byte("hello world")),
+			want: []byte("hello world"),
+		},
+		{
+			name: "has BOM",
+			in:   bytes.NewReader([]byte{0xEF, 0xBB, 0xBF, 104, 101, 108, 108, 111}),
+			want: []byte("hello"),
+		},
+		{
+			name: "BOM only once",
+			in:   bytes.NewReader([]byte{0xEF, 0xBB, 0xBF, 0xEF, 0xBB, 0xBF}),
+			want: []byte{0xEF, 0xBB, 0xBF},
+		},
+		{
+			name: "empty",
+			in:   bytes.NewReader([]byte{}),
+			want: []byte{},
+		},
+		{
+			name: "short",
+			in:   bytes.NewReader([]byte{1, 2}),
+			want: []byte{1, 2},
+		},
+		{
+			name: "error",
+			in:   errReader{err: errors.New("test error")},
+			want: []byte{},
+			err:  errors.New("test error"),
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ioutil.ReadAll(skipBOM(tc.in))
+			if !bytes.Equal(tc.want, got) {
+				t.Errorf("unexpected bytes -want/+got:\n\t- %v\n\t+ %v", tc.want, got)
+			}
+			if tc.err != nil {
+				if err == nil {
+					t.Errorf("expected error: %v", tc.err)
+				} else if got, want := err.Error(), tc.err.Error(); got != want {
+					t.Errorf("unexpected error -want/+got:\n\t- %q\n\t+ %q", want, got)
+				}
+			} else if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+type errReader struct {
+	err error
+}
+
+func (e errReader) Read(_ []byte) (int, error) {
+	return 0, e.err
+}
@@ -3,8 +3,10 @@ package csv_test
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"io/ioutil"
+	"math"
 	"regexp"
 	"strings"
 	"testing"
@@ -15,8 +17,10 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/influxdata/flux"
 	"github.com/influxdata/flux/csv"
+	"github.com/influxdata/flux/execute"
 	"github.com/influxdata/flux/execute/executetest"
 	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/semantic"
 	"github.com/influxdata/flux/values"
 )
 
@@ -316,6 +320,46 @@ func TestResultDecoder(t *testing.T) {
 				}},
 			},
 		},
+		{
+			name:          "single table with bytes",
+			encoderConfig: csv.DefaultEncoderConfig(),
+			encoded: toCRLF(`#datatype,string,long,dateTime:RFC3339,string,base64Binary
+#group,false,false,false,true,false
+#default,_result,,,,
+,result,table,_time,host,payload
+,,0,2018-04-17T00:00:00Z,A,3q2+7w==
+,,0,2018-04-17T00:00:01Z,A,Zmx1eA==
+,,0,2018-04-17T00:00:02Z,A,
+`),
+			result: &executetest.Result{
+				Nm: "_result",
+				Tbls: []*executetest.Table{{
+					KeyCols: []string{"host"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "host", Type: flux.TString},
+						{Label: "payload", Type: flux.TBytes},
+					},
+					Data: [][]interface{}{
+						{
+							values.ConvertTime(time.Date(2018, 4, 17, 0, 0, 0, 0, time.UTC)),
+							"A",
+							[]byte{0xde, 0xad, 0xbe, 0xef},
+						},
+						{
+							values.ConvertTime(time.Date(2018, 4, 17, 0, 0, 1, 0, time.UTC)),
+							"A",
+							[]byte("flux"),
+						},
+						{
+							values.ConvertTime(time.Date(2018, 4, 17, 0, 0, 2, 0, time.UTC)),
+							"A",
+							nil,
+						},
+					},
+				}},
+			},
+		},
 		{
 			name:          "single table with null in group key column",
 			encoderConfig: csv.DefaultEncoderConfig(),
@@ -1495,6 +1539,114 @@ data1,data2,data3
 				}},
 			},
 		},
+		{
+			name:          "byte order mark",
+			encoderConfig: csv.DefaultEncoderConfig(),
+			encoded: toCRLF("\ufeff" + `#datatype,string,long,string
+#group,false,false,true
+#default,_result,,
+,result,table,host
+,,0,A
+`),
+			result: &executetest.Result{
+				Nm: "_result",
+				Tbls: []*executetest.Table{{
+					KeyCols: []string{"host"},
+					ColMeta: []flux.ColMeta{
+						{Label: "host", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{"A"},
+					},
+				}},
+			},
+		},
+		{
+			name: "trim space and null values",
+			decoderConfig: csv.ResultDecoderConfig{
+				TrimSpace:  true,
+				NullValues: []string{"", "NULL", "N/A"},
+			},
+			encoderConfig: csv.DefaultEncoderConfig(),
+			encoded: toCRLF("\ufeff" + `#datatype, string, long, string, double, long
+#group, false, false, true, false, false
+#default, _result, , , ,
+, result, table, host , _value , count
+, , 0,  A , 1.5 , N/A
+, , 0,  A , N/A , 2
+, , 0,  A ,  NULL , 3
+`),
+			result: &executetest.Result{
+				Nm: "_result",
+				Tbls: []*executetest.Table{{
+					KeyCols: []string{"host"},
+					ColMeta: []flux.ColMeta{
+						{Label: "host", Type: flux.TString},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "count", Type: flux.TInt},
+					},
+					Data: [][]interface{}{
+						{"A", 1.5, nil},
+						{"A", nil, int64(2)},
+						{"A", nil, int64(3)},
+					},
+				}},
+			},
+		},
+		{
+			name: "null values in the group key",
+			decoderConfig: csv.ResultDecoderConfig{
+				NullValues: []string{"N/A"},
+			},
+			encoderConfig: csv.DefaultEncoderConfig(),
+			encoded: toCRLF(`#datatype,string,long,string,double
+#group,false,false,true,false
+#default,_result,,A,
+,result,table,host,_value
+,,0,N/A,1.5
+`),
+			result: &executetest.Result{
+				Nm: "_result",
+				Tbls: []*executetest.Table{{
+					KeyCols:   []string{"host"},
+					KeyValues: []interface{}{nil},
+					ColMeta: []flux.ColMeta{
+						{Label: "host", Type: flux.TString},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{nil, 1.5},
+					},
+				}},
+			},
+		},
+		{
+			name: "raw trim space and null values",
+			decoderConfig: csv.ResultDecoderConfig{
+				NoAnnotations: true,
+				TrimSpace:     true,
+				NullValues:    []string{"N/A"},
+			},
+			encoderConfig: csv.DefaultEncoderConfig(),
+			encoded: toCRLF("\ufeff" + ` name , value , note
+ a , 1 , N/A
+ b ,  , x 
+`),
+			result: &executetest.Result{
+				Nm: "_result",
+				Tbls: []*executetest.Table{{
+					ColMeta: []flux.ColMeta{
+						{Label: "name", Type: flux.TString},
+						{Label: "value", Type: flux.TString},
+						{Label: "note", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{"a", "1", nil},
+						{"b", nil, "x"},
+					},
+				}},
+			},
+		},
 		{
 			name:          "error on short annotation datatype",
 			encoderConfig: csv.DefaultEncoderConfig(),
@@ -1685,6 +1837,398 @@ func TestResultEncoder(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:          "single table with bytes",
+			encoderConfig: csv.DefaultEncoderConfig(),
+			encoded: toCRLF(`#datatype,string,long,dateTime:RFC3339,string,base64Binary
+#group,false,false,false,true,false
+#default,_result,,,,
+,result,table,_time,host,payload
+,,0,2018-04-17T00:00:00Z,A,3q2+7w==
+,,0,2018-04-17T00:00:01Z,A,Zmx1eA==
+,,0,2018-04-17T00:00:02Z,A,
+`),
+			result: &executetest.Result{
+				Nm: "_result",
+				Tbls: []*executetest.Table{{
+					KeyCols: []string{"host"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "host", Type: flux.TString},
+						{Label: "payload", Type: flux.TBytes},
+					},
+					Data: [][]interface{}{
+						{
+							values.ConvertTime(time.Date(2018, 4, 17, 0, 0, 0, 0, time.UTC)),
+							"A",
+							[]byte{0xde, 0xad, 0xbe, 0xef},
+						},
+						{
+							values.ConvertTime(time.Date(2018, 4, 17, 0, 0, 1, 0, time.UTC)),
+							"A",
+							[]byte("flux"),
+						},
+						{
+							values.ConvertTime(time.Date(2018, 4, 17, 0, 0, 2, 0, time.UTC)),
+							"A",
+							nil,
+						},
+					},
+				}},
+			},
+		},
+		{
+			name: "float decimal places",
+			encoderConfig: csv.ResultEncoderConfig{
+				Annotations:    []string{"datatype"},
+				FloatFormat:    csv.DecimalPlaces,
+				FloatPrecision: 2,
+			},
+			encoded: toCRLF(`#datatype,string,long,dateTime:RFC3339,double
+,result,table,_time,_value
+,_result,0,2018-04-17T00:00:00Z,0.3
+,_result,0,2018-04-17T00:00:01Z,2.68
+,_result,0,2018-04-17T00:00:02Z,2.62
+,_result,0,2018-04-17T00:00:03Z,-1
+,_result,0,2018-04-17T00:00:04Z,12345678.91
+,_result,0,2018-04-17T00:00:05Z,NaN
+,_result,0,2018-04-17T00:00:06Z,+Inf
+,_result,0,2018-04-17T00:00:07Z,-Inf
+,_result,0,2018-04-17T00:00:08Z,
+`),
+			result: &executetest.Result{
+				Nm: "_result",
+				Tbls: []*executetest.Table{{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{values.ConvertTime(time.Date(2018, 4, 17, 0, 0, 0, 0, time.UTC)), 0.30000000000000004},
+						{values.ConvertTime(time.Date(2018, 4, 17, 0, 0, 1, 0, time.UTC)), 2.675},
+						{values.ConvertTime(time.Date(2018, 4, 17, 0, 0, 2, 0, time.UTC)), 2.625},
+						{values.ConvertTime(time.Date(2018, 4, 17, 0, 0, 3, 0, time.UTC)), -0.999},
+						{values.ConvertTime(time.Date(2018, 4, 17, 0, 0, 4, 0, time.UTC)), 12345678.9051},
+						{values.ConvertTime(time.Date(2018, 4, 17, 0, 0, 5, 0, time.UTC)), math.NaN()},
+						{values.ConvertTime(time.Date(2018, 4, 17, 0, 0, 6, 0, time.UTC)), math.Inf(1)},
+						{values.ConvertTime(time.Date(2018, 4, 17, 0, 0, 7, 0, time.UTC)), math.Inf(-1)},
+						{values.ConvertTime(time.Date(2018, 4, 17, 0, 0, 8, 0, time.UTC)), nil},
+					},
+				}},
+			},
+		},
+		{
+			name: "float significant digits",
+			encoderConfig: csv.ResultEncoderConfig{
+				Annotations:    []string{"datatype"},
+				FloatFormat:    csv.SignificantDigits,
+				FloatPrecision: 3,
+			},
+			encoded: toCRLF(`#datatype,string,long,dateTime:RFC3339,double
+,result,table,_time,_value
+,_result,0,2018-04-17T00:00:00Z,0.3
+,_result,0,2018-04-17T00:00:01Z,123000
+,_result,0,2018-04-17T00:00:02Z,0.000124
+,_result,0,2018-04-17T00:00:03Z,NaN
+`),
+			result: &executetest.Result{
+				Nm: "_result",
+				Tbls: []*executetest.Table{{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{values.ConvertTime(time.Date(2018, 4, 17, 0, 0, 0, 0, time.UTC)), 0.30000000000000004},
+						{values.ConvertTime(time.Date(2018, 4, 17, 0, 0, 1, 0, time.UTC)), 123456.0},
+						{values.ConvertTime(time.Date(2018, 4, 17, 0, 0, 2, 0, time.UTC)), 0.0001235},
+						{values.ConvertTime(time.Date(2018, 4, 17, 0, 0, 3, 0, time.UTC)), math.NaN()},
+					},
+				}},
+			},
+		},
+		{
+			name: "float full precision",
+			encoderConfig: csv.ResultEncoderConfig{
+				Annotations:    []string{"datatype"},
+				FloatPrecision: 2,
+			},
+			encoded: toCRLF(`#datatype,string,long,dateTime:RFC3339,double
+,result,table,_time,_value
+,_result,0,2018-04-17T00:00:00Z,0.30000000000000004
+`),
+			result: &executetest.Result{
+				Nm: "_result",
+				Tbls: []*executetest.Table{{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{values.ConvertTime(time.Date(2018, 4, 17, 0, 0, 0, 0, time.UTC)), 0.30000000000000004},
+					},
+				}},
+			},
+		},
+		{
+			name: "non-finite floats as strings",
+			encoderConfig: csv.ResultEncoderConfig{
+				Annotations:     []string{"datatype"},
+				NonFiniteFloats: csv.NonFiniteAsString,
+			},
+			encoded: toCRLF(`#datatype,string,long,dateTime:RFC3339,double
+,result,table,_time,_value
+,_result,0,2018-04-17T00:00:00Z,2.675
+,_result,0,2018-04-17T00:00:01Z,NaN
+,_result,0,2018-04-17T00:00:02Z,+Inf
+,_result,0,2018-04-17T00:00:03Z,-Inf
+,_result,0,2018-04-17T00:00:04Z,
+`),
+			result: &executetest.Result{
+				Nm: "_result",
+				Tbls: []*executetest.Table{{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{values.ConvertTime(time.Date(2018, 4, 17, 0, 0, 0, 0, time.UTC)), 2.675},
+						{values.ConvertTime(time.Date(2018, 4, 17, 0, 0, 1, 0, time.UTC)), math.NaN()},
+						{values.ConvertTime(time.Date(2018, 4, 17, 0, 0, 2, 0, time.UTC)), math.Inf(1)},
+						{values.ConvertTime(time.Date(2018, 4, 17, 0, 0, 3, 0, time.UTC)), math.Inf(-1)},
+						{values.ConvertTime(time.Date(2018, 4, 17, 0, 0, 4, 0, time.UTC)), nil},
+					},
+				}},
+			},
+		},
+		{
+			name: "non-finite floats as null",
+			encoderConfig: csv.ResultEncoderConfig{
+				Annotations:     []string{"datatype"},
+				NonFiniteFloats: csv.NonFiniteAsNull,
+			},
+			encoded: toCRLF(`#datatype,string,long,dateTime:RFC3339,double
+,result,table,_time,_value
+,_result,0,2018-04-17T00:00:00Z,2.675
+,_result,0,2018-04-17T00:00:01Z,
+,_result,0,2018-04-17T00:00:02Z,
+,_result,0,2018-04-17T00:00:03Z,
+,_result,0,2018-04-17T00:00:04Z,
+`),
+			result: &executetest.Result{
+				Nm: "_result",
+				Tbls: []*executetest.Table{{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{values.ConvertTime(time.Date(2018, 4, 17, 0, 0, 0, 0, time.UTC)), 2.675},
+						{values.ConvertTime(time.Date(2018, 4, 17, 0, 0, 1, 0, time.UTC)), math.NaN()},
+						{values.ConvertTime(time.Date(2018, 4, 17, 0, 0, 2, 0, time.UTC)), math.Inf(1)},
+						{values.ConvertTime(time.Date(2018, 4, 17, 0, 0, 3, 0, time.UTC)), math.Inf(-1)},
+						{values.ConvertTime(time.Date(2018, 4, 17, 0, 0, 4, 0, time.UTC)), nil},
+					},
+				}},
+			},
+		},
+		{
+			name: "non-finite floats as null with decimal places",
+			encoderConfig: csv.ResultEncoderConfig{
+				Annotations:     []string{"datatype"},
+				FloatFormat:     csv.DecimalPlaces,
+				FloatPrecision:  1,
+				NonFiniteFloats: csv.NonFiniteAsNull,
+			},
+			encoded: toCRLF(`#datatype,string,long,dateTime:RFC3339,double
+,result,table,_time,_value
+,_result,0,2018-04-17T00:00:00Z,2.7
+,_result,0,2018-04-17T00:00:01Z,
+,_result,0,2018-04-17T00:00:02Z,
+,_result,0,2018-04-17T00:00:03Z,
+,_result,0,2018-04-17T00:00:04Z,
+`),
+			result: &executetest.Result{
+				Nm: "_result",
+				Tbls: []*executetest.Table{{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{values.ConvertTime(time.Date(2018, 4, 17, 0, 0, 0, 0, time.UTC)), 2.675},
+						{values.ConvertTime(time.Date(2018, 4, 17, 0, 0, 1, 0, time.UTC)), math.NaN()},
+						{values.ConvertTime(time.Date(2018, 4, 17, 0, 0, 2, 0, time.UTC)), math.Inf(1)},
+						{values.ConvertTime(time.Date(2018, 4, 17, 0, 0, 3, 0, time.UTC)), math.Inf(-1)},
+						{values.ConvertTime(time.Date(2018, 4, 17, 0, 0, 4, 0, time.UTC)), nil},
+					},
+				}},
+			},
+		},
+		{
+			name: "non-finite floats as error",
+			encoderConfig: csv.ResultEncoderConfig{
+				Annotations:     []string{"datatype"},
+				NonFiniteFloats: csv.NonFiniteAsError,
+			},
+			result: &executetest.Result{
+				Nm: "_result",
+				Tbls: []*executetest.Table{{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{values.ConvertTime(time.Date(2018, 4, 17, 0, 0, 0, 0, time.UTC)), 2.675},
+						{values.ConvertTime(time.Date(2018, 4, 17, 0, 0, 1, 0, time.UTC)), math.NaN()},
+						{values.ConvertTime(time.Date(2018, 4, 17, 0, 0, 2, 0, time.UTC)), math.Inf(1)},
+						{values.ConvertTime(time.Date(2018, 4, 17, 0, 0, 3, 0, time.UTC)), math.Inf(-1)},
+						{values.ConvertTime(time.Date(2018, 4, 17, 0, 0, 4, 0, time.UTC)), nil},
+					},
+				}},
+			},
+			err: errors.New(`csv encoder error: cannot encode NaN in float column "_value"`),
+		},
+		{
+			name: "finite floats with non-finite floats as error",
+			encoderConfig: csv.ResultEncoderConfig{
+				Annotations:     []string{"datatype"},
+				NonFiniteFloats: csv.NonFiniteAsError,
+			},
+			encoded: toCRLF(`#datatype,string,long,dateTime:RFC3339,double
+,result,table,_time,_value
+,_result,0,2018-04-17T00:00:00Z,2.675
+,_result,0,2018-04-17T00:00:01Z,
+`),
+			result: &executetest.Result{
+				Nm: "_result",
+				Tbls: []*executetest.Table{{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{values.ConvertTime(time.Date(2018, 4, 17, 0, 0, 0, 0, time.UTC)), 2.675},
+						{values.ConvertTime(time.Date(2018, 4, 17, 0, 0, 1, 0, time.UTC)), nil},
+					},
+				}},
+			},
+		},
+		{
+			name: "non-finite group key as null",
+			encoderConfig: csv.ResultEncoderConfig{
+				Annotations:     []string{"datatype", "group", "default"},
+				NonFiniteFloats: csv.NonFiniteAsNull,
+			},
+			encoded: toCRLF(`#datatype,string,long,double,long
+#group,false,false,true,false
+#default,_result,,,
+,result,table,k,_value
+,,0,,1
+`),
+			result: &executetest.Result{
+				Nm: "_result",
+				Tbls: []*executetest.Table{{
+					KeyCols: []string{"k"},
+					ColMeta: []flux.ColMeta{
+						{Label: "k", Type: flux.TFloat},
+						{Label: "_value", Type: flux.TInt},
+					},
+					Data: [][]interface{}{
+						{math.Inf(1), int64(1)},
+					},
+				}},
+			},
+		},
+		{
+			name: "non-finite group key as error",
+			encoderConfig: csv.ResultEncoderConfig{
+				Annotations:     []string{"datatype", "group", "default"},
+				NonFiniteFloats: csv.NonFiniteAsError,
+			},
+			result: &executetest.Result{
+				Nm: "_result",
+				Tbls: []*executetest.Table{{
+					KeyCols: []string{"k"},
+					ColMeta: []flux.ColMeta{
+						{Label: "k", Type: flux.TFloat},
+						{Label: "_value", Type: flux.TInt},
+					},
+					KeyValues: []interface{}{math.Inf(-1)},
+				}},
+			},
+			err: errors.New(`csv encoder error: cannot encode -Inf in float column "k"`),
+		},
+		{
+			name: "composite group key as JSON",
+			encoderConfig: csv.ResultEncoderConfig{
+				Annotations: []string{"datatype", "group"},
+				JSONCells:   true,
+			},
+			encoded: toCRLF(`#datatype,string,long,string,double,string
+#group,false,false,true,false,true
+,result,table,host,_value,meta
+,_result,0,A,1,"{""region"":""west"",""tags"":[""a"",""b""]}"
+,_result,0,A,2,"{""region"":""west"",""tags"":[""a"",""b""]}"
+`),
+			result: &executetest.Result{
+				Nm: "_result",
+				Tbls: []*executetest.Table{{
+					GroupKey: execute.NewGroupKey(
+						[]flux.ColMeta{
+							{Label: "host", Type: flux.TString},
+							{Label: "meta", Type: flux.TInvalid},
+						},
+						[]values.Value{
+							values.NewString("A"),
+							values.NewObjectWithValues(map[string]values.Value{
+								"tags": values.NewArrayWithBacking(
+									semantic.NewArrayType(semantic.BasicString),
+									[]values.Value{values.NewString("a"), values.NewString("b")},
+								),
+								"region": values.NewString("west"),
+							}),
+						},
+					),
+					ColMeta: []flux.ColMeta{
+						{Label: "host", Type: flux.TString},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{"A", 1.0},
+						{"A", 2.0},
+					},
+				}},
+			},
+		},
+		{
+			name: "composite group key without JSON cells",
+			encoderConfig: csv.ResultEncoderConfig{
+				Annotations: []string{"datatype", "group"},
+			},
+			result: &executetest.Result{
+				Nm: "_result",
+				Tbls: []*executetest.Table{{
+					GroupKey: execute.NewGroupKey(
+						[]flux.ColMeta{
+							{Label: "meta", Type: flux.TInvalid},
+						},
+						[]values.Value{
+							values.NewObjectWithValues(map[string]values.Value{
+								"region": values.NewString("west"),
+							}),
+						},
+					),
+					ColMeta: []flux.ColMeta{
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{1.0},
+					},
+				}},
+			},
+			err: errors.New(`cannot encode group key column "meta": composite values are only supported as JSON cells`),
+		},
 		{
 			name: "table error",
 			result: &executetest.Result{
@@ -1720,6 +2264,99 @@ func TestResultEncoder(t *testing.T) {
 	}
 }
 
+func TestResultEncoderConfig_JSON(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		config  csv.ResultEncoderConfig
+		encoded string
+	}{
+		{
+			name:    "full precision",
+			config:  csv.ResultEncoderConfig{Delimiter: ','},
+			encoded: `{"header":true,"delimiter":","}`,
+		},
+		{
+			name: "decimal places",
+			config: csv.ResultEncoderConfig{
+				Delimiter:      ',',
+				FloatFormat:    csv.DecimalPlaces,
+				FloatPrecision: 3,
+			},
+			encoded: `{"header":true,"delimiter":",","floatFormat":"decimal","floatPrecision":3}`,
+		},
+		{
+			name: "significant digits",
+			config: csv.ResultEncoderConfig{
+				Delimiter:      ',',
+				FloatFormat:    csv.SignificantDigits,
+				FloatPrecision: 6,
+			},
+			encoded: `{"header":true,"delimiter":",","floatFormat":"significant","floatPrecision":6}`,
+		},
+		{
+			name: "non-finite floats as null",
+			config: csv.ResultEncoderConfig{
+				Delimiter:       ',',
+				NonFiniteFloats: csv.NonFiniteAsNull,
+			},
+			encoded: `{"header":true,"delimiter":",","nonFiniteFloats":"null"}`,
+		},
+		{
+			name: "JSON cells",
+			config: csv.ResultEncoderConfig{
+				Delimiter: ',',
+				JSONCells: true,
+			},
+			encoded: `{"header":true,"delimiter":",","jsonCells":true}`,
+		},
+		{
+			name: "non-finite floats as error",
+			config: csv.ResultEncoderConfig{
+				Delimiter:       ',',
+				FloatFormat:     csv.DecimalPlaces,
+				FloatPrecision:  2,
+				NonFiniteFloats: csv.NonFiniteAsError,
+			},
+			encoded: `{"header":true,"delimiter":",","floatFormat":"decimal","floatPrecision":2,"nonFiniteFloats":"error"}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := json.Marshal(tc.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := string(b), tc.encoded; got != want {
+				t.Errorf("unexpected encoding -want/+got:\n\t- %s\n\t+ %s", want, got)
+			}
+
+			var got csv.ResultEncoderConfig
+			if err := json.Unmarshal(b, &got); err != nil {
+				t.Fatal(err)
+			}
+			if !cmp.Equal(tc.config, got) {
+				t.Errorf("unexpected config -want/+got:\n%s", cmp.Diff(tc.config, got))
+			}
+		})
+	}
+
+	var c csv.ResultEncoderConfig
+	err := json.Unmarshal([]byte(`{"floatFormat":"scientific"}`), &c)
+	if err == nil {
+		t.Fatal("expected error, got none")
+	}
+	if got, want := err.Error(), `invalid float format "scientific", must be "full", "decimal" or "significant"`; got != want {
+		t.Errorf("unexpected error -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+
+	err = json.Unmarshal([]byte(`{"nonFiniteFloats":"zero"}`), &c)
+	if err == nil {
+		t.Fatal("expected error, got none")
+	}
+	if got, want := err.Error(), `invalid non-finite float format "zero", must be "string", "null" or "error"`; got != want {
+		t.Errorf("unexpected error -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+}
+
 func TestMultiResultEncoder(t *testing.T) {
 	testCases := []struct {
 		name    string
@@ -2532,6 +3169,227 @@ func TestMultiResultDecoder(t *testing.T) {
 	}
 }
 
+func TestResultDecoder_ErrorTables(t *testing.T) {
+	encoded := toCRLF(`#datatype,string,long,dateTime:RFC3339,string,double
+#group,false,false,false,true,false
+#default,_result,,,,
+,result,table,_time,host,_value
+,,0,2018-04-17T00:00:00Z,A,1.0
+,,0,2018-04-17T00:00:01Z,A,2.0
+,,1,2018-04-17T00:00:00Z,B,3.0
+
+#datatype,string,string
+#group,true,true
+#default,,
+,error,reference
+,here is an error,
+`)
+
+	for _, tc := range []struct {
+		name        string
+		errorTables bool
+		// tables is the number of tables that are read without an error.
+		tables int
+		// tableErr is the index of the table that returns the error,
+		// or -1 if the iteration of the tables returns it.
+		tableErr int
+	}{
+		{
+			name:        "error table",
+			errorTables: true,
+			tables:      2,
+			tableErr:    2,
+		},
+		{
+			name:     "fail on error",
+			tables:   2,
+			tableErr: -1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			decoder := csv.NewResultDecoder(csv.ResultDecoderConfig{
+				ErrorTables: tc.errorTables,
+			})
+			result, err := decoder.Decode(bytes.NewReader(encoded))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var (
+				n        int
+				tables   int
+				tableErr = -1
+			)
+			err = result.Tables().Do(func(tbl flux.Table) error {
+				defer func() { n++ }()
+				if err := tbl.Do(func(flux.ColReader) error { return nil }); err != nil {
+					if got, want := err.Error(), "here is an error"; got != want {
+						t.Errorf("unexpected table error -want/+got:\n\t- %q\n\t+ %q", want, got)
+					}
+					tableErr = n
+					return nil
+				}
+				tables++
+				return nil
+			})
+			if tc.tableErr < 0 {
+				if err == nil {
+					t.Fatal("expected an error, got none")
+				} else if got, want := err.Error(), "here is an error"; got != want {
+					t.Errorf("unexpected error -want/+got:\n\t- %q\n\t+ %q", want, got)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if tables != tc.tables {
+				t.Errorf("unexpected number of tables -want/+got:\n\t- %d\n\t+ %d", tc.tables, tables)
+			}
+			if tableErr != tc.tableErr {
+				t.Errorf("unexpected position of the error -want/+got:\n\t- %d\n\t+ %d", tc.tableErr, tableErr)
+			}
+		})
+	}
+}
+
+func TestMultiResultDecoder_ErrorTables(t *testing.T) {
+	encoded := toCRLF(`#datatype,string,string
+#group,true,true
+#default,,
+,error,reference
+,test error,
+`)
+	decoder := csv.NewMultiResultDecoder(csv.ResultDecoderConfig{
+		ErrorTables: true,
+	})
+	results, err := decoder.Decode(ioutil.NopCloser(bytes.NewReader(encoded)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer results.Release()
+
+	if !results.More() {
+		t.Fatalf("expected a result, got error: %v", results.Err())
+	}
+	var tableErrs []string
+	if err := results.Next().Tables().Do(func(tbl flux.Table) error {
+		if err := tbl.Do(func(flux.ColReader) error { return nil }); err != nil {
+			tableErrs = append(tableErrs, err.Error())
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"test error"}; !cmp.Equal(want, tableErrs) {
+		t.Errorf("unexpected table errors -want/+got:\n%s", cmp.Diff(want, tableErrs))
+	}
+	if results.More() {
+		t.Error("expected no more results")
+	}
+	if err := results.Err(); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+// metadataResult is a result that has metadata.
+type metadataResult struct {
+	*executetest.Result
+	metadata map[string]string
+}
+
+func (r metadataResult) Metadata() map[string]string {
+	return r.metadata
+}
+
+func TestResultEncoder_Metadata(t *testing.T) {
+	newResult := func() *executetest.Result {
+		return &executetest.Result{
+			Nm: "_result",
+			Tbls: []*executetest.Table{{
+				KeyCols: []string{"host"},
+				ColMeta: []flux.ColMeta{
+					{Label: "host", Type: flux.TString},
+					{Label: "_value", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{"A", 1.0},
+					{"A", 2.0},
+				},
+			}},
+		}
+	}
+	metadata := map[string]string{
+		"source": "sampledata",
+		"unit":   "count, \"items\"",
+	}
+
+	for _, tc := range []struct {
+		name    string
+		config  csv.ResultEncoderConfig
+		encoded string
+	}{
+		{
+			name:   "annotations",
+			config: csv.DefaultEncoderConfig(),
+			encoded: `#metadata,"{""source"":""sampledata"",""unit"":""count, \""items\""""}",,,
+#datatype,string,long,string,double
+#group,false,false,true,false
+#default,_result,,,
+,result,table,host,_value
+,,0,A,1
+,,0,A,2
+`,
+		},
+		{
+			name:   "no annotations",
+			config: csv.ResultEncoderConfig{},
+			encoded: `,result,table,host,_value
+,_result,0,A,1
+,_result,0,A,2
+`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			encoder := csv.NewResultEncoder(tc.config)
+			if _, err := encoder.Encode(&buf, metadataResult{Result: newResult(), metadata: metadata}); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := buf.String(), string(toCRLF(tc.encoded)); got != want {
+				t.Fatalf("unexpected encoding -want/+got:\n%s", diff.LineDiff(want, got))
+			}
+		})
+	}
+
+	t.Run("decode", func(t *testing.T) {
+		var buf bytes.Buffer
+		encoder := csv.NewResultEncoder(csv.DefaultEncoderConfig())
+		if _, err := encoder.Encode(&buf, metadataResult{Result: newResult(), metadata: metadata}); err != nil {
+			t.Fatal(err)
+		}
+
+		decoder := csv.NewResultDecoder(csv.ResultDecoderConfig{})
+		result, err := decoder.Decode(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mr, ok := result.(flux.MetadataResult)
+		if !ok {
+			t.Fatalf("expected a flux.MetadataResult, got %T", result)
+		}
+		if got, want := mr.Metadata(), metadata; !cmp.Equal(want, got) {
+			t.Errorf("unexpected metadata -want/+got:\n%s", cmp.Diff(want, got))
+		}
+
+		got := executetest.ConvertResult(result)
+		want := newResult()
+		got.Normalize()
+		want.Normalize()
+		if !cmp.Equal(want, got) {
+			t.Errorf("unexpected result -want/+got:\n%s", cmp.Diff(want, got))
+		}
+	})
+}
+
 func TestTable(t *testing.T) {
 	executetest.RunTableTests(t, executetest.TableTest{
 		NewFn: func(ctx context.Context, alloc memory.Allocator) flux.TableIterator {
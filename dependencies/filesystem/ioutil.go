@@ -4,6 +4,9 @@ import (
 	"context"
 	"io/ioutil"
 	"os"
+
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/internal/errors"
 )
 
 // ReadFile will open the file from the service and read
@@ -43,3 +46,50 @@ func Stat(ctx context.Context, filename string) (os.FileInfo, error) {
 	defer func() { _ = f.Close() }()
 	return f.Stat()
 }
+
+// CreateTemp will create a new temporary file with the service.
+// The pattern is used to generate the name of the file in the same
+// way as os.CreateTemp. The caller must remove the file with Remove
+// once it is no longer needed.
+func CreateTemp(ctx context.Context, pattern string) (TempFile, error) {
+	fs, err := Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tfs, ok := fs.(TempService)
+	if !ok {
+		return nil, errors.New(codes.Unimplemented, "filesystem service does not support temporary files")
+	}
+	return tfs.CreateTemp(pattern)
+}
+
+// Create will create a file for writing with the service.
+// An existing file is truncated when overwrite is set and
+// is an error otherwise.
+func Create(ctx context.Context, filename string, overwrite bool) (WritableFile, error) {
+	fs, err := Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	wfs, ok := fs.(WriteService)
+	if !ok {
+		return nil, errors.New(codes.Unimplemented, "filesystem service does not support writing files")
+	}
+	return wfs.Create(filename, overwrite)
+}
+
+// Remove will remove a file created with CreateTemp or Create.
+func Remove(ctx context.Context, filename string) error {
+	fs, err := Get(ctx)
+	if err != nil {
+		return err
+	}
+	switch fs := fs.(type) {
+	case TempService:
+		return fs.Remove(filename)
+	case WriteService:
+		return fs.Remove(filename)
+	default:
+		return errors.New(codes.Unimplemented, "filesystem service does not support removing files")
+	}
+}
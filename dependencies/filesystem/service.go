@@ -20,6 +20,39 @@ type Service interface {
 	Open(fpath string) (File, error)
 }
 
+// TempFile is a temporary file that can be written
+// and then read back from the beginning.
+type TempFile interface {
+	io.ReadWriteSeeker
+	io.Closer
+	Name() string
+}
+
+// TempService is implemented by a Service that
+// can create and remove temporary files.
+type TempService interface {
+	CreateTemp(pattern string) (TempFile, error)
+	Remove(fpath string) error
+}
+
+// WritableFile is a file that is being written.
+type WritableFile interface {
+	io.WriteCloser
+	Name() string
+	// Sync commits the contents of the file to stable storage.
+	Sync() error
+}
+
+// WriteService is implemented by a Service that can create files.
+// A host that does not allow queries to write files should provide
+// a Service that does not implement it.
+type WriteService interface {
+	// Create creates the file for writing. An existing file is
+	// truncated when overwrite is set and is an error otherwise.
+	Create(fpath string, overwrite bool) (WritableFile, error)
+	Remove(fpath string) error
+}
+
 type key int
 
 const serviceKey key = iota
@@ -17,3 +17,27 @@ func (systemFS) Open(fpath string) (File, error) {
 	}
 	return f, nil
 }
+
+func (systemFS) CreateTemp(pattern string) (TempFile, error) {
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (systemFS) Create(fpath string, overwrite bool) (WritableFile, error) {
+	flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !overwrite {
+		flag |= os.O_EXCL
+	}
+	f, err := os.OpenFile(fpath, flag, 0666)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (systemFS) Remove(fpath string) error {
+	return os.Remove(fpath)
+}
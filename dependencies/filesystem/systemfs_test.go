@@ -95,3 +95,82 @@ func TestSystemFS_ReadFile(t *testing.T) {
 		t.Fatalf("unexpected file contents -want/+got:\n\t- %q\n\t+ %q", want, got)
 	}
 }
+
+func TestSystemFS_CreateTemp(t *testing.T) {
+	ctx := filesystem.Inject(context.Background(), filesystem.SystemFS)
+	f, err := filesystem.CreateTemp(ctx, "flux-systemfs-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	if _, err := io.WriteString(f, "Hello, World!"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := string(data), "Hello, World!"; got != want {
+		t.Fatalf("unexpected file contents -want/+got:\n\t- %q\n\t+ %q", want, got)
+	}
+
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := filesystem.Remove(ctx, f.Name()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(f.Name()); !os.IsNotExist(err) {
+		t.Fatalf("expected the temporary file to be removed, got %v", err)
+	}
+}
+
+func TestSystemFS_Create(t *testing.T) {
+	ctx := filesystem.Inject(context.Background(), filesystem.SystemFS)
+	fpath := filepath.Join(t.TempDir(), "flux-systemfs-test")
+
+	write := func(overwrite bool, s string) error {
+		f, err := filesystem.Create(ctx, fpath, overwrite)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		if _, err := io.WriteString(f, s); err != nil {
+			return err
+		}
+		if err := f.Sync(); err != nil {
+			return err
+		}
+		return f.Close()
+	}
+	if err := write(false, "Hello, World!"); err != nil {
+		t.Fatal(err)
+	}
+	if err := write(false, "Goodbye!"); err == nil {
+		t.Fatal("expected an error for an existing file, got none")
+	}
+	if err := write(true, "Goodbye!"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := filesystem.ReadFile(ctx, fpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data), "Goodbye!"; got != want {
+		t.Fatalf("unexpected file contents -want/+got:\n\t- %q\n\t+ %q", want, got)
+	}
+
+	if err := filesystem.Remove(ctx, fpath); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(fpath); !os.IsNotExist(err) {
+		t.Fatalf("expected the file to be removed, got %v", err)
+	}
+}
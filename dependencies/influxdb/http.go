@@ -20,6 +20,7 @@ import (
 	"github.com/influxdata/flux/codes"
 	"github.com/influxdata/flux/csv"
 	"github.com/influxdata/flux/dependencies/http"
+	"github.com/influxdata/flux/execute"
 	"github.com/influxdata/flux/internal/errors"
 	"github.com/influxdata/flux/semantic"
 	"github.com/influxdata/flux/values"
@@ -36,7 +37,10 @@ type HttpProvider struct {
 	DefaultConfig Config
 }
 
-var _ Provider = HttpProvider{}
+var (
+	_ Provider           = HttpProvider{}
+	_ ProjectionProvider = HttpProvider{}
+)
 
 func (h HttpProvider) ReaderFor(ctx context.Context, conf Config, bounds flux.Bounds, predicateSet PredicateSet) (Reader, error) {
 	c, err := h.clientFor(ctx, conf)
@@ -50,6 +54,22 @@ func (h HttpProvider) ReaderFor(ctx context.Context, conf Config, bounds flux.Bo
 	}, nil
 }
 
+// ProjectedReaderFor implements ProjectionProvider.
+// The query that is sent drops the columns that are not in the
+// group key of a series and are not in keepCols.
+func (h HttpProvider) ProjectedReaderFor(ctx context.Context, conf Config, bounds flux.Bounds, predicateSet PredicateSet, keepCols []string) (Reader, error) {
+	c, err := h.clientFor(ctx, conf)
+	if err != nil {
+		return nil, err
+	}
+	return filteredHttpReader{
+		HttpClient:   c,
+		Bounds:       bounds,
+		PredicateSet: predicateSet,
+		KeepCols:     keepCols,
+	}, nil
+}
+
 func (h HttpProvider) SeriesCardinalityReaderFor(ctx context.Context, conf Config, bounds flux.Bounds, predicateSet PredicateSet) (Reader, error) {
 	// If any of the predicates use keep empty then they are not
 	// valid for series cardinality reader.
@@ -348,8 +368,16 @@ type filteredHttpReader struct {
 	*HttpClient
 	Bounds       flux.Bounds
 	PredicateSet PredicateSet
+
+	// KeepCols is the list of columns that are used by the query.
+	// A nil list means that all of the columns are used.
+	KeepCols []string
 }
 
+// seriesValueColumns are the columns of the data read from storage
+// that are not in the group key of a series.
+var seriesValueColumns = []string{execute.DefaultTimeColLabel, execute.DefaultValueColLabel}
+
 func (h filteredHttpReader) Read(ctx context.Context, f func(flux.Table) error, mem memory.Allocator) error {
 	imports := make(map[string]*ast.ImportDeclaration)
 	query := &ast.PipeExpression{
@@ -393,6 +421,26 @@ func (h filteredHttpReader) Read(ctx context.Context, f func(flux.Table) error,
 			},
 		}
 	}
+	if cols := h.droppedColumns(); len(cols) > 0 {
+		elements := make([]ast.Expression, len(cols))
+		for i, col := range cols {
+			elements[i] = ast.StringLiteralFromValue(col)
+		}
+		query = &ast.PipeExpression{
+			Argument: query,
+			Call: &ast.CallExpression{
+				Callee: &ast.Identifier{Name: "drop"},
+				Arguments: []ast.Expression{
+					&ast.ObjectExpression{
+						Properties: []*ast.Property{{
+							Key:   &ast.Identifier{Name: "columns"},
+							Value: &ast.ArrayExpression{Elements: elements},
+						}},
+					},
+				},
+			},
+		}
+	}
 
 	file := h.newFile(imports)
 	file.Body = []ast.Statement{
@@ -401,6 +449,22 @@ func (h filteredHttpReader) Read(ctx context.Context, f func(flux.Table) error,
 	return h.Query(ctx, f, &file, h.Bounds.Now, mem)
 }
 
+// droppedColumns returns the columns that can be dropped
+// by the query because they are not used.
+// The columns in the group key are never dropped.
+func (h filteredHttpReader) droppedColumns() []string {
+	if h.KeepCols == nil {
+		return nil
+	}
+	var cols []string
+	for _, col := range seriesValueColumns {
+		if !execute.ContainsStr(h.KeepCols, col) {
+			cols = append(cols, col)
+		}
+	}
+	return cols
+}
+
 type seriesCardinalityHttpReader struct {
 	*HttpClient
 	Bounds       flux.Bounds
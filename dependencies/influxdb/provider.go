@@ -100,6 +100,16 @@ type Provider interface {
 	WriterFor(ctx context.Context, conf Config) (Writer, error)
 }
 
+// ProjectionProvider is implemented by a Provider that can
+// avoid reading the columns that a query does not use.
+type ProjectionProvider interface {
+	// ProjectedReaderFor will construct a Reader like ReaderFor
+	// that only needs to read the columns in keepCols. The columns
+	// in the group key of the series are always read since they
+	// determine how the rows are grouped into tables.
+	ProjectedReaderFor(ctx context.Context, conf Config, bounds flux.Bounds, predicateSet PredicateSet, keepCols []string) (Reader, error)
+}
+
 // Reader reads tables from an influxdb instance.
 type Reader interface {
 	// Read will produce flux.Table values using the memory.Allocator
@@ -458,6 +458,8 @@ func (t *simpleAggregateTransformation2) Compute(key flux.GroupKey, state interf
 	buffer := arrow.TableBuffer{
 		GroupKey: key,
 		Columns:  make([]flux.ColMeta, 0, len(key.Cols())+len(aggregates)),
+		// The key columns are repeated from the group key below.
+		KeyConstant: true,
 	}
 	buffer.Columns = append(buffer.Columns, key.Cols()...)
 	for i, s := range aggregates {
@@ -1,7 +1,12 @@
 package execute
 
 import (
+	"sync/atomic"
+
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/internal/errors"
 	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/plan"
 )
 
 const (
@@ -11,25 +16,94 @@ const (
 	float64Size = 8
 	stringSize  = 16
 	timeSize    = 8
+	bytesSize   = 24
 )
 
+// hasMemoryLimit reports whether the query limits
+// the memory that it may allocate.
+func hasMemoryLimit(es *executionState) bool {
+	if !HaveExecutionDependencies(es.ctx) {
+		return false
+	}
+	return GetExecutionDependencies(es.ctx).ExecutionOptions.MemoryLimit > 0
+}
+
+// operationAllocator names the operation that requested
+// the memory when an allocation exceeds the memory limit.
+type operationAllocator struct {
+	memory.Allocator
+	id plan.NodeID
+}
+
+func (a *operationAllocator) Allocate(size int) []byte {
+	defer a.recover()
+	return a.Allocator.Allocate(size)
+}
+
+func (a *operationAllocator) Reallocate(size int, b []byte) []byte {
+	defer a.recover()
+	return a.Allocator.Reallocate(size, b)
+}
+
+func (a *operationAllocator) Account(size int) error {
+	if err := a.Allocator.Account(size); err != nil {
+		return a.annotate(err)
+	}
+	return nil
+}
+
+// recover annotates the panic of an allocation
+// that exceeded the memory limit and panics again.
+func (a *operationAllocator) recover() {
+	if e := recover(); e != nil {
+		if err, ok := e.(error); ok {
+			panic(a.annotate(err))
+		}
+		panic(e)
+	}
+}
+
+func (a *operationAllocator) annotate(err error) error {
+	if errors.Code(err) != codes.ResourceExhausted {
+		return err
+	}
+	return errors.Wrapf(err, codes.ResourceExhausted, "operation %q exceeded the memory limit", a.id)
+}
+
 // Allocator is used to track memory allocations for directly allocated structs.
 // Normally, you should use arrow builders and the memory.Allocator by itself to
 // create arrays, but the Allocator is used by older builders that were pre-arrow
 // and directly allocated Go slices rather than relying on arrow's builders.
 type Allocator struct {
 	memory.Allocator
+
+	// used counts the bytes held by the slices of the
+	// allocator and the contents of its strings and byte
+	// slices when it is set. The table builders of a cache
+	// share it so the cache does not have to walk them.
+	used *int64
 }
 
 // Free informs the allocator that memory has been freed.
 func (a *Allocator) Free(n, size int) {
 	_ = a.Allocator.Account(-n * size)
+	a.count(-n * size)
 }
 
 func (a *Allocator) account(n, size int) {
 	if err := a.Allocator.Account(n * size); err != nil {
 		panic(err)
 	}
+	a.count(n * size)
+}
+
+// count adds n bytes to the memory that is used.
+// The contents of strings and byte slices are only
+// counted here since the allocator does not hold them.
+func (a *Allocator) count(n int) {
+	if a.used != nil && n != 0 {
+		atomic.AddInt64(a.used, int64(n))
+	}
 }
 
 // Bools makes a slice of bool values.
@@ -220,3 +294,36 @@ func (a *Allocator) GrowTimes(slice []Time, n int) []Time {
 	a.account(diff, timeSize)
 	return s
 }
+
+// ByteSlices makes a slice of byte slices.
+// Only the slice headers are accounted for.
+func (a *Allocator) ByteSlices(l, c int) [][]byte {
+	a.account(c, bytesSize)
+	return make([][]byte, l, c)
+}
+
+// AppendByteSlices appends byte slices to a slice.
+// Only the slice headers are accounted for.
+func (a *Allocator) AppendByteSlices(slice [][]byte, vs ...[]byte) [][]byte {
+	if cap(slice)-len(slice) >= len(vs) {
+		return append(slice, vs...)
+	}
+	s := append(slice, vs...)
+	diff := cap(s) - cap(slice)
+	a.account(diff, bytesSize)
+	return s
+}
+
+func (a *Allocator) GrowByteSlices(slice [][]byte, n int) [][]byte {
+	newCap := len(slice) + n
+	if newCap < cap(slice) {
+		return slice[:newCap]
+	}
+	// grow capacity same way as built-in append
+	newCap = newCap*3/2 + 1
+	s := make([][]byte, len(slice)+n, newCap)
+	copy(s, slice)
+	diff := cap(s) - cap(slice)
+	a.account(diff, bytesSize)
+	return s
+}
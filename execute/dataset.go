@@ -31,6 +31,13 @@ type DatasetContext interface {
 	WithContext(ctx context.Context)
 }
 
+// MemoryUser is implemented by the caches and datasets
+// that can report the memory that they hold.
+type MemoryUser interface {
+	// MemoryUsed returns the number of bytes held.
+	MemoryUsed() int64
+}
+
 // DataCache holds all working data for a transformation.
 type DataCache interface {
 	Table(flux.GroupKey) (flux.Table, error)
@@ -86,7 +93,8 @@ type dataset struct {
 	watermark      Time
 	processingTime Time
 
-	cache DataCache
+	cache    DataCache
+	finished bool
 }
 
 func NewDataset(id DatasetID, accMode AccumulationMode, cache DataCache) *dataset {
@@ -102,6 +110,15 @@ func (d *dataset) WithContext(ctx context.Context) {
 	d.ctx = ctx
 }
 
+// MemoryUsed returns the number of bytes held by the cache
+// of the dataset. It is zero when the cache does not report it.
+func (d *dataset) MemoryUsed() int64 {
+	if mu, ok := d.cache.(MemoryUser); ok {
+		return mu.MemoryUsed()
+	}
+	return 0
+}
+
 func (d *dataset) AddTransformation(t Transformation) {
 	d.ts = append(d.ts, t)
 }
@@ -177,6 +194,13 @@ func (d *dataset) RetractTable(key flux.GroupKey) error {
 }
 
 func (d *dataset) Finish(err error) {
+	// Finish may be called more than once when multiple
+	// upstream errors race. Only the first call is forwarded.
+	if d.finished {
+		return
+	}
+	d.finished = true
+
 	// Only trigger tables if we are not finishing because of an error.
 	if err == nil {
 		err = d.cache.ForEach(func(bk flux.GroupKey) error {
@@ -8,9 +8,11 @@ import (
 	"github.com/influxdata/flux"
 	"github.com/influxdata/flux/array"
 	"github.com/influxdata/flux/arrow"
+	"github.com/influxdata/flux/codes"
 	"github.com/influxdata/flux/execute"
 	"github.com/influxdata/flux/execute/executetest"
 	"github.com/influxdata/flux/execute/table"
+	"github.com/influxdata/flux/internal/errors"
 	"github.com/influxdata/flux/memory"
 	"github.com/influxdata/flux/mock"
 )
@@ -227,3 +229,28 @@ func TestTransportDataset_MultipleDownstream(t *testing.T) {
 		t.Fatalf("unexpected number of messages -want/+got:\n\t- %d\n\t+ %d", want, got)
 	}
 }
+
+func TestDataset_FinishOnce(t *testing.T) {
+	var errs []error
+	tr := &mock.Transformation{
+		FinishFn: func(id execute.DatasetID, err error) {
+			errs = append(errs, err)
+		},
+	}
+
+	cache := execute.NewTableBuilderCache(memory.DefaultAllocator)
+	dataset := execute.NewDataset(executetest.RandomDatasetID(), execute.DiscardingMode, cache)
+	dataset.AddTransformation(tr)
+
+	want := errors.New(codes.Internal, "first error")
+	dataset.Finish(want)
+	dataset.Finish(errors.New(codes.Invalid, "second error"))
+	dataset.Finish(nil)
+
+	if got := len(errs); got != 1 {
+		t.Fatalf("unexpected number of finish calls -want/+got:\n\t- %d\n\t+ %d", 1, got)
+	}
+	if got := errs[0]; got != want {
+		t.Fatalf("unexpected error -want/+got:\n\t- %v\n\t+ %v", want, got)
+	}
+}
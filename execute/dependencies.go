@@ -2,7 +2,9 @@ package execute
 
 import (
 	"context"
+	"hash/fnv"
 	"math"
+	"math/rand"
 	"time"
 
 	"github.com/influxdata/flux/codes"
@@ -10,6 +12,7 @@ import (
 	"github.com/influxdata/flux/interpreter"
 	"github.com/influxdata/flux/memory"
 	"github.com/influxdata/flux/metadata"
+	"github.com/influxdata/flux/plan"
 	"github.com/influxdata/flux/semantic"
 	"github.com/influxdata/flux/values"
 	"go.uber.org/zap"
@@ -17,13 +20,48 @@ import (
 
 type key int
 
-const executionDependenciesKey key = iota
+const (
+	executionDependenciesKey key = iota
+	stateStoreKey
+)
 
 type ExecutionOptions struct {
 	OperatorProfiler   *OperatorProfiler
 	Profilers          []Profiler
 	DefaultMemoryLimit int64
 	ConcurrencyLimit   int
+
+	// SortTables orders the tables within each result by their
+	// group key so the output is the same each time a query runs.
+	// This requires each result to buffer all of its tables
+	// before any of them can be read.
+	SortTables bool
+
+	// SpillThreshold is the number of bytes that a transformation
+	// may buffer in memory for a single group key before it writes
+	// the buffered rows to a temporary file. A value of zero or less
+	// keeps all of the rows in memory.
+	SpillThreshold int64
+
+	// MemoryLimit is the maximum number of bytes that the query
+	// may allocate. When it is set, an error from exceeding the
+	// limit names the operation that requested the memory.
+	MemoryLimit int64
+
+	// Location is the value of the location option of the query.
+	// Transformations that interpret times in a location use it
+	// when a location is not passed to them. The zero value is UTC.
+	Location plan.Location
+
+	// Metrics collects the rows and bytes that each transformation
+	// reads and produces. It is nil when they are not collected.
+	Metrics *TransformationMetrics
+
+	// TableConcurrency is the number of tables that a
+	// ParallelTransformation may process at the same time.
+	// A value less than 2 processes them one at a time, so the
+	// tables are produced in the order they were read.
+	TableConcurrency int
 }
 
 // ExecutionDependencies represents the dependencies that a function call
@@ -41,6 +79,12 @@ type ExecutionDependencies struct {
 	Metadata metadata.Metadata
 
 	ExecutionOptions *ExecutionOptions
+
+	// Seed is the seed for the pseudo-random number generators
+	// used by nondeterministic builtins in this query.
+	// Two executions of the same query with the same seed
+	// produce the same output.
+	Seed int64
 }
 
 func (d ExecutionDependencies) Inject(ctx context.Context) context.Context {
@@ -66,6 +110,16 @@ func (d ExecutionDependencies) ResolveTimeable(t values.Value) (values.Time, err
 	return time, nil
 }
 
+// Random returns a pseudo-random number generator for the dataset
+// with the given id. The generator is derived from the query seed and
+// the dataset id so each dataset draws from its own sequence regardless
+// of the order in which the datasets are executed.
+func (d ExecutionDependencies) Random(id DatasetID) *rand.Rand {
+	h := fnv.New64a()
+	_, _ = h.Write(id[:])
+	return rand.New(rand.NewSource(d.Seed ^ int64(h.Sum64())))
+}
+
 func HaveExecutionDependencies(ctx context.Context) bool {
 	return ctx.Value(executionDependenciesKey) != nil
 }
@@ -74,6 +128,30 @@ func GetExecutionDependencies(ctx context.Context) ExecutionDependencies {
 	return ctx.Value(executionDependenciesKey).(ExecutionDependencies)
 }
 
+// GetRandom returns the pseudo-random number generator for the dataset
+// with the given id using the execution dependencies in the context.
+// If there are no execution dependencies, the generator is seeded
+// with the current time.
+func GetRandom(ctx context.Context, id DatasetID) *rand.Rand {
+	if !HaveExecutionDependencies(ctx) {
+		return rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return GetExecutionDependencies(ctx).Random(id)
+}
+
+// GetLocation returns the location from the execution options
+// in the context. If there are no execution dependencies, UTC is returned.
+func GetLocation(ctx context.Context) plan.Location {
+	if !HaveExecutionDependencies(ctx) {
+		return plan.Location{Name: "UTC"}
+	}
+	opts := GetExecutionDependencies(ctx).ExecutionOptions
+	if opts == nil {
+		return plan.Location{Name: "UTC"}
+	}
+	return opts.Location
+}
+
 // Create some execution dependencies. Any arg may be nil, this will choose
 // some suitable defaults.
 func NewExecutionDependencies(allocator memory.Allocator, now *time.Time, logger *zap.Logger) ExecutionDependencies {
@@ -94,6 +172,7 @@ func NewExecutionDependencies(allocator memory.Allocator, now *time.Time, logger
 			DefaultMemoryLimit: math.MaxInt64,
 			ConcurrencyLimit:   0,
 		},
+		Seed: time.Now().UnixNano(),
 	}
 }
 
@@ -44,6 +44,11 @@ type Table struct {
 	// Alloc is the allocator used to create the column readers.
 	// Memory is not tracked unless this is set.
 	Alloc memory.Allocator
+	// ViolateKey changes the last row of each group key column
+	// so it does not match the group key when calling Do.
+	// It is used to verify that a table whose group key columns
+	// are not constant is caught.
+	ViolateKey bool
 	// IsDone indicates if this table has been used.
 	IsDone bool
 }
@@ -101,13 +106,18 @@ func (t *Table) Do(f func(flux.ColReader) error) error {
 	}
 	t.IsDone = true
 
+	data := t.Data
+	if t.ViolateKey && len(data) > 0 {
+		data = t.violateKey()
+	}
+
 	cols := make([]array.Array, len(t.ColMeta))
 	for j, col := range t.ColMeta {
 		switch col.Type {
 		case flux.TBool:
 			b := arrow.NewBoolBuilder(t.Alloc)
-			for i := range t.Data {
-				if v := t.Data[i][j]; v != nil {
+			for i := range data {
+				if v := data[i][j]; v != nil {
 					b.Append(v.(bool))
 				} else {
 					b.AppendNull()
@@ -117,8 +127,8 @@ func (t *Table) Do(f func(flux.ColReader) error) error {
 			b.Release()
 		case flux.TFloat:
 			b := arrow.NewFloatBuilder(t.Alloc)
-			for i := range t.Data {
-				if v := t.Data[i][j]; v != nil {
+			for i := range data {
+				if v := data[i][j]; v != nil {
 					b.Append(v.(float64))
 				} else {
 					b.AppendNull()
@@ -128,8 +138,8 @@ func (t *Table) Do(f func(flux.ColReader) error) error {
 			b.Release()
 		case flux.TInt:
 			b := arrow.NewIntBuilder(t.Alloc)
-			for i := range t.Data {
-				if v := t.Data[i][j]; v != nil {
+			for i := range data {
+				if v := data[i][j]; v != nil {
 					b.Append(v.(int64))
 				} else {
 					b.AppendNull()
@@ -139,8 +149,8 @@ func (t *Table) Do(f func(flux.ColReader) error) error {
 			b.Release()
 		case flux.TString:
 			b := arrow.NewStringBuilder(t.Alloc)
-			for i := range t.Data {
-				if v := t.Data[i][j]; v != nil {
+			for i := range data {
+				if v := data[i][j]; v != nil {
 					b.Append(v.(string))
 				} else {
 					b.AppendNull()
@@ -150,8 +160,8 @@ func (t *Table) Do(f func(flux.ColReader) error) error {
 			b.Release()
 		case flux.TTime:
 			b := arrow.NewIntBuilder(t.Alloc)
-			for i := range t.Data {
-				if v := t.Data[i][j]; v != nil {
+			for i := range data {
+				if v := data[i][j]; v != nil {
 					b.Append(int64(v.(values.Time)))
 				} else {
 					b.AppendNull()
@@ -161,8 +171,8 @@ func (t *Table) Do(f func(flux.ColReader) error) error {
 			b.Release()
 		case flux.TUInt:
 			b := arrow.NewUintBuilder(t.Alloc)
-			for i := range t.Data {
-				if v := t.Data[i][j]; v != nil {
+			for i := range data {
+				if v := data[i][j]; v != nil {
 					b.Append(v.(uint64))
 				} else {
 					b.AppendNull()
@@ -170,6 +180,17 @@ func (t *Table) Do(f func(flux.ColReader) error) error {
 			}
 			cols[j] = b.NewUintArray()
 			b.Release()
+		case flux.TBytes:
+			b := arrow.NewBytesBuilder(t.Alloc)
+			for i := range data {
+				if v := data[i][j]; v != nil {
+					b.Append(v.([]byte))
+				} else {
+					b.AppendNull()
+				}
+			}
+			cols[j] = b.NewBinaryArray()
+			b.Release()
 		}
 	}
 
@@ -182,6 +203,68 @@ func (t *Table) Do(f func(flux.ColReader) error) error {
 	return f(cr)
 }
 
+// violateKey returns a copy of the data where the last row
+// of each group key column differs from the group key value.
+func (t *Table) violateKey() [][]interface{} {
+	data := make([][]interface{}, len(t.Data))
+	copy(data, t.Data)
+	last := make([]interface{}, len(data[len(data)-1]))
+	copy(last, data[len(data)-1])
+	data[len(data)-1] = last
+
+	key := t.Key()
+	for j, c := range key.Cols() {
+		idx := execute.ColIdx(c.Label, t.ColMeta)
+		if idx < 0 {
+			continue
+		}
+		last[idx] = differentValue(c.Type, key.Value(j))
+	}
+	return data
+}
+
+// differentValue returns a value of the column type that is not v.
+func differentValue(typ flux.ColType, v values.Value) interface{} {
+	if v.IsNull() {
+		switch typ {
+		case flux.TBool:
+			return false
+		case flux.TInt:
+			return int64(0)
+		case flux.TUInt:
+			return uint64(0)
+		case flux.TFloat:
+			return 0.0
+		case flux.TString:
+			return ""
+		case flux.TTime:
+			return values.Time(0)
+		case flux.TBytes:
+			return []byte{}
+		}
+		return nil
+	}
+
+	switch typ {
+	case flux.TBool:
+		return !v.Bool()
+	case flux.TInt:
+		return v.Int() + 1
+	case flux.TUInt:
+		return v.UInt() + 1
+	case flux.TFloat:
+		return v.Float() + 1
+	case flux.TString:
+		return v.Str() + "'"
+	case flux.TTime:
+		return v.Time() + 1
+	case flux.TBytes:
+		return append(append([]byte(nil), v.Bytes()...), 0)
+	default:
+		return nil
+	}
+}
+
 func (t *Table) Done() {
 	t.IsDone = true
 }
@@ -231,6 +314,10 @@ func (cr *ColReader) Times(j int) *array.Int {
 	return cr.cols[j].(*array.Int)
 }
 
+func (cr *ColReader) Bytes(j int) *array.Binary {
+	return cr.cols[j].(*array.Binary)
+}
+
 func (cr *ColReader) Retain() {
 	for _, col := range cr.cols {
 		col.Retain()
@@ -320,6 +407,17 @@ func (t *RowWiseTable) Do(f func(flux.ColReader) error) error {
 			}
 			cols[j] = b.NewUintArray()
 			b.Release()
+		case flux.TBytes:
+			b := arrow.NewBytesBuilder(nil)
+			for i := range t.Data {
+				if v := t.Data[i][j]; v != nil {
+					b.Append(v.([]byte))
+				} else {
+					b.AppendNull()
+				}
+			}
+			cols[j] = b.NewBinaryArray()
+			b.Release()
 		}
 	}
 
@@ -347,6 +445,8 @@ func (t *RowWiseTable) Do(f func(flux.ColReader) error) error {
 				row[j] = arrow.IntSlice(cols[j].(*array.Int), i, i+1)
 			case flux.TUInt:
 				row[j] = arrow.UintSlice(cols[j].(*array.Uint), i, i+1)
+			case flux.TBytes:
+				row[j] = arrow.BytesSlice(cols[j].(*array.Binary), i, i+1)
 			}
 		}
 		if err := f(&ColReader{
@@ -453,6 +553,17 @@ func (t *ParallelTable) Do(f func(flux.ColReader) error) error {
 			}
 			cols[j] = b.NewUintArray()
 			b.Release()
+		case flux.TBytes:
+			b := arrow.NewBytesBuilder(t.Alloc)
+			for i := range t.Data {
+				if v := t.Data[i][j]; v != nil {
+					b.Append(v.([]byte))
+				} else {
+					b.AppendNull()
+				}
+			}
+			cols[j] = b.NewBinaryArray()
+			b.Release()
 		}
 	}
 
@@ -512,6 +623,12 @@ func ConvertTable(tbl flux.Table) (*Table, error) {
 					v = key.ValueString(j)
 				case flux.TTime:
 					v = key.ValueTime(j)
+				case flux.TBytes:
+					v = key.Value(j).Bytes()
+				case flux.TInvalid:
+					// Composite values such as records are only
+					// part of the group key and are kept as values.
+					v = key.Value(j)
 				default:
 					return nil, fmt.Errorf("unsupported column type %v", c.Type)
 				}
@@ -550,6 +667,10 @@ func ConvertTable(tbl flux.Table) (*Table, error) {
 					if col := cr.Times(j); col.IsValid(i) {
 						row[j] = values.Time(col.Value(i))
 					}
+				case flux.TBytes:
+					if col := cr.Bytes(j); col.IsValid(i) {
+						row[j] = append([]byte(nil), col.Value(i)...)
+					}
 				default:
 					panic(fmt.Errorf("unknown column type %s", c.Type))
 				}
@@ -898,6 +1019,8 @@ func RunTableTests(t *testing.T, tt TableTest) {
 							return cr.Bools(i).Len()
 						case flux.TTime:
 							return cr.Times(i).Len()
+						case flux.TBytes:
+							return cr.Bytes(i).Len()
 						default:
 							panic(fmt.Errorf("unexpected column type: %v", cr.Cols()[i].Type))
 						}
@@ -955,6 +1078,10 @@ func colReadersEqual(a, b flux.ColReader) bool {
 			if a.Times(i) != b.Times(i) {
 				return false
 			}
+		case flux.TBytes:
+			if a.Bytes(i) != b.Bytes(i) {
+				return false
+			}
 		}
 	}
 	return true
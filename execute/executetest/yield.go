@@ -10,11 +10,18 @@ func NewYieldProcedureSpec(name string) plan.PhysicalProcedureSpec {
 	return &YieldProcedureSpec{name: name}
 }
 
+// NewYieldProcedureSpecWithMetadata creates a yield
+// that attaches the metadata to its result.
+func NewYieldProcedureSpecWithMetadata(name string, metadata map[string]string) plan.PhysicalProcedureSpec {
+	return &YieldProcedureSpec{name: name, metadata: metadata}
+}
+
 const YieldKind = "yield-test"
 
 type YieldProcedureSpec struct {
 	plan.DefaultCost
-	name string
+	name     string
+	metadata map[string]string
 }
 
 func (YieldProcedureSpec) Kind() plan.ProcedureKind {
@@ -22,13 +29,17 @@ func (YieldProcedureSpec) Kind() plan.ProcedureKind {
 }
 
 func (y YieldProcedureSpec) Copy() plan.ProcedureSpec {
-	return YieldProcedureSpec{name: y.name}
+	return YieldProcedureSpec{name: y.name, metadata: y.metadata}
 }
 
 func (y YieldProcedureSpec) YieldName() string {
 	return y.name
 }
 
+func (y YieldProcedureSpec) YieldMetadata() map[string]string {
+	return y.metadata
+}
+
 // yieldTransformation copies the table as it is.
 type yieldTransformation struct {
 	execute.ExecutionNode
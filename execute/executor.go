@@ -63,10 +63,18 @@ type executionState struct {
 	sources []Source
 	metaCh  chan metadata.Metadata
 
+	// metaTransformations are transformations that report
+	// metadata once all of their input has been processed.
+	metaTransformations []MetadataTransformation
+
 	transports []AsyncTransport
 
 	dispatcher *poolDispatcher
 	logger     *zap.Logger
+
+	// abortErr is the first error the execution was aborted with.
+	abortMu  sync.Mutex
+	abortErr error
 }
 
 func (e *executor) Execute(ctx context.Context, p *plan.Spec, a memory.Allocator) (map[string]flux.Result, <-chan metadata.Metadata, error) {
@@ -100,10 +108,10 @@ func (e *executor) createExecutionState(ctx context.Context, p *plan.Spec, a mem
 		return nil, err
 	}
 
-	// Only sources can be a MetadataNode at the moment so allocate enough
-	// space for all of them to report metadata. Not all of them will necessarily
-	// report metadata.
-	es.metaCh = make(chan metadata.Metadata, len(es.sources))
+	// Sources and transformations can both report metadata so allocate enough
+	// space for all of them to report metadata. Not all of the sources will
+	// necessarily report metadata.
+	es.metaCh = make(chan metadata.Metadata, len(es.sources)+len(es.metaTransformations))
 
 	// Choose some default resource limits based on execution options, if necessary.
 	es.chooseDefaultResources(ctx, p)
@@ -216,6 +224,24 @@ func (v *createExecutionNodeVisitor) Visit(node plan.Node) error {
 
 	v.nodes[node] = make([]Node, copies)
 
+	// When the query has a memory limit, the operation that
+	// exceeds it is named by the allocator of its execution context.
+	if hasMemoryLimit(v.es) {
+		for i := range ec {
+			ec[i].alloc = &operationAllocator{Allocator: v.es.alloc, id: node.ID()}
+		}
+	}
+
+	// The allocations of a metered node are counted
+	// by the allocator of its execution context.
+	var metric *transformationMetric
+	if metrics := getTransformationMetrics(v.es); metrics != nil {
+		metric = metrics.metric(node)
+		for i := range ec {
+			ec[i].alloc = &meteredAllocator{Allocator: ec[i].Allocator(), metric: metric}
+		}
+	}
+
 	// If node is a leaf, create a source
 	if len(node.Predecessors()) == 0 {
 		createSourceFn, ok := procedureToSource[kind]
@@ -233,6 +259,9 @@ func (v *createExecutionNodeVisitor) Visit(node plan.Node) error {
 			}
 
 			source.SetLabel(string(node.ID()))
+			if metric != nil {
+				source = &meteredSource{Source: source, metric: metric, mem: v.es.alloc}
+			}
 			v.es.sources = append(v.es.sources, source)
 			v.nodes[node][i] = source
 		}
@@ -245,6 +274,7 @@ func (v *createExecutionNodeVisitor) Visit(node plan.Node) error {
 			return fmt.Errorf("unsupported procedure %v", kind)
 		}
 
+		limit := v.es.memoryLimit()
 		for i := 0; i < copies; i++ {
 			id := datasetIDFromNodeID(node.ID(), i)
 
@@ -258,12 +288,29 @@ func (v *createExecutionNodeVisitor) Visit(node plan.Node) error {
 				ds.WithContext(v.es.ctx)
 			}
 
+			if mt, ok := tr.(MetadataTransformation); ok {
+				v.es.metaTransformations = append(v.es.metaTransformations, mt)
+			}
+
 			if ppn.TriggerSpec == nil {
 				ppn.TriggerSpec = plan.DefaultTriggerSpec
 			}
 			ds.SetTriggerSpec(ppn.TriggerSpec)
 			v.nodes[node][i] = ds
 
+			if metric != nil {
+				tr = newMeteredTransformation(tr, &metric.in, metric, v.es.alloc)
+				v.nodes[node][i] = &meteredNode{Node: ds, counter: &metric.out, mem: v.es.alloc}
+			}
+
+			// The transports from every predecessor share the guard
+			// so the memory is only read while none of them is sending
+			// a message to the transformation.
+			var guard *memoryGuard
+			if mu, ok := ds.(MemoryUser); ok && limit > 0 {
+				guard = newMemoryGuard(mu, limit)
+			}
+
 			for _, p := range nonYieldPredecessors(node) {
 				// In case (1) above, both copies and predCopies are 1. We link
 				// forward from the only copy of the predecessor node.
@@ -280,7 +327,11 @@ func (v *createExecutionNodeVisitor) Visit(node plan.Node) error {
 				for j := 0; j < predCopies; j++ {
 					// Either i == 0 && j == 0: we are either iterating i, or we are iterating j.
 					executionNode := v.nodes[p][i+j]
-					transport := newConsecutiveTransport(v.es.ctx, v.es.dispatcher, tr, node, v.es.logger, v.es.alloc)
+					src := datasetIDFromNodeID(p.ID(), i+j)
+					transport := newConsecutiveTransport(v.es.ctx, v.es.dispatcher, tr, src, node, v.es.logger, v.es.alloc)
+					if guard != nil {
+						transport.limitMemory(guard)
+					}
 					v.es.transports = append(v.es.transports, transport)
 					executionNode.AddTransformation(transport)
 				}
@@ -323,6 +374,11 @@ func (v *createExecutionNodeVisitor) generateResult(resultName string, node plan
 		return errors.Newf(codes.Invalid, "tried to produce more than one result with the name %q", resultName)
 	}
 	r := newResult(resultName)
+	r.sortTables = sortTables(v.es.ctx)
+	r.schema = plan.GetOutputSchema(skipYields(node))
+	if spec, ok := node.ProcedureSpec().(plan.YieldMetadataSpec); ok {
+		r.metadata = spec.YieldMetadata()
+	}
 	v.es.results[resultName] = r
 	v.nodes[skipYields(node)][idx].AddTransformation(r)
 	return nil
@@ -373,6 +429,15 @@ func getResourceLimits(ctx context.Context) (int64, int) {
 	return execOptions.DefaultMemoryLimit, execOptions.ConcurrencyLimit
 }
 
+// sortTables reports whether the tables within
+// a result should be ordered by their group key.
+func sortTables(ctx context.Context) bool {
+	if !HaveExecutionDependencies(ctx) {
+		return false
+	}
+	return GetExecutionDependencies(ctx).ExecutionOptions.SortTables
+}
+
 func (es *executionState) chooseDefaultResources(ctx context.Context, p *plan.Spec) {
 	defaultMemoryLimit, concurrencyLimit := getResourceLimits(ctx)
 
@@ -416,19 +481,68 @@ func (es *executionState) chooseDefaultResources(ctx context.Context, p *plan.Sp
 }
 
 func (es *executionState) abort(err error) {
+	es.abortMu.Lock()
+	if es.abortErr == nil {
+		es.abortErr = err
+	}
+	es.abortMu.Unlock()
+
 	for _, r := range es.results {
 		r.(*result).abort(err)
 	}
 	es.cancel()
 }
 
+// memoryLimit returns the number of bytes that the query may
+// allocate, or zero when its allocator does not limit it.
+func (es *executionState) memoryLimit() int64 {
+	if ra, ok := es.alloc.(*memory.ResourceAllocator); ok && ra.Limit != nil {
+		return *ra.Limit
+	}
+	return 0
+}
+
+// finishTransports finishes the transformations that did not
+// finish before the execution was aborted with the abort error,
+// so they release the memory they hold and downstream
+// transformations are told why the execution stopped.
+// It must be called once the dispatcher has stopped.
+func (es *executionState) finishTransports() {
+	es.abortMu.Lock()
+	err := es.abortErr
+	es.abortMu.Unlock()
+	if err == nil {
+		return
+	}
+	for _, t := range es.transports {
+		if ct, ok := t.(*consecutiveTransport); ok {
+			ct.abort(err)
+		}
+	}
+}
+
+// ContextError returns the error of a context that is done.
+// A deadline is reported with the DeadlineExceeded code.
+func ContextError(err error) error {
+	if err == context.DeadlineExceeded {
+		return errors.Wrap(err, codes.DeadlineExceeded, "query deadline exceeded")
+	}
+	return err
+}
+
 func (es *executionState) do() {
 	var wg sync.WaitGroup
 	for _, src := range es.sources {
 		wg.Add(1)
 		go func(src Source) {
+			// A metered source is traced and reports
+			// metadata as the source that it wraps.
+			inner := src
+			if ms, ok := src.(*meteredSource); ok {
+				inner = ms.Source
+			}
 			ctx := es.ctx
-			if ctxWithSpan, span := StartSpanFromContext(ctx, reflect.TypeOf(src).String(), src.Label()); span != nil {
+			if ctxWithSpan, span := StartSpanFromContext(ctx, reflect.TypeOf(inner).String(), src.Label()); span != nil {
 				ctx = ctxWithSpan
 				defer span.Finish()
 			}
@@ -438,7 +552,7 @@ func (es *executionState) do() {
 			defer es.recover()
 			src.Run(ctx)
 
-			if mdn, ok := src.(MetadataNode); ok {
+			if mdn, ok := inner.(MetadataNode); ok {
 				es.metaCh <- mdn.Metadata()
 			}
 		}(src)
@@ -454,7 +568,7 @@ func (es *executionState) do() {
 			select {
 			case <-t.Finished():
 			case <-es.ctx.Done():
-				es.abort(es.ctx.Err())
+				es.abort(ContextError(es.ctx.Err()))
 			case err := <-es.dispatcher.Err():
 				if err != nil {
 					es.abort(err)
@@ -466,6 +580,13 @@ func (es *executionState) do() {
 		if err != nil {
 			es.abort(err)
 		}
+		es.finishTransports()
+
+		// All transformations have finished so any
+		// metadata they collected is now complete.
+		for _, mt := range es.metaTransformations {
+			es.metaCh <- mt.Metadata()
+		}
 	}()
 
 	go func() {
@@ -485,6 +606,9 @@ type executionContext struct {
 	parents       []DatasetID
 	streamContext streamContext
 	parallelOpts  ParallelOpts
+	// alloc is the allocator of the node when
+	// it differs from the one of the query.
+	alloc memory.Allocator
 }
 
 func resolveTime(qt flux.Time, now time.Time) Time {
@@ -504,6 +628,9 @@ func (ec executionContext) StreamContext() StreamContext {
 }
 
 func (ec executionContext) Allocator() memory.Allocator {
+	if ec.alloc != nil {
+		return ec.alloc
+	}
 	return ec.es.alloc
 }
 
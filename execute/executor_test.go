@@ -3,6 +3,8 @@ package execute_test
 import (
 	"context"
 	"math"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -28,6 +30,7 @@ func init() {
 	execute.RegisterSource(executetest.AllocatingFromTestKind, executetest.CreateAllocatingFromSource)
 	execute.RegisterTransformation(executetest.ToTestKind, executetest.CreateToTransformation)
 	plan.RegisterProcedureSpecWithSideEffect(executetest.ToTestKind, executetest.NewToProcedure, executetest.ToTestKind)
+	execute.RegisterTransformation(bufferTestKind, createBufferTestTransformation)
 }
 
 func TestExecutor_Execute(t *testing.T) {
@@ -802,3 +805,632 @@ func TestExecutor_Execute(t *testing.T) {
 		})
 	}
 }
+
+// TestExecutor_FinishError verifies that an error raised by one or both
+// parents of a transformation with multiple inputs is reported exactly
+// once and keeps the error code of the original failure.
+func TestExecutor_FinishError(t *testing.T) {
+	newTables := func(err error) []*executetest.Table {
+		return []*executetest.Table{{
+			KeyCols: []string{"_start", "_stop"},
+			ColMeta: []flux.ColMeta{
+				{Label: "_start", Type: flux.TTime},
+				{Label: "_stop", Type: flux.TTime},
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_value", Type: flux.TFloat},
+			},
+			Data: [][]interface{}{
+				{execute.Time(0), execute.Time(5), execute.Time(0), 1.0},
+			},
+			Err: err,
+		}}
+	}
+
+	leftErr := errors.New(codes.Invalid, "left error")
+	rightErr := errors.New(codes.Unavailable, "right error")
+
+	testcases := []struct {
+		name        string
+		left, right error
+		wantErrs    []error
+	}{
+		{
+			name:     "left error",
+			left:     leftErr,
+			wantErrs: []error{leftErr},
+		},
+		{
+			name:     "right error",
+			right:    rightErr,
+			wantErrs: []error{rightErr},
+		},
+		{
+			name:  "both errors",
+			left:  leftErr,
+			right: rightErr,
+			// Either parent may fail first.
+			wantErrs: []error{leftErr, rightErr},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			// Run each case several times so both parents
+			// get a chance to report their error first.
+			for i := 0; i < 10; i++ {
+				spec := &plantest.PlanSpec{
+					Nodes: []plan.Node{
+						plan.CreatePhysicalNode("from-left", executetest.NewFromProcedureSpec(newTables(tc.left))),
+						plan.CreatePhysicalNode("from-right", executetest.NewFromProcedureSpec(newTables(tc.right))),
+						plan.CreatePhysicalNode("sum-left", &universe.SumProcedureSpec{
+							SimpleAggregateConfig: execute.DefaultSimpleAggregateConfig,
+						}),
+						plan.CreatePhysicalNode("sum-right", &universe.SumProcedureSpec{
+							SimpleAggregateConfig: execute.DefaultSimpleAggregateConfig,
+						}),
+						plan.CreatePhysicalNode("join", &universe.MergeJoinProcedureSpec{
+							On:         []string{"_start", "_stop"},
+							TableNames: []string{"a", "b"},
+						}),
+						plan.CreatePhysicalNode("yield", executetest.NewYieldProcedureSpec("_result")),
+					},
+					Edges: [][2]int{
+						{0, 2},
+						{1, 3},
+						{2, 4},
+						{3, 4},
+						{4, 5},
+					},
+					Resources: flux.ResourceManagement{
+						ConcurrencyQuota: 2,
+						MemoryBytesQuota: math.MaxInt64,
+					},
+					Now: time.Now(),
+				}
+
+				exe := execute.NewExecutor(zaptest.NewLogger(t))
+				ctx, deps := dependency.Inject(context.Background(), executetest.NewTestExecuteDependencies())
+				results, _, err := exe.Execute(ctx, plantest.CreatePlanSpec(spec), executetest.UnlimitedAllocator)
+				if err != nil {
+					deps.Finish()
+					t.Fatal(err)
+				}
+
+				var errs []error
+				for _, r := range results {
+					if err := r.Tables().Do(func(tbl flux.Table) error {
+						return tbl.Do(func(flux.ColReader) error { return nil })
+					}); err != nil {
+						errs = append(errs, err)
+					}
+				}
+				deps.Finish()
+
+				if len(errs) != 1 {
+					t.Fatalf("expected exactly one error, got %d: %v", len(errs), errs)
+				}
+				found := false
+				for _, want := range tc.wantErrs {
+					if errors.Code(errs[0]) == errors.Code(want) && strings.Contains(errs[0].Error(), want.Error()) {
+						found = true
+						break
+					}
+				}
+				if !found {
+					t.Fatalf("unexpected error %q, want one of %v", errs[0], tc.wantErrs)
+				}
+			}
+		})
+	}
+}
+
+func TestExecutor_SortTables(t *testing.T) {
+	newTable := func(tag string, v float64) *executetest.Table {
+		return &executetest.Table{
+			KeyCols: []string{"t0"},
+			ColMeta: []flux.ColMeta{
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_value", Type: flux.TFloat},
+				{Label: "t0", Type: flux.TString},
+			},
+			Data: [][]interface{}{
+				{execute.Time(0), v, tag},
+				{execute.Time(1), v + 1, tag},
+			},
+		}
+	}
+
+	spec := &plantest.PlanSpec{
+		Nodes: []plan.Node{
+			plan.CreatePhysicalNode("from-test", executetest.NewFromProcedureSpec(
+				[]*executetest.Table{
+					newTable("c", 5),
+					newTable("a", 1),
+					newTable("d", 7),
+					newTable("b", 3),
+				},
+			)),
+			plan.CreatePhysicalNode("yield", executetest.NewYieldProcedureSpec("_result")),
+		},
+		Edges: [][2]int{
+			{0, 1},
+		},
+		Resources: flux.ResourceManagement{
+			ConcurrencyQuota: 1,
+			MemoryBytesQuota: math.MaxInt64,
+		},
+		Now: time.Now(),
+	}
+
+	ctx, deps := dependency.Inject(context.Background(), executetest.NewTestExecuteDependencies())
+	defer deps.Finish()
+
+	execDeps := execute.DefaultExecutionDependencies()
+	execDeps.ExecutionOptions.SortTables = true
+	ctx = execDeps.Inject(ctx)
+
+	exe := execute.NewExecutor(zaptest.NewLogger(t))
+	results, _, err := exe.Execute(ctx, plantest.CreatePlanSpec(spec), executetest.UnlimitedAllocator)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []*executetest.Table
+	if err := results["_result"].Tables().Do(func(tbl flux.Table) error {
+		cb, err := executetest.ConvertTable(tbl)
+		if err != nil {
+			return err
+		}
+		got = append(got, cb)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []*executetest.Table{
+		newTable("a", 1),
+		newTable("b", 3),
+		newTable("c", 5),
+		newTable("d", 7),
+	}
+	executetest.NormalizeTables(got)
+	executetest.NormalizeTables(want)
+
+	// The tables are not sorted before they are compared
+	// since the order they are returned in is under test.
+	if !cmp.Equal(want, got) {
+		t.Errorf("unexpected results -want/+got\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestExecutor_MemoryLimit(t *testing.T) {
+	spec := &plantest.PlanSpec{
+		Nodes: []plan.Node{
+			plan.CreatePhysicalNode("allocating-from-test", &executetest.AllocatingFromProcedureSpec{ByteCount: 65}),
+			plan.CreatePhysicalNode("yield", executetest.NewYieldProcedureSpec("_result")),
+		},
+		Edges: [][2]int{
+			{0, 1},
+		},
+		Resources: flux.ResourceManagement{
+			ConcurrencyQuota: 1,
+			MemoryBytesQuota: math.MaxInt64,
+		},
+		Now: time.Now(),
+	}
+
+	ctx, deps := dependency.Inject(context.Background(), executetest.NewTestExecuteDependencies())
+	defer deps.Finish()
+
+	execDeps := execute.DefaultExecutionDependencies()
+	execDeps.ExecutionOptions.MemoryLimit = 64
+	ctx = execDeps.Inject(ctx)
+
+	alloc := &memory.ResourceAllocator{
+		Limit: func(v int64) *int64 { return &v }(64),
+	}
+	exe := execute.NewExecutor(zaptest.NewLogger(t))
+	results, _, err := exe.Execute(ctx, plantest.CreatePlanSpec(spec), alloc)
+	if err == nil {
+		err = results["_result"].Tables().Do(func(flux.Table) error {
+			return nil
+		})
+	}
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	if want, got := codes.ResourceExhausted, flux.ErrorCode(err); want != got {
+		t.Errorf("unexpected error code -want/+got:\n\t- %v\n\t+ %v", want, got)
+	}
+	if want, got := `operation "allocating-from-test" exceeded the memory limit: memory allocation limit reached: limit 64 bytes, allocated: 0, wanted: 65`, err.Error(); want != got {
+		t.Errorf("unexpected error -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+}
+
+func TestExecutor_MemoryUsed(t *testing.T) {
+	// The table holds 100 strings of 1 KiB. The allocator only
+	// counts the headers of the strings that to-test buffers,
+	// but the memory used by its cache counts their contents.
+	newTable := func() *executetest.Table {
+		tbl := &executetest.Table{
+			ColMeta: []flux.ColMeta{
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_value", Type: flux.TString},
+			},
+		}
+		for i := 0; i < 100; i++ {
+			tbl.Data = append(tbl.Data, []interface{}{execute.Time(i), strings.Repeat("a", 1024)})
+		}
+		return tbl
+	}
+
+	for _, tc := range []struct {
+		name    string
+		limit   int64
+		wantErr bool
+	}{
+		{
+			name:    "exceeds limit",
+			limit:   64 * 1024,
+			wantErr: true,
+		},
+		{
+			name:  "within limit",
+			limit: 1024 * 1024,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			spec := &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("from", executetest.NewFromProcedureSpec([]*executetest.Table{newTable()})),
+					plan.CreatePhysicalNode("to", &executetest.ToProcedureSpec{}),
+					plan.CreatePhysicalNode("yield", executetest.NewYieldProcedureSpec("_result")),
+				},
+				Edges: [][2]int{
+					{0, 1},
+					{1, 2},
+				},
+				Resources: flux.ResourceManagement{
+					ConcurrencyQuota: 1,
+					MemoryBytesQuota: math.MaxInt64,
+				},
+				Now: time.Now(),
+			}
+
+			ctx, deps := dependency.Inject(context.Background(), executetest.NewTestExecuteDependencies())
+			defer deps.Finish()
+
+			alloc := &memory.ResourceAllocator{Limit: &tc.limit}
+			exe := execute.NewExecutor(zaptest.NewLogger(t))
+			results, _, err := exe.Execute(ctx, plantest.CreatePlanSpec(spec), alloc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = results["_result"].Tables().Do(func(tbl flux.Table) error {
+				return tbl.Do(func(flux.ColReader) error { return nil })
+			})
+			if !tc.wantErr {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+			if !errors.Is(err, execute.ErrMemoryLimitExceeded) {
+				t.Fatalf("expected the memory limit to be exceeded, got: %v", err)
+			}
+			if want, got := codes.ResourceExhausted, flux.ErrorCode(err); want != got {
+				t.Errorf("unexpected error code -want/+got:\n\t- %v\n\t+ %v", want, got)
+			}
+		})
+	}
+}
+
+const bufferTestKind = "buffer-test"
+
+// bufferTestProcedureSpec is the spec of a transformation that
+// buffers the tables from all of its inputs until they finish.
+type bufferTestProcedureSpec struct {
+	plan.DefaultCost
+}
+
+func (s *bufferTestProcedureSpec) Kind() plan.ProcedureKind {
+	return bufferTestKind
+}
+
+func (s *bufferTestProcedureSpec) Copy() plan.ProcedureSpec {
+	return &bufferTestProcedureSpec{}
+}
+
+func createBufferTestTransformation(id execute.DatasetID, mode execute.AccumulationMode, spec plan.ProcedureSpec, a execute.Administration) (execute.Transformation, execute.Dataset, error) {
+	cache := execute.NewTableBuilderCache(a.Allocator())
+	d := execute.NewDataset(id, mode, cache)
+	t := &bufferTestTransformation{d: d, cache: cache, parents: len(a.Parents())}
+	return t, d, nil
+}
+
+type bufferTestTransformation struct {
+	execute.ExecutionNode
+	mu       sync.Mutex
+	d        execute.Dataset
+	cache    execute.TableBuilderCache
+	parents  int
+	finished int
+}
+
+func (t *bufferTestTransformation) RetractTable(id execute.DatasetID, key flux.GroupKey) error {
+	return nil
+}
+
+func (t *bufferTestTransformation) Process(id execute.DatasetID, tbl flux.Table) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	builder, _ := t.cache.TableBuilder(tbl.Key())
+	if err := execute.AddTableCols(tbl, builder); err != nil {
+		return err
+	}
+	return execute.AppendTable(tbl, builder)
+}
+
+func (t *bufferTestTransformation) UpdateWatermark(id execute.DatasetID, mark execute.Time) error {
+	return nil
+}
+
+func (t *bufferTestTransformation) UpdateProcessingTime(id execute.DatasetID, pt execute.Time) error {
+	return nil
+}
+
+func (t *bufferTestTransformation) Finish(id execute.DatasetID, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.finished++; err != nil || t.finished == t.parents {
+		t.d.Finish(err)
+	}
+}
+
+func TestExecutor_MemoryUsedMultipleInputs(t *testing.T) {
+	// Each input has a table of 50 strings of 1 KiB, so only
+	// the tables from both inputs exceed the lower limit.
+	newTable := func(tag string) *executetest.Table {
+		tbl := &executetest.Table{
+			KeyCols: []string{"t0"},
+			ColMeta: []flux.ColMeta{
+				{Label: "_time", Type: flux.TTime},
+				{Label: "t0", Type: flux.TString},
+				{Label: "_value", Type: flux.TString},
+			},
+		}
+		for i := 0; i < 50; i++ {
+			tbl.Data = append(tbl.Data, []interface{}{execute.Time(i), tag, strings.Repeat("a", 1024)})
+		}
+		return tbl
+	}
+
+	for _, tc := range []struct {
+		name    string
+		limit   int64
+		wantErr bool
+	}{
+		{
+			name:    "exceeds limit",
+			limit:   64 * 1024,
+			wantErr: true,
+		},
+		{
+			name:  "within limit",
+			limit: 1024 * 1024,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			spec := &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("from0", executetest.NewFromProcedureSpec([]*executetest.Table{newTable("a")})),
+					plan.CreatePhysicalNode("from1", executetest.NewFromProcedureSpec([]*executetest.Table{newTable("b")})),
+					plan.CreatePhysicalNode("buffer", &bufferTestProcedureSpec{}),
+					plan.CreatePhysicalNode("yield", executetest.NewYieldProcedureSpec("_result")),
+				},
+				Edges: [][2]int{
+					{0, 2},
+					{1, 2},
+					{2, 3},
+				},
+				Resources: flux.ResourceManagement{
+					ConcurrencyQuota: 2,
+					MemoryBytesQuota: math.MaxInt64,
+				},
+				Now: time.Now(),
+			}
+
+			ctx, deps := dependency.Inject(context.Background(), executetest.NewTestExecuteDependencies())
+			defer deps.Finish()
+
+			alloc := &memory.ResourceAllocator{Limit: &tc.limit}
+			exe := execute.NewExecutor(zaptest.NewLogger(t))
+			results, _, err := exe.Execute(ctx, plantest.CreatePlanSpec(spec), alloc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			n := 0
+			err = results["_result"].Tables().Do(func(tbl flux.Table) error {
+				n++
+				return tbl.Do(func(flux.ColReader) error { return nil })
+			})
+			if !tc.wantErr {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				if n != 2 {
+					t.Errorf("expected a table from each input, got %d tables", n)
+				}
+				return
+			}
+			if !errors.Is(err, execute.ErrMemoryLimitExceeded) {
+				t.Fatalf("expected the memory limit to be exceeded, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestExecutor_YieldMetadata(t *testing.T) {
+	metadata := map[string]string{"unit": "count"}
+	spec := &plantest.PlanSpec{
+		Nodes: []plan.Node{
+			plan.CreatePhysicalNode("from-test", executetest.NewFromProcedureSpec(
+				[]*executetest.Table{{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(0), 1.0},
+					},
+				}},
+			)),
+			plan.CreatePhysicalNode("yield", executetest.NewYieldProcedureSpecWithMetadata("_result", metadata)),
+		},
+		Edges: [][2]int{
+			{0, 1},
+		},
+		Resources: flux.ResourceManagement{
+			ConcurrencyQuota: 1,
+			MemoryBytesQuota: math.MaxInt64,
+		},
+		Now: time.Now(),
+	}
+
+	ctx, deps := dependency.Inject(context.Background(), executetest.NewTestExecuteDependencies())
+	defer deps.Finish()
+
+	exe := execute.NewExecutor(zaptest.NewLogger(t))
+	results, _, err := exe.Execute(ctx, plantest.CreatePlanSpec(spec), executetest.UnlimitedAllocator)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := results["_result"]
+	if err := result.Tables().Do(func(tbl flux.Table) error {
+		return tbl.Do(func(flux.ColReader) error { return nil })
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	mr, ok := result.(flux.MetadataResult)
+	if !ok {
+		t.Fatalf("expected a flux.MetadataResult, got %T", result)
+	}
+	if got, want := mr.Metadata(), metadata; !cmp.Equal(want, got) {
+		t.Errorf("unexpected metadata -want/+got\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestExecutor_Metrics(t *testing.T) {
+	newTable := func(tag string, v float64) *executetest.Table {
+		return &executetest.Table{
+			KeyCols: []string{"t0"},
+			ColMeta: []flux.ColMeta{
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_value", Type: flux.TFloat},
+				{Label: "t0", Type: flux.TString},
+			},
+			Data: [][]interface{}{
+				{execute.Time(0), v, tag},
+				{execute.Time(1), v + 1, tag},
+			},
+		}
+	}
+
+	// The output of sum is sent to both yields,
+	// but it is only counted once.
+	spec := &plantest.PlanSpec{
+		Nodes: []plan.Node{
+			plan.CreatePhysicalNode("from-test", executetest.NewFromProcedureSpec(
+				[]*executetest.Table{
+					newTable("a", 1),
+					newTable("b", 3),
+					newTable("c", 5),
+					newTable("d", 7),
+				},
+			)),
+			plan.CreatePhysicalNode("limit", &universe.LimitProcedureSpec{N: 1}),
+			plan.CreatePhysicalNode("sum", &universe.SumProcedureSpec{
+				SimpleAggregateConfig: execute.DefaultSimpleAggregateConfig,
+			}),
+			plan.CreatePhysicalNode("yield0", executetest.NewYieldProcedureSpec("a")),
+			plan.CreatePhysicalNode("yield1", executetest.NewYieldProcedureSpec("b")),
+		},
+		Edges: [][2]int{
+			{0, 1},
+			{1, 2},
+			{2, 3},
+			{2, 4},
+		},
+		Resources: flux.ResourceManagement{
+			ConcurrencyQuota: 1,
+			MemoryBytesQuota: math.MaxInt64,
+		},
+		Now: time.Now(),
+	}
+
+	ctx, deps := dependency.Inject(context.Background(), executetest.NewTestExecuteDependencies())
+	defer deps.Finish()
+
+	metrics := execute.NewTransformationMetrics()
+	execDeps := execute.DefaultExecutionDependencies()
+	execDeps.ExecutionOptions.Metrics = metrics
+	ctx = execDeps.Inject(ctx)
+
+	exe := execute.NewExecutor(zaptest.NewLogger(t))
+	results, _, err := exe.Execute(ctx, plantest.CreatePlanSpec(spec), executetest.UnlimitedAllocator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		if err := r.Tables().Do(func(tbl flux.Table) error {
+			return tbl.Do(func(flux.ColReader) error { return nil })
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := metrics.Metrics()
+	for i := range got {
+		if got[i].Duration <= 0 {
+			t.Errorf("expected %s to take some time, got %v", got[i].Name, got[i].Duration)
+		}
+		got[i].Duration = 0
+		// Sum builds new tables, so it allocates memory.
+		if got[i].Name == "sum" && got[i].Allocated <= 0 {
+			t.Errorf("expected sum to allocate memory, got %d bytes", got[i].Allocated)
+		}
+		got[i].Allocated = 0
+	}
+	// Each row of the input has two eight byte values and a one byte string.
+	want := []execute.TransformationMetric{
+		{
+			Name:     "from-test",
+			Kind:     executetest.FromTestKind,
+			RowsOut:  8,
+			BytesOut: 8 * 17,
+		},
+		{
+			Name:     "limit",
+			Kind:     universe.LimitKind,
+			RowsIn:   8,
+			BytesIn:  8 * 17,
+			RowsOut:  4,
+			BytesOut: 4 * 17,
+		},
+		{
+			Name:     "sum",
+			Kind:     universe.SumKind,
+			RowsIn:   4,
+			BytesIn:  4 * 17,
+			RowsOut:  4,
+			BytesOut: 4 * 9,
+		},
+	}
+	if !cmp.Equal(want, got) {
+		t.Errorf("unexpected metrics -want/+got\n%s", cmp.Diff(want, got))
+	}
+}
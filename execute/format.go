@@ -1,6 +1,7 @@
 package execute
 
 import (
+	"encoding/hex"
 	"fmt"
 	"io"
 	"sort"
@@ -88,6 +89,7 @@ var minWidthsByType = map[flux.ColType]int{
 	flux.TFloat:   28,
 	flux.TString:  22,
 	flux.TTime:    len(fixedWidthTimeFmt),
+	flux.TBytes:   22,
 	flux.TInvalid: 10,
 }
 
@@ -257,6 +259,10 @@ func (f *Formatter) valueBuf(i, j int, typ flux.ColType, cr flux.ColReader) []by
 		if cr.Times(j).IsValid(i) {
 			buf = []byte(values.Time(cr.Times(j).Value(i)).String())
 		}
+	case flux.TBytes:
+		if cr.Bytes(j).IsValid(i) {
+			buf = []byte(hex.EncodeToString(cr.Bytes(j).Value(i)))
+		}
 	}
 	return buf
 }
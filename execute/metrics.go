@@ -0,0 +1,283 @@
+package execute
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/plan"
+)
+
+// TransformationMetric holds the data that a transformation
+// of a query has processed.
+type TransformationMetric struct {
+	// Name is the ID of the plan node of the transformation.
+	Name string
+	// Kind is the procedure kind of the transformation.
+	Kind plan.ProcedureKind
+
+	// RowsIn and BytesIn count the data that was sent to the transformation.
+	RowsIn  int64
+	BytesIn int64
+	// RowsOut and BytesOut count the data that the transformation produced.
+	RowsOut  int64
+	BytesOut int64
+
+	// Duration is the time that the transformation spent processing its input.
+	// The duration of a source is the time that it spent running.
+	Duration time.Duration
+	// Allocated is the total number of bytes that the transformation
+	// allocated with the allocator of its administration.
+	Allocated int64
+}
+
+// TransformationMetrics collects a TransformationMetric for each
+// transformation and source of a query. When the execution options
+// hold one, each transformation is wrapped to count the rows and bytes
+// of the tables that it reads and produces. A source has no input,
+// so only the data that it produces is counted.
+//
+// The bytes of a column are estimated from its values. A string value
+// counts its length, a boolean value one byte and other values eight bytes.
+type TransformationMetrics struct {
+	mu      sync.Mutex
+	metrics []*transformationMetric
+}
+
+// NewTransformationMetrics creates an empty TransformationMetrics.
+func NewTransformationMetrics() *TransformationMetrics {
+	return &TransformationMetrics{}
+}
+
+// Metrics returns the metrics of each transformation in the order
+// they were created, which has the predecessors of a transformation
+// before it. The metrics are complete once the query is done.
+func (m *TransformationMetrics) Metrics() []TransformationMetric {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	metrics := make([]TransformationMetric, len(m.metrics))
+	for i, tm := range m.metrics {
+		metrics[i] = TransformationMetric{
+			Name:      tm.name,
+			Kind:      tm.kind,
+			RowsIn:    atomic.LoadInt64(&tm.in.rows),
+			BytesIn:   atomic.LoadInt64(&tm.in.bytes),
+			RowsOut:   atomic.LoadInt64(&tm.out.rows),
+			BytesOut:  atomic.LoadInt64(&tm.out.bytes),
+			Duration:  time.Duration(atomic.LoadInt64(&tm.duration)),
+			Allocated: atomic.LoadInt64(&tm.allocated),
+		}
+	}
+	return metrics
+}
+
+// metric returns the metric of a plan node.
+// The parallel copies of a node share their metric.
+func (m *TransformationMetrics) metric(node plan.Node) *transformationMetric {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, tm := range m.metrics {
+		if tm.name == string(node.ID()) {
+			return tm
+		}
+	}
+	tm := &transformationMetric{
+		name: string(node.ID()),
+		kind: node.Kind(),
+	}
+	m.metrics = append(m.metrics, tm)
+	return tm
+}
+
+type transformationMetric struct {
+	name      string
+	kind      plan.ProcedureKind
+	in, out   dataCounter
+	duration  int64
+	allocated int64
+}
+
+// dataCounter counts the rows and bytes of column readers.
+type dataCounter struct {
+	rows, bytes int64
+}
+
+func (c *dataCounter) count(cr flux.ColReader) {
+	n := cr.Len()
+	var bytes int64
+	for j, col := range cr.Cols() {
+		switch col.Type {
+		case flux.TString:
+			vs := cr.Strings(j)
+			for i := 0; i < n; i++ {
+				bytes += int64(vs.ValueLen(i))
+			}
+		case flux.TBool:
+			bytes += int64(n)
+		default:
+			bytes += 8 * int64(n)
+		}
+	}
+	atomic.AddInt64(&c.rows, int64(n))
+	atomic.AddInt64(&c.bytes, bytes)
+}
+
+// getTransformationMetrics returns the TransformationMetrics
+// of the execution options, if there are any.
+func getTransformationMetrics(es *executionState) *TransformationMetrics {
+	if !HaveExecutionDependencies(es.ctx) {
+		return nil
+	}
+	return GetExecutionDependencies(es.ctx).ExecutionOptions.Metrics
+}
+
+// meteredTransformation counts the data that is sent to a
+// transformation and, when it has a metric, the time that
+// the transformation spends processing what is sent to it.
+type meteredTransformation struct {
+	t       Transformation
+	tr      Transport
+	counter *dataCounter
+	metric  *transformationMetric
+}
+
+func newMeteredTransformation(t Transformation, counter *dataCounter, metric *transformationMetric, mem memory.Allocator) *meteredTransformation {
+	return &meteredTransformation{
+		t:       t,
+		tr:      WrapTransformationInTransport(t, mem),
+		counter: counter,
+		metric:  metric,
+	}
+}
+
+// timed adds the time since start to the duration of the metric.
+func (t *meteredTransformation) timed(start time.Time) {
+	if t.metric != nil {
+		atomic.AddInt64(&t.metric.duration, int64(time.Since(start)))
+	}
+}
+
+func (t *meteredTransformation) ProcessMessage(m Message) error {
+	defer t.timed(time.Now())
+	switch m := m.(type) {
+	case ProcessMsg:
+		return t.tr.ProcessMessage(&processMsg{
+			srcMessage: srcMessage(m.SrcDatasetID()),
+			table:      &meteredTable{Table: m.Table(), counter: t.counter},
+		})
+	case ProcessChunkMsg:
+		buffer := m.TableChunk().Buffer()
+		t.counter.count(&buffer)
+	}
+	return t.tr.ProcessMessage(m)
+}
+
+func (t *meteredTransformation) RetractTable(id DatasetID, key flux.GroupKey) error {
+	defer t.timed(time.Now())
+	return t.t.RetractTable(id, key)
+}
+
+func (t *meteredTransformation) Process(id DatasetID, tbl flux.Table) error {
+	defer t.timed(time.Now())
+	return t.t.Process(id, &meteredTable{Table: tbl, counter: t.counter})
+}
+
+func (t *meteredTransformation) UpdateWatermark(id DatasetID, time Time) error {
+	return t.t.UpdateWatermark(id, time)
+}
+
+func (t *meteredTransformation) UpdateProcessingTime(id DatasetID, time Time) error {
+	return t.t.UpdateProcessingTime(id, time)
+}
+
+func (t *meteredTransformation) Finish(id DatasetID, err error) {
+	defer t.timed(time.Now())
+	t.t.Finish(id, err)
+}
+
+func (t *meteredTransformation) OperationType() string {
+	return OperationType(t.t)
+}
+
+// meteredTable counts the column readers of a table as they are read.
+type meteredTable struct {
+	flux.Table
+	counter *dataCounter
+}
+
+func (t *meteredTable) Do(f func(flux.ColReader) error) error {
+	return t.Table.Do(func(cr flux.ColReader) error {
+		t.counter.count(cr)
+		return f(cr)
+	})
+}
+
+// meteredNode counts the data that a node produces. Each of the
+// transformations of a node receives the same data, so only
+// the data sent to the first one is counted.
+type meteredNode struct {
+	Node
+	counter *dataCounter
+	mem     memory.Allocator
+	added   bool
+}
+
+func (n *meteredNode) AddTransformation(t Transformation) {
+	if !n.added {
+		n.added = true
+		t = newMeteredTransformation(t, n.counter, nil, n.mem)
+	}
+	n.Node.AddTransformation(t)
+}
+
+// meteredSource counts the data that a source produces
+// and the time that it spends running.
+type meteredSource struct {
+	Source
+	metric *transformationMetric
+	mem    memory.Allocator
+	added  bool
+}
+
+func (s *meteredSource) Run(ctx context.Context) {
+	start := time.Now()
+	s.Source.Run(ctx)
+	atomic.AddInt64(&s.metric.duration, int64(time.Since(start)))
+}
+
+func (s *meteredSource) AddTransformation(t Transformation) {
+	if !s.added {
+		s.added = true
+		t = newMeteredTransformation(t, &s.metric.out, nil, s.mem)
+	}
+	s.Source.AddTransformation(t)
+}
+
+// meteredAllocator counts the bytes that a node allocates.
+// The memory that is freed is not subtracted.
+type meteredAllocator struct {
+	memory.Allocator
+	metric *transformationMetric
+}
+
+func (a *meteredAllocator) Allocate(size int) []byte {
+	atomic.AddInt64(&a.metric.allocated, int64(size))
+	return a.Allocator.Allocate(size)
+}
+
+func (a *meteredAllocator) Reallocate(size int, b []byte) []byte {
+	if grown := size - len(b); grown > 0 {
+		atomic.AddInt64(&a.metric.allocated, int64(grown))
+	}
+	return a.Allocator.Reallocate(size, b)
+}
+
+func (a *meteredAllocator) Account(size int) error {
+	if size > 0 {
+		atomic.AddInt64(&a.metric.allocated, int64(size))
+	}
+	return a.Allocator.Account(size)
+}
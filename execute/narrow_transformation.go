@@ -3,6 +3,7 @@ package execute
 import (
 	"github.com/apache/arrow/go/v7/arrow/memory"
 	"github.com/influxdata/flux/execute/table"
+	"github.com/influxdata/flux/metadata"
 )
 
 // NarrowTransformation implements a transformation that processes
@@ -23,12 +24,37 @@ type narrowTransformation struct {
 
 // NewNarrowTransformation constructs a Transformation and Dataset
 // using the NarrowTransformation implementation.
+//
+// If the NarrowTransformation has a Metadata method, the returned
+// Transformation is a MetadataTransformation that reports it.
+// If it has a Concurrency method, the returned Transformation is a
+// ParallelTransformation with that concurrency.
 func NewNarrowTransformation(id DatasetID, t NarrowTransformation, mem memory.Allocator) (Transformation, Dataset, error) {
 	tr := &narrowTransformation{
 		t: t,
 		d: NewTransportDataset(id, mem),
 	}
-	return NewTransformationFromTransport(tr), tr.d, nil
+	adapter := &transportTransformationAdapter{Transport: tr}
+	if mt, ok := t.(interface {
+		Metadata() metadata.Metadata
+	}); ok {
+		return &metadataTransportTransformationAdapter{
+			transportTransformationAdapter: adapter,
+			metadata:                       mt.Metadata,
+		}, tr.d, nil
+	}
+	return adapter, tr.d, nil
+}
+
+// metadataTransportTransformationAdapter is a transportTransformationAdapter
+// that reports the metadata of the underlying transformation.
+type metadataTransportTransformationAdapter struct {
+	*transportTransformationAdapter
+	metadata func() metadata.Metadata
+}
+
+func (t *metadataTransportTransformationAdapter) Metadata() metadata.Metadata {
+	return t.metadata()
 }
 
 // ProcessMessage will process the incoming message.
@@ -55,6 +81,17 @@ func (n *narrowTransformation) Finish(id DatasetID, err error) {
 	n.d.Finish(err)
 }
 
+// Concurrency returns the concurrency of the NarrowTransformation
+// if it has a Concurrency method and 1 otherwise.
+func (n *narrowTransformation) Concurrency() int {
+	if pt, ok := n.t.(interface {
+		Concurrency() int
+	}); ok {
+		return pt.Concurrency()
+	}
+	return 1
+}
+
 func (n *narrowTransformation) OperationType() string {
 	return OperationType(n.t)
 }
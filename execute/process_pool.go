@@ -0,0 +1,109 @@
+package execute
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+
+	"github.com/influxdata/flux"
+)
+
+// processPool processes the tables sent to a ParallelTransformation
+// with a fixed number of goroutines.
+//
+// Each table is sent to the goroutine chosen by its group key so
+// the chunks of a table are processed in the order they arrive.
+type processPool struct {
+	ctx     context.Context
+	n       int
+	process func(ctx context.Context, m Message)
+
+	workers []chan processRequest
+	wg      sync.WaitGroup
+}
+
+type processRequest struct {
+	ctx context.Context
+	m   Message
+}
+
+func newProcessPool(ctx context.Context, n int, process func(ctx context.Context, m Message)) *processPool {
+	return &processPool{
+		ctx:     ctx,
+		n:       n,
+		process: process,
+	}
+}
+
+// start starts the goroutines of the pool the first time it is called.
+func (p *processPool) start() {
+	if p.workers != nil {
+		return
+	}
+	p.workers = make([]chan processRequest, p.n)
+	for i := range p.workers {
+		ch := make(chan processRequest)
+		p.workers[i] = ch
+		go p.run(ch)
+	}
+}
+
+func (p *processPool) run(ch <-chan processRequest) {
+	for {
+		select {
+		case req, ok := <-ch:
+			if !ok {
+				return
+			}
+			p.process(req.ctx, req.m)
+			p.wg.Done()
+		case <-p.ctx.Done():
+			return
+		}
+	}
+}
+
+// dispatch sends the message to the goroutine for the group key.
+// It blocks until that goroutine is ready to process it.
+func (p *processPool) dispatch(ctx context.Context, key flux.GroupKey, m Message) {
+	p.start()
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key.String()))
+	ch := p.workers[h.Sum64()%uint64(len(p.workers))]
+
+	p.wg.Add(1)
+	select {
+	case ch <- processRequest{ctx: ctx, m: m}:
+	case <-p.ctx.Done():
+		m.Ack()
+		p.wg.Done()
+	}
+}
+
+// wait blocks until every message that was dispatched has been processed.
+func (p *processPool) wait() {
+	p.wg.Wait()
+}
+
+// close waits for the dispatched messages and stops the goroutines.
+func (p *processPool) close() {
+	p.wait()
+	for _, ch := range p.workers {
+		close(ch)
+	}
+	p.workers = nil
+}
+
+// processKey returns the group key of a message
+// that can be processed by a processPool.
+func processKey(m Message) (flux.GroupKey, bool) {
+	switch m := m.(type) {
+	case ProcessMsg:
+		return m.Table().Key(), true
+	case ProcessChunkMsg:
+		return m.TableChunk().Key(), true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,329 @@
+package execute_test
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/dependency"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/execute/executetest"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/plan"
+	"github.com/influxdata/flux/plan/plantest"
+	"go.uber.org/zap/zaptest"
+)
+
+const parallelTestKind = "parallel-test"
+
+func init() {
+	execute.RegisterTransformation(parallelTestKind, createParallelTestTransformation)
+}
+
+// parallelTestProcedureSpec is the spec of a ParallelTransformation
+// that records how the executor called it.
+type parallelTestProcedureSpec struct {
+	plan.DefaultCost
+	concurrency int
+	delay       time.Duration
+	// failTag is the t0 value of the table that fails.
+	failTag string
+	stats   *parallelTestStats
+}
+
+func (s *parallelTestProcedureSpec) Kind() plan.ProcedureKind {
+	return parallelTestKind
+}
+
+func (s *parallelTestProcedureSpec) Copy() plan.ProcedureSpec {
+	ns := *s
+	return &ns
+}
+
+type parallelTestStats struct {
+	mu        sync.Mutex
+	active    int
+	maxActive int
+	processed int
+	// values holds the first value of each table by t0.
+	values map[string][]float64
+	// processedAtFinish is the number of tables
+	// that were processed when Finish was called.
+	processedAtFinish int
+}
+
+func newParallelTestStats() *parallelTestStats {
+	return &parallelTestStats{values: make(map[string][]float64)}
+}
+
+func createParallelTestTransformation(id execute.DatasetID, mode execute.AccumulationMode, spec plan.ProcedureSpec, a execute.Administration) (execute.Transformation, execute.Dataset, error) {
+	s := spec.(*parallelTestProcedureSpec)
+	d := execute.NewPassthroughDataset(id)
+	return &parallelTestTransformation{d: d, spec: s}, d, nil
+}
+
+type parallelTestTransformation struct {
+	execute.ExecutionNode
+	d    *execute.PassthroughDataset
+	spec *parallelTestProcedureSpec
+}
+
+func (t *parallelTestTransformation) Concurrency() int {
+	return t.spec.concurrency
+}
+
+func (t *parallelTestTransformation) Process(id execute.DatasetID, tbl flux.Table) error {
+	stats := t.spec.stats
+	stats.mu.Lock()
+	stats.active++
+	if stats.active > stats.maxActive {
+		stats.maxActive = stats.active
+	}
+	stats.mu.Unlock()
+	defer func() {
+		stats.mu.Lock()
+		stats.active--
+		stats.processed++
+		stats.mu.Unlock()
+	}()
+
+	time.Sleep(t.spec.delay)
+	tag := tbl.Key().LabelValue("t0").Str()
+	if tag == t.spec.failTag {
+		tbl.Done()
+		return errors.Newf(codes.Invalid, "failed to process %s", tag)
+	}
+
+	buf, err := execute.CopyTable(tbl)
+	if err != nil {
+		return err
+	}
+	first := math.NaN()
+	if err := buf.Do(func(cr flux.ColReader) error {
+		if vs := cr.Floats(execute.ColIdx("_value", cr.Cols())); vs.Len() > 0 && math.IsNaN(first) {
+			first = vs.Value(0)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	stats.mu.Lock()
+	stats.values[tag] = append(stats.values[tag], first)
+	stats.mu.Unlock()
+	return t.d.Process(buf)
+}
+
+func (t *parallelTestTransformation) RetractTable(id execute.DatasetID, key flux.GroupKey) error {
+	return t.d.RetractTable(key)
+}
+
+func (t *parallelTestTransformation) UpdateWatermark(id execute.DatasetID, mark execute.Time) error {
+	return t.d.UpdateWatermark(mark)
+}
+
+func (t *parallelTestTransformation) UpdateProcessingTime(id execute.DatasetID, pt execute.Time) error {
+	return t.d.UpdateProcessingTime(pt)
+}
+
+func (t *parallelTestTransformation) Finish(id execute.DatasetID, err error) {
+	stats := t.spec.stats
+	stats.mu.Lock()
+	stats.processedAtFinish = stats.processed
+	stats.mu.Unlock()
+	t.d.Finish(err)
+}
+
+// parallelTestTables returns a table with n rows for each tag.
+// The values of each table start at its index.
+func parallelTestTables(tags []string, n int) []*executetest.Table {
+	tables := make([]*executetest.Table, len(tags))
+	for i, tag := range tags {
+		tbl := &executetest.Table{
+			KeyCols: []string{"t0"},
+			ColMeta: []flux.ColMeta{
+				{Label: "_time", Type: flux.TTime},
+				{Label: "t0", Type: flux.TString},
+				{Label: "_value", Type: flux.TFloat},
+			},
+		}
+		for j := 0; j < n; j++ {
+			tbl.Data = append(tbl.Data, []interface{}{execute.Time(j), tag, float64(i + j)})
+		}
+		tables[i] = tbl
+	}
+	return tables
+}
+
+// executeParallelTest executes the test transformation on the tables
+// and returns the number of tables in the result.
+func executeParallelTest(tb testing.TB, tables []*executetest.Table, spec *parallelTestProcedureSpec) (int, error) {
+	ps := &plantest.PlanSpec{
+		Nodes: []plan.Node{
+			plan.CreatePhysicalNode("from", executetest.NewFromProcedureSpec(tables)),
+			plan.CreatePhysicalNode("parallel", spec),
+			plan.CreatePhysicalNode("yield", executetest.NewYieldProcedureSpec("_result")),
+		},
+		Edges: [][2]int{
+			{0, 1},
+			{1, 2},
+		},
+		Resources: flux.ResourceManagement{
+			ConcurrencyQuota: 1,
+			MemoryBytesQuota: math.MaxInt64,
+		},
+		Now: time.Now(),
+	}
+
+	exe := execute.NewExecutor(zaptest.NewLogger(tb))
+	ctx, deps := dependency.Inject(context.Background(), executetest.NewTestExecuteDependencies())
+	defer deps.Finish()
+	results, _, err := exe.Execute(ctx, plantest.CreatePlanSpec(ps), executetest.UnlimitedAllocator)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	n := 0
+	err = results["_result"].Tables().Do(func(tbl flux.Table) error {
+		n++
+		return tbl.Do(func(flux.ColReader) error { return nil })
+	})
+	return n, err
+}
+
+func TestParallelTransformation_Concurrency(t *testing.T) {
+	tags := make([]string, 16)
+	for i := range tags {
+		tags[i] = fmt.Sprintf("t%d", i)
+	}
+	for _, concurrency := range []int{1, 4} {
+		t.Run(fmt.Sprint(concurrency), func(t *testing.T) {
+			spec := &parallelTestProcedureSpec{
+				concurrency: concurrency,
+				delay:       10 * time.Millisecond,
+				stats:       newParallelTestStats(),
+			}
+			n, err := executeParallelTest(t, parallelTestTables(tags, 10), spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := len(tags); n != want {
+				t.Errorf("unexpected number of tables -want/+got:\n\t- %d\n\t+ %d", want, n)
+			}
+
+			stats := spec.stats
+			if stats.maxActive > concurrency {
+				t.Errorf("processed %d tables at the same time with a concurrency of %d", stats.maxActive, concurrency)
+			} else if concurrency > 1 && stats.maxActive < 2 {
+				t.Errorf("expected tables to be processed at the same time with a concurrency of %d", concurrency)
+			}
+			if want := len(tags); stats.processedAtFinish != want {
+				t.Errorf("unexpected number of tables processed before finish -want/+got:\n\t- %d\n\t+ %d", want, stats.processedAtFinish)
+			}
+		})
+	}
+}
+
+func TestParallelTransformation_SameKeyOrder(t *testing.T) {
+	// The tables with the same key must be processed
+	// in the order that they were sent.
+	var tags []string
+	for i := 0; i < 8; i++ {
+		tags = append(tags, "a", "b")
+	}
+	spec := &parallelTestProcedureSpec{
+		concurrency: 4,
+		delay:       time.Millisecond,
+		stats:       newParallelTestStats(),
+	}
+	if _, err := executeParallelTest(t, parallelTestTables(tags, 1), spec); err != nil {
+		t.Fatal(err)
+	}
+	for _, tag := range []string{"a", "b"} {
+		vs := spec.stats.values[tag]
+		if len(vs) != 8 {
+			t.Fatalf("unexpected number of tables for %s: %d", tag, len(vs))
+		}
+		for i := 1; i < len(vs); i++ {
+			if vs[i] < vs[i-1] {
+				t.Errorf("tables for %s were processed out of order: %v", tag, vs)
+				break
+			}
+		}
+	}
+}
+
+func TestParallelTransformation_Error(t *testing.T) {
+	tags := make([]string, 16)
+	for i := range tags {
+		tags[i] = fmt.Sprintf("t%d", i)
+	}
+	spec := &parallelTestProcedureSpec{
+		concurrency: 4,
+		failTag:     "t5",
+		stats:       newParallelTestStats(),
+	}
+	_, err := executeParallelTest(t, parallelTestTables(tags, 10), spec)
+	if err == nil {
+		t.Fatal("expected an error, got none")
+	} else if !strings.Contains(err.Error(), "failed to process t5") {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if got := errors.Code(err); got != codes.Invalid {
+		t.Errorf("unexpected error code -want/+got:\n\t- %s\n\t+ %s", codes.Invalid, got)
+	}
+}
+
+func TestTableConcurrency(t *testing.T) {
+	withOption := func(n int) context.Context {
+		deps := execute.DefaultExecutionDependencies()
+		deps.ExecutionOptions.TableConcurrency = n
+		return deps.Inject(context.Background())
+	}
+	for _, tc := range []struct {
+		name string
+		ctx  context.Context
+		want int
+	}{
+		{name: "no dependencies", ctx: context.Background(), want: 1},
+		{name: "not set", ctx: withOption(0), want: 1},
+		{name: "set", ctx: withOption(4), want: 4},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := execute.TableConcurrency(tc.ctx); got != tc.want {
+				t.Errorf("unexpected concurrency -want/+got:\n\t- %d\n\t+ %d", tc.want, got)
+			}
+		})
+	}
+}
+
+func BenchmarkParallelTransformation(b *testing.B) {
+	tags := make([]string, 16)
+	for i := range tags {
+		tags[i] = fmt.Sprintf("t%d", i)
+	}
+	for _, concurrency := range []int{1, 4} {
+		b.Run(fmt.Sprintf("concurrency=%d", concurrency), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				// The tables can only be read once.
+				b.StopTimer()
+				tables := parallelTestTables(tags, 10000)
+				b.StartTimer()
+
+				spec := &parallelTestProcedureSpec{
+					concurrency: concurrency,
+					stats:       newParallelTestStats(),
+				}
+				if _, err := executeParallelTest(b, tables, spec); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
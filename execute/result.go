@@ -1,6 +1,7 @@
 package execute
 
 import (
+	"sort"
 	"sync"
 
 	"github.com/influxdata/flux"
@@ -12,6 +13,18 @@ type result struct {
 	ExecutionNode
 	name string
 
+	// sortTables orders the tables by their group key before
+	// they are returned from Do.
+	sortTables bool
+
+	// schema describes the tables of the result as
+	// far as it is known from the plan.
+	schema flux.ResultSchema
+
+	// metadata is the metadata that the yield
+	// of the result attaches to it.
+	metadata map[string]string
+
 	mu     sync.Mutex
 	tables chan resultMessage
 
@@ -37,6 +50,17 @@ func newResult(name string) *result {
 func (s *result) Name() string {
 	return s.name
 }
+
+// Schema implements flux.SchemaResult.
+func (s *result) Schema() flux.ResultSchema {
+	return s.schema
+}
+
+// Metadata implements flux.MetadataResult.
+func (s *result) Metadata() map[string]string {
+	return s.metadata
+}
+
 func (s *result) RetractTable(DatasetID, flux.GroupKey) error {
 	//TODO implement
 	return nil
@@ -57,6 +81,9 @@ func (s *result) Tables() flux.TableIterator {
 }
 
 func (s *result) Do(f func(flux.Table) error) error {
+	if s.sortTables {
+		return s.doSorted(f)
+	}
 	for {
 		select {
 		case err := <-s.abortErr:
@@ -75,6 +102,47 @@ func (s *result) Do(f func(flux.Table) error) error {
 	}
 }
 
+// doSorted reads all of the tables in the result and
+// then calls f with each table ordered by its group key.
+// The rows within each table are not modified.
+func (s *result) doSorted(f func(flux.Table) error) error {
+	var tables []flux.Table
+	defer func() {
+		// Discard any tables that were not passed to f.
+		for _, tbl := range tables {
+			tbl.Done()
+		}
+	}()
+
+READ:
+	for {
+		select {
+		case err := <-s.abortErr:
+			return err
+		case msg, more := <-s.tables:
+			if !more {
+				break READ
+			}
+			if msg.err != nil {
+				return msg.err
+			}
+			tables = append(tables, msg.table)
+		}
+	}
+
+	sort.SliceStable(tables, func(i, j int) bool {
+		return tables[i].Key().Less(tables[j].Key())
+	})
+	for len(tables) > 0 {
+		tbl := tables[0]
+		tables = tables[1:]
+		if err := f(tbl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (s *result) UpdateWatermark(id DatasetID, mark Time) error {
 	//Nothing to do
 	return nil
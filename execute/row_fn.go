@@ -164,6 +164,8 @@ func ConvertToKind(t flux.ColType) semantic.Nature {
 		return semantic.String
 	case flux.TTime:
 		return semantic.Time
+	case flux.TBytes:
+		return semantic.Bytes
 	default:
 		return semantic.Invalid
 	}
@@ -186,6 +188,8 @@ func ConvertFromKind(k semantic.Nature) flux.ColType {
 		return flux.TString
 	case semantic.Time:
 		return flux.TTime
+	case semantic.Bytes:
+		return flux.TBytes
 	default:
 		return flux.TInvalid
 	}
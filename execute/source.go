@@ -20,6 +20,14 @@ type MetadataNode interface {
 	Metadata() metadata.Metadata
 }
 
+// MetadataTransformation is a transformation that has
+// additional metadata that should be added to the result
+// after all of its input has been processed.
+type MetadataTransformation interface {
+	Transformation
+	Metadata() metadata.Metadata
+}
+
 type Source interface {
 	Node
 	Run(ctx context.Context)
@@ -0,0 +1,136 @@
+package execute
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/internal/errors"
+)
+
+// StateKey identifies the partial state of a transformation
+// for a single group key and window.
+type StateKey struct {
+	// ID identifies the transformation and its configuration
+	// so that different aggregates do not share state.
+	ID       string
+	GroupKey flux.GroupKey
+	Bounds   Bounds
+}
+
+func (k StateKey) String() string {
+	return fmt.Sprintf("%s%v%v", k.ID, k.GroupKey, k.Bounds)
+}
+
+// StateStore persists the partial state of a transformation
+// between query executions. It is used to maintain materialized
+// views incrementally so that each execution only needs to
+// read the data that arrived since the previous one.
+type StateStore interface {
+	// Get returns the state stored for the key.
+	// The boolean reports whether any state was found.
+	Get(ctx context.Context, key StateKey) ([]byte, bool, error)
+
+	// Put replaces the state stored for the key.
+	Put(ctx context.Context, key StateKey, state []byte) error
+}
+
+// StateStoreDependency injects a StateStore into the context.
+type StateStoreDependency struct {
+	StateStore StateStore
+}
+
+func (d StateStoreDependency) Inject(ctx context.Context) context.Context {
+	return context.WithValue(ctx, stateStoreKey, d.StateStore)
+}
+
+// GetStateStore returns the StateStore injected into the context.
+// The boolean is false if no state store was configured.
+func GetStateStore(ctx context.Context) (StateStore, bool) {
+	s, ok := ctx.Value(stateStoreKey).(StateStore)
+	return s, ok && s != nil
+}
+
+// MemoryStateStore is a StateStore that keeps the state in memory.
+type MemoryStateStore struct {
+	mu     sync.RWMutex
+	states map[string][]byte
+}
+
+func NewMemoryStateStore() *MemoryStateStore {
+	return &MemoryStateStore{
+		states: make(map[string][]byte),
+	}
+}
+
+func (s *MemoryStateStore) Get(ctx context.Context, key StateKey) ([]byte, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	state, ok := s.states[key.String()]
+	return state, ok, nil
+}
+
+func (s *MemoryStateStore) Put(ctx context.Context, key StateKey, state []byte) error {
+	buf := make([]byte, len(state))
+	copy(buf, state)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[key.String()] = buf
+	return nil
+}
+
+// FileStateStore is a StateStore that keeps each state
+// in its own file within a directory.
+type FileStateStore struct {
+	dir string
+}
+
+func NewFileStateStore(dir string) *FileStateStore {
+	return &FileStateStore{dir: dir}
+}
+
+func (s *FileStateStore) path(key StateKey) string {
+	sum := sha256.Sum256([]byte(key.String()))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:]))
+}
+
+func (s *FileStateStore) Get(ctx context.Context, key StateKey) ([]byte, bool, error) {
+	state, err := os.ReadFile(s.path(key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, false, nil
+		}
+		return nil, false, errors.Wrap(err, codes.Internal, "failed to read state")
+	}
+	return state, true, nil
+}
+
+func (s *FileStateStore) Put(ctx context.Context, key StateKey, state []byte) error {
+	// Write to a temporary file and rename it so that
+	// a failed write never leaves partial state behind.
+	f, err := os.CreateTemp(s.dir, ".state-")
+	if err != nil {
+		return errors.Wrap(err, codes.Internal, "failed to write state")
+	}
+	if _, err := f.Write(state); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return errors.Wrap(err, codes.Internal, "failed to write state")
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return errors.Wrap(err, codes.Internal, "failed to write state")
+	}
+	if err := os.Rename(f.Name(), s.path(key)); err != nil {
+		_ = os.Remove(f.Name())
+		return errors.Wrap(err, codes.Internal, "failed to write state")
+	}
+	return nil
+}
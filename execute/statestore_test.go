@@ -0,0 +1,85 @@
+package execute_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/values"
+)
+
+func TestStateStore(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		new  func(t *testing.T) execute.StateStore
+	}{
+		{
+			name: "memory",
+			new: func(t *testing.T) execute.StateStore {
+				return execute.NewMemoryStateStore()
+			},
+		},
+		{
+			name: "file",
+			new: func(t *testing.T) execute.StateStore {
+				return execute.NewFileStateStore(t.TempDir())
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := tc.new(t)
+
+			key := func(tag string, start execute.Time) execute.StateKey {
+				return execute.StateKey{
+					ID: "test",
+					GroupKey: execute.NewGroupKey(
+						[]flux.ColMeta{{Label: "t0", Type: flux.TString}},
+						[]values.Value{values.NewString(tag)},
+					),
+					Bounds: execute.Bounds{Start: start, Stop: start + 10},
+				}
+			}
+
+			if _, ok, err := store.Get(ctx, key("a", 0)); err != nil {
+				t.Fatal(err)
+			} else if ok {
+				t.Fatal("expected no state")
+			}
+
+			if err := store.Put(ctx, key("a", 0), []byte("first")); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Put(ctx, key("a", 0), []byte("second")); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Put(ctx, key("b", 0), []byte("other")); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, want := range []struct {
+				key   execute.StateKey
+				state string
+				ok    bool
+			}{
+				{key: key("a", 0), state: "second", ok: true},
+				{key: key("b", 0), state: "other", ok: true},
+				{key: key("a", 10)},
+			} {
+				state, ok, err := store.Get(ctx, want.key)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if ok != want.ok {
+					t.Fatalf("unexpected found for %v: want %v, got %v", want.key, want.ok, ok)
+				}
+				if !cmp.Equal(want.state, string(state)) {
+					t.Fatalf("unexpected state for %v -want/+got:\n%s", want.key, cmp.Diff(want.state, string(state)))
+				}
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package execute
 
 import (
+	"bytes"
 	"fmt"
 	"sort"
 	"sync/atomic"
@@ -191,6 +192,8 @@ func AppendCol(bj, cj int, cr flux.ColReader, builder TableBuilder) error {
 		return builder.AppendStrings(bj, cr.Strings(cj))
 	case flux.TTime:
 		return builder.AppendTimes(bj, cr.Times(cj))
+	case flux.TBytes:
+		return builder.AppendBytesValues(bj, cr.Bytes(cj))
 	default:
 		PanicUnknownType(c.Type)
 	}
@@ -300,6 +303,9 @@ func TablesEqual(left, right flux.Table, alloc memory.Allocator) (bool, error) {
 			case flux.TTime:
 				eq = cmp.Equal(leftBuffer.cols[j].(*timeColumnBuilder).data,
 					rightBuffer.cols[j].(*timeColumnBuilder).data)
+			case flux.TBytes:
+				eq = cmp.Equal(leftBuffer.cols[j].(*bytesColumnBuilder).data,
+					rightBuffer.cols[j].(*bytesColumnBuilder).data)
 			default:
 				PanicUnknownType(c.Type)
 			}
@@ -436,6 +442,11 @@ func ValueForRow(cr flux.ColReader, i, j int) values.Value {
 			return values.NewNull(semantic.BasicTime)
 		}
 		return values.NewTime(values.Time(cr.Times(j).Value(i)))
+	case flux.TBytes:
+		if cr.Bytes(j).IsNull(i) {
+			return values.NewNull(semantic.BasicBytes)
+		}
+		return values.NewBytes(cr.Bytes(j).Value(i))
 	default:
 		PanicUnknownType(t)
 		return values.InvalidValue
@@ -468,6 +479,7 @@ type TableBuilder interface {
 	AppendFloat(j int, value float64) error
 	AppendString(j int, value string) error
 	AppendTime(j int, value Time) error
+	AppendBytes(j int, value []byte) error
 	AppendValue(j int, value values.Value) error
 	AppendNil(j int) error
 
@@ -480,6 +492,7 @@ type TableBuilder interface {
 	AppendFloats(j int, vs *array.Float) error
 	AppendStrings(j int, vs *array.String) error
 	AppendTimes(j int, vs *array.Int) error
+	AppendBytesValues(j int, vs *array.Binary) error
 
 	// TODO(adam): determine if there's a useful API for AppendValues
 	// AppendValues(j int, values []values.Value)
@@ -494,6 +507,7 @@ type TableBuilder interface {
 	GrowFloats(j, n int) error
 	GrowStrings(j, n int) error
 	GrowTimes(j, n int) error
+	GrowBytes(j, n int) error
 
 	// LevelColumns will check for columns that are too short and Grow them
 	// so that each column is of uniform size.
@@ -514,11 +528,12 @@ type TableBuilder interface {
 }
 
 type ColListTableBuilder struct {
-	key     flux.GroupKey
-	colMeta []flux.ColMeta
-	cols    []columnBuilder
-	nrows   int
-	alloc   *Allocator
+	key      flux.GroupKey
+	colMeta  []flux.ColMeta
+	cols     []columnBuilder
+	nrows    int
+	alloc    *Allocator
+	sizeHint int
 }
 
 func NewColListTableBuilder(key flux.GroupKey, a memory.Allocator) *ColListTableBuilder {
@@ -545,6 +560,44 @@ func (b *ColListTableBuilder) Cols() []flux.ColMeta {
 	return b.colMeta
 }
 
+// MemoryUsed returns the number of bytes held by the columns
+// of the builder. Unlike the memory counted by its allocator,
+// it includes the contents of string and bytes values.
+func (b *ColListTableBuilder) MemoryUsed() int64 {
+	var n int64
+	for _, col := range b.cols {
+		n += col.MemoryUsed()
+	}
+	return n
+}
+
+// SizeHint informs the builder that it is expected to hold n rows.
+// The existing columns and any columns added later are allocated
+// with room for n rows so appending to them does not reallocate.
+func (b *ColListTableBuilder) SizeHint(n int) {
+	b.sizeHint = n
+	for _, c := range b.cols {
+		if c.Cap() < n {
+			c.SetCap(n)
+		}
+	}
+}
+
+// shrinkSlack is the number of unused rows a column
+// may hold before it is shrunk when the table is built.
+const shrinkSlack = 64
+
+// shrink reallocates the columns that hold more than twice
+// the capacity they need so that a builder that is kept after
+// its table is built does not retain the over-allocated memory.
+func (b *ColListTableBuilder) shrink() {
+	for _, c := range b.cols {
+		if unused := c.Cap() - b.nrows; unused > shrinkSlack && unused > b.nrows {
+			c.SetCap(b.nrows)
+		}
+	}
+}
+
 func (b *ColListTableBuilder) AddCol(c flux.ColMeta) (int, error) {
 	if ColIdx(c.Label, b.Cols()) >= 0 {
 		return -1, fmt.Errorf("table builder already has column with label %s", c.Label)
@@ -611,10 +664,22 @@ func (b *ColListTableBuilder) AddCol(c flux.ColMeta) (int, error) {
 				return -1, err
 			}
 		}
+	case flux.TBytes:
+		b.cols = append(b.cols, &bytesColumnBuilder{
+			columnBuilderBase: colBase,
+		})
+		if b.NRows() > 0 {
+			if err := b.GrowBytes(newIdx, b.NRows()); err != nil {
+				return -1, err
+			}
+		}
 	default:
 		PanicUnknownType(c.Type)
 	}
 
+	if col := b.cols[newIdx]; col.Cap() < b.sizeHint {
+		col.SetCap(b.sizeHint)
+	}
 	return newIdx, nil
 }
 
@@ -684,6 +749,17 @@ func (b *ColListTableBuilder) LevelColumns() error {
 				}
 			}
 
+			if toGrow < 0 {
+				_ = fmt.Errorf("column %s is longer than expected length of table", c.Label)
+			}
+		case flux.TBytes:
+			toGrow := b.NRows() - b.cols[idx].Len()
+			if toGrow > 0 {
+				if err := b.GrowBytes(idx, toGrow); err != nil {
+					return err
+				}
+			}
+
 			if toGrow < 0 {
 				_ = fmt.Errorf("column %s is longer than expected length of table", c.Label)
 			}
@@ -917,8 +993,10 @@ func (b *ColListTableBuilder) SetString(i int, j int, value string) error {
 	if err := b.checkCol(j, flux.TString); err != nil {
 		return err
 	}
-	b.cols[j].(*stringColumnBuilder).data[i] = value
-	b.cols[j].SetNil(i, false)
+	col := b.cols[j].(*stringColumnBuilder)
+	b.alloc.count(len(value) - len(col.data[i]))
+	col.data[i] = value
+	col.SetNil(i, false)
 	return nil
 }
 
@@ -928,6 +1006,7 @@ func (b *ColListTableBuilder) AppendString(j int, value string) error {
 	}
 	col := b.cols[j].(*stringColumnBuilder)
 	col.data = b.alloc.AppendStrings(col.data, value)
+	b.alloc.count(len(value))
 	b.nrows = len(col.data)
 	return nil
 }
@@ -1021,6 +1100,62 @@ func (b *ColListTableBuilder) GrowTimes(j, n int) error {
 
 }
 
+func (b *ColListTableBuilder) SetBytes(i int, j int, value []byte) error {
+	if err := b.checkCol(j, flux.TBytes); err != nil {
+		return err
+	}
+	col := b.cols[j].(*bytesColumnBuilder)
+	b.alloc.count(cap(value) - cap(col.data[i]))
+	col.data[i] = value
+	col.SetNil(i, false)
+	return nil
+}
+
+func (b *ColListTableBuilder) AppendBytes(j int, value []byte) error {
+	if err := b.checkCol(j, flux.TBytes); err != nil {
+		return err
+	}
+	col := b.cols[j].(*bytesColumnBuilder)
+	col.data = b.alloc.AppendByteSlices(col.data, value)
+	b.alloc.count(cap(value))
+	b.nrows = len(col.data)
+	return nil
+}
+
+func (b *ColListTableBuilder) AppendBytesValues(j int, vs *array.Binary) error {
+	if err := b.checkCol(j, flux.TBytes); err != nil {
+		return err
+	}
+	col := b.cols[j].(*bytesColumnBuilder)
+	for i := 0; i < vs.Len(); i++ {
+		if vs.IsNull(i) {
+			if err := b.AppendNil(j); err != nil {
+				return err
+			}
+		} else if err := b.AppendBytes(j, vs.Value(i)); err != nil {
+			return err
+		}
+	}
+	b.nrows = len(col.data)
+	return nil
+}
+
+func (b *ColListTableBuilder) GrowBytes(j, n int) error {
+	if err := b.checkCol(j, flux.TBytes); err != nil {
+		return err
+	}
+	col := b.cols[j].(*bytesColumnBuilder)
+	i := len(col.data)
+	col.data = b.alloc.GrowByteSlices(col.data, n)
+	b.nrows = len(col.data)
+	for ; i < b.nrows; i++ {
+		if err := b.SetNil(i, j); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (b *ColListTableBuilder) SetValue(i, j int, v values.Value) error {
 	if v.IsNull() {
 		return b.SetNil(i, j)
@@ -1039,6 +1174,8 @@ func (b *ColListTableBuilder) SetValue(i, j int, v values.Value) error {
 		return b.SetString(i, j, v.Str())
 	case semantic.Time:
 		return b.SetTime(i, j, v.Time())
+	case semantic.Bytes:
+		return b.SetBytes(i, j, v.Bytes())
 	default:
 		panic(fmt.Errorf("unexpected value type %v", v.Type()))
 	}
@@ -1062,6 +1199,8 @@ func (b *ColListTableBuilder) AppendValue(j int, v values.Value) error {
 		return b.AppendString(j, v.Str())
 	case semantic.Time:
 		return b.AppendTime(j, v.Time())
+	case semantic.Bytes:
+		return b.AppendBytes(j, v.Bytes())
 	default:
 		panic(fmt.Errorf("unexpected value type %v", v.Type()))
 	}
@@ -1109,6 +1248,10 @@ func (b *ColListTableBuilder) AppendNil(j int) error {
 		if err := b.AppendTime(j, 0); err != nil {
 			return err
 		}
+	case flux.TBytes:
+		if err := b.AppendBytes(j, nil); err != nil {
+			return err
+		}
 	default:
 		panic(fmt.Errorf("unexpected value type %v", typ))
 	}
@@ -1159,6 +1302,10 @@ func (b *ColListTableBuilder) Times(j int) []values.Time {
 	CheckColType(b.colMeta[j], flux.TTime)
 	return b.cols[j].(*timeColumnBuilder).data
 }
+func (b *ColListTableBuilder) Bytes(j int) [][]byte {
+	CheckColType(b.colMeta[j], flux.TBytes)
+	return b.cols[j].(*bytesColumnBuilder).data
+}
 
 // GetRow takes a row index and returns the record located at that index in the cache
 func (b *ColListTableBuilder) GetRow(row int) values.Object {
@@ -1181,6 +1328,8 @@ func (b *ColListTableBuilder) GetRow(row int) values.Object {
 					val = values.NewString(b.cols[j].(*stringColumnBuilder).data[row])
 				case flux.TTime:
 					val = values.NewTime(b.cols[j].(*timeColumnBuilder).data[row])
+				case flux.TBytes:
+					val = values.NewBytes(b.cols[j].(*bytesColumnBuilder).data[row])
 				}
 			}
 			set(col.Label, val)
@@ -1191,6 +1340,7 @@ func (b *ColListTableBuilder) GetRow(row int) values.Object {
 }
 
 func (b *ColListTableBuilder) Table() (flux.Table, error) {
+	b.shrink()
 	t := &ColListTable{
 		key:      b.key,
 		colMeta:  b.colMeta,
@@ -1236,10 +1386,15 @@ func (b *ColListTableBuilder) SliceColumns(start, stop int) error {
 			col.data = col.data[start:stop]
 		case flux.TString:
 			col := b.cols[i].(*stringColumnBuilder)
+			b.alloc.count(-col.contentSize(0, start) - col.contentSize(stop, len(col.data)))
 			col.data = col.data[start:stop]
 		case flux.TTime:
 			col := b.cols[i].(*timeColumnBuilder)
 			col.data = col.data[start:stop]
+		case flux.TBytes:
+			col := b.cols[i].(*bytesColumnBuilder)
+			b.alloc.count(-col.contentSize(0, start) - col.contentSize(stop, len(col.data)))
+			col.data = col.data[start:stop]
 		default:
 			panic(fmt.Errorf("unexpected column type %v", c.Meta().Type))
 		}
@@ -1345,6 +1500,8 @@ func (t *ColListTable) Do(f func(flux.ColReader) error) error {
 				buffer.Values[i] = col.data
 			case *timeColumn:
 				buffer.Values[i] = col.data
+			case *bytesColumn:
+				buffer.Values[i] = col.data
 			default:
 				return errors.Newf(codes.Internal, "unknown column type: %T", col)
 			}
@@ -1386,6 +1543,10 @@ func (t *ColListTable) Times(j int) *array.Int {
 	CheckColType(t.colMeta[j], flux.TTime)
 	return t.cols[j].(*timeColumn).data
 }
+func (t *ColListTable) Bytes(j int) *array.Binary {
+	CheckColType(t.colMeta[j], flux.TBytes)
+	return t.cols[j].(*bytesColumn).data
+}
 
 type colListTableSorter struct {
 	cols []int
@@ -1437,6 +1598,12 @@ type columnBuilder interface {
 	Release()
 	Copy() column
 	Len() int
+	Cap() int
+	// MemoryUsed returns the number of bytes held by the column.
+	MemoryUsed() int64
+	// SetCap reallocates the column with capacity for n rows.
+	// The capacity must not be less than the length.
+	SetCap(n int)
 	IsNil(i int) bool
 	SetNil(i int, isNil bool)
 	Equal(i, j int) bool
@@ -1543,6 +1710,21 @@ func (c *boolColumnBuilder) Release() {
 	c.data = nil
 }
 
+func (c *boolColumnBuilder) Cap() int {
+	return cap(c.data)
+}
+
+func (c *boolColumnBuilder) MemoryUsed() int64 {
+	return int64(cap(c.data) * boolSize)
+}
+
+func (c *boolColumnBuilder) SetCap(n int) {
+	data := c.alloc.Bools(len(c.data), n)
+	copy(data, c.data)
+	c.alloc.Free(cap(c.data), boolSize)
+	c.data = data
+}
+
 func (c *boolColumnBuilder) Copy() column {
 	var data *array.Boolean
 	if len(c.nils) > 0 {
@@ -1628,6 +1810,21 @@ func (c *intColumnBuilder) Release() {
 	c.data = nil
 }
 
+func (c *intColumnBuilder) Cap() int {
+	return cap(c.data)
+}
+
+func (c *intColumnBuilder) MemoryUsed() int64 {
+	return int64(cap(c.data) * int64Size)
+}
+
+func (c *intColumnBuilder) SetCap(n int) {
+	data := c.alloc.Ints(len(c.data), n)
+	copy(data, c.data)
+	c.alloc.Free(cap(c.data), int64Size)
+	c.data = data
+}
+
 func (c *intColumnBuilder) Copy() column {
 	var data *array.Int
 	if len(c.nils) > 0 {
@@ -1710,6 +1907,21 @@ func (c *uintColumnBuilder) Release() {
 	c.data = nil
 }
 
+func (c *uintColumnBuilder) Cap() int {
+	return cap(c.data)
+}
+
+func (c *uintColumnBuilder) MemoryUsed() int64 {
+	return int64(cap(c.data) * uint64Size)
+}
+
+func (c *uintColumnBuilder) SetCap(n int) {
+	data := c.alloc.UInts(len(c.data), n)
+	copy(data, c.data)
+	c.alloc.Free(cap(c.data), uint64Size)
+	c.data = data
+}
+
 func (c *uintColumnBuilder) Copy() column {
 	var data *array.Uint
 	if len(c.nils) > 0 {
@@ -1793,6 +2005,21 @@ func (c *floatColumnBuilder) Release() {
 	c.data = nil
 }
 
+func (c *floatColumnBuilder) Cap() int {
+	return cap(c.data)
+}
+
+func (c *floatColumnBuilder) MemoryUsed() int64 {
+	return int64(cap(c.data) * float64Size)
+}
+
+func (c *floatColumnBuilder) SetCap(n int) {
+	data := c.alloc.Floats(len(c.data), n)
+	copy(data, c.data)
+	c.alloc.Free(cap(c.data), float64Size)
+	c.data = data
+}
+
 func (c *floatColumnBuilder) Copy() column {
 	var data *array.Float
 	if len(c.nils) > 0 {
@@ -1868,14 +2095,42 @@ type stringColumnBuilder struct {
 }
 
 func (c *stringColumnBuilder) Clear() {
+	c.alloc.count(-c.contentSize(0, len(c.data)))
 	c.data = c.data[0:0]
 }
 
 func (c *stringColumnBuilder) Release() {
+	c.alloc.count(-c.contentSize(0, len(c.data)))
 	c.alloc.Free(cap(c.data), stringSize)
 	c.data = nil
 }
 
+// contentSize returns the length of the strings in [start:stop].
+func (c *stringColumnBuilder) contentSize(start, stop int) int {
+	n := 0
+	for _, v := range c.data[start:stop] {
+		n += len(v)
+	}
+	return n
+}
+
+func (c *stringColumnBuilder) Cap() int {
+	return cap(c.data)
+}
+
+// MemoryUsed includes the contents of the strings, which are
+// not counted by the allocator of the builder.
+func (c *stringColumnBuilder) MemoryUsed() int64 {
+	return int64(cap(c.data)*stringSize + c.contentSize(0, len(c.data)))
+}
+
+func (c *stringColumnBuilder) SetCap(n int) {
+	data := c.alloc.Strings(len(c.data), n)
+	copy(data, c.data)
+	c.alloc.Free(cap(c.data), stringSize)
+	c.data = data
+}
+
 func (c *stringColumnBuilder) Copy() column {
 	var data *array.String
 	if len(c.nils) > 0 {
@@ -1966,6 +2221,21 @@ func (c *timeColumnBuilder) Release() {
 	c.data = nil
 }
 
+func (c *timeColumnBuilder) Cap() int {
+	return cap(c.data)
+}
+
+func (c *timeColumnBuilder) MemoryUsed() int64 {
+	return int64(cap(c.data) * timeSize)
+}
+
+func (c *timeColumnBuilder) SetCap(n int) {
+	data := c.alloc.Times(len(c.data), n)
+	copy(data, c.data)
+	c.alloc.Free(cap(c.data), timeSize)
+	c.data = data
+}
+
 func (c *timeColumnBuilder) Copy() column {
 	b := arrow.NewIntBuilder(c.alloc.Allocator)
 	b.Reserve(len(c.data))
@@ -2005,17 +2275,136 @@ func (c *timeColumnBuilder) Swap(i, j int) {
 	c.data[i], c.data[j] = c.data[j], c.data[i]
 }
 
+type bytesColumn struct {
+	flux.ColMeta
+	data *array.Binary
+}
+
+func (c *bytesColumn) Meta() flux.ColMeta {
+	return c.ColMeta
+}
+
+func (c *bytesColumn) Clear() {
+	if c.data != nil {
+		c.data.Release()
+		c.data = nil
+	}
+}
+
+func (c *bytesColumn) Copy() column {
+	c.data.Retain()
+	return &bytesColumn{
+		ColMeta: c.ColMeta,
+		data:    c.data,
+	}
+}
+
+type bytesColumnBuilder struct {
+	columnBuilderBase
+	data [][]byte
+}
+
+func (c *bytesColumnBuilder) Clear() {
+	c.alloc.count(-c.contentSize(0, len(c.data)))
+	c.data = c.data[0:0]
+}
+
+func (c *bytesColumnBuilder) Release() {
+	c.alloc.count(-c.contentSize(0, len(c.data)))
+	c.alloc.Free(cap(c.data), bytesSize)
+	c.data = nil
+}
+
+// contentSize returns the capacity of the byte slices in [start:stop].
+func (c *bytesColumnBuilder) contentSize(start, stop int) int {
+	n := 0
+	for _, v := range c.data[start:stop] {
+		n += cap(v)
+	}
+	return n
+}
+
+func (c *bytesColumnBuilder) Cap() int {
+	return cap(c.data)
+}
+
+// MemoryUsed includes the contents of the byte slices, which are
+// not counted by the allocator of the builder.
+func (c *bytesColumnBuilder) MemoryUsed() int64 {
+	return int64(cap(c.data)*bytesSize + c.contentSize(0, len(c.data)))
+}
+
+func (c *bytesColumnBuilder) SetCap(n int) {
+	data := c.alloc.ByteSlices(len(c.data), n)
+	copy(data, c.data)
+	c.alloc.Free(cap(c.data), bytesSize)
+	c.data = data
+}
+
+func (c *bytesColumnBuilder) Copy() column {
+	b := arrow.NewBytesBuilder(c.alloc.Allocator)
+	b.Reserve(len(c.data))
+	sz := 0
+	for i, v := range c.data {
+		if c.nils[i] {
+			continue
+		}
+		sz += len(v)
+	}
+	b.ReserveData(sz)
+	for i, v := range c.data {
+		if c.nils[i] {
+			b.AppendNull()
+			continue
+		}
+		b.Append(v)
+	}
+	col := &bytesColumn{
+		ColMeta: c.ColMeta,
+		data:    b.NewBinaryArray(),
+	}
+	b.Release()
+	return col
+}
+
+func (c *bytesColumnBuilder) Len() int {
+	return len(c.data)
+}
+
+func (c *bytesColumnBuilder) Equal(i, j int) bool {
+	return c.EqualFunc(i, j, func(i, j int) bool {
+		return bytes.Equal(c.data[i], c.data[j])
+	})
+}
+
+func (c *bytesColumnBuilder) Less(i, j int) bool {
+	return c.LessFunc(i, j, func(i, j int) bool {
+		return bytes.Compare(c.data[i], c.data[j]) < 0
+	})
+}
+
+func (c *bytesColumnBuilder) Swap(i, j int) {
+	c.columnBuilderBase.Swap(i, j)
+	c.data[i], c.data[j] = c.data[j], c.data[i]
+}
+
 type TableBuilderCache interface {
 	// TableBuilder returns an existing or new TableBuilder for the given meta data.
 	// The boolean return value indicates if TableBuilder is new.
 	TableBuilder(key flux.GroupKey) (TableBuilder, bool)
 	ForEachBuilder(f func(flux.GroupKey, TableBuilder) error) error
+	// MemoryUsed returns the number of bytes held by the table builders.
+	MemoryUsed() int64
 }
 
 type tableBuilderCache struct {
 	tables *GroupLookup
 	alloc  memory.Allocator
 
+	// used is the number of bytes held by the table builders.
+	// The builders update it as they grow and are released.
+	used int64
+
 	triggerSpec plan.TriggerSpec
 }
 
@@ -2057,6 +2446,7 @@ func (d *tableBuilderCache) TableBuilder(key flux.GroupKey) (TableBuilder, bool)
 	b, ok := d.lookupState(key)
 	if !ok {
 		builder := NewColListTableBuilder(key, d.alloc)
+		builder.alloc.used = &d.used
 		t := NewTriggerFromSpec(d.triggerSpec)
 		b = tableState{
 			builder: builder,
@@ -2073,6 +2463,13 @@ func (d *tableBuilderCache) ForEachBuilder(f func(flux.GroupKey, TableBuilder) e
 	})
 }
 
+// MemoryUsed returns the number of bytes held by the table builders.
+// The builders count the bytes as they append and release them, so
+// it does not walk the builders.
+func (d *tableBuilderCache) MemoryUsed() int64 {
+	return atomic.LoadInt64(&d.used)
+}
+
 func (d *tableBuilderCache) DiscardTable(key flux.GroupKey) {
 	b, ok := d.lookupState(key)
 	if ok {
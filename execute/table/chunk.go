@@ -31,9 +31,10 @@ func ChunkFromBuffer(buf arrow.TableBuffer) Chunk {
 // unless Retain is called.
 func ChunkFromReader(cr flux.ColReader) Chunk {
 	buf := arrow.TableBuffer{
-		GroupKey: cr.Key(),
-		Columns:  cr.Cols(),
-		Values:   make([]array.Array, len(cr.Cols())),
+		GroupKey:    cr.Key(),
+		Columns:     cr.Cols(),
+		Values:      make([]array.Array, len(cr.Cols())),
+		KeyConstant: arrow.IsKeyConstant(cr),
 	}
 	for j := range buf.Values {
 		buf.Values[j] = Values(cr, j)
@@ -126,6 +127,12 @@ func (v Chunk) Strings(j int) *array.String {
 	return v.Values(j).(*array.String)
 }
 
+// Bytes is a convenience function for retrieving an array
+// as a binary array.
+func (v Chunk) Bytes(j int) *array.Binary {
+	return v.Values(j).(*array.Binary)
+}
+
 // Retain will retain a reference to this Chunk.
 func (v Chunk) Retain() {
 	v.buf.Retain()
@@ -66,6 +66,8 @@ func (s Table) Table(mem memory.Allocator) flux.Table {
 	buffer := &arrow.TableBuffer{
 		GroupKey: key,
 		Columns:  cols,
+		// Key columns are built from a single value.
+		KeyConstant: true,
 	}
 
 	// Determine the size by looking at the first non-key column.
@@ -1,6 +1,7 @@
 package table
 
 import (
+	"encoding/hex"
 	"fmt"
 	"sort"
 	"strings"
@@ -150,6 +151,11 @@ func valueForRow(cr flux.ColReader, i, j int) values.Value {
 			return values.NewNull(semantic.BasicTime)
 		}
 		return values.NewTime(values.Time(cr.Times(j).Value(i)))
+	case flux.TBytes:
+		if cr.Bytes(j).IsNull(i) {
+			return values.NewNull(semantic.BasicBytes)
+		}
+		return values.NewBytes(cr.Bytes(j).Value(i))
 	default:
 		panic(fmt.Errorf("unknown type %v", t))
 	}
@@ -183,6 +189,8 @@ func stringifyValue(sb *strings.Builder, v values.Value) {
 		} else {
 			sb.WriteString(ts.Format(time.RFC3339))
 		}
+	case semantic.Bytes:
+		sb.WriteString(hex.EncodeToString(v.Bytes()))
 	default:
 		sb.WriteString("!(invalid)")
 	}
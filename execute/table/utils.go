@@ -22,6 +22,8 @@ func Values(cr flux.ColReader, j int) array.Array {
 		return cr.Bools(j)
 	case flux.TTime:
 		return cr.Times(j)
+	case flux.TBytes:
+		return cr.Bytes(j)
 	default:
 		panic(errors.Newf(codes.Internal, "unimplemented column type: %s", typ))
 	}
@@ -3,6 +3,7 @@ package execute_test
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -15,6 +16,7 @@ import (
 	"github.com/influxdata/flux/execute/table"
 	"github.com/influxdata/flux/internal/gen"
 	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/plan"
 	"github.com/influxdata/flux/values"
 )
 
@@ -569,6 +571,142 @@ func TestColListTableBuilder_AppendValues(t *testing.T) {
 	}
 }
 
+func TestColListTableBuilder_SizeHint(t *testing.T) {
+	mem := memory.NewResourceAllocator(nil)
+	b := execute.NewColListTableBuilder(execute.NewGroupKey(nil, nil), mem)
+	if _, err := b.AddCol(flux.ColMeta{Label: "_value", Type: flux.TFloat}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The hint allocates room for the expected rows up front
+	// and columns added after the hint are sized the same way.
+	b.SizeHint(100)
+	if _, err := b.AddCol(flux.ColMeta{Label: "_time", Type: flux.TTime}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := mem.Allocated(), int64(2*100*8); got != want {
+		t.Fatalf("unexpected allocated memory after size hint -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+
+	// Appending the expected rows does not allocate any more memory.
+	for i := 0; i < 100; i++ {
+		if err := b.AppendFloat(0, float64(i)); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.AppendTime(1, execute.Time(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := mem.Allocated(), int64(2*100*8); got != want {
+		t.Fatalf("unexpected allocated memory after append -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+
+	tbl, err := b.Table()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tbl.Done()
+	if got, want := mem.Allocated(), int64(2*100*8); got != want {
+		t.Fatalf("unexpected allocated memory after table -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+
+	// Building the table from a heavily over-allocated
+	// builder shrinks the columns to the rows they hold.
+	b.ClearData()
+	b.SizeHint(1000)
+	for i := 0; i < 10; i++ {
+		if err := b.AppendFloat(0, float64(i)); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.AppendTime(1, execute.Time(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tbl, err = b.Table()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tbl.Done()
+	if got, want := mem.Allocated(), int64(2*10*8); got != want {
+		t.Fatalf("unexpected allocated memory after shrink -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+
+	b.Release()
+	if got := mem.Allocated(); got != 0 {
+		t.Fatalf("expected all memory to be released, got %d bytes", got)
+	}
+}
+
+func TestTableBuilderCache_MemoryUsed(t *testing.T) {
+	c := execute.NewTableBuilderCache(memory.NewResourceAllocator(nil))
+	c.SetTriggerSpec(plan.DefaultTriggerSpec)
+	for i, tag := range []string{"a", "b"} {
+		key := execute.NewGroupKey(
+			[]flux.ColMeta{{Label: "t0", Type: flux.TString}},
+			[]values.Value{values.NewString(tag)},
+		)
+		b, _ := c.TableBuilder(key)
+		b.(*execute.ColListTableBuilder).SizeHint(10)
+		if _, err := b.AddCol(flux.ColMeta{Label: "_value", Type: flux.TFloat}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := b.AddCol(flux.ColMeta{Label: "_field", Type: flux.TString}); err != nil {
+			t.Fatal(err)
+		}
+		for j := 0; j < 10; j++ {
+			if err := b.AppendFloat(0, float64(i+j)); err != nil {
+				t.Fatal(err)
+			}
+			if err := b.AppendString(1, strings.Repeat(tag, 100)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// The strings count their headers and their contents.
+	want := int64(2 * (10*8 + 10*16 + 10*100))
+	if got := c.MemoryUsed(); got != want {
+		t.Fatalf("unexpected memory used -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+
+	// The memory used is counted as the builders change,
+	// so it must match the sum of the builders.
+	builders := func() int64 {
+		var n int64
+		_ = c.ForEachBuilder(func(key flux.GroupKey, b execute.TableBuilder) error {
+			n += b.(*execute.ColListTableBuilder).MemoryUsed()
+			return nil
+		})
+		return n
+	}
+	keyA := execute.NewGroupKey(
+		[]flux.ColMeta{{Label: "t0", Type: flux.TString}},
+		[]values.Value{values.NewString("a")},
+	)
+	keyB := execute.NewGroupKey(
+		[]flux.ColMeta{{Label: "t0", Type: flux.TString}},
+		[]values.Value{values.NewString("b")},
+	)
+	b, _ := c.TableBuilder(keyA)
+	if err := b.(*execute.ColListTableBuilder).SetString(0, 1, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := c.MemoryUsed(), builders(); got != want {
+		t.Fatalf("unexpected memory used after set -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+
+	c.DiscardTable(keyA)
+	if got, want := c.MemoryUsed(), builders(); got != want {
+		t.Fatalf("unexpected memory used after discard -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+
+	c.ExpireTable(keyA)
+	c.ExpireTable(keyB)
+	if got := c.MemoryUsed(); got != 0 {
+		t.Fatalf("expected all memory to be released, got %d bytes", got)
+	}
+}
+
 func TestCopyTable_Empty(t *testing.T) {
 	in := &executetest.Table{
 		GroupKey: execute.NewGroupKey(
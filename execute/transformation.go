@@ -23,6 +23,37 @@ type Transformation interface {
 	Finish(id DatasetID, err error)
 }
 
+// ParallelTransformation is a Transformation that can process
+// more than one table at the same time.
+//
+// The executor sends the tables to a pool of Concurrency goroutines.
+// The tables with the same group key are always processed by the same
+// goroutine in the order they were sent, so Process must only be safe
+// to call concurrently for tables with different group keys.
+// Every other method is called once the tables that were sent
+// before it have been processed.
+type ParallelTransformation interface {
+	Transformation
+	// Concurrency returns the number of tables that
+	// may be processed at the same time.
+	// A value less than 2 processes them one at a time.
+	Concurrency() int
+}
+
+// TableConcurrency returns the number of tables that a
+// ParallelTransformation may process at the same time as set by
+// the execution options. It is 1 when the option is not set.
+func TableConcurrency(ctx context.Context) int {
+	if !HaveExecutionDependencies(ctx) {
+		return 1
+	}
+	opts := GetExecutionDependencies(ctx).ExecutionOptions
+	if opts == nil || opts.TableConcurrency < 1 {
+		return 1
+	}
+	return opts.TableConcurrency
+}
+
 // TransformationSet is a group of transformations.
 type TransformationSet []Transformation
 
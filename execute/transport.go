@@ -43,6 +43,7 @@ type consecutiveTransport struct {
 	logger     *zap.Logger
 
 	t         Transport
+	src       DatasetID
 	messages  MessageQueue
 	op, label string
 	stack     []interpreter.StackEntry
@@ -57,14 +58,28 @@ type consecutiveTransport struct {
 
 	initSpanOnce sync.Once
 	span         opentracing.Span
+
+	// pool processes the tables when the transformation
+	// is a ParallelTransformation. It is nil when the
+	// tables are processed one at a time.
+	pool *processPool
+
+	// mem checks the memory held by the dataset of the
+	// transformation. It is nil when the memory is not checked.
+	mem *memoryGuard
 }
 
-func newConsecutiveTransport(ctx context.Context, dispatcher Dispatcher, t Transformation, n plan.Node, logger *zap.Logger, mem memory.Allocator) *consecutiveTransport {
-	return &consecutiveTransport{
+// ErrMemoryLimitExceeded is the error a transformation is finished
+// with when its dataset holds more memory than the query may allocate.
+var ErrMemoryLimitExceeded = errors.New(codes.ResourceExhausted, "memory limit exceeded")
+
+func newConsecutiveTransport(ctx context.Context, dispatcher Dispatcher, t Transformation, src DatasetID, n plan.Node, logger *zap.Logger, mem memory.Allocator) *consecutiveTransport {
+	tr := &consecutiveTransport{
 		ctx:        ctx,
 		dispatcher: dispatcher,
 		logger:     logger,
 		t:          WrapTransformationInTransport(t, mem),
+		src:        src,
 		// TODO(nathanielc): Have planner specify message queue initial buffer size.
 		messages: newMessageQueue(64),
 		op:       OperationType(t),
@@ -72,6 +87,61 @@ func newConsecutiveTransport(ctx context.Context, dispatcher Dispatcher, t Trans
 		stack:    n.CallStack(),
 		finished: make(chan struct{}),
 	}
+	if pt, ok := t.(ParallelTransformation); ok {
+		if n := pt.Concurrency(); n > 1 {
+			tr.pool = newProcessPool(ctx, n, tr.processTable)
+		}
+	}
+	return tr
+}
+
+// memoryGuard limits the memory held by the dataset of a transformation.
+// Every transport that sends messages to the transformation shares the
+// guard, so the memory is never read while another input of the
+// transformation is writing to the dataset.
+type memoryGuard struct {
+	mu    sync.Mutex
+	mem   MemoryUser
+	limit int64
+}
+
+func newMemoryGuard(mem MemoryUser, limit int64) *memoryGuard {
+	return &memoryGuard{mem: mem, limit: limit}
+}
+
+// limitMemory checks the memory held by the dataset of the transformation
+// after each table that it processes. The tables of a ParallelTransformation
+// are processed concurrently by its own goroutines and would have to wait
+// for each other to read the memory, so it is not checked.
+func (t *consecutiveTransport) limitMemory(g *memoryGuard) {
+	if t.pool != nil {
+		return
+	}
+	t.mem = g
+}
+
+// sendMessage sends the message to the transformation. When the memory
+// is limited, the message is sent while holding the lock of the guard
+// and ErrMemoryLimitExceeded is returned when the message was a table
+// and the memory held by the dataset exceeds the limit.
+func (t *consecutiveTransport) sendMessage(m Message) error {
+	if t.mem == nil {
+		return t.t.ProcessMessage(m)
+	}
+	_, isTable := processKey(m)
+
+	t.mem.mu.Lock()
+	defer t.mem.mu.Unlock()
+	if err := t.t.ProcessMessage(m); err != nil {
+		return err
+	}
+	if !isTable {
+		return nil
+	}
+	if used := t.mem.mem.MemoryUsed(); used > t.mem.limit {
+		return errors.Wrapf(ErrMemoryLimitExceeded, codes.Inherit, "%s holds %d bytes with a limit of %d bytes", t.label, used, t.mem.limit)
+	}
+	return nil
 }
 
 func (t *consecutiveTransport) sourceInfo() string {
@@ -95,15 +165,24 @@ func (t *consecutiveTransport) sourceInfo() string {
 	entry := t.stack[0]
 	return fmt.Sprintf("@%s: %s", entry.Location, entry.FunctionName)
 }
+
+// setErr records the error for the transport.
+// Only the first non-nil error is kept so the original
+// cause is the one reported to the upstream dataset.
 func (t *consecutiveTransport) setErr(err error) {
+	if err == nil {
+		return
+	}
 	t.errMu.Lock()
+	defer t.errMu.Unlock()
+	if t.errValue != nil {
+		return
+	}
 	msg := "runtime error"
 	if srcInfo := t.sourceInfo(); srcInfo != "" {
 		msg += " " + srcInfo
 	}
-	err = errors.Wrap(err, codes.Inherit, msg)
-	t.errValue = err
-	t.errMu.Unlock()
+	t.errValue = errors.Wrap(err, codes.Inherit, msg)
 }
 func (t *consecutiveTransport) err() error {
 	t.errMu.Lock()
@@ -241,19 +320,23 @@ PROCESS:
 	for m := t.messages.Pop(); m != nil; m = t.messages.Pop() {
 		atomic.AddInt32(&t.inflight, -1)
 		atomic.AddInt32(&t.totalMsgs, 1)
-		if f, err := t.processMessage(ctx, m); err != nil || f {
+		if f, err := t.dispatchMessage(ctx, m); err != nil || f {
 			// Set the error if there was any
 			t.setErr(err)
 
 			// Transition to the finished state.
 			if t.tryTransition(running, finished) {
+				// Wait for the tables that are still being processed.
+				if t.pool != nil {
+					t.pool.close()
+				}
 				// Call Finish if we have not already
 				if !f {
 					m := &finishMsg{
 						srcMessage: srcMessage(m.SrcDatasetID()),
 						err:        t.err(),
 					}
-					_ = t.t.ProcessMessage(m)
+					_ = t.sendMessage(m)
 				}
 				// We are finished
 				close(t.finished)
@@ -281,13 +364,88 @@ PROCESS:
 	}
 }
 
+// abort finishes the transformation with the error when it has not
+// finished yet. The messages that are still queued are dropped.
+// It must only be called once the dispatcher has stopped, since
+// no other goroutine may be processing the messages then.
+func (t *consecutiveTransport) abort(err error) {
+	for {
+		state := atomic.LoadInt32(&t.schedulerState)
+		if state == finished {
+			return
+		}
+		if t.tryTransition(state, finished) {
+			break
+		}
+	}
+	if t.pool != nil {
+		t.pool.close()
+	}
+	for m := t.messages.Pop(); m != nil; m = t.messages.Pop() {
+		atomic.AddInt32(&t.inflight, -1)
+		m.Ack()
+	}
+
+	t.errMu.Lock()
+	if t.errValue == nil {
+		t.errValue = err
+	}
+	t.errMu.Unlock()
+
+	t.contextWithSpan(t.ctx)
+	_ = t.sendMessage(&finishMsg{
+		srcMessage: srcMessage(t.src),
+		err:        t.err(),
+	})
+	close(t.finished)
+	t.finishSpan(err)
+}
+
+// dispatchMessage processes the message on t or, when t has a pool,
+// sends the tables to the pool to be processed concurrently.
+// Any other message is processed once the pool has processed the
+// tables that were sent before it.
+// The return value is true if the message was a FinishMsg.
+func (t *consecutiveTransport) dispatchMessage(ctx context.Context, m Message) (finished bool, err error) {
+	if t.pool == nil {
+		return t.processMessage(ctx, m)
+	}
+	if key, ok := processKey(m); ok {
+		if err := t.err(); err != nil {
+			m.Ack()
+			return false, err
+		}
+		t.pool.dispatch(ctx, key, m)
+		return false, nil
+	}
+
+	t.pool.wait()
+	if err := t.err(); err != nil {
+		m.Ack()
+		return false, err
+	}
+	return t.processMessage(ctx, m)
+}
+
+// processTable processes a table that was sent to the pool.
+// The table is dropped if processing another table failed.
+func (t *consecutiveTransport) processTable(ctx context.Context, m Message) {
+	if t.err() != nil {
+		m.Ack()
+		return
+	}
+	if _, err := t.processMessage(ctx, m); err != nil {
+		t.setErr(err)
+	}
+}
+
 // processMessage processes the message on t.
 // The return value is true if the message was a FinishMsg.
 func (t *consecutiveTransport) processMessage(ctx context.Context, m Message) (finished bool, err error) {
 	if _, span := StartSpanFromContext(ctx, t.op, t.label); span != nil {
 		defer span.Finish()
 	}
-	if err := t.t.ProcessMessage(m); err != nil {
+	if err := t.sendMessage(m); err != nil {
 		return false, err
 	}
 	finished = isFinishMessage(m)
@@ -702,6 +860,7 @@ func (t *transformationTransportAdapter) OperationType() string {
 }
 
 var _ Transport = (*transportTransformationAdapter)(nil)
+var _ ParallelTransformation = (*transportTransformationAdapter)(nil)
 
 type transportTransformationAdapter struct {
 	Transport
@@ -713,6 +872,17 @@ func NewTransformationFromTransport(t Transport) Transformation {
 	return &transportTransformationAdapter{Transport: t}
 }
 
+// Concurrency returns the concurrency of the Transport
+// if it can process tables concurrently and 1 otherwise.
+func (t *transportTransformationAdapter) Concurrency() int {
+	if pt, ok := t.Transport.(interface {
+		Concurrency() int
+	}); ok {
+		return pt.Concurrency()
+	}
+	return 1
+}
+
 func (t *transportTransformationAdapter) ProcessMessage(m Message) error {
 	switch m := m.(type) {
 	case ProcessMsg:
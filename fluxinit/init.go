@@ -10,7 +10,9 @@ import (
 
 // The FluxInit() function prepares the runtime for compilation and execution
 // of Flux. This is a costly step and should only be performed if the intention
-// is to compile and execute flux code.
+// is to compile and execute flux code. The standard library is compiled when
+// flux is built, so this step loads the embedded packages and validates the
+// builtin values that are registered for them.
 //
 // This package imports the standard library. These modules register themselves
 // in go init() functions. This package must ensure all required standard
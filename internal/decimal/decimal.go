@@ -0,0 +1,107 @@
+// Package decimal rounds floats as the decimal numbers they are displayed as.
+//
+// A float such as 2.675 cannot be represented exactly and is stored as
+// 2.67499999999999982236431605997495353221893310546875. Rounding the
+// stored value would round it down, while a reader of the number expects
+// it to be a tie. The functions in this package round the shortest
+// decimal representation of a float instead, which is the representation
+// used when a float is displayed, and round ties to even.
+package decimal
+
+import (
+	"math"
+	"strconv"
+)
+
+// Round rounds x to the given number of decimal places.
+// A negative number of places rounds to the left of the decimal point.
+// Ties are rounded to even. NaN and ±Inf are returned unchanged.
+func Round(x float64, places int) float64 {
+	if x == 0 || math.IsNaN(x) || math.IsInf(x, 0) {
+		return x
+	}
+	digits, exp := parse(x)
+	// The digits are the significant digits of 0.digits × 10^exp,
+	// so the first exp digits are to the left of the decimal point.
+	return round(x, digits, exp, exp+places)
+}
+
+// RoundSignificant rounds x to the given number of significant digits.
+// Ties are rounded to even. x is returned unchanged if it is NaN or ±Inf,
+// or if n is less than one.
+func RoundSignificant(x float64, n int) float64 {
+	if n < 1 || x == 0 || math.IsNaN(x) || math.IsInf(x, 0) {
+		return x
+	}
+	digits, exp := parse(x)
+	return round(x, digits, exp, n)
+}
+
+// parse returns the significant digits of the shortest decimal
+// representation of x and the exponent where x is 0.digits × 10^exp.
+func parse(x float64) (string, int) {
+	s := strconv.FormatFloat(math.Abs(x), 'e', -1, 64)
+	i := 0
+	for s[i] != 'e' {
+		i++
+	}
+	// The exponent of d.ddd is one less than the exponent of 0.dddd.
+	exp, _ := strconv.Atoi(s[i+1:])
+	digits := s[:1]
+	if i > 1 {
+		digits += s[2:i]
+	}
+	return digits, exp + 1
+}
+
+// round keeps the first n digits of x, which is 0.digits × 10^exp.
+func round(x float64, digits string, exp, n int) float64 {
+	if n >= len(digits) {
+		return x
+	}
+
+	var kept []byte
+	if n < 0 {
+		// The value is less than half of the last digit that is kept.
+		return math.Copysign(0, x)
+	} else if n == 0 {
+		// The value is between zero and one of the last digit that
+		// is kept. It is rounded up if it is more than half, and
+		// it is rounded down to zero, which is even, for a tie.
+		if digits[0] > '5' || digits[0] == '5' && len(digits) > 1 {
+			kept = []byte{'1'}
+		} else {
+			return math.Copysign(0, x)
+		}
+	} else {
+		kept = []byte(digits[:n])
+		rest := digits[n:]
+		// The shortest representation has no trailing zeros so
+		// any digit after the first one makes it more than half.
+		up := rest[0] > '5' ||
+			rest[0] == '5' && (len(rest) > 1 || (kept[n-1]-'0')%2 == 1)
+		if up {
+			kept = increment(kept)
+		}
+	}
+
+	// The kept digits are an integer scaled by the
+	// digits that were removed.
+	s := string(kept) + "e" + strconv.Itoa(exp-n)
+	// Rounding up the largest floats may overflow. The error is ignored
+	// because the value is then ±Inf, the nearest float to the result.
+	v, _ := strconv.ParseFloat(s, 64)
+	return math.Copysign(v, x)
+}
+
+// increment adds one to the decimal integer in digits.
+func increment(digits []byte) []byte {
+	for i := len(digits) - 1; i >= 0; i-- {
+		if digits[i] < '9' {
+			digits[i]++
+			return digits
+		}
+		digits[i] = '0'
+	}
+	return append([]byte{'1'}, digits...)
+}
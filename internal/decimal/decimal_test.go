@@ -0,0 +1,78 @@
+package decimal_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/influxdata/flux/internal/decimal"
+)
+
+func TestRound(t *testing.T) {
+	testCases := []struct {
+		x      float64
+		places int
+		want   float64
+	}{
+		{x: 0.30000000000000004, places: 2, want: 0.3},
+		{x: 0.30000000000000004, places: 15, want: 0.3},
+		{x: 0.30000000000000004, places: 17, want: 0.30000000000000004},
+		{x: 3.14159, places: 2, want: 3.14},
+		{x: 3.14159, places: 0, want: 3},
+		{x: 0.5, places: 0, want: 0},
+		{x: 1.5, places: 0, want: 2},
+		{x: 2.5, places: 0, want: 2},
+		{x: -2.5, places: 0, want: -2},
+		{x: 2.675, places: 2, want: 2.68},
+		{x: 2.665, places: 2, want: 2.66},
+		{x: 1.005, places: 2, want: 1.0},
+		{x: 1.0051, places: 2, want: 1.01},
+		{x: 9.995, places: 2, want: 10},
+		{x: 0.05, places: 1, want: 0},
+		{x: 0.15, places: 1, want: 0.2},
+		{x: 0.06, places: 1, want: 0.1},
+		{x: 0.004, places: 1, want: 0},
+		{x: 125, places: -1, want: 120},
+		{x: 135, places: -1, want: 140},
+		{x: 1250, places: -2, want: 1200},
+		{x: 12.5, places: 5, want: 12.5},
+		{x: 0, places: 2, want: 0},
+		{x: math.MaxFloat64, places: -308, want: math.Inf(1)},
+		{x: math.Inf(1), places: 2, want: math.Inf(1)},
+		{x: math.Inf(-1), places: 2, want: math.Inf(-1)},
+	}
+	for _, tc := range testCases {
+		if got := decimal.Round(tc.x, tc.places); got != tc.want {
+			t.Errorf("Round(%v, %d): want %v, got %v", tc.x, tc.places, tc.want, got)
+		}
+	}
+
+	if got := decimal.Round(math.NaN(), 2); !math.IsNaN(got) {
+		t.Errorf("Round(NaN, 2): want NaN, got %v", got)
+	}
+	if got := decimal.Round(-0.004, 2); got != 0 || !math.Signbit(got) {
+		t.Errorf("Round(-0.004, 2): want -0, got %v", got)
+	}
+}
+
+func TestRoundSignificant(t *testing.T) {
+	testCases := []struct {
+		x    float64
+		n    int
+		want float64
+	}{
+		{x: 0.30000000000000004, n: 15, want: 0.3},
+		{x: 123456, n: 3, want: 123000},
+		{x: 123556, n: 3, want: 124000},
+		{x: 0.00012345, n: 2, want: 0.00012},
+		{x: 0.000125, n: 2, want: 0.00012},
+		{x: 0.000135, n: 2, want: 0.00014},
+		{x: -99.95, n: 3, want: -100},
+		{x: 12.5, n: 0, want: 12.5},
+		{x: 12.5, n: 20, want: 12.5},
+	}
+	for _, tc := range testCases {
+		if got := decimal.RoundSignificant(tc.x, tc.n); got != tc.want {
+			t.Errorf("RoundSignificant(%v, %d): want %v, got %v", tc.x, tc.n, tc.want, got)
+		}
+	}
+}
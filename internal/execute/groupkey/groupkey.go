@@ -1,6 +1,7 @@
 package groupkey
 
 import (
+	"bytes"
 	"fmt"
 	"sort"
 	"strings"
@@ -144,6 +145,12 @@ func (k *groupKey) hash64() (h uint64) {
 			case flux.TTime:
 				arrow.Int64Traits.PutValue(data[:], int64(v.Time()))
 				_, _ = hash.Write(data[:arrow.Int64SizeBytes])
+			case flux.TBytes:
+				_, _ = hash.Write(v.Bytes())
+			default:
+				// Composite values such as records and arrays
+				// do not have a column type of their own.
+				values.WriteCanonical(hash, v)
 			}
 		} else {
 			// Write an invalid byte if there is a null value
@@ -210,6 +217,14 @@ func groupKeyEqual(a *groupKey, other flux.GroupKey, truenulls bool) bool {
 			if a.ValueTime(idx) != b.ValueTime(jdx) {
 				return false
 			}
+		case flux.TBytes:
+			if !bytes.Equal(a.values[idx].Bytes(), b.values[jdx].Bytes()) {
+				return false
+			}
+		default:
+			if !values.DeepEqual(a.values[idx], b.values[jdx]) {
+				return false
+			}
 		}
 	}
 	return true
@@ -280,6 +295,19 @@ func groupKeyLess(a *groupKey, other flux.GroupKey) bool {
 			if av, bv := a.ValueTime(idx), b.ValueTime(jdx); av != bv {
 				return av < bv
 			}
+		case flux.TBytes:
+			if c := bytes.Compare(a.values[idx].Bytes(), b.values[jdx].Bytes()); c != 0 {
+				return c < 0
+			}
+		default:
+			// There is no natural order for composite values
+			// so order them by their canonical encoding.
+			var av, bv bytes.Buffer
+			values.WriteCanonical(&av, a.values[idx])
+			values.WriteCanonical(&bv, b.values[jdx])
+			if c := bytes.Compare(av.Bytes(), bv.Bytes()); c != 0 {
+				return c < 0
+			}
 		}
 	}
 
@@ -164,6 +164,46 @@ func TestGroupKey_Equal(t *testing.T) {
 			),
 			want: false,
 		},
+		{
+			name: "Record_DifferentOrder",
+			left: execute.NewGroupKey(
+				[]flux.ColMeta{
+					{Label: "meta", Type: flux.TInvalid},
+				},
+				[]values.Value{
+					mustBuildObject("host", values.NewString("a"), "region", values.NewString("west")),
+				},
+			),
+			right: execute.NewGroupKey(
+				[]flux.ColMeta{
+					{Label: "meta", Type: flux.TInvalid},
+				},
+				[]values.Value{
+					mustBuildObject("region", values.NewString("west"), "host", values.NewString("a")),
+				},
+			),
+			want: true,
+		},
+		{
+			name: "Record_NotEqual",
+			left: execute.NewGroupKey(
+				[]flux.ColMeta{
+					{Label: "meta", Type: flux.TInvalid},
+				},
+				[]values.Value{
+					mustBuildObject("host", values.NewString("a"), "region", values.NewString("west")),
+				},
+			),
+			right: execute.NewGroupKey(
+				[]flux.ColMeta{
+					{Label: "meta", Type: flux.TInvalid},
+				},
+				[]values.Value{
+					mustBuildObject("host", values.NewString("a"), "region", values.NewString("east")),
+				},
+			),
+			want: false,
+		},
 	} {
 		t.Run(tt.name, func(t *testing.T) {
 			if want, got := tt.want, tt.left.Equal(tt.right); want != got {
@@ -342,3 +382,16 @@ func TestGroupKey_Less(t *testing.T) {
 		})
 	}
 }
+
+func mustBuildObject(kvs ...interface{}) values.Value {
+	obj, err := values.BuildObject(func(set values.ObjectSetter) error {
+		for i := 0; i < len(kvs); i += 2 {
+			set(kvs[i].(string), kvs[i+1].(values.Value))
+		}
+		return nil
+	})
+	if err != nil {
+		panic(err)
+	}
+	return obj
+}
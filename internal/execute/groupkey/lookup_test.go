@@ -382,6 +382,51 @@ func TestRandomAccessGroupLookup_LookupOrSet(t *testing.T) {
 	})
 }
 
+func testGroupLookup_RecordKey(t *testing.T, l table.KeyLookup) {
+	t.Helper()
+	cols := []flux.ColMeta{
+		{Label: "meta", Type: flux.TInvalid},
+	}
+	keys := []flux.GroupKey{
+		execute.NewGroupKey(cols, []values.Value{
+			mustBuildObject("host", values.NewString("a"), "region", values.NewString("west")),
+		}),
+		execute.NewGroupKey(cols, []values.Value{
+			mustBuildObject("host", values.NewString("b"), "region", values.NewString("west")),
+		}),
+		execute.NewGroupKey(cols, []values.Value{
+			mustBuildObject("region", values.NewString("west"), "host", values.NewString("a")),
+		}),
+	}
+
+	tables := 0
+	for _, key := range keys {
+		if _, ok := l.Lookup(key); !ok {
+			l.Set(key, tables)
+			tables++
+		}
+	}
+	if want, got := 2, tables; want != got {
+		t.Fatalf("unexpected number of tables -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+
+	v, ok := l.Lookup(keys[2])
+	if !ok {
+		t.Fatalf("key lookup failed: %s", keys[2])
+	}
+	if want, got := 0, v.(int); want != got {
+		t.Errorf("record key with a different property order was assigned to table %d, want %d", got, want)
+	}
+}
+
+func TestGroupLookup_RecordKey(t *testing.T) {
+	testGroupLookup_RecordKey(t, execute.NewGroupLookup())
+}
+
+func TestRandomAccessGroupLookup_RecordKey(t *testing.T) {
+	testGroupLookup_RecordKey(t, execute.NewRandomAccessGroupLookup())
+}
+
 func benchmarkGroupLookup_LookupOrSet(b *testing.B, fn func() table.KeyLookup) {
 	b.Helper()
 	testGroupLookupHelper(b, func(name string, keys []flux.GroupKey) {
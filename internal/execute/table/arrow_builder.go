@@ -17,6 +17,11 @@ type ArrowBuilder struct {
 	Columns   []flux.ColMeta
 	Builders  []array.Builder
 	Allocator memory.Allocator
+
+	// SizeHint is the number of rows the builder is expected to hold.
+	// Columns are created with room for at least this many rows so
+	// they do not have to be grown while values are appended.
+	SizeHint int
 }
 
 // NewArrowBuilder constructs a new ArrowBuilder.
@@ -49,6 +54,9 @@ func (a *ArrowBuilder) Init(cols []flux.ColMeta) {
 	a.Builders = make([]array.Builder, len(cols))
 	for i, col := range cols {
 		a.Builders[i] = arrow.NewBuilder(col.Type, a.Allocator)
+		if a.SizeHint > 0 {
+			a.Builders[i].Reserve(a.SizeHint)
+		}
 	}
 }
 
@@ -90,13 +98,18 @@ func (a *ArrowBuilder) AddCol(c flux.ColMeta) (int, error) {
 		}
 	}
 
-	// Create a builder and append null values to match the default size.
+	// Create a builder with room for the expected number of rows
+	// and append null values to match the default size.
 	b := arrow.NewBuilder(c.Type, mem)
-	if n > 0 {
-		b.Reserve(n)
-		for i := 0; i < n; i++ {
-			b.AppendNull()
-		}
+	sz := n
+	if sz < a.SizeHint {
+		sz = a.SizeHint
+	}
+	if sz > 0 {
+		b.Reserve(sz)
+	}
+	for i := 0; i < n; i++ {
+		b.AppendNull()
 	}
 	a.Columns = append(a.Columns, c)
 	a.Builders = append(a.Builders, b)
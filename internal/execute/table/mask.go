@@ -102,6 +102,7 @@ func (m *maskTableView) UInts(j int) *array.Uint     { return m.reader.UInts(j +
 func (m *maskTableView) Floats(j int) *array.Float   { return m.reader.Floats(j + m.offsets[j]) }
 func (m *maskTableView) Strings(j int) *array.String { return m.reader.Strings(j + m.offsets[j]) }
 func (m *maskTableView) Times(j int) *array.Int      { return m.reader.Times(j + m.offsets[j]) }
+func (m *maskTableView) Bytes(j int) *array.Binary   { return m.reader.Bytes(j + m.offsets[j]) }
 func (m *maskTableView) Retain()                     { m.reader.Retain() }
 func (m *maskTableView) Release()                    { m.reader.Release() }
 
@@ -0,0 +1,100 @@
+// Package hll implements a HyperLogLog sketch to estimate
+// the number of distinct values in a stream using a fixed
+// amount of memory.
+package hll
+
+import (
+	"hash/fnv"
+	"math"
+	"math/bits"
+)
+
+// precision is the number of bits of a hash used to select
+// a register. The sketch uses 2^precision one byte registers
+// and has a standard error of about 1.04/sqrt(2^precision),
+// which is 1.6%.
+const precision = 12
+
+// Sketch estimates the number of distinct values added to it.
+// The zero value is not usable; create a Sketch with New.
+type Sketch struct {
+	registers []uint8
+}
+
+// New creates an empty Sketch.
+func New() *Sketch {
+	return &Sketch{
+		registers: make([]uint8, 1<<precision),
+	}
+}
+
+// AddUint64 adds a 64 bit value to the sketch.
+// Values of other numeric types should be converted
+// to their bit representation before they are added.
+func (s *Sketch) AddUint64(v uint64) {
+	s.add(mix(v))
+}
+
+// AddString adds a string to the sketch.
+func (s *Sketch) AddString(v string) {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(v))
+	s.add(mix(h.Sum64()))
+}
+
+func (s *Sketch) add(h uint64) {
+	idx := h >> (64 - precision)
+	// Set the bit after the index bits so the rank
+	// is bounded when the remaining bits are all zero.
+	w := h<<precision | 1<<(precision-1)
+	rank := uint8(bits.LeadingZeros64(w) + 1)
+	if rank > s.registers[idx] {
+		s.registers[idx] = rank
+	}
+}
+
+// Merge adds the values of another sketch to this sketch.
+func (s *Sketch) Merge(other *Sketch) {
+	for i, r := range other.registers {
+		if r > s.registers[i] {
+			s.registers[i] = r
+		}
+	}
+}
+
+// Count returns the estimated number of distinct values
+// added to the sketch.
+func (s *Sketch) Count() uint64 {
+	m := float64(len(s.registers))
+	var (
+		sum   float64
+		zeros int
+	)
+	for _, r := range s.registers {
+		sum += 1 / float64(uint64(1)<<r)
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	alpha := 0.7213 / (1 + 1.079/m)
+	estimate := alpha * m * m / sum
+	if estimate <= 2.5*m && zeros > 0 {
+		// Use linear counting for small cardinalities
+		// where the raw estimate is biased.
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return uint64(estimate + 0.5)
+}
+
+// mix spreads the bits of v over the whole word so that
+// values that only differ in a few bits, such as consecutive
+// integers, land in different registers.
+func mix(v uint64) uint64 {
+	v ^= v >> 33
+	v *= 0xff51afd7ed558ccd
+	v ^= v >> 33
+	v *= 0xc4ceb9fe1a85ec53
+	v ^= v >> 33
+	return v
+}
@@ -0,0 +1,45 @@
+package hll_test
+
+import (
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/influxdata/flux/internal/hll"
+)
+
+func TestSketch_Small(t *testing.T) {
+	s := hll.New()
+	for i := 0; i < 3; i++ {
+		for _, v := range []string{"a", "b", "c", "d", "e"} {
+			s.AddString(v)
+		}
+	}
+	if got, want := s.Count(), uint64(5); got != want {
+		t.Fatalf("unexpected count -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+}
+
+func TestSketch_Large(t *testing.T) {
+	const n = 100000
+	s := hll.New()
+	for i := 0; i < n; i++ {
+		s.AddUint64(uint64(i))
+		s.AddUint64(uint64(i))
+	}
+	if got := s.Count(); math.Abs(float64(got)-n)/n > 0.05 {
+		t.Fatalf("estimate %d is not within 5%% of %d", got, n)
+	}
+}
+
+func TestSketch_Merge(t *testing.T) {
+	a, b := hll.New(), hll.New()
+	for i := 0; i < 1000; i++ {
+		a.AddString(strconv.Itoa(i))
+		b.AddString(strconv.Itoa(i + 500))
+	}
+	a.Merge(b)
+	if got := a.Count(); math.Abs(float64(got)-1500)/1500 > 0.05 {
+		t.Fatalf("estimate %d is not within 5%% of %d", got, 1500)
+	}
+}
@@ -18,6 +18,10 @@ const (
 	PackageMain = "main"
 	NowPkg      = "universe"
 	NowOption   = "now"
+
+	// LocationOption is the option that sets the default
+	// location of the functions that work with time.
+	LocationOption = "location"
 )
 
 // This interface is used by the interpreter to set options that are relevant
@@ -665,6 +669,18 @@ func functionName(call *semantic.CallExpression) string {
 	}
 }
 
+// qualifiedFunctionName returns the name of the called function
+// including the package name when it is called as a member of
+// an imported package.
+func qualifiedFunctionName(call *semantic.CallExpression) string {
+	if callee, ok := call.Callee.(*semantic.MemberExpression); ok {
+		if obj, ok := callee.Object.(*semantic.IdentifierExpression); ok {
+			return obj.Name.Name() + "." + callee.Property.Name()
+		}
+	}
+	return functionName(call)
+}
+
 // DoFunctionCall will call DoFunctionCallContext with a background context.
 func DoFunctionCall(f func(args Arguments) (values.Value, error), argsObj values.Object) (values.Value, error) {
 	return DoFunctionCallContext(func(_ context.Context, args Arguments) (values.Value, error) {
@@ -677,9 +693,11 @@ func DoFunctionCall(f func(args Arguments) (values.Value, error), argsObj values
 // value from the function.
 //
 // This function verifies that all of the arguments have been consumed
-// by the function call.
+// by the function call. Errors for missing or invalid arguments include
+// the name and location of the call from the context.
 func DoFunctionCallContext(f func(ctx context.Context, args Arguments) (values.Value, error), ctx context.Context, argsObj values.Object) (values.Value, error) {
-	args := NewArguments(argsObj)
+	args := newArguments(argsObj)
+	args.callee, args.loc = currentCall(ctx)
 	v, err := f(ctx, args)
 	if err != nil {
 		return nil, err
@@ -720,12 +738,14 @@ func (itrp *Interpreter) doCall(ctx context.Context, call *semantic.CallExpressi
 	// arguments as this source location information is only
 	// for the currently called function.
 	fname := functionName(call)
-	ctx = withStackEntry(ctx, fname, call.Location())
+	ctx = withStackEntry(ctx, fname, qualifiedFunctionName(call), call.Location())
 	value, err := f.Call(ctx, argObj)
 	if err != nil {
 		// If a function has an underscore as a prefix, consider it
 		// as an internal call and don't add it to the error message.
-		if !strings.HasPrefix(fname, "_") {
+		// Errors with the arguments already identify the call.
+		var argErr *argumentError
+		if !strings.HasPrefix(fname, "_") && !(errors.As(err, &argErr) && argErr.loc == call.Location()) {
 			err = errors.Wrapf(err, codes.Inherit, "error calling function %q @%s", fname, call.Location())
 		}
 		return nil, err
@@ -1427,6 +1447,11 @@ type Arguments interface {
 type arguments struct {
 	obj  values.Object
 	used map[string]bool
+
+	// callee and loc identify the function call
+	// in errors if they are known.
+	callee string
+	loc    ast.SourceLocation
 }
 
 func newArguments(obj values.Object) *arguments {
@@ -1460,7 +1485,7 @@ func (a *arguments) GetRequired(name string) (values.Value, error) {
 	a.used[name] = true
 	v, ok := a.obj.Get(name)
 	if !ok {
-		return nil, errors.Newf(codes.Invalid, "missing required keyword argument %q", name)
+		return nil, a.errorf("missing required keyword argument %q", name)
 	}
 	return v, nil
 }
@@ -1653,16 +1678,45 @@ func (a *arguments) get(name string, kind semantic.Nature, required bool) (value
 	v, ok := a.obj.Get(name)
 	if !ok {
 		if required {
-			return nil, false, errors.Newf(codes.Invalid, "missing required keyword argument %q", name)
+			return nil, false, a.errorf("missing required keyword argument %q", name)
 		}
 		return nil, false, nil
 	}
 	if v.Type().Nature() != kind {
-		return nil, true, errors.Newf(codes.Invalid, "keyword argument %q should be of kind %v, but got %v", name, kind, v.Type().Nature())
+		return nil, true, a.errorf("keyword argument %q should be of kind %v, but got %v", name, kind, v.Type().Nature())
 	}
 	return v, true, nil
 }
 
+// errorf returns an invalid argument error. If the call is known,
+// the message is prefixed with the function name and suffixed
+// with the location of the call.
+func (a *arguments) errorf(format string, args ...interface{}) error {
+	msg := fmt.Sprintf(format, args...)
+	if a.callee == "" {
+		return errors.New(codes.Invalid, msg)
+	}
+	return &errors.Error{
+		Code: codes.Invalid,
+		Err: &argumentError{
+			msg: fmt.Sprintf("%s: %s @%s", a.callee, msg, a.loc),
+			loc: a.loc,
+		},
+	}
+}
+
+// argumentError is an error with the arguments of a function call.
+// It records the location of the call so the interpreter does not
+// report the call again when the error is returned from it.
+type argumentError struct {
+	msg string
+	loc ast.SourceLocation
+}
+
+func (e *argumentError) Error() string {
+	return e.msg
+}
+
 func (a *arguments) listUnused() []string {
 	var unused []string
 	if a.obj != nil {
@@ -1692,6 +1746,10 @@ type stackElement struct {
 	parent *stackElement
 	entry  StackEntry
 	depth  int
+
+	// qualifiedName is the function name including
+	// the package it was called from.
+	qualifiedName string
 }
 
 // Stack retrieves the call stack for a given context.
@@ -1713,14 +1771,27 @@ func Stack(ctx context.Context) []StackEntry {
 	return stack
 }
 
+// currentCall returns the qualified name and location of the innermost
+// function call in the context that is not an internal function.
+func currentCall(ctx context.Context) (string, ast.SourceLocation) {
+	e, _ := ctx.Value(callStackKey).(*stackElement)
+	for ; e != nil; e = e.parent {
+		if !strings.HasPrefix(e.entry.FunctionName, "_") {
+			return e.qualifiedName, e.entry.Location
+		}
+	}
+	return "", ast.SourceLocation{}
+}
+
 // withStackEntry will attach StackEntry information
 // to the context to be retrieved by Stack.
-func withStackEntry(ctx context.Context, name string, loc ast.SourceLocation) context.Context {
+func withStackEntry(ctx context.Context, name, qualifiedName string, loc ast.SourceLocation) context.Context {
 	stack := &stackElement{
 		entry: StackEntry{
 			FunctionName: name,
 			Location:     loc,
 		},
+		qualifiedName: qualifiedName,
 	}
 	if parent := ctx.Value(callStackKey); parent != nil {
 		stack.parent = parent.(*stackElement)
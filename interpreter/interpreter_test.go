@@ -12,6 +12,7 @@ import (
 	"github.com/influxdata/flux/dependencies/dependenciestest"
 	"github.com/influxdata/flux/dependency"
 	"github.com/influxdata/flux/execute/executetest"
+	"github.com/influxdata/flux/internal/errors"
 	"github.com/influxdata/flux/interpreter"
 	"github.com/influxdata/flux/repl"
 	"github.com/influxdata/flux/runtime"
@@ -883,8 +884,8 @@ func TestStack(t *testing.T) {
 			FunctionName: "window",
 			Location: ast.SourceLocation{
 				File:   "universe.flux",
-				Start:  ast.Position{Line: 3696, Column: 12},
-				End:    ast.Position{Line: 3696, Column: 51},
+				Start:  ast.Position{Line: 3900, Column: 12},
+				End:    ast.Position{Line: 3900, Column: 51},
 				Source: `window(every: inf, timeColumn: timeDst)`,
 			},
 		},
@@ -902,3 +903,21 @@ func TestStack(t *testing.T) {
 		t.Fatalf("unexpected stack -want/+got:\n%s", cmp.Diff(want, got))
 	}
 }
+
+func TestArgumentError(t *testing.T) {
+	src := `a = from(bucket: "telegraf") |> range(start: -5m)
+join(tables: {a: a, b: a})`
+	ctx, deps := dependency.Inject(context.Background(), dependenciestest.Default())
+	defer deps.Finish()
+
+	_, _, err := runtime.Eval(ctx, src)
+	if err == nil {
+		t.Fatal("expected error, got none")
+	}
+	if want, got := codes.Invalid, errors.Code(err); want != got {
+		t.Errorf("unexpected error code -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+	if want, got := `join: missing required keyword argument "on" @2:1-2:27`, err.Error(); want != got {
+		t.Errorf("unexpected error message -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+}
@@ -1,6 +1,8 @@
 package interval
 
 import (
+	"time"
+
 	"github.com/influxdata/flux/codes"
 	"github.com/influxdata/flux/internal/errors"
 	"github.com/influxdata/flux/internal/zoneinfo"
@@ -65,6 +67,26 @@ func NewWindowInLocation(every, period, offset values.Duration, loc Location) (W
 	return w, nil
 }
 
+// WeekStartOffset returns the offset adjusted so that windows whose every
+// duration is a whole number of weeks start on the given weekday.
+// Windows are otherwise aligned to the epoch, which was a Thursday.
+// The offset is returned unchanged if every is not a whole number
+// of weeks or if the offset has a month component.
+//
+// The adjustment is a whole number of days which is exact in any
+// location because window boundaries are computed in local clock time.
+func WeekStartOffset(every, offset values.Duration, weekStart time.Weekday) values.Duration {
+	const week = int64(7 * 24 * time.Hour)
+	if !every.NanoOnly() || every.Nanoseconds()%week != 0 || offset.Months() != 0 {
+		return offset
+	}
+	days := (int(weekStart) - int(epoch.Time().Weekday()) + 7) % 7
+	if days == 0 {
+		return offset
+	}
+	return values.ConvertDurationNsecs(offset.Duration() + time.Duration(days)*24*time.Hour)
+}
+
 // IsZero checks if the window's every duration is zero
 func (w Window) IsZero() bool {
 	return w.every.IsZero()
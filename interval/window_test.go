@@ -1376,6 +1376,146 @@ func TestWindow_GetOverlappingBounds_InLocation(t *testing.T) {
 	}
 }
 
+func TestWindow_WeekStart(t *testing.T) {
+	var testcases = []struct {
+		name      string
+		loc       string
+		every     string
+		weekStart time.Weekday
+		start     string
+		stop      string
+		want      []string
+	}{
+		{
+			// Daylight saving time starts on 2021-03-14.
+			name:      "monday US_Eastern DST",
+			loc:       "America/New_York",
+			every:     "1w",
+			weekStart: time.Monday,
+			start:     "2021-03-01T00:00:00-05:00",
+			stop:      "2021-04-01T00:00:00-04:00",
+			want: []string{
+				"2021-03-01T00:00:00-05:00",
+				"2021-03-08T00:00:00-05:00",
+				"2021-03-15T00:00:00-04:00",
+				"2021-03-22T00:00:00-04:00",
+				"2021-03-29T00:00:00-04:00",
+			},
+		},
+		{
+			name:      "sunday US_Eastern DST",
+			loc:       "America/New_York",
+			every:     "1w",
+			weekStart: time.Sunday,
+			start:     "2021-03-01T00:00:00-05:00",
+			stop:      "2021-04-01T00:00:00-04:00",
+			want: []string{
+				"2021-02-28T00:00:00-05:00",
+				"2021-03-07T00:00:00-05:00",
+				"2021-03-14T00:00:00-05:00",
+				"2021-03-21T00:00:00-04:00",
+				"2021-03-28T00:00:00-04:00",
+			},
+		},
+		{
+			// Daylight saving time ends on 2021-10-31.
+			name:      "monday Europe_Berlin DST",
+			loc:       "Europe/Berlin",
+			every:     "1w",
+			weekStart: time.Monday,
+			start:     "2021-10-01T00:00:00+02:00",
+			stop:      "2021-11-01T00:00:00+01:00",
+			want: []string{
+				"2021-09-27T00:00:00+02:00",
+				"2021-10-04T00:00:00+02:00",
+				"2021-10-11T00:00:00+02:00",
+				"2021-10-18T00:00:00+02:00",
+				"2021-10-25T00:00:00+02:00",
+			},
+		},
+		{
+			name:      "sunday two weeks UTC",
+			loc:       "UTC",
+			every:     "2w",
+			weekStart: time.Sunday,
+			start:     "2021-03-01T00:00:00Z",
+			stop:      "2021-04-01T00:00:00Z",
+			want: []string{
+				"2021-02-21T00:00:00Z",
+				"2021-03-07T00:00:00Z",
+				"2021-03-21T00:00:00Z",
+			},
+		},
+		{
+			name:      "thursday is the epoch",
+			loc:       "UTC",
+			every:     "1w",
+			weekStart: time.Thursday,
+			start:     "2021-03-01T00:00:00Z",
+			stop:      "2021-03-15T00:00:00Z",
+			want: []string{
+				"2021-02-25T00:00:00Z",
+				"2021-03-04T00:00:00Z",
+				"2021-03-11T00:00:00Z",
+			},
+		},
+		{
+			name:      "ignored without weeks",
+			loc:       "UTC",
+			every:     "1d",
+			weekStart: time.Monday,
+			start:     "2021-03-01T00:00:00Z",
+			stop:      "2021-03-03T00:00:00Z",
+			want: []string{
+				"2021-03-01T00:00:00Z",
+				"2021-03-02T00:00:00Z",
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			loc, err := interval.LoadLocation(tc.loc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			every, err := values.ParseDuration(tc.every)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			offset := interval.WeekStartOffset(every, values.Duration{}, tc.weekStart)
+			window, err := interval.NewWindowInLocation(every, every, offset, loc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			start := values.Time(mustTimeInLocation(t, tc.start, tc.loc))
+			stop := values.Time(mustTimeInLocation(t, tc.stop, tc.loc))
+			bounds := window.GetOverlappingBounds(start, stop)
+
+			timeLoc, err := time.LoadLocation(tc.loc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, len(bounds))
+			for i, b := range bounds {
+				ts := b.Start().Time().In(timeLoc)
+				if every.Nanoseconds()%int64(7*24*time.Hour) == 0 && ts.Weekday() != tc.weekStart {
+					t.Errorf("window starting at %s does not start on %s", ts, tc.weekStart)
+				}
+				// The bounds are in decreasing order.
+				got[len(bounds)-1-i] = ts.Format(time.RFC3339)
+			}
+			if !cmp.Equal(tc.want, got) {
+				t.Errorf("got unexpected window starts; -want/+got:\n%v\n", cmp.Diff(tc.want, got))
+			}
+		})
+	}
+}
+
 func TestWindow_NextBounds(t *testing.T) {
 	testcases := []struct {
 		name string
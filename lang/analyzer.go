@@ -0,0 +1,134 @@
+package lang
+
+import (
+	"context"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/plan"
+	"github.com/influxdata/flux/runtime"
+)
+
+// QueryAnalyzer plans queries and estimates their cost without executing them.
+type QueryAnalyzer struct {
+	// Runtime is used to compile the queries.
+	// The default runtime is used when it is nil.
+	Runtime flux.Runtime
+
+	// EstimatedBytesPerSecond is the number of bytes that a read is
+	// estimated to scan for each second of its time range. It can be
+	// set to match the cardinality of the data that is usually read.
+	// The reads use their own estimate when it is zero.
+	EstimatedBytesPerSecond int64
+}
+
+// NewQueryAnalyzer creates a QueryAnalyzer that uses the default runtime
+// and plan.DefaultEstimatedBytesPerSecond to estimate the data that is read.
+func NewQueryAnalyzer() *QueryAnalyzer {
+	return &QueryAnalyzer{
+		EstimatedBytesPerSecond: plan.DefaultEstimatedBytesPerSecond,
+	}
+}
+
+// QueryAnalysis is the physical plan of a query with the estimates of its cost.
+type QueryAnalysis struct {
+	// Plan is the physical plan of the query.
+	Plan *plan.Spec
+	// Nodes holds the analysis of each node of the plan.
+	// A node is always after its predecessors.
+	Nodes []NodeAnalysis
+	// Cost is the estimated cost of all of the nodes. The memory
+	// used by the query is estimated by Cost.MEM and the bytes of
+	// data that are scanned by Cost.Disk.
+	Cost plan.Cost
+	// Schemas holds the schema of each result by the name of the result.
+	Schemas map[string]flux.ResultSchema
+}
+
+// NodeAnalysis is the analysis of a node of a physical plan.
+type NodeAnalysis struct {
+	ID   plan.NodeID
+	Kind plan.ProcedureKind
+	// Cost is the estimated cost of the node without its predecessors.
+	Cost plan.Cost
+	// Rows is the estimated number of rows in the output of the node.
+	Rows int64
+	// Schema is the schema of the output of the node.
+	Schema flux.ResultSchema
+}
+
+// Analyze compiles and plans the program of the compiler and estimates its cost.
+// The program is evaluated, so functions that read data during evaluation,
+// such as tableFind, are still executed, but the plan is not executed.
+//
+// The cost of each node is estimated by the Cost method of its procedure spec
+// from the statistics of the outputs of its predecessors.
+func (a *QueryAnalyzer) Analyze(ctx context.Context, compiler flux.Compiler) (*QueryAnalysis, error) {
+	rt := a.Runtime
+	if rt == nil {
+		rt = runtime.Default
+	}
+	ps, err := planProgram(ctx, rt, compiler)
+	if err != nil {
+		return nil, err
+	}
+	return analyzePlan(ps, a.EstimatedBytesPerSecond)
+}
+
+// planProgram compiles and plans the program of a compiler.
+func planProgram(ctx context.Context, rt flux.Runtime, c flux.Compiler) (*plan.Spec, error) {
+	program, err := c.Compile(ctx, rt)
+	if err != nil {
+		return nil, err
+	}
+	switch p := program.(type) {
+	case *AstProgram:
+		return p.Plan(ctx, &memory.ResourceAllocator{})
+	case *Program:
+		return p.PlanSpec, nil
+	default:
+		return nil, errors.Newf(codes.Internal, "cannot plan program of type %T", program)
+	}
+}
+
+func analyzePlan(ps *plan.Spec, bytesPerSecond int64) (*QueryAnalysis, error) {
+	analysis := &QueryAnalysis{
+		Plan:    ps,
+		Schemas: make(map[string]flux.ResultSchema),
+	}
+	stats := make(map[plan.Node]plan.Statistics)
+	if err := ps.BottomUpWalk(func(node plan.Node) error {
+		ppn, ok := node.(*plan.PhysicalPlanNode)
+		if !ok {
+			return errors.Newf(codes.Internal, "cannot analyze plan node %q of type %T", node.ID(), node)
+		}
+		inStats := make([]plan.Statistics, 0, len(node.Predecessors()))
+		for _, pred := range node.Predecessors() {
+			inStats = append(inStats, stats[pred])
+		}
+		if s, ok := ppn.Spec.(plan.ScanEstimator); ok && bytesPerSecond > 0 {
+			s.SetEstimatedBytesPerSecond(bytesPerSecond)
+		}
+		cost, outStats := ppn.Cost(inStats)
+		stats[node] = outStats
+
+		schema := plan.GetOutputSchema(node)
+		analysis.Nodes = append(analysis.Nodes, NodeAnalysis{
+			ID:     node.ID(),
+			Kind:   node.Kind(),
+			Cost:   cost,
+			Rows:   outStats.Cardinality,
+			Schema: schema,
+		})
+		analysis.Cost = plan.Add(analysis.Cost, cost)
+		if y, ok := node.ProcedureSpec().(plan.YieldProcedureSpec); ok {
+			analysis.Schemas[y.YieldName()] = schema
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return analysis, nil
+}
@@ -0,0 +1,104 @@
+package lang_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/influxdata/flux"
+	_ "github.com/influxdata/flux/fluxinit/static"
+	"github.com/influxdata/flux/lang"
+	"github.com/influxdata/flux/parser"
+	"github.com/influxdata/flux/plan"
+	"github.com/influxdata/flux/stdlib/influxdata/influxdb"
+)
+
+func TestQueryAnalyzer(t *testing.T) {
+	// The query must be planned without reading from the host.
+	var requests int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requests, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	now := parser.MustParseTime("2018-10-10T00:00:00Z").Value
+	compiler := lang.FluxCompiler{
+		Query: fmt.Sprintf(`
+import "influxdata/influxdb"
+
+influxdb.from(bucket: "telegraf", host: %q, org: "influxdata", token: "mytoken")
+	|> range(start: -1h)
+	|> filter(fn: (r) => r._measurement == "cpu")
+`, server.URL),
+		Now: now,
+	}
+
+	analyzer := lang.NewQueryAnalyzer()
+	analyzer.EstimatedBytesPerSecond = 2048
+	analysis, err := analyzer.Analyze(context.Background(), compiler)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if n := atomic.LoadInt64(&requests); n != 0 {
+		t.Fatalf("expected no requests to the host, got %d", n)
+	}
+
+	if analysis.Plan == nil {
+		t.Fatal("expected a plan")
+	}
+	if analysis.Cost.Disk <= 0 {
+		t.Errorf("expected data to be scanned, got a cost of %+v", analysis.Cost)
+	}
+
+	var from *lang.NodeAnalysis
+	for i, node := range analysis.Nodes {
+		if node.Kind == influxdb.FromRemoteKind {
+			from = &analysis.Nodes[i]
+		}
+	}
+	if from == nil {
+		t.Fatalf("expected a %s node in the analysis, got %+v", influxdb.FromRemoteKind, analysis.Nodes)
+	}
+	if want := int64(3600 * 2048); from.Cost.Disk != want {
+		t.Errorf("unexpected data scanned -want/+got\n\t- %d\n\t+ %d", want, from.Cost.Disk)
+	}
+	if from.Rows <= 0 {
+		t.Errorf("expected rows to be read, got %d", from.Rows)
+	}
+	// The rows that are read pass through the other nodes
+	// unless those nodes estimate their own statistics.
+	if last := analysis.Nodes[len(analysis.Nodes)-1]; last.Rows != from.Rows {
+		t.Errorf("unexpected rows in the output of %s -want/+got\n\t- %d\n\t+ %d", last.ID, from.Rows, last.Rows)
+	}
+
+	schema, ok := analysis.Schemas[plan.DefaultYieldName]
+	if !ok {
+		t.Fatalf("expected the schema of the %q result, got %v", plan.DefaultYieldName, analysis.Schemas)
+	}
+	if schema.Dynamic {
+		t.Errorf("expected the columns of the result to be known, got %+v", schema)
+	}
+	if !hasColumn(schema, "_measurement") {
+		t.Errorf("expected a _measurement column in %+v", schema)
+	}
+}
+
+func TestQueryAnalyzer_Error(t *testing.T) {
+	analyzer := lang.NewQueryAnalyzer()
+	if _, err := analyzer.Analyze(context.Background(), lang.FluxCompiler{Query: `x = 1`}); err == nil {
+		t.Fatal("expected an error, got none")
+	}
+}
+
+func hasColumn(schema flux.ResultSchema, label string) bool {
+	for _, col := range schema.Columns {
+		if col.Label == label {
+			return true
+		}
+	}
+	return false
+}
@@ -1,13 +1,18 @@
 package lang
 
 import (
+	"bytes"
 	"context"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/ast"
 	"github.com/influxdata/flux/codes"
 	"github.com/influxdata/flux/dependency"
 	"github.com/influxdata/flux/execute"
@@ -19,6 +24,7 @@ import (
 	"github.com/influxdata/flux/memory"
 	"github.com/influxdata/flux/metadata"
 	"github.com/influxdata/flux/plan"
+	"github.com/influxdata/flux/runtime"
 	"github.com/influxdata/flux/semantic"
 	"github.com/influxdata/flux/values"
 	"github.com/opentracing/opentracing-go"
@@ -55,6 +61,48 @@ type compileOptions struct {
 
 	extern flux.ASTHandle
 
+	// seed is the seed for the query's random number generators.
+	// When it is nil, a random seed is chosen when the program starts.
+	seed *int64
+
+	// sortTables orders the tables within each result by group key.
+	sortTables bool
+
+	// spillThreshold is the number of bytes buffered for a group key
+	// before a transformation spills it to disk. Zero disables spilling.
+	spillThreshold int64
+
+	// tableConcurrency is the number of tables that a transformation
+	// may process at the same time. Zero or one processes them in order.
+	tableConcurrency int
+
+	// memoryLimit is the maximum number of bytes the query
+	// may allocate. Zero or less does not limit the query.
+	memoryLimit int64
+
+	// timeout is the maximum duration of the execution
+	// of the program. Zero or less does not limit it.
+	timeout time.Duration
+
+	// maxOptimizationPasses is the maximum number of passes
+	// each planner makes over the plan. Zero is unlimited.
+	maxOptimizationPasses int
+
+	// metrics enables the collection of the metrics
+	// of the transformations of the program.
+	metrics bool
+
+	// planVisualization is where the physical plan is written
+	// when the program starts and in which format.
+	planVisualization struct {
+		w      io.Writer
+		format string
+	}
+
+	// ruleMetrics is where the metrics of the planner
+	// rules are stored once the program is planned.
+	ruleMetrics *[]plan.RuleMetrics
+
 	planOptions struct {
 		logical  []plan.LogicalOption
 		physical []plan.PhysicalOption
@@ -77,6 +125,112 @@ func WithExtern(extern flux.ASTHandle) CompileOption {
 	}
 }
 
+// WithSeed sets the seed used by nondeterministic builtins such as sample()
+// so that the program produces the same output each time it runs.
+func WithSeed(seed int64) CompileOption {
+	return func(o *compileOptions) {
+		o.seed = &seed
+	}
+}
+
+// WithSortTables orders the tables within each result by their
+// group key so encoders produce the same output each time the
+// program runs. Each result buffers all of its tables before
+// the first one is returned.
+func WithSortTables() CompileOption {
+	return func(o *compileOptions) {
+		o.sortTables = true
+	}
+}
+
+// WithSpillThreshold allows transformations that buffer their input,
+// such as join.join, to write the rows buffered for a group key to
+// temporary files once they use more than n bytes. The files are
+// created with the filesystem dependency.
+func WithSpillThreshold(n int64) CompileOption {
+	return func(o *compileOptions) {
+		o.spillThreshold = n
+	}
+}
+
+// WithTableConcurrency allows transformations that process each table
+// on its own, such as filter, to process up to n tables at the same
+// time. The tables they produce are no longer in the order they were
+// read, so queries that rely on that order should also use
+// WithSortTables. A value of one or less processes them one at a time.
+func WithTableConcurrency(n int) CompileOption {
+	return func(o *compileOptions) {
+		o.tableConcurrency = n
+	}
+}
+
+// WithMemoryLimit limits the memory that the program may allocate
+// while it is evaluated and executed to n bytes. The allocator passed
+// to Start is wrapped with the limit, so a larger limit set on that
+// allocator still applies. When the limit is exceeded, the query fails
+// with a resource exhausted error that names the operation that
+// requested the memory. A value of zero or less does not limit it.
+func WithMemoryLimit(n int64) CompileOption {
+	return func(o *compileOptions) {
+		o.memoryLimit = n
+	}
+}
+
+// WithTimeout limits the time that the program may spend executing
+// to d. The context of the execution gets a deadline when the program
+// starts and, once it passes, the query fails with a deadline exceeded
+// error and every transformation is finished with that error.
+// A value of zero or less does not limit it.
+func WithTimeout(d time.Duration) CompileOption {
+	return func(o *compileOptions) {
+		o.timeout = d
+	}
+}
+
+// WithMaxOptimizationPasses limits the number of times the planner
+// applies its rules to the whole plan. When the plan is not optimized
+// after n passes, a warning is logged and the plan is used as it is.
+// The plan is valid, but some rules may not have been applied to it.
+// A value of zero or less does not limit the number of passes.
+func WithMaxOptimizationPasses(n int) CompileOption {
+	return func(o *compileOptions) {
+		o.maxOptimizationPasses = n
+	}
+}
+
+// WithMetrics collects the rows and bytes that each transformation
+// of the program reads and produces, the time it spends processing
+// them and the bytes it allocates. They are returned by AstProgram.Metrics
+// and by the Nodes of the statistics of the query once it is done.
+// Each transformation is wrapped to count its data, so the metrics
+// are only collected when they are enabled.
+func WithMetrics() CompileOption {
+	return func(o *compileOptions) {
+		o.metrics = true
+	}
+}
+
+// WithPlanVisualization writes the physical plan to w when the program
+// starts, as rendered by PlanVisualizer in the format, which is either
+// PlanFormatDOT or PlanFormatMermaid. The program fails to start when
+// the format is neither of these or when the plan cannot be written.
+func WithPlanVisualization(w io.Writer, format string) CompileOption {
+	return func(o *compileOptions) {
+		o.planVisualization.w = w
+		o.planVisualization.format = format
+	}
+}
+
+// WithRuleMetrics stores the metrics of the planner rules in dest
+// each time the program is planned, which happens when it starts.
+// The metrics tell how often each rule was applied, how often it
+// rewrote the plan and how long it took.
+func WithRuleMetrics(dest *[]plan.RuleMetrics) CompileOption {
+	return func(o *compileOptions) {
+		o.ruleMetrics = dest
+	}
+}
+
 func defaultOptions() *compileOptions {
 	o := new(compileOptions)
 	return o
@@ -155,8 +309,20 @@ func buildPlan(ctx context.Context, spec *flux.Spec, opts *compileOptions) (*pla
 
 	pb.AddLogicalOptions(lopts...)
 	pb.AddPhysicalOptions(popts...)
+	if n := opts.maxOptimizationPasses; n > 0 {
+		var logger *zap.Logger
+		if execute.HaveExecutionDependencies(ctx) {
+			logger = execute.GetExecutionDependencies(ctx).Logger
+		}
+		pb.AddLogicalOptions(plan.WithMaxLogicalPasses(n, logger))
+		pb.AddPhysicalOptions(plan.WithMaxPhysicalPasses(n, logger))
+	}
 
-	ps, err := pb.Build().Plan(ctx, spec)
+	planner := pb.Build()
+	ps, err := planner.Plan(ctx, spec)
+	if opts.ruleMetrics != nil {
+		*opts.ruleMetrics = planner.Metrics()
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -168,6 +334,63 @@ type FluxCompiler struct {
 	Now    time.Time
 	Extern json.RawMessage `json:"extern,omitempty"`
 	Query  string          `json:"query"`
+	// Seed is the seed for nondeterministic builtins.
+	// A zero value selects a random seed.
+	Seed int64 `json:"seed,omitempty"`
+	// SortTables orders the tables within each result by group key.
+	SortTables bool `json:"sortTables,omitempty"`
+	// SpillThreshold is the number of bytes buffered for a group key
+	// before it is spilled to disk. A zero value disables spilling.
+	SpillThreshold int64 `json:"spillThreshold,omitempty"`
+	// TableConcurrency is the number of tables that a transformation
+	// may process at the same time. A zero value processes them in order.
+	TableConcurrency int `json:"tableConcurrency,omitempty"`
+	// MemoryLimit is the maximum number of bytes the query
+	// may allocate. A zero value does not limit the query.
+	MemoryLimit int64 `json:"memoryLimit,omitempty"`
+	// Timeout is the maximum duration of the execution
+	// of the query. A zero value does not limit the query.
+	Timeout time.Duration `json:"timeout,omitempty"`
+	// Variables are bound as options before the query is compiled.
+	// Values may be a string, int64, float64, bool, time.Time or
+	// flux.Duration. An int, int32, float32 or time.Duration is
+	// converted to the matching type.
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+// MarshalJSON encodes the compiler with the type of each variable
+// so that the variables are decoded with the same types.
+func (c FluxCompiler) MarshalJSON() ([]byte, error) {
+	type fluxCompiler FluxCompiler
+	vars, err := marshalVariables(c.Variables)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(struct {
+		fluxCompiler
+		Variables map[string]variableJSON `json:"variables,omitempty"`
+	}{
+		fluxCompiler: fluxCompiler(c),
+		Variables:    vars,
+	})
+}
+
+func (c *FluxCompiler) UnmarshalJSON(data []byte) error {
+	type fluxCompiler FluxCompiler
+	var v struct {
+		fluxCompiler
+		Variables map[string]json.RawMessage `json:"variables,omitempty"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	vars, err := unmarshalVariables(v.Variables)
+	if err != nil {
+		return err
+	}
+	*c = FluxCompiler(v.fluxCompiler)
+	c.Variables = vars
+	return nil
 }
 
 func wrapFileJSONInPkg(bs []byte) []byte {
@@ -189,26 +412,80 @@ func IsNonNullJSON(bs json.RawMessage) bool {
 func (c FluxCompiler) Compile(ctx context.Context, runtime flux.Runtime) (flux.Program, error) {
 	query := c.Query
 
+	var opts []CompileOption
+	if c.Seed != 0 {
+		opts = append(opts, WithSeed(c.Seed))
+	}
+	if c.SortTables {
+		opts = append(opts, WithSortTables())
+	}
+	if c.SpillThreshold > 0 {
+		opts = append(opts, WithSpillThreshold(c.SpillThreshold))
+	}
+	if c.TableConcurrency > 0 {
+		opts = append(opts, WithTableConcurrency(c.TableConcurrency))
+	}
+	if c.MemoryLimit > 0 {
+		opts = append(opts, WithMemoryLimit(c.MemoryLimit))
+	}
+	if c.Timeout > 0 {
+		opts = append(opts, WithTimeout(c.Timeout))
+	}
+
+	extern := c.Extern
+	if len(c.Variables) > 0 {
+		var err error
+		if extern, err = externWithVariables(extern, c.Variables); err != nil {
+			return nil, err
+		}
+	}
+
 	// Ignore context, it will be provided upon Program Start.
-	if IsNonNullJSON(c.Extern) {
-		hdl, err := runtime.JSONToHandle(wrapFileJSONInPkg(c.Extern))
+	if IsNonNullJSON(extern) {
+		hdl, err := runtime.JSONToHandle(wrapFileJSONInPkg(extern))
 		if err != nil {
 			return nil, errors.Wrap(err, codes.Inherit, "extern json parse error")
 		}
-		return Compile(query, runtime, c.Now, WithExtern(hdl))
+		opts = append(opts, WithExtern(hdl))
 	}
-	return Compile(query, runtime, c.Now)
+	return Compile(query, runtime, c.Now, opts...)
 }
 
 func (c FluxCompiler) CompilerType() flux.CompilerType {
 	return FluxCompilerType
 }
 
+// DryRun compiles the query with the default runtime and returns its
+// physical plan without executing it.
+func (c FluxCompiler) DryRun() (*plan.Spec, error) {
+	return dryRun(c)
+}
+
 // ASTCompiler implements Compiler by producing a Program from an AST.
 type ASTCompiler struct {
+	// Extern is a JSON-encoded ast.File or an array of them that
+	// is evaluated before the AST. Each file of an array may set
+	// options that were set by the files before it.
 	Extern json.RawMessage `json:"extern,omitempty"`
 	AST    json.RawMessage `json:"ast"`
 	Now    time.Time
+	// Seed is the seed for nondeterministic builtins.
+	// A zero value selects a random seed.
+	Seed int64 `json:"seed,omitempty"`
+	// SortTables orders the tables within each result by group key.
+	SortTables bool `json:"sortTables,omitempty"`
+	// SpillThreshold is the number of bytes buffered for a group key
+	// before it is spilled to disk. A zero value disables spilling.
+	SpillThreshold int64 `json:"spillThreshold,omitempty"`
+	// TableConcurrency is the number of tables that a transformation
+	// may process at the same time. A zero value processes them in order.
+	TableConcurrency int `json:"tableConcurrency,omitempty"`
+	// MemoryLimit is the maximum number of bytes the query
+	// may allocate. A zero value does not limit the query.
+	MemoryLimit int64 `json:"memoryLimit,omitempty"`
+	// Timeout is the maximum duration of the execution
+	// of the query. A zero value does not limit the query.
+	Timeout time.Duration `json:"timeout,omitempty"`
 }
 
 func (c ASTCompiler) Compile(ctx context.Context, runtime flux.Runtime) (flux.Program, error) {
@@ -224,21 +501,124 @@ func (c ASTCompiler) Compile(ctx context.Context, runtime flux.Runtime) (flux.Pr
 		return nil, err
 	}
 
+	var opts []CompileOption
+	if c.Seed != 0 {
+		opts = append(opts, WithSeed(c.Seed))
+	}
+	if c.SortTables {
+		opts = append(opts, WithSortTables())
+	}
+	if c.SpillThreshold > 0 {
+		opts = append(opts, WithSpillThreshold(c.SpillThreshold))
+	}
+	if c.TableConcurrency > 0 {
+		opts = append(opts, WithTableConcurrency(c.TableConcurrency))
+	}
+	if c.MemoryLimit > 0 {
+		opts = append(opts, WithMemoryLimit(c.MemoryLimit))
+	}
+	if c.Timeout > 0 {
+		opts = append(opts, WithTimeout(c.Timeout))
+	}
+
 	// Ignore context, it will be provided upon Program Start.
 	if IsNonNullJSON(c.Extern) {
-		extHdl, err := runtime.JSONToHandle(wrapFileJSONInPkg(c.Extern))
+		extern, err := externPackage(c.Extern)
 		if err != nil {
 			return nil, err
 		}
-		return CompileAST(hdl, runtime, now, WithExtern(extHdl)), nil
+		if extern != nil {
+			extHdl, err := runtime.JSONToHandle(extern)
+			if err != nil {
+				return nil, err
+			}
+			opts = append(opts, WithExtern(extHdl))
+		}
 	}
-	return CompileAST(hdl, runtime, now), nil
+	return CompileAST(hdl, runtime, now, opts...), nil
+}
+
+// externPackage wraps the extern of an ASTCompiler in a package.
+// The extern is either a single file or an array of files that are
+// evaluated in order. An option that is set by a later file replaces
+// the option statement of an earlier file, so each file can override
+// the defaults of the files before it. A file without a name is named
+// after its index so errors point at the file that caused them.
+// A nil package is returned when the array has no files.
+func externPackage(extern json.RawMessage) ([]byte, error) {
+	if bs := bytes.TrimSpace(extern); len(bs) == 0 || bs[0] != '[' {
+		return wrapFileJSONInPkg(extern), nil
+	}
+	var raw []json.RawMessage
+	if err := json.Unmarshal(extern, &raw); err != nil {
+		return nil, errors.Wrap(err, codes.Invalid, "extern json parse error")
+	}
+	files := make([]*ast.File, 0, len(raw))
+	for i, bs := range raw {
+		if !IsNonNullJSON(bs) {
+			continue
+		}
+		file := &ast.File{}
+		if err := json.Unmarshal(bs, file); err != nil {
+			return nil, errors.Wrapf(err, codes.Invalid, "extern %d json parse error", i)
+		}
+		if file.Name == "" {
+			file.Name = fmt.Sprintf("extern_%d.flux", i)
+		}
+		files = append(files, file)
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	// Walk the files from the last one so the options that are
+	// set by later files are known when an earlier file is read.
+	overridden := make(map[string]bool)
+	for i := len(files) - 1; i >= 0; i-- {
+		file := files[i]
+		var (
+			body  []ast.Statement
+			names []string
+		)
+		for _, stmt := range file.Body {
+			if opt, ok := stmt.(*ast.OptionStatement); ok {
+				if name, ok := optionName(file, opt); ok {
+					if overridden[name] {
+						continue
+					}
+					names = append(names, name)
+				}
+			}
+			body = append(body, stmt)
+		}
+		file.Body = body
+		for _, name := range names {
+			overridden[name] = true
+		}
+	}
+	return json.Marshal(&ast.Package{
+		Package: "main",
+		Files:   files,
+	})
 }
 
 func (ASTCompiler) CompilerType() flux.CompilerType {
 	return ASTCompilerType
 }
 
+// DryRun compiles the AST with the default runtime and returns its
+// physical plan without executing it.
+func (c ASTCompiler) DryRun() (*plan.Spec, error) {
+	return dryRun(c)
+}
+
+// dryRun compiles and plans the program of a compiler.
+// The program is evaluated, so functions that read data during
+// evaluation, such as tableFind, are still executed.
+func dryRun(c flux.Compiler) (*plan.Spec, error) {
+	return planProgram(context.Background(), runtime.Default, c)
+}
+
 // TableObjectCompiler compiles a TableObject into an executable flux.Program.
 // It is not added to CompilerMappings and it is not serializable, because
 // it is impossible to use it outside of the context of an ongoing execution.
@@ -274,8 +654,43 @@ func (p *Program) SetLogger(logger *zap.Logger) {
 	p.Logger = logger
 }
 
+// writePlan writes the plan of the program to w in the format.
+func (p *Program) writePlan(w io.Writer, format string) error {
+	out, err := PlanVisualizer{}.visualize(p.PlanSpec, format)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, out); err != nil {
+		return errors.Wrap(err, codes.Inherit, "failed to write the plan visualization")
+	}
+	return nil
+}
+
+// limitAllocator wraps alloc with the memory limit of the program.
+// The allocator is returned as it is when it already enforces
+// the same limit or a smaller one.
+func (p *Program) limitAllocator(alloc memory.Allocator) memory.Allocator {
+	if p.opts == nil || p.opts.memoryLimit <= 0 {
+		return alloc
+	}
+	limit := p.opts.memoryLimit
+	if ra, ok := alloc.(*memory.ResourceAllocator); ok && ra.Limit != nil && *ra.Limit <= limit {
+		return alloc
+	}
+	return &memory.ResourceAllocator{
+		Allocator: alloc,
+		Limit:     &limit,
+	}
+}
+
 func (p *Program) Start(ctx context.Context, alloc memory.Allocator) (flux.Query, error) {
-	ctx, cancel := context.WithCancel(ctx)
+	var cancel context.CancelFunc
+	if p.opts != nil && p.opts.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, p.opts.timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	alloc = p.limitAllocator(alloc)
 
 	// This span gets closed by the query when it is done.
 	var s opentracing.Span
@@ -312,6 +727,7 @@ func (p *Program) Start(ctx context.Context, alloc memory.Allocator) (flux.Query
 	if execute.HaveExecutionDependencies(ctx) {
 		deps := execute.GetExecutionDependencies(ctx)
 		q.stats.Metadata.AddAll(deps.Metadata)
+		q.metrics = deps.ExecutionOptions.Metrics
 	}
 
 	if traceID, sampled, found := jaeger.InfoFromSpan(s); found {
@@ -322,6 +738,13 @@ func (p *Program) Start(ctx context.Context, alloc memory.Allocator) (flux.Query
 	q.stats.Metadata.Add("flux/query-plan",
 		fmt.Sprintf("%v", plan.Formatted(p.PlanSpec, plan.WithDetails())))
 
+	if p.opts != nil && p.opts.planVisualization.w != nil {
+		if err := p.writePlan(p.opts.planVisualization.w, p.opts.planVisualization.format); err != nil {
+			s.Finish()
+			return nil, err
+		}
+	}
+
 	e := execute.NewExecutor(p.Logger)
 	resultMap, md, err := e.Execute(ctx, p.PlanSpec, q.alloc)
 	if err != nil {
@@ -335,7 +758,7 @@ func (p *Program) Start(ctx context.Context, alloc memory.Allocator) (flux.Query
 
 	// Begin reading from the metadata channel.
 	q.wg.Add(1)
-	go p.readMetadata(q, md)
+	go p.readMetadata(ctx, q, md)
 
 	return q, nil
 }
@@ -348,16 +771,31 @@ func (p *Program) processResults(ctx context.Context, q *query, resultMap map[st
 		select {
 		case q.results <- res:
 		case <-ctx.Done():
-			q.err = ctx.Err()
+			q.err = execute.ContextError(ctx.Err())
 			return
 		}
 	}
 }
 
-func (p *Program) readMetadata(q *query, metaCh <-chan metadata.Metadata) {
+func (p *Program) readMetadata(ctx context.Context, q *query, metaCh <-chan metadata.Metadata) {
 	defer q.wg.Done()
-	for md := range metaCh {
-		q.stats.Metadata.AddAll(md)
+	for {
+		select {
+		case md, ok := <-metaCh:
+			if !ok {
+				return
+			}
+			q.stats.Metadata.AddAll(md)
+		case <-ctx.Done():
+			// A source may keep running after the deadline when it
+			// ignores the context, so stop waiting for its metadata.
+			// The metadata channel is buffered so it never blocks.
+			if ctx.Err() == context.DeadlineExceeded {
+				q.deadlineExceeded = true
+				return
+			}
+			ctx = context.Background()
+		}
 	}
 }
 
@@ -373,6 +811,18 @@ type AstProgram struct {
 	// The operator profiler that is profiling this query, if any.
 	// Note this operator profiler is also cached in the Profilers array.
 	tfProfiler *execute.OperatorProfiler
+	// fingerprint is the semantic fingerprint of the program
+	// once it has been computed.
+	fingerprint *[32]byte
+	// metrics collects the metrics of the transformations
+	// of the last execution when they are enabled.
+	metrics *execute.TransformationMetrics
+	// optionSources holds the source of each option
+	// that is set by the extern or the script.
+	optionSources map[string]string
+	// effectiveOptions holds the options that the
+	// program used when it was last planned.
+	effectiveOptions []EffectiveOption
 }
 
 // Prepare the Ast for semantic analysis
@@ -383,6 +833,11 @@ func (p *AstProgram) GetAst() (flux.ASTHandle, error) {
 	if p.opts == nil {
 		p.opts = defaultOptions()
 	}
+	if p.optionSources == nil {
+		// The sources are found before the extern
+		// and the script are merged into one package.
+		p.optionSources = optionSources(p.opts.extern, p.Ast)
+	}
 	if p.opts.extern != nil {
 		extern := p.opts.extern
 		if err := p.Runtime.MergePackages(extern, p.Ast); err != nil {
@@ -445,6 +900,27 @@ func (eoc *ExecOptsConfig) ConfigureNow(ctx context.Context, now time.Time) {
 	*deps.Now = now
 }
 
+// setLocationOption stores the value of the location option
+// in the execution options so transformations that are not
+// passed a location use the location of the script.
+func setLocationOption(ctx context.Context, opt values.Value) error {
+	if opt == nil || !execute.HaveExecutionDependencies(ctx) {
+		return nil
+	}
+	if opt.Type().Nature() != semantic.Object {
+		return errors.Newf(codes.Invalid, "location option must be a record, got %s", opt.Type().Nature())
+	}
+	loc, err := plan.LocationFromObject(opt.Object())
+	if err != nil {
+		return errors.Wrap(err, codes.Inherit, "invalid location option")
+	}
+	deps := execute.GetExecutionDependencies(ctx)
+	if deps.ExecutionOptions != nil {
+		deps.ExecutionOptions.Location = loc
+	}
+	return nil
+}
+
 func (p *AstProgram) getSpec(ctx context.Context, alloc memory.Allocator) (*flux.Spec, values.Scope, error) {
 	ast, astErr := p.GetAst()
 	if astErr != nil {
@@ -461,7 +937,7 @@ func (p *AstProgram) getSpec(ctx context.Context, alloc memory.Allocator) (*flux
 	// TODO(jsternberg): Personal note, I don't like how now interacts with
 	// the runtime and flux code in so many places. We should evaluate how
 	// now is used and see if we can improve how now interacts with the system.
-	var nowOpt values.Value
+	var nowOpt, locationOpt values.Value
 	sideEffects, scope, err := p.Runtime.Eval(cctx, ast, &ExecOptsConfig{},
 		flux.SetNowOption(p.Now),
 		func(r flux.Runtime, scope values.Scope) {
@@ -469,6 +945,9 @@ func (p *AstProgram) getSpec(ctx context.Context, alloc memory.Allocator) (*flux
 			if _, ok := nowOpt.(*values.Option); !ok {
 				panic("now must be an option")
 			}
+			// The location option is in the prelude, so it is
+			// shared with the scope and updated by `option location`.
+			locationOpt, _ = scope.Lookup(interpreter.LocationOption)
 		},
 	)
 	if err != nil {
@@ -483,6 +962,9 @@ func (p *AstProgram) getSpec(ctx context.Context, alloc memory.Allocator) (*flux
 		return nil, nil, errors.Wrap(err, codes.Inherit, "error in evaluating AST while starting program")
 	}
 	p.Now = nowTime.Time().Time()
+	if err := setLocationOption(ctx, locationOpt); err != nil {
+		return nil, nil, err
+	}
 	sp, err := spec.FromEvaluation(cctx, sideEffects, p.Now)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, codes.Inherit, "error in query specification while starting program")
@@ -491,6 +973,74 @@ func (p *AstProgram) getSpec(ctx context.Context, alloc memory.Allocator) (*flux
 }
 
 func (p *AstProgram) Start(ctx context.Context, alloc memory.Allocator) (flux.Query, error) {
+	alloc = p.limitAllocator(alloc)
+	ctx, span := p.injectDependencies(ctx, alloc)
+	if _, err := p.plan(ctx, alloc); err != nil {
+		return nil, err
+	}
+	return p.execute(ctx, alloc, span)
+}
+
+// Plan evaluates the program and builds its plan without executing it.
+// No goroutines are started and no sources are opened.
+// Each call evaluates the program again and replaces PlanSpec,
+// so it is safe to call more than once.
+func (p *AstProgram) Plan(ctx context.Context, alloc memory.Allocator) (*plan.Spec, error) {
+	alloc = p.limitAllocator(alloc)
+	ctx, span := p.injectDependencies(ctx, alloc)
+	defer span.Finish()
+	return p.plan(ctx, alloc)
+}
+
+// PlanDigest returns the digest of the plan of the program as computed
+// by plan.Digest. Programs compiled from the same query with the same
+// now time and options have the same digest, so it can identify the
+// results of the program in a cache. The program must have been planned
+// with Plan or started, otherwise the zero digest is returned.
+func (p *AstProgram) PlanDigest() [32]byte {
+	if p.PlanSpec == nil {
+		return [32]byte{}
+	}
+	return plan.Digest(p.PlanSpec)
+}
+
+// PlanFormatJSON is the format of a plan encoded by plan.Spec.MarshalJSON.
+const PlanFormatJSON = "json"
+
+// PlanString returns the plan of the program in the format, which is
+// PlanFormatJSON, PlanFormatDOT or PlanFormatMermaid. The output is the
+// same each time the program is planned and the credentials in the
+// procedure specs are redacted, so it can be compared in tests and
+// shared for debugging. The program must have been planned.
+func (p *AstProgram) PlanString(format string) (string, error) {
+	if p.PlanSpec == nil {
+		return "", errors.New(codes.FailedPrecondition, "program has not been planned")
+	}
+	if format != PlanFormatJSON {
+		return PlanVisualizer{}.visualize(p.PlanSpec, format)
+	}
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(p.PlanSpec); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// Metrics returns the metrics of each transformation of the last
+// execution of the program, with the predecessors of a transformation
+// before it. The metrics are complete once the query is done.
+// It returns nil unless the program was compiled with WithMetrics.
+func (p *AstProgram) Metrics() []execute.TransformationMetric {
+	if p.metrics == nil {
+		return nil
+	}
+	return p.metrics.Metrics()
+}
+
+func (p *AstProgram) injectDependencies(ctx context.Context, alloc memory.Allocator) (context.Context, *dependency.Span) {
 	// The program must inject execution dependencies to make it available to
 	// function calls during the evaluation phase (see `tableFind`).
 	deps := execute.NewExecutionDependencies(alloc, &p.Now, p.Logger)
@@ -500,9 +1050,32 @@ func (p *AstProgram) Start(ctx context.Context, alloc memory.Allocator) (flux.Qu
 	// execution begins.
 	deps.ExecutionOptions.ConcurrencyLimit = feature.QueryConcurrencyLimit().Int(ctx)
 
+	if p.opts.seed != nil {
+		deps.Seed = *p.opts.seed
+	}
+	deps.ExecutionOptions.SortTables = p.opts.sortTables
+	deps.ExecutionOptions.SpillThreshold = p.opts.spillThreshold
+	deps.ExecutionOptions.TableConcurrency = p.opts.tableConcurrency
+	deps.ExecutionOptions.MemoryLimit = p.opts.memoryLimit
+	if p.opts.metrics {
+		p.metrics = execute.NewTransformationMetrics()
+		deps.ExecutionOptions.Metrics = p.metrics
+	}
+
 	ctx, span := dependency.Inject(ctx, deps)
 	nextPlanNodeID := new(int)
 	ctx = context.WithValue(ctx, plan.NextPlanNodeIDKey, nextPlanNodeID)
+	return ctx, span
+}
+
+func (p *AstProgram) plan(ctx context.Context, alloc memory.Allocator) (*plan.Spec, error) {
+	// The fingerprint is computed before evaluation consumes the AST.
+	fingerprint, err := p.SemanticFingerprint()
+	if err != nil {
+		return nil, err
+	}
+	deps := execute.GetExecutionDependencies(ctx)
+	deps.Metadata.Add(SemanticFingerprintMetadataKey, hex.EncodeToString(fingerprint[:]))
 
 	// Evaluation.
 	sp, scope, err := p.getSpec(ctx, alloc)
@@ -512,24 +1085,29 @@ func (p *AstProgram) Start(ctx context.Context, alloc memory.Allocator) (flux.Qu
 
 	// Planning.
 	s, cctx := opentracing.StartSpanFromContext(ctx, "plan")
+	defer s.Finish()
 	if p.opts.verbose {
 		log.Println("Query Spec: ", flux.Formatted(sp, flux.FmtJSON))
 	}
-	if err := p.updateOpts(scope); err != nil {
+	opts, err := p.planOpts(scope)
+	if err != nil {
 		return nil, errors.Wrap(err, codes.Inherit, "error in reading options while starting program")
 	}
 	if err := p.updateProfilers(ctx, scope); err != nil {
 		return nil, errors.Wrap(err, codes.Inherit, "error in reading profiler settings while starting program")
 	}
-	ps, err := buildPlan(cctx, sp, p.opts)
+	p.effectiveOptions = p.resolveOptions(ctx, scope)
+	deps.Metadata.Add(EffectiveOptionsMetadataKey, p.effectiveOptions)
+	ps, err := buildPlan(cctx, sp, opts)
 	if err != nil {
 		return nil, errors.Wrap(err, codes.Inherit, "error in building plan while starting program")
 	}
 	p.PlanSpec = ps
-	s.Finish()
+	return ps, nil
+}
 
-	// Execution.
-	s, cctx = opentracing.StartSpanFromContext(ctx, "start-program")
+func (p *AstProgram) execute(ctx context.Context, alloc memory.Allocator, span *dependency.Span) (flux.Query, error) {
+	s, cctx := opentracing.StartSpanFromContext(ctx, "start-program")
 	defer s.Finish()
 	q, err := p.Program.Start(cctx, alloc)
 	if err != nil {
@@ -551,22 +1129,30 @@ func (p *AstProgram) updateProfilers(ctx context.Context, scope values.Scope) er
 	return nil
 }
 
-func (p *AstProgram) updateOpts(scope values.Scope) error {
+// planOpts returns the compile options with the planner options
+// set by the program. The options of the program are not modified
+// so that the program can be planned more than once.
+func (p *AstProgram) planOpts(scope values.Scope) (*compileOptions, error) {
+	opts := *p.opts
 	pkg, ok := getPackageFromScope("planner", scope)
 	if !ok {
-		return nil
+		return &opts, nil
 	}
 	lo, po, err := getPlanOptions(pkg)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	// Limit the capacity of the slices so appending
+	// copies them instead of sharing their arrays.
 	if lo != nil {
-		p.opts.planOptions.logical = append(p.opts.planOptions.logical, lo)
+		logical := opts.planOptions.logical
+		opts.planOptions.logical = append(logical[:len(logical):len(logical)], lo)
 	}
 	if po != nil {
-		p.opts.planOptions.physical = append(p.opts.planOptions.physical, po)
+		physical := opts.planOptions.physical
+		opts.planOptions.physical = append(physical[:len(physical):len(physical)], po)
 	}
-	return nil
+	return &opts, nil
 }
 
 type spanQuery struct {
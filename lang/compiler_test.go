@@ -3,10 +3,13 @@ package lang_test
 import (
 	"bytes"
 	"context"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"math"
 	"regexp"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -14,6 +17,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/influxdata/flux"
 	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/codes"
 	fcsv "github.com/influxdata/flux/csv"
 	"github.com/influxdata/flux/dependencies/dependenciestest"
 	"github.com/influxdata/flux/dependency"
@@ -27,15 +31,25 @@ import (
 	"github.com/influxdata/flux/plan"
 	"github.com/influxdata/flux/plan/plantest"
 	"github.com/influxdata/flux/runtime"
+	"github.com/influxdata/flux/stdlib/array"
 	"github.com/influxdata/flux/stdlib/csv"
 	"github.com/influxdata/flux/stdlib/influxdata/influxdb"
 	"github.com/influxdata/flux/stdlib/universe"
+	"github.com/influxdata/flux/values"
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/mocktracer"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 )
 
+// mockFromSources counts the sources created for influxdb.from.
+var mockFromSources int32
+
 func init() {
-	execute.RegisterSource(influxdb.FromKind, mock.CreateMockFromSource)
+	execute.RegisterSource(influxdb.FromKind, func(spec plan.ProcedureSpec, id execute.DatasetID, a execute.Administration) (execute.Source, error) {
+		atomic.AddInt32(&mockFromSources, 1)
+		return mock.CreateMockFromSource(spec, id, a)
+	})
 	plan.RegisterLogicalRules(
 		influxdb.DefaultFromAttributes{
 			Org:  &influxdb.NameOrID{Name: "influxdata"},
@@ -232,7 +246,9 @@ func TestASTCompiler(t *testing.T) {
 		script       string
 		jsonCompiler []byte
 		want         plantest.PlanSpec
-		startErr     string
+		// wantNowSource is the source of the effective now option.
+		wantNowSource string
+		startErr      string
 	}{
 		{
 			name: "override now time using now option",
@@ -252,6 +268,7 @@ csv.from(csv: "foo,bar") |> range(start: 2017-10-10T00:00:00Z)
 				},
 				Now: parser.MustParseTime("2017-10-10T00:01:00Z").Value,
 			},
+			wantNowSource: lang.OptionSourceScript,
 		},
 		{
 			name: "get now time from compiler",
@@ -270,6 +287,7 @@ csv.from(csv: "foo,bar") |> range(start: 2017-10-10T00:00:00Z)
 				},
 				Now: parser.MustParseTime("2018-10-10T00:00:00Z").Value,
 			},
+			wantNowSource: lang.OptionSourceCompiler,
 		},
 		{
 			name: "extern",
@@ -301,6 +319,40 @@ csv.from(csv: "foo,bar") |> range(start: 2017-10-10T00:00:00Z)
 				},
 				Now: parser.MustParseTime("2018-10-10T00:00:00Z").Value,
 			},
+			wantNowSource: lang.OptionSourceExtern,
+		},
+		{
+			name: "override extern now time using now option",
+			file: &ast.File{
+				Body: []ast.Statement{
+					&ast.OptionStatement{
+						Assignment: &ast.VariableAssignment{
+							ID: &ast.Identifier{Name: "now"},
+							Init: &ast.FunctionExpression{
+								Body: &ast.DateTimeLiteral{
+									Value: parser.MustParseTime("2018-10-10T00:00:00Z").Value,
+								},
+							},
+						},
+					},
+				},
+			},
+			script: `
+import "csv"
+option now = () => 2017-10-10T00:01:00Z
+csv.from(csv: "foo,bar") |> range(start: 2017-10-10T00:00:00Z)
+`,
+			want: plantest.PlanSpec{
+				Nodes: []plan.Node{
+					&plan.PhysicalPlanNode{Spec: &csv.FromCSVProcedureSpec{}},
+					&plan.PhysicalPlanNode{Spec: &universe.RangeProcedureSpec{}},
+				},
+				Edges: [][2]int{
+					{0, 1},
+				},
+				Now: parser.MustParseTime("2017-10-10T00:01:00Z").Value,
+			},
+			wantNowSource: lang.OptionSourceScript,
 		},
 		{
 			name:     "simple case",
@@ -428,6 +480,18 @@ csv.from(csv: "foo,bar") |> range(start: 2017-10-10T00:00:00Z)
 			if err := plantest.ComparePlansShallow(want, got); err != nil {
 				t.Error(err)
 			}
+
+			// The effective now option tells where the now time came from.
+			opts := program.(*lang.AstProgram).EffectiveOptions()
+			if len(opts) == 0 || opts[0].Name != "now" {
+				t.Fatalf("expected the now option first in the effective options, got %v", opts)
+			}
+			if got, want := opts[0].Value, tc.want.Now; !cmp.Equal(want, got) {
+				t.Errorf("unexpected now option -want/+got:\n\t- %v\n\t+ %v", want, got)
+			}
+			if got, want := opts[0].Source, tc.wantNowSource; got != want {
+				t.Errorf("unexpected source of the now option -want/+got:\n\t- %s\n\t+ %s", want, got)
+			}
 		})
 	}
 }
@@ -486,130 +550,1499 @@ csv.from(csv: "
 	}
 }
 
-func TestCompileOptions(t *testing.T) {
-	src := `import "csv"
-			csv.from(csv: "foo,bar")
-				|> range(start: 2017-10-10T00:00:00Z)
-				|> count()`
-
-	now := parser.MustParseTime("2018-10-10T00:00:00Z").Value
+// nowExtern returns an extern file that sets the now option to ts.
+func nowExtern(ts string) *ast.File {
+	return &ast.File{
+		Body: []ast.Statement{
+			&ast.OptionStatement{
+				Assignment: &ast.VariableAssignment{
+					ID: &ast.Identifier{Name: "now"},
+					Init: &ast.FunctionExpression{
+						Body: &ast.DateTimeLiteral{
+							Value: parser.MustParseTime(ts).Value,
+						},
+					},
+				},
+			},
+		},
+	}
+}
 
-	opt := lang.WithLogPlanOpts(plan.OnlyLogicalRules(removeCount{}))
+func TestASTCompiler_Externs(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		externs  []*ast.File
+		script   string
+		wantNow  time.Time
+		startErr string
+	}{
+		{
+			name: "later extern overrides now",
+			externs: []*ast.File{
+				nowExtern("2018-10-10T00:00:00Z"),
+				nowExtern("2019-10-10T00:00:00Z"),
+			},
+			script: `
+import "csv"
+csv.from(csv: "foo,bar") |> range(start: 2017-10-10T00:00:00Z)
+`,
+			wantNow: parser.MustParseTime("2019-10-10T00:00:00Z").Value,
+		},
+		{
+			name: "conflict with the query",
+			externs: []*ast.File{
+				nowExtern("2018-10-10T00:00:00Z"),
+				{
+					Body: []ast.Statement{
+						&ast.VariableAssignment{
+							ID:   &ast.Identifier{Name: "x"},
+							Init: &ast.IntegerLiteral{Value: 1},
+						},
+					},
+				},
+			},
+			script: `
+import "csv"
+x = 2
+csv.from(csv: "foo,bar") |> range(start: 2017-10-10T00:00:00Z)
+`,
+			startErr: `variable "x" reassigned`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			astPkg, err := runtime.Default.Parse(tc.script)
+			if err != nil {
+				t.Fatalf("failed to parse script: %v", err)
+			}
+			jsonPkg, err := parser.HandleToJSON(astPkg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			extern, err := json.Marshal(tc.externs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c := lang.ASTCompiler{
+				Extern: extern,
+				AST:    jsonPkg,
+			}
 
-	program, err := lang.Compile(src, runtime.Default, now, opt)
-	if err != nil {
-		t.Fatalf("failed to compile script: %v", err)
-	}
+			program, err := c.Compile(context.Background(), runtime.Default)
+			if err != nil {
+				t.Fatalf("failed to compile AST: %v", err)
+			}
+			ctx, deps := dependency.Inject(context.Background(), executetest.NewTestExecuteDependencies())
+			defer deps.Finish()
 
-	// start program in order to evaluate planner options
-	ctx, deps := dependency.Inject(context.Background(), executetest.NewTestExecuteDependencies())
-	defer deps.Finish()
+			if _, err := program.Start(ctx, &memory.ResourceAllocator{}); err != nil {
+				if tc.startErr == "" {
+					t.Fatalf("failed to start program: %v", err)
+				} else if !strings.Contains(err.Error(), tc.startErr) {
+					t.Fatalf("expected to get an error containing %q but got %q", tc.startErr, err.Error())
+				}
+				return
+			} else if tc.startErr != "" {
+				t.Fatalf("expected an error containing %q", tc.startErr)
+			}
 
-	if _, err := program.Start(ctx, &memory.ResourceAllocator{}); err != nil {
-		t.Fatalf("failed to start program: %v", err)
+			if got := program.(*lang.AstProgram).PlanSpec.Now; !tc.wantNow.Equal(got) {
+				t.Errorf("unexpected now -want/+got:\n\t- %v\n\t+ %v", tc.wantNow, got)
+			}
+		})
 	}
+}
 
-	want := plantest.CreatePlanSpec(&plantest.PlanSpec{
-		Nodes: []plan.Node{
-			&plan.PhysicalPlanNode{Spec: &csv.FromCSVProcedureSpec{}},
-			&plan.PhysicalPlanNode{Spec: &universe.RangeProcedureSpec{}},
+func TestExternPackage(t *testing.T) {
+	externs := []*ast.File{
+		nowExtern("2018-10-10T00:00:00Z"),
+		{
+			Name: "task.flux",
+			Body: []ast.Statement{
+				&ast.VariableAssignment{
+					ID:   &ast.Identifier{Name: "x"},
+					Init: &ast.IntegerLiteral{Value: 1},
+				},
+			},
 		},
-		Edges: [][2]int{
-			{0, 1},
+		nowExtern("2019-10-10T00:00:00Z"),
+	}
+	// The first extern also sets an option
+	// that is not set by the later ones.
+	externs[0].Body = append(externs[0].Body, &ast.OptionStatement{
+		Assignment: &ast.VariableAssignment{
+			ID:   &ast.Identifier{Name: "y"},
+			Init: &ast.IntegerLiteral{Value: 2},
 		},
-		Now: parser.MustParseTime("2018-10-10T00:00:00Z").Value,
 	})
+	extern, err := json.Marshal(externs)
+	if err != nil {
+		t.Fatal(err)
+	}
 
-	if err := plantest.ComparePlansShallow(want, program.PlanSpec); err != nil {
-		t.Fatalf("unexpected plans: %v", err)
+	bs, err := lang.ExternPackage(extern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pkg ast.Package
+	if err := json.Unmarshal(bs, &pkg); err != nil {
+		t.Fatal(err)
 	}
-}
 
-type removeCount struct{}
+	// The option statements of each file, in order.
+	type fileOptions struct {
+		Name       string
+		Statements int
+		Options    []string
+	}
+	var got []fileOptions
+	for _, file := range pkg.Files {
+		fo := fileOptions{Name: file.Name, Statements: len(file.Body)}
+		for _, stmt := range file.Body {
+			if opt, ok := stmt.(*ast.OptionStatement); ok {
+				fo.Options = append(fo.Options, opt.Assignment.(*ast.VariableAssignment).ID.Name)
+			}
+		}
+		got = append(got, fo)
+	}
+	want := []fileOptions{
+		{Name: "extern_0.flux", Statements: 1, Options: []string{"y"}},
+		{Name: "task.flux", Statements: 1},
+		{Name: "extern_2.flux", Statements: 1, Options: []string{"now"}},
+	}
+	if !cmp.Equal(want, got) {
+		t.Errorf("unexpected extern files -want/+got:\n%s", cmp.Diff(want, got))
+	}
 
-func (rule removeCount) Name() string {
-	return "removeCountRule"
-}
-func (rule removeCount) Pattern() plan.Pattern {
-	return plan.Pat(universe.CountKind, plan.Any())
+	// A single file is wrapped in a package as it is.
+	single, err := json.Marshal(externs[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bs, err := lang.ExternPackage(single); err != nil {
+		t.Fatal(err)
+	} else if want := fmt.Sprintf(`{"type":"Package","package":"main","files":[%s]}`, single); string(bs) != want {
+		t.Errorf("unexpected package -want/+got:\n\t- %s\n\t+ %s", want, bs)
+	}
 }
-func (rule removeCount) Rewrite(ctx context.Context, node plan.Node) (plan.Node, bool, error) {
-	return node.Predecessors()[0], true, nil
+
+func TestFluxCompiler_Seed(t *testing.T) {
+	now, err := time.Parse(time.RFC3339, "2020-12-04T13:00:00Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &lang.FluxCompiler{
+		Query: `package main
+import "generate"
+generate.from(count: 100, fn: (n) => n, start: 2020-12-04T00:00:00Z, stop: 2020-12-04T12:00:00Z)
+	|> sample(n: 7)
+`,
+		Now:  now,
+		Seed: 42,
+	}
+
+	run := func() string {
+		program, err := c.Compile(context.Background(), runtime.Default)
+		if err != nil {
+			t.Fatalf("unexpected compile error: %s", err)
+		}
+
+		mem := &memory.ResourceAllocator{}
+		qry, err := program.Start(context.Background(), mem)
+		if err != nil {
+			t.Fatalf("unexpected program error: %s", err)
+		}
+
+		results := flux.NewResultIteratorFromQuery(qry)
+		defer results.Release()
+
+		var b strings.Builder
+		enc := fcsv.NewMultiResultEncoder(fcsv.DefaultEncoderConfig())
+		if _, err := enc.Encode(&b, results); err != nil {
+			t.Fatalf("unexpected encode error: %s", err)
+		}
+		return b.String()
+	}
+
+	want, got := run(), run()
+	if want != got {
+		t.Fatalf("unexpected output -want/+got:\n%s", diff.LineDiff(want, got))
+	}
 }
 
-func TestCompileOptions_FromFluxOptions(t *testing.T) {
-	nowFn := func() time.Time {
-		return parser.MustParseTime("2018-10-10T00:00:00Z").Value
+// TestFluxCompiler_SortTables verifies that a grouping query produces
+// byte-identical CSV each time it runs when the tables are sorted.
+// Sorting buffers every table in a result before the first one is
+// encoded, so it trades streaming output for stable output.
+func TestFluxCompiler_SortTables(t *testing.T) {
+	now, err := time.Parse(time.RFC3339, "2020-12-04T13:00:00Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &lang.FluxCompiler{
+		Query: `package main
+import "array"
+array.from(rows: [
+	{_time: 2020-12-04T00:00:00Z, _value: 1, t0: "d"},
+	{_time: 2020-12-04T00:00:00Z, _value: 2, t0: "b"},
+	{_time: 2020-12-04T00:00:00Z, _value: 3, t0: "e"},
+	{_time: 2020-12-04T00:00:00Z, _value: 4, t0: "a"},
+	{_time: 2020-12-04T00:00:00Z, _value: 5, t0: "c"},
+	{_time: 2020-12-04T00:01:00Z, _value: 6, t0: "a"},
+	{_time: 2020-12-04T00:01:00Z, _value: 7, t0: "d"},
+])
+	|> group(columns: ["t0"])
+`,
+		Now:        now,
+		SortTables: true,
 	}
-	// If this test is run with a count of greater than one, we panic here
-	// because removeCount is already registered.
-	plan.RegisterLogicalRules(&removeCount{})
 
-	tcs := []struct {
-		name    string
-		files   []string
-		want    *plan.Spec
-		wantErr string
+	run := func() string {
+		program, err := c.Compile(context.Background(), runtime.Default)
+		if err != nil {
+			t.Fatalf("unexpected compile error: %s", err)
+		}
+
+		mem := &memory.ResourceAllocator{}
+		qry, err := program.Start(context.Background(), mem)
+		if err != nil {
+			t.Fatalf("unexpected program error: %s", err)
+		}
+
+		results := flux.NewResultIteratorFromQuery(qry)
+		defer results.Release()
+
+		var b strings.Builder
+		enc := fcsv.NewMultiResultEncoder(fcsv.DefaultEncoderConfig())
+		if _, err := enc.Encode(&b, results); err != nil {
+			t.Fatalf("unexpected encode error: %s", err)
+		}
+		return b.String()
+	}
+
+	want := run()
+	for i := 0; i < 10; i++ {
+		if got := run(); want != got {
+			t.Fatalf("unexpected output -want/+got:\n%s", diff.LineDiff(want, got))
+		}
+	}
+}
+
+func TestFluxCompiler_LocationOption(t *testing.T) {
+	// The row is at 23:30 in UTC and 00:30 on the next day in Europe/Berlin.
+	const prefix = `
+import "array"
+import "date"
+
+option location = {zone: "Europe/Berlin", offset: 0h}
+
+data = array.from(rows: [{_time: 2022-01-01T23:30:00Z, _value: 1}])
+`
+	for _, tc := range []struct {
+		name  string
+		query string
+		want  []string
 	}{
 		{
-			name: "no planner option set",
-			files: []string{`
-import "planner"
-
-from(bucket: "bkt") |> range(start: 0) |> filter(fn: (r) => r._value > 0) |> count()`},
-			want: plantest.CreatePlanSpec(&plantest.PlanSpec{
-				Nodes: []plan.Node{
-					&plan.PhysicalPlanNode{Spec: &influxdb.FromRemoteProcedureSpec{}},
-				},
-				Edges: [][2]int{},
-				Now:   nowFn(),
-			}),
+			name:  "window",
+			query: `data |> window(every: 1d) |> keep(columns: ["_start"]) |> rename(columns: {_start: "t"})`,
+			want:  []string{"2022-01-01T23:00:00Z"},
 		},
 		{
-			name: "remove push down filter",
-			files: []string{`
-import "planner"
-
-option planner.disablePhysicalRules = ["influxdata/influxdb.MergeRemoteFilterRule"]
-
-from(bucket: "bkt") |> range(start: 0) |> filter(fn: (r) => r._value > 0) |> count()`},
-			want: plantest.CreatePlanSpec(&plantest.PlanSpec{
-				Nodes: []plan.Node{
-					&plan.PhysicalPlanNode{Spec: &influxdb.FromRemoteProcedureSpec{}},
-					&plan.PhysicalPlanNode{Spec: &universe.FilterProcedureSpec{}},
-				},
-				Edges: [][2]int{
-					{0, 1},
-				},
-				Now: nowFn(),
-			}),
+			name:  "window utc",
+			query: `data |> window(every: 1d, location: {zone: "UTC", offset: 0h}) |> keep(columns: ["_start"]) |> rename(columns: {_start: "t"})`,
+			want:  []string{"2022-01-01T00:00:00Z"},
 		},
 		{
-			name: "remove push down filter and count",
-			files: []string{`
-import "planner"
-
-option planner.disablePhysicalRules = ["influxdata/influxdb.MergeRemoteFilterRule"]
-option planner.disableLogicalRules = ["removeCountRule"]
-
-from(bucket: "bkt") |> range(start: 0) |> filter(fn: (r) => r._value > 0) |> count()`},
-			want: plantest.CreatePlanSpec(&plantest.PlanSpec{
-				Nodes: []plan.Node{
-					&plan.PhysicalPlanNode{Spec: &influxdb.FromRemoteProcedureSpec{}},
-					&plan.PhysicalPlanNode{Spec: &universe.FilterProcedureSpec{}},
-					&plan.PhysicalPlanNode{Spec: &universe.CountProcedureSpec{}},
-				},
-				Edges: [][2]int{
-					{0, 1},
-					{1, 2},
-				},
-				Now: nowFn(),
-			}),
+			name:  "date.truncate",
+			query: `data |> map(fn: (r) => ({t: date.truncate(t: r._time, unit: 1d)}))`,
+			want:  []string{"2022-01-01T23:00:00Z"},
 		},
 		{
-			name: "remove push down filter and count - with non existent rule",
-			files: []string{`
-import "planner"
+			name:  "date.truncate utc",
+			query: `data |> map(fn: (r) => ({t: date.truncate(t: r._time, unit: 1d, location: {zone: "UTC", offset: 0h})}))`,
+			want:  []string{"2022-01-01T00:00:00Z"},
+		},
+		{
+			name:  "hourSelection",
+			query: `data |> hourSelection(start: 0, stop: 0) |> keep(columns: ["_time"]) |> rename(columns: {_time: "t"})`,
+			want:  []string{"2022-01-01T23:30:00Z"},
+		},
+		{
+			name:  "hourSelection utc",
+			query: `data |> hourSelection(start: 0, stop: 0, location: {zone: "UTC", offset: 0h}) |> keep(columns: ["_time"]) |> rename(columns: {_time: "t"})`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := lang.FluxCompiler{Query: prefix + tc.query}
+			program, err := c.Compile(context.Background(), runtime.Default)
+			if err != nil {
+				t.Fatalf("unexpected compile error: %s", err)
+			}
+			qry, err := program.Start(context.Background(), &memory.ResourceAllocator{})
+			if err != nil {
+				t.Fatalf("unexpected program error: %s", err)
+			}
+			results := flux.NewResultIteratorFromQuery(qry)
+			defer results.Release()
+
+			var got []string
+			for results.More() {
+				if err := results.Next().Tables().Do(func(tbl flux.Table) error {
+					et, err := executetest.ConvertTable(tbl)
+					if err != nil {
+						return err
+					}
+					idx := execute.ColIdx("t", et.ColMeta)
+					for _, row := range et.Data {
+						got = append(got, row[idx].(values.Time).Time().Format(time.RFC3339))
+					}
+					return nil
+				}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := results.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if !cmp.Equal(tc.want, got) {
+				t.Errorf("unexpected times -want/+got:\n%s", cmp.Diff(tc.want, got))
+			}
+		})
+	}
+}
+
+func TestFluxCompiler_Variables(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		extern      *ast.File
+		vars        map[string]interface{}
+		q           string
+		compilerErr string
+		startErr    string
+	}{
+		{
+			name: "types",
+			vars: map[string]interface{}{
+				"s": `a "quoted" string`,
+				"i": int64(-3),
+				"f": -1.5,
+				"b": true,
+				"t": time.Date(2020, 12, 4, 0, 0, 0, 0, time.UTC),
+				"d": flux.ConvertDuration(-90 * time.Minute),
+			},
+			q: `s == "a \"quoted\" string" and i == -3 and f == -1.5 and b
+				and t == 2020-12-04T00:00:00Z and string(v: d) == "-1h30m"`,
+		},
+		{
+			name: "coerced types",
+			vars: map[string]interface{}{
+				"i":   2,
+				"i32": int32(3),
+				"f32": float32(0.5),
+				"d":   time.Minute,
+			},
+			q: `i + i32 == 5 and f32 == 0.5 and string(v: d) == "1m"`,
+		},
+		{
+			name: "zero duration",
+			vars: map[string]interface{}{
+				"d": flux.Duration{},
+			},
+			q: `string(v: d) == "0ns"`,
+		},
+		{
+			name: "with extern",
+			extern: &ast.File{
+				Body: []ast.Statement{
+					&ast.OptionStatement{
+						Assignment: &ast.VariableAssignment{
+							ID:   &ast.Identifier{Name: "twentySix"},
+							Init: &ast.IntegerLiteral{Value: 26},
+						},
+					},
+				},
+			},
+			vars: map[string]interface{}{
+				"one": int64(1),
+			},
+			q: `twentySix + one == 27`,
+		},
+		{
+			name: "missing variable",
+			vars: map[string]interface{}{
+				"present": int64(1),
+			},
+			q:        `present + missing == 2`,
+			startErr: "undefined identifier missing",
+		},
+		{
+			name: "unsupported type",
+			vars: map[string]interface{}{
+				"v": []string{"a"},
+			},
+			q:           `true`,
+			compilerErr: `variable "v" has unsupported type []string`,
+		},
+		{
+			name: "non-finite float",
+			vars: map[string]interface{}{
+				"f": math.Inf(1),
+			},
+			q:           `true`,
+			compilerErr: `variable "f" must be a finite float`,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			c := lang.FluxCompiler{
+				Query:     "import \"array\"\narray.from(rows: [{_value: " + tc.q + "}])",
+				Now:       time.Unix(1000, 0),
+				Variables: tc.vars,
+			}
+			if tc.extern != nil {
+				var err error
+				if c.Extern, err = json.Marshal(tc.extern); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			program, err := c.Compile(context.Background(), runtime.Default)
+			if err != nil {
+				if tc.compilerErr == "" {
+					t.Fatalf("failed to compile: %v", err)
+				} else if !strings.Contains(err.Error(), tc.compilerErr) {
+					t.Fatalf(`expected query to error with "%v" but got "%v"`, tc.compilerErr, err)
+				}
+				return
+			} else if tc.compilerErr != "" {
+				t.Fatalf("expected query to error with %q, but got no error", tc.compilerErr)
+			}
+
+			qry, err := program.Start(context.Background(), &memory.ResourceAllocator{})
+			if err != nil {
+				if tc.startErr == "" {
+					t.Fatalf("failed to start: %v", err)
+				} else if !strings.Contains(err.Error(), tc.startErr) {
+					t.Fatalf(`expected query to error with "%v" but got "%v"`, tc.startErr, err)
+				}
+				return
+			} else if tc.startErr != "" {
+				t.Fatalf("expected query to start with error %q, but got no error", tc.startErr)
+			}
+
+			results := flux.NewResultIteratorFromQuery(qry)
+			defer results.Release()
+
+			var got []bool
+			for results.More() {
+				if err := results.Next().Tables().Do(func(tbl flux.Table) error {
+					return tbl.Do(func(cr flux.ColReader) error {
+						vs := cr.Bools(0)
+						for i := 0; i < vs.Len(); i++ {
+							got = append(got, vs.Value(i))
+						}
+						return nil
+					})
+				}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := results.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if want := []bool{true}; !cmp.Equal(want, got) {
+				t.Errorf("unexpected result -want/+got:\n%s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
+
+func TestFluxCompiler_VariablesJSON(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		c       lang.FluxCompiler
+		data    string
+		want    map[string]interface{}
+		wantErr string
+	}{
+		{
+			name: "round trip",
+			c: lang.FluxCompiler{
+				Query: "x",
+				Variables: map[string]interface{}{
+					"s": "a",
+					"i": int64(1) << 60,
+					"f": 2.0,
+					"b": false,
+					"t": time.Date(2020, 12, 4, 0, 0, 0, 5, time.UTC),
+					"d": flux.ConvertDuration(-90 * time.Minute),
+				},
+			},
+			want: map[string]interface{}{
+				"s": "a",
+				"i": int64(1) << 60,
+				"f": 2.0,
+				"b": false,
+				"t": time.Date(2020, 12, 4, 0, 0, 0, 5, time.UTC),
+				"d": flux.ConvertDuration(-90 * time.Minute),
+			},
+		},
+		{
+			name: "coerced types",
+			c: lang.FluxCompiler{
+				Query: "x",
+				Variables: map[string]interface{}{
+					"i":   2,
+					"i32": int32(3),
+					"f32": float32(0.5),
+					"d":   time.Minute,
+				},
+			},
+			want: map[string]interface{}{
+				"i":   int64(2),
+				"i32": int64(3),
+				"f32": 0.5,
+				"d":   flux.ConvertDuration(time.Minute),
+			},
+		},
+		{
+			name: "plain json values",
+			data: `{"query":"x","variables":{"s":"a","i":2,"f":2.5,"b":true}}`,
+			want: map[string]interface{}{
+				"s": "a",
+				"i": int64(2),
+				"f": 2.5,
+				"b": true,
+			},
+		},
+		{
+			name:    "unknown type",
+			data:    `{"query":"x","variables":{"v":{"type":"regexp","value":"a"}}}`,
+			wantErr: `invalid value for variable "v": unknown variable type "regexp"`,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			data := []byte(tc.data)
+			if len(data) == 0 {
+				var err error
+				if data, err = json.Marshal(tc.c); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var got lang.FluxCompiler
+			if err := json.Unmarshal(data, &got); err != nil {
+				if tc.wantErr == "" || err.Error() != tc.wantErr {
+					t.Fatalf("unexpected error -want/+got:\n%s", cmp.Diff(tc.wantErr, err.Error()))
+				}
+				return
+			} else if tc.wantErr != "" {
+				t.Fatalf("expected error %q, but got none", tc.wantErr)
+			}
+
+			if diff := cmp.Diff(tc.want, got.Variables); diff != "" {
+				t.Errorf("unexpected variables -want/+got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCompiler_DryRun(t *testing.T) {
+	now := parser.MustParseTime("2018-10-10T00:00:00Z").Value
+	for _, tc := range []struct {
+		name    string
+		script  string
+		wantErr string
+	}{
+		{
+			name: "plan",
+			script: `
+import "csv"
+csv.from(csv: "#datatype,string,long,dateTime:RFC3339,double
+#group,false,false,false,false
+#default,_result,,,
+,result,table,_time,_value
+,,0,2018-10-09T00:00:00Z,1.0
+,,0,2018-10-09T00:01:00Z,2.0
+")
+	|> range(start: 2017-10-10T00:00:00Z)
+	|> filter(fn: (r) => r._value > 1.0)
+`,
+		},
+		{
+			name:    "no streaming data",
+			script:  `x = 1`,
+			wantErr: "no streaming data",
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			astPkg, err := runtime.Default.Parse(tc.script)
+			if err != nil {
+				t.Fatalf("failed to parse script: %v", err)
+			}
+			jsonPkg, err := parser.HandleToJSON(astPkg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, c := range []interface {
+				flux.Compiler
+				DryRun() (*plan.Spec, error)
+			}{
+				lang.FluxCompiler{Query: tc.script, Now: now},
+				lang.ASTCompiler{AST: jsonPkg, Now: now},
+			} {
+				got, err := c.DryRun()
+				if err != nil {
+					if tc.wantErr == "" {
+						t.Fatalf("unexpected %s dry run error: %v", c.CompilerType(), err)
+					} else if !strings.Contains(err.Error(), tc.wantErr) {
+						t.Fatalf(`expected %s dry run to error with "%v" but got "%v"`, c.CompilerType(), tc.wantErr, err)
+					}
+					continue
+				} else if tc.wantErr != "" {
+					t.Fatalf("expected %s dry run to error with %q, but got no error", c.CompilerType(), tc.wantErr)
+				}
+
+				// Planning the program does not hold any memory.
+				program, err := c.Compile(context.Background(), runtime.Default)
+				if err != nil {
+					t.Fatal(err)
+				}
+				astProg := program.(*lang.AstProgram)
+				mem := &memory.ResourceAllocator{}
+				if _, err := astProg.Plan(context.Background(), mem); err != nil {
+					t.Fatal(err)
+				}
+				if n := mem.Allocated(); n != 0 {
+					t.Errorf("expected planning %s program to hold no memory, got %d bytes", c.CompilerType(), n)
+				}
+
+				// Executing the program plans it again and produces the same plan.
+				q, err := astProg.Start(context.Background(), mem)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for res := range q.Results() {
+					if err := res.Tables().Do(func(tbl flux.Table) error {
+						return tbl.Do(func(flux.ColReader) error { return nil })
+					}); err != nil {
+						t.Fatal(err)
+					}
+				}
+				q.Done()
+				if err := q.Err(); err != nil {
+					t.Fatal(err)
+				}
+				if mem.MaxAllocated() == 0 {
+					t.Errorf("expected executing %s program to allocate memory", c.CompilerType())
+				}
+
+				want := fmt.Sprintf("%v", plan.Formatted(astProg.PlanSpec, plan.WithDetails()))
+				if got := fmt.Sprintf("%v", plan.Formatted(got, plan.WithDetails())); want != got {
+					t.Errorf("unexpected %s dry run plan -want/+got:\n%s", c.CompilerType(), diff.LineDiff(want, got))
+				}
+			}
+		})
+	}
+}
+
+func TestAstProgram_PlanErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		q       string
+		wantErr string
+	}{
+		{
+			name:    "no streaming data",
+			q:       `x = from(bucket: "foo") |> range(start: -5m)`,
+			wantErr: "no streaming data",
+		},
+		{
+			name: "undefined identifier",
+			q: `twentySeven = twentyFive + 2
+				from(bucket: "foo") |> range(start: -5m)`,
+			wantErr: "undefined identifier twentyFive",
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			run := func(fn func(ctx context.Context, program *lang.AstProgram) error) error {
+				program, err := lang.Compile(tc.q, runtime.Default, time.Unix(0, 0))
+				if err != nil {
+					t.Fatalf("failed to compile: %v", err)
+				}
+				ctx, deps := dependency.Inject(context.Background(), executetest.NewTestExecuteDependencies())
+				defer deps.Finish()
+				return fn(ctx, program)
+			}
+
+			sources := atomic.LoadInt32(&mockFromSources)
+			planErr := run(func(ctx context.Context, program *lang.AstProgram) error {
+				_, err := program.Plan(ctx, &memory.ResourceAllocator{})
+				return err
+			})
+			if planErr == nil {
+				t.Fatalf("expected planning to error with %q, but got no error", tc.wantErr)
+			} else if !strings.Contains(planErr.Error(), tc.wantErr) {
+				t.Fatalf(`expected planning to error with "%v" but got "%v"`, tc.wantErr, planErr)
+			}
+			if n := atomic.LoadInt32(&mockFromSources) - sources; n != 0 {
+				t.Errorf("expected planning to create no sources, got %d", n)
+			}
+
+			startErr := run(func(ctx context.Context, program *lang.AstProgram) error {
+				q, err := program.Start(ctx, &memory.ResourceAllocator{})
+				if err == nil {
+					q.Done()
+				}
+				return err
+			})
+			if diff := cmp.Diff(fmt.Sprint(startErr), fmt.Sprint(planErr)); diff != "" {
+				t.Errorf("unexpected plan error -start/+plan:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAstProgram_PlanDigest(t *testing.T) {
+	now := parser.MustParseTime("2018-10-10T00:00:00Z").Value
+	script := `
+import "csv"
+csv.from(csv: "#datatype,string,long,dateTime:RFC3339,double
+#group,false,false,false,false
+#default,_result,,,
+,result,table,_time,_value
+,,0,2018-10-09T00:00:00Z,1.0
+")
+	|> range(start: -1d)
+	|> filter(fn: (r) => r._value > 1.0)
+`
+	digest := func(script string, now time.Time) [32]byte {
+		t.Helper()
+		c := lang.FluxCompiler{Query: script, Now: now}
+		program, err := c.Compile(context.Background(), runtime.Default)
+		if err != nil {
+			t.Fatalf("failed to compile: %v", err)
+		}
+		astProg := program.(*lang.AstProgram)
+		if _, err := astProg.Plan(context.Background(), &memory.ResourceAllocator{}); err != nil {
+			t.Fatal(err)
+		}
+		return astProg.PlanDigest()
+	}
+
+	want := digest(script, now)
+	if want == [32]byte{} {
+		t.Fatal("expected a digest for a planned program")
+	}
+	for i := 0; i < 3; i++ {
+		if got := digest(script, now); got != want {
+			t.Fatalf("expected the digest to be stable across compilations, got %x and %x", want, got)
+		}
+	}
+
+	if got := digest(script, now.Add(time.Hour)); got == want {
+		t.Errorf("expected a different digest for a different now time, got %x", got)
+	}
+	other := strings.Replace(script, "r._value > 1.0", "r._value > 2.0", 1)
+	if got := digest(other, now); got == want {
+		t.Errorf("expected a different digest for a different query, got %x", got)
+	}
+
+	// A program that was not planned has no digest.
+	program, err := lang.FluxCompiler{Query: script, Now: now}.Compile(context.Background(), runtime.Default)
+	if err != nil {
+		t.Fatalf("failed to compile: %v", err)
+	}
+	if got := program.(*lang.AstProgram).PlanDigest(); got != [32]byte{} {
+		t.Errorf("expected the zero digest for a program that was not planned, got %x", got)
+	}
+}
+
+func TestAstProgram_PlanString(t *testing.T) {
+	now := parser.MustParseTime("2018-10-10T00:00:00Z").Value
+	script := `
+from(bucket: "telegraf", host: "http://localhost:8086", token: "mytoken")
+	|> range(start: -1h)
+	|> filter(fn: (r) => r._value > 0.0)
+	|> count()
+`
+	planString := func(t *testing.T, format string) string {
+		t.Helper()
+		program, err := lang.FluxCompiler{Query: script, Now: now}.Compile(context.Background(), runtime.Default)
+		if err != nil {
+			t.Fatalf("failed to compile: %v", err)
+		}
+		astProg := program.(*lang.AstProgram)
+		if _, err := astProg.Plan(context.Background(), &memory.ResourceAllocator{}); err != nil {
+			t.Fatal(err)
+		}
+		s, err := astProg.PlanString(format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	for _, format := range []string{lang.PlanFormatJSON, lang.PlanFormatDOT} {
+		t.Run(format, func(t *testing.T) {
+			want := planString(t, format)
+			if got := planString(t, format); got != want {
+				t.Fatalf("expected the plan to be the same across compilations -want/+got:\n%s", cmp.Diff(want, got))
+			}
+			if strings.Contains(want, "mytoken") {
+				t.Errorf("expected the token to be redacted, got:\n%s", want)
+			}
+			for _, kind := range []string{"fromRemote", "filter", "count"} {
+				if !strings.Contains(want, kind) {
+					t.Errorf("expected %s in the plan, got:\n%s", kind, want)
+				}
+			}
+
+			switch format {
+			case lang.PlanFormatJSON:
+				var p struct {
+					Nodes []struct {
+						Spec map[string]interface{} `json:"spec"`
+					} `json:"nodes"`
+				}
+				if err := json.Unmarshal([]byte(want), &p); err != nil {
+					t.Fatal(err)
+				}
+				if len(p.Nodes) == 0 {
+					t.Errorf("expected nodes in the plan, got:\n%s", want)
+				}
+				if !strings.Contains(want, "(r) => r._value > 0.000000") {
+					t.Errorf("expected the filter function in the plan, got:\n%s", want)
+				}
+			case lang.PlanFormatDOT:
+				if !strings.HasPrefix(want, "digraph {\n") {
+					t.Errorf("expected a digraph, got:\n%s", want)
+				}
+			}
+		})
+	}
+
+	// A program that was not planned has no plan to write.
+	program, err := lang.FluxCompiler{Query: script, Now: now}.Compile(context.Background(), runtime.Default)
+	if err != nil {
+		t.Fatalf("failed to compile: %v", err)
+	}
+	if _, err := program.(*lang.AstProgram).PlanString(lang.PlanFormatJSON); err == nil {
+		t.Error("expected an error for a program that was not planned, got none")
+	} else if code := flux.ErrorCode(err); code != codes.FailedPrecondition {
+		t.Errorf("unexpected error code -want/+got\n\t- %v\n\t+ %v", codes.FailedPrecondition, code)
+	}
+}
+
+func TestSemanticFingerprint(t *testing.T) {
+	script := `
+import "csv"
+
+csv.from(csv: "data")
+	|> range(start: -1d)
+	|> filter(fn: (r) => r._value > 1.0)
+`
+	fingerprint := func(script string) [32]byte {
+		t.Helper()
+		program, err := lang.Compile(script, runtime.Default, time.Unix(0, 0))
+		if err != nil {
+			t.Fatalf("failed to compile: %v", err)
+		}
+		sum, err := lang.SemanticFingerprint(program)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sum
+	}
+
+	want := fingerprint(script)
+	if want == [32]byte{} {
+		t.Fatal("expected a fingerprint")
+	}
+	for name, script := range map[string]string{
+		"whitespace": `import "csv" csv.from(csv:"data")|>range(start:-1d)
+|>filter(fn:(r)=>
+	r._value>1.0)`,
+		"comments": `
+// Read the data.
+import "csv"
+
+csv.from(csv: "data") // from csv
+	// Keep the last day.
+	|> range(start: -1d)
+	|> filter(fn: (r) => r._value > 1.0)
+`,
+	} {
+		if got := fingerprint(script); got != want {
+			t.Errorf("%s: expected the same fingerprint, got %x and %x", name, want, got)
+		}
+	}
+	for name, script := range map[string]string{
+		"literal":    strings.Replace(script, "r._value > 1.0", "r._value > 2.0", 1),
+		"duration":   strings.Replace(script, "-1d", "-2d", 1),
+		"identifier": strings.Replace(script, "r._value", "r.value", 1),
+		"operator":   strings.Replace(script, "r._value > 1.0", "r._value >= 1.0", 1),
+		"filter":     script + "\t|> filter(fn: (r) => r._field == \"usage\")\n",
+	} {
+		if got := fingerprint(script); got == want {
+			t.Errorf("%s: expected a different fingerprint, got %x", name, got)
+		}
+	}
+}
+
+func TestSemanticFingerprint_Metadata(t *testing.T) {
+	program, err := lang.Compile(`
+import "csv"
+
+csv.from(csv: "#datatype,string,long,double
+#group,false,false,false
+#default,_result,,
+,result,table,_value
+,,0,1.0
+")`, runtime.Default, time.Unix(0, 0))
+	if err != nil {
+		t.Fatalf("failed to compile: %v", err)
+	}
+	ctx, deps := dependency.Inject(context.Background(), executetest.NewTestExecuteDependencies())
+	defer deps.Finish()
+	q, err := program.Start(ctx, memory.DefaultAllocator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for r := range q.Results() {
+		if err := r.Tables().Do(func(flux.Table) error { return nil }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	q.Done()
+	if err := q.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The fingerprint is kept after the program has been evaluated.
+	sum, err := program.SemanticFingerprint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{hex.EncodeToString(sum[:])}
+	if got := q.Statistics().Metadata[lang.SemanticFingerprintMetadataKey]; !cmp.Equal(want, got) {
+		t.Errorf("unexpected fingerprint in the metadata -want/+got:\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestAstProgram_EffectiveOptions(t *testing.T) {
+	extern, err := runtime.Default.Parse(`
+import "planner"
+
+option planner.disablePhysicalRules = ["fromRangeRule"]
+option location = {zone: "America/New_York", offset: 0h}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	program, err := lang.Compile(`
+import "array"
+import pl "planner"
+import "timezone"
+
+option pl.disableLogicalRules = ["removeCountRule"]
+option location = timezone.location(name: "Europe/Paris")
+
+array.from(rows: [{_value: 1}])`, runtime.Default, parser.MustParseTime("2018-10-10T00:00:00Z").Value,
+		lang.WithExtern(extern))
+	if err != nil {
+		t.Fatalf("failed to compile: %v", err)
+	}
+	if opts := program.EffectiveOptions(); opts != nil {
+		t.Errorf("expected no effective options before the program is started, got %v", opts)
+	}
+
+	ctx, deps := dependency.Inject(context.Background(), executetest.NewTestExecuteDependencies())
+	defer deps.Finish()
+	q, err := program.Start(ctx, memory.DefaultAllocator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for r := range q.Results() {
+		if err := r.Tables().Do(func(flux.Table) error { return nil }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	q.Done()
+	if err := q.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []lang.EffectiveOption{
+		{
+			Name:   "now",
+			Value:  parser.MustParseTime("2018-10-10T00:00:00Z").Value,
+			Source: lang.OptionSourceCompiler,
+		},
+		{
+			// The script shadows the location of the extern.
+			Name:   "location",
+			Value:  plan.Location{Name: "Europe/Paris"},
+			Source: lang.OptionSourceScript,
+		},
+		{
+			// The option is set through the alias of the package.
+			Name:   "planner.disableLogicalRules",
+			Value:  []string{"removeCountRule"},
+			Source: lang.OptionSourceScript,
+		},
+		{
+			Name:   "planner.disablePhysicalRules",
+			Value:  []string{"fromRangeRule"},
+			Source: lang.OptionSourceExtern,
+		},
+		{
+			Name:   "profiler.enabledProfilers",
+			Value:  []string{},
+			Source: lang.OptionSourceDefault,
+		},
+	}
+	if got := program.EffectiveOptions(); !cmp.Equal(want, got) {
+		t.Errorf("unexpected effective options -want/+got:\n%s", cmp.Diff(want, got))
+	}
+	if got := q.Statistics().Metadata[lang.EffectiveOptionsMetadataKey]; !cmp.Equal([]interface{}{want}, got) {
+		t.Errorf("unexpected effective options in the metadata -want/+got:\n%s", cmp.Diff([]interface{}{want}, got))
+	}
+}
+
+func TestAstProgram_Metrics(t *testing.T) {
+	program, err := lang.Compile(`
+import "array"
+
+array.from(rows: [
+	{_time: 2018-10-10T00:00:00Z, _value: 1.0, t0: "a"},
+	{_time: 2018-10-10T00:00:01Z, _value: 2.0, t0: "a"},
+	{_time: 2018-10-10T00:00:02Z, _value: 3.0, t0: "b"},
+	{_time: 2018-10-10T00:00:03Z, _value: 4.0, t0: "b"},
+])
+	|> filter(fn: (r) => r._value > 1.5)
+	|> map(fn: (r) => ({r with _value: r._value * 2.0}))
+	|> group(columns: ["t0"])
+`, runtime.Default, time.Unix(0, 0), lang.WithMetrics())
+	if err != nil {
+		t.Fatalf("failed to compile: %v", err)
+	}
+	ctx, deps := dependency.Inject(context.Background(), executetest.NewTestExecuteDependencies())
+	defer deps.Finish()
+	q, err := program.Start(ctx, memory.DefaultAllocator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for r := range q.Results() {
+		if err := r.Tables().Do(func(tbl flux.Table) error {
+			return tbl.Do(func(flux.ColReader) error { return nil })
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	q.Done()
+	if err := q.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	metrics := make(map[plan.ProcedureKind]execute.TransformationMetric)
+	for _, m := range program.Metrics() {
+		metrics[m.Kind] = m
+	}
+	filter, ok := metrics[universe.FilterKind]
+	if !ok {
+		t.Fatalf("expected the metrics of filter, got %+v", program.Metrics())
+	}
+	if filter.RowsIn != 4 {
+		t.Errorf("unexpected rows sent to filter -want/+got\n\t- %d\n\t+ %d", 4, filter.RowsIn)
+	}
+	if filter.RowsOut > filter.RowsIn {
+		t.Errorf("expected filter to produce at most the %d rows sent to it, got %d", filter.RowsIn, filter.RowsOut)
+	}
+	m, ok := metrics[universe.MapKind]
+	if !ok {
+		t.Fatalf("expected the metrics of map, got %+v", program.Metrics())
+	}
+	if m.RowsOut != m.RowsIn {
+		t.Errorf("expected map to produce the %d rows sent to it, got %d", m.RowsIn, m.RowsOut)
+	}
+	if m.RowsIn != filter.RowsOut {
+		t.Errorf("expected map to read the %d rows produced by filter, got %d", filter.RowsOut, m.RowsIn)
+	}
+	if _, ok := metrics[universe.GroupKind]; !ok {
+		t.Errorf("expected the metrics of group, got %+v", program.Metrics())
+	}
+}
+
+func TestAstProgram_MemoryLimit(t *testing.T) {
+	const query = `
+import "generate"
+
+generate.from(start: 2018-10-10T00:00:00Z, stop: 2018-10-11T00:00:00Z, count: 1000, fn: (n) => n)
+	|> map(fn: (r) => ({r with label: "row"}))
+`
+	run := func(limit int64) error {
+		program, err := lang.Compile(query, runtime.Default, time.Unix(0, 0), lang.WithMemoryLimit(limit))
+		if err != nil {
+			t.Fatalf("failed to compile: %v", err)
+		}
+		ctx, deps := dependency.Inject(context.Background(), executetest.NewTestExecuteDependencies())
+		defer deps.Finish()
+		q, err := program.Start(ctx, memory.DefaultAllocator)
+		if err != nil {
+			return err
+		}
+		for r := range q.Results() {
+			if err := r.Tables().Do(func(tbl flux.Table) error {
+				return tbl.Do(func(flux.ColReader) error { return nil })
+			}); err != nil {
+				q.Cancel()
+				q.Done()
+				return err
+			}
+		}
+		q.Done()
+		return q.Err()
+	}
+
+	err := run(1024)
+	if err == nil {
+		t.Fatal("expected the query to exceed the memory limit")
+	}
+	if want, got := codes.ResourceExhausted, flux.ErrorCode(err); want != got {
+		t.Errorf("unexpected error code -want/+got:\n\t- %v\n\t+ %v", want, got)
+	}
+	if !strings.Contains(err.Error(), "limit 1024 bytes") {
+		t.Errorf("expected the error to include the limit, got %q", err)
+	}
+	if !strings.Contains(err.Error(), "exceeded the memory limit") {
+		t.Errorf("expected the error to name the operation, got %q", err)
+	}
+
+	if err := run(1 << 30); err != nil {
+		t.Fatalf("unexpected error with a larger limit: %v", err)
+	}
+}
+
+func TestAstProgram_NodeStatistics(t *testing.T) {
+	program, err := lang.Compile(`
+import "array"
+
+array.from(rows: [
+	{_time: 2018-10-10T00:00:00Z, _value: 1.0},
+	{_time: 2018-10-10T00:00:01Z, _value: 2.0},
+	{_time: 2018-10-10T00:00:02Z, _value: 3.0},
+	{_time: 2018-10-10T00:00:03Z, _value: 4.0},
+])
+	|> filter(fn: (r) => r._value > 1.5)
+	|> map(fn: (r) => ({r with _value: r._value * 2.0}))
+`, runtime.Default, time.Unix(0, 0), lang.WithMetrics())
+	if err != nil {
+		t.Fatalf("failed to compile: %v", err)
+	}
+	ctx, deps := dependency.Inject(context.Background(), executetest.NewTestExecuteDependencies())
+	defer deps.Finish()
+	q, err := program.Start(ctx, memory.DefaultAllocator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for r := range q.Results() {
+		if err := r.Tables().Do(func(tbl flux.Table) error {
+			return tbl.Do(func(flux.ColReader) error { return nil })
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	q.Done()
+	if err := q.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	nodes := q.Statistics().Nodes
+	if len(nodes) != 3 {
+		t.Fatalf("expected the statistics of 3 nodes, got %+v", nodes)
+	}
+	// The nodes are in the order of the plan.
+	for i, want := range []plan.ProcedureKind{array.FromKind, universe.FilterKind, universe.MapKind} {
+		if got := plan.ProcedureKind(nodes[i].Operation); got != want {
+			t.Errorf("unexpected operation of node %d -want/+got\n\t- %s\n\t+ %s", i, want, got)
+		}
+		if nodes[i].Duration <= 0 {
+			t.Errorf("expected %s to take some time, got %v", nodes[i].ID, nodes[i].Duration)
+		}
+	}
+	from, filter, m := nodes[0], nodes[1], nodes[2]
+	if from.RowsIn != 0 || from.RowsOut != 4 {
+		t.Errorf("expected array.from to produce 4 rows, got %d rows in and %d rows out", from.RowsIn, from.RowsOut)
+	}
+	if filter.RowsIn != 4 || filter.RowsOut != 3 {
+		t.Errorf("expected filter to keep 3 of 4 rows, got %d rows in and %d rows out", filter.RowsIn, filter.RowsOut)
+	}
+	if m.RowsIn != 3 || m.RowsOut != 3 {
+		t.Errorf("expected map to produce the 3 rows sent to it, got %d rows in and %d rows out", m.RowsIn, m.RowsOut)
+	}
+	if m.Allocated <= 0 {
+		t.Errorf("expected map to allocate memory, got %d bytes", m.Allocated)
+	}
+}
+
+func TestCompileOptions(t *testing.T) {
+	src := `import "csv"
+			csv.from(csv: "foo,bar")
+				|> range(start: 2017-10-10T00:00:00Z)
+				|> count()`
+
+	now := parser.MustParseTime("2018-10-10T00:00:00Z").Value
+
+	opt := lang.WithLogPlanOpts(plan.OnlyLogicalRules(removeCount{}))
+
+	program, err := lang.Compile(src, runtime.Default, now, opt)
+	if err != nil {
+		t.Fatalf("failed to compile script: %v", err)
+	}
+
+	// start program in order to evaluate planner options
+	ctx, deps := dependency.Inject(context.Background(), executetest.NewTestExecuteDependencies())
+	defer deps.Finish()
+
+	if _, err := program.Start(ctx, &memory.ResourceAllocator{}); err != nil {
+		t.Fatalf("failed to start program: %v", err)
+	}
+
+	want := plantest.CreatePlanSpec(&plantest.PlanSpec{
+		Nodes: []plan.Node{
+			&plan.PhysicalPlanNode{Spec: &csv.FromCSVProcedureSpec{}},
+			&plan.PhysicalPlanNode{Spec: &universe.RangeProcedureSpec{}},
+		},
+		Edges: [][2]int{
+			{0, 1},
+		},
+		Now: parser.MustParseTime("2018-10-10T00:00:00Z").Value,
+	})
+
+	if err := plantest.ComparePlansShallow(want, program.PlanSpec); err != nil {
+		t.Fatalf("unexpected plans: %v", err)
+	}
+}
+
+func TestCompileOptions_MaxOptimizationPasses(t *testing.T) {
+	// The removeCount rule removes two of the count calls
+	// in the first pass and the last one in the second pass.
+	src := `import "csv"
+			csv.from(csv: "foo,bar")
+				|> range(start: 2017-10-10T00:00:00Z)
+				|> count()
+				|> count()
+				|> count()`
+
+	now := parser.MustParseTime("2018-10-10T00:00:00Z").Value
+	optimized := plantest.CreatePlanSpec(&plantest.PlanSpec{
+		Nodes: []plan.Node{
+			&plan.PhysicalPlanNode{Spec: &csv.FromCSVProcedureSpec{}},
+			&plan.PhysicalPlanNode{Spec: &universe.RangeProcedureSpec{}},
+		},
+		Edges: [][2]int{
+			{0, 1},
+		},
+		Now: now,
+	})
+
+	for _, tc := range []struct {
+		name     string
+		passes   int
+		want     *plan.Spec
+		wantWarn bool
+	}{
+		{
+			name:   "unlimited",
+			passes: 0,
+			want:   optimized,
+		},
+		{
+			name:   "enough passes",
+			passes: 10,
+			want:   optimized,
+		},
+		{
+			name:   "one pass",
+			passes: 1,
+			want: plantest.CreatePlanSpec(&plantest.PlanSpec{
+				Nodes: []plan.Node{
+					&plan.PhysicalPlanNode{Spec: &csv.FromCSVProcedureSpec{}},
+					&plan.PhysicalPlanNode{Spec: &universe.RangeProcedureSpec{}},
+					&plan.PhysicalPlanNode{Spec: &universe.CountProcedureSpec{}},
+				},
+				Edges: [][2]int{
+					{0, 1},
+					{1, 2},
+				},
+				Now: now,
+			}),
+			wantWarn: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			program, err := lang.Compile(src, runtime.Default, now,
+				lang.WithLogPlanOpts(plan.OnlyLogicalRules(removeCount{})),
+				lang.WithMaxOptimizationPasses(tc.passes),
+			)
+			if err != nil {
+				t.Fatalf("failed to compile script: %v", err)
+			}
+			core, logs := observer.New(zap.WarnLevel)
+			program.SetLogger(zap.New(core))
+
+			ctx, deps := dependency.Inject(context.Background(), executetest.NewTestExecuteDependencies())
+			defer deps.Finish()
+			if _, err := program.Plan(ctx, &memory.ResourceAllocator{}); err != nil {
+				t.Fatalf("failed to plan program: %v", err)
+			}
+
+			if err := plantest.ComparePlansShallow(tc.want, program.PlanSpec); err != nil {
+				t.Fatalf("unexpected plans: %v", err)
+			}
+			if got := logs.Len() > 0; got != tc.wantWarn {
+				t.Errorf("expected a warning to be logged: %v, got %d log entries", tc.wantWarn, logs.Len())
+			}
+		})
+	}
+}
+
+func TestCompileOptions_RuleMetrics(t *testing.T) {
+	// The removeCount rule removes one count call in each of the first
+	// three passes. The fourth pass matches no count call.
+	src := `import "csv"
+			csv.from(csv: "foo,bar")
+				|> range(start: 2017-10-10T00:00:00Z)
+				|> count()
+				|> count()
+				|> count()`
+
+	var metrics []plan.RuleMetrics
+	program, err := lang.Compile(src, runtime.Default, parser.MustParseTime("2018-10-10T00:00:00Z").Value,
+		lang.WithLogPlanOpts(plan.OnlyLogicalRules(removeCount{})),
+		lang.WithRuleMetrics(&metrics),
+	)
+	if err != nil {
+		t.Fatalf("failed to compile script: %v", err)
+	}
+	ctx, deps := dependency.Inject(context.Background(), executetest.NewTestExecuteDependencies())
+	defer deps.Finish()
+	q, err := program.Start(ctx, &memory.ResourceAllocator{})
+	if err != nil {
+		t.Fatalf("failed to start program: %v", err)
+	}
+	for range q.Results() {
+	}
+	q.Done()
+
+	var total time.Duration
+	got := make(map[string]plan.RuleMetrics)
+	for _, m := range metrics {
+		got[m.RuleName] = m
+		total += m.Duration
+	}
+	if m := got["removeCountRule"]; m.Applications == 0 || m.Rewrites != 3 {
+		t.Errorf("expected the removeCount rule to be applied with 3 rewrites, got %+v", m)
+	}
+	if m := got["physicalConverterRule"]; m.Applications == 0 {
+		t.Errorf("expected the physical converter rule to be applied, got %+v", m)
+	}
+	if total <= 0 {
+		t.Errorf("expected a positive total duration, got %v", total)
+	}
+}
+
+type removeCount struct{}
+
+func (rule removeCount) Name() string {
+	return "removeCountRule"
+}
+func (rule removeCount) Pattern() plan.Pattern {
+	return plan.Pat(universe.CountKind, plan.Any())
+}
+func (rule removeCount) Rewrite(ctx context.Context, node plan.Node) (plan.Node, bool, error) {
+	return node.Predecessors()[0], true, nil
+}
+
+func TestCompileOptions_FromFluxOptions(t *testing.T) {
+	nowFn := func() time.Time {
+		return parser.MustParseTime("2018-10-10T00:00:00Z").Value
+	}
+	plan.RegisterLogicalRulesOnce(&removeCount{})
+	t.Cleanup(func() {
+		plan.UnregisterLogicalRule(removeCount{}.Name())
+	})
+
+	tcs := []struct {
+		name    string
+		files   []string
+		want    *plan.Spec
+		wantErr string
+	}{
+		{
+			name: "no planner option set",
+			files: []string{`
+import "planner"
+
+from(bucket: "bkt") |> range(start: 0) |> filter(fn: (r) => r._value > 0) |> count()`},
+			want: plantest.CreatePlanSpec(&plantest.PlanSpec{
+				Nodes: []plan.Node{
+					&plan.PhysicalPlanNode{Spec: &influxdb.FromRemoteProcedureSpec{}},
+				},
+				Edges: [][2]int{},
+				Now:   nowFn(),
+			}),
+		},
+		{
+			name: "remove push down filter",
+			files: []string{`
+import "planner"
+
+option planner.disablePhysicalRules = ["influxdata/influxdb.MergeRemoteFilterRule"]
+
+from(bucket: "bkt") |> range(start: 0) |> filter(fn: (r) => r._value > 0) |> count()`},
+			want: plantest.CreatePlanSpec(&plantest.PlanSpec{
+				Nodes: []plan.Node{
+					&plan.PhysicalPlanNode{Spec: &influxdb.FromRemoteProcedureSpec{}},
+					&plan.PhysicalPlanNode{Spec: &universe.FilterProcedureSpec{}},
+				},
+				Edges: [][2]int{
+					{0, 1},
+				},
+				Now: nowFn(),
+			}),
+		},
+		{
+			name: "remove push down filter and count",
+			files: []string{`
+import "planner"
+
+option planner.disablePhysicalRules = ["influxdata/influxdb.MergeRemoteFilterRule"]
+option planner.disableLogicalRules = ["removeCountRule"]
+
+from(bucket: "bkt") |> range(start: 0) |> filter(fn: (r) => r._value > 0) |> count()`},
+			want: plantest.CreatePlanSpec(&plantest.PlanSpec{
+				Nodes: []plan.Node{
+					&plan.PhysicalPlanNode{Spec: &influxdb.FromRemoteProcedureSpec{}},
+					&plan.PhysicalPlanNode{Spec: &universe.FilterProcedureSpec{}},
+					&plan.PhysicalPlanNode{Spec: &universe.CountProcedureSpec{}},
+				},
+				Edges: [][2]int{
+					{0, 1},
+					{1, 2},
+				},
+				Now: nowFn(),
+			}),
+		},
+		{
+			name: "remove push down filter and count - with non existent rule",
+			files: []string{`
+import "planner"
 
 option planner.disablePhysicalRules = ["influxdata/influxdb.MergeRemoteFilterRule", "non_existent"]
 option planner.disableLogicalRules = ["removeCountRule", "non_existent"]
@@ -825,6 +2258,65 @@ option planner.disableLogicalRules = ["removeCountRule"]`},
 	}
 }
 
+func TestCompileOptions_RemoveRules(t *testing.T) {
+	now := parser.MustParseTime("2018-10-10T00:00:00Z").Value
+	plan.RegisterLogicalRulesOnce(&removeCount{})
+	t.Cleanup(func() {
+		plan.UnregisterLogicalRule(removeCount{}.Name())
+	})
+
+	const query = `from(bucket: "bkt") |> range(start: 0) |> filter(fn: (r) => r._value > 0) |> count()`
+	planSpec := func(src string, opts ...lang.CompileOption) *plan.Spec {
+		t.Helper()
+		astPkg, err := runtime.Parse(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		program := lang.CompileAST(astPkg, runtime.Default, now, opts...)
+		ctx, deps := dependency.Inject(context.Background(), executetest.NewTestExecuteDependencies())
+		defer deps.Finish()
+		q, err := program.Start(ctx, &memory.ResourceAllocator{})
+		if err != nil {
+			t.Fatalf("failed to start program: %v", err)
+		}
+		q.Done()
+		return program.PlanSpec
+	}
+
+	// Removing the rules with the plan options gives the same
+	// plan as disabling them with the planner options.
+	want := planSpec(`
+import "planner"
+
+option planner.disablePhysicalRules = ["influxdata/influxdb.MergeRemoteFilterRule"]
+option planner.disableLogicalRules = ["removeCountRule"]
+
+` + query)
+	got := planSpec(query,
+		lang.WithLogPlanOpts(plan.RemoveLogicalRules("removeCountRule")),
+		lang.WithPhysPlanOpts(plan.RemovePhysicalRules("influxdata/influxdb.MergeRemoteFilterRule")),
+	)
+	if err := plantest.ComparePlansShallow(want, got); err != nil {
+		t.Errorf("unexpected plans: %v", err)
+	}
+
+	// The count is removed when only the physical rule is removed.
+	want = plantest.CreatePlanSpec(&plantest.PlanSpec{
+		Nodes: []plan.Node{
+			&plan.PhysicalPlanNode{Spec: &influxdb.FromRemoteProcedureSpec{}},
+			&plan.PhysicalPlanNode{Spec: &universe.FilterProcedureSpec{}},
+		},
+		Edges: [][2]int{
+			{0, 1},
+		},
+		Now: now,
+	})
+	got = planSpec(query, lang.WithPhysPlanOpts(plan.RemovePhysicalRules("influxdata/influxdb.MergeRemoteFilterRule")))
+	if err := plantest.ComparePlansShallow(want, got); err != nil {
+		t.Errorf("unexpected plans: %v", err)
+	}
+}
+
 func TestQueryTracing(t *testing.T) {
 	// temporarily install a mock tracer to see which spans are created.
 	oldTracer := opentracing.GlobalTracer()
@@ -990,7 +2482,7 @@ func TestTableObjectCompiler(t *testing.T) {
 
 	script := `import "csv"
 data = "` + dataRaw + `"
-csv.from(csv: data)
+csv.from(csv: "data")
 	|> range(start: 2017-10-10T00:00:00Z, stop: 2018-05-22T19:54:00Z)
 	|> filter(fn: (r) => r._value < 1000)`
 
@@ -0,0 +1,15 @@
+package lang
+
+import "github.com/influxdata/flux/plan"
+
+// NewProgram returns a Program for the plan that is
+// configured with the options.
+func NewProgram(ps *plan.Spec, opts ...CompileOption) *Program {
+	return &Program{
+		PlanSpec: ps,
+		opts:     applyOptions(opts...),
+	}
+}
+
+// ExternPackage wraps the extern of an ASTCompiler in a package.
+var ExternPackage = externPackage
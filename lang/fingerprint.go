@@ -0,0 +1,148 @@
+package lang
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"hash"
+	"strconv"
+	"time"
+
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/runtime"
+	"github.com/influxdata/flux/semantic"
+)
+
+// SemanticFingerprintMetadataKey is the key of the hex encoded
+// semantic fingerprint in the metadata of the statistics of a query.
+const SemanticFingerprintMetadataKey = "flux/semantic-fingerprint"
+
+// SemanticFingerprint returns the semantic fingerprint of the program.
+// See AstProgram.SemanticFingerprint.
+func SemanticFingerprint(p *AstProgram) ([32]byte, error) {
+	return p.SemanticFingerprint()
+}
+
+// SemanticFingerprint returns the SHA-256 digest of the semantic graph
+// of the program. Programs that only differ by their whitespace, their
+// comments or the positions of their nodes have the same fingerprint,
+// while a change to an identifier, a literal, an operator or the shape
+// of the graph changes it. The types that are inferred for the graph
+// are not part of the fingerprint. The now time and the compile options
+// of the program are not part of it either.
+//
+// The fingerprint of a program does not change between the patch
+// releases of a minor release. It may change between minor releases.
+//
+// The program is analyzed again to compute its fingerprint, so the
+// fingerprint must be computed before the program is evaluated.
+// The fingerprint is computed when the program is planned or started
+// and then kept, so it can be read after the program has started.
+func (p *AstProgram) SemanticFingerprint() ([32]byte, error) {
+	if p.fingerprint != nil {
+		return *p.fingerprint, nil
+	}
+	ast, err := p.GetAst()
+	if err != nil {
+		return [32]byte{}, err
+	}
+	// Analysis frees the AST, so a copy of it is analyzed.
+	// The feature flags of a context are not used, so the
+	// fingerprint does not change when they do.
+	data, err := json.Marshal(ast)
+	if err != nil {
+		return [32]byte{}, errors.Wrap(err, codes.Inherit, "could not copy the program to compute its fingerprint")
+	}
+	hdl, err := p.Runtime.JSONToHandle(data)
+	if err != nil {
+		return [32]byte{}, errors.Wrap(err, codes.Inherit, "could not copy the program to compute its fingerprint")
+	}
+	pkg, err := runtime.AnalyzePackage(context.Background(), hdl)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	sum := semanticDigest(pkg)
+	p.fingerprint = &sum
+	return sum, nil
+}
+
+// semanticDigest computes the digest of a semantic graph.
+func semanticDigest(n semantic.Node) [32]byte {
+	f := &fingerprinter{h: sha256.New()}
+	semantic.Walk(f, n)
+	var sum [32]byte
+	copy(sum[:], f.h.Sum(nil))
+	return sum
+}
+
+// fingerprinter writes an encoding of each node and its attributes
+// to a hash. The children of a node are written between the start
+// and the end of the node, so the shape of the graph is encoded.
+type fingerprinter struct {
+	h hash.Hash
+}
+
+func (f *fingerprinter) str(s string) {
+	f.int(int64(len(s)))
+	_, _ = f.h.Write([]byte(s))
+}
+
+func (f *fingerprinter) int(n int64) {
+	var b [binary.MaxVarintLen64]byte
+	_, _ = f.h.Write(b[:binary.PutVarint(b[:], n)])
+}
+
+func (f *fingerprinter) symbol(s semantic.Symbol) {
+	f.str(s.LocalName)
+	f.str(s.Package)
+}
+
+func (f *fingerprinter) Visit(node semantic.Node) semantic.Visitor {
+	f.str("(")
+	f.str(node.NodeType())
+	switch n := node.(type) {
+	case *semantic.Package:
+		f.str(n.Package)
+	case *semantic.Identifier:
+		f.symbol(n.Name)
+	case *semantic.IdentifierExpression:
+		f.symbol(n.Name)
+	case *semantic.MemberExpression:
+		f.symbol(n.Property)
+	case *semantic.BinaryExpression:
+		f.str(n.Operator.String())
+	case *semantic.UnaryExpression:
+		f.str(n.Operator.String())
+	case *semantic.LogicalExpression:
+		f.str(n.Operator.String())
+	case *semantic.TextPart:
+		f.str(n.Value)
+	case *semantic.BooleanLiteral:
+		f.str(strconv.FormatBool(n.Value))
+	case *semantic.DateTimeLiteral:
+		f.str(n.Value.UTC().Format(time.RFC3339Nano))
+	case *semantic.DurationLiteral:
+		f.int(int64(len(n.Values)))
+		for _, d := range n.Values {
+			f.int(d.Magnitude)
+			f.str(d.Unit)
+		}
+	case *semantic.IntegerLiteral:
+		f.int(n.Value)
+	case *semantic.UnsignedIntegerLiteral:
+		f.str(strconv.FormatUint(n.Value, 10))
+	case *semantic.FloatLiteral:
+		f.str(strconv.FormatFloat(n.Value, 'g', -1, 64))
+	case *semantic.RegexpLiteral:
+		f.str(n.Value.String())
+	case *semantic.StringLiteral:
+		f.str(n.Value)
+	}
+	return f
+}
+
+func (f *fingerprinter) Done(node semantic.Node) {
+	f.str(")")
+}
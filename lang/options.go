@@ -0,0 +1,179 @@
+package lang
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/interpreter"
+	"github.com/influxdata/flux/semantic"
+	"github.com/influxdata/flux/values"
+)
+
+// EffectiveOptionsMetadataKey is the key of the effective options
+// in the metadata of the statistics of a query.
+const EffectiveOptionsMetadataKey = "flux/effective-options"
+
+// Sources of the value of an option.
+const (
+	// OptionSourceDefault is the source of an option
+	// that was not set and has its default value.
+	OptionSourceDefault = "default"
+	// OptionSourceCompiler is the source of an option
+	// that was set by a field of the compiler.
+	OptionSourceCompiler = "compiler"
+	// OptionSourceExtern is the source of an option
+	// that was set by an option statement of the extern.
+	OptionSourceExtern = "extern"
+	// OptionSourceScript is the source of an option
+	// that was set by an option statement of the script.
+	OptionSourceScript = "script"
+)
+
+// EffectiveOption is the value of an option that a program used
+// and where that value came from.
+type EffectiveOption struct {
+	// Name is the name of the option. The name of an option of
+	// a package other than the prelude is prefixed by the path
+	// of the package, such as "planner.disableLogicalRules".
+	Name string `json:"name"`
+	// Value is the value of the option. It is a time.Time for now,
+	// a plan.Location for location and a []string for the others.
+	Value interface{} `json:"value"`
+	// Source is where the value came from, one of the OptionSource constants.
+	Source string `json:"source"`
+}
+
+// EffectiveOptions returns the values of the now, location, planner
+// and profiler options that the program used when it was last planned
+// or started, and whether each came from the compiler, the extern or
+// the script. An option set by the script shadows the extern, which
+// shadows the compiler. It returns nil before the program is planned.
+func (p *AstProgram) EffectiveOptions() []EffectiveOption {
+	return p.effectiveOptions
+}
+
+// optionSources returns the names of the options that are set
+// by the option statements of the extern and of the script.
+// An option that the script sets has the script as its source,
+// even when the extern sets it too.
+func optionSources(extern, script flux.ASTHandle) map[string]string {
+	sources := make(map[string]string)
+	if extern != nil {
+		for _, name := range optionStatements(extern) {
+			sources[name] = OptionSourceExtern
+		}
+	}
+	for _, name := range optionStatements(script) {
+		sources[name] = OptionSourceScript
+	}
+	return sources
+}
+
+// optionStatements returns the names of the options that are set
+// by the option statements of a package. The sources of the options
+// are only reported, so a package that cannot be read has none.
+func optionStatements(hdl flux.ASTHandle) []string {
+	data, err := json.Marshal(hdl)
+	if err != nil {
+		return nil
+	}
+	var pkg ast.Package
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil
+	}
+
+	var names []string
+	for _, file := range pkg.Files {
+		for _, stmt := range file.Body {
+			opt, ok := stmt.(*ast.OptionStatement)
+			if !ok {
+				continue
+			}
+			if name, ok := optionName(file, opt); ok {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// optionName returns the name of the option that is set by an
+// option statement of a file. The name of an option of another
+// package is qualified with the path of that package.
+func optionName(file *ast.File, opt *ast.OptionStatement) (string, bool) {
+	switch a := opt.Assignment.(type) {
+	case *ast.VariableAssignment:
+		return a.ID.Name, true
+	case *ast.MemberAssignment:
+		id, ok := a.Member.Object.(*ast.Identifier)
+		if !ok || a.Member.Property == nil {
+			return "", false
+		}
+		return importPath(file, id.Name) + "." + a.Member.Property.Key(), true
+	}
+	return "", false
+}
+
+// importPath returns the path of the package that
+// is imported with the given name by a file.
+func importPath(file *ast.File, name string) string {
+	for _, imp := range file.Imports {
+		if imp.Path == nil {
+			continue
+		}
+		if imp.As != nil {
+			if imp.As.Name == name {
+				return imp.Path.Value
+			}
+		} else if path.Base(imp.Path.Value) == name {
+			return imp.Path.Value
+		}
+	}
+	return name
+}
+
+// resolveOptions reads the values of the options that the
+// program used once it has been evaluated with the scope.
+func (p *AstProgram) resolveOptions(ctx context.Context, scope values.Scope) []EffectiveOption {
+	source := func(name, unset string) string {
+		if s, ok := p.optionSources[name]; ok {
+			return s
+		}
+		return unset
+	}
+
+	opts := []EffectiveOption{{
+		Name:   interpreter.NowOption,
+		Value:  p.Now,
+		Source: source(interpreter.NowOption, OptionSourceCompiler),
+	}, {
+		Name:   interpreter.LocationOption,
+		Value:  execute.GetLocation(ctx),
+		Source: source(interpreter.LocationOption, OptionSourceDefault),
+	}}
+	for _, opt := range []struct {
+		pkg, name string
+	}{
+		{pkg: "planner", name: "disableLogicalRules"},
+		{pkg: "planner", name: "disablePhysicalRules"},
+		{pkg: "profiler", name: "enabledProfilers"},
+	} {
+		name := opt.pkg + "." + opt.name
+		value := []string{}
+		if pkg, ok := getPackageFromScope(opt.pkg, scope); ok && pkg.Type().Nature() == semantic.Object {
+			if v, err := getOptionValues(pkg.Object(), opt.name); err == nil {
+				value = v
+			}
+		}
+		opts = append(opts, EffectiveOption{
+			Name:   name,
+			Value:  value,
+			Source: source(name, OptionSourceDefault),
+		})
+	}
+	return opts
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/influxdata/flux"
 	"github.com/influxdata/flux/dependencies/testing"
+	"github.com/influxdata/flux/execute"
 	"github.com/influxdata/flux/memory"
 	"github.com/opentracing/opentracing-go"
 )
@@ -21,6 +22,14 @@ type query struct {
 	cancel         func()
 	err            error
 	wg             sync.WaitGroup
+
+	// deadlineExceeded is set when the deadline of the
+	// query passed before its execution completed.
+	deadlineExceeded bool
+
+	// metrics collects the metrics of the nodes
+	// of the plan when they are enabled.
+	metrics *execute.TransformationMetrics
 }
 
 func (q *query) Results() <-chan flux.Result {
@@ -32,6 +41,9 @@ func (q *query) Done() {
 	q.wg.Wait()
 	q.stats.MaxAllocated = q.allocatorStats.MaxAllocated()
 	q.stats.TotalAllocated = q.allocatorStats.TotalAllocated()
+	if q.metrics != nil {
+		q.stats.Nodes = nodeStatistics(q.metrics.Metrics())
+	}
 	if q.span != nil {
 		q.span.Finish()
 		q.span = nil
@@ -40,12 +52,33 @@ func (q *query) Done() {
 	// Note: it is safe to read and write to q.err because we have explicitly
 	// waited on the wait group, therefore only a the current goroutine
 	// can access q.err
+	if q.err == nil && q.deadlineExceeded {
+		q.err = execute.ContextError(context.DeadlineExceeded)
+	}
 	if q.err == nil {
 		// If the testing framework was configured, verify all expectations.
 		q.err = testing.Check(q.ctx)
 	}
 }
 
+// nodeStatistics converts the metrics of the nodes of a plan.
+func nodeStatistics(metrics []execute.TransformationMetric) []flux.NodeStatistics {
+	nodes := make([]flux.NodeStatistics, len(metrics))
+	for i, m := range metrics {
+		nodes[i] = flux.NodeStatistics{
+			ID:        m.Name,
+			Operation: string(m.Kind),
+			Duration:  m.Duration,
+			RowsIn:    m.RowsIn,
+			RowsOut:   m.RowsOut,
+			BytesIn:   m.BytesIn,
+			BytesOut:  m.BytesOut,
+			Allocated: m.Allocated,
+		}
+	}
+	return nodes
+}
+
 func (q *query) Cancel() {
 	q.cancel()
 }
@@ -3,21 +3,70 @@ package lang_test
 import (
 	"context"
 	"fmt"
+	"math"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
 	ftesting "github.com/influxdata/flux/dependencies/testing"
 	"github.com/influxdata/flux/dependency"
+	"github.com/influxdata/flux/execute"
 	"github.com/influxdata/flux/execute/executetest"
 	_ "github.com/influxdata/flux/fluxinit/static"
 	"github.com/influxdata/flux/lang"
 	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/plan"
+	"github.com/influxdata/flux/plan/plantest"
 	"github.com/influxdata/flux/runtime"
+	"github.com/influxdata/flux/stdlib/universe"
 )
 
+func init() {
+	execute.RegisterSource(executetest.FromTestKind, executetest.CreateFromSource)
+	execute.RegisterSource(sleepKind, createSleepSource)
+}
+
+const sleepKind = "sleep-test"
+
+// sleepProcedureSpec is the spec of a source that sleeps
+// for its duration without checking the context.
+type sleepProcedureSpec struct {
+	plan.DefaultCost
+	d time.Duration
+}
+
+func (s *sleepProcedureSpec) Kind() plan.ProcedureKind {
+	return sleepKind
+}
+
+func (s *sleepProcedureSpec) Copy() plan.ProcedureSpec {
+	ns := *s
+	return &ns
+}
+
+func createSleepSource(spec plan.ProcedureSpec, id execute.DatasetID, a execute.Administration) (execute.Source, error) {
+	return &sleepSource{id: id, d: spec.(*sleepProcedureSpec).d}, nil
+}
+
+type sleepSource struct {
+	execute.ExecutionNode
+	id execute.DatasetID
+	d  time.Duration
+	ts execute.TransformationSet
+}
+
+func (s *sleepSource) AddTransformation(t execute.Transformation) {
+	s.ts = append(s.ts, t)
+}
+
+func (s *sleepSource) Run(ctx context.Context) {
+	time.Sleep(s.d)
+	s.ts.Finish(s.id, nil)
+}
+
 func runQuery(ctx context.Context, script string) (flux.Query, func(), error) {
 	program, err := lang.Compile(script, runtime.Default, time.Unix(0, 0))
 	if err != nil {
@@ -189,6 +238,127 @@ func TestQuery_Stats(t *testing.T) {
 	}
 }
 
+func TestQuery_NodeStatistics(t *testing.T) {
+	spec := plantest.CreatePlanSpec(&plantest.PlanSpec{
+		Nodes: []plan.Node{
+			plan.CreatePhysicalNode("from-test", executetest.NewFromProcedureSpec(
+				[]*executetest.Table{{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(0), 1.0},
+						{execute.Time(1), 2.0},
+						{execute.Time(2), 3.0},
+					},
+				}},
+			)),
+			plan.CreatePhysicalNode("limit", &universe.LimitProcedureSpec{N: 2}),
+			plan.CreatePhysicalNode("yield", executetest.NewYieldProcedureSpec("_result")),
+		},
+		Edges: [][2]int{
+			{0, 1},
+			{1, 2},
+		},
+		Resources: flux.ResourceManagement{
+			ConcurrencyQuota: 1,
+			MemoryBytesQuota: math.MaxInt64,
+		},
+		Now: time.Unix(0, 0),
+	})
+
+	ctx, deps := dependency.Inject(context.Background(), executetest.NewTestExecuteDependencies())
+	defer deps.Finish()
+	execDeps := execute.DefaultExecutionDependencies()
+	execDeps.ExecutionOptions.Metrics = execute.NewTransformationMetrics()
+	ctx = execDeps.Inject(ctx)
+
+	program := &lang.Program{PlanSpec: spec}
+	q, err := program.Start(ctx, memory.DefaultAllocator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for res := range q.Results() {
+		if err := res.Tables().Do(func(tbl flux.Table) error {
+			return tbl.Do(func(cr flux.ColReader) error {
+				return nil
+			})
+		}); err != nil {
+			t.Fatalf("unexpected error while iterating over tables: %s", err)
+		}
+	}
+	q.Done()
+	if err := q.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := q.Statistics().Nodes
+	for i := range got {
+		if got[i].Duration <= 0 {
+			t.Errorf("expected %s to take some time, got %v", got[i].ID, got[i].Duration)
+		}
+		got[i].Duration = 0
+		got[i].Allocated = 0
+	}
+	want := []flux.NodeStatistics{
+		{ID: "from-test", Operation: executetest.FromTestKind, RowsOut: 3, BytesOut: 3 * 16},
+		{ID: "limit", Operation: universe.LimitKind, RowsIn: 3, BytesIn: 3 * 16, RowsOut: 2, BytesOut: 2 * 16},
+	}
+	if !cmp.Equal(want, got) {
+		t.Errorf("unexpected node statistics -want/+got:\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestQuery_Timeout(t *testing.T) {
+	spec := plantest.CreatePlanSpec(&plantest.PlanSpec{
+		Nodes: []plan.Node{
+			plan.CreatePhysicalNode("sleep", &sleepProcedureSpec{d: 5 * time.Second}),
+			plan.CreatePhysicalNode("limit", &universe.LimitProcedureSpec{N: 2}),
+			plan.CreatePhysicalNode("yield", executetest.NewYieldProcedureSpec("_result")),
+		},
+		Edges: [][2]int{
+			{0, 1},
+			{1, 2},
+		},
+		Resources: flux.ResourceManagement{
+			ConcurrencyQuota: 1,
+			MemoryBytesQuota: math.MaxInt64,
+		},
+		Now: time.Unix(0, 0),
+	})
+
+	ctx, deps := dependency.Inject(context.Background(), executetest.NewTestExecuteDependencies())
+	defer deps.Finish()
+
+	start := time.Now()
+	program := lang.NewProgram(spec, lang.WithTimeout(50*time.Millisecond))
+	q, err := program.Start(ctx, memory.DefaultAllocator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for res := range q.Results() {
+		err := res.Tables().Do(func(tbl flux.Table) error {
+			return tbl.Do(func(cr flux.ColReader) error {
+				return nil
+			})
+		})
+		if got := flux.ErrorCode(err); got != codes.DeadlineExceeded {
+			t.Errorf("unexpected result error code -want/+got:\n\t- %s\n\t+ %s", codes.DeadlineExceeded, got)
+		}
+	}
+	q.Done()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the query to be done once its deadline passed, took %s", elapsed)
+	}
+	if got := flux.ErrorCode(q.Err()); got != codes.DeadlineExceeded {
+		t.Errorf("unexpected query error code -want/+got:\n\t- %s\n\t+ %s", codes.DeadlineExceeded, got)
+	}
+	if _, ok := q.Statistics().Metadata["flux/query-plan"]; !ok {
+		t.Error("expected the statistics of the query to be populated")
+	}
+}
+
 func TestQuery_RuntimeError(t *testing.T) {
 	var invalidScript = `
 import "csv"
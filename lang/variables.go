@@ -0,0 +1,257 @@
+package lang
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/internal/errors"
+)
+
+// Variable types used in the JSON encoding of FluxCompiler variables.
+const (
+	variableTypeString   = "string"
+	variableTypeInt      = "int"
+	variableTypeFloat    = "float"
+	variableTypeBool     = "bool"
+	variableTypeTime     = "time"
+	variableTypeDuration = "duration"
+)
+
+// variableJSON is the JSON encoding of a single variable.
+// The type is recorded with the value so that integers,
+// times and durations survive a round trip.
+type variableJSON struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+// normalizeVariable converts v to one of the supported variable types:
+// string, int64, float64, bool, time.Time or flux.Duration.
+func normalizeVariable(name string, v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case string, int64, float64, bool, time.Time, flux.Duration:
+		return v, nil
+	case int:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case float32:
+		return float64(v), nil
+	case time.Duration:
+		return flux.ConvertDuration(v), nil
+	default:
+		return nil, errors.Newf(codes.Invalid, "variable %q has unsupported type %T", name, v)
+	}
+}
+
+func marshalVariables(vars map[string]interface{}) (map[string]variableJSON, error) {
+	if vars == nil {
+		return nil, nil
+	}
+	out := make(map[string]variableJSON, len(vars))
+	for name, v := range vars {
+		v, err := normalizeVariable(name, v)
+		if err != nil {
+			return nil, err
+		}
+
+		var typ string
+		switch v.(type) {
+		case string:
+			typ = variableTypeString
+		case int64:
+			typ = variableTypeInt
+		case float64:
+			typ = variableTypeFloat
+		case bool:
+			typ = variableTypeBool
+		case time.Time:
+			typ = variableTypeTime
+		case flux.Duration:
+			typ = variableTypeDuration
+		}
+		value, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		out[name] = variableJSON{Type: typ, Value: value}
+	}
+	return out, nil
+}
+
+func unmarshalVariables(raw map[string]json.RawMessage) (map[string]interface{}, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	vars := make(map[string]interface{}, len(raw))
+	for name, data := range raw {
+		v, err := unmarshalVariable(data)
+		if err != nil {
+			return nil, errors.Wrapf(err, codes.Invalid, "invalid value for variable %q", name)
+		}
+		vars[name] = v
+	}
+	return vars, nil
+}
+
+// unmarshalVariable decodes a typed variable. Plain JSON strings,
+// numbers and booleans are also accepted so that clients do not need
+// to know the typed encoding. A number is an int if it has no
+// fractional part or exponent and a float otherwise.
+func unmarshalVariable(data json.RawMessage) (interface{}, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] != '{' {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			return nil, err
+		}
+		switch v := v.(type) {
+		case string, bool:
+			return v, nil
+		case json.Number:
+			if n, err := v.Int64(); err == nil {
+				return n, nil
+			}
+			return v.Float64()
+		default:
+			return nil, errors.Newf(codes.Invalid, "unsupported JSON value %s", data)
+		}
+	}
+
+	var vj variableJSON
+	if err := json.Unmarshal(data, &vj); err != nil {
+		return nil, err
+	}
+	var err error
+	switch vj.Type {
+	case variableTypeString:
+		var v string
+		err = json.Unmarshal(vj.Value, &v)
+		return v, err
+	case variableTypeInt:
+		var v int64
+		err = json.Unmarshal(vj.Value, &v)
+		return v, err
+	case variableTypeFloat:
+		var v float64
+		err = json.Unmarshal(vj.Value, &v)
+		return v, err
+	case variableTypeBool:
+		var v bool
+		err = json.Unmarshal(vj.Value, &v)
+		return v, err
+	case variableTypeTime:
+		var v time.Time
+		err = json.Unmarshal(vj.Value, &v)
+		return v, err
+	case variableTypeDuration:
+		var v flux.Duration
+		err = json.Unmarshal(vj.Value, &v)
+		return v, err
+	default:
+		return nil, errors.Newf(codes.Invalid, "unknown variable type %q", vj.Type)
+	}
+}
+
+// variableStatements converts the variables into option statements.
+// The statements are ordered by name so the generated extern is deterministic.
+func variableStatements(vars map[string]interface{}) ([]ast.Statement, error) {
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	stmts := make([]ast.Statement, 0, len(names))
+	for _, name := range names {
+		v, err := normalizeVariable(name, vars[name])
+		if err != nil {
+			return nil, err
+		}
+		expr, err := variableExpression(name, v)
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, &ast.OptionStatement{
+			Assignment: &ast.VariableAssignment{
+				ID:   &ast.Identifier{Name: name},
+				Init: expr,
+			},
+		})
+	}
+	return stmts, nil
+}
+
+// variableExpression returns the literal for a normalized variable.
+// Literals are never negative in the AST so negative numbers and
+// durations are wrapped in a unary minus.
+func variableExpression(name string, v interface{}) (ast.Expression, error) {
+	switch v := v.(type) {
+	case string:
+		return &ast.StringLiteral{Value: v}, nil
+	case int64:
+		if v < 0 {
+			if v == math.MinInt64 {
+				return nil, errors.Newf(codes.Invalid, "variable %q is out of range", name)
+			}
+			return negate(&ast.IntegerLiteral{Value: -v}), nil
+		}
+		return &ast.IntegerLiteral{Value: v}, nil
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, errors.Newf(codes.Invalid, "variable %q must be a finite float", name)
+		}
+		if v < 0 {
+			return negate(&ast.FloatLiteral{Value: -v}), nil
+		}
+		return &ast.FloatLiteral{Value: v}, nil
+	case bool:
+		return &ast.BooleanLiteral{Value: v}, nil
+	case time.Time:
+		return &ast.DateTimeLiteral{Value: v}, nil
+	case flux.Duration:
+		if v.IsZero() {
+			return &ast.DurationLiteral{Values: []ast.Duration{{Magnitude: 0, Unit: ast.NanosecondUnit}}}, nil
+		}
+		if v.IsNegative() {
+			return negate(&ast.DurationLiteral{Values: v.Mul(-1).AsValues()}), nil
+		}
+		return &ast.DurationLiteral{Values: v.AsValues()}, nil
+	default:
+		return nil, errors.Newf(codes.Internal, "variable %q has unsupported type %T", name, v)
+	}
+}
+
+func negate(expr ast.Expression) ast.Expression {
+	return &ast.UnaryExpression{
+		Operator: ast.SubtractionOperator,
+		Argument: expr,
+	}
+}
+
+// externWithVariables returns the JSON for an extern file that binds
+// each variable as an option before the statements of the extern.
+// Options declared by the extern are evaluated afterwards, so they
+// take precedence over variables with the same name.
+func externWithVariables(extern json.RawMessage, vars map[string]interface{}) (json.RawMessage, error) {
+	stmts, err := variableStatements(vars)
+	if err != nil {
+		return nil, err
+	}
+	file := &ast.File{}
+	if IsNonNullJSON(extern) {
+		if err := json.Unmarshal(extern, file); err != nil {
+			return nil, errors.Wrap(err, codes.Invalid, "extern json parse error")
+		}
+	}
+	file.Body = append(stmts, file.Body...)
+	return json.Marshal(file)
+}
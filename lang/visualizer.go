@@ -0,0 +1,149 @@
+package lang
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/plan"
+)
+
+// Formats of the plan visualization written by WithPlanVisualization.
+const (
+	PlanFormatDOT     = "dot"
+	PlanFormatMermaid = "mermaid"
+)
+
+// PlanVisualizer renders a plan as a graph with a node for each plan node
+// and an edge from each node to each of its successors. Each node shows
+// its ID, its procedure kind and, for a physical plan, the cost and rows
+// that the analysis of the plan estimates for it.
+type PlanVisualizer struct{}
+
+// visualNode is a plan node as it is rendered.
+type visualNode struct {
+	id    plan.NodeID
+	lines []string
+	preds []int
+}
+
+// nodes returns the nodes of the plan with their predecessors
+// before them, so the output is the same each time.
+func (PlanVisualizer) nodes(p *plan.Spec) []visualNode {
+	var estimates map[plan.NodeID]NodeAnalysis
+	if analysis, err := analyzePlan(p, 0); err == nil {
+		estimates = make(map[plan.NodeID]NodeAnalysis, len(analysis.Nodes))
+		for _, n := range analysis.Nodes {
+			estimates[n.ID] = n
+		}
+	}
+
+	var nodes []visualNode
+	index := make(map[plan.Node]int)
+	_ = p.BottomUpWalk(func(pn plan.Node) error {
+		n := visualNode{
+			id:    pn.ID(),
+			lines: []string{string(pn.ID()), string(pn.Kind())},
+		}
+		if est, ok := estimates[pn.ID()]; ok {
+			n.lines = append(n.lines,
+				fmt.Sprintf("cost: %s", formatCost(est.Cost)),
+				fmt.Sprintf("rows: %d", est.Rows),
+			)
+		}
+		for _, pred := range pn.Predecessors() {
+			n.preds = append(n.preds, index[pred])
+		}
+		index[pn] = len(nodes)
+		nodes = append(nodes, n)
+		return nil
+	})
+	return nodes
+}
+
+// formatCost lists the dimensions of the cost that are not zero.
+func formatCost(c plan.Cost) string {
+	var parts []string
+	for _, dim := range []struct {
+		name  string
+		value int64
+	}{
+		{"disk", c.Disk},
+		{"cpu", c.CPU},
+		{"gpu", c.GPU},
+		{"mem", c.MEM},
+		{"net", c.NET},
+	} {
+		if dim.value != 0 {
+			parts = append(parts, fmt.Sprintf("%s=%d", dim.name, dim.value))
+		}
+	}
+	if len(parts) == 0 {
+		return "0"
+	}
+	return strings.Join(parts, " ")
+}
+
+// ToDOT renders the plan in the DOT language of Graphviz.
+func (v PlanVisualizer) ToDOT(p *plan.Spec) string {
+	nodes := v.nodes(p)
+	var b strings.Builder
+	b.WriteString("digraph {\n")
+	for _, n := range nodes {
+		lines := make([]string, len(n.lines))
+		for i, line := range n.lines {
+			lines[i] = dotEscape(line)
+		}
+		fmt.Fprintf(&b, "  %q [label=\"%s\"];\n", string(n.id), strings.Join(lines, `\n`))
+	}
+	for _, n := range nodes {
+		for _, pred := range n.preds {
+			fmt.Fprintf(&b, "  %q -> %q;\n", string(nodes[pred].id), string(n.id))
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func dotEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
+
+// ToMermaid renders the plan as a Mermaid flowchart.
+// The IDs of the plan nodes may have characters that Mermaid
+// does not allow in an ID, so each node is named by its position.
+func (v PlanVisualizer) ToMermaid(p *plan.Spec) string {
+	nodes := v.nodes(p)
+	var b strings.Builder
+	b.WriteString("flowchart TD\n")
+	for i, n := range nodes {
+		lines := make([]string, len(n.lines))
+		for j, line := range n.lines {
+			lines[j] = mermaidEscape(line)
+		}
+		fmt.Fprintf(&b, "  n%d[\"%s\"]\n", i, strings.Join(lines, "<br/>"))
+	}
+	for i, n := range nodes {
+		for _, pred := range n.preds {
+			fmt.Fprintf(&b, "  n%d --> n%d\n", pred, i)
+		}
+	}
+	return b.String()
+}
+
+func mermaidEscape(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;").Replace(s)
+}
+
+// visualize renders the plan in the format.
+func (v PlanVisualizer) visualize(p *plan.Spec, format string) (string, error) {
+	switch format {
+	case PlanFormatDOT:
+		return v.ToDOT(p), nil
+	case PlanFormatMermaid:
+		return v.ToMermaid(p), nil
+	default:
+		return "", errors.Newf(codes.Invalid, "unknown plan visualization format %q; expected %q or %q", format, PlanFormatDOT, PlanFormatMermaid)
+	}
+}
@@ -0,0 +1,170 @@
+package lang_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/dependency"
+	"github.com/influxdata/flux/execute/executetest"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/lang"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/plan"
+	"github.com/influxdata/flux/plan/plantest"
+	"github.com/influxdata/flux/runtime"
+)
+
+// diamondPlan has two sources. The first feeds two nodes
+// that are joined again with the second source.
+func diamondPlan() *plan.Spec {
+	return plantest.CreatePlanSpec(&plantest.PlanSpec{
+		Nodes: []plan.Node{
+			plantest.CreatePhysicalMockNode("source0"),
+			plantest.CreatePhysicalMockNode("source1"),
+			plantest.CreatePhysicalMockNode("left"),
+			plantest.CreatePhysicalMockNode("right"),
+			plantest.CreatePhysicalMockNode("join"),
+		},
+		Edges: [][2]int{
+			{0, 2},
+			{0, 3},
+			{2, 4},
+			{3, 4},
+			{1, 4},
+		},
+	})
+}
+
+func countLines(s, substr string) int {
+	n := 0
+	for _, line := range strings.Split(s, "\n") {
+		if strings.Contains(line, substr) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestPlanVisualizer_ToDOT(t *testing.T) {
+	dot := lang.PlanVisualizer{}.ToDOT(diamondPlan())
+
+	if !strings.HasPrefix(dot, "digraph {\n") || !strings.HasSuffix(dot, "}\n") {
+		t.Fatalf("expected a digraph, got:\n%s", dot)
+	}
+	if got, want := countLines(dot, "[label="), 5; got != want {
+		t.Errorf("unexpected number of nodes -want/+got\n\t- %d\n\t+ %d\n%s", want, got, dot)
+	}
+	if got, want := countLines(dot, " -> "), 5; got != want {
+		t.Errorf("unexpected number of edges -want/+got\n\t- %d\n\t+ %d\n%s", want, got, dot)
+	}
+	for _, edge := range []string{
+		`"source0" -> "left"`,
+		`"source0" -> "right"`,
+		`"left" -> "join"`,
+		`"right" -> "join"`,
+		`"source1" -> "join"`,
+	} {
+		if !strings.Contains(dot, edge) {
+			t.Errorf("expected edge %s in:\n%s", edge, dot)
+		}
+	}
+	// Each node is labeled with its kind and its estimates.
+	if got, want := countLines(dot, `\nmock\ncost: `), 5; got != want {
+		t.Errorf("unexpected number of annotated nodes -want/+got\n\t- %d\n\t+ %d\n%s", want, got, dot)
+	}
+}
+
+func TestPlanVisualizer_ToDOT_Logical(t *testing.T) {
+	ps := plantest.CreatePlanSpec(&plantest.PlanSpec{
+		Nodes: []plan.Node{
+			plantest.CreateLogicalMockNode("a"),
+			plantest.CreateLogicalMockNode("b"),
+		},
+		Edges: [][2]int{{0, 1}},
+	})
+	dot := lang.PlanVisualizer{}.ToDOT(ps)
+	if got, want := countLines(dot, "[label="), 2; got != want {
+		t.Errorf("unexpected number of nodes -want/+got\n\t- %d\n\t+ %d\n%s", want, got, dot)
+	}
+	// Logical nodes have no estimates.
+	if strings.Contains(dot, "cost:") {
+		t.Errorf("expected no cost in:\n%s", dot)
+	}
+}
+
+func TestPlanVisualizer_ToMermaid(t *testing.T) {
+	mermaid := lang.PlanVisualizer{}.ToMermaid(diamondPlan())
+
+	if !strings.HasPrefix(mermaid, "flowchart TD\n") {
+		t.Fatalf("expected a flowchart, got:\n%s", mermaid)
+	}
+	if got, want := countLines(mermaid, `["`), 5; got != want {
+		t.Errorf("unexpected number of nodes -want/+got\n\t- %d\n\t+ %d\n%s", want, got, mermaid)
+	}
+	if got, want := countLines(mermaid, " --> "), 5; got != want {
+		t.Errorf("unexpected number of edges -want/+got\n\t- %d\n\t+ %d\n%s", want, got, mermaid)
+	}
+	for _, id := range []string{"source0", "source1", "left", "right", "join"} {
+		if !strings.Contains(mermaid, `["`+id+"<br/>mock<br/>") {
+			t.Errorf("expected node %s in:\n%s", id, mermaid)
+		}
+	}
+}
+
+func TestWithPlanVisualization(t *testing.T) {
+	for _, format := range []string{lang.PlanFormatDOT, lang.PlanFormatMermaid} {
+		t.Run(format, func(t *testing.T) {
+			var out strings.Builder
+			program, err := lang.Compile(validScript, runtime.Default, time.Unix(0, 0),
+				lang.WithPlanVisualization(&out, format))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx, deps := dependency.Inject(context.Background(), executetest.NewTestExecuteDependencies())
+			defer deps.Finish()
+			q, err := program.Start(ctx, memory.DefaultAllocator)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for range q.Results() {
+			}
+			q.Done()
+
+			var want string
+			switch format {
+			case lang.PlanFormatDOT:
+				want = lang.PlanVisualizer{}.ToDOT(program.PlanSpec)
+			case lang.PlanFormatMermaid:
+				want = lang.PlanVisualizer{}.ToMermaid(program.PlanSpec)
+			}
+			if got := out.String(); got != want {
+				t.Errorf("unexpected plan -want/+got\n\t- %s\n\t+ %s", want, got)
+			}
+			if !strings.Contains(out.String(), "yield") {
+				t.Errorf("expected the yield in the plan, got:\n%s", out.String())
+			}
+		})
+	}
+}
+
+func TestWithPlanVisualization_UnknownFormat(t *testing.T) {
+	var out strings.Builder
+	program, err := lang.Compile(validScript, runtime.Default, time.Unix(0, 0),
+		lang.WithPlanVisualization(&out, "svg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, deps := dependency.Inject(context.Background(), executetest.NewTestExecuteDependencies())
+	defer deps.Finish()
+	if _, err := program.Start(ctx, memory.DefaultAllocator); err == nil {
+		t.Fatal("expected an error, got none")
+	} else if code := errors.Code(err); code != codes.Invalid {
+		t.Errorf("unexpected error code -want/+got\n\t- %v\n\t+ %v", codes.Invalid, code)
+	}
+	if out.Len() != 0 {
+		t.Errorf("expected nothing to be written, got:\n%s", out.String())
+	}
+}
@@ -25,3 +25,75 @@ type ParallelMergeAttribute struct {
 func (ParallelMergeAttribute) SuccessorsMustRequire() bool {
 	return false
 }
+
+// CollationKey is the key of the attribute that describes the order
+// of the rows within each table that a node produces.
+const CollationKey = "collation"
+
+// CollationAttr declares that the rows within each table are sorted by
+// the columns in order. The rows are in ascending order unless Desc is set.
+type CollationAttr struct {
+	Columns []string
+	Desc    bool
+}
+
+// The collation of a node does not constrain its successors.
+func (*CollationAttr) SuccessorsMustRequire() bool {
+	return false
+}
+
+// SatisfiedBy reports whether rows that are sorted by the given attribute
+// are also sorted by this collation. That is the case when the attribute
+// is a collation in the same direction and the columns of this collation
+// are a prefix of its columns.
+func (a *CollationAttr) SatisfiedBy(attr PhysicalAttr) bool {
+	other, ok := attr.(*CollationAttr)
+	if !ok || other == nil || other.Desc != a.Desc || len(other.Columns) < len(a.Columns) {
+		return false
+	}
+	for i, label := range a.Columns {
+		if other.Columns[i] != label {
+			return false
+		}
+	}
+	return true
+}
+
+// OutputAttributer is implemented by procedure specs that guarantee
+// physical attributes of their output, such as a source that produces
+// rows sorted by time.
+type OutputAttributer interface {
+	OutputAttributes() PhysicalAttributes
+}
+
+// PassThroughAttributer is implemented by procedure specs that
+// preserve a physical attribute of the output of their predecessor.
+type PassThroughAttributer interface {
+	PassThroughAttribute(attrKey string) bool
+}
+
+// GetOutputAttribute returns the attribute with the key that is guaranteed
+// by the output of the node, or nil if there is no such attribute.
+// An attribute set on a physical node takes precedence over the attributes
+// of its procedure spec. A node with a single predecessor that passes through
+// the attribute has the attribute of its predecessor.
+func GetOutputAttribute(node Node, attrKey string) PhysicalAttr {
+	if ppn, ok := node.(*PhysicalPlanNode); ok {
+		if attr, ok := ppn.OutputAttrs[attrKey]; ok {
+			return attr
+		}
+	}
+
+	spec := node.ProcedureSpec()
+	if s, ok := spec.(OutputAttributer); ok {
+		if attr, ok := s.OutputAttributes()[attrKey]; ok {
+			return attr
+		}
+	}
+	if s, ok := spec.(PassThroughAttributer); ok && s.PassThroughAttribute(attrKey) {
+		if preds := node.Predecessors(); len(preds) == 1 {
+			return GetOutputAttribute(preds[0], attrKey)
+		}
+	}
+	return nil
+}
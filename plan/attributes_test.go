@@ -0,0 +1,61 @@
+package plan_test
+
+import (
+	"testing"
+
+	"github.com/influxdata/flux/plan"
+)
+
+func TestCollationAttr_SatisfiedBy(t *testing.T) {
+	testCases := []struct {
+		name string
+		want *plan.CollationAttr
+		attr plan.PhysicalAttr
+		ok   bool
+	}{
+		{
+			name: "same columns",
+			want: &plan.CollationAttr{Columns: []string{"_time"}},
+			attr: &plan.CollationAttr{Columns: []string{"_time"}},
+			ok:   true,
+		},
+		{
+			name: "prefix",
+			want: &plan.CollationAttr{Columns: []string{"_time"}},
+			attr: &plan.CollationAttr{Columns: []string{"_time", "host"}},
+			ok:   true,
+		},
+		{
+			name: "more columns",
+			want: &plan.CollationAttr{Columns: []string{"_time", "host"}},
+			attr: &plan.CollationAttr{Columns: []string{"_time"}},
+		},
+		{
+			name: "different order",
+			want: &plan.CollationAttr{Columns: []string{"host", "_time"}},
+			attr: &plan.CollationAttr{Columns: []string{"_time", "host"}},
+		},
+		{
+			name: "different direction",
+			want: &plan.CollationAttr{Columns: []string{"_time"}},
+			attr: &plan.CollationAttr{Columns: []string{"_time"}, Desc: true},
+		},
+		{
+			name: "other attribute",
+			want: &plan.CollationAttr{Columns: []string{"_time"}},
+			attr: plan.ParallelMergeAttribute{Factor: 2},
+		},
+		{
+			name: "missing",
+			want: &plan.CollationAttr{Columns: []string{"_time"}},
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.want.SatisfiedBy(tc.attr); got != tc.ok {
+				t.Errorf("unexpected result -want/+got:\n\t- %v\n\t+ %v", tc.ok, got)
+			}
+		})
+	}
+}
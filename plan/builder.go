@@ -50,5 +50,13 @@ func (p *planner) Plan(ctx context.Context, fspec *flux.Spec) (*Spec, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := ValidateProcedureSpecs(pp); err != nil {
+		return nil, err
+	}
 	return pp, nil
 }
+
+// Metrics returns the metrics of the logical and the physical rules.
+func (p *planner) Metrics() []RuleMetrics {
+	return mergeRuleMetrics(p.lp.Metrics(), p.pp.Metrics())
+}
@@ -25,9 +25,30 @@ func Add(a Cost, b Cost) Cost {
 	}
 }
 
+// DefaultCost is embedded by procedure specs that do not estimate
+// their cost. The cost is zero and the output is estimated to have
+// as many rows as all of the inputs together.
 type DefaultCost struct {
 }
 
+// DefaultEstimatedBytesPerSecond is the number of bytes that a read
+// is estimated to scan for each second of its time range when no
+// other rate is set.
+const DefaultEstimatedBytesPerSecond = 1024
+
+// ScanEstimator is implemented by procedure specs that estimate the
+// bytes they scan from the duration of their time range. The rate can
+// be set before the cost is estimated to match the cardinality of the
+// data that is usually read.
+type ScanEstimator interface {
+	SetEstimatedBytesPerSecond(n int64)
+}
+
 func (c DefaultCost) Cost(inStats []Statistics) (Cost, Statistics) {
-	return Cost{}, Statistics{}
+	var stats Statistics
+	for _, in := range inStats {
+		stats.Cardinality += in.Cardinality
+		stats.GroupCardinality += in.GroupCardinality
+	}
+	return Cost{}, stats
 }
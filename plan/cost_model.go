@@ -0,0 +1,179 @@
+package plan
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// CostModel estimates the rows that a procedure produces
+// and the memory that it uses from the rows of its inputs.
+type CostModel interface {
+	// EstimateRows returns the number of rows that the procedure
+	// produces from inputs with the given numbers of rows.
+	EstimateRows(spec ProcedureSpec, inputRows []int64) int64
+	// EstimateMemory returns the bytes of memory that the procedure
+	// uses for inputs with the given numbers of rows.
+	EstimateMemory(spec ProcedureSpec, inputRows []int64) int64
+}
+
+var costModels = make(map[ProcedureKind]CostModel)
+
+// RegisterCostModel registers the cost model of a procedure kind.
+// While any cost model is registered, the planners apply the rules
+// that match a node in the order of the estimated cost of the plan
+// that each rule produces, cheapest first. A procedure kind without
+// a cost model is estimated with the DefaultCostModel.
+// The call panics if the kind already has a cost model.
+func RegisterCostModel(kind ProcedureKind, model CostModel) {
+	if _, ok := costModels[kind]; ok {
+		panic(fmt.Errorf("duplicate cost model for procedure kind %v", kind))
+	}
+	costModels[kind] = model
+}
+
+// UnregisterCostModel removes the cost model of a procedure kind.
+// It does nothing if the kind has no cost model.
+func UnregisterCostModel(kind ProcedureKind) {
+	delete(costModels, kind)
+}
+
+// DefaultCostModel estimates a procedure with the Cost method of
+// its spec, such as the one of DefaultCost. A spec without a Cost
+// method produces as many rows as its inputs and uses no memory.
+type DefaultCostModel struct{}
+
+func (DefaultCostModel) EstimateRows(spec ProcedureSpec, inputRows []int64) int64 {
+	_, stats := specCost(spec, inputRows)
+	return stats.Cardinality
+}
+
+func (DefaultCostModel) EstimateMemory(spec ProcedureSpec, inputRows []int64) int64 {
+	cost, _ := specCost(spec, inputRows)
+	return cost.MEM
+}
+
+// specCost returns the Cost of a spec with inputs that
+// have the given numbers of rows.
+func specCost(spec ProcedureSpec, inputRows []int64) (Cost, Statistics) {
+	inStats := make([]Statistics, len(inputRows))
+	for i, rows := range inputRows {
+		inStats[i].Cardinality = rows
+	}
+	if c, ok := spec.(interface {
+		Cost(inStats []Statistics) (Cost, Statistics)
+	}); ok {
+		return c.Cost(inStats)
+	}
+	return DefaultCost{}.Cost(inStats)
+}
+
+func costModelFor(kind ProcedureKind) CostModel {
+	if model, ok := costModels[kind]; ok {
+		return model
+	}
+	return DefaultCostModel{}
+}
+
+// planCost is the estimated cost of a node and its predecessors.
+// The rows that the nodes produce are compared first and the
+// memory that they use breaks ties.
+type planCost struct {
+	rows, memory int64
+}
+
+func (c planCost) less(other planCost) bool {
+	if c.rows != other.rows {
+		return c.rows < other.rows
+	}
+	return c.memory < other.memory
+}
+
+// estimateCost estimates the cost of a node and its predecessors.
+// A predecessor that is shared by several nodes is counted once.
+func estimateCost(node Node) planCost {
+	var total planCost
+	rows := make(map[Node]int64)
+	var estimate func(node Node) int64
+	estimate = func(node Node) int64 {
+		if n, ok := rows[node]; ok {
+			return n
+		}
+		inputRows := make([]int64, len(node.Predecessors()))
+		for i, pred := range node.Predecessors() {
+			inputRows[i] = estimate(pred)
+		}
+		spec, model := node.ProcedureSpec(), costModelFor(node.Kind())
+		n := model.EstimateRows(spec, inputRows)
+		total.rows += n
+		total.memory += model.EstimateMemory(spec, inputRows)
+		rows[node] = n
+		return n
+	}
+	estimate(node)
+	return total
+}
+
+// copySubgraph copies a node and its predecessors so that a rule can
+// be tried on the copy without changing the plan. The copies only have
+// the successors that are within the copy.
+func copySubgraph(node Node, copies map[Node]Node) Node {
+	if c, ok := copies[node]; ok {
+		return c
+	}
+	c := node.ShallowCopy()
+	c.ClearPredecessors()
+	c.ClearSuccessors()
+	copies[node] = c
+	for _, pred := range node.Predecessors() {
+		predCopy := copySubgraph(pred, copies)
+		c.AddPredecessors(predCopy)
+		predCopy.AddSuccessors(c)
+	}
+	return c
+}
+
+// orderByCost orders the rules that match a node by the estimated cost
+// of the plan that each of them produces when it is tried on a copy of
+// the node and its predecessors, cheapest first. The rules that do not
+// match the node follow in their original order. The rules are not
+// reordered when no cost model is registered.
+func orderByCost(ctx context.Context, node Node, rules []Rule) []Rule {
+	if len(costModels) == 0 || len(rules) < 2 {
+		return rules
+	}
+
+	type candidate struct {
+		rule Rule
+		cost planCost
+	}
+	var (
+		matched []candidate
+		others  []Rule
+	)
+	for _, rule := range rules {
+		if !rule.Pattern().Match(node) {
+			others = append(others, rule)
+			continue
+		}
+		// A rule that fails or does not change the copy
+		// is estimated with the cost of the plan as it is.
+		trial := node
+		if newNode, changed, err := rule.Rewrite(ctx, copySubgraph(node, make(map[Node]Node))); err == nil && changed {
+			trial = newNode
+		}
+		matched = append(matched, candidate{rule: rule, cost: estimateCost(trial)})
+	}
+	if len(matched) < 2 {
+		return rules
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].cost.less(matched[j].cost)
+	})
+	ordered := make([]Rule, 0, len(rules))
+	for _, c := range matched {
+		ordered = append(ordered, c.rule)
+	}
+	return append(ordered, others...)
+}
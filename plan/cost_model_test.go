@@ -0,0 +1,142 @@
+package plan_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/influxdata/flux/plan"
+	"github.com/influxdata/flux/plan/plantest"
+)
+
+const (
+	costSourceKind  = "costSource"
+	costFilterKind  = "costFilter"
+	costFilterAKind = "costFilterA"
+	costFilterBKind = "costFilterB"
+)
+
+type costProcedureSpec struct {
+	kind plan.ProcedureKind
+}
+
+func (s *costProcedureSpec) Kind() plan.ProcedureKind {
+	return s.kind
+}
+
+func (s *costProcedureSpec) Copy() plan.ProcedureSpec {
+	return &costProcedureSpec{kind: s.kind}
+}
+
+// convertRule converts a node of one kind into a node of another kind.
+type convertRule struct {
+	name     string
+	from, to plan.ProcedureKind
+}
+
+func (r convertRule) Name() string {
+	return r.name
+}
+
+func (r convertRule) Pattern() plan.Pattern {
+	return plan.Pat(r.from, plan.Any())
+}
+
+func (r convertRule) Rewrite(ctx context.Context, node plan.Node) (plan.Node, bool, error) {
+	if err := node.ReplaceSpec(&costProcedureSpec{kind: r.to}); err != nil {
+		return nil, false, err
+	}
+	return node, true, nil
+}
+
+// fakeCostModel estimates that a source produces 100 rows
+// and a filter keeps the fraction of its input rows.
+type fakeCostModel struct {
+	fraction int64
+}
+
+func (m fakeCostModel) EstimateRows(spec plan.ProcedureSpec, inputRows []int64) int64 {
+	if len(inputRows) == 0 {
+		return 100
+	}
+	return inputRows[0] / m.fraction
+}
+
+func (m fakeCostModel) EstimateMemory(spec plan.ProcedureSpec, inputRows []int64) int64 {
+	return 0
+}
+
+func TestCostModel_ChoosesCheaperRewrite(t *testing.T) {
+	logicalPlan := func(t *testing.T) plan.ProcedureKind {
+		t.Helper()
+		spec := plantest.CreatePlanSpec(&plantest.PlanSpec{
+			Nodes: []plan.Node{
+				plan.CreateLogicalNode("source", &costProcedureSpec{kind: costSourceKind}),
+				plan.CreateLogicalNode("filter", &costProcedureSpec{kind: costFilterKind}),
+			},
+			Edges: [][2]int{{0, 1}},
+		})
+		// Both rules rewrite the filter, so the first
+		// rule that is applied is the only one.
+		planner := plan.NewLogicalPlanner(plan.OnlyLogicalRules(
+			convertRule{name: "toFilterA", from: costFilterKind, to: costFilterAKind},
+			convertRule{name: "toFilterB", from: costFilterKind, to: costFilterBKind},
+		))
+		spec, err := planner.Plan(context.Background(), spec)
+		if err != nil {
+			t.Fatalf("could not do logical planning: %v", err)
+		}
+		for root := range spec.Roots {
+			return root.Kind()
+		}
+		t.Fatal("expected a root node")
+		return ""
+	}
+
+	t.Run("without cost models", func(t *testing.T) {
+		if got, want := logicalPlan(t), plan.ProcedureKind(costFilterAKind); got != want {
+			t.Errorf("unexpected root kind, want %v, got %v", want, got)
+		}
+	})
+
+	t.Run("with default cost model", func(t *testing.T) {
+		// The default cost model estimates both rewrites
+		// to cost the same, so the order of the rules is kept.
+		plan.RegisterCostModel(costFilterAKind, plan.DefaultCostModel{})
+		defer plan.UnregisterCostModel(costFilterAKind)
+
+		if got, want := logicalPlan(t), plan.ProcedureKind(costFilterAKind); got != want {
+			t.Errorf("unexpected root kind, want %v, got %v", want, got)
+		}
+	})
+
+	t.Run("with fake cost models", func(t *testing.T) {
+		plan.RegisterCostModel(costSourceKind, fakeCostModel{})
+		plan.RegisterCostModel(costFilterAKind, fakeCostModel{fraction: 2})
+		plan.RegisterCostModel(costFilterBKind, fakeCostModel{fraction: 10})
+		defer func() {
+			plan.UnregisterCostModel(costSourceKind)
+			plan.UnregisterCostModel(costFilterAKind)
+			plan.UnregisterCostModel(costFilterBKind)
+		}()
+
+		if got, want := logicalPlan(t), plan.ProcedureKind(costFilterBKind); got != want {
+			t.Errorf("unexpected root kind, want %v, got %v", want, got)
+		}
+	})
+}
+
+func TestDefaultCostModel(t *testing.T) {
+	var model plan.DefaultCostModel
+	spec := &plan.GeneratedYieldProcedureSpec{Name: "result"}
+	if got, want := model.EstimateRows(spec, []int64{3, 4}), int64(7); got != want {
+		t.Errorf("unexpected rows, want %d, got %d", want, got)
+	}
+	if got, want := model.EstimateMemory(spec, []int64{3, 4}), int64(0); got != want {
+		t.Errorf("unexpected memory, want %d, got %d", want, got)
+	}
+
+	// A spec without a Cost method produces the rows of its inputs.
+	if got, want := model.EstimateRows(&costProcedureSpec{kind: costFilterKind}, []int64{5}), int64(5); got != want {
+		t.Errorf("unexpected rows, want %d, got %d", want, got)
+	}
+}
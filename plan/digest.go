@@ -0,0 +1,212 @@
+package plan
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/flux/values"
+)
+
+// Digest returns the SHA-256 digest of a canonical encoding of the plan.
+//
+// The IDs of the nodes are not encoded, so plans that are built by
+// applying the planner rules in a different order have the same digest
+// when they have the same nodes and edges. A node is encoded by the kind
+// and fields of its procedure spec, its trigger spec and the encodings of
+// its predecessors in order. The roots of the plan are sorted by their
+// encodings. The resources and the now time of the plan are also encoded.
+//
+// The exported fields of a procedure spec are encoded except for
+// those with the json tag "-". Values are encoded with their type
+// and how they are displayed. Functions and channels are only
+// encoded by their types.
+func Digest(p *Spec) [32]byte {
+	d := &digester{nodes: make(map[Node][32]byte)}
+
+	roots := make([][32]byte, 0, len(p.Roots))
+	for root := range p.Roots {
+		roots = append(roots, d.node(root))
+	}
+	sort.Slice(roots, func(i, j int) bool {
+		return string(roots[i][:]) < string(roots[j][:])
+	})
+
+	e := &encoder{}
+	e.str("plan")
+	for _, root := range roots {
+		e.buf = append(e.buf, root[:]...)
+	}
+	e.value(reflect.ValueOf(p.Resources))
+	e.str(p.Now.UTC().Format(time.RFC3339Nano))
+	return sha256.Sum256(e.buf)
+}
+
+// digester computes the digests of the nodes of a plan.
+// A node with more than one successor is only encoded once.
+type digester struct {
+	nodes map[Node][32]byte
+}
+
+func (d *digester) node(n Node) [32]byte {
+	if sum, ok := d.nodes[n]; ok {
+		return sum
+	}
+
+	e := &encoder{}
+	e.str(string(n.Kind()))
+	e.value(reflect.ValueOf(n.ProcedureSpec()))
+	if ppn, ok := n.(*PhysicalPlanNode); ok {
+		e.value(reflect.ValueOf(ppn.TriggerSpec))
+	}
+	e.int(int64(len(n.Predecessors())))
+	for _, pred := range n.Predecessors() {
+		sum := d.node(pred)
+		e.buf = append(e.buf, sum[:]...)
+	}
+
+	sum := sha256.Sum256(e.buf)
+	d.nodes[n] = sum
+	return sum
+}
+
+var (
+	timeType  = reflect.TypeOf(time.Time{})
+	valueType = reflect.TypeOf((*values.Value)(nil)).Elem()
+)
+
+// encoder writes an unambiguous encoding of a value.
+// Strings are prefixed with their lengths and every
+// composite value is prefixed with its type.
+type encoder struct {
+	buf []byte
+	// path holds the pointers that are being encoded
+	// so that a cycle is not followed forever.
+	path map[uintptr]bool
+}
+
+func (e *encoder) str(s string) {
+	e.int(int64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+func (e *encoder) int(n int64) {
+	var b [binary.MaxVarintLen64]byte
+	e.buf = append(e.buf, b[:binary.PutVarint(b[:], n)]...)
+}
+
+func (e *encoder) value(v reflect.Value) {
+	if !v.IsValid() {
+		e.str("nil")
+		return
+	}
+
+	if v.Type().Implements(valueType) && v.CanInterface() {
+		if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+			e.str("nil")
+			return
+		}
+		val := v.Interface().(values.Value)
+		e.str("value")
+		e.str(val.Type().Nature().String())
+		e.str(values.DisplayString(val))
+		return
+	}
+	if v.Type() == timeType {
+		e.str(v.Interface().(time.Time).UTC().Format(time.RFC3339Nano))
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		e.str(strconv.FormatBool(v.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.int(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.str(strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		e.str(strconv.FormatFloat(v.Float(), 'g', -1, 64))
+	case reflect.Complex64, reflect.Complex128:
+		e.str(strconv.FormatComplex(v.Complex(), 'g', -1, 128))
+	case reflect.String:
+		e.str(v.String())
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			e.str(hex.EncodeToString(v.Bytes()))
+			return
+		}
+		e.int(int64(v.Len()))
+		for i := 0; i < v.Len(); i++ {
+			e.value(v.Index(i))
+		}
+	case reflect.Map:
+		e.mapValue(v)
+	case reflect.Struct:
+		e.str(v.Type().String())
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" || strings.Split(f.Tag.Get("json"), ",")[0] == "-" {
+				continue
+			}
+			e.str(f.Name)
+			e.value(v.Field(i))
+		}
+	case reflect.Ptr:
+		if v.IsNil() {
+			e.str("nil")
+			return
+		}
+		ptr := v.Pointer()
+		if e.path[ptr] {
+			e.str("cycle")
+			return
+		}
+		if e.path == nil {
+			e.path = make(map[uintptr]bool)
+		}
+		e.path[ptr] = true
+		e.value(v.Elem())
+		delete(e.path, ptr)
+	case reflect.Interface:
+		if v.IsNil() {
+			e.str("nil")
+			return
+		}
+		e.str(v.Elem().Type().String())
+		e.value(v.Elem())
+	default:
+		// Functions, channels and unsafe pointers
+		// are only identified by their types.
+		e.str(v.Type().String())
+	}
+}
+
+// mapValue encodes the entries of a map sorted by their encoded keys.
+func (e *encoder) mapValue(v reflect.Value) {
+	type entry struct {
+		key   []byte
+		value reflect.Value
+	}
+	entries := make([]entry, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		ke := &encoder{path: e.path}
+		ke.value(iter.Key())
+		entries = append(entries, entry{key: ke.buf, value: iter.Value()})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return string(entries[i].key) < string(entries[j].key)
+	})
+
+	e.int(int64(len(entries)))
+	for _, entry := range entries {
+		e.buf = append(e.buf, entry.key...)
+		e.value(entry.value)
+	}
+}
@@ -0,0 +1,181 @@
+package plan_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/plan"
+	"github.com/influxdata/flux/plan/plantest"
+	"github.com/influxdata/flux/stdlib/influxdata/influxdb"
+	"github.com/influxdata/flux/stdlib/universe"
+	"github.com/influxdata/flux/values"
+)
+
+func TestDigest(t *testing.T) {
+	now := time.Date(2018, 4, 17, 0, 0, 0, 0, time.UTC)
+
+	type options struct {
+		// ids are the ids of the from, range, limit, union
+		// and yield nodes in that order.
+		ids   []plan.NodeID
+		limit int64
+		fill  values.Value
+		now   time.Time
+		// swap swaps the predecessors of the union.
+		swap bool
+		// reverse creates the nodes in reverse order.
+		reverse bool
+	}
+	defaults := func() options {
+		return options{
+			ids:   []plan.NodeID{"from", "range", "limit", "union", "yield"},
+			limit: 10,
+			fill:  values.NewFloat(0),
+			now:   now,
+		}
+	}
+
+	// from |> range |> limit -----> union |> fill |> yield
+	//      \______________________/
+	newPlan := func(o options) *plan.Spec {
+		nodes := []plan.Node{
+			plan.CreatePhysicalNode(o.ids[0], &influxdb.FromProcedureSpec{
+				Bucket: influxdb.NameOrID{Name: "my-bucket"},
+			}),
+			plan.CreatePhysicalNode(o.ids[1], &universe.RangeProcedureSpec{
+				Bounds: flux.Bounds{
+					Start: flux.Time{IsRelative: true, Relative: -time.Hour},
+					Stop:  flux.Now,
+					Now:   o.now,
+				},
+				TimeColumn:  "_time",
+				StartColumn: "_start",
+				StopColumn:  "_stop",
+			}),
+			plan.CreatePhysicalNode(o.ids[2], &universe.LimitProcedureSpec{N: o.limit}),
+			plan.CreatePhysicalNode(o.ids[3], &universe.UnionProcedureSpec{}),
+			plan.CreatePhysicalNode("fill", &universe.FillProcedureSpec{
+				Column: "_value",
+				Value:  o.fill,
+			}),
+			plan.CreatePhysicalNode(o.ids[4], &universe.YieldProcedureSpec{Name: "_result"}),
+		}
+		edges := [][2]int{{0, 1}, {1, 2}, {2, 3}, {0, 3}, {3, 4}, {4, 5}}
+		if o.swap {
+			edges = [][2]int{{0, 1}, {1, 2}, {0, 3}, {2, 3}, {3, 4}, {4, 5}}
+		}
+		if o.reverse {
+			n := len(nodes)
+			for i := 0; i < n/2; i++ {
+				nodes[i], nodes[n-1-i] = nodes[n-1-i], nodes[i]
+			}
+			for i, edge := range edges {
+				edges[i] = [2]int{n - 1 - edge[0], n - 1 - edge[1]}
+			}
+		}
+		return plantest.CreatePlanSpec(&plantest.PlanSpec{
+			Nodes: nodes,
+			Edges: edges,
+			Now:   o.now,
+		})
+	}
+
+	want := plan.Digest(newPlan(defaults()))
+	if got := plan.Digest(newPlan(defaults())); got != want {
+		t.Fatalf("expected the digest of the same plan to be stable, got %x and %x", want, got)
+	}
+
+	for _, tc := range []struct {
+		name  string
+		opts  func(o *options)
+		equal bool
+	}{
+		{
+			name: "different ids",
+			opts: func(o *options) {
+				o.ids = []plan.NodeID{"ReadRange0", "merged_range_limit", "limit4", "union2", "generated_yield"}
+			},
+			equal: true,
+		},
+		{
+			name: "nodes in reverse order",
+			opts: func(o *options) {
+				o.reverse = true
+			},
+			equal: true,
+		},
+		{
+			name: "different limit",
+			opts: func(o *options) {
+				o.limit = 5
+			},
+		},
+		{
+			name: "different fill value",
+			opts: func(o *options) {
+				o.fill = values.NewFloat(1)
+			},
+		},
+		{
+			name: "different fill type",
+			opts: func(o *options) {
+				o.fill = values.NewInt(0)
+			},
+		},
+		{
+			name: "different now",
+			opts: func(o *options) {
+				o.now = now.Add(time.Second)
+			},
+		},
+		{
+			name: "swapped predecessors",
+			opts: func(o *options) {
+				o.swap = true
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o := defaults()
+			tc.opts(&o)
+			got := plan.Digest(newPlan(o))
+			if tc.equal && got != want {
+				t.Errorf("expected equal digests, got %x and %x", want, got)
+			} else if !tc.equal && got == want {
+				t.Errorf("expected different digests, got %x for both", got)
+			}
+		})
+	}
+}
+
+func TestDigest_Roots(t *testing.T) {
+	// Two results are read from the same source.
+	// The order of the roots in the plan must not
+	// change the digest.
+	newPlan := func(names ...string) *plan.Spec {
+		nodes := []plan.Node{
+			plan.CreatePhysicalNode("from", &influxdb.FromProcedureSpec{
+				Bucket: influxdb.NameOrID{Name: "my-bucket"},
+			}),
+		}
+		var edges [][2]int
+		for _, name := range names {
+			nodes = append(nodes, plan.CreatePhysicalNode(plan.NodeID("yield_"+name), &universe.YieldProcedureSpec{Name: name}))
+			edges = append(edges, [2]int{0, len(nodes) - 1})
+		}
+		return plantest.CreatePlanSpec(&plantest.PlanSpec{
+			Nodes: nodes,
+			Edges: edges,
+		})
+	}
+
+	for i := 0; i < 10; i++ {
+		if want, got := plan.Digest(newPlan("a", "b")), plan.Digest(newPlan("b", "a")); want != got {
+			t.Fatalf("expected equal digests, got %x and %x", want, got)
+		}
+	}
+	if a, b := plan.Digest(newPlan("a", "b")), plan.Digest(newPlan("a", "c")); a == b {
+		t.Fatalf("expected different digests, got %x for both", a)
+	}
+}
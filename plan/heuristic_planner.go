@@ -5,6 +5,7 @@ import (
 	"sort"
 
 	"github.com/influxdata/flux/dependencies/testing"
+	"go.uber.org/zap"
 )
 
 // heuristicPlanner applies a set of rules to the nodes in a Spec
@@ -12,12 +13,23 @@ import (
 type heuristicPlanner struct {
 	rules         map[ProcedureKind][]Rule
 	disabledRules map[string]bool
+
+	// maxPasses is the maximum number of passes over the plan.
+	// The number of passes is unlimited when it is zero or less.
+	maxPasses int
+	// logger logs a warning when the plan is returned
+	// before a fixed point is reached.
+	logger *zap.Logger
+
+	// metrics counts the applications of each rule.
+	metrics ruleMetrics
 }
 
 func newHeuristicPlanner() *heuristicPlanner {
 	return &heuristicPlanner{
 		rules:         make(map[ProcedureKind][]Rule),
 		disabledRules: make(map[string]bool),
+		metrics:       make(ruleMetrics),
 	}
 }
 
@@ -40,33 +52,43 @@ func (p *heuristicPlanner) clearRules() {
 	p.rules = make(map[ProcedureKind][]Rule)
 }
 
+func (p *heuristicPlanner) setMaxPasses(n int, logger *zap.Logger) {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	p.maxPasses = n
+	p.logger = logger
+}
+
 // matchRules applies any applicable rules to the given plan node,
 // and returns the rewritten plan node and whether or not any rewriting was done.
 func (p *heuristicPlanner) matchRules(ctx context.Context, node Node) (Node, bool, error) {
-	anyChanged := false
+	node, anyChanged, err := p.applyRules(ctx, node, p.rules[AnyKind])
+	if err != nil {
+		return nil, false, err
+	}
+	node, changed, err := p.applyRules(ctx, node, p.rules[node.Kind()])
+	if err != nil {
+		return nil, false, err
+	}
+	return node, anyChanged || changed, nil
+}
 
-	for _, rule := range p.rules[AnyKind] {
-		if p.disabledRules[rule.Name()] {
-			continue
-		}
-		if rule.Pattern().Match(node) {
-			newNode, changed, err := rule.Rewrite(ctx, node)
-			if err != nil {
-				return nil, false, err
-			} else if changed {
-				testing.MarkInvokedPlannerRule(ctx, rule.Name())
-				anyChanged = true
-			}
-			node = newNode
+// applyRules applies the rules that match the node in turn. When a cost
+// model is registered, the rules are applied in the order of the cost of
+// the plans that they produce.
+func (p *heuristicPlanner) applyRules(ctx context.Context, node Node, rules []Rule) (Node, bool, error) {
+	enabled := make([]Rule, 0, len(rules))
+	for _, rule := range rules {
+		if !p.disabledRules[rule.Name()] {
+			enabled = append(enabled, rule)
 		}
 	}
 
-	for _, rule := range p.rules[node.Kind()] {
-		if p.disabledRules[rule.Name()] {
-			continue
-		}
+	anyChanged := false
+	for _, rule := range orderByCost(ctx, node, enabled) {
 		if rule.Pattern().Match(node) {
-			newNode, changed, err := rule.Rewrite(ctx, node)
+			newNode, changed, err := p.metrics.rewrite(ctx, rule, node)
 			if err != nil {
 				return nil, false, err
 			} else if changed {
@@ -76,18 +98,35 @@ func (p *heuristicPlanner) matchRules(ctx context.Context, node Node) (Node, boo
 			node = newNode
 		}
 	}
-
 	return node, anyChanged, nil
 }
 
+// Metrics returns the metrics of the rules that were applied
+// by the planner, sorted by rule name.
+func (p *heuristicPlanner) Metrics() []RuleMetrics {
+	metrics := make([]RuleMetrics, 0, len(p.metrics))
+	for _, m := range p.metrics {
+		metrics = append(metrics, *m)
+	}
+	return mergeRuleMetrics(metrics)
+}
+
 // Plan is a fixed-point query planning algorithm.
 // It traverses the DAG depth-first, attempting to apply rewrite rules at each node.
 // Traversal is repeated until a pass over the DAG results in no changes with the given rule set.
+// If the maximum number of passes is reached first, a warning is logged and the plan is returned
+// as it is after the last pass. The plan is valid, but more rules could have been applied to it.
 //
 // Plan may change its argument and/or return a new instance of Spec, so the correct way to call Plan is:
 //     plan, err = plan.Plan(plan)
 func (p *heuristicPlanner) Plan(ctx context.Context, inputPlan *Spec) (*Spec, error) {
-	for anyChanged := true; anyChanged; {
+	for anyChanged, passes := true, 0; anyChanged; passes++ {
+		if p.maxPasses > 0 && passes == p.maxPasses {
+			p.logger.Warn("planner reached the maximum number of passes before the plan was fully optimized",
+				zap.Int("max_passes", p.maxPasses))
+			break
+		}
+
 		visited := make(map[Node]struct{})
 
 		nodeStack := make([]Node, 0, len(inputPlan.Roots))
@@ -2,11 +2,15 @@ package plan_test
 
 import (
 	"context"
+	"sort"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/influxdata/flux/plan"
 	"github.com/influxdata/flux/plan/plantest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func TestPlanTraversal(t *testing.T) {
@@ -141,3 +145,165 @@ func TestPlanTraversal(t *testing.T) {
 		})
 	}
 }
+
+func TestPlanMaxPasses(t *testing.T) {
+	// The rule marks a node once all of its predecessors were
+	// marked in an earlier pass. The nodes are visited from the
+	// root, so marking the chain of 4 nodes takes 4 passes that
+	// change the plan and one more pass that does not.
+	//
+	//   3
+	//   |
+	//   2
+	//   |
+	//   1
+	//   |
+	//   0
+	newPlan := func() *plan.Spec {
+		return plantest.CreatePlanSpec(&plantest.PlanSpec{
+			Nodes: []plan.Node{
+				plantest.CreatePhysicalMockNode("0"),
+				plantest.CreatePhysicalMockNode("1"),
+				plantest.CreatePhysicalMockNode("2"),
+				plantest.CreatePhysicalMockNode("3"),
+			},
+			Edges: [][2]int{
+				{0, 1},
+				{1, 2},
+				{2, 3},
+			},
+		})
+	}
+
+	for _, tc := range []struct {
+		name      string
+		maxPasses int
+		want      []plan.NodeID
+		wantWarn  bool
+	}{
+		{
+			name: "unlimited",
+			want: []plan.NodeID{"0", "1", "2", "3"},
+		},
+		{
+			name:      "negative is unlimited",
+			maxPasses: -1,
+			want:      []plan.NodeID{"0", "1", "2", "3"},
+		},
+		{
+			name:      "enough passes",
+			maxPasses: 5,
+			want:      []plan.NodeID{"0", "1", "2", "3"},
+		},
+		{
+			name:      "two passes",
+			maxPasses: 2,
+			want:      []plan.NodeID{"0", "1"},
+			wantWarn:  true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var marked []plan.NodeID
+			done := make(map[plan.NodeID]bool)
+			var pass map[plan.NodeID]bool
+			rule := &plantest.FunctionRule{
+				RewriteFn: func(ctx context.Context, node plan.Node) (plan.Node, bool, error) {
+					// The root is visited first in each pass.
+					if node.ID() == "3" {
+						pass = make(map[plan.NodeID]bool)
+					}
+					if done[node.ID()] {
+						return node, false, nil
+					}
+					for _, pred := range node.Predecessors() {
+						if !done[pred.ID()] || pass[pred.ID()] {
+							return node, false, nil
+						}
+					}
+					done[node.ID()] = true
+					pass[node.ID()] = true
+					marked = append(marked, node.ID())
+					return node, true, nil
+				},
+			}
+
+			core, logs := observer.New(zap.WarnLevel)
+			thePlanner := plan.NewPhysicalPlanner(
+				plan.OnlyPhysicalRules(rule),
+				plan.WithMaxPhysicalPasses(tc.maxPasses, zap.New(core)),
+			)
+			if _, err := thePlanner.Plan(context.Background(), newPlan()); err != nil {
+				t.Fatalf("Could not plan: %v", err)
+			}
+
+			if !cmp.Equal(tc.want, marked) {
+				t.Errorf("unexpected marked nodes, -want/+got:\n%v", cmp.Diff(tc.want, marked))
+			}
+			if got := logs.Len() > 0; got != tc.wantWarn {
+				t.Errorf("expected a warning to be logged: %v, got %d log entries", tc.wantWarn, logs.Len())
+			}
+		})
+	}
+}
+
+func TestPlanRuleMetrics(t *testing.T) {
+	//   2
+	//   |
+	//   1
+	//   |
+	//   0
+	planSpec := plantest.CreatePlanSpec(&plantest.PlanSpec{
+		Nodes: []plan.Node{
+			plantest.CreatePhysicalMockNode("0"),
+			plantest.CreatePhysicalMockNode("1"),
+			plantest.CreatePhysicalMockNode("2"),
+		},
+		Edges: [][2]int{
+			{0, 1},
+			{1, 2},
+		},
+	})
+
+	// The rule rewrites each node the first time it is visited,
+	// so the first pass changes the plan and the second does not.
+	done := make(map[plan.NodeID]bool)
+	rule := &plantest.FunctionRule{
+		RewriteFn: func(ctx context.Context, node plan.Node) (plan.Node, bool, error) {
+			if done[node.ID()] {
+				return node, false, nil
+			}
+			done[node.ID()] = true
+			return node, true, nil
+		},
+	}
+	thePlanner := plan.NewPhysicalPlanner(plan.OnlyPhysicalRules(rule))
+	if metrics := thePlanner.Metrics(); len(metrics) != 0 {
+		t.Errorf("expected no metrics before planning, got %v", metrics)
+	}
+	if _, err := thePlanner.Plan(context.Background(), planSpec); err != nil {
+		t.Fatalf("Could not plan: %v", err)
+	}
+
+	metrics := thePlanner.Metrics()
+	var total time.Duration
+	got := make(map[string]plan.RuleMetrics)
+	for _, m := range metrics {
+		got[m.RuleName] = m
+		total += m.Duration
+	}
+	if m := got["function"]; m.Applications != 6 || m.Rewrites != 3 {
+		t.Errorf("unexpected metrics of the function rule, want 6 applications and 3 rewrites, got %+v", m)
+	}
+	// The physical converter rule is always applied.
+	if m := got["physicalConverterRule"]; m.Applications == 0 {
+		t.Errorf("expected the physical converter rule to be applied, got %+v", m)
+	}
+	if total <= 0 {
+		t.Errorf("expected a positive total duration, got %v", total)
+	}
+	if !sort.SliceIsSorted(metrics, func(i, j int) bool {
+		return metrics[i].RuleName < metrics[j].RuleName
+	}) {
+		t.Errorf("expected the metrics to be sorted by rule name, got %v", metrics)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/influxdata/flux/codes"
 	"github.com/influxdata/flux/internal/errors"
 	"github.com/influxdata/flux/interpreter"
+	"go.uber.org/zap"
 )
 
 // LogicalPlanner translates a flux.Spec into a plan.Spec and applies any
@@ -20,6 +21,9 @@ import (
 type LogicalPlanner interface {
 	CreateInitialPlan(spec *flux.Spec) (*Spec, error)
 	Plan(context.Context, *Spec) (*Spec, error)
+	// Metrics returns the metrics of the rules that
+	// the planner applied, sorted by rule name.
+	Metrics() []RuleMetrics
 }
 
 // NewLogicalPlanner returns a new logical plan with the given options.
@@ -79,6 +83,10 @@ func AddLogicalRules(rules ...Rule) LogicalOption {
 	})
 }
 
+// RemoveLogicalRules produces a logical plan option that applies every rule
+// except the rules with the given names. It is the complement of
+// OnlyLogicalRules and has the same effect as the names being listed in
+// the planner.disableLogicalRules option. A name without a rule is ignored.
 func RemoveLogicalRules(rules ...string) LogicalOption {
 	return logicalOption(func(lp *logicalPlanner) {
 		lp.removeRules(rules...)
@@ -92,6 +100,16 @@ func DisableIntegrityChecks() LogicalOption {
 	})
 }
 
+// WithMaxLogicalPasses limits the logical planner to n passes over the plan.
+// When the plan still changes after n passes, the logger is given a warning
+// and the plan is returned without applying the remaining rules.
+// A limit of zero or less is the same as no limit.
+func WithMaxLogicalPasses(n int, logger *zap.Logger) LogicalOption {
+	return logicalOption(func(lp *logicalPlanner) {
+		lp.setMaxPasses(n, logger)
+	})
+}
+
 // CreateInitialPlan translates the flux.Spec into an unoptimized, naive plan.
 func (l *logicalPlanner) CreateInitialPlan(spec *flux.Spec) (*Spec, error) {
 	return createLogicalPlan(spec)
@@ -0,0 +1,289 @@
+package plan
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/flux/semantic"
+	"github.com/influxdata/flux/values"
+)
+
+// redacted replaces the value of a sensitive field of a procedure spec.
+const redacted = "<redacted>"
+
+// sensitiveFields are the names of the procedure spec fields that
+// may hold credentials. Their values are redacted when a plan is
+// serialized so that the output can be shared to debug a query.
+var sensitiveFields = map[string]bool{
+	"DataSourceName": true,
+	"Headers":        true,
+	"Password":       true,
+	"Secret":         true,
+	"Token":          true,
+}
+
+// planJSON is the JSON representation of a plan.
+type planJSON struct {
+	Now   time.Time  `json:"now"`
+	Nodes []nodeJSON `json:"nodes"`
+	Edges []edgeJSON `json:"edges"`
+}
+
+type nodeJSON struct {
+	ID   NodeID                 `json:"id"`
+	Kind ProcedureKind          `json:"kind"`
+	Spec map[string]interface{} `json:"spec"`
+	Cost *costJSON              `json:"cost,omitempty"`
+}
+
+type costJSON struct {
+	Disk int64 `json:"disk"`
+	CPU  int64 `json:"cpu"`
+	GPU  int64 `json:"gpu"`
+	MEM  int64 `json:"mem"`
+	NET  int64 `json:"net"`
+	Rows int64 `json:"rows"`
+}
+
+type edgeJSON struct {
+	From NodeID `json:"from"`
+	To   NodeID `json:"to"`
+}
+
+// MarshalJSON encodes the nodes and edges of the plan.
+//
+// Each node has its ID, its procedure kind, a summary of its procedure
+// spec and, for a physical plan, its estimated cost. The nodes are sorted
+// by their IDs and the edges by the IDs of their nodes, so the output is
+// the same each time the plan is encoded. The summary of a spec contains
+// its exported fields. Functions are written as their source when it can
+// be formatted, and the values of fields that may hold credentials,
+// such as the token of a from, are redacted.
+//
+// HTML characters are not escaped, so the plan should be written
+// with an encoder that does not escape them either.
+func (plan *Spec) MarshalJSON() ([]byte, error) {
+	p := planJSON{
+		Now:   plan.Now,
+		Nodes: []nodeJSON{},
+		Edges: []edgeJSON{},
+	}
+	costs := nodeCosts(plan)
+	if err := plan.BottomUpWalk(func(n Node) error {
+		spec, _ := summarize(reflect.ValueOf(n.ProcedureSpec()), nil).(map[string]interface{})
+		if spec == nil {
+			spec = map[string]interface{}{}
+		}
+		p.Nodes = append(p.Nodes, nodeJSON{
+			ID:   n.ID(),
+			Kind: n.Kind(),
+			Spec: spec,
+			Cost: costs[n],
+		})
+		for _, pred := range n.Predecessors() {
+			p.Edges = append(p.Edges, edgeJSON{From: pred.ID(), To: n.ID()})
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	sortNodesAndEdges(p.Nodes, p.Edges)
+
+	// Functions are written with operators such as => and >
+	// which should stay readable.
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(p); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// nodeCosts estimates the cost of each node of a physical plan.
+// A logical plan has no costs.
+func nodeCosts(plan *Spec) map[Node]*costJSON {
+	costs := make(map[Node]*costJSON)
+	stats := make(map[Node]Statistics)
+	_ = plan.BottomUpWalk(func(n Node) error {
+		ppn, ok := n.(*PhysicalPlanNode)
+		if !ok {
+			return nil
+		}
+		inStats := make([]Statistics, 0, len(n.Predecessors()))
+		for _, pred := range n.Predecessors() {
+			inStats = append(inStats, stats[pred])
+		}
+		cost, outStats := ppn.Cost(inStats)
+		stats[n] = outStats
+		costs[n] = &costJSON{
+			Disk: cost.Disk,
+			CPU:  cost.CPU,
+			GPU:  cost.GPU,
+			MEM:  cost.MEM,
+			NET:  cost.NET,
+			Rows: outStats.Cardinality,
+		}
+		return nil
+	})
+	return costs
+}
+
+// sortNodesAndEdges sorts the nodes by their IDs and
+// the edges by the IDs of the nodes that they connect.
+func sortNodesAndEdges(nodes []nodeJSON, edges []edgeJSON) {
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].ID < nodes[j].ID
+	})
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		return edges[i].To < edges[j].To
+	})
+}
+
+var (
+	stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+	scopeType    = reflect.TypeOf((*values.Scope)(nil)).Elem()
+	functionType = reflect.TypeOf((*semantic.FunctionExpression)(nil))
+)
+
+// summarize converts a value of a procedure spec to a value that
+// can be encoded as JSON. The path holds the pointers that are being
+// summarized so that a cycle is not followed forever.
+func summarize(v reflect.Value, path map[uintptr]bool) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+	}
+
+	if v.Type() == functionType {
+		return formatFunction(v.Interface().(*semantic.FunctionExpression))
+	}
+	if v.Type().Implements(valueType) && v.CanInterface() {
+		return values.DisplayString(v.Interface().(values.Value))
+	}
+	if v.Type() == timeType {
+		return v.Interface().(time.Time).UTC().Format(time.RFC3339Nano)
+	}
+	if v.Type().Implements(stringerType) && v.CanInterface() {
+		return v.Interface().(fmt.Stringer).String()
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint()
+	case reflect.Float32, reflect.Float64:
+		// JSON has no representation for NaN or infinities.
+		if f := v.Float(); math.IsNaN(f) || math.IsInf(f, 0) {
+			return strconv.FormatFloat(f, 'g', -1, 64)
+		}
+		return v.Float()
+	case reflect.String:
+		return v.String()
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return hex.EncodeToString(v.Bytes())
+		}
+		list := make([]interface{}, v.Len())
+		for i := range list {
+			list[i] = summarize(v.Index(i), path)
+		}
+		return list
+	case reflect.Map:
+		m := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			m[fmt.Sprint(summarize(iter.Key(), path))] = summarize(iter.Value(), path)
+		}
+		return m
+	case reflect.Struct:
+		m := make(map[string]interface{})
+		summarizeFields(v, m, path)
+		return m
+	case reflect.Ptr:
+		ptr := v.Pointer()
+		if path[ptr] {
+			return "<cycle>"
+		}
+		if path == nil {
+			path = make(map[uintptr]bool)
+		}
+		path[ptr] = true
+		defer delete(path, ptr)
+		return summarize(v.Elem(), path)
+	case reflect.Interface:
+		return summarize(v.Elem(), path)
+	default:
+		// Functions, channels and unsafe pointers
+		// are only identified by their types.
+		return v.Type().String()
+	}
+}
+
+// summarizeFields adds the exported fields of a struct to m.
+// The fields of an embedded struct are added as if they were
+// fields of the struct that embeds it.
+func summarizeFields(v reflect.Value, m map[string]interface{}, path map[uintptr]bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" || strings.Split(f.Tag.Get("json"), ",")[0] == "-" {
+			continue
+		}
+		if f.Type == scopeType {
+			// The scope of a function holds every value
+			// that it can reference, including the standard
+			// library, so it is not useful in a summary.
+			continue
+		}
+		fv := v.Field(i)
+		if f.Anonymous && fv.Kind() == reflect.Struct && !fv.Type().Implements(stringerType) {
+			summarizeFields(fv, m, path)
+			continue
+		}
+		if sensitiveFields[f.Name] && !fv.IsZero() {
+			m[f.Name] = redacted
+			continue
+		}
+		m[f.Name] = summarize(fv, path)
+	}
+}
+
+// formatFunction writes a function as its source when its body is
+// a single expression that can be formatted.
+func formatFunction(fn *semantic.FunctionExpression) string {
+	var params []string
+	if fn.Parameters != nil {
+		for _, p := range fn.Parameters.List {
+			params = append(params, p.Key.Name.Name())
+		}
+	}
+	body := "..."
+	if fn.Block != nil {
+		if expr, ok := fn.GetFunctionBodyExpression(); ok {
+			if s := fmt.Sprint(semantic.Formatted(expr)); !strings.Contains(s, "<semantic format error") {
+				body = s
+			}
+		}
+	}
+	return fmt.Sprintf("(%s) => %s", strings.Join(params, ", "), body)
+}
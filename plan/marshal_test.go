@@ -0,0 +1,187 @@
+package plan_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/interpreter"
+	"github.com/influxdata/flux/plan"
+	"github.com/influxdata/flux/plan/plantest"
+	"github.com/influxdata/flux/semantic"
+	"github.com/influxdata/flux/stdlib/influxdata/influxdb"
+	"github.com/influxdata/flux/stdlib/universe"
+)
+
+// newMarshalPlan returns the physical plan of
+// from |> range |> filter |> count once the
+// range has been merged into the remote read.
+func newMarshalPlan() *plan.Spec {
+	now := time.Date(2018, 4, 17, 0, 0, 0, 0, time.UTC)
+	r := func() semantic.Expression {
+		return &semantic.IdentifierExpression{Name: semantic.NewSymbol("r")}
+	}
+	fn := &semantic.FunctionExpression{
+		Parameters: &semantic.FunctionParameters{
+			List: []*semantic.FunctionParameter{{
+				Key: &semantic.Identifier{Name: semantic.NewSymbol("r")},
+			}},
+		},
+		Block: &semantic.Block{
+			Body: []semantic.Statement{
+				&semantic.ReturnStatement{
+					Argument: &semantic.BinaryExpression{
+						Operator: ast.GreaterThanOperator,
+						Left: &semantic.MemberExpression{
+							Object:   r(),
+							Property: semantic.NewSymbol("_value"),
+						},
+						Right: &semantic.FloatLiteral{Value: 0},
+					},
+				},
+			},
+		},
+	}
+	return plantest.CreatePlanSpec(&plantest.PlanSpec{
+		Nodes: []plan.Node{
+			plan.CreatePhysicalNode("merged_fromRemote0_range1", &influxdb.FromRemoteProcedureSpec{
+				Config: influxdb.Config{
+					Bucket: influxdb.NameOrID{Name: "telegraf"},
+					Host:   "http://localhost:8086",
+					Token:  "mytoken",
+				},
+				Bounds: flux.Bounds{
+					Start: flux.Time{IsRelative: true, Relative: -time.Hour},
+					Stop:  flux.Now,
+					Now:   now,
+				},
+			}),
+			plan.CreatePhysicalNode("filter2", &universe.FilterProcedureSpec{
+				Fn: interpreter.ResolvedFunction{Fn: fn},
+			}),
+			plan.CreatePhysicalNode("count3", &universe.CountProcedureSpec{
+				SimpleAggregateConfig: execute.SimpleAggregateConfig{
+					Columns: []string{"_value"},
+				},
+			}),
+		},
+		Edges: [][2]int{
+			{0, 1},
+			{1, 2},
+		},
+		Now: now,
+	})
+}
+
+func TestSpec_MarshalJSON(t *testing.T) {
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(newMarshalPlan()); err != nil {
+		t.Fatal(err)
+	}
+	data := b.String()
+
+	want := `{
+  "now": "2018-04-17T00:00:00Z",
+  "nodes": [
+    {
+      "id": "count3",
+      "kind": "count",
+      "spec": {
+        "Columns": [
+          "_value"
+        ]
+      },
+      "cost": {
+        "disk": 0,
+        "cpu": 0,
+        "gpu": 0,
+        "mem": 0,
+        "net": 0,
+        "rows": 57600
+      }
+    },
+    {
+      "id": "filter2",
+      "kind": "filter",
+      "spec": {
+        "Fn": {
+          "Fn": "(r) => r._value > 0.000000"
+        },
+        "KeepEmptyTables": false
+      },
+      "cost": {
+        "disk": 0,
+        "cpu": 0,
+        "gpu": 0,
+        "mem": 0,
+        "net": 0,
+        "rows": 57600
+      }
+    },
+    {
+      "id": "merged_fromRemote0_range1",
+      "kind": "influxdata/influxdb.fromRemote",
+      "spec": {
+        "Bounds": {
+          "Now": "2018-04-17T00:00:00Z",
+          "Start": {
+            "Absolute": "0001-01-01T00:00:00Z",
+            "IsRelative": true,
+            "Relative": "-1h0m0s"
+          },
+          "Stop": {
+            "Absolute": "0001-01-01T00:00:00Z",
+            "IsRelative": true,
+            "Relative": "0s"
+          }
+        },
+        "Bucket": {
+          "ID": "",
+          "Name": "telegraf"
+        },
+        "Host": "http://localhost:8086",
+        "KeepCols": null,
+        "Org": {
+          "ID": "",
+          "Name": ""
+        },
+        "PredicateSet": null,
+        "Token": "<redacted>"
+      },
+      "cost": {
+        "disk": 3686400,
+        "cpu": 0,
+        "gpu": 0,
+        "mem": 0,
+        "net": 3686400,
+        "rows": 57600
+      }
+    }
+  ],
+  "edges": [
+    {
+      "from": "filter2",
+      "to": "count3"
+    },
+    {
+      "from": "merged_fromRemote0_range1",
+      "to": "filter2"
+    }
+  ]
+}
+`
+	if diff := cmp.Diff(want, data); diff != "" {
+		t.Errorf("unexpected json -want/+got:\n%s", diff)
+	}
+	if strings.Contains(data, "mytoken") {
+		t.Error("expected the token to be redacted")
+	}
+}
@@ -63,6 +63,33 @@ func Any() Pattern {
 	return &AnyPattern{}
 }
 
+// Not returns a pattern that matches any plan node that the given pattern
+// does not match. It is composed with Pat to match a node that is not
+// preceded by a specific node.
+//
+// For example, to construct a pattern that matches a filter
+// that does not directly follow a from:
+//
+//   Pat(FilterKind, Not(Pat(FromKind)))
+func Not(pattern Pattern) Pattern {
+	return NotPattern{pattern: pattern}
+}
+
+// NotPattern matches any plan node that its pattern does not match.
+type NotPattern struct {
+	pattern Pattern
+}
+
+// Roots returns AnyKind since a node of any kind
+// can fail to match the pattern.
+func (NotPattern) Roots() []ProcedureKind {
+	return []ProcedureKind{AnyKind}
+}
+
+func (np NotPattern) Match(node Node) bool {
+	return !np.pattern.Match(node)
+}
+
 // UnionKindPattern matches any one of a set of procedures that have a
 // specified predecessor pattern.
 //
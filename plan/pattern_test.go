@@ -1,9 +1,12 @@
 package plan_test
 
 import (
+	"context"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/influxdata/flux/plan"
+	"github.com/influxdata/flux/plan/plantest"
 	"github.com/influxdata/flux/stdlib/influxdata/influxdb"
 	"github.com/influxdata/flux/stdlib/universe"
 )
@@ -91,3 +94,101 @@ func TestPat(t *testing.T) {
 		t.Fatalf("Expected match")
 	}
 }
+
+func TestNot(t *testing.T) {
+	from := &plan.LogicalNode{
+		Spec: &influxdb.FromProcedureSpec{},
+	}
+	filter := &plan.LogicalNode{
+		Spec: &universe.FilterProcedureSpec{},
+	}
+	count := &plan.LogicalNode{
+		Spec: &universe.CountProcedureSpec{},
+	}
+
+	if plan.Not(plan.Any()).Match(filter) {
+		t.Fatalf("Unexpected match")
+	}
+
+	notFilterPat := plan.Not(plan.Pat(universe.FilterKind))
+	if !notFilterPat.Match(count) {
+		t.Fatalf("Expected match")
+	}
+	if notFilterPat.Match(filter) {
+		t.Fatalf("Unexpected match")
+	}
+
+	// Matches a filter that does not directly follow a from.
+	filterNotFromPat := plan.Pat(universe.FilterKind, plan.Not(plan.Pat(influxdb.FromKind)))
+
+	// from |> filter
+	addEdge(from, filter)
+	if filterNotFromPat.Match(filter) {
+		t.Fatalf("Unexpected match")
+	}
+
+	// count |> filter2
+	filter2 := &plan.LogicalNode{
+		Spec: &universe.FilterProcedureSpec{},
+	}
+	addEdge(count, filter2)
+	if !filterNotFromPat.Match(filter2) {
+		t.Fatalf("Expected match")
+	}
+}
+
+// seenRule records the nodes that its pattern matched.
+type seenRule struct {
+	pattern   plan.Pattern
+	seenNodes []plan.NodeID
+}
+
+func (r *seenRule) Name() string {
+	return "seen"
+}
+
+func (r *seenRule) Pattern() plan.Pattern {
+	return r.pattern
+}
+
+func (r *seenRule) Rewrite(ctx context.Context, node plan.Node) (plan.Node, bool, error) {
+	for _, id := range r.seenNodes {
+		if id == node.ID() {
+			return node, false, nil
+		}
+	}
+	r.seenNodes = append(r.seenNodes, node.ID())
+	return node, false, nil
+}
+
+func TestNot_Rule(t *testing.T) {
+	// from |> filter0 |> filter1 |> count |> filter2
+	spec := plantest.CreatePlanSpec(&plantest.PlanSpec{
+		Nodes: []plan.Node{
+			plan.CreateLogicalNode("from", &influxdb.FromProcedureSpec{}),
+			plan.CreateLogicalNode("filter0", &universe.FilterProcedureSpec{}),
+			plan.CreateLogicalNode("filter1", &universe.FilterProcedureSpec{}),
+			plan.CreateLogicalNode("count", &universe.CountProcedureSpec{}),
+			plan.CreateLogicalNode("filter2", &universe.FilterProcedureSpec{}),
+		},
+		Edges: [][2]int{
+			{0, 1},
+			{1, 2},
+			{2, 3},
+			{3, 4},
+		},
+	})
+
+	rule := &seenRule{
+		pattern: plan.Pat(universe.FilterKind, plan.Not(plan.Pat(influxdb.FromKind))),
+	}
+	planner := plan.NewLogicalPlanner(plan.OnlyLogicalRules(rule))
+	if _, err := planner.Plan(context.Background(), spec); err != nil {
+		t.Fatalf("could not do logical planning: %v", err)
+	}
+
+	want := []plan.NodeID{"filter2", "filter1"}
+	if !cmp.Equal(want, rule.seenNodes) {
+		t.Errorf("unexpected matched nodes -want/+got:\n%s", cmp.Diff(want, rule.seenNodes))
+	}
+}
@@ -8,12 +8,16 @@ import (
 	"github.com/influxdata/flux"
 	"github.com/influxdata/flux/codes"
 	"github.com/influxdata/flux/interpreter"
+	"go.uber.org/zap"
 )
 
 // PhysicalPlanner performs transforms a logical plan to a physical plan,
 // by applying any registered physical rules.
 type PhysicalPlanner interface {
 	Plan(ctx context.Context, lplan *Spec) (*Spec, error)
+	// Metrics returns the metrics of the rules that
+	// the planner applied, sorted by rule name.
+	Metrics() []RuleMetrics
 }
 
 // NewPhysicalPlanner creates a new physical plan with the specified options.
@@ -54,6 +58,14 @@ func NewPhysicalPlanner(options ...PhysicalOption) PhysicalPlanner {
 	return pp
 }
 
+// Metrics returns the metrics of the physical and the parallelize rules.
+func (pp *physicalPlanner) Metrics() []RuleMetrics {
+	return mergeRuleMetrics(
+		pp.heuristicPlannerPhysical.Metrics(),
+		pp.heuristicPlannerParallel.Metrics(),
+	)
+}
+
 func (pp *physicalPlanner) Plan(ctx context.Context, spec *Spec) (*Spec, error) {
 	intermediateSpec, err := pp.heuristicPlannerPhysical.Plan(ctx, spec)
 	if err != nil {
@@ -207,6 +219,11 @@ func AddParallelRules(rules ...Rule) PhysicalOption {
 	})
 }
 
+// RemovePhysicalRules produces a physical plan option that applies every
+// physical and parallel rule except the rules with the given names.
+// It is the complement of OnlyPhysicalRules and has the same effect as the
+// names being listed in the planner.disablePhysicalRules option.
+// A name without a rule is ignored.
 func RemovePhysicalRules(rules ...string) PhysicalOption {
 	return physicalOption(func(pp *physicalPlanner) {
 		pp.heuristicPlannerPhysical.removeRules(rules...)
@@ -214,6 +231,19 @@ func RemovePhysicalRules(rules ...string) PhysicalOption {
 	})
 }
 
+// WithMaxPhysicalPasses limits the physical planner to n passes over the
+// plan for the physical rules and n passes for the parallel rules. When the
+// plan still changes after n passes, the logger is given a warning and the
+// plan is returned without applying the remaining rules. The first pass
+// converts the logical nodes, so the plan is still valid. A limit of zero
+// or less is the same as no limit.
+func WithMaxPhysicalPasses(n int, logger *zap.Logger) PhysicalOption {
+	return physicalOption(func(pp *physicalPlanner) {
+		pp.heuristicPlannerPhysical.setMaxPasses(n, logger)
+		pp.heuristicPlannerParallel.setMaxPasses(n, logger)
+	})
+}
+
 // DisableValidation disables validation in the physical planner.
 func DisableValidation() PhysicalOption {
 	return physicalOption(func(p *physicalPlanner) {
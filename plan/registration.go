@@ -2,6 +2,7 @@ package plan
 
 import (
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/influxdata/flux"
@@ -79,6 +80,26 @@ func RegisterLogicalRules(rules ...Rule) {
 	registerRule(ruleNameToLogicalRule, rules...)
 }
 
+// RegisterLogicalRulesOnce registers the rules with the logical plan like
+// RegisterLogicalRules, but skips a rule when a rule with the same name has
+// already been registered instead of panicking. This lets a test register
+// the rules it needs without knowing whether they have been registered.
+func RegisterLogicalRulesOnce(rules ...Rule) {
+	for _, rule := range rules {
+		name := rule.Name()
+		if _, ok := ruleNameToLogicalRule[name]; ok {
+			continue
+		}
+		ruleNameToLogicalRule[name] = rule
+	}
+}
+
+// UnregisterLogicalRule removes the logical rule with the given name.
+// It does nothing if no rule with that name is registered.
+func UnregisterLogicalRule(name string) {
+	delete(ruleNameToLogicalRule, name)
+}
+
 // RegisterPhysicalRules registers the rule created by createFn with the physical plan.
 func RegisterPhysicalRules(rules ...Rule) {
 	registerRule(ruleNameToPhysicalRule, rules...)
@@ -99,6 +120,29 @@ func registerRule(ruleMap map[string]Rule, rules ...Rule) {
 	}
 }
 
+// RegisteredRulesForKind returns the sorted names of the registered
+// logical, physical and parallelize rules whose patterns are rooted at
+// the given procedure kind.
+func RegisteredRulesForKind(kind ProcedureKind) []string {
+	var names []string
+	for _, ruleMap := range []map[string]Rule{
+		ruleNameToLogicalRule,
+		ruleNameToPhysicalRule,
+		ruleNameToParallelizeRules,
+	} {
+		for name, rule := range ruleMap {
+			for _, root := range rule.Pattern().Roots() {
+				if root == kind {
+					names = append(names, name)
+					break
+				}
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
 func ClearRegisteredRules() {
 	ruleNameToLogicalRule = make(map[string]Rule)
 	ruleNameToPhysicalRule = make(map[string]Rule)
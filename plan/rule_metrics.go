@@ -0,0 +1,67 @@
+package plan
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// RuleMetrics holds how often a planner rule was applied
+// and the time that the rule spent rewriting the plan.
+type RuleMetrics struct {
+	// RuleName is the name of the rule.
+	RuleName string
+	// Applications counts the nodes whose pattern matched the rule,
+	// so that the rule was asked to rewrite them.
+	Applications int64
+	// Rewrites counts the applications that changed the plan.
+	Rewrites int64
+	// Duration is the time that the rule spent rewriting the plan.
+	Duration time.Duration
+}
+
+// ruleMetrics collects the RuleMetrics of each rule by its name.
+type ruleMetrics map[string]*RuleMetrics
+
+// rewrite applies the rule to the node and adds the application to the metrics.
+func (m ruleMetrics) rewrite(ctx context.Context, rule Rule, node Node) (Node, bool, error) {
+	start := time.Now()
+	newNode, changed, err := rule.Rewrite(ctx, node)
+	d := time.Since(start)
+
+	rm, ok := m[rule.Name()]
+	if !ok {
+		rm = &RuleMetrics{RuleName: rule.Name()}
+		m[rule.Name()] = rm
+	}
+	rm.Applications++
+	if err == nil && changed {
+		rm.Rewrites++
+	}
+	rm.Duration += d
+	return newNode, changed, err
+}
+
+// mergeRuleMetrics adds up the metrics of the rules that
+// have the same name and sorts the result by rule name.
+func mergeRuleMetrics(metrics ...[]RuleMetrics) []RuleMetrics {
+	byName := make(map[string]int)
+	var merged []RuleMetrics
+	for _, ms := range metrics {
+		for _, m := range ms {
+			i, ok := byName[m.RuleName]
+			if !ok {
+				byName[m.RuleName] = len(merged)
+				merged = append(merged, m)
+				continue
+			}
+			merged[i].Applications += m.Applications
+			merged[i].Rewrites += m.Rewrites
+			merged[i].Duration += m.Duration
+		}
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].RuleName < merged[j].RuleName
+	})
+	return merged
+}
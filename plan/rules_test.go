@@ -78,6 +78,119 @@ func TestRuleRegistration(t *testing.T) {
 	}
 }
 
+func TestRegisterLogicalRulesOnce(t *testing.T) {
+	plan.ClearRegisteredRules()
+	defer plan.ClearRegisteredRules()
+
+	logicalPlan := func() {
+		t.Helper()
+		spec := plantest.CreatePlanSpec(&plantest.PlanSpec{
+			Nodes: []plan.Node{
+				plantest.CreateLogicalMockNode("0"),
+				plantest.CreateLogicalMockNode("1"),
+			},
+			Edges: [][2]int{{0, 1}},
+		})
+		if _, err := plan.NewLogicalPlanner().Plan(context.Background(), spec); err != nil {
+			t.Fatalf("could not do logical planning: %v", err)
+		}
+	}
+
+	first, second := plantest.SimpleRule{}, plantest.SimpleRule{}
+	plan.RegisterLogicalRulesOnce(&first)
+	// The second rule has the same name, so it is skipped.
+	plan.RegisterLogicalRulesOnce(&second)
+
+	logicalPlan()
+	if len(first.SeenNodes) == 0 {
+		t.Error("expected the first rule to have been registered and have seen some nodes")
+	}
+	if len(second.SeenNodes) != 0 {
+		t.Errorf("expected the second rule to not have been registered, but it saw %v", second.SeenNodes)
+	}
+
+	first.SeenNodes = first.SeenNodes[0:0]
+	plan.UnregisterLogicalRule(first.Name())
+	logicalPlan()
+	if len(first.SeenNodes) != 0 {
+		t.Errorf("expected the rule to have been unregistered, but it saw %v", first.SeenNodes)
+	}
+
+	// Once it is unregistered, the name can be registered again.
+	plan.RegisterLogicalRules(&second)
+	logicalPlan()
+	if len(second.SeenNodes) == 0 {
+		t.Error("expected the second rule to have been registered and have seen some nodes")
+	}
+}
+
+func TestRemoveRules(t *testing.T) {
+	plan.ClearRegisteredRules()
+	defer plan.ClearRegisteredRules()
+
+	logicalRule, physicalRule := plantest.SimpleRule{}, plantest.SimpleRule{}
+	plan.RegisterLogicalRules(&logicalRule)
+	plan.RegisterPhysicalRules(&physicalRule)
+
+	for _, tc := range []struct {
+		name         string
+		lopts        []plan.LogicalOption
+		popts        []plan.PhysicalOption
+		wantLogical  bool
+		wantPhysical bool
+	}{
+		{
+			name:         "no rules removed",
+			wantLogical:  true,
+			wantPhysical: true,
+		},
+		{
+			name:         "remove logical rule",
+			lopts:        []plan.LogicalOption{plan.RemoveLogicalRules("simple")},
+			wantPhysical: true,
+		},
+		{
+			name:        "remove physical rule",
+			popts:       []plan.PhysicalOption{plan.RemovePhysicalRules("simple")},
+			wantLogical: true,
+		},
+		{
+			name:         "remove non existent rule",
+			lopts:        []plan.LogicalOption{plan.RemoveLogicalRules("non_existent")},
+			popts:        []plan.PhysicalOption{plan.RemovePhysicalRules("non_existent")},
+			wantLogical:  true,
+			wantPhysical: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			logicalRule.SeenNodes = nil
+			physicalRule.SeenNodes = nil
+
+			spec := plantest.CreatePlanSpec(&plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plantest.CreateLogicalMockNode("0"),
+					plantest.CreateLogicalMockNode("1"),
+				},
+				Edges: [][2]int{{0, 1}},
+			})
+			spec, err := plan.NewLogicalPlanner(tc.lopts...).Plan(context.Background(), spec)
+			if err != nil {
+				t.Fatalf("could not do logical planning: %v", err)
+			}
+			if _, err := plan.NewPhysicalPlanner(tc.popts...).Plan(context.Background(), spec); err != nil {
+				t.Fatalf("could not do physical planning: %v", err)
+			}
+
+			if got := len(logicalRule.SeenNodes) > 0; got != tc.wantLogical {
+				t.Errorf("unexpected logical rule application, want %v, got nodes %v", tc.wantLogical, logicalRule.SeenNodes)
+			}
+			if got := len(physicalRule.SeenNodes) > 0; got != tc.wantPhysical {
+				t.Errorf("unexpected physical rule application, want %v, got nodes %v", tc.wantPhysical, physicalRule.SeenNodes)
+			}
+		})
+	}
+}
+
 type contextKey string
 
 func TestRewriteWithContext(t *testing.T) {
@@ -0,0 +1,25 @@
+package plan
+
+import "github.com/influxdata/flux"
+
+// OutputSchemer is implemented by procedure specs that can derive
+// the columns of their output from the plan. The input is the schema
+// of the output of the predecessor, which is dynamic for a source.
+type OutputSchemer interface {
+	OutputSchema(input flux.ResultSchema) flux.ResultSchema
+}
+
+// GetOutputSchema returns the schema of the output of the node.
+// The schema is dynamic unless the procedure spec of the node is
+// an OutputSchemer, since nothing is known about its output.
+func GetOutputSchema(node Node) flux.ResultSchema {
+	s, ok := node.ProcedureSpec().(OutputSchemer)
+	if !ok {
+		return flux.ResultSchema{Dynamic: true}
+	}
+	input := flux.ResultSchema{Dynamic: true}
+	if preds := node.Predecessors(); len(preds) == 1 {
+		input = GetOutputSchema(preds[0])
+	}
+	return s.OutputSchema(input)
+}
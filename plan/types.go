@@ -7,12 +7,19 @@ import (
 	"time"
 
 	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/internal/errors"
 	"github.com/influxdata/flux/interpreter"
 	"github.com/influxdata/flux/interval"
+	"github.com/influxdata/flux/semantic"
+	"github.com/influxdata/flux/values"
 )
 
 type Planner interface {
 	Plan(context.Context, *flux.Spec) (*Spec, error)
+	// Metrics returns the metrics of the rules that
+	// the planner applied, sorted by rule name.
+	Metrics() []RuleMetrics
 }
 
 // Node defines the common interface for interacting with
@@ -157,6 +164,39 @@ type ProcedureSpec interface {
 	Copy() ProcedureSpec
 }
 
+// ValidatingProcedureSpec is implemented by procedure specs that check
+// their own invariants. The planner validates the spec of every node of
+// the final plan, so an invalid spec is reported as a planning error
+// instead of failing during execution.
+type ValidatingProcedureSpec interface {
+	ProcedureSpec
+	Validate() error
+}
+
+// DefaultValidate is embedded by procedure specs
+// that do not have any invariants to check.
+type DefaultValidate struct{}
+
+func (DefaultValidate) Validate() error {
+	return nil
+}
+
+// ValidateProcedureSpecs validates the procedure spec
+// of every node of the plan that implements
+// ValidatingProcedureSpec.
+func ValidateProcedureSpecs(plan *Spec) error {
+	return plan.BottomUpWalk(func(node Node) error {
+		spec, ok := node.ProcedureSpec().(ValidatingProcedureSpec)
+		if !ok {
+			return nil
+		}
+		if err := spec.Validate(); err != nil {
+			return errors.Wrapf(err, codes.Inherit, "invalid procedure spec for %q", node.ID())
+		}
+		return nil
+	})
+}
+
 // ProcedureKind denotes the kind of operation
 type ProcedureKind string
 
@@ -373,3 +413,25 @@ func (l Location) Load() (interval.Location, error) {
 	loc.Offset = l.Offset
 	return loc, nil
 }
+
+// LocationFromObject reads a location from a record
+// with a zone and an optional offset such as the
+// value of the location option.
+func LocationFromObject(obj values.Object) (Location, error) {
+	var loc Location
+	name, ok := obj.Get("zone")
+	if !ok {
+		return loc, errors.New(codes.Invalid, "zone property missing from location record")
+	} else if got := name.Type().Nature(); got != semantic.String {
+		return loc, errors.Newf(codes.Invalid, "zone property for location must be of type %s, got %s", semantic.String, got)
+	}
+	loc.Name = name.Str()
+
+	if offset, ok := obj.Get("offset"); ok {
+		if got := offset.Type().Nature(); got != semantic.Duration {
+			return loc, errors.Newf(codes.Invalid, "offset property for location must be of type %s, got %s", semantic.Duration, got)
+		}
+		loc.Offset = offset.Duration()
+	}
+	return loc, nil
+}
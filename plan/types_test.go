@@ -3,6 +3,9 @@ package plan_test
 import (
 	"testing"
 
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/internal/errors"
 	"github.com/influxdata/flux/plan"
 	"github.com/influxdata/flux/plan/plantest"
 )
@@ -78,3 +81,45 @@ func assertKO(t *testing.T, p *plan.Spec) {
 		t.Fatal("unexpected integrity check pass")
 	}
 }
+
+// validatingSpec is a procedure spec that
+// fails to validate when err is set.
+type validatingSpec struct {
+	plantest.MockProcedureSpec
+	err error
+}
+
+func (s *validatingSpec) Validate() error {
+	return s.err
+}
+
+func TestValidateProcedureSpecs(t *testing.T) {
+	newPlan := func(err error) *plan.Spec {
+		return plantest.CreatePlanSpec(&plantest.PlanSpec{
+			Nodes: []plan.Node{
+				plantest.CreateLogicalMockNode("0"),
+				plan.CreateLogicalNode("1", &validatingSpec{err: err}),
+				plantest.CreateLogicalMockNode("2"),
+			},
+			Edges: [][2]int{
+				{0, 1},
+				{1, 2},
+			},
+		})
+	}
+
+	if err := plan.ValidateProcedureSpecs(newPlan(nil)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	err := plan.ValidateProcedureSpecs(newPlan(errors.New(codes.Invalid, "bad spec")))
+	if err == nil {
+		t.Fatal("expected an error, got none")
+	}
+	if got, want := err.Error(), `invalid procedure spec for "1": bad spec`; got != want {
+		t.Errorf("unexpected error, want %q, got %q", want, got)
+	}
+	if got, want := flux.ErrorCode(err), codes.Invalid; got != want {
+		t.Errorf("unexpected error code, want %v, got %v", want, got)
+	}
+}
@@ -1,5 +1,7 @@
 package plan
 
+import "github.com/influxdata/flux"
+
 // DefaultYieldName is the name of a result that doesn't
 // have any name assigned.
 const DefaultYieldName = "_result"
@@ -10,6 +12,12 @@ type YieldProcedureSpec interface {
 	YieldName() string
 }
 
+// YieldMetadataSpec is implemented by a yield that
+// attaches metadata to the result that it produces.
+type YieldMetadataSpec interface {
+	YieldMetadata() map[string]string
+}
+
 const generatedYieldKind = "generatedYield"
 
 // GeneratedYieldProcedureSpec provides a special planner-generated yield for queries that don't
@@ -30,3 +38,9 @@ func (y *GeneratedYieldProcedureSpec) Copy() ProcedureSpec {
 func (y *GeneratedYieldProcedureSpec) YieldName() string {
 	return y.Name
 }
+
+// OutputSchema implements OutputSchemer.
+// The result is the output of the predecessor.
+func (y *GeneratedYieldProcedureSpec) OutputSchema(input flux.ResultSchema) flux.ResultSchema {
+	return input
+}
@@ -61,6 +61,31 @@ type Statistics struct {
 
 	// Metadata contains metadata key/value pairs that have been attached during execution.
 	Metadata metadata.Metadata `json:"metadata"`
+
+	// Nodes contains the statistics of each node of the plan.
+	// It is only populated when the execution collects metrics.
+	Nodes []NodeStatistics `json:"nodes,omitempty"`
+}
+
+// NodeStatistics is a collection of statistics about
+// the processing of one node of the plan of a query.
+type NodeStatistics struct {
+	// ID is the ID of the plan node.
+	ID string `json:"id"`
+	// Operation is the procedure kind of the plan node.
+	Operation string `json:"operation"`
+	// Duration is the wall time that the node spent processing data.
+	Duration time.Duration `json:"duration"`
+	// RowsIn and RowsOut are the number of rows that
+	// the node read and produced.
+	RowsIn  int64 `json:"rows_in"`
+	RowsOut int64 `json:"rows_out"`
+	// BytesIn and BytesOut are the estimated number of
+	// bytes of the rows that the node read and produced.
+	BytesIn  int64 `json:"bytes_in"`
+	BytesOut int64 `json:"bytes_out"`
+	// Allocated is the total number of bytes that the node allocated.
+	Allocated int64 `json:"allocated"`
 }
 
 // Add returns the sum of s and other.
@@ -71,6 +96,12 @@ func (s Statistics) Add(other Statistics) Statistics {
 	md := make(metadata.Metadata)
 	md.AddAll(s.Metadata)
 	md.AddAll(other.Metadata)
+	var nodes []NodeStatistics
+	if len(s.Nodes)+len(other.Nodes) > 0 {
+		nodes = make([]NodeStatistics, 0, len(s.Nodes)+len(other.Nodes))
+		nodes = append(nodes, s.Nodes...)
+		nodes = append(nodes, other.Nodes...)
+	}
 	return Statistics{
 		TotalDuration:   s.TotalDuration + other.TotalDuration,
 		CompileDuration: s.CompileDuration + other.CompileDuration,
@@ -83,5 +114,6 @@ func (s Statistics) Add(other Statistics) Statistics {
 		TotalAllocated:  s.TotalAllocated + other.TotalAllocated,
 		RuntimeErrors:   errs,
 		Metadata:        md,
+		Nodes:           nodes,
 	}
 }
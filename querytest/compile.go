@@ -25,6 +25,8 @@ type NewQueryTestCase struct {
 	Raw     string
 	Want    *flux.Spec
 	WantErr bool
+	// WantErrMsg, if set, must be contained in the error message.
+	WantErrMsg string
 }
 
 var opts = append(
@@ -49,6 +51,9 @@ func NewQueryTestHelper(t *testing.T, tc NewQueryTestCase) {
 		return
 	}
 	if tc.WantErr {
+		if tc.WantErrMsg != "" && !strings.Contains(err.Error(), tc.WantErrMsg) {
+			t.Errorf("unexpected error message: got %q, want it to contain %q", err.Error(), tc.WantErrMsg)
+		}
 		return
 	}
 	if tc.Want != nil {
@@ -7,7 +7,9 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/interpreter"
 	"github.com/influxdata/flux/semantic/semantictest"
+	"github.com/influxdata/flux/stdlib/join"
 	"github.com/influxdata/flux/stdlib/universe"
 )
 
@@ -18,6 +20,9 @@ func OperationMarshalingTestHelper(t *testing.T, data []byte, expOp *flux.Operat
 		semantictest.CmpOptions,
 		cmp.AllowUnexported(universe.JoinOpSpec{}),
 		cmpopts.IgnoreUnexported(universe.JoinOpSpec{}),
+		cmp.AllowUnexported(join.JoinOpSpec{}),
+		// The scope of a function is not encoded.
+		cmpopts.IgnoreFields(interpreter.ResolvedFunction{}, "Scope"),
 	)
 
 	// Ensure we can properly unmarshal a spec
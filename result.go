@@ -15,6 +15,78 @@ type Result interface {
 	Tables() TableIterator
 }
 
+// ResultSchema describes the columns of the tables of a result
+// that are known from the query plan before any rows arrive.
+type ResultSchema struct {
+	// Columns are the columns known to be in the tables.
+	// A column whose type depends on the data has the type TInvalid.
+	Columns []ColMeta
+	// Key are the labels of the columns known to be in the group key.
+	Key []string
+	// Partial is set when the tables may have columns other than Columns.
+	Partial bool
+	// Dynamic is set when the columns depend on the data
+	// and nothing is known about them from the plan.
+	Dynamic bool
+}
+
+// Copy returns a copy of the schema that can be modified
+// without modifying the schema it was copied from.
+func (s ResultSchema) Copy() ResultSchema {
+	ns := s
+	ns.Columns = append([]ColMeta(nil), s.Columns...)
+	ns.Key = append([]string(nil), s.Key...)
+	return ns
+}
+
+// SetColumn adds the column to the schema or replaces the type of the
+// column with the same label. The column is added to the group key when
+// key is set. The schema is modified in place.
+func (s *ResultSchema) SetColumn(c ColMeta, key bool) {
+	found := false
+	for i := range s.Columns {
+		if s.Columns[i].Label == c.Label {
+			s.Columns[i].Type = c.Type
+			found = true
+			break
+		}
+	}
+	if !found {
+		s.Columns = append(s.Columns, c)
+	}
+	if key && !s.IsKey(c.Label) {
+		s.Key = append(s.Key, c.Label)
+	}
+}
+
+// IsKey reports whether the column with the label
+// is known to be in the group key.
+func (s ResultSchema) IsKey(label string) bool {
+	for _, k := range s.Key {
+		if k == label {
+			return true
+		}
+	}
+	return false
+}
+
+// SchemaResult is implemented by a Result that can
+// describe its tables before any of them are read.
+type SchemaResult interface {
+	Result
+	// Schema returns the schema derived from the query plan.
+	Schema() ResultSchema
+}
+
+// MetadataResult is implemented by a Result that carries metadata,
+// such as the metadata that a yield attaches to its result.
+type MetadataResult interface {
+	Result
+	// Metadata returns the metadata of the result.
+	// It is nil when the result has none.
+	Metadata() map[string]string
+}
+
 type TableIterator interface {
 	Do(f func(Table) error) error
 }
@@ -88,6 +160,7 @@ const (
 	TFloat
 	TString
 	TTime
+	TBytes
 )
 
 // ColumnType returns the column type when given a semantic.Type.
@@ -106,6 +179,8 @@ func ColumnType(typ semantic.MonoType) ColType {
 		return TString
 	case semantic.Time:
 		return TTime
+	case semantic.Bytes:
+		return TBytes
 	default:
 		return TInvalid
 	}
@@ -125,6 +200,8 @@ func SemanticType(typ ColType) semantic.MonoType {
 		return semantic.BasicString
 	case TTime:
 		return semantic.BasicTime
+	case TBytes:
+		return semantic.BasicBytes
 	default:
 		return semantic.MonoType{}
 	}
@@ -147,6 +224,8 @@ func (t ColType) String() string {
 		return "string"
 	case TTime:
 		return "time"
+	case TBytes:
+		return "bytes"
 	default:
 		return "unknown"
 	}
@@ -170,6 +249,7 @@ type ColReader interface {
 	Floats(j int) *array.Float
 	Strings(j int) *array.String
 	Times(j int) *array.Int
+	Bytes(j int) *array.Binary
 
 	// Retain will retain this buffer to avoid having the
 	// memory consumed by it freed.
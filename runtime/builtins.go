@@ -0,0 +1,151 @@
+package runtime
+
+import (
+	"path"
+	"sort"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/plan"
+	"github.com/influxdata/flux/semantic"
+	"github.com/influxdata/flux/values"
+)
+
+// BuiltinKind describes the role a builtin value plays in a query.
+type BuiltinKind string
+
+const (
+	// SourceBuiltin is a function that produces a stream of tables
+	// without consuming one.
+	SourceBuiltin BuiltinKind = "source"
+	// TransformationBuiltin is a function that consumes a stream of
+	// tables and produces a new one.
+	TransformationBuiltin BuiltinKind = "transformation"
+	// SinkBuiltin is a function that produces a stream of tables and
+	// has a side effect, such as writing the tables elsewhere.
+	SinkBuiltin BuiltinKind = "sink"
+	// FunctionBuiltin is any other function.
+	FunctionBuiltin BuiltinKind = "function"
+	// ValueBuiltin is a builtin value that is not a function.
+	ValueBuiltin BuiltinKind = "value"
+)
+
+// BuiltinInfo is machine-readable metadata about a builtin value.
+type BuiltinInfo struct {
+	Package    string          `json:"package"`
+	Name       string          `json:"name"`
+	Kind       BuiltinKind     `json:"kind"`
+	Type       string          `json:"type"`
+	Parameters []ParameterInfo `json:"parameters,omitempty"`
+
+	// OperationKind is the kind of the operation registered
+	// for the builtin, if there is one.
+	OperationKind string `json:"operationKind,omitempty"`
+	// PlannerRules are the names of the planner rules that may
+	// rewrite the operation, such as rules that push it down
+	// into a source.
+	PlannerRules []string `json:"plannerRules,omitempty"`
+	// Deprecated is the version the builtin was deprecated in.
+	Deprecated string `json:"deprecated,omitempty"`
+}
+
+// ParameterInfo describes a parameter of a builtin function.
+type ParameterInfo struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Optional bool   `json:"optional,omitempty"`
+	Pipe     bool   `json:"pipe,omitempty"`
+}
+
+// ListBuiltins returns metadata about each registered builtin value
+// sorted by package and name.
+func (r *runtime) ListBuiltins() ([]BuiltinInfo, error) {
+	var infos []BuiltinInfo
+	for pkgpath, pkg := range r.builtins {
+		for name, v := range pkg {
+			info, err := newBuiltinInfo(pkgpath, name, v)
+			if err != nil {
+				return nil, err
+			}
+			info.Deprecated = r.deprecated[pkgpath][name]
+			infos = append(infos, info)
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Package != infos[j].Package {
+			return infos[i].Package < infos[j].Package
+		}
+		return infos[i].Name < infos[j].Name
+	})
+	return infos, nil
+}
+
+func newBuiltinInfo(pkgpath, name string, v values.Value) (BuiltinInfo, error) {
+	typ := v.Type()
+	info := BuiltinInfo{
+		Package: pkgpath,
+		Name:    name,
+		Kind:    ValueBuiltin,
+		Type:    typ.String(),
+	}
+	if typ.Nature() != semantic.Function {
+		return info, nil
+	}
+
+	n, err := typ.NumArguments()
+	if err != nil {
+		return BuiltinInfo{}, err
+	}
+	consumesStream := false
+	for i := 0; i < n; i++ {
+		arg, err := typ.Argument(i)
+		if err != nil {
+			return BuiltinInfo{}, err
+		}
+		argType, err := arg.TypeOf()
+		if err != nil {
+			return BuiltinInfo{}, err
+		}
+		if arg.Pipe() || argType.Nature() == semantic.Stream {
+			consumesStream = true
+		}
+		info.Parameters = append(info.Parameters, ParameterInfo{
+			Name:     string(arg.Name()),
+			Type:     argType.String(),
+			Optional: arg.Optional(),
+			Pipe:     arg.Pipe(),
+		})
+	}
+
+	retType, err := typ.ReturnType()
+	if err != nil {
+		return BuiltinInfo{}, err
+	}
+	fn, _ := v.(values.Function)
+	switch {
+	case retType.Nature() != semantic.Stream:
+		info.Kind = FunctionBuiltin
+		return info, nil
+	case fn != nil && fn.HasSideEffect():
+		info.Kind = SinkBuiltin
+	case consumesStream:
+		info.Kind = TransformationBuiltin
+	default:
+		info.Kind = SourceBuiltin
+	}
+
+	// Operations are conventionally registered under the name
+	// of the builtin, qualified by its package outside of the
+	// universe package.
+	kinds := []string{name}
+	if pkgpath != "universe" {
+		kinds = []string{pkgpath + "." + name, path.Base(pkgpath) + "." + name}
+	}
+	for _, kind := range kinds {
+		if flux.OperationSpecNewFn(flux.OperationKind(kind)) != nil {
+			info.OperationKind = kind
+			info.PlannerRules = plan.RegisteredRulesForKind(plan.ProcedureKind(kind))
+			break
+		}
+	}
+	return info, nil
+}
@@ -118,3 +118,31 @@ func Test_options(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestDeprecatePackageValue(t *testing.T) {
+	r := &runtime{}
+	if err := r.RegisterPackageValue("mypkg", "old", values.NewInt(0)); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RegisterPackageValue("mypkg", "new", values.NewInt(1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.DeprecatePackageValue("mypkg", "old", "v0.150.0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.DeprecatePackageValue("mypkg", "missing", "v0.150.0"); err == nil {
+		t.Error("expected an error when deprecating a missing value")
+	}
+
+	infos, err := r.ListBuiltins()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []BuiltinInfo{
+		{Package: "mypkg", Name: "new", Kind: ValueBuiltin, Type: "int"},
+		{Package: "mypkg", Name: "old", Kind: ValueBuiltin, Type: "int", Deprecated: "v0.150.0"},
+	}
+	if !cmp.Equal(want, infos) {
+		t.Errorf("unexpected builtins -want/+got:\n%s", cmp.Diff(want, infos))
+	}
+}
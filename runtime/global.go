@@ -25,6 +25,19 @@ func ReplacePackageValue(pkgpath, name string, value values.Value) {
 	}
 }
 
+// DeprecatePackageValue marks a registered builtin package value as
+// deprecated since the given version.
+func DeprecatePackageValue(pkgpath, name, version string) {
+	if err := Default.DeprecatePackageValue(pkgpath, name, version); err != nil {
+		panic(err)
+	}
+}
+
+// ListBuiltins returns metadata about each registered builtin value.
+func ListBuiltins() ([]BuiltinInfo, error) {
+	return Default.ListBuiltins()
+}
+
 // StdLib returns an importer for the Flux standard library.
 func StdLib() interpreter.Importer {
 	return Default.Stdlib()
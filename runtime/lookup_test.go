@@ -40,7 +40,7 @@ func TestLookupComplexTypes(t *testing.T) {
 			path: "csv",
 			id:   "from",
 			name: "lookup csv.from",
-			want: "(?csv: string, ?file: string, ?mode: string) => stream[A]",
+			want: "(?csv: string, ?failOnError: bool, ?file: string, ?mode: string, ?nullValues: [string], ?trimSpace: bool) => stream[A]",
 		},
 		{
 			path: "date",
@@ -24,9 +24,10 @@ var Default = &runtime{}
 // runtime contains the flux runtime for interpreting and
 // executing queries.
 type runtime struct {
-	pkgs      map[string]*semantic.Package
-	builtins  map[string]map[string]values.Value
-	finalized bool
+	pkgs       map[string]*semantic.Package
+	builtins   map[string]map[string]values.Value
+	deprecated map[string]map[string]string
+	finalized  bool
 }
 
 func (r *runtime) Parse(flux string) (flux.ASTHandle, error) {
@@ -86,6 +87,26 @@ func (r *runtime) registerPackageValue(pkgpath, name string, value values.Value,
 	return nil
 }
 
+func (r *runtime) DeprecatePackageValue(pkgpath, name, version string) error {
+	if r.finalized {
+		return errors.Newf(codes.Internal, "already finalized, cannot deprecate builtin package value")
+	}
+	if _, ok := r.builtins[pkgpath][name]; !ok {
+		return errors.Newf(codes.Internal, "missing builtin package value %q %q", pkgpath, name)
+	}
+
+	if r.deprecated == nil {
+		r.deprecated = make(map[string]map[string]string)
+	}
+	pkg, ok := r.deprecated[pkgpath]
+	if !ok {
+		pkg = make(map[string]string)
+		r.deprecated[pkgpath] = pkg
+	}
+	pkg[name] = version
+	return nil
+}
+
 func (r *runtime) Prelude() values.Scope {
 	if !r.finalized {
 		panic("builtins not finalized")
@@ -166,6 +187,9 @@ func (r *runtime) Stdlib() interpreter.Importer {
 	return &importer{r: r}
 }
 
+// compilePackages loads the semantic graphs of the standard library.
+// The packages are compiled when flux is built and embedded as
+// flatbuffers, so loading them only deserializes the embedded data.
 func (r *runtime) compilePackages() error {
 	pkgs := make(map[string]*semantic.Package)
 	if err := fs.WalkDir(embed.FS, "stdlib", func(path string, d fs.DirEntry, err error) error {
@@ -2,10 +2,12 @@ package runtime_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/influxdata/flux/dependencies/dependenciestest"
 	"github.com/influxdata/flux/dependency"
 	_ "github.com/influxdata/flux/fluxinit/static"
@@ -114,3 +116,57 @@ func Example_overrideDefaultOptionExternally() {
 	fmt.Printf("The new current time (UTC) is: %v", now)
 	// Output: The new current time (UTC) is: 2018-07-13T00:00:00.000000000Z
 }
+
+func TestListBuiltins(t *testing.T) {
+	infos, err := runtime.ListBuiltins()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The number of builtins only changes when a builtin is added
+	// or removed. Update this when doing so intentionally.
+	if want, got := 375, len(infos); want != got {
+		t.Errorf("unexpected number of builtins -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+
+	var join *runtime.BuiltinInfo
+	for i := range infos {
+		if infos[i].Package == "universe" && infos[i].Name == "join" {
+			join = &infos[i]
+			break
+		}
+	}
+	if join == nil {
+		t.Fatal("universe.join is missing from the list of builtins")
+	}
+	if want, got := runtime.TransformationBuiltin, join.Kind; want != got {
+		t.Errorf("unexpected kind for universe.join -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+	if want, got := "join", join.OperationKind; want != got {
+		t.Errorf("unexpected operation kind for universe.join -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+	params := make(map[string]runtime.ParameterInfo)
+	for _, p := range join.Parameters {
+		params[p.Name] = p
+	}
+	for _, name := range []string{"tables", "on", "method"} {
+		if _, ok := params[name]; !ok {
+			t.Errorf("universe.join is missing parameter %q", name)
+		}
+	}
+	if !params["tables"].Pipe {
+		t.Error("expected the tables parameter of universe.join to be the pipe parameter")
+	}
+
+	data, err := json.Marshal(infos)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []runtime.BuiltinInfo
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(infos, got) {
+		t.Errorf("unexpected builtins after json round trip -want/+got:\n%s", cmp.Diff(infos, got))
+	}
+}
@@ -0,0 +1,107 @@
+package bytes
+
+import (
+	"context"
+	"encoding/hex"
+
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/interpreter"
+	"github.com/influxdata/flux/runtime"
+	"github.com/influxdata/flux/semantic"
+	"github.com/influxdata/flux/values"
+)
+
+const pkgName = "bytes"
+
+// getBytes reads the bytes argument v. The returned value is
+// false when v is null so the caller can return a null value.
+func getBytes(args interpreter.Arguments) ([]byte, bool, error) {
+	v, err := args.GetRequired("v")
+	if err != nil {
+		return nil, false, err
+	} else if v.IsNull() {
+		return nil, false, nil
+	} else if v.Type().Nature() != semantic.Bytes {
+		return nil, false, errors.Newf(codes.Invalid, "keyword argument %q should be of kind %v, but got %v", "v", semantic.Bytes, v.Type().Nature())
+	}
+	return v.Bytes(), true, nil
+}
+
+var length = values.NewFunction(
+	"length",
+	runtime.MustLookupBuiltinType(pkgName, "length"),
+	func(ctx context.Context, args values.Object) (values.Value, error) {
+		return interpreter.DoFunctionCallContext(func(ctx context.Context, args interpreter.Arguments) (values.Value, error) {
+			v, ok, err := getBytes(args)
+			if err != nil {
+				return nil, err
+			} else if !ok {
+				return values.NewNull(semantic.BasicInt), nil
+			}
+			return values.NewInt(int64(len(v))), nil
+		}, ctx, args)
+	}, false,
+)
+
+var slice = values.NewFunction(
+	"slice",
+	runtime.MustLookupBuiltinType(pkgName, "slice"),
+	func(ctx context.Context, args values.Object) (values.Value, error) {
+		return interpreter.DoFunctionCallContext(func(ctx context.Context, args interpreter.Arguments) (values.Value, error) {
+			v, ok, err := getBytes(args)
+			if err != nil {
+				return nil, err
+			}
+			start, err := args.GetRequiredInt("start")
+			if err != nil {
+				return nil, err
+			}
+			end, err := args.GetRequiredInt("end")
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				return values.NewNull(semantic.BasicBytes), nil
+			}
+
+			if start < 0 {
+				start = 0
+			} else if start > int64(len(v)) {
+				start = int64(len(v))
+			}
+			if end > int64(len(v)) {
+				end = int64(len(v))
+			} else if end < start {
+				end = start
+			}
+			// Copy the subsequence so the result does not
+			// share memory with the input value.
+			b := make([]byte, end-start)
+			copy(b, v[start:end])
+			return values.NewBytes(b), nil
+		}, ctx, args)
+	}, false,
+)
+
+var toHexString = values.NewFunction(
+	"toHexString",
+	runtime.MustLookupBuiltinType(pkgName, "toHexString"),
+	func(ctx context.Context, args values.Object) (values.Value, error) {
+		return interpreter.DoFunctionCallContext(func(ctx context.Context, args interpreter.Arguments) (values.Value, error) {
+			v, ok, err := getBytes(args)
+			if err != nil {
+				return nil, err
+			} else if !ok {
+				return values.NewNull(semantic.BasicString), nil
+			}
+			return values.NewString(hex.EncodeToString(v)), nil
+		}, ctx, args)
+	}, false,
+)
+
+func init() {
+	runtime.RegisterPackageValue(pkgName, "length", length)
+	runtime.RegisterPackageValue(pkgName, "slice", slice)
+	runtime.RegisterPackageValue(pkgName, "toHexString", toHexString)
+}
@@ -0,0 +1,94 @@
+package bytes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/influxdata/flux/values"
+)
+
+func call(fn values.Function, args map[string]values.Value) (values.Value, error) {
+	return fn.Call(context.Background(), values.NewObjectWithValues(args))
+}
+
+func TestLength(t *testing.T) {
+	got, err := call(length, map[string]values.Value{
+		"v": values.NewBytes([]byte{0xde, 0xad, 0xbe, 0xef}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := int64(4); got.Int() != want {
+		t.Fatalf("unexpected length -want/+got:\n\t- %d\n\t+ %d", want, got.Int())
+	}
+}
+
+func TestSlice(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		start, end int64
+		want       []byte
+	}{
+		{name: "middle", start: 1, end: 3, want: []byte{0xad, 0xbe}},
+		{name: "start before bounds", start: -1, end: 2, want: []byte{0xde, 0xad}},
+		{name: "end past bounds", start: 2, end: 10, want: []byte{0xbe, 0xef}},
+		{name: "end before start", start: 3, end: 1, want: []byte{}},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := call(slice, map[string]values.Value{
+				"v":     values.NewBytes([]byte{0xde, 0xad, 0xbe, 0xef}),
+				"start": values.NewInt(tc.start),
+				"end":   values.NewInt(tc.end),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !cmp.Equal(tc.want, got.Bytes()) {
+				t.Fatalf("unexpected slice -want/+got:\n%s", cmp.Diff(tc.want, got.Bytes()))
+			}
+		})
+	}
+}
+
+func TestToHexString(t *testing.T) {
+	got, err := call(toHexString, map[string]values.Value{
+		"v": values.NewBytes([]byte{0xde, 0xad, 0xbe, 0xef}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "deadbeef"; got.Str() != want {
+		t.Fatalf("unexpected string -want/+got:\n\t- %s\n\t+ %s", want, got.Str())
+	}
+}
+
+func TestNull(t *testing.T) {
+	for name, fn := range map[string]values.Function{
+		"length":      length,
+		"toHexString": toHexString,
+	} {
+		got, err := call(fn, map[string]values.Value{
+			"v": values.Null,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !got.IsNull() {
+			t.Errorf("%s: expected null, got %v", name, got)
+		}
+	}
+}
+
+func TestInvalidType(t *testing.T) {
+	_, err := call(length, map[string]values.Value{
+		"v": values.NewString("abc"),
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if want, got := `keyword argument "v" should be of kind bytes, but got string`, err.Error(); got != want {
+		t.Fatalf("unexpected error -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+}
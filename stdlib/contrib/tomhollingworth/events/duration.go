@@ -1,6 +1,7 @@
 package events
 
 import (
+	"sync"
 	"time"
 
 	"github.com/influxdata/flux"
@@ -16,13 +17,35 @@ const pkgPath = "contrib/tomhollingworth/events"
 
 const DurationKind = "duration"
 
+// The methods of computing the durations of events.
+const (
+	// methodNext computes the time between a row and the next row.
+	methodNext = "next"
+	// methodInterval computes the time between the start
+	// and the stop time of each row.
+	methodInterval = "interval"
+)
+
+// The formats of the duration column.
+const (
+	// formatNumber writes the durations as a number of units.
+	formatNumber = "number"
+	// formatString writes the durations as duration strings, such as "1m30s".
+	formatString = "string"
+)
+
 type DurationOpSpec struct {
-	Unit       flux.Duration `json:"unit"`
-	TimeColumn string        `json:"timeColumn"`
-	ColumnName string        `json:"columnName"`
-	StopColumn string        `json:"stopColumn"`
-	Stop       flux.Time     `json:"stop"`
-	IsStop     bool
+	Unit        flux.Duration `json:"unit"`
+	TimeColumn  string        `json:"timeColumn"`
+	ColumnName  string        `json:"columnName"`
+	StopColumn  string        `json:"stopColumn"`
+	Stop        flux.Time     `json:"stop"`
+	IsStop      bool
+	AsFloat     bool   `json:"asFloat"`
+	Overwrite   bool   `json:"overwrite"`
+	Method      string `json:"method"`
+	StartColumn string `json:"startColumn"`
+	Format      string `json:"format"`
 }
 
 func init() {
@@ -40,10 +63,12 @@ func createDurationOpSpec(args flux.Arguments, a *flux.Administration) (flux.Ope
 
 	spec := new(DurationOpSpec)
 
+	unitSet := false
 	if unit, ok, err := args.GetDuration("unit"); err != nil {
 		return nil, err
 	} else if ok {
 		spec.Unit = unit
+		unitSet = true
 	} else {
 		spec.Unit = flux.ConvertDuration(time.Second)
 	}
@@ -82,6 +107,74 @@ func createDurationOpSpec(args flux.Arguments, a *flux.Administration) (flux.Ope
 		spec.Stop = flux.Now
 	}
 
+	if asFloat, ok, err := args.GetBool("asFloat"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.AsFloat = asFloat
+	}
+
+	if overwrite, ok, err := args.GetBool("overwrite"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.Overwrite = overwrite
+	}
+
+	if method, ok, err := args.GetString("method"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.Method = method
+	} else {
+		spec.Method = methodNext
+	}
+
+	if startCol, ok, err := args.GetString("startColumn"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.StartColumn = startCol
+	} else {
+		spec.StartColumn = execute.DefaultStartColLabel
+	}
+
+	if format, ok, err := args.GetString("format"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.Format = format
+	} else {
+		spec.Format = formatNumber
+	}
+
+	// A duration string keeps every unit of the duration,
+	// so the options that convert it to a number are rejected.
+	switch spec.Format {
+	case formatNumber:
+	case formatString:
+		if unitSet {
+			return nil, errors.Newf(codes.Invalid, "unit cannot be used with format %q", formatString)
+		} else if spec.AsFloat {
+			return nil, errors.Newf(codes.Invalid, "asFloat cannot be used with format %q", formatString)
+		}
+	default:
+		return nil, errors.Newf(codes.Invalid, "unknown format %q, expected %q or %q", spec.Format, formatNumber, formatString)
+	}
+
+	// The columns that the method reads exist in every table
+	// that is not empty, so a collision with them is found here.
+	var collides bool
+	switch spec.Method {
+	case methodNext:
+		collides = spec.ColumnName == spec.TimeColumn || (!spec.IsStop && spec.ColumnName == spec.StopColumn)
+	case methodInterval:
+		if spec.IsStop {
+			return nil, errors.Newf(codes.Invalid, "stop cannot be used with method %q", methodInterval)
+		}
+		collides = spec.ColumnName == spec.StartColumn || spec.ColumnName == spec.StopColumn
+	default:
+		return nil, errors.Newf(codes.Invalid, "unknown method %q, expected %q or %q", spec.Method, methodNext, methodInterval)
+	}
+	if collides && !spec.Overwrite {
+		return nil, errors.Newf(codes.Invalid, "column %q already exists; set overwrite to replace it", spec.ColumnName)
+	}
+
 	return spec, nil
 }
 
@@ -95,12 +188,17 @@ func (s *DurationOpSpec) Kind() flux.OperationKind {
 
 type DurationProcedureSpec struct {
 	plan.DefaultCost
-	Unit       flux.Duration `json:"unit"`
-	TimeColumn string        `json:"timeColumn"`
-	ColumnName string        `json:"columnName"`
-	StopColumn string        `json:"stopColumn"`
-	Stop       flux.Time     `json:"stop"`
-	IsStop     bool
+	Unit        flux.Duration `json:"unit"`
+	TimeColumn  string        `json:"timeColumn"`
+	ColumnName  string        `json:"columnName"`
+	StopColumn  string        `json:"stopColumn"`
+	Stop        flux.Time     `json:"stop"`
+	IsStop      bool
+	AsFloat     bool   `json:"asFloat"`
+	Overwrite   bool   `json:"overwrite"`
+	Method      string `json:"method"`
+	StartColumn string `json:"startColumn"`
+	Format      string `json:"format"`
 }
 
 func newDurationProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.ProcedureSpec, error) {
@@ -110,12 +208,17 @@ func newDurationProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.P
 	}
 
 	return &DurationProcedureSpec{
-		Unit:       spec.Unit,
-		TimeColumn: spec.TimeColumn,
-		ColumnName: spec.ColumnName,
-		StopColumn: spec.StopColumn,
-		Stop:       spec.Stop,
-		IsStop:     spec.IsStop,
+		Unit:        spec.Unit,
+		TimeColumn:  spec.TimeColumn,
+		ColumnName:  spec.ColumnName,
+		StopColumn:  spec.StopColumn,
+		Stop:        spec.Stop,
+		IsStop:      spec.IsStop,
+		AsFloat:     spec.AsFloat,
+		Overwrite:   spec.Overwrite,
+		Method:      spec.Method,
+		StartColumn: spec.StartColumn,
+		Format:      spec.Format,
 	}, nil
 }
 
@@ -125,15 +228,28 @@ func (s *DurationProcedureSpec) Kind() plan.ProcedureKind {
 
 func (s *DurationProcedureSpec) Copy() plan.ProcedureSpec {
 	return &DurationProcedureSpec{
-		Unit:       s.Unit,
-		TimeColumn: s.TimeColumn,
-		ColumnName: s.ColumnName,
-		StopColumn: s.StopColumn,
-		Stop:       s.Stop,
-		IsStop:     s.IsStop,
+		Unit:        s.Unit,
+		TimeColumn:  s.TimeColumn,
+		ColumnName:  s.ColumnName,
+		StopColumn:  s.StopColumn,
+		Stop:        s.Stop,
+		IsStop:      s.IsStop,
+		AsFloat:     s.AsFloat,
+		Overwrite:   s.Overwrite,
+		Method:      s.Method,
+		StartColumn: s.StartColumn,
+		Format:      s.Format,
 	}
 }
 
+// Validate checks that the unit of the durations is not zero.
+func (s *DurationProcedureSpec) Validate() error {
+	if values.Duration(s.Unit).IsZero() {
+		return errors.New(codes.Invalid, "unit must not be zero")
+	}
+	return nil
+}
+
 func createDurationTransformation(id execute.DatasetID, mode execute.AccumulationMode, spec plan.ProcedureSpec, a execute.Administration) (execute.Transformation, execute.Dataset, error) {
 	s, ok := spec.(*DurationProcedureSpec)
 	if !ok {
@@ -142,12 +258,18 @@ func createDurationTransformation(id execute.DatasetID, mode execute.Accumulatio
 	cache := execute.NewTableBuilderCache(a.Allocator())
 	d := execute.NewDataset(id, mode, cache)
 	t := NewDurationTransformation(d, cache, s)
+	t.concurrency = execute.TableConcurrency(a.Context())
 	return t, d, nil
 }
 
+// durationTransformation computes the durations of each table on its own,
+// so the tables may be processed concurrently when the execution options
+// allow it. The builders for the tables are created under mu since the
+// cache is shared between the tables.
 type durationTransformation struct {
 	execute.ExecutionNode
 	d     execute.Dataset
+	mu    sync.Mutex
 	cache execute.TableBuilderCache
 
 	unit       float64
@@ -156,6 +278,18 @@ type durationTransformation struct {
 	stopColumn string
 	stop       values.Time
 	isStop     bool
+	asFloat    bool
+	overwrite  bool
+	// interval is set when the durations are computed
+	// between the start and the stop time of each row.
+	interval    bool
+	startColumn string
+	// asString is set when the durations are written as duration
+	// strings. The unit and asFloat are ignored.
+	asString bool
+	// concurrency is the number of tables that may be
+	// processed at the same time.
+	concurrency int
 }
 
 func NewDurationTransformation(d execute.Dataset, cache execute.TableBuilderCache, spec *DurationProcedureSpec) *durationTransformation {
@@ -169,9 +303,39 @@ func NewDurationTransformation(d execute.Dataset, cache execute.TableBuilderCach
 		stopColumn: spec.StopColumn,
 		stop:       values.ConvertTime(spec.Stop.Absolute),
 		isStop:     spec.IsStop,
+		asFloat:    spec.AsFloat,
+		overwrite:  spec.Overwrite,
+
+		interval:    spec.Method == methodInterval,
+		startColumn: spec.StartColumn,
+		asString:    spec.Format == formatString,
 	}
 }
 
+// durationType is the type of the duration column.
+func (t *durationTransformation) durationType() flux.ColType {
+	if t.asString {
+		return flux.TString
+	} else if t.asFloat {
+		return flux.TFloat
+	}
+	return flux.TInt
+}
+
+// appendDuration appends the duration between two times in the unit.
+// An integer duration is truncated to a whole number of units.
+// A duration string has every unit of the duration.
+func (t *durationTransformation) appendDuration(builder execute.TableBuilder, j int, start, stop int64) error {
+	if t.asString {
+		return builder.AppendString(j, values.ConvertDurationNsecs(time.Duration(stop-start)).String())
+	}
+	d := (float64(stop) - float64(start)) / t.unit
+	if t.asFloat {
+		return builder.AppendFloat(j, d)
+	}
+	return builder.AppendInt(j, int64(d))
+}
+
 func (t *durationTransformation) RetractTable(id execute.DatasetID, key flux.GroupKey) error {
 	return t.d.RetractTable(key)
 }
@@ -188,17 +352,62 @@ func (t *durationTransformation) Finish(id execute.DatasetID, err error) {
 	t.d.Finish(err)
 }
 
+// Concurrency implements execute.ParallelTransformation.
+func (t *durationTransformation) Concurrency() int {
+	return t.concurrency
+}
+
 func (t *durationTransformation) Process(id execute.DatasetID, tbl flux.Table) error {
+	t.mu.Lock()
 	builder, created := t.cache.TableBuilder(tbl.Key())
+	t.mu.Unlock()
 	if !created {
 		return errors.Newf(codes.FailedPrecondition, "found duplicate table with key: %v", tbl.Key())
 	}
 	cols := tbl.Cols()
 	numCol := 0
 
-	err := execute.AddTableCols(tbl, builder)
-	if err != nil {
-		return err
+	// A column with the name of the duration column is replaced
+	// by the durations when overwrite is set, so the durations
+	// keep the position of that column.
+	dupIdx := execute.ColIdx(t.columnName, cols)
+	outCols := cols
+	if dupIdx >= 0 {
+		if !t.overwrite {
+			return errors.Newf(codes.Invalid, "column %q already exists; set overwrite to replace it", t.columnName)
+		} else if tbl.Key().HasCol(t.columnName) {
+			return errors.Newf(codes.Invalid, "cannot overwrite group key column %q", t.columnName)
+		}
+		outCols = make([]flux.ColMeta, len(cols))
+		copy(outCols, cols)
+		outCols[dupIdx] = flux.ColMeta{
+			Label: t.columnName,
+			Type:  t.durationType(),
+		}
+	}
+	for _, c := range outCols {
+		if _, err := builder.AddCol(c); err != nil {
+			return err
+		}
+	}
+
+	if t.interval {
+		return t.processInterval(tbl, builder, dupIdx)
+	}
+
+	// An empty table has no durations, so its output
+	// is empty even when the columns are missing.
+	if tbl.Empty() {
+		if idx := execute.ColIdx(t.timeColumn, cols); dupIdx < 0 && idx >= 0 && cols[idx].Type == flux.TTime {
+			if _, err := builder.AddCol(flux.ColMeta{
+				Label: t.columnName,
+				Type:  t.durationType(),
+			}); err != nil {
+				return err
+			}
+		}
+		tbl.Done()
+		return nil
 	}
 
 	timeIdx := execute.ColIdx(t.timeColumn, cols)
@@ -217,47 +426,84 @@ func (t *durationTransformation) Process(id execute.DatasetID, tbl flux.Table) e
 	}
 
 	timeCol := cols[timeIdx]
-	if timeCol.Type == flux.TTime {
+	if dupIdx >= 0 {
+		numCol = dupIdx
+	} else if timeCol.Type == flux.TTime {
+		var err error
 		if numCol, err = builder.AddCol(flux.ColMeta{
 			Label: t.columnName,
-			Type:  flux.TInt,
+			Type:  t.durationType(),
 		}); err != nil {
 			return err
 		}
 	}
 
 	colMap := execute.ColMap([]int{0}, builder, tbl.Cols())
+	// The values of a replaced column are not copied.
+	if dupIdx >= 0 {
+		colMap[dupIdx] = -1
+	}
 
 	var (
 		cTime      int64
 		cTimeValid bool
 		sTime      int64
+		sTimeValid bool
+		// nulls is the number of rows with a null time after the
+		// current time. Their null durations are appended after
+		// the duration of the current time is known.
+		nulls int
 	)
 
 	// If we have specified a stop value, record it here.
 	if t.isStop {
-		sTime = int64(t.stop)
+		sTime, sTimeValid = int64(t.stop), true
+	}
+
+	appendNulls := func() error {
+		for ; nulls > 0; nulls-- {
+			if err := builder.AppendNil(numCol); err != nil {
+				return err
+			}
+		}
+		return nil
 	}
 
 	if err := tbl.Do(func(cr flux.ColReader) error {
 		l := cr.Len()
+		if l == 0 {
+			// An empty buffer has neither times nor a stop time.
+			return nil
+		}
 
 		ts := cr.Times(timeIdx)
 		for i := 0; i < l; i++ {
-			// Read the current time value. If we have a current time to compare
-			// it to, then append the difference between them.
-			//
-			// This section will always append the previous row. During the first
-			// invocation of this section, it is skipped.
-			nTime := ts.Value(i)
-			if cTimeValid {
-				currentTime := float64(cTime)
-				nextTime := float64(nTime)
-				if err := builder.AppendInt(numCol, int64((nextTime-currentTime)/t.unit)); err != nil {
+			// A row with a null time has a null duration. It does not
+			// change the current time, so the duration of the current
+			// time is computed from the next valid time.
+			if ts.IsNull(i) {
+				if cTimeValid {
+					nulls++
+				} else if err := builder.AppendNil(numCol); err != nil {
 					return err
 				}
+			} else {
+				// Read the current time value. If we have a current time to compare
+				// it to, then append the difference between them.
+				//
+				// This section will always append the previous row. During the first
+				// invocation of this section, it is skipped.
+				nTime := ts.Value(i)
+				if cTimeValid {
+					if err := t.appendDuration(builder, numCol, cTime, nTime); err != nil {
+						return err
+					}
+					if err := appendNulls(); err != nil {
+						return err
+					}
+				}
+				cTime, cTimeValid = nTime, true
 			}
-			cTime, cTimeValid = nTime, true
 
 			// Append the existing columns. We always append the currently
 			// processed row except for the duration between the two.
@@ -269,14 +515,19 @@ func (t *durationTransformation) Process(id execute.DatasetID, tbl flux.Table) e
 			}
 		}
 
-		// If no stop timestamp is provided, get last value in stopColumn.
+		// If no stop timestamp is provided, get the last valid value in stopColumn.
 		// We just record this as the actual append happens outside this loop.
 		// We do not know if this is the final buffer until we have already
 		// finished reading the buffers so we just record this in case it is the
-		// proper value.
+		// proper value. A null stop time falls back to the previous valid one.
 		if !t.isStop {
 			stopTimes := cr.Times(stopIdx)
-			sTime = stopTimes.Value(l - 1)
+			for i := l - 1; i >= 0; i-- {
+				if stopTimes.IsValid(i) {
+					sTime, sTimeValid = stopTimes.Value(i), true
+					break
+				}
+			}
 		}
 		return nil
 	}); err != nil {
@@ -284,13 +535,91 @@ func (t *durationTransformation) Process(id execute.DatasetID, tbl flux.Table) e
 	}
 
 	// If there was at least one valid time, append the difference between
-	// the last time and the stop time.
+	// the last time and the stop time. The duration is null when there is
+	// no valid stop time.
 	if cTimeValid {
-		currentTime := float64(cTime)
-		nextTime := float64(sTime)
-		if err := builder.AppendInt(numCol, int64((nextTime-currentTime)/t.unit)); err != nil {
+		if sTimeValid {
+			if err := t.appendDuration(builder, numCol, cTime, sTime); err != nil {
+				return err
+			}
+		} else if err := builder.AppendNil(numCol); err != nil {
+			return err
+		}
+		if err := appendNulls(); err != nil {
 			return err
 		}
 	}
 	return nil
 }
+
+// processInterval appends the duration between the start and the stop
+// time of each row. A row with a null start or stop time has a null
+// duration, and a stop time before the start time gives a negative one.
+func (t *durationTransformation) processInterval(tbl flux.Table, builder execute.TableBuilder, dupIdx int) error {
+	cols := tbl.Cols()
+	startIdx := execute.ColIdx(t.startColumn, cols)
+	stopIdx := execute.ColIdx(t.stopColumn, cols)
+
+	// An empty table has no durations, so its output
+	// is empty even when the columns are missing.
+	if tbl.Empty() {
+		if dupIdx < 0 && startIdx >= 0 && stopIdx >= 0 && cols[startIdx].Type == flux.TTime && cols[stopIdx].Type == flux.TTime {
+			if _, err := builder.AddCol(flux.ColMeta{
+				Label: t.columnName,
+				Type:  t.durationType(),
+			}); err != nil {
+				return err
+			}
+		}
+		tbl.Done()
+		return nil
+	}
+
+	for _, col := range []struct {
+		label string
+		idx   int
+	}{
+		{label: t.startColumn, idx: startIdx},
+		{label: t.stopColumn, idx: stopIdx},
+	} {
+		if col.idx < 0 {
+			return errors.Newf(codes.FailedPrecondition, "column %q does not exist", col.label)
+		} else if c := cols[col.idx]; c.Type != flux.TTime {
+			return errors.Newf(codes.FailedPrecondition, "column %q must be of type %s, got %s", c.Label, flux.TTime, c.Type)
+		}
+	}
+
+	numCol := dupIdx
+	if dupIdx < 0 {
+		var err error
+		if numCol, err = builder.AddCol(flux.ColMeta{
+			Label: t.columnName,
+			Type:  t.durationType(),
+		}); err != nil {
+			return err
+		}
+	}
+
+	colMap := execute.ColMap([]int{0}, builder, cols)
+	// The values of a replaced column are not copied.
+	if dupIdx >= 0 {
+		colMap[dupIdx] = -1
+	}
+
+	return tbl.Do(func(cr flux.ColReader) error {
+		starts, stops := cr.Times(startIdx), cr.Times(stopIdx)
+		for i, l := 0, cr.Len(); i < l; i++ {
+			if starts.IsValid(i) && stops.IsValid(i) {
+				if err := t.appendDuration(builder, numCol, starts.Value(i), stops.Value(i)); err != nil {
+					return err
+				}
+			} else if err := builder.AppendNil(numCol); err != nil {
+				return err
+			}
+			if err := execute.AppendMappedRecordExplicit(i, cr, builder, colMap); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
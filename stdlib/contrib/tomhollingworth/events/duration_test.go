@@ -1,19 +1,34 @@
 package events_test
 
 import (
+	"context"
+	"fmt"
+	"math"
+	goruntime "runtime"
 	"testing"
 	"time"
 
 	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/dependency"
 	"github.com/influxdata/flux/execute"
 	"github.com/influxdata/flux/execute/executetest"
 	_ "github.com/influxdata/flux/fluxinit/static" // We need to init flux for the tests to work.
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/plan"
+	"github.com/influxdata/flux/plan/plantest"
 	"github.com/influxdata/flux/querytest"
 	"github.com/influxdata/flux/stdlib/contrib/tomhollingworth/events"
 	"github.com/influxdata/flux/stdlib/influxdata/influxdb"
 	"github.com/influxdata/flux/stdlib/universe"
+	"github.com/influxdata/flux/values"
+	"go.uber.org/zap/zaptest"
 )
 
+func init() {
+	execute.RegisterSource(executetest.FromTestKind, executetest.CreateFromSource)
+}
+
 func TestDuration_NewQuery(t *testing.T) {
 	tests := []querytest.NewQueryTestCase{
 		{
@@ -26,6 +41,41 @@ func TestDuration_NewQuery(t *testing.T) {
 			Raw:     `import "contrib/tomhollingworth/events" from(bucket:"mydb") |> range(start:-1h) |> drop(columns: ["_time"]  |> events.duration()`,
 			WantErr: true,
 		},
+		{
+			Name:    "duration columnName collides with time column",
+			Raw:     `import "contrib/tomhollingworth/events" from(bucket:"mydb") |> range(start:-1h) |> events.duration(columnName: "_time")`,
+			WantErr: true,
+		},
+		{
+			Name:    "duration unknown method",
+			Raw:     `import "contrib/tomhollingworth/events" from(bucket:"mydb") |> range(start:-1h) |> events.duration(method: "previous")`,
+			WantErr: true,
+		},
+		{
+			Name:    "duration interval with stop",
+			Raw:     `import "contrib/tomhollingworth/events" from(bucket:"mydb") |> range(start:-1h) |> events.duration(method: "interval", stop: 2020-10-20T08:30:00Z)`,
+			WantErr: true,
+		},
+		{
+			Name:    "duration interval columnName collides with start column",
+			Raw:     `import "contrib/tomhollingworth/events" from(bucket:"mydb") |> range(start:-1h) |> events.duration(method: "interval", columnName: "_start")`,
+			WantErr: true,
+		},
+		{
+			Name:    "duration unknown format",
+			Raw:     `import "contrib/tomhollingworth/events" from(bucket:"mydb") |> range(start:-1h) |> events.duration(format: "hex")`,
+			WantErr: true,
+		},
+		{
+			Name:    "duration string format with unit",
+			Raw:     `import "contrib/tomhollingworth/events" from(bucket:"mydb") |> range(start:-1h) |> events.duration(format: "string", unit: 1m)`,
+			WantErr: true,
+		},
+		{
+			Name:    "duration string format with asFloat",
+			Raw:     `import "contrib/tomhollingworth/events" from(bucket:"mydb") |> range(start:-1h) |> events.duration(format: "string", asFloat: true)`,
+			WantErr: true,
+		},
 		{
 			Name:    "duration default",
 			Raw:     `import "contrib/tomhollingworth/events" from(bucket:"mydb") |> range(start:-1h)  |> events.duration()`,
@@ -54,12 +104,15 @@ func TestDuration_NewQuery(t *testing.T) {
 					{
 						ID: "duration2",
 						Spec: &events.DurationOpSpec{
-							Unit:       flux.ConvertDuration(time.Second),
-							TimeColumn: "_time",
-							ColumnName: "duration",
-							StopColumn: "_stop",
-							Stop:       flux.Now,
-							IsStop:     false,
+							Unit:        flux.ConvertDuration(time.Second),
+							TimeColumn:  "_time",
+							ColumnName:  "duration",
+							StopColumn:  "_stop",
+							Stop:        flux.Now,
+							IsStop:      false,
+							Method:      "next",
+							StartColumn: "_start",
+							Format:      "number",
 						},
 					},
 				},
@@ -97,12 +150,15 @@ func TestDuration_NewQuery(t *testing.T) {
 					{
 						ID: "duration2",
 						Spec: &events.DurationOpSpec{
-							Unit:       flux.ConvertDuration(time.Millisecond),
-							TimeColumn: "start",
-							ColumnName: "result",
-							StopColumn: "end",
-							Stop:       flux.Now,
-							IsStop:     false,
+							Unit:        flux.ConvertDuration(time.Millisecond),
+							TimeColumn:  "start",
+							ColumnName:  "result",
+							StopColumn:  "end",
+							Stop:        flux.Now,
+							IsStop:      false,
+							Method:      "next",
+							StartColumn: "_start",
+							Format:      "number",
 						},
 					},
 				},
@@ -147,7 +203,100 @@ func TestDuration_NewQuery(t *testing.T) {
 							Stop: flux.Time{
 								Absolute: time.Date(2020, 10, 20, 8, 30, 0, 0, time.UTC),
 							},
-							IsStop: true,
+							IsStop:      true,
+							Method:      "next",
+							StartColumn: "_start",
+							Format:      "number",
+						},
+					},
+				},
+				Edges: []flux.Edge{
+					{Parent: "from0", Child: "range1"},
+					{Parent: "range1", Child: "duration2"},
+				},
+			},
+		},
+		{
+			Name:    "duration as float",
+			Raw:     `import "contrib/tomhollingworth/events" from(bucket:"mydb") |> range(start:-1h)  |> events.duration(unit: 1m, asFloat: true)`,
+			WantErr: false,
+			Want: &flux.Spec{
+				Operations: []*flux.Operation{
+					{
+						ID: "from0",
+						Spec: &influxdb.FromOpSpec{
+							Bucket: influxdb.NameOrID{Name: "mydb"},
+						},
+					},
+					{
+						ID: "range1",
+						Spec: &universe.RangeOpSpec{
+							Start: flux.Time{
+								Relative:   -1 * time.Hour,
+								IsRelative: true,
+							},
+							Stop:        flux.Now,
+							TimeColumn:  "_time",
+							StartColumn: "_start",
+							StopColumn:  "_stop",
+						},
+					},
+					{
+						ID: "duration2",
+						Spec: &events.DurationOpSpec{
+							Unit:        flux.ConvertDuration(time.Minute),
+							TimeColumn:  "_time",
+							ColumnName:  "duration",
+							StopColumn:  "_stop",
+							Stop:        flux.Now,
+							AsFloat:     true,
+							Method:      "next",
+							StartColumn: "_start",
+							Format:      "number",
+						},
+					},
+				},
+				Edges: []flux.Edge{
+					{Parent: "from0", Child: "range1"},
+					{Parent: "range1", Child: "duration2"},
+				},
+			},
+		},
+		{
+			Name: "duration interval",
+			Raw:  `import "contrib/tomhollingworth/events" from(bucket:"mydb") |> range(start:-1h)  |> events.duration(method: "interval", startColumn: "begin", stopColumn: "end")`,
+			Want: &flux.Spec{
+				Operations: []*flux.Operation{
+					{
+						ID: "from0",
+						Spec: &influxdb.FromOpSpec{
+							Bucket: influxdb.NameOrID{Name: "mydb"},
+						},
+					},
+					{
+						ID: "range1",
+						Spec: &universe.RangeOpSpec{
+							Start: flux.Time{
+								Relative:   -1 * time.Hour,
+								IsRelative: true,
+							},
+							Stop:        flux.Now,
+							TimeColumn:  "_time",
+							StartColumn: "_start",
+							StopColumn:  "_stop",
+						},
+					},
+					{
+						ID: "duration2",
+						Spec: &events.DurationOpSpec{
+							Unit:        flux.ConvertDuration(time.Second),
+							TimeColumn:  "_time",
+							ColumnName:  "duration",
+							StopColumn:  "end",
+							Stop:        flux.Now,
+							Method:      "interval",
+							StartColumn: "begin",
+							Format:      "number",
 						},
 					},
 				},
@@ -202,7 +351,12 @@ func TestDuration_DurationProcedureSpec(t *testing.T) {
 			Relative:   time.Duration(0),
 			Absolute:   goTime,
 		},
-		IsStop: true,
+		IsStop:      true,
+		AsFloat:     true,
+		Overwrite:   true,
+		Method:      "interval",
+		StartColumn: execute.DefaultStartColLabel,
+		Format:      "string",
 	}
 
 	if s.Kind() != "duration" {
@@ -214,6 +368,29 @@ func TestDuration_DurationProcedureSpec(t *testing.T) {
 	if sCopy.Kind() != s.Kind() {
 		t.Errorf("sCopy.Kind() != %s; want %s", sCopy.Kind(), s.Kind())
 	}
+	if !sCopy.(*events.DurationProcedureSpec).AsFloat {
+		t.Error("sCopy.AsFloat = false; want true")
+	}
+	if !sCopy.(*events.DurationProcedureSpec).Overwrite {
+		t.Error("sCopy.Overwrite = false; want true")
+	}
+	if got := sCopy.(*events.DurationProcedureSpec).Method; got != "interval" {
+		t.Errorf("sCopy.Method = %q; want %q", got, "interval")
+	}
+	if got := sCopy.(*events.DurationProcedureSpec).StartColumn; got != execute.DefaultStartColLabel {
+		t.Errorf("sCopy.StartColumn = %q; want %q", got, execute.DefaultStartColLabel)
+	}
+	if got := sCopy.(*events.DurationProcedureSpec).Format; got != "string" {
+		t.Errorf("sCopy.Format = %q; want %q", got, "string")
+	}
+
+	if err := s.Validate(); err != nil {
+		t.Errorf("s.Validate() = %v; want nil", err)
+	}
+	s.Unit = flux.ConvertDuration(0)
+	if err := s.Validate(); err == nil {
+		t.Error("s.Validate() = nil; want error for zero unit")
+	}
 }
 
 func TestDuration_Process(t *testing.T) {
@@ -631,6 +808,201 @@ func TestDuration_Process(t *testing.T) {
 				},
 			}},
 		},
+		{
+			name: "float output with fractions",
+			spec: &events.DurationProcedureSpec{
+				Unit:       flux.ConvertDuration(time.Minute),
+				TimeColumn: execute.DefaultTimeColLabel,
+				ColumnName: "duration",
+				StopColumn: execute.DefaultStopColLabel,
+				AsFloat:    true,
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_stop", Type: flux.TTime},
+					{Label: "_time", Type: flux.TTime},
+				},
+				Data: [][]interface{}{
+					{values.ConvertTime(time.Unix(300, 0)), values.ConvertTime(time.Unix(0, 0))},
+					{values.ConvertTime(time.Unix(300, 0)), values.ConvertTime(time.Unix(90, 0))},
+					{values.ConvertTime(time.Unix(300, 0)), values.ConvertTime(time.Unix(105, 0))},
+				},
+			}},
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "_stop", Type: flux.TTime},
+					{Label: "_time", Type: flux.TTime},
+					{Label: "duration", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{values.ConvertTime(time.Unix(300, 0)), values.ConvertTime(time.Unix(0, 0)), 1.5},
+					{values.ConvertTime(time.Unix(300, 0)), values.ConvertTime(time.Unix(90, 0)), 0.25},
+					{values.ConvertTime(time.Unix(300, 0)), values.ConvertTime(time.Unix(105, 0)), 3.25},
+				},
+			}},
+		},
+		{
+			name: "integer output truncates fractions",
+			spec: &events.DurationProcedureSpec{
+				Unit:       flux.ConvertDuration(time.Minute),
+				TimeColumn: execute.DefaultTimeColLabel,
+				ColumnName: "duration",
+				StopColumn: execute.DefaultStopColLabel,
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_stop", Type: flux.TTime},
+					{Label: "_time", Type: flux.TTime},
+				},
+				Data: [][]interface{}{
+					{values.ConvertTime(time.Unix(300, 0)), values.ConvertTime(time.Unix(0, 0))},
+					{values.ConvertTime(time.Unix(300, 0)), values.ConvertTime(time.Unix(90, 0))},
+					{values.ConvertTime(time.Unix(300, 0)), values.ConvertTime(time.Unix(105, 0))},
+				},
+			}},
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "_stop", Type: flux.TTime},
+					{Label: "_time", Type: flux.TTime},
+					{Label: "duration", Type: flux.TInt},
+				},
+				Data: [][]interface{}{
+					{values.ConvertTime(time.Unix(300, 0)), values.ConvertTime(time.Unix(0, 0)), int64(1)},
+					{values.ConvertTime(time.Unix(300, 0)), values.ConvertTime(time.Unix(90, 0)), int64(0)},
+					{values.ConvertTime(time.Unix(300, 0)), values.ConvertTime(time.Unix(105, 0)), int64(3)},
+				},
+			}},
+		},
+		{
+			name: "float output with exact multiples and columnName",
+			spec: &events.DurationProcedureSpec{
+				Unit:       flux.ConvertDuration(time.Minute),
+				TimeColumn: execute.DefaultTimeColLabel,
+				ColumnName: "minutes",
+				StopColumn: execute.DefaultStopColLabel,
+				AsFloat:    true,
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_stop", Type: flux.TTime},
+					{Label: "_time", Type: flux.TTime},
+				},
+				Data: [][]interface{}{
+					{values.ConvertTime(time.Unix(300, 0)), values.ConvertTime(time.Unix(0, 0))},
+					{values.ConvertTime(time.Unix(300, 0)), values.ConvertTime(time.Unix(120, 0))},
+				},
+			}},
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "_stop", Type: flux.TTime},
+					{Label: "_time", Type: flux.TTime},
+					{Label: "minutes", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{values.ConvertTime(time.Unix(300, 0)), values.ConvertTime(time.Unix(0, 0)), 2.0},
+					{values.ConvertTime(time.Unix(300, 0)), values.ConvertTime(time.Unix(120, 0)), 3.0},
+				},
+			}},
+		},
+		{
+			name: "overwrite existing column",
+			spec: &events.DurationProcedureSpec{
+				Unit:       flux.ConvertDuration(time.Second),
+				TimeColumn: execute.DefaultTimeColLabel,
+				ColumnName: "_value",
+				StopColumn: execute.DefaultStopColLabel,
+				Overwrite:  true,
+			},
+			data: []flux.Table{
+				&executetest.Table{
+					KeyCols: []string{"_stop", "host"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_stop", Type: flux.TTime},
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TString},
+						{Label: "host", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{values.ConvertTime(time.Unix(60, 0)), values.ConvertTime(time.Unix(0, 0)), "ok", "a"},
+						{values.ConvertTime(time.Unix(60, 0)), values.ConvertTime(time.Unix(10, 0)), "warn", "a"},
+						{values.ConvertTime(time.Unix(60, 0)), values.ConvertTime(time.Unix(40, 0)), "ok", "a"},
+					},
+				},
+				&executetest.Table{
+					KeyCols: []string{"_stop", "host"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_stop", Type: flux.TTime},
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TString},
+						{Label: "host", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{values.ConvertTime(time.Unix(60, 0)), values.ConvertTime(time.Unix(5, 0)), "crit", "b"},
+						{values.ConvertTime(time.Unix(60, 0)), values.ConvertTime(time.Unix(50, 0)), "ok", "b"},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					KeyCols: []string{"_stop", "host"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_stop", Type: flux.TTime},
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TInt},
+						{Label: "host", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{values.ConvertTime(time.Unix(60, 0)), values.ConvertTime(time.Unix(0, 0)), int64(10), "a"},
+						{values.ConvertTime(time.Unix(60, 0)), values.ConvertTime(time.Unix(10, 0)), int64(30), "a"},
+						{values.ConvertTime(time.Unix(60, 0)), values.ConvertTime(time.Unix(40, 0)), int64(20), "a"},
+					},
+				},
+				{
+					KeyCols: []string{"_stop", "host"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_stop", Type: flux.TTime},
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TInt},
+						{Label: "host", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{values.ConvertTime(time.Unix(60, 0)), values.ConvertTime(time.Unix(5, 0)), int64(45), "b"},
+						{values.ConvertTime(time.Unix(60, 0)), values.ConvertTime(time.Unix(50, 0)), int64(10), "b"},
+					},
+				},
+			},
+		},
+		{
+			name: "overwrite time column",
+			spec: &events.DurationProcedureSpec{
+				Unit:       flux.ConvertDuration(time.Second),
+				TimeColumn: execute.DefaultTimeColLabel,
+				ColumnName: execute.DefaultTimeColLabel,
+				StopColumn: execute.DefaultStopColLabel,
+				AsFloat:    true,
+				Overwrite:  true,
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_stop", Type: flux.TTime},
+					{Label: "_time", Type: flux.TTime},
+				},
+				Data: [][]interface{}{
+					{values.ConvertTime(time.Unix(60, 0)), values.ConvertTime(time.Unix(0, 0))},
+					{values.ConvertTime(time.Unix(60, 0)), values.ConvertTime(time.Unix(15, 0))},
+				},
+			}},
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "_stop", Type: flux.TTime},
+					{Label: "_time", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{values.ConvertTime(time.Unix(60, 0)), 15.0},
+					{values.ConvertTime(time.Unix(60, 0)), 45.0},
+				},
+			}},
+		},
 	}
 	for _, tc := range testCases {
 		tc := tc
@@ -647,3 +1019,717 @@ func TestDuration_Process(t *testing.T) {
 		})
 	}
 }
+
+func TestDuration_ColumnCollision(t *testing.T) {
+	data := func() []flux.Table {
+		return []flux.Table{&executetest.Table{
+			KeyCols: []string{"_stop", "host"},
+			ColMeta: []flux.ColMeta{
+				{Label: "_stop", Type: flux.TTime},
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_value", Type: flux.TFloat},
+				{Label: "host", Type: flux.TString},
+			},
+			Data: [][]interface{}{
+				{values.ConvertTime(time.Unix(60, 0)), values.ConvertTime(time.Unix(0, 0)), 1.0, "a"},
+			},
+		}}
+	}
+	testCases := []struct {
+		name    string
+		spec    *events.DurationProcedureSpec
+		wantErr error
+	}{
+		{
+			name: "existing column",
+			spec: &events.DurationProcedureSpec{
+				Unit:       flux.ConvertDuration(time.Second),
+				TimeColumn: execute.DefaultTimeColLabel,
+				ColumnName: "_value",
+				StopColumn: execute.DefaultStopColLabel,
+			},
+			wantErr: errors.New(codes.Invalid, `column "_value" already exists; set overwrite to replace it`),
+		},
+		{
+			name: "overwrite group key column",
+			spec: &events.DurationProcedureSpec{
+				Unit:       flux.ConvertDuration(time.Second),
+				TimeColumn: execute.DefaultTimeColLabel,
+				ColumnName: "host",
+				StopColumn: execute.DefaultStopColLabel,
+				Overwrite:  true,
+			},
+			wantErr: errors.New(codes.Invalid, `cannot overwrite group key column "host"`),
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			executetest.ProcessTestHelper(
+				t,
+				data(),
+				nil,
+				tc.wantErr,
+				func(d execute.Dataset, c execute.TableBuilderCache) execute.Transformation {
+					return events.NewDurationTransformation(d, c, tc.spec)
+				},
+			)
+		})
+	}
+}
+
+// bufferedTable is a table that reads each of its
+// buffers as a separate flux.ColReader.
+type bufferedTable struct {
+	*executetest.Table
+	buffers []*executetest.Table
+}
+
+func (t *bufferedTable) Do(f func(flux.ColReader) error) error {
+	for _, buf := range t.buffers {
+		if err := buf.Do(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestDuration_EmptyBuffers(t *testing.T) {
+	cols := []flux.ColMeta{
+		{Label: "_stop", Type: flux.TTime},
+		{Label: "_time", Type: flux.TTime},
+	}
+	spec := &events.DurationProcedureSpec{
+		Unit:       flux.ConvertDuration(time.Nanosecond),
+		TimeColumn: execute.DefaultTimeColLabel,
+		ColumnName: "duration",
+		StopColumn: execute.DefaultStopColLabel,
+	}
+	for _, tc := range []struct {
+		name string
+		data flux.Table
+		want *executetest.Table
+	}{
+		{
+			name: "empty buffer before rows",
+			data: &bufferedTable{
+				Table: &executetest.Table{
+					ColMeta: cols,
+					Data: [][]interface{}{
+						{execute.Time(50), execute.Time(1)},
+					},
+				},
+				buffers: []*executetest.Table{
+					{ColMeta: cols},
+					{
+						ColMeta: cols,
+						Data: [][]interface{}{
+							{execute.Time(50), execute.Time(1)},
+							{execute.Time(50), execute.Time(4)},
+						},
+					},
+					{ColMeta: cols},
+				},
+			},
+			want: &executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_stop", Type: flux.TTime},
+					{Label: "_time", Type: flux.TTime},
+					{Label: "duration", Type: flux.TInt},
+				},
+				Data: [][]interface{}{
+					{execute.Time(50), execute.Time(1), int64(3)},
+					{execute.Time(50), execute.Time(4), int64(46)},
+				},
+			},
+		},
+		{
+			name: "empty table",
+			data: &executetest.Table{
+				KeyCols:   []string{"_stop"},
+				KeyValues: []interface{}{execute.Time(50)},
+				ColMeta:   cols,
+			},
+			want: &executetest.Table{
+				KeyCols:   []string{"_stop"},
+				KeyValues: []interface{}{execute.Time(50)},
+				ColMeta: []flux.ColMeta{
+					{Label: "_stop", Type: flux.TTime},
+					{Label: "_time", Type: flux.TTime},
+					{Label: "duration", Type: flux.TInt},
+				},
+			},
+		},
+		{
+			name: "empty table without columns",
+			data: &executetest.Table{
+				KeyCols:   []string{"_stop"},
+				KeyValues: []interface{}{execute.Time(50)},
+				ColMeta:   cols[:1],
+			},
+			want: &executetest.Table{
+				KeyCols:   []string{"_stop"},
+				KeyValues: []interface{}{execute.Time(50)},
+				ColMeta:   cols[:1],
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			executetest.ProcessTestHelper(
+				t,
+				[]flux.Table{tc.data},
+				[]*executetest.Table{tc.want},
+				nil,
+				func(d execute.Dataset, c execute.TableBuilderCache) execute.Transformation {
+					return events.NewDurationTransformation(d, c, spec)
+				},
+			)
+		})
+	}
+}
+
+func TestDuration_Nulls(t *testing.T) {
+	cols := []flux.ColMeta{
+		{Label: "_stop", Type: flux.TTime},
+		{Label: "_time", Type: flux.TTime},
+	}
+	wantCols := []flux.ColMeta{
+		{Label: "_stop", Type: flux.TTime},
+		{Label: "_time", Type: flux.TTime},
+		{Label: "duration", Type: flux.TInt},
+	}
+	spec := &events.DurationProcedureSpec{
+		Unit:       flux.ConvertDuration(time.Nanosecond),
+		TimeColumn: execute.DefaultTimeColLabel,
+		ColumnName: "duration",
+		StopColumn: execute.DefaultStopColLabel,
+	}
+	for _, tc := range []struct {
+		name string
+		data flux.Table
+		want *executetest.Table
+	}{
+		{
+			name: "null times",
+			data: &executetest.Table{
+				ColMeta: cols,
+				Data: [][]interface{}{
+					{execute.Time(50), nil},
+					{execute.Time(50), execute.Time(1)},
+					{execute.Time(50), nil},
+					{execute.Time(50), nil},
+					{execute.Time(50), execute.Time(4)},
+					{execute.Time(50), nil},
+					{execute.Time(50), execute.Time(9)},
+				},
+			},
+			want: &executetest.Table{
+				ColMeta: wantCols,
+				Data: [][]interface{}{
+					{execute.Time(50), nil, nil},
+					{execute.Time(50), execute.Time(1), int64(3)},
+					{execute.Time(50), nil, nil},
+					{execute.Time(50), nil, nil},
+					{execute.Time(50), execute.Time(4), int64(5)},
+					{execute.Time(50), nil, nil},
+					{execute.Time(50), execute.Time(9), int64(41)},
+				},
+			},
+		},
+		{
+			name: "null times at the end",
+			data: &executetest.Table{
+				ColMeta: cols,
+				Data: [][]interface{}{
+					{execute.Time(50), execute.Time(1)},
+					{execute.Time(50), nil},
+					{execute.Time(50), nil},
+				},
+			},
+			want: &executetest.Table{
+				ColMeta: wantCols,
+				Data: [][]interface{}{
+					{execute.Time(50), execute.Time(1), int64(49)},
+					{execute.Time(50), nil, nil},
+					{execute.Time(50), nil, nil},
+				},
+			},
+		},
+		{
+			name: "null last stop",
+			data: &executetest.Table{
+				ColMeta: cols,
+				Data: [][]interface{}{
+					{execute.Time(50), execute.Time(1)},
+					{execute.Time(60), execute.Time(2)},
+					{nil, execute.Time(3)},
+				},
+			},
+			want: &executetest.Table{
+				ColMeta: wantCols,
+				Data: [][]interface{}{
+					{execute.Time(50), execute.Time(1), int64(1)},
+					{execute.Time(60), execute.Time(2), int64(1)},
+					{nil, execute.Time(3), int64(57)},
+				},
+			},
+		},
+		{
+			name: "null stops",
+			data: &executetest.Table{
+				ColMeta: cols,
+				Data: [][]interface{}{
+					{nil, execute.Time(1)},
+					{nil, execute.Time(2)},
+				},
+			},
+			want: &executetest.Table{
+				ColMeta: wantCols,
+				Data: [][]interface{}{
+					{nil, execute.Time(1), int64(1)},
+					{nil, execute.Time(2), nil},
+				},
+			},
+		},
+		{
+			name: "nulls across buffers",
+			data: &bufferedTable{
+				Table: &executetest.Table{
+					ColMeta: cols,
+					Data: [][]interface{}{
+						{execute.Time(50), execute.Time(1)},
+					},
+				},
+				buffers: []*executetest.Table{
+					{
+						ColMeta: cols,
+						Data: [][]interface{}{
+							{execute.Time(50), execute.Time(1)},
+							{nil, nil},
+						},
+					},
+					{
+						ColMeta: cols,
+						Data: [][]interface{}{
+							{execute.Time(70), nil},
+							{nil, execute.Time(5)},
+						},
+					},
+					{
+						ColMeta: cols,
+						Data: [][]interface{}{
+							{nil, nil},
+						},
+					},
+				},
+			},
+			want: &executetest.Table{
+				ColMeta: wantCols,
+				Data: [][]interface{}{
+					{execute.Time(50), execute.Time(1), int64(4)},
+					{nil, nil, nil},
+					{execute.Time(70), nil, nil},
+					{nil, execute.Time(5), int64(65)},
+					{nil, nil, nil},
+				},
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			executetest.ProcessTestHelper(
+				t,
+				[]flux.Table{tc.data},
+				[]*executetest.Table{tc.want},
+				nil,
+				func(d execute.Dataset, c execute.TableBuilderCache) execute.Transformation {
+					return events.NewDurationTransformation(d, c, spec)
+				},
+			)
+		})
+	}
+}
+
+func TestDuration_Interval(t *testing.T) {
+	cols := []flux.ColMeta{
+		{Label: "_start", Type: flux.TTime},
+		{Label: "_stop", Type: flux.TTime},
+	}
+	wantCols := func(typ flux.ColType) []flux.ColMeta {
+		return []flux.ColMeta{
+			{Label: "_start", Type: flux.TTime},
+			{Label: "_stop", Type: flux.TTime},
+			{Label: "duration", Type: typ},
+		}
+	}
+	spec := func(unit time.Duration, asFloat bool) *events.DurationProcedureSpec {
+		return &events.DurationProcedureSpec{
+			Unit:        flux.ConvertDuration(unit),
+			TimeColumn:  execute.DefaultTimeColLabel,
+			ColumnName:  "duration",
+			StartColumn: execute.DefaultStartColLabel,
+			StopColumn:  execute.DefaultStopColLabel,
+			AsFloat:     asFloat,
+			Method:      "interval",
+		}
+	}
+	for _, tc := range []struct {
+		name    string
+		spec    *events.DurationProcedureSpec
+		data    flux.Table
+		want    []*executetest.Table
+		wantErr error
+	}{
+		{
+			name: "durations of rows",
+			spec: spec(time.Second, false),
+			data: &executetest.Table{
+				ColMeta: cols,
+				Data: [][]interface{}{
+					{values.ConvertTime(time.Unix(0, 0)), values.ConvertTime(time.Unix(10, 0))},
+					{values.ConvertTime(time.Unix(5, 0)), values.ConvertTime(time.Unix(7, 0))},
+					{values.ConvertTime(time.Unix(20, 0)), values.ConvertTime(time.Unix(20, 0))},
+				},
+			},
+			want: []*executetest.Table{{
+				ColMeta: wantCols(flux.TInt),
+				Data: [][]interface{}{
+					{values.ConvertTime(time.Unix(0, 0)), values.ConvertTime(time.Unix(10, 0)), int64(10)},
+					{values.ConvertTime(time.Unix(5, 0)), values.ConvertTime(time.Unix(7, 0)), int64(2)},
+					{values.ConvertTime(time.Unix(20, 0)), values.ConvertTime(time.Unix(20, 0)), int64(0)},
+				},
+			}},
+		},
+		{
+			name: "stop before start",
+			spec: spec(time.Second, false),
+			data: &executetest.Table{
+				ColMeta: cols,
+				Data: [][]interface{}{
+					{values.ConvertTime(time.Unix(30, 0)), values.ConvertTime(time.Unix(10, 0))},
+				},
+			},
+			want: []*executetest.Table{{
+				ColMeta: wantCols(flux.TInt),
+				Data: [][]interface{}{
+					{values.ConvertTime(time.Unix(30, 0)), values.ConvertTime(time.Unix(10, 0)), int64(-20)},
+				},
+			}},
+		},
+		{
+			name: "null starts and stops",
+			spec: spec(time.Nanosecond, false),
+			data: &executetest.Table{
+				ColMeta: cols,
+				Data: [][]interface{}{
+					{nil, execute.Time(10)},
+					{execute.Time(3), nil},
+					{nil, nil},
+					{execute.Time(3), execute.Time(10)},
+				},
+			},
+			want: []*executetest.Table{{
+				ColMeta: wantCols(flux.TInt),
+				Data: [][]interface{}{
+					{nil, execute.Time(10), nil},
+					{execute.Time(3), nil, nil},
+					{nil, nil, nil},
+					{execute.Time(3), execute.Time(10), int64(7)},
+				},
+			}},
+		},
+		{
+			name: "unit truncates integers",
+			spec: spec(time.Minute, false),
+			data: &executetest.Table{
+				ColMeta: cols,
+				Data: [][]interface{}{
+					{values.ConvertTime(time.Unix(0, 0)), values.ConvertTime(time.Unix(90, 0))},
+					{values.ConvertTime(time.Unix(90, 0)), values.ConvertTime(time.Unix(0, 0))},
+				},
+			},
+			want: []*executetest.Table{{
+				ColMeta: wantCols(flux.TInt),
+				Data: [][]interface{}{
+					{values.ConvertTime(time.Unix(0, 0)), values.ConvertTime(time.Unix(90, 0)), int64(1)},
+					{values.ConvertTime(time.Unix(90, 0)), values.ConvertTime(time.Unix(0, 0)), int64(-1)},
+				},
+			}},
+		},
+		{
+			name: "unit as float",
+			spec: spec(time.Minute, true),
+			data: &executetest.Table{
+				ColMeta: cols,
+				Data: [][]interface{}{
+					{values.ConvertTime(time.Unix(0, 0)), values.ConvertTime(time.Unix(90, 0))},
+				},
+			},
+			want: []*executetest.Table{{
+				ColMeta: wantCols(flux.TFloat),
+				Data: [][]interface{}{
+					{values.ConvertTime(time.Unix(0, 0)), values.ConvertTime(time.Unix(90, 0)), 1.5},
+				},
+			}},
+		},
+		{
+			name: "multiple buffers",
+			spec: spec(time.Nanosecond, false),
+			data: &bufferedTable{
+				Table: &executetest.Table{
+					ColMeta: cols,
+					Data: [][]interface{}{
+						{execute.Time(1), execute.Time(2)},
+					},
+				},
+				buffers: []*executetest.Table{
+					{
+						ColMeta: cols,
+						Data: [][]interface{}{
+							{execute.Time(1), execute.Time(2)},
+						},
+					},
+					{
+						ColMeta: cols,
+						Data: [][]interface{}{
+							{execute.Time(3), execute.Time(7)},
+						},
+					},
+				},
+			},
+			want: []*executetest.Table{{
+				ColMeta: wantCols(flux.TInt),
+				Data: [][]interface{}{
+					{execute.Time(1), execute.Time(2), int64(1)},
+					{execute.Time(3), execute.Time(7), int64(4)},
+				},
+			}},
+		},
+		{
+			name: "missing start column",
+			spec: spec(time.Second, false),
+			data: &executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_stop", Type: flux.TTime},
+				},
+				Data: [][]interface{}{
+					{execute.Time(1)},
+				},
+			},
+			wantErr: errors.New(codes.FailedPrecondition, `column "_start" does not exist`),
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			executetest.ProcessTestHelper(
+				t,
+				[]flux.Table{tc.data},
+				tc.want,
+				tc.wantErr,
+				func(d execute.Dataset, c execute.TableBuilderCache) execute.Transformation {
+					return events.NewDurationTransformation(d, c, tc.spec)
+				},
+			)
+		})
+	}
+}
+
+func TestDuration_StringFormat(t *testing.T) {
+	cols := []flux.ColMeta{
+		{Label: "_stop", Type: flux.TTime},
+		{Label: "_time", Type: flux.TTime},
+	}
+	wantCols := []flux.ColMeta{
+		{Label: "_stop", Type: flux.TTime},
+		{Label: "_time", Type: flux.TTime},
+		{Label: "duration", Type: flux.TString},
+	}
+	day := int64(24 * time.Hour)
+	for _, tc := range []struct {
+		name string
+		spec *events.DurationProcedureSpec
+		data flux.Table
+		want *executetest.Table
+	}{
+		{
+			name: "sub-second durations",
+			spec: &events.DurationProcedureSpec{
+				TimeColumn: execute.DefaultTimeColLabel,
+				ColumnName: "duration",
+				StopColumn: execute.DefaultStopColLabel,
+				Format:     "string",
+			},
+			data: &executetest.Table{
+				ColMeta: cols,
+				Data: [][]interface{}{
+					{execute.Time(2500000000), execute.Time(0)},
+					{execute.Time(2500000000), execute.Time(1500)},
+					{execute.Time(2500000000), execute.Time(1000002000)},
+				},
+			},
+			want: &executetest.Table{
+				ColMeta: wantCols,
+				Data: [][]interface{}{
+					{execute.Time(2500000000), execute.Time(0), "1us500ns"},
+					{execute.Time(2500000000), execute.Time(1500), "1s500ns"},
+					{execute.Time(2500000000), execute.Time(1000002000), "1s499ms998us"},
+				},
+			},
+		},
+		{
+			name: "multi-day durations",
+			spec: &events.DurationProcedureSpec{
+				TimeColumn: execute.DefaultTimeColLabel,
+				ColumnName: "duration",
+				StopColumn: execute.DefaultStopColLabel,
+				Format:     "string",
+			},
+			data: &executetest.Table{
+				ColMeta: cols,
+				Data: [][]interface{}{
+					{execute.Time(20 * day), execute.Time(0)},
+					{execute.Time(20 * day), execute.Time(2*day + int64(3*time.Hour+90*time.Second))},
+				},
+			},
+			want: &executetest.Table{
+				ColMeta: wantCols,
+				Data: [][]interface{}{
+					{execute.Time(20 * day), execute.Time(0), "2d3h1m30s"},
+					{execute.Time(20 * day), execute.Time(2*day + int64(3*time.Hour+90*time.Second)), "2w3d20h58m30s"},
+				},
+			},
+		},
+		{
+			name: "unit is ignored",
+			spec: &events.DurationProcedureSpec{
+				Unit:       flux.ConvertDuration(time.Minute),
+				TimeColumn: execute.DefaultTimeColLabel,
+				ColumnName: "duration",
+				StopColumn: execute.DefaultStopColLabel,
+				AsFloat:    true,
+				Format:     "string",
+			},
+			data: &executetest.Table{
+				ColMeta: cols,
+				Data: [][]interface{}{
+					{execute.Time(int64(90 * time.Second)), execute.Time(0)},
+				},
+			},
+			want: &executetest.Table{
+				ColMeta: wantCols,
+				Data: [][]interface{}{
+					{execute.Time(int64(90 * time.Second)), execute.Time(0), "1m30s"},
+				},
+			},
+		},
+		{
+			name: "negative interval",
+			spec: &events.DurationProcedureSpec{
+				ColumnName:  "duration",
+				StartColumn: execute.DefaultTimeColLabel,
+				StopColumn:  execute.DefaultStopColLabel,
+				Method:      "interval",
+				Format:      "string",
+			},
+			data: &executetest.Table{
+				ColMeta: cols,
+				Data: [][]interface{}{
+					{execute.Time(0), execute.Time(int64(time.Hour))},
+					{nil, execute.Time(int64(time.Hour))},
+				},
+			},
+			want: &executetest.Table{
+				ColMeta: wantCols,
+				Data: [][]interface{}{
+					{execute.Time(0), execute.Time(int64(time.Hour)), "-1h"},
+					{nil, execute.Time(int64(time.Hour)), nil},
+				},
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			executetest.ProcessTestHelper(
+				t,
+				[]flux.Table{tc.data},
+				[]*executetest.Table{tc.want},
+				nil,
+				func(d execute.Dataset, c execute.TableBuilderCache) execute.Transformation {
+					return events.NewDurationTransformation(d, c, tc.spec)
+				},
+			)
+		})
+	}
+}
+
+// BenchmarkDuration_Tables executes duration on 16 tables.
+// The table concurrency option is set to GOMAXPROCS,
+// so compare the results with -cpu 1,4.
+func BenchmarkDuration_Tables(b *testing.B) {
+	newTables := func() []*executetest.Table {
+		tables := make([]*executetest.Table, 16)
+		for i := range tables {
+			tbl := &executetest.Table{
+				KeyCols: []string{"_stop", "t0"},
+				ColMeta: []flux.ColMeta{
+					{Label: "_stop", Type: flux.TTime},
+					{Label: "t0", Type: flux.TString},
+					{Label: "_time", Type: flux.TTime},
+				},
+			}
+			for j := 0; j < 10000; j++ {
+				tbl.Data = append(tbl.Data, []interface{}{execute.Time(10000), fmt.Sprintf("t%d", i), execute.Time(j)})
+			}
+			tables[i] = tbl
+		}
+		return tables
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		// The tables can only be read once.
+		b.StopTimer()
+		tables := newTables()
+		b.StartTimer()
+
+		spec := &plantest.PlanSpec{
+			Nodes: []plan.Node{
+				plan.CreatePhysicalNode("from", executetest.NewFromProcedureSpec(tables)),
+				plan.CreatePhysicalNode("duration", &events.DurationProcedureSpec{
+					Unit:       flux.ConvertDuration(time.Nanosecond),
+					TimeColumn: execute.DefaultTimeColLabel,
+					ColumnName: "duration",
+					StopColumn: execute.DefaultStopColLabel,
+					Format:     "string",
+				}),
+				plan.CreatePhysicalNode("yield", executetest.NewYieldProcedureSpec("_result")),
+			},
+			Edges: [][2]int{
+				{0, 1},
+				{1, 2},
+			},
+			Resources: flux.ResourceManagement{
+				ConcurrencyQuota: 1,
+				MemoryBytesQuota: math.MaxInt64,
+			},
+			Now: time.Now(),
+		}
+
+		exe := execute.NewExecutor(zaptest.NewLogger(b))
+		ctx, deps := dependency.Inject(context.Background(), executetest.NewTestExecuteDependencies())
+		execDeps := execute.DefaultExecutionDependencies()
+		execDeps.ExecutionOptions.TableConcurrency = goruntime.GOMAXPROCS(0)
+		ctx = execDeps.Inject(ctx)
+		results, _, err := exe.Execute(ctx, plantest.CreatePlanSpec(spec), executetest.UnlimitedAllocator)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if err := results["_result"].Tables().Do(func(tbl flux.Table) error {
+			return tbl.Do(func(flux.ColReader) error { return nil })
+		}); err != nil {
+			b.Fatal(err)
+		}
+		deps.Finish()
+	}
+}
@@ -0,0 +1,274 @@
+package events
+
+import (
+	"time"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/plan"
+	"github.com/influxdata/flux/runtime"
+	"github.com/influxdata/flux/values"
+)
+
+const GapKind = "gap"
+
+type GapOpSpec struct {
+	Unit       flux.Duration `json:"unit"`
+	TimeColumn string        `json:"timeColumn"`
+	StopColumn string        `json:"stopColumn"`
+	ColumnName string        `json:"columnName"`
+	FillFirst  bool          `json:"fillFirst"`
+}
+
+func init() {
+	gapSignature := runtime.MustLookupBuiltinType(pkgPath, GapKind)
+	runtime.RegisterPackageValue(pkgPath, GapKind, flux.MustValue(flux.FunctionValue(GapKind, createGapOpSpec, gapSignature)))
+	flux.RegisterOpSpec(GapKind, newGapOp)
+	plan.RegisterProcedureSpec(GapKind, newGapProcedure, GapKind)
+	execute.RegisterTransformation(GapKind, createGapTransformation)
+}
+
+func createGapOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
+	if err := a.AddParentFromArgs(args); err != nil {
+		return nil, err
+	}
+
+	spec := new(GapOpSpec)
+
+	if unit, ok, err := args.GetDuration("unit"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.Unit = unit
+	} else {
+		spec.Unit = flux.ConvertDuration(time.Second)
+	}
+
+	if timeCol, ok, err := args.GetString("timeColumn"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.TimeColumn = timeCol
+	} else {
+		spec.TimeColumn = execute.DefaultTimeColLabel
+	}
+
+	// Without a stop column, an event ends at its time.
+	if stopCol, ok, err := args.GetString("stopColumn"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.StopColumn = stopCol
+	}
+
+	if name, ok, err := args.GetString("columnName"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.ColumnName = name
+	} else {
+		spec.ColumnName = "gap"
+	}
+
+	if fillFirst, ok, err := args.GetBool("fillFirst"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.FillFirst = fillFirst
+	}
+
+	if spec.ColumnName == spec.TimeColumn || spec.ColumnName == spec.StopColumn {
+		return nil, errors.Newf(codes.Invalid, "column %q already exists", spec.ColumnName)
+	}
+
+	return spec, nil
+}
+
+func newGapOp() flux.OperationSpec {
+	return new(GapOpSpec)
+}
+
+func (s *GapOpSpec) Kind() flux.OperationKind {
+	return GapKind
+}
+
+type GapProcedureSpec struct {
+	plan.DefaultCost
+	Unit       flux.Duration `json:"unit"`
+	TimeColumn string        `json:"timeColumn"`
+	StopColumn string        `json:"stopColumn"`
+	ColumnName string        `json:"columnName"`
+	FillFirst  bool          `json:"fillFirst"`
+}
+
+func newGapProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.ProcedureSpec, error) {
+	spec, ok := qs.(*GapOpSpec)
+	if !ok {
+		return nil, errors.Newf(codes.Internal, "invalid spec type %T", qs)
+	}
+
+	return &GapProcedureSpec{
+		Unit:       spec.Unit,
+		TimeColumn: spec.TimeColumn,
+		StopColumn: spec.StopColumn,
+		ColumnName: spec.ColumnName,
+		FillFirst:  spec.FillFirst,
+	}, nil
+}
+
+func (s *GapProcedureSpec) Kind() plan.ProcedureKind {
+	return GapKind
+}
+
+func (s *GapProcedureSpec) Copy() plan.ProcedureSpec {
+	return &GapProcedureSpec{
+		Unit:       s.Unit,
+		TimeColumn: s.TimeColumn,
+		StopColumn: s.StopColumn,
+		ColumnName: s.ColumnName,
+		FillFirst:  s.FillFirst,
+	}
+}
+
+func createGapTransformation(id execute.DatasetID, mode execute.AccumulationMode, spec plan.ProcedureSpec, a execute.Administration) (execute.Transformation, execute.Dataset, error) {
+	s, ok := spec.(*GapProcedureSpec)
+	if !ok {
+		return nil, nil, errors.Newf(codes.Internal, "invalid spec type %T", spec)
+	}
+	cache := execute.NewTableBuilderCache(a.Allocator())
+	d := execute.NewDataset(id, mode, cache)
+	t := NewGapTransformation(d, cache, s)
+	return t, d, nil
+}
+
+type gapTransformation struct {
+	execute.ExecutionNode
+	d     execute.Dataset
+	cache execute.TableBuilderCache
+
+	unit       float64
+	timeColumn string
+	stopColumn string
+	columnName string
+	fillFirst  bool
+}
+
+func NewGapTransformation(d execute.Dataset, cache execute.TableBuilderCache, spec *GapProcedureSpec) *gapTransformation {
+	return &gapTransformation{
+		d:     d,
+		cache: cache,
+
+		unit:       float64(values.Duration(spec.Unit).Duration()),
+		timeColumn: spec.TimeColumn,
+		stopColumn: spec.StopColumn,
+		columnName: spec.ColumnName,
+		fillFirst:  spec.FillFirst,
+	}
+}
+
+func (t *gapTransformation) RetractTable(id execute.DatasetID, key flux.GroupKey) error {
+	return t.d.RetractTable(key)
+}
+
+func (t *gapTransformation) UpdateWatermark(id execute.DatasetID, mark execute.Time) error {
+	return t.d.UpdateWatermark(mark)
+}
+
+func (t *gapTransformation) UpdateProcessingTime(id execute.DatasetID, pt execute.Time) error {
+	return t.d.UpdateProcessingTime(pt)
+}
+
+func (t *gapTransformation) Finish(id execute.DatasetID, err error) {
+	t.d.Finish(err)
+}
+
+// Process appends the gap between the time of each row and the end of
+// the previous event, which is the stop time of the previous row or its
+// time without a stop column. The gap is negative when the events overlap.
+// A row with a null time has a null gap, and the gap of the first row is
+// null unless fillFirst is set. A null end is skipped, so the next gap is
+// measured from the last known end.
+func (t *gapTransformation) Process(id execute.DatasetID, tbl flux.Table) error {
+	builder, created := t.cache.TableBuilder(tbl.Key())
+	if !created {
+		return errors.Newf(codes.FailedPrecondition, "found duplicate table with key: %v", tbl.Key())
+	}
+	cols := tbl.Cols()
+	if execute.ColIdx(t.columnName, cols) >= 0 {
+		return errors.Newf(codes.Invalid, "column %q already exists", t.columnName)
+	}
+	if err := execute.AddTableCols(tbl, builder); err != nil {
+		return err
+	}
+
+	timeIdx := execute.ColIdx(t.timeColumn, cols)
+	stopIdx := timeIdx
+	if t.stopColumn != "" {
+		stopIdx = execute.ColIdx(t.stopColumn, cols)
+	}
+
+	// An empty table has no gaps, so its output
+	// is empty even when the columns are missing.
+	if tbl.Empty() {
+		if timeIdx >= 0 && stopIdx >= 0 && cols[timeIdx].Type == flux.TTime && cols[stopIdx].Type == flux.TTime {
+			if _, err := builder.AddCol(flux.ColMeta{
+				Label: t.columnName,
+				Type:  flux.TInt,
+			}); err != nil {
+				return err
+			}
+		}
+		tbl.Done()
+		return nil
+	}
+
+	for _, col := range []struct {
+		label string
+		idx   int
+	}{
+		{label: t.timeColumn, idx: timeIdx},
+		{label: t.stopColumn, idx: stopIdx},
+	} {
+		if col.idx < 0 {
+			return errors.Newf(codes.FailedPrecondition, "column %q does not exist", col.label)
+		} else if c := cols[col.idx]; c.Type != flux.TTime {
+			return errors.Newf(codes.FailedPrecondition, "column %q must be of type %s, got %s", c.Label, flux.TTime, c.Type)
+		}
+	}
+
+	gapCol, err := builder.AddCol(flux.ColMeta{
+		Label: t.columnName,
+		Type:  flux.TInt,
+	})
+	if err != nil {
+		return err
+	}
+	colMap := execute.ColMap([]int{0}, builder, cols)
+
+	var (
+		end      int64
+		endValid bool
+	)
+	return tbl.Do(func(cr flux.ColReader) error {
+		times, stops := cr.Times(timeIdx), cr.Times(stopIdx)
+		for i, l := 0, cr.Len(); i < l; i++ {
+			if err := execute.AppendMappedRecordExplicit(i, cr, builder, colMap); err != nil {
+				return err
+			}
+			switch {
+			case times.IsNull(i):
+				err = builder.AppendNil(gapCol)
+			case endValid:
+				err = builder.AppendInt(gapCol, int64((float64(times.Value(i))-float64(end))/t.unit))
+			case t.fillFirst:
+				err = builder.AppendInt(gapCol, 0)
+			default:
+				err = builder.AppendNil(gapCol)
+			}
+			if err != nil {
+				return err
+			}
+			if stops.IsValid(i) {
+				end, endValid = stops.Value(i), true
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,401 @@
+package events_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/execute/executetest"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/querytest"
+	"github.com/influxdata/flux/stdlib/contrib/tomhollingworth/events"
+	"github.com/influxdata/flux/stdlib/influxdata/influxdb"
+)
+
+func TestGap_NewQuery(t *testing.T) {
+	tests := []querytest.NewQueryTestCase{
+		{
+			Name:    "gap columnName collides with time column",
+			Raw:     `import "contrib/tomhollingworth/events" from(bucket:"mydb") |> events.gap(columnName: "_time")`,
+			WantErr: true,
+		},
+		{
+			Name:    "gap columnName collides with stop column",
+			Raw:     `import "contrib/tomhollingworth/events" from(bucket:"mydb") |> events.gap(stopColumn: "end", columnName: "end")`,
+			WantErr: true,
+		},
+		{
+			Name: "gap default",
+			Raw:  `import "contrib/tomhollingworth/events" from(bucket:"mydb") |> events.gap()`,
+			Want: &flux.Spec{
+				Operations: []*flux.Operation{
+					{
+						ID: "from0",
+						Spec: &influxdb.FromOpSpec{
+							Bucket: influxdb.NameOrID{Name: "mydb"},
+						},
+					},
+					{
+						ID: "gap1",
+						Spec: &events.GapOpSpec{
+							Unit:       flux.ConvertDuration(time.Second),
+							TimeColumn: "_time",
+							ColumnName: "gap",
+						},
+					},
+				},
+				Edges: []flux.Edge{
+					{Parent: "from0", Child: "gap1"},
+				},
+			},
+		},
+		{
+			Name: "gap all parameters",
+			Raw:  `import "contrib/tomhollingworth/events" from(bucket:"mydb") |> events.gap(unit: 1m, timeColumn: "start", stopColumn: "end", columnName: "idle", fillFirst: true)`,
+			Want: &flux.Spec{
+				Operations: []*flux.Operation{
+					{
+						ID: "from0",
+						Spec: &influxdb.FromOpSpec{
+							Bucket: influxdb.NameOrID{Name: "mydb"},
+						},
+					},
+					{
+						ID: "gap1",
+						Spec: &events.GapOpSpec{
+							Unit:       flux.ConvertDuration(time.Minute),
+							TimeColumn: "start",
+							StopColumn: "end",
+							ColumnName: "idle",
+							FillFirst:  true,
+						},
+					},
+				},
+				Edges: []flux.Edge{
+					{Parent: "from0", Child: "gap1"},
+				},
+			},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			querytest.NewQueryTestHelper(t, tc)
+		})
+	}
+}
+
+func TestGapOperation_Marshaling(t *testing.T) {
+	data := []byte(`{"id":"gap","kind":"gap","spec":{"timeColumn":"_time","stopColumn":"end","fillFirst":true}}`)
+	op := &flux.Operation{
+		ID: "gap",
+		Spec: &events.GapOpSpec{
+			TimeColumn: "_time",
+			StopColumn: "end",
+			FillFirst:  true,
+		},
+	}
+	querytest.OperationMarshalingTestHelper(t, data, op)
+}
+
+func TestGap_PassThrough(t *testing.T) {
+	executetest.TransformationPassThroughTestHelper(t, func(d execute.Dataset, c execute.TableBuilderCache) execute.Transformation {
+		return events.NewGapTransformation(d, c, &events.GapProcedureSpec{})
+	})
+}
+
+func TestGap_Process(t *testing.T) {
+	cols := []flux.ColMeta{
+		{Label: "_time", Type: flux.TTime},
+		{Label: "end", Type: flux.TTime},
+	}
+	wantCols := []flux.ColMeta{
+		{Label: "_time", Type: flux.TTime},
+		{Label: "end", Type: flux.TTime},
+		{Label: "gap", Type: flux.TInt},
+	}
+	spec := func(stopColumn string, fillFirst bool) *events.GapProcedureSpec {
+		return &events.GapProcedureSpec{
+			Unit:       flux.ConvertDuration(time.Nanosecond),
+			TimeColumn: execute.DefaultTimeColLabel,
+			StopColumn: stopColumn,
+			ColumnName: "gap",
+			FillFirst:  fillFirst,
+		}
+	}
+	testCases := []struct {
+		name    string
+		spec    *events.GapProcedureSpec
+		data    []flux.Table
+		want    []*executetest.Table
+		wantErr error
+	}{
+		{
+			name: "time column",
+			spec: spec("", false),
+			data: []flux.Table{&executetest.Table{
+				ColMeta: cols,
+				Data: [][]interface{}{
+					{execute.Time(1), execute.Time(2)},
+					{execute.Time(4), execute.Time(6)},
+					{execute.Time(10), execute.Time(11)},
+				},
+			}},
+			want: []*executetest.Table{{
+				ColMeta: wantCols,
+				Data: [][]interface{}{
+					{execute.Time(1), execute.Time(2), nil},
+					{execute.Time(4), execute.Time(6), int64(3)},
+					{execute.Time(10), execute.Time(11), int64(6)},
+				},
+			}},
+		},
+		{
+			name: "stop column",
+			spec: spec("end", false),
+			data: []flux.Table{&executetest.Table{
+				ColMeta: cols,
+				Data: [][]interface{}{
+					{execute.Time(1), execute.Time(2)},
+					{execute.Time(4), execute.Time(6)},
+					{execute.Time(10), execute.Time(11)},
+				},
+			}},
+			want: []*executetest.Table{{
+				ColMeta: wantCols,
+				Data: [][]interface{}{
+					{execute.Time(1), execute.Time(2), nil},
+					{execute.Time(4), execute.Time(6), int64(2)},
+					{execute.Time(10), execute.Time(11), int64(4)},
+				},
+			}},
+		},
+		{
+			name: "overlapping events",
+			spec: spec("end", false),
+			data: []flux.Table{&executetest.Table{
+				ColMeta: cols,
+				Data: [][]interface{}{
+					{execute.Time(1), execute.Time(8)},
+					{execute.Time(5), execute.Time(6)},
+					{execute.Time(6), execute.Time(9)},
+				},
+			}},
+			want: []*executetest.Table{{
+				ColMeta: wantCols,
+				Data: [][]interface{}{
+					{execute.Time(1), execute.Time(8), nil},
+					{execute.Time(5), execute.Time(6), int64(-3)},
+					{execute.Time(6), execute.Time(9), int64(0)},
+				},
+			}},
+		},
+		{
+			name: "unit",
+			spec: &events.GapProcedureSpec{
+				Unit:       flux.ConvertDuration(time.Second),
+				TimeColumn: execute.DefaultTimeColLabel,
+				StopColumn: "end",
+				ColumnName: "gap",
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: cols,
+				Data: [][]interface{}{
+					{execute.Time(0), execute.Time(int64(time.Second))},
+					{execute.Time(int64(3500 * time.Millisecond)), execute.Time(int64(4 * time.Second))},
+				},
+			}},
+			want: []*executetest.Table{{
+				ColMeta: wantCols,
+				Data: [][]interface{}{
+					{execute.Time(0), execute.Time(int64(time.Second)), nil},
+					{execute.Time(int64(3500 * time.Millisecond)), execute.Time(int64(4 * time.Second)), int64(2)},
+				},
+			}},
+		},
+		{
+			name: "single row",
+			spec: spec("end", false),
+			data: []flux.Table{&executetest.Table{
+				ColMeta: cols,
+				Data: [][]interface{}{
+					{execute.Time(1), execute.Time(2)},
+				},
+			}},
+			want: []*executetest.Table{{
+				ColMeta: wantCols,
+				Data: [][]interface{}{
+					{execute.Time(1), execute.Time(2), nil},
+				},
+			}},
+		},
+		{
+			name: "single row fill first",
+			spec: spec("end", true),
+			data: []flux.Table{&executetest.Table{
+				ColMeta: cols,
+				Data: [][]interface{}{
+					{execute.Time(1), execute.Time(2)},
+				},
+			}},
+			want: []*executetest.Table{{
+				ColMeta: wantCols,
+				Data: [][]interface{}{
+					{execute.Time(1), execute.Time(2), int64(0)},
+				},
+			}},
+		},
+		{
+			name: "each table",
+			spec: spec("end", true),
+			data: []flux.Table{
+				&executetest.Table{
+					KeyCols: []string{"host"},
+					ColMeta: append(cols, flux.ColMeta{Label: "host", Type: flux.TString}),
+					Data: [][]interface{}{
+						{execute.Time(1), execute.Time(2), "a"},
+						{execute.Time(5), execute.Time(6), "a"},
+					},
+				},
+				&executetest.Table{
+					KeyCols: []string{"host"},
+					ColMeta: append(cols, flux.ColMeta{Label: "host", Type: flux.TString}),
+					Data: [][]interface{}{
+						{execute.Time(3), execute.Time(4), "b"},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					KeyCols: []string{"host"},
+					ColMeta: append(cols, flux.ColMeta{Label: "host", Type: flux.TString}, flux.ColMeta{Label: "gap", Type: flux.TInt}),
+					Data: [][]interface{}{
+						{execute.Time(1), execute.Time(2), "a", int64(0)},
+						{execute.Time(5), execute.Time(6), "a", int64(3)},
+					},
+				},
+				{
+					KeyCols: []string{"host"},
+					ColMeta: append(cols, flux.ColMeta{Label: "host", Type: flux.TString}, flux.ColMeta{Label: "gap", Type: flux.TInt}),
+					Data: [][]interface{}{
+						{execute.Time(3), execute.Time(4), "b", int64(0)},
+					},
+				},
+			},
+		},
+		{
+			name: "multiple buffers",
+			spec: spec("end", false),
+			data: []flux.Table{&bufferedTable{
+				Table: &executetest.Table{
+					ColMeta: cols,
+					Data: [][]interface{}{
+						{execute.Time(1), execute.Time(2)},
+					},
+				},
+				buffers: []*executetest.Table{
+					{
+						ColMeta: cols,
+						Data: [][]interface{}{
+							{execute.Time(1), execute.Time(2)},
+							{execute.Time(4), execute.Time(5)},
+						},
+					},
+					{ColMeta: cols},
+					{
+						ColMeta: cols,
+						Data: [][]interface{}{
+							{execute.Time(9), execute.Time(10)},
+						},
+					},
+				},
+			}},
+			want: []*executetest.Table{{
+				ColMeta: wantCols,
+				Data: [][]interface{}{
+					{execute.Time(1), execute.Time(2), nil},
+					{execute.Time(4), execute.Time(5), int64(2)},
+					{execute.Time(9), execute.Time(10), int64(4)},
+				},
+			}},
+		},
+		{
+			name: "nulls",
+			spec: spec("end", true),
+			data: []flux.Table{&executetest.Table{
+				ColMeta: cols,
+				Data: [][]interface{}{
+					{nil, execute.Time(2)},
+					{execute.Time(4), nil},
+					{nil, nil},
+					{execute.Time(9), execute.Time(10)},
+				},
+			}},
+			want: []*executetest.Table{{
+				ColMeta: wantCols,
+				Data: [][]interface{}{
+					{nil, execute.Time(2), nil},
+					{execute.Time(4), nil, int64(2)},
+					{nil, nil, nil},
+					{execute.Time(9), execute.Time(10), int64(7)},
+				},
+			}},
+		},
+		{
+			name: "empty table",
+			spec: spec("end", false),
+			data: []flux.Table{&executetest.Table{
+				KeyCols:   []string{"end"},
+				KeyValues: []interface{}{execute.Time(50)},
+				ColMeta:   cols,
+			}},
+			want: []*executetest.Table{{
+				KeyCols:   []string{"end"},
+				KeyValues: []interface{}{execute.Time(50)},
+				ColMeta:   wantCols,
+			}},
+		},
+		{
+			name: "missing stop column",
+			spec: spec("stop", false),
+			data: []flux.Table{&executetest.Table{
+				ColMeta: cols,
+				Data: [][]interface{}{
+					{execute.Time(1), execute.Time(2)},
+				},
+			}},
+			wantErr: errors.New(codes.FailedPrecondition, `column "stop" does not exist`),
+		},
+		{
+			name: "existing column",
+			spec: &events.GapProcedureSpec{
+				Unit:       flux.ConvertDuration(time.Nanosecond),
+				TimeColumn: execute.DefaultTimeColLabel,
+				ColumnName: "end",
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: cols,
+				Data: [][]interface{}{
+					{execute.Time(1), execute.Time(2)},
+				},
+			}},
+			wantErr: errors.New(codes.Invalid, `column "end" already exists`),
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			executetest.ProcessTestHelper(
+				t,
+				tc.data,
+				tc.want,
+				tc.wantErr,
+				func(d execute.Dataset, c execute.TableBuilderCache) execute.Transformation {
+					return events.NewGapTransformation(d, c, tc.spec)
+				},
+			)
+		})
+	}
+}
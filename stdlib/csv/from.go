@@ -12,9 +12,11 @@ import (
 	"github.com/influxdata/flux/dependencies/filesystem"
 	"github.com/influxdata/flux/execute"
 	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/interpreter"
 	"github.com/influxdata/flux/memory"
 	"github.com/influxdata/flux/plan"
 	"github.com/influxdata/flux/runtime"
+	"github.com/influxdata/flux/semantic"
 )
 
 const FromCSVKind = "fromCSV"
@@ -23,6 +25,10 @@ type FromCSVOpSpec struct {
 	CSV  string `json:"csv"`
 	File string `json:"file"`
 	Mode string `json:"mode"`
+
+	FailOnError bool     `json:"failOnError"`
+	TrimSpace   bool     `json:"trimSpace"`
+	NullValues  []string `json:"nullValues"`
 }
 
 const (
@@ -70,6 +76,27 @@ func createFromCSVOpSpec(args flux.Arguments, a *flux.Administration) (flux.Oper
 		spec.Mode = annotationMode
 	}
 
+	if failOnError, ok, err := args.GetBool("failOnError"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.FailOnError = failOnError
+	}
+
+	if trimSpace, ok, err := args.GetBool("trimSpace"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.TrimSpace = trimSpace
+	}
+
+	if nullValues, ok, err := args.GetArrayAllowEmpty("nullValues", semantic.String); err != nil {
+		return nil, err
+	} else if ok {
+		spec.NullValues, err = interpreter.ToStringArray(nullValues)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return spec, nil
 }
 
@@ -86,6 +113,10 @@ type FromCSVProcedureSpec struct {
 	CSV  string
 	File string
 	Mode string
+
+	FailOnError bool
+	TrimSpace   bool
+	NullValues  []string
 }
 
 func newFromCSVProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.ProcedureSpec, error) {
@@ -98,6 +129,10 @@ func newFromCSVProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.Pr
 		CSV:  spec.CSV,
 		File: spec.File,
 		Mode: spec.Mode,
+
+		FailOnError: spec.FailOnError,
+		TrimSpace:   spec.TrimSpace,
+		NullValues:  spec.NullValues,
 	}, nil
 }
 
@@ -110,6 +145,12 @@ func (s *FromCSVProcedureSpec) Copy() plan.ProcedureSpec {
 	ns.CSV = s.CSV
 	ns.File = s.File
 	ns.Mode = s.Mode
+	ns.FailOnError = s.FailOnError
+	ns.TrimSpace = s.TrimSpace
+	if s.NullValues != nil {
+		ns.NullValues = make([]string, len(s.NullValues))
+		copy(ns.NullValues, s.NullValues)
+	}
 	return ns
 }
 
@@ -141,6 +182,9 @@ func CreateSource(spec *FromCSVProcedureSpec, dsid execute.DatasetID, a execute.
 		getDataStream: getDataStream,
 		alloc:         a.Allocator(),
 		mode:          spec.Mode,
+		failOnError:   spec.FailOnError,
+		trimSpace:     spec.TrimSpace,
+		nullValues:    spec.NullValues,
 	}
 
 	return &csvSource, nil
@@ -153,6 +197,9 @@ type CSVSource struct {
 	ts            []execute.Transformation
 	alloc         memory.Allocator
 	mode          string
+	failOnError   bool
+	trimSpace     bool
+	nullValues    []string
 }
 
 func (c *CSVSource) AddTransformation(t execute.Transformation) {
@@ -173,6 +220,11 @@ func (c *CSVSource) Run(ctx context.Context) {
 		config := csv.ResultDecoderConfig{
 			Allocator: c.alloc,
 			Context:   ctx,
+			// An error in the data is read as a table, so the tables
+			// before it are processed before the error is returned.
+			ErrorTables: !c.failOnError,
+			TrimSpace:   c.trimSpace,
+			NullValues:  c.nullValues,
 		}
 		switch c.mode {
 		case rawMode:
@@ -185,12 +237,9 @@ func (c *CSVSource) Run(ctx context.Context) {
 		if err != nil {
 			goto FINISH
 		}
-		// Many applications will add a UTF BOM (byte order mark) to the beginning of csv files
-		// We expect UTF8 encoded data so the byte order does not matter.
-		// Therefore we skip the BOM if it exists.
-		// See http://www.unicode.org/faq/utf_bom.html#BOM
-		rc := newSkipBOMReader(data)
-		results, decodeErr := decoder.Decode(rc)
+		// The decoder skips the byte order mark
+		// that many applications write to csv files.
+		results, decodeErr := decoder.Decode(data)
 		defer results.Release()
 		if decodeErr != nil {
 			err = decodeErr
@@ -245,48 +294,3 @@ FINISH:
 		t.Finish(c.id, err)
 	}
 }
-
-// skipBOMReader wraps an io.ReadCloser and skips the BOM,
-// if it exists at the beginning of the stream.
-type skipBOMReader struct {
-	io.ReadCloser
-	buf     []byte
-	checked bool
-}
-
-func newSkipBOMReader(r io.ReadCloser) io.ReadCloser {
-	return &skipBOMReader{
-		ReadCloser: r,
-		// BOM is three bytes long
-		buf:     []byte{0, 0, 0},
-		checked: false,
-	}
-}
-
-func (r *skipBOMReader) Read(p []byte) (n int, err error) {
-	if !r.checked {
-		r.checked = true
-		// Read at least 3 bytes if possible
-		// We do not use io.ReadFull or io.ReadAtLeast here as they
-		// change the semantics of how errors are reported..
-		total := 0
-		for total < len(r.buf) {
-			n, err := r.ReadCloser.Read(r.buf[total:])
-			total += n
-			if err != nil {
-				m := copy(p, r.buf[:total])
-				return m, err
-			}
-		}
-		if r.buf[0] == 0xef && r.buf[1] == 0xbb && r.buf[2] == 0xbf {
-			// Found the BOM, null the buffer so that we don't return those bytes
-			r.buf = nil
-		}
-	}
-	if len(r.buf) > 0 {
-		n := copy(p, r.buf)
-		r.buf = r.buf[n:]
-		return n, nil
-	}
-	return r.ReadCloser.Read(p)
-}
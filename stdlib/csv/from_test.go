@@ -2,6 +2,7 @@ package csv_test
 
 import (
 	"context"
+	"fmt"
 	"strings"
 	"testing"
 	"time"
@@ -245,6 +246,39 @@ func TestFromCSV_NewQuery(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name: "fromCSV failOnError",
+			Raw:  `import "csv" csv.from(csv: "1,2", failOnError: true)`,
+			Want: &flux.Spec{
+				Operations: []*flux.Operation{
+					{
+						ID: "fromCSV0",
+						Spec: &csv.FromCSVOpSpec{
+							CSV:         "1,2",
+							Mode:        "annotations",
+							FailOnError: true,
+						},
+					},
+				},
+			},
+		},
+		{
+			Name: "fromCSV trimSpace nullValues",
+			Raw:  `import "csv" csv.from(csv: "1,2", trimSpace: true, nullValues: ["", "N/A"])`,
+			Want: &flux.Spec{
+				Operations: []*flux.Operation{
+					{
+						ID: "fromCSV0",
+						Spec: &csv.FromCSVOpSpec{
+							CSV:        "1,2",
+							Mode:       "annotations",
+							TrimSpace:  true,
+							NullValues: []string{"", "N/A"},
+						},
+					},
+				},
+			},
+		},
 	}
 	for _, tc := range tests {
 		tc := tc
@@ -256,12 +290,13 @@ func TestFromCSV_NewQuery(t *testing.T) {
 }
 
 func TestFromCSVOperation_Marshaling(t *testing.T) {
-	data := []byte(`{"id":"fromCSV","kind":"fromCSV","spec":{"csv":"1,2","mode":"annotations"}}`)
+	data := []byte(`{"id":"fromCSV","kind":"fromCSV","spec":{"csv":"1,2","mode":"annotations","failOnError":true}}`)
 	op := &flux.Operation{
 		ID: "fromCSV",
 		Spec: &csv.FromCSVOpSpec{
-			CSV:  "1,2",
-			Mode: "annotations",
+			CSV:         "1,2",
+			Mode:        "annotations",
+			FailOnError: true,
 		},
 	}
 	querytest.OperationMarshalingTestHelper(t, data, op)
@@ -353,6 +388,86 @@ func TestFromCSV_Run(t *testing.T) {
 	)
 }
 
+func TestFromCSV_RunTrimSpace(t *testing.T) {
+	spec := &csv.FromCSVProcedureSpec{
+		CSV: "\ufeff" + ` host , _value
+ A , 1.5
+ B , N/A
+`,
+		Mode:       "raw",
+		TrimSpace:  true,
+		NullValues: []string{"N/A"},
+	}
+	want := []*executetest.Table{{
+		ColMeta: []flux.ColMeta{
+			{Label: "host", Type: flux.TString},
+			{Label: "_value", Type: flux.TString},
+		},
+		Data: [][]interface{}{
+			{"A", "1.5"},
+			{"B", nil},
+		},
+	}}
+	executetest.RunSourceHelper(t,
+		want,
+		nil,
+		func(id execute.DatasetID) execute.Source {
+			a := mock.AdministrationWithContext(context.Background())
+			s, err := csv.CreateSource(spec, id, a)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return s
+		},
+	)
+}
+
+func TestFromCSV_RunError(t *testing.T) {
+	data := `#datatype,string,long,dateTime:RFC3339,string,double
+#group,false,false,false,true,false
+#default,_result,,,,
+,result,table,_time,host,_value
+,,0,2018-04-17T00:00:00Z,A,1.0
+,,1,2018-04-17T00:00:00Z,B,2.0
+
+#datatype,string,string
+#group,true,true
+#default,,
+,error,reference
+,here is an error,
+`
+	for _, failOnError := range []bool{false, true} {
+		t.Run(fmt.Sprintf("failOnError=%v", failOnError), func(t *testing.T) {
+			spec := &csv.FromCSVProcedureSpec{
+				CSV:         data,
+				FailOnError: failOnError,
+			}
+			a := mock.AdministrationWithContext(context.Background())
+			s, err := csv.CreateSource(spec, executetest.RandomDatasetID(), a)
+			if err != nil {
+				t.Fatal(err)
+			}
+			store := executetest.NewDataStore()
+			s.AddTransformation(store)
+			s.Run(context.Background())
+
+			if err := store.Err(); err == nil {
+				t.Fatal("expected an error, got none")
+			} else if got, want := err.Error(), "error in csv.from(): here is an error"; got != want {
+				t.Errorf("unexpected error -want/+got:\n\t- %q\n\t+ %q", want, got)
+			}
+			// The tables before the error are processed.
+			got, err := executetest.TablesFromCache(store)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != 2 {
+				t.Errorf("unexpected number of tables -want/+got:\n\t- %d\n\t+ %d", 2, len(got))
+			}
+		})
+	}
+}
+
 func TestFromCSV_RunCancel(t *testing.T) {
 	var csvTextBuilder strings.Builder
 	csvTextBuilder.WriteString(`#datatype,string,long,dateTime:RFC3339,dateTime:RFC3339,dateTime:RFC3339,string,string,double
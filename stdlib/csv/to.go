@@ -0,0 +1,220 @@
+package csv
+
+import (
+	"bufio"
+	"context"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/csv"
+	"github.com/influxdata/flux/dependencies/filesystem"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/plan"
+	"github.com/influxdata/flux/runtime"
+)
+
+const ToCSVKind = "toCSV"
+
+type ToCSVOpSpec struct {
+	File      string `json:"file"`
+	Overwrite bool   `json:"overwrite"`
+}
+
+func init() {
+	toCSVSignature := runtime.MustLookupBuiltinType("csv", "to")
+	runtime.RegisterPackageValue("csv", "to", flux.MustValue(flux.FunctionValueWithSideEffect(ToCSVKind, createToCSVOpSpec, toCSVSignature)))
+	flux.RegisterOpSpec(ToCSVKind, func() flux.OperationSpec { return &ToCSVOpSpec{} })
+	plan.RegisterProcedureSpecWithSideEffect(ToCSVKind, newToCSVProcedure, ToCSVKind)
+	execute.RegisterTransformation(ToCSVKind, createToCSVTransformation)
+}
+
+func createToCSVOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
+	if err := a.AddParentFromArgs(args); err != nil {
+		return nil, err
+	}
+
+	spec := new(ToCSVOpSpec)
+	file, err := args.GetRequiredString("file")
+	if err != nil {
+		return nil, err
+	} else if file == "" {
+		return nil, errors.New(codes.Invalid, "file must not be empty")
+	}
+	spec.File = file
+
+	if overwrite, ok, err := args.GetBool("overwrite"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.Overwrite = overwrite
+	}
+	return spec, nil
+}
+
+func (s *ToCSVOpSpec) Kind() flux.OperationKind {
+	return ToCSVKind
+}
+
+type ToCSVProcedureSpec struct {
+	plan.DefaultCost
+	File      string
+	Overwrite bool
+}
+
+func newToCSVProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.ProcedureSpec, error) {
+	spec, ok := qs.(*ToCSVOpSpec)
+	if !ok {
+		return nil, errors.Newf(codes.Internal, "invalid spec type %T", qs)
+	}
+	return &ToCSVProcedureSpec{
+		File:      spec.File,
+		Overwrite: spec.Overwrite,
+	}, nil
+}
+
+func (s *ToCSVProcedureSpec) Kind() plan.ProcedureKind {
+	return ToCSVKind
+}
+
+func (s *ToCSVProcedureSpec) Copy() plan.ProcedureSpec {
+	ns := *s
+	return &ns
+}
+
+func createToCSVTransformation(id execute.DatasetID, mode execute.AccumulationMode, spec plan.ProcedureSpec, a execute.Administration) (execute.Transformation, execute.Dataset, error) {
+	s, ok := spec.(*ToCSVProcedureSpec)
+	if !ok {
+		return nil, nil, errors.Newf(codes.Internal, "invalid spec type %T", spec)
+	}
+	return NewToCSVTransformation(a.Context(), id, s)
+}
+
+// toCSVTransformation writes the tables to a file as annotated CSV
+// and passes them to the next transformation unchanged.
+//
+// The tables are encoded by a goroutine as a single result with the
+// same encoder that is used for the results of a query, so each table
+// is written as soon as it is processed. The file is synced when the
+// input is finished and it is removed if the query fails, so a file
+// that exists once the query succeeds always has all of the tables.
+type toCSVTransformation struct {
+	execute.ExecutionNode
+	ctx  context.Context
+	d    *execute.PassthroughDataset
+	file filesystem.WritableFile
+	w    *bufio.Writer
+
+	// tables sends the tables to the encoder.
+	tables chan flux.Table
+	// done is closed once the encoder returns and
+	// encodeErr holds the error that it returned.
+	done      chan struct{}
+	encodeErr error
+}
+
+// NewToCSVTransformation creates the file through the filesystem service
+// of the context and starts to encode the tables that it processes.
+func NewToCSVTransformation(ctx context.Context, id execute.DatasetID, spec *ToCSVProcedureSpec) (execute.Transformation, execute.Dataset, error) {
+	file, err := filesystem.Create(ctx, spec.File, spec.Overwrite)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, codes.Inherit, "failed to create file %q", spec.File)
+	}
+	t := &toCSVTransformation{
+		ctx:    ctx,
+		d:      execute.NewPassthroughDataset(id),
+		file:   file,
+		w:      bufio.NewWriter(file),
+		tables: make(chan flux.Table),
+		done:   make(chan struct{}),
+	}
+	go t.encode()
+	return t, t.d, nil
+}
+
+func (t *toCSVTransformation) encode() {
+	defer close(t.done)
+	result := &toCSVResult{tables: t.tables}
+	enc := csv.NewMultiResultEncoder(csv.DefaultEncoderConfig())
+	_, t.encodeErr = enc.Encode(t.w, flux.NewSliceResultIterator([]flux.Result{result}))
+
+	// Release any tables that were sent after the encoder returned.
+	for tbl := range t.tables {
+		tbl.Done()
+	}
+}
+
+func (t *toCSVTransformation) Process(id execute.DatasetID, tbl flux.Table) error {
+	buf, err := execute.CopyTable(tbl)
+	if err != nil {
+		return err
+	}
+	cpy := buf.Copy()
+	select {
+	case t.tables <- cpy:
+	case <-t.done:
+		cpy.Done()
+		buf.Done()
+		return errors.Wrapf(t.encodeErr, codes.Inherit, "failed to write to file %q", t.file.Name())
+	}
+	return t.d.Process(buf)
+}
+
+func (t *toCSVTransformation) RetractTable(id execute.DatasetID, key flux.GroupKey) error {
+	return t.d.RetractTable(key)
+}
+
+func (t *toCSVTransformation) UpdateWatermark(id execute.DatasetID, mark execute.Time) error {
+	return t.d.UpdateWatermark(mark)
+}
+
+func (t *toCSVTransformation) UpdateProcessingTime(id execute.DatasetID, pt execute.Time) error {
+	return t.d.UpdateProcessingTime(pt)
+}
+
+func (t *toCSVTransformation) Finish(id execute.DatasetID, err error) {
+	close(t.tables)
+	<-t.done
+
+	if err == nil && t.encodeErr != nil {
+		err = errors.Wrapf(t.encodeErr, codes.Inherit, "failed to write to file %q", t.file.Name())
+	}
+	if err == nil {
+		if ferr := t.w.Flush(); ferr != nil {
+			err = errors.Wrapf(ferr, codes.Inherit, "failed to write to file %q", t.file.Name())
+		} else if serr := t.file.Sync(); serr != nil {
+			err = errors.Wrapf(serr, codes.Inherit, "failed to sync file %q", t.file.Name())
+		}
+	}
+	if cerr := t.file.Close(); cerr != nil && err == nil {
+		err = errors.Wrapf(cerr, codes.Inherit, "failed to close file %q", t.file.Name())
+	}
+	if err != nil {
+		// Do not leave a file with only some of the tables.
+		_ = filesystem.Remove(t.ctx, t.file.Name())
+	}
+	t.d.Finish(err)
+}
+
+// toCSVResult is the result that is encoded to the file.
+// Its tables are those sent on the channel until it is closed.
+type toCSVResult struct {
+	tables chan flux.Table
+}
+
+func (r *toCSVResult) Name() string {
+	return "_result"
+}
+
+func (r *toCSVResult) Tables() flux.TableIterator {
+	return r
+}
+
+func (r *toCSVResult) Do(f func(flux.Table) error) error {
+	for tbl := range r.tables {
+		if err := f(tbl); err != nil {
+			tbl.Done()
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package csv_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
+	fcsv "github.com/influxdata/flux/csv"
+	"github.com/influxdata/flux/dependencies/filesystem"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/execute/executetest"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/stdlib/csv"
+)
+
+func toCSVTables() []*executetest.Table {
+	return []*executetest.Table{
+		{
+			KeyCols: []string{"_measurement", "host"},
+			ColMeta: []flux.ColMeta{
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_measurement", Type: flux.TString},
+				{Label: "host", Type: flux.TString},
+				{Label: "_value", Type: flux.TFloat},
+			},
+			Data: [][]interface{}{
+				{execute.Time(1), "cpu", "a", 1.5},
+				{execute.Time(2), "cpu", "a", nil},
+			},
+		},
+		{
+			KeyCols: []string{"_measurement", "host"},
+			ColMeta: []flux.ColMeta{
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_measurement", Type: flux.TString},
+				{Label: "host", Type: flux.TString},
+				{Label: "_value", Type: flux.TFloat},
+			},
+			Data: [][]interface{}{
+				{execute.Time(1), "cpu", "b", 2.5},
+			},
+		},
+		{
+			KeyCols: []string{"_measurement"},
+			ColMeta: []flux.ColMeta{
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_measurement", Type: flux.TString},
+				{Label: "count", Type: flux.TInt},
+			},
+			Data: [][]interface{}{
+				{execute.Time(3), "mem", int64(4)},
+			},
+		},
+	}
+}
+
+func TestToCSV_Process(t *testing.T) {
+	ctx := filesystem.Inject(context.Background(), filesystem.SystemFS)
+	path := filepath.Join(t.TempDir(), "out.csv")
+
+	var data []flux.Table
+	for _, tbl := range toCSVTables() {
+		data = append(data, tbl)
+	}
+	executetest.ProcessTestHelper2(
+		t,
+		data,
+		toCSVTables(),
+		nil,
+		func(id execute.DatasetID, alloc memory.Allocator) (execute.Transformation, execute.Dataset) {
+			tr, d, err := csv.NewToCSVTransformation(ctx, id, &csv.ToCSVProcedureSpec{File: path})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return tr, d
+		},
+	)
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	decoder := fcsv.NewMultiResultDecoder(fcsv.ResultDecoderConfig{})
+	results, err := decoder.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer results.Release()
+	var got []*executetest.Table
+	for results.More() {
+		if err := results.Next().Tables().Do(func(tbl flux.Table) error {
+			cpy, err := executetest.ConvertTable(tbl)
+			if err != nil {
+				return err
+			}
+			got = append(got, cpy)
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := results.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := toCSVTables()
+	executetest.NormalizeTables(got)
+	executetest.NormalizeTables(want)
+	sort.Sort(executetest.SortedTables(got))
+	sort.Sort(executetest.SortedTables(want))
+	if !cmp.Equal(want, got) {
+		t.Errorf("unexpected tables in the file -want/+got:\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestToCSV_RemoveOnError(t *testing.T) {
+	ctx := filesystem.Inject(context.Background(), filesystem.SystemFS)
+	path := filepath.Join(t.TempDir(), "out.csv")
+
+	data := []flux.Table{
+		toCSVTables()[0],
+		&executetest.Table{
+			KeyCols: []string{"_measurement"},
+			ColMeta: []flux.ColMeta{
+				{Label: "_measurement", Type: flux.TString},
+			},
+			Err: errors.New(codes.Internal, "expected error"),
+		},
+	}
+	executetest.ProcessTestHelper2(
+		t,
+		data,
+		nil,
+		errors.New(codes.Internal, "expected error"),
+		func(id execute.DatasetID, alloc memory.Allocator) (execute.Transformation, execute.Dataset) {
+			tr, d, err := csv.NewToCSVTransformation(ctx, id, &csv.ToCSVProcedureSpec{File: path})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return tr, d
+		},
+	)
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected the partial file to be removed, got error %v", err)
+	}
+}
+
+func TestToCSV_Overwrite(t *testing.T) {
+	ctx := filesystem.Inject(context.Background(), filesystem.SystemFS)
+	path := filepath.Join(t.TempDir(), "out.csv")
+	if err := os.WriteFile(path, []byte("existing"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := csv.NewToCSVTransformation(ctx, executetest.RandomDatasetID(), &csv.ToCSVProcedureSpec{File: path}); err == nil {
+		t.Fatal("expected an error for an existing file, got none")
+	} else if !strings.Contains(err.Error(), "failed to create file") {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if data, err := os.ReadFile(path); err != nil {
+		t.Fatal(err)
+	} else if string(data) != "existing" {
+		t.Errorf("expected the existing file to be kept, got %q", data)
+	}
+
+	tr, _, err := csv.NewToCSVTransformation(ctx, executetest.RandomDatasetID(), &csv.ToCSVProcedureSpec{File: path, Overwrite: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr.Finish(executetest.RandomDatasetID(), nil)
+	if data, err := os.ReadFile(path); err != nil {
+		t.Fatal(err)
+	} else if strings.Contains(string(data), "existing") {
+		t.Errorf("expected the file to be replaced, got %q", data)
+	}
+}
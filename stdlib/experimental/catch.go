@@ -0,0 +1,289 @@
+package experimental
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/interpreter"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/metadata"
+	"github.com/influxdata/flux/plan"
+	"github.com/influxdata/flux/runtime"
+	"github.com/influxdata/flux/semantic"
+)
+
+const CatchKind = "experimental.catch"
+
+// WarningsKey is the metadata key of the errors that were
+// caught by catch and did not fail the query.
+const WarningsKey = "flux/warnings"
+
+// DefaultCatchCodes are the error codes that catch
+// catches when no codes are specified.
+var DefaultCatchCodes = []codes.Code{codes.NotFound, codes.Unavailable}
+
+func init() {
+	catchSignature := runtime.MustLookupBuiltinType("experimental", "catch")
+	runtime.RegisterPackageValue("experimental", "catch", flux.MustValue(flux.FunctionValue("catch", createCatchOpSpec, catchSignature)))
+	flux.RegisterOpSpec(CatchKind, newCatchOp)
+	plan.RegisterProcedureSpec(CatchKind, newCatchProcedure, CatchKind)
+	execute.RegisterTransformation(CatchKind, createCatchTransformation)
+}
+
+type CatchOpSpec struct {
+	Codes []codes.Code `json:"codes"`
+}
+
+func createCatchOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
+	if err := a.AddParentFromArgs(args); err != nil {
+		return nil, err
+	}
+
+	spec := &CatchOpSpec{
+		Codes: DefaultCatchCodes,
+	}
+	if v, ok := args.Get("fallback"); ok {
+		fallback, ok := v.(*flux.TableObject)
+		if !ok {
+			return nil, errors.New(codes.Invalid, "argument 'fallback' must be a table stream")
+		}
+		a.AddParent(fallback)
+	}
+
+	if arr, ok, err := args.GetArray("codes", semantic.String); err != nil {
+		return nil, err
+	} else if ok {
+		names, err := interpreter.ToStringArray(arr)
+		if err != nil {
+			return nil, err
+		}
+		spec.Codes = make([]codes.Code, len(names))
+		for i, name := range names {
+			if err := spec.Codes[i].UnmarshalText([]byte(name)); err != nil || spec.Codes[i] == codes.Inherit {
+				return nil, errors.Newf(codes.Invalid, "invalid error code %q", name)
+			}
+		}
+	}
+	return spec, nil
+}
+
+func newCatchOp() flux.OperationSpec {
+	return new(CatchOpSpec)
+}
+
+func (s *CatchOpSpec) Kind() flux.OperationKind {
+	return CatchKind
+}
+
+type CatchProcedureSpec struct {
+	plan.DefaultCost
+	Codes []codes.Code
+}
+
+func newCatchProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.ProcedureSpec, error) {
+	spec, ok := qs.(*CatchOpSpec)
+	if !ok {
+		return nil, errors.Newf(codes.Internal, "invalid spec type %T", qs)
+	}
+	return &CatchProcedureSpec{
+		Codes: spec.Codes,
+	}, nil
+}
+
+func (s *CatchProcedureSpec) Kind() plan.ProcedureKind {
+	return CatchKind
+}
+
+func (s *CatchProcedureSpec) Copy() plan.ProcedureSpec {
+	ns := *s
+	ns.Codes = make([]codes.Code, len(s.Codes))
+	copy(ns.Codes, s.Codes)
+	return &ns
+}
+
+func createCatchTransformation(id execute.DatasetID, mode execute.AccumulationMode, spec plan.ProcedureSpec, a execute.Administration) (execute.Transformation, execute.Dataset, error) {
+	s, ok := spec.(*CatchProcedureSpec)
+	if !ok {
+		return nil, nil, errors.Newf(codes.Internal, "invalid spec type %T", spec)
+	}
+	cache := execute.NewTableBuilderCache(a.Allocator())
+	d := execute.NewDataset(id, mode, cache)
+	t := NewCatchTransformation(d, cache, s, a.Parents(), a.Allocator())
+	return t, d, nil
+}
+
+// catchTransformation outputs the tables of its first parent or,
+// if the first parent fails with one of the caught error codes,
+// the tables of its second parent. The tables of both parents are
+// buffered until the first parent has finished so that the tables
+// read before the error are not mixed with the fallback tables.
+type catchTransformation struct {
+	execute.ExecutionNode
+	mu sync.Mutex
+
+	d     execute.Dataset
+	cache execute.TableBuilderCache
+
+	tablesID   execute.DatasetID
+	tables     execute.TableBuilderCache
+	fallbackID execute.DatasetID
+	fallback   execute.TableBuilderCache
+	codes      []codes.Code
+
+	finished map[execute.DatasetID]bool
+	done     bool
+	caught   error
+}
+
+func NewCatchTransformation(d execute.Dataset, cache execute.TableBuilderCache, spec *CatchProcedureSpec, parents []execute.DatasetID, alloc memory.Allocator) *catchTransformation {
+	t := &catchTransformation{
+		d:        d,
+		cache:    cache,
+		tablesID: parents[0],
+		tables:   newCatchBuffer(alloc),
+		codes:    spec.Codes,
+		finished: make(map[execute.DatasetID]bool, len(parents)),
+	}
+	if len(parents) > 1 {
+		t.fallbackID = parents[1]
+		t.fallback = newCatchBuffer(alloc)
+	}
+	return t
+}
+
+// newCatchBuffer creates a cache that holds the tables of
+// a parent until they are copied to the output dataset.
+func newCatchBuffer(alloc memory.Allocator) execute.TableBuilderCache {
+	cache := execute.NewTableBuilderCache(alloc)
+	cache.SetTriggerSpec(plan.DefaultTriggerSpec)
+	return cache
+}
+
+func (t *catchTransformation) RetractTable(id execute.DatasetID, key flux.GroupKey) error {
+	panic("not implemented")
+}
+
+func (t *catchTransformation) Process(id execute.DatasetID, tbl flux.Table) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	buf := t.tables
+	if id != t.tablesID {
+		buf = t.fallback
+	} else if t.caught != nil {
+		// The remaining tables of a failed input are discarded.
+		tbl.Done()
+		return nil
+	}
+
+	if err := appendCatchTable(buf, tbl); err != nil {
+		// The error is returned unless it is caught. An error returned
+		// from Process would stop the fallback from being read.
+		if id == t.tablesID && t.catch(err) {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// UpdateWatermark is ignored because no table is
+// output until the input has been read completely.
+func (t *catchTransformation) UpdateWatermark(id execute.DatasetID, mark execute.Time) error {
+	return nil
+}
+
+// UpdateProcessingTime is ignored because no table is
+// output until the input has been read completely.
+func (t *catchTransformation) UpdateProcessingTime(id execute.DatasetID, pt execute.Time) error {
+	return nil
+}
+
+func (t *catchTransformation) Finish(id execute.DatasetID, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.done || t.finished[id] {
+		return
+	}
+	t.finished[id] = true
+
+	if err != nil && !(id == t.tablesID && t.catch(err)) {
+		t.done = true
+		t.d.Finish(err)
+		return
+	}
+
+	if !t.finished[t.tablesID] || t.fallback != nil && !t.finished[t.fallbackID] {
+		return
+	}
+	t.done = true
+
+	// The caught error is not reported to the output.
+	err = nil
+	buf := t.tables
+	if t.caught != nil {
+		buf = t.fallback
+	}
+	if buf != nil {
+		err = buf.ForEachBuilder(func(key flux.GroupKey, builder execute.TableBuilder) error {
+			tbl, err := builder.Table()
+			if err != nil {
+				return err
+			}
+			return appendCatchTable(t.cache, tbl)
+		})
+	}
+	for _, buf := range []execute.TableBuilderCache{t.tables, t.fallback} {
+		if buf != nil {
+			_ = buf.ForEachBuilder(func(key flux.GroupKey, builder execute.TableBuilder) error {
+				builder.Release()
+				return nil
+			})
+		}
+	}
+	t.d.Finish(err)
+}
+
+// catch reports whether the error has one of the caught codes
+// and records it so the fallback tables are output instead.
+func (t *catchTransformation) catch(err error) bool {
+	code := flux.ErrorCode(err)
+	for _, c := range t.codes {
+		if c == code {
+			if t.caught == nil {
+				t.caught = err
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// Metadata reports the error that was caught as a warning.
+func (t *catchTransformation) Metadata() metadata.Metadata {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	md := make(metadata.Metadata)
+	if t.caught != nil {
+		md.Add(WarningsKey, fmt.Sprintf("%s: caught error: %s", CatchKind, t.caught))
+	}
+	return md
+}
+
+// appendCatchTable appends the rows of a table to the
+// builder with its group key. Columns that are missing
+// from the builder are added to it.
+func appendCatchTable(cache execute.TableBuilderCache, tbl flux.Table) error {
+	builder, _ := cache.TableBuilder(tbl.Key())
+	colMap, err := execute.AddNewTableCols(tbl, builder, nil)
+	if err != nil {
+		return err
+	}
+	return execute.AppendMappedTable(tbl, builder, colMap)
+}
@@ -0,0 +1,350 @@
+package experimental_test
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/dependency"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/execute/executetest"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/metadata"
+	"github.com/influxdata/flux/plan"
+	"github.com/influxdata/flux/plan/plantest"
+	"github.com/influxdata/flux/querytest"
+	"github.com/influxdata/flux/stdlib/csv"
+	"github.com/influxdata/flux/stdlib/experimental"
+	"github.com/influxdata/flux/stdlib/influxdata/influxdb"
+	"github.com/influxdata/flux/stdlib/universe"
+	"go.uber.org/zap/zaptest"
+)
+
+const catchTestSourceKind = "catch-test-source"
+
+func init() {
+	execute.RegisterSource(catchTestSourceKind, createCatchTestSource)
+}
+
+// catchTestSourceProcedureSpec is a source that outputs
+// its tables and then finishes with its error.
+type catchTestSourceProcedureSpec struct {
+	plan.DefaultCost
+	Tables []*executetest.Table
+	Err    error
+}
+
+func (s *catchTestSourceProcedureSpec) Kind() plan.ProcedureKind {
+	return catchTestSourceKind
+}
+
+func (s *catchTestSourceProcedureSpec) Copy() plan.ProcedureSpec {
+	return s
+}
+
+type catchTestSource struct {
+	execute.ExecutionNode
+	id   execute.DatasetID
+	spec *catchTestSourceProcedureSpec
+	ts   []execute.Transformation
+}
+
+func createCatchTestSource(spec plan.ProcedureSpec, id execute.DatasetID, a execute.Administration) (execute.Source, error) {
+	return &catchTestSource{id: id, spec: spec.(*catchTestSourceProcedureSpec)}, nil
+}
+
+func (s *catchTestSource) AddTransformation(t execute.Transformation) {
+	s.ts = append(s.ts, t)
+}
+
+func (s *catchTestSource) Run(ctx context.Context) {
+	for _, t := range s.ts {
+		err := s.spec.Err
+		for _, tbl := range s.spec.Tables {
+			if err == nil {
+				err = t.Process(s.id, tbl)
+			}
+		}
+		t.Finish(s.id, err)
+	}
+}
+
+func TestCatch_NewQuery(t *testing.T) {
+	tests := []querytest.NewQueryTestCase{
+		{
+			Name: "default codes",
+			Raw: `import "experimental"
+from(bucket: "a") |> experimental.catch()`,
+			Want: &flux.Spec{
+				Operations: []*flux.Operation{
+					{
+						ID:   "from0",
+						Spec: &influxdb.FromOpSpec{Bucket: influxdb.NameOrID{Name: "a"}},
+					},
+					{
+						ID:   "experimental.catch1",
+						Spec: &experimental.CatchOpSpec{Codes: []codes.Code{codes.NotFound, codes.Unavailable}},
+					},
+				},
+				Edges: []flux.Edge{
+					{Parent: "from0", Child: "experimental.catch1"},
+				},
+			},
+		},
+		{
+			Name: "fallback and codes",
+			Raw: `import "experimental"
+from(bucket: "a") |> experimental.catch(fallback: from(bucket: "b"), codes: ["invalid"])`,
+			Want: &flux.Spec{
+				Operations: []*flux.Operation{
+					{
+						ID:   "from0",
+						Spec: &influxdb.FromOpSpec{Bucket: influxdb.NameOrID{Name: "a"}},
+					},
+					{
+						ID:   "from1",
+						Spec: &influxdb.FromOpSpec{Bucket: influxdb.NameOrID{Name: "b"}},
+					},
+					{
+						ID:   "experimental.catch2",
+						Spec: &experimental.CatchOpSpec{Codes: []codes.Code{codes.Invalid}},
+					},
+				},
+				Edges: []flux.Edge{
+					{Parent: "from0", Child: "experimental.catch2"},
+					{Parent: "from1", Child: "experimental.catch2"},
+				},
+			},
+		},
+		{
+			Name: "invalid code",
+			Raw: `import "experimental"
+from(bucket: "a") |> experimental.catch(codes: ["missing"])`,
+			WantErr:    true,
+			WantErrMsg: `invalid error code "missing"`,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			querytest.NewQueryTestHelper(t, tc)
+		})
+	}
+}
+
+func TestCatch_Execute(t *testing.T) {
+	healthy := `#datatype,string,long,string,dateTime:RFC3339,double
+#group,false,false,true,false,false
+#default,_result,,,,
+,result,table,_measurement,_time,_value
+,,0,healthy,2022-01-01T00:00:00Z,1
+,,0,healthy,2022-01-01T00:01:00Z,2
+`
+	cols := []flux.ColMeta{
+		{Label: "_measurement", Type: flux.TString},
+		{Label: "_time", Type: flux.TTime},
+		{Label: "_value", Type: flux.TFloat},
+	}
+	table := func(measurement string, err error) *executetest.Table {
+		return &executetest.Table{
+			KeyCols: []string{"_measurement"},
+			ColMeta: cols,
+			Data: [][]interface{}{
+				{measurement, execute.Time(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC).UnixNano()), 3.0},
+			},
+			Err: err,
+		}
+	}
+	want := func(measurements ...string) []*executetest.Table {
+		tables := []*executetest.Table{{
+			KeyCols: []string{"_measurement"},
+			ColMeta: cols,
+			Data: [][]interface{}{
+				{"healthy", execute.Time(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC).UnixNano()), 1.0},
+				{"healthy", execute.Time(time.Date(2022, 1, 1, 0, 1, 0, 0, time.UTC).UnixNano()), 2.0},
+			},
+		}}
+		for _, m := range measurements {
+			tables = append(tables, table(m, nil))
+		}
+		return tables
+	}
+
+	testCases := []struct {
+		name string
+		// sourceErr is the error that the input source finishes with
+		// and err is the error from reading the table of the input.
+		sourceErr error
+		err       error
+		// sum passes the input through a sum so that the
+		// error is reported by a transformation instead.
+		sum         bool
+		fallback    bool
+		codes       []codes.Code
+		want        []*executetest.Table
+		wantWarning string
+		wantErr     string
+	}{
+		{
+			name: "no error",
+			want: want("input"),
+		},
+		{
+			name:        "source not found",
+			sourceErr:   errors.New(codes.NotFound, "bucket not found"),
+			want:        want(),
+			wantWarning: "experimental.catch: caught error: bucket not found",
+		},
+		{
+			name:        "read unavailable with fallback",
+			err:         errors.New(codes.Unavailable, "storage unavailable"),
+			fallback:    true,
+			want:        want("fallback"),
+			wantWarning: "experimental.catch: caught error: storage unavailable",
+		},
+		{
+			name:        "error from transformation",
+			err:         errors.New(codes.NotFound, "bucket not found"),
+			sum:         true,
+			fallback:    true,
+			want:        want("fallback"),
+			wantWarning: "bucket not found",
+		},
+		{
+			name:      "code not caught",
+			sourceErr: errors.New(codes.Invalid, "bad request"),
+			fallback:  true,
+			wantErr:   "bad request",
+		},
+		{
+			name:        "configured codes",
+			err:         errors.New(codes.Invalid, "bad request"),
+			codes:       []codes.Code{codes.Invalid},
+			want:        want(),
+			wantWarning: "experimental.catch: caught error: bad request",
+		},
+		{
+			name:      "default code not configured",
+			sourceErr: errors.New(codes.NotFound, "bucket not found"),
+			codes:     []codes.Code{codes.Invalid},
+			wantErr:   "bucket not found",
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			catchCodes := tc.codes
+			if catchCodes == nil {
+				catchCodes = experimental.DefaultCatchCodes
+			}
+
+			// The failing input is followed by the optional sum and
+			// the catch, which is unioned with the healthy csv branch.
+			nodes := []plan.Node{
+				plan.CreatePhysicalNode("from-input", &catchTestSourceProcedureSpec{
+					Tables: []*executetest.Table{table("input", tc.err)},
+					Err:    tc.sourceErr,
+				}),
+			}
+			var edges [][2]int
+			input := 0
+			if tc.sum {
+				nodes = append(nodes, plan.CreatePhysicalNode("sum", &universe.SumProcedureSpec{
+					SimpleAggregateConfig: execute.DefaultSimpleAggregateConfig,
+				}))
+				edges = append(edges, [2]int{input, len(nodes) - 1})
+				input = len(nodes) - 1
+			}
+			nodes = append(nodes, plan.CreatePhysicalNode("catch", &experimental.CatchProcedureSpec{
+				Codes: catchCodes,
+			}))
+			catch := len(nodes) - 1
+			edges = append(edges, [2]int{input, catch})
+			if tc.fallback {
+				nodes = append(nodes, plan.CreatePhysicalNode("from-fallback", &catchTestSourceProcedureSpec{
+					Tables: []*executetest.Table{table("fallback", nil)},
+				}))
+				edges = append(edges, [2]int{len(nodes) - 1, catch})
+			}
+			nodes = append(nodes,
+				plan.CreatePhysicalNode("from-csv", &csv.FromCSVProcedureSpec{CSV: healthy}),
+				plan.CreatePhysicalNode("union", &universe.UnionProcedureSpec{}),
+				plan.CreatePhysicalNode("yield", executetest.NewYieldProcedureSpec("_result")),
+			)
+			union := len(nodes) - 2
+			edges = append(edges,
+				[2]int{catch, union},
+				[2]int{union - 1, union},
+				[2]int{union, union + 1},
+			)
+
+			spec := &plantest.PlanSpec{
+				Nodes: nodes,
+				Edges: edges,
+				Resources: flux.ResourceManagement{
+					ConcurrencyQuota: 1,
+					MemoryBytesQuota: math.MaxInt64,
+				},
+				Now: time.Now(),
+			}
+
+			exe := execute.NewExecutor(zaptest.NewLogger(t))
+			ctx, deps := dependency.Inject(context.Background(), executetest.NewTestExecuteDependencies())
+			defer deps.Finish()
+			results, metaCh, err := exe.Execute(ctx, plantest.CreatePlanSpec(spec), executetest.UnlimitedAllocator)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []*executetest.Table
+			for _, r := range results {
+				err = r.Tables().Do(func(tbl flux.Table) error {
+					cb, err := executetest.ConvertTable(tbl)
+					if err != nil {
+						return err
+					}
+					got = append(got, cb)
+					return nil
+				})
+			}
+			md := make(metadata.Metadata)
+			for m := range metaCh {
+				md.AddAll(m)
+			}
+
+			if tc.wantErr != "" {
+				if err == nil {
+					t.Fatalf("expected error %q, got none", tc.wantErr)
+				} else if !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("unexpected error -want/+got:\n\t- %s\n\t+ %s", tc.wantErr, err)
+				}
+				return
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			executetest.NormalizeTables(got)
+			executetest.NormalizeTables(tc.want)
+			if !cmp.Equal(tc.want, got) {
+				t.Errorf("unexpected tables -want/+got\n%s", cmp.Diff(tc.want, got))
+			}
+
+			warnings := md[experimental.WarningsKey]
+			if tc.wantWarning == "" {
+				if len(warnings) != 0 {
+					t.Errorf("unexpected warnings: %v", warnings)
+				}
+			} else if len(warnings) != 1 {
+				t.Errorf("expected one warning, got %v", warnings)
+			} else if got := warnings[0].(string); !strings.Contains(got, tc.wantWarning) {
+				t.Errorf("unexpected warning -want/+got:\n\t- %s\n\t+ %s", tc.wantWarning, got)
+			}
+		})
+	}
+}
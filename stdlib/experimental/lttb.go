@@ -0,0 +1,265 @@
+package experimental
+
+import (
+	"math"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/execute/table"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/plan"
+	"github.com/influxdata/flux/runtime"
+)
+
+const LTTBKind = "experimental.lttb"
+
+// LTTBOpSpec downsamples each table to n points using the
+// Largest-Triangle-Three-Buckets algorithm.
+type LTTBOpSpec struct {
+	N int64 `json:"n"`
+}
+
+func init() {
+	lttbSignature := runtime.MustLookupBuiltinType("experimental", "lttb")
+	runtime.RegisterPackageValue("experimental", "lttb", flux.MustValue(flux.FunctionValue("lttb", createLTTBOpSpec, lttbSignature)))
+	flux.RegisterOpSpec(LTTBKind, newLTTBOp)
+	plan.RegisterProcedureSpec(LTTBKind, newLTTBProcedure, LTTBKind)
+	execute.RegisterTransformation(LTTBKind, createLTTBTransformation)
+}
+
+func createLTTBOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
+	if err := a.AddParentFromArgs(args); err != nil {
+		return nil, err
+	}
+
+	spec := new(LTTBOpSpec)
+	n, err := args.GetRequiredInt("n")
+	if err != nil {
+		return nil, err
+	} else if n < 3 {
+		return nil, errors.Newf(codes.Invalid, "n must be at least 3, but was %d", n)
+	}
+	spec.N = n
+	return spec, nil
+}
+
+func newLTTBOp() flux.OperationSpec {
+	return new(LTTBOpSpec)
+}
+
+func (s *LTTBOpSpec) Kind() flux.OperationKind {
+	return LTTBKind
+}
+
+type LTTBProcedureSpec struct {
+	plan.DefaultCost
+	N int64
+}
+
+func newLTTBProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.ProcedureSpec, error) {
+	spec, ok := qs.(*LTTBOpSpec)
+	if !ok {
+		return nil, errors.Newf(codes.Internal, "invalid spec type %T", qs)
+	}
+	return &LTTBProcedureSpec{N: spec.N}, nil
+}
+
+func (s *LTTBProcedureSpec) Kind() plan.ProcedureKind {
+	return LTTBKind
+}
+
+func (s *LTTBProcedureSpec) Copy() plan.ProcedureSpec {
+	ns := new(LTTBProcedureSpec)
+	*ns = *s
+	return ns
+}
+
+// TriggerSpec implements plan.TriggerAwareProcedureSpec
+func (s *LTTBProcedureSpec) TriggerSpec() plan.TriggerSpec {
+	return plan.NarrowTransformationTriggerSpec{}
+}
+
+func createLTTBTransformation(id execute.DatasetID, mode execute.AccumulationMode, spec plan.ProcedureSpec, a execute.Administration) (execute.Transformation, execute.Dataset, error) {
+	s, ok := spec.(*LTTBProcedureSpec)
+	if !ok {
+		return nil, nil, errors.Newf(codes.Internal, "invalid spec type %T", spec)
+	}
+	cache := execute.NewTableBuilderCache(a.Allocator())
+	d := execute.NewDataset(id, mode, cache)
+	t := NewLTTBTransformation(d, cache, s, a.Allocator())
+	return t, d, nil
+}
+
+type lttbTransformation struct {
+	execute.ExecutionNode
+	d     execute.Dataset
+	cache execute.TableBuilderCache
+	alloc memory.Allocator
+
+	n int
+}
+
+func NewLTTBTransformation(d execute.Dataset, cache execute.TableBuilderCache, spec *LTTBProcedureSpec, alloc memory.Allocator) *lttbTransformation {
+	return &lttbTransformation{
+		d:     d,
+		cache: cache,
+		alloc: alloc,
+		n:     int(spec.N),
+	}
+}
+
+func (t *lttbTransformation) RetractTable(id execute.DatasetID, key flux.GroupKey) error {
+	return t.d.RetractTable(key)
+}
+
+func (t *lttbTransformation) Process(id execute.DatasetID, tbl flux.Table) error {
+	builder, created := t.cache.TableBuilder(tbl.Key())
+	if !created {
+		return errors.Newf(codes.FailedPrecondition, "lttb found duplicate table with key: %v", tbl.Key())
+	}
+	if err := execute.AddTableCols(tbl, builder); err != nil {
+		return err
+	}
+
+	timeIdx := execute.ColIdx(execute.DefaultTimeColLabel, tbl.Cols())
+	if timeIdx < 0 {
+		return errors.Newf(codes.FailedPrecondition, "column %q does not exist", execute.DefaultTimeColLabel)
+	} else if typ := tbl.Cols()[timeIdx].Type; typ != flux.TTime {
+		return errors.Newf(codes.FailedPrecondition, "column %q must be of type %s, got %s", execute.DefaultTimeColLabel, flux.TTime, typ)
+	}
+	valueIdx := execute.ColIdx(execute.DefaultValueColLabel, tbl.Cols())
+	if valueIdx < 0 {
+		return errors.Newf(codes.FailedPrecondition, "column %q does not exist", execute.DefaultValueColLabel)
+	}
+	switch typ := tbl.Cols()[valueIdx].Type; typ {
+	case flux.TInt, flux.TUInt, flux.TFloat:
+	default:
+		return errors.Newf(codes.FailedPrecondition, "column %q must be numeric, got %s", execute.DefaultValueColLabel, typ)
+	}
+
+	// The algorithm needs random access to the whole table
+	// so read it into a single buffer first.
+	buffer := execute.NewColListTableBuilder(tbl.Key(), t.alloc)
+	if err := execute.AddTableCols(tbl, buffer); err != nil {
+		return err
+	}
+	if err := execute.AppendTable(tbl, buffer); err != nil {
+		return err
+	}
+	buffered, err := buffer.Table()
+	if err != nil {
+		return err
+	}
+	return buffered.Do(func(cr flux.ColReader) error {
+		// Collect the rows that have both a time and a value.
+		// Rows with nulls are skipped.
+		var (
+			rows   []int
+			xs, ys []float64
+		)
+		times, vs := cr.Times(timeIdx), table.Values(cr, valueIdx)
+		var first, prev int64
+		for i, l := 0, cr.Len(); i < l; i++ {
+			if times.IsNull(i) || vs.IsNull(i) {
+				continue
+			}
+			ts := times.Value(i)
+			if len(rows) == 0 {
+				first = ts
+			} else if ts < prev {
+				return errors.New(codes.FailedPrecondition, "lttb requires each table to be sorted by _time")
+			}
+			prev = ts
+
+			var y float64
+			switch cr.Cols()[valueIdx].Type {
+			case flux.TInt:
+				y = float64(cr.Ints(valueIdx).Value(i))
+			case flux.TUInt:
+				y = float64(cr.UInts(valueIdx).Value(i))
+			case flux.TFloat:
+				y = cr.Floats(valueIdx).Value(i)
+			}
+			rows = append(rows, i)
+			// Use the offset from the first point so the
+			// times do not lose precision as floats.
+			xs = append(xs, float64(ts-first))
+			ys = append(ys, y)
+		}
+
+		for _, i := range largestTriangleThreeBuckets(xs, ys, t.n) {
+			if err := execute.AppendRecord(rows[i], cr, builder); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// largestTriangleThreeBuckets returns the indices of the n points that
+// best preserve the visual shape of the series. The first and last
+// points are always selected. If there are n or fewer points, all of
+// them are selected.
+func largestTriangleThreeBuckets(xs, ys []float64, n int) []int {
+	l := len(xs)
+	if l <= n {
+		selected := make([]int, l)
+		for i := range selected {
+			selected[i] = i
+		}
+		return selected
+	}
+
+	selected := make([]int, 0, n)
+	selected = append(selected, 0)
+
+	// The first and last points are their own buckets so
+	// the remaining points are divided into n-2 buckets.
+	every := float64(l-2) / float64(n-2)
+	a := 0
+	for i := 0; i < n-2; i++ {
+		// Compute the average point of the next bucket.
+		avgStart := int(math.Floor(float64(i+1)*every)) + 1
+		avgEnd := int(math.Floor(float64(i+2)*every)) + 1
+		if avgEnd > l {
+			avgEnd = l
+		}
+		var avgX, avgY float64
+		for j := avgStart; j < avgEnd; j++ {
+			avgX += xs[j]
+			avgY += ys[j]
+		}
+		avgX /= float64(avgEnd - avgStart)
+		avgY /= float64(avgEnd - avgStart)
+
+		// Choose the point in this bucket that forms the largest
+		// triangle with the previously selected point and the
+		// average of the next bucket.
+		start := int(math.Floor(float64(i)*every)) + 1
+		end := int(math.Floor(float64(i+1)*every)) + 1
+		maxArea, next := -1.0, start
+		for j := start; j < end; j++ {
+			area := math.Abs((xs[a]-avgX)*(ys[j]-ys[a]) - (xs[a]-xs[j])*(avgY-ys[a]))
+			if area > maxArea {
+				maxArea, next = area, j
+			}
+		}
+		selected = append(selected, next)
+		a = next
+	}
+	return append(selected, l-1)
+}
+
+func (t *lttbTransformation) UpdateWatermark(id execute.DatasetID, mark execute.Time) error {
+	return t.d.UpdateWatermark(mark)
+}
+
+func (t *lttbTransformation) UpdateProcessingTime(id execute.DatasetID, pt execute.Time) error {
+	return t.d.UpdateProcessingTime(pt)
+}
+
+func (t *lttbTransformation) Finish(id execute.DatasetID, err error) {
+	t.d.Finish(err)
+}
@@ -0,0 +1,217 @@
+package experimental_test
+
+import (
+	"testing"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/execute/executetest"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/stdlib/experimental"
+)
+
+func TestLTTB_Process(t *testing.T) {
+	cols := []flux.ColMeta{
+		{Label: "_time", Type: flux.TTime},
+		{Label: "_value", Type: flux.TFloat},
+		{Label: "t0", Type: flux.TString},
+	}
+	testCases := []struct {
+		name    string
+		spec    *experimental.LTTBProcedureSpec
+		data    []flux.Table
+		want    []*executetest.Table
+		wantErr error
+	}{
+		{
+			name: "keeps spike",
+			spec: &experimental.LTTBProcedureSpec{N: 4},
+			data: []flux.Table{&executetest.Table{
+				KeyCols: []string{"t0"},
+				ColMeta: cols,
+				Data: [][]interface{}{
+					{execute.Time(1), 0.0, "a"},
+					{execute.Time(2), 0.0, "a"},
+					{execute.Time(3), 10.0, "a"},
+					{execute.Time(4), 0.0, "a"},
+					{execute.Time(5), 0.0, "a"},
+					{execute.Time(6), 0.0, "a"},
+				},
+			}},
+			want: []*executetest.Table{{
+				KeyCols: []string{"t0"},
+				ColMeta: cols,
+				Data: [][]interface{}{
+					{execute.Time(1), 0.0, "a"},
+					{execute.Time(3), 10.0, "a"},
+					{execute.Time(4), 0.0, "a"},
+					{execute.Time(6), 0.0, "a"},
+				},
+			}},
+		},
+		{
+			name: "integers",
+			spec: &experimental.LTTBProcedureSpec{N: 3},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TInt},
+				},
+				Data: [][]interface{}{
+					{execute.Time(1), int64(5)},
+					{execute.Time(2), int64(6)},
+					{execute.Time(3), int64(-20)},
+					{execute.Time(4), int64(4)},
+					{execute.Time(5), int64(5)},
+				},
+			}},
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TInt},
+				},
+				Data: [][]interface{}{
+					{execute.Time(1), int64(5)},
+					{execute.Time(3), int64(-20)},
+					{execute.Time(5), int64(5)},
+				},
+			}},
+		},
+		{
+			name: "fewer points than n",
+			spec: &experimental.LTTBProcedureSpec{N: 5},
+			data: []flux.Table{&executetest.Table{
+				KeyCols: []string{"t0"},
+				ColMeta: cols,
+				Data: [][]interface{}{
+					{execute.Time(1), 1.0, "a"},
+					{execute.Time(2), 2.0, "a"},
+					{execute.Time(3), 3.0, "a"},
+				},
+			}},
+			want: []*executetest.Table{{
+				KeyCols: []string{"t0"},
+				ColMeta: cols,
+				Data: [][]interface{}{
+					{execute.Time(1), 1.0, "a"},
+					{execute.Time(2), 2.0, "a"},
+					{execute.Time(3), 3.0, "a"},
+				},
+			}},
+		},
+		{
+			name: "skips nulls",
+			spec: &experimental.LTTBProcedureSpec{N: 3},
+			data: []flux.Table{&executetest.Table{
+				KeyCols: []string{"t0"},
+				ColMeta: cols,
+				Data: [][]interface{}{
+					{execute.Time(1), 1.0, "a"},
+					{execute.Time(2), nil, "a"},
+					{nil, 7.0, "a"},
+					{execute.Time(4), 2.0, "a"},
+					{execute.Time(5), 3.0, "a"},
+				},
+			}},
+			want: []*executetest.Table{{
+				KeyCols: []string{"t0"},
+				ColMeta: cols,
+				Data: [][]interface{}{
+					{execute.Time(1), 1.0, "a"},
+					{execute.Time(4), 2.0, "a"},
+					{execute.Time(5), 3.0, "a"},
+				},
+			}},
+		},
+		{
+			name: "multiple tables",
+			spec: &experimental.LTTBProcedureSpec{N: 3},
+			data: []flux.Table{
+				&executetest.Table{
+					KeyCols: []string{"t0"},
+					ColMeta: cols,
+					Data: [][]interface{}{
+						{execute.Time(1), 1.0, "a"},
+						{execute.Time(2), 9.0, "a"},
+						{execute.Time(3), 1.0, "a"},
+						{execute.Time(4), 1.0, "a"},
+					},
+				},
+				&executetest.Table{
+					KeyCols: []string{"t0"},
+					ColMeta: cols,
+					Data: [][]interface{}{
+						{execute.Time(1), 1.0, "b"},
+						{execute.Time(2), 1.0, "b"},
+						{execute.Time(3), -9.0, "b"},
+						{execute.Time(4), 1.0, "b"},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: cols,
+					Data: [][]interface{}{
+						{execute.Time(1), 1.0, "a"},
+						{execute.Time(2), 9.0, "a"},
+						{execute.Time(4), 1.0, "a"},
+					},
+				},
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: cols,
+					Data: [][]interface{}{
+						{execute.Time(1), 1.0, "b"},
+						{execute.Time(3), -9.0, "b"},
+						{execute.Time(4), 1.0, "b"},
+					},
+				},
+			},
+		},
+		{
+			name: "unsorted",
+			spec: &experimental.LTTBProcedureSpec{N: 3},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: cols,
+				Data: [][]interface{}{
+					{execute.Time(1), 1.0, "a"},
+					{execute.Time(3), 2.0, "a"},
+					{execute.Time(2), 3.0, "a"},
+					{execute.Time(4), 4.0, "a"},
+				},
+			}},
+			wantErr: errors.New(codes.FailedPrecondition, "lttb requires each table to be sorted by _time"),
+		},
+		{
+			name: "string values",
+			spec: &experimental.LTTBProcedureSpec{N: 3},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TString},
+				},
+				Data: [][]interface{}{
+					{execute.Time(1), "a"},
+				},
+			}},
+			wantErr: errors.New(codes.FailedPrecondition, `column "_value" must be numeric, got string`),
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			executetest.ProcessTestHelper(
+				t,
+				tc.data,
+				tc.want,
+				tc.wantErr,
+				func(d execute.Dataset, c execute.TableBuilderCache) execute.Transformation {
+					return experimental.NewLTTBTransformation(d, c, tc.spec, memory.DefaultAllocator)
+				},
+			)
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package table
+
+import (
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/plan"
+	"github.com/influxdata/flux/runtime"
+)
+
+const HasColumnKind = pkgpath + ".hasColumn"
+
+type HasColumnOpSpec struct {
+	Column string `json:"column"`
+}
+
+func init() {
+	hasColumnSignature := runtime.MustLookupBuiltinType(pkgpath, "hasColumn")
+
+	runtime.RegisterPackageValue(pkgpath, "hasColumn", flux.MustValue(flux.FunctionValue(HasColumnKind, createHasColumnOpSpec, hasColumnSignature)))
+	plan.RegisterProcedureSpec(HasColumnKind, newHasColumnProcedure, HasColumnKind)
+	execute.RegisterTransformation(HasColumnKind, createHasColumnTransformation)
+}
+
+func createHasColumnOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
+	if err := a.AddParentFromArgs(args); err != nil {
+		return nil, err
+	}
+
+	column, err := args.GetRequiredString("column")
+	if err != nil {
+		return nil, err
+	}
+	return &HasColumnOpSpec{Column: column}, nil
+}
+
+func (s *HasColumnOpSpec) Kind() flux.OperationKind {
+	return HasColumnKind
+}
+
+type HasColumnProcedureSpec struct {
+	plan.DefaultCost
+	Column string
+}
+
+func newHasColumnProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.ProcedureSpec, error) {
+	spec, ok := qs.(*HasColumnOpSpec)
+	if !ok {
+		return nil, errors.Newf(codes.Internal, "invalid spec type %T", qs)
+	}
+	return &HasColumnProcedureSpec{Column: spec.Column}, nil
+}
+
+func (s *HasColumnProcedureSpec) Kind() plan.ProcedureKind {
+	return HasColumnKind
+}
+
+func (s *HasColumnProcedureSpec) Copy() plan.ProcedureSpec {
+	ns := *s
+	return &ns
+}
+
+func createHasColumnTransformation(id execute.DatasetID, mode execute.AccumulationMode, spec plan.ProcedureSpec, a execute.Administration) (execute.Transformation, execute.Dataset, error) {
+	s, ok := spec.(*HasColumnProcedureSpec)
+	if !ok {
+		return nil, nil, errors.Newf(codes.Internal, "invalid spec type %T", spec)
+	}
+	return NewHasColumnTransformation(id, s)
+}
+
+type hasColumnTransformation struct {
+	execute.ExecutionNode
+	d      *execute.PassthroughDataset
+	column string
+}
+
+func NewHasColumnTransformation(id execute.DatasetID, spec *HasColumnProcedureSpec) (execute.Transformation, execute.Dataset, error) {
+	t := &hasColumnTransformation{
+		d:      execute.NewPassthroughDataset(id),
+		column: spec.Column,
+	}
+	return t, t.d, nil
+}
+
+// Process passes the table through when its schema has the column
+// and drops it otherwise.
+func (t *hasColumnTransformation) Process(id execute.DatasetID, tbl flux.Table) error {
+	if !execute.HasCol(t.column, tbl.Cols()) {
+		tbl.Done()
+		return nil
+	}
+	return t.d.Process(tbl)
+}
+
+func (t *hasColumnTransformation) UpdateWatermark(id execute.DatasetID, mark execute.Time) error {
+	return t.d.UpdateWatermark(mark)
+}
+
+func (t *hasColumnTransformation) UpdateProcessingTime(id execute.DatasetID, ts execute.Time) error {
+	return t.d.UpdateProcessingTime(ts)
+}
+
+func (t *hasColumnTransformation) RetractTable(id execute.DatasetID, key flux.GroupKey) error {
+	return t.d.RetractTable(key)
+}
+
+func (t *hasColumnTransformation) Finish(id execute.DatasetID, err error) {
+	t.d.Finish(err)
+}
@@ -0,0 +1,111 @@
+package table_test
+
+import (
+	"testing"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/execute/executetest"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/stdlib/experimental/table"
+)
+
+func TestHasColumn_Process(t *testing.T) {
+	data := []flux.Table{
+		&executetest.Table{
+			KeyCols: []string{"host"},
+			ColMeta: []flux.ColMeta{
+				{Label: "host", Type: flux.TString},
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_value", Type: flux.TFloat},
+			},
+			Data: [][]interface{}{
+				{"a", execute.Time(1), 1.0},
+			},
+		},
+		&executetest.Table{
+			KeyCols: []string{"host", "region"},
+			ColMeta: []flux.ColMeta{
+				{Label: "host", Type: flux.TString},
+				{Label: "region", Type: flux.TString},
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_value", Type: flux.TFloat},
+			},
+			Data: [][]interface{}{
+				{"b", "east", execute.Time(1), 2.0},
+			},
+		},
+		&executetest.Table{
+			KeyCols: []string{"host"},
+			ColMeta: []flux.ColMeta{
+				{Label: "host", Type: flux.TString},
+				{Label: "region", Type: flux.TString},
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_value", Type: flux.TFloat},
+			},
+			Data: [][]interface{}{
+				{"c", nil, execute.Time(1), 3.0},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name   string
+		column string
+		want   []*executetest.Table
+	}{
+		{
+			name:   "column in some tables",
+			column: "region",
+			want: []*executetest.Table{
+				{
+					KeyCols: []string{"host", "region"},
+					ColMeta: []flux.ColMeta{
+						{Label: "host", Type: flux.TString},
+						{Label: "region", Type: flux.TString},
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{"b", "east", execute.Time(1), 2.0},
+					},
+				},
+				{
+					KeyCols: []string{"host"},
+					ColMeta: []flux.ColMeta{
+						{Label: "host", Type: flux.TString},
+						{Label: "region", Type: flux.TString},
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{"c", nil, execute.Time(1), 3.0},
+					},
+				},
+			},
+		},
+		{
+			name:   "column in no tables",
+			column: "zone",
+			want:   []*executetest.Table(nil),
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			executetest.ProcessTestHelper2(
+				t,
+				data,
+				tc.want,
+				nil,
+				func(id execute.DatasetID, alloc memory.Allocator) (execute.Transformation, execute.Dataset) {
+					tx, d, err := table.NewHasColumnTransformation(id, &table.HasColumnProcedureSpec{Column: tc.column})
+					if err != nil {
+						t.Fatal(err)
+					}
+					return tx, d
+				},
+			)
+		})
+	}
+}
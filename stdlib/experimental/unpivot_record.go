@@ -0,0 +1,359 @@
+package experimental
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/plan"
+	"github.com/influxdata/flux/runtime"
+)
+
+const UnpivotRecordKind = "experimental.unpivotRecord"
+
+// UnpivotRecordOpSpec expands the JSON object held by a column
+// into a row for each of its fields.
+type UnpivotRecordOpSpec struct {
+	Column      string `json:"column"`
+	KeyColumn   string `json:"keyColumn"`
+	ValueColumn string `json:"valueColumn"`
+}
+
+func init() {
+	unpivotRecordSignature := runtime.MustLookupBuiltinType("experimental", "unpivotRecord")
+	runtime.RegisterPackageValue("experimental", "unpivotRecord", flux.MustValue(flux.FunctionValue("unpivotRecord", createUnpivotRecordOpSpec, unpivotRecordSignature)))
+	flux.RegisterOpSpec(UnpivotRecordKind, newUnpivotRecordOp)
+	plan.RegisterProcedureSpec(UnpivotRecordKind, newUnpivotRecordProcedure, UnpivotRecordKind)
+	execute.RegisterTransformation(UnpivotRecordKind, createUnpivotRecordTransformation)
+}
+
+func createUnpivotRecordOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
+	if err := a.AddParentFromArgs(args); err != nil {
+		return nil, err
+	}
+
+	spec := &UnpivotRecordOpSpec{
+		KeyColumn:   "_key",
+		ValueColumn: execute.DefaultValueColLabel,
+	}
+	column, err := args.GetRequiredString("column")
+	if err != nil {
+		return nil, err
+	}
+	spec.Column = column
+	if keyColumn, ok, err := args.GetString("keyColumn"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.KeyColumn = keyColumn
+	}
+	if valueColumn, ok, err := args.GetString("valueColumn"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.ValueColumn = valueColumn
+	}
+	if spec.KeyColumn == spec.ValueColumn {
+		return nil, errors.Newf(codes.Invalid, "keyColumn and valueColumn must be different, both are %q", spec.KeyColumn)
+	}
+	return spec, nil
+}
+
+func newUnpivotRecordOp() flux.OperationSpec {
+	return new(UnpivotRecordOpSpec)
+}
+
+func (s *UnpivotRecordOpSpec) Kind() flux.OperationKind {
+	return UnpivotRecordKind
+}
+
+type UnpivotRecordProcedureSpec struct {
+	plan.DefaultCost
+	Column      string
+	KeyColumn   string
+	ValueColumn string
+}
+
+func newUnpivotRecordProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.ProcedureSpec, error) {
+	spec, ok := qs.(*UnpivotRecordOpSpec)
+	if !ok {
+		return nil, errors.Newf(codes.Internal, "invalid spec type %T", qs)
+	}
+	return &UnpivotRecordProcedureSpec{
+		Column:      spec.Column,
+		KeyColumn:   spec.KeyColumn,
+		ValueColumn: spec.ValueColumn,
+	}, nil
+}
+
+func (s *UnpivotRecordProcedureSpec) Kind() plan.ProcedureKind {
+	return UnpivotRecordKind
+}
+
+func (s *UnpivotRecordProcedureSpec) Copy() plan.ProcedureSpec {
+	ns := new(UnpivotRecordProcedureSpec)
+	*ns = *s
+	return ns
+}
+
+// TriggerSpec implements plan.TriggerAwareProcedureSpec
+func (s *UnpivotRecordProcedureSpec) TriggerSpec() plan.TriggerSpec {
+	return plan.NarrowTransformationTriggerSpec{}
+}
+
+func createUnpivotRecordTransformation(id execute.DatasetID, mode execute.AccumulationMode, spec plan.ProcedureSpec, a execute.Administration) (execute.Transformation, execute.Dataset, error) {
+	s, ok := spec.(*UnpivotRecordProcedureSpec)
+	if !ok {
+		return nil, nil, errors.Newf(codes.Internal, "invalid spec type %T", spec)
+	}
+	cache := execute.NewTableBuilderCache(a.Allocator())
+	d := execute.NewDataset(id, mode, cache)
+	t := NewUnpivotRecordTransformation(d, cache, s, a.Allocator())
+	return t, d, nil
+}
+
+type unpivotRecordTransformation struct {
+	execute.ExecutionNode
+	d     execute.Dataset
+	cache execute.TableBuilderCache
+	alloc memory.Allocator
+
+	column, keyColumn, valueColumn string
+}
+
+func NewUnpivotRecordTransformation(d execute.Dataset, cache execute.TableBuilderCache, spec *UnpivotRecordProcedureSpec, alloc memory.Allocator) *unpivotRecordTransformation {
+	return &unpivotRecordTransformation{
+		d:           d,
+		cache:       cache,
+		alloc:       alloc,
+		column:      spec.Column,
+		keyColumn:   spec.KeyColumn,
+		valueColumn: spec.ValueColumn,
+	}
+}
+
+func (t *unpivotRecordTransformation) RetractTable(id execute.DatasetID, key flux.GroupKey) error {
+	return t.d.RetractTable(key)
+}
+
+// recordField is a field of a record and the row that holds the record.
+type recordField struct {
+	row   int
+	key   string
+	value interface{}
+}
+
+func (t *unpivotRecordTransformation) Process(id execute.DatasetID, tbl flux.Table) error {
+	builder, created := t.cache.TableBuilder(tbl.Key())
+	if !created {
+		return errors.Newf(codes.FailedPrecondition, "unpivotRecord found duplicate table with key: %v", tbl.Key())
+	}
+
+	recordIdx := execute.ColIdx(t.column, tbl.Cols())
+	if recordIdx < 0 {
+		return errors.Newf(codes.FailedPrecondition, "column %q does not exist", t.column)
+	} else if typ := tbl.Cols()[recordIdx].Type; typ != flux.TString {
+		return errors.Newf(codes.FailedPrecondition, "column %q must be of type %s, got %s", t.column, flux.TString, typ)
+	}
+	for _, label := range []string{t.column, t.keyColumn, t.valueColumn} {
+		if tbl.Key().HasCol(label) {
+			return errors.Newf(codes.FailedPrecondition, "column %q is part of the group key", label)
+		}
+	}
+
+	// The other columns are copied to each row of a record.
+	// Columns with the same label as the key or value column are replaced.
+	var cols []int
+	for j, c := range tbl.Cols() {
+		if c.Label == t.column || c.Label == t.keyColumn || c.Label == t.valueColumn {
+			continue
+		}
+		if _, err := builder.AddCol(c); err != nil {
+			return err
+		}
+		cols = append(cols, j)
+	}
+
+	// The type of the value column depends on all of the values
+	// of the table so read it into a single buffer first.
+	buffer := execute.NewColListTableBuilder(tbl.Key(), t.alloc)
+	if err := execute.AddTableCols(tbl, buffer); err != nil {
+		return err
+	}
+	if err := execute.AppendTable(tbl, buffer); err != nil {
+		return err
+	}
+	buffered, err := buffer.Table()
+	if err != nil {
+		return err
+	}
+	if err := buffered.Do(func(cr flux.ColReader) error {
+		var fields []recordField
+		records := cr.Strings(recordIdx)
+		for i, l := 0, cr.Len(); i < l; i++ {
+			if records.IsNull(i) {
+				continue
+			}
+			record, err := decodeRecord(records.Value(i))
+			if err != nil {
+				return errors.Wrapf(err, codes.Invalid, "column %q", t.column)
+			}
+			keys := make([]string, 0, len(record))
+			for k := range record {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				fields = append(fields, recordField{row: i, key: k, value: record[k]})
+			}
+		}
+
+		typ := recordValueType(fields)
+		keyIdx, err := builder.AddCol(flux.ColMeta{Label: t.keyColumn, Type: flux.TString})
+		if err != nil {
+			return err
+		}
+		valueIdx, err := builder.AddCol(flux.ColMeta{Label: t.valueColumn, Type: typ})
+		if err != nil {
+			return err
+		}
+		for _, f := range fields {
+			for k, j := range cols {
+				if err := builder.AppendValue(k, execute.ValueForRow(cr, f.row, j)); err != nil {
+					return err
+				}
+			}
+			if err := builder.AppendString(keyIdx, f.key); err != nil {
+				return err
+			}
+			if err := appendRecordValue(builder, valueIdx, typ, f.value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	// An empty table has no values so its value column is a string.
+	if execute.ColIdx(t.keyColumn, builder.Cols()) < 0 {
+		if _, err := builder.AddCol(flux.ColMeta{Label: t.keyColumn, Type: flux.TString}); err != nil {
+			return err
+		}
+		if _, err := builder.AddCol(flux.ColMeta{Label: t.valueColumn, Type: flux.TString}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeRecord decodes a JSON object. A JSON null decodes to a nil record.
+// Numbers are kept as json.Number so integers can be told apart from floats.
+func decodeRecord(s string) (map[string]interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader([]byte(s)))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, errors.Wrap(err, codes.Invalid, "invalid JSON object")
+	}
+	if v == nil {
+		return nil, nil
+	}
+	record, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, errors.Newf(codes.Invalid, "expected a JSON object, got %s", s)
+	}
+	return record, nil
+}
+
+// recordValueType returns the type that all of the values of the fields
+// can be represented with. Integers and floats are represented as floats
+// and any other mix of types, arrays and objects as strings.
+func recordValueType(fields []recordField) flux.ColType {
+	typ := flux.TInvalid
+	for _, f := range fields {
+		var ft flux.ColType
+		switch v := f.value.(type) {
+		case nil:
+			continue
+		case bool:
+			ft = flux.TBool
+		case json.Number:
+			if _, err := v.Int64(); err == nil {
+				ft = flux.TInt
+			} else {
+				ft = flux.TFloat
+			}
+		default:
+			ft = flux.TString
+		}
+
+		switch {
+		case typ == flux.TInvalid || typ == ft:
+			typ = ft
+		case (typ == flux.TInt && ft == flux.TFloat) || (typ == flux.TFloat && ft == flux.TInt):
+			typ = flux.TFloat
+		default:
+			return flux.TString
+		}
+	}
+	if typ == flux.TInvalid {
+		return flux.TString
+	}
+	return typ
+}
+
+// appendRecordValue appends the value of a field to column j,
+// which has the type returned by recordValueType.
+func appendRecordValue(builder execute.TableBuilder, j int, typ flux.ColType, v interface{}) error {
+	if v == nil {
+		return builder.AppendNil(j)
+	}
+	switch typ {
+	case flux.TBool:
+		return builder.AppendBool(j, v.(bool))
+	case flux.TInt:
+		n, err := v.(json.Number).Int64()
+		if err != nil {
+			return err
+		}
+		return builder.AppendInt(j, n)
+	case flux.TFloat:
+		n, err := v.(json.Number).Float64()
+		if err != nil {
+			return err
+		}
+		return builder.AppendFloat(j, n)
+	}
+
+	switch v := v.(type) {
+	case string:
+		return builder.AppendString(j, v)
+	case bool:
+		return builder.AppendString(j, strconv.FormatBool(v))
+	case json.Number:
+		return builder.AppendString(j, v.String())
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		return builder.AppendString(j, string(b))
+	}
+}
+
+func (t *unpivotRecordTransformation) UpdateWatermark(id execute.DatasetID, mark execute.Time) error {
+	return t.d.UpdateWatermark(mark)
+}
+
+func (t *unpivotRecordTransformation) UpdateProcessingTime(id execute.DatasetID, pt execute.Time) error {
+	return t.d.UpdateProcessingTime(pt)
+}
+
+func (t *unpivotRecordTransformation) Finish(id execute.DatasetID, err error) {
+	t.d.Finish(err)
+}
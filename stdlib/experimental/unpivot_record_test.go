@@ -0,0 +1,215 @@
+package experimental_test
+
+import (
+	"testing"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/execute/executetest"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/stdlib/experimental"
+)
+
+func TestUnpivotRecord_Process(t *testing.T) {
+	spec := &experimental.UnpivotRecordProcedureSpec{
+		Column:      "payload",
+		KeyColumn:   "_key",
+		ValueColumn: "_value",
+	}
+	cols := []flux.ColMeta{
+		{Label: "_time", Type: flux.TTime},
+		{Label: "t0", Type: flux.TString},
+		{Label: "payload", Type: flux.TString},
+	}
+	outCols := func(typ flux.ColType) []flux.ColMeta {
+		return []flux.ColMeta{
+			{Label: "_time", Type: flux.TTime},
+			{Label: "t0", Type: flux.TString},
+			{Label: "_key", Type: flux.TString},
+			{Label: "_value", Type: typ},
+		}
+	}
+	testCases := []struct {
+		name    string
+		spec    *experimental.UnpivotRecordProcedureSpec
+		data    []flux.Table
+		want    []*executetest.Table
+		wantErr error
+	}{
+		{
+			name: "numbers",
+			spec: spec,
+			data: []flux.Table{&executetest.Table{
+				KeyCols: []string{"t0"},
+				ColMeta: cols,
+				Data: [][]interface{}{
+					{execute.Time(1), "a", `{"y": 2, "x": 1}`},
+					{execute.Time(2), "a", `{"z": 2.5}`},
+				},
+			}},
+			want: []*executetest.Table{{
+				KeyCols: []string{"t0"},
+				ColMeta: outCols(flux.TFloat),
+				Data: [][]interface{}{
+					{execute.Time(1), "a", "x", 1.0},
+					{execute.Time(1), "a", "y", 2.0},
+					{execute.Time(2), "a", "z", 2.5},
+				},
+			}},
+		},
+		{
+			name: "integers",
+			spec: spec,
+			data: []flux.Table{&executetest.Table{
+				ColMeta: cols,
+				Data: [][]interface{}{
+					{execute.Time(1), "a", `{"x": 1, "y": null}`},
+				},
+			}},
+			want: []*executetest.Table{{
+				ColMeta: outCols(flux.TInt),
+				Data: [][]interface{}{
+					{execute.Time(1), "a", "x", int64(1)},
+					{execute.Time(1), "a", "y", nil},
+				},
+			}},
+		},
+		{
+			name: "heterogeneous values",
+			spec: spec,
+			data: []flux.Table{&executetest.Table{
+				ColMeta: cols,
+				Data: [][]interface{}{
+					{execute.Time(1), "a", `{"n": 1.5, "b": true, "s": "on", "o": {"k": [1, 2]}}`},
+				},
+			}},
+			want: []*executetest.Table{{
+				ColMeta: outCols(flux.TString),
+				Data: [][]interface{}{
+					{execute.Time(1), "a", "b", "true"},
+					{execute.Time(1), "a", "n", "1.5"},
+					{execute.Time(1), "a", "o", `{"k":[1,2]}`},
+					{execute.Time(1), "a", "s", "on"},
+				},
+			}},
+		},
+		{
+			name: "empty and null records",
+			spec: spec,
+			data: []flux.Table{&executetest.Table{
+				KeyCols: []string{"t0"},
+				ColMeta: cols,
+				Data: [][]interface{}{
+					{execute.Time(1), "a", `{}`},
+					{execute.Time(2), "a", `null`},
+					{execute.Time(3), "a", nil},
+				},
+			}},
+			want: []*executetest.Table{{
+				KeyCols:   []string{"t0"},
+				KeyValues: []interface{}{"a"},
+				ColMeta:   outCols(flux.TString),
+			}},
+		},
+		{
+			name: "group key",
+			spec: spec,
+			data: []flux.Table{
+				&executetest.Table{
+					KeyCols: []string{"t0"},
+					ColMeta: cols,
+					Data: [][]interface{}{
+						{execute.Time(1), "a", `{"on": true}`},
+					},
+				},
+				&executetest.Table{
+					KeyCols: []string{"t0"},
+					ColMeta: cols,
+					Data: [][]interface{}{
+						{execute.Time(1), "b", `{"on": false, "off": true}`},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: outCols(flux.TBool),
+					Data: [][]interface{}{
+						{execute.Time(1), "a", "on", true},
+					},
+				},
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: outCols(flux.TBool),
+					Data: [][]interface{}{
+						{execute.Time(1), "b", "off", true},
+						{execute.Time(1), "b", "on", false},
+					},
+				},
+			},
+		},
+		{
+			name: "replace value column",
+			spec: spec,
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TFloat},
+					{Label: "payload", Type: flux.TString},
+				},
+				Data: [][]interface{}{
+					{execute.Time(1), 1.0, `{"x": "y"}`},
+				},
+			}},
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_key", Type: flux.TString},
+					{Label: "_value", Type: flux.TString},
+				},
+				Data: [][]interface{}{
+					{execute.Time(1), "x", "y"},
+				},
+			}},
+		},
+		{
+			name: "not an object",
+			spec: spec,
+			data: []flux.Table{&executetest.Table{
+				ColMeta: cols,
+				Data: [][]interface{}{
+					{execute.Time(1), "a", `[1, 2]`},
+				},
+			}},
+			wantErr: errors.New(codes.Invalid, `column "payload": expected a JSON object, got [1, 2]`),
+		},
+		{
+			name: "record column in group key",
+			spec: spec,
+			data: []flux.Table{&executetest.Table{
+				KeyCols: []string{"payload"},
+				ColMeta: cols,
+				Data: [][]interface{}{
+					{execute.Time(1), "a", `{}`},
+				},
+			}},
+			wantErr: errors.New(codes.FailedPrecondition, `column "payload" is part of the group key`),
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			executetest.ProcessTestHelper(
+				t,
+				tc.data,
+				tc.want,
+				tc.wantErr,
+				func(d execute.Dataset, c execute.TableBuilderCache) execute.Transformation {
+					return experimental.NewUnpivotRecordTransformation(d, c, tc.spec, memory.DefaultAllocator)
+				},
+			)
+		})
+	}
+}
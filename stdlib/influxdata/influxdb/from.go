@@ -5,6 +5,8 @@
 package influxdb
 
 import (
+	"time"
+
 	"github.com/influxdata/flux"
 	"github.com/influxdata/flux/codes"
 	"github.com/influxdata/flux/dependencies/influxdb"
@@ -53,6 +55,7 @@ func init() {
 		FromRemoteRule{},
 		MergeRemoteRangeRule{},
 		MergeRemoteFilterRule{},
+		ProjectionPushdownRule{},
 	)
 }
 
@@ -147,6 +150,11 @@ func (s *FromProcedureSpec) Copy() plan.ProcedureSpec {
 	return ns
 }
 
+// OutputSchema implements plan.OutputSchemer.
+func (s *FromProcedureSpec) OutputSchema(flux.ResultSchema) flux.ResultSchema {
+	return fromSchema()
+}
+
 func (s *FromProcedureSpec) SetOrg(org *NameOrID)   { s.Org = org }
 func (s *FromProcedureSpec) SetHost(host *string)   { s.Host = host }
 func (s *FromProcedureSpec) SetToken(token *string) { s.Token = token }
@@ -172,6 +180,17 @@ type FromRemoteProcedureSpec struct {
 	influxdb.Config
 	Bounds       flux.Bounds
 	PredicateSet influxdb.PredicateSet
+
+	// KeepCols is a hint with the columns that are used by
+	// the transformations that read from the source.
+	// It is set by the ProjectionPushdownRule and
+	// a nil list means that every column is used.
+	KeepCols []string
+
+	// EstimatedBytesPerSecond is the number of bytes the read is
+	// estimated to scan for each second of its time range.
+	// plan.DefaultEstimatedBytesPerSecond is used when it is zero.
+	EstimatedBytesPerSecond int64 `json:"-"`
 }
 
 func (s *FromRemoteProcedureSpec) Kind() plan.ProcedureKind {
@@ -196,9 +215,84 @@ func (s *FromRemoteProcedureSpec) Copy() plan.ProcedureSpec {
 	ns := new(FromRemoteProcedureSpec)
 	*ns = *s
 	ns.PredicateSet = s.PredicateSet.Copy()
+	if s.KeepCols != nil {
+		ns.KeepCols = make([]string, len(s.KeepCols))
+		copy(ns.KeepCols, s.KeepCols)
+	}
 	return ns
 }
 
+// OutputAttributes implements plan.OutputAttributer.
+// The remote read is a range and filter of the storage engine,
+// which produces the rows of each series in ascending time order.
+func (s *FromRemoteProcedureSpec) OutputAttributes() plan.PhysicalAttributes {
+	return plan.PhysicalAttributes{
+		plan.CollationKey: &plan.CollationAttr{Columns: []string{execute.DefaultTimeColLabel}},
+	}
+}
+
+// OutputSchema implements plan.OutputSchemer.
+func (s *FromRemoteProcedureSpec) OutputSchema(flux.ResultSchema) flux.ResultSchema {
+	return fromSchema()
+}
+
+// SetEstimatedBytesPerSecond implements plan.ScanEstimator.
+func (s *FromRemoteProcedureSpec) SetEstimatedBytesPerSecond(n int64) {
+	s.EstimatedBytesPerSecond = n
+}
+
+// estimatedBytesPerRow is the estimated size of a row read from storage.
+const estimatedBytesPerRow = 64
+
+// Cost implements plan.PhysicalProcedureSpec.
+// The data scanned by the read is estimated from the duration of its
+// time range and all of it is sent over the network. Nothing is known
+// about the series that are read, so the number of tables is not estimated.
+func (s *FromRemoteProcedureSpec) Cost(inStats []plan.Statistics) (plan.Cost, plan.Statistics) {
+	if s.Bounds.IsEmpty() {
+		return plan.Cost{}, plan.Statistics{}
+	}
+	bounds := plan.FromFluxBounds(s.Bounds)
+	nanos := int64(bounds.Stop - bounds.Start)
+	if nanos <= 0 {
+		return plan.Cost{}, plan.Statistics{}
+	}
+	seconds := (nanos + int64(time.Second) - 1) / int64(time.Second)
+	perSecond := s.EstimatedBytesPerSecond
+	if perSecond <= 0 {
+		perSecond = plan.DefaultEstimatedBytesPerSecond
+	}
+	bytes := seconds * perSecond
+	rows := bytes / estimatedBytesPerRow
+	if rows == 0 && bytes > 0 {
+		rows = 1
+	}
+	return plan.Cost{Disk: bytes, NET: bytes}, plan.Statistics{Cardinality: rows}
+}
+
+// fromSchema returns the schema of the series read from the storage engine.
+// The type of the values depends on the fields and the tags depend on the
+// series that are read, so the schema is partial.
+func fromSchema() flux.ResultSchema {
+	return flux.ResultSchema{
+		Columns: []flux.ColMeta{
+			{Label: execute.DefaultStartColLabel, Type: flux.TTime},
+			{Label: execute.DefaultStopColLabel, Type: flux.TTime},
+			{Label: execute.DefaultTimeColLabel, Type: flux.TTime},
+			{Label: execute.DefaultValueColLabel, Type: flux.TInvalid},
+			{Label: DefaultFieldColLabel, Type: flux.TString},
+			{Label: DefaultMeasurementColLabel, Type: flux.TString},
+		},
+		Key: []string{
+			execute.DefaultStartColLabel,
+			execute.DefaultStopColLabel,
+			DefaultFieldColLabel,
+			DefaultMeasurementColLabel,
+		},
+		Partial: true,
+	}
+}
+
 func (s *FromRemoteProcedureSpec) PostPhysicalValidate(id plan.NodeID) error {
 	if s.Bounds.IsEmpty() {
 		var bucket string
@@ -218,8 +312,16 @@ func createFromSource(ps plan.ProcedureSpec, id execute.DatasetID, a execute.Adm
 		return nil, errors.Newf(codes.Invalid, "bounds must be set")
 	}
 
-	provider := influxdb.GetProvider(a.Context())
-	reader, err := provider.ReaderFor(a.Context(), spec.Config, spec.Bounds, spec.PredicateSet)
+	var (
+		provider = influxdb.GetProvider(a.Context())
+		reader   influxdb.Reader
+		err      error
+	)
+	if pp, ok := provider.(influxdb.ProjectionProvider); ok && spec.KeepCols != nil {
+		reader, err = pp.ProjectedReaderFor(a.Context(), spec.Config, spec.Bounds, spec.PredicateSet, spec.KeepCols)
+	} else {
+		reader, err = provider.ReaderFor(a.Context(), spec.Config, spec.Bounds, spec.PredicateSet)
+	}
 	if err != nil {
 		return nil, err
 	}
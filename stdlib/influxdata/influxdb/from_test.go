@@ -9,6 +9,7 @@ import (
 	"github.com/influxdata/flux/execute"
 	"github.com/influxdata/flux/execute/executetest"
 	"github.com/influxdata/flux/interpreter"
+	"github.com/influxdata/flux/plan"
 	"github.com/influxdata/flux/querytest"
 	"github.com/influxdata/flux/runtime"
 	"github.com/influxdata/flux/stdlib/influxdata/influxdb"
@@ -467,3 +468,69 @@ func mustParseTime(v string) time.Time {
 	}
 	return t
 }
+
+func TestFromRemoteProcedureSpec_Cost(t *testing.T) {
+	now := time.Date(2018, 10, 10, 0, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name      string
+		bounds    flux.Bounds
+		perSecond int64
+		wantBytes int64
+		wantRows  int64
+	}{
+		{
+			name: "relative",
+			bounds: flux.Bounds{
+				Start: flux.Time{IsRelative: true, Relative: -time.Hour},
+				Stop:  flux.Now,
+				Now:   now,
+			},
+			wantBytes: 3600 * plan.DefaultEstimatedBytesPerSecond,
+			wantRows:  3600 * plan.DefaultEstimatedBytesPerSecond / 64,
+		},
+		{
+			name: "partial second",
+			bounds: flux.Bounds{
+				Start: flux.Time{Absolute: now},
+				Stop:  flux.Time{Absolute: now.Add(time.Millisecond)},
+			},
+			wantBytes: plan.DefaultEstimatedBytesPerSecond,
+			wantRows:  plan.DefaultEstimatedBytesPerSecond / 64,
+		},
+		{
+			name: "rate",
+			bounds: flux.Bounds{
+				Start: flux.Time{Absolute: now},
+				Stop:  flux.Time{Absolute: now.Add(time.Minute)},
+			},
+			perSecond: 4096,
+			wantBytes: 60 * 4096,
+			wantRows:  60 * 4096 / 64,
+		},
+		{
+			name: "empty",
+			bounds: flux.Bounds{
+				Start: flux.Time{Absolute: now},
+				Stop:  flux.Time{Absolute: now},
+			},
+		},
+		{
+			name: "unbounded",
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			spec := &influxdb.FromRemoteProcedureSpec{Bounds: tc.bounds}
+			if tc.perSecond > 0 {
+				spec.SetEstimatedBytesPerSecond(tc.perSecond)
+			}
+			cost, stats := spec.Cost(nil)
+			if cost.Disk != tc.wantBytes || cost.NET != tc.wantBytes {
+				t.Errorf("unexpected cost: want %d bytes scanned and sent, got %+v", tc.wantBytes, cost)
+			}
+			if stats.Cardinality != tc.wantRows {
+				t.Errorf("unexpected rows -want/+got\n\t- %d\n\t+ %d", tc.wantRows, stats.Cardinality)
+			}
+		})
+	}
+}
@@ -0,0 +1,256 @@
+package monitor
+
+import (
+	"github.com/apache/arrow/go/v7/arrow/bitutil"
+	arrowmem "github.com/apache/arrow/go/v7/arrow/memory"
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/array"
+	"github.com/influxdata/flux/arrow"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/internal/arrowutil"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/internal/execute/table"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/plan"
+	"github.com/influxdata/flux/runtime"
+)
+
+const pkgpath = "influxdata/influxdb/monitor"
+
+const StateChangesKind = pkgpath + "._stateChanges"
+
+const (
+	// anyLevel matches every level when used as fromLevel or toLevel.
+	anyLevel = "any"
+
+	// defaultLevelColumn is the column that holds the level of a status.
+	defaultLevelColumn = "_level"
+
+	// prevLevelColumn is the output column that holds the level
+	// a row transitioned from.
+	prevLevelColumn = "_prev_level"
+)
+
+// StateChangesOpSpec detects transitions between levels.
+type StateChangesOpSpec struct {
+	FromLevel   string `json:"fromLevel"`
+	ToLevel     string `json:"toLevel"`
+	LevelColumn string `json:"levelColumn"`
+}
+
+func init() {
+	runtime.RegisterPackageValue(pkgpath, "_stateChanges", flux.MustValue(flux.FunctionValue(
+		"_stateChanges",
+		createStateChangesOpSpec,
+		runtime.MustLookupBuiltinType(pkgpath, "_stateChanges"),
+	)))
+	flux.RegisterOpSpec(StateChangesKind, newStateChangesOp)
+	plan.RegisterProcedureSpec(StateChangesKind, newStateChangesProcedure, StateChangesKind)
+	execute.RegisterTransformation(StateChangesKind, createStateChangesTransformation)
+}
+
+func createStateChangesOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
+	if err := a.AddParentFromArgs(args); err != nil {
+		return nil, err
+	}
+
+	spec := &StateChangesOpSpec{
+		FromLevel:   anyLevel,
+		ToLevel:     anyLevel,
+		LevelColumn: defaultLevelColumn,
+	}
+
+	if level, ok, err := args.GetString("fromLevel"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.FromLevel = level
+	}
+
+	if level, ok, err := args.GetString("toLevel"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.ToLevel = level
+	}
+
+	if col, ok, err := args.GetString("levelColumn"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.LevelColumn = col
+	}
+	return spec, nil
+}
+
+func newStateChangesOp() flux.OperationSpec {
+	return new(StateChangesOpSpec)
+}
+
+func (s *StateChangesOpSpec) Kind() flux.OperationKind {
+	return StateChangesKind
+}
+
+type StateChangesProcedureSpec struct {
+	plan.DefaultCost
+	FromLevel   string
+	ToLevel     string
+	LevelColumn string
+}
+
+func newStateChangesProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.ProcedureSpec, error) {
+	spec, ok := qs.(*StateChangesOpSpec)
+	if !ok {
+		return nil, errors.Newf(codes.Internal, "invalid spec type %T", qs)
+	}
+	return &StateChangesProcedureSpec{
+		FromLevel:   spec.FromLevel,
+		ToLevel:     spec.ToLevel,
+		LevelColumn: spec.LevelColumn,
+	}, nil
+}
+
+func (s *StateChangesProcedureSpec) Kind() plan.ProcedureKind {
+	return StateChangesKind
+}
+
+func (s *StateChangesProcedureSpec) Copy() plan.ProcedureSpec {
+	ns := new(StateChangesProcedureSpec)
+	*ns = *s
+	return ns
+}
+
+// PassThroughAttribute implements plan.PassThroughAttributer.
+// State changes only removes rows so the order of the rows is preserved.
+func (s *StateChangesProcedureSpec) PassThroughAttribute(attrKey string) bool {
+	return attrKey == plan.CollationKey
+}
+
+// TriggerSpec implements plan.TriggerAwareProcedureSpec
+func (s *StateChangesProcedureSpec) TriggerSpec() plan.TriggerSpec {
+	return plan.NarrowTransformationTriggerSpec{}
+}
+
+func createStateChangesTransformation(id execute.DatasetID, mode execute.AccumulationMode, spec plan.ProcedureSpec, a execute.Administration) (execute.Transformation, execute.Dataset, error) {
+	s, ok := spec.(*StateChangesProcedureSpec)
+	if !ok {
+		return nil, nil, errors.Newf(codes.Internal, "invalid spec type %T", spec)
+	}
+	return NewStateChangesTransformation(s, id, a.Allocator())
+}
+
+type stateChangesTransformation struct {
+	fromLevel   string
+	toLevel     string
+	levelColumn string
+}
+
+// stateChangesState is the last level seen in a table.
+// It is carried between buffers so a transition that
+// spans two buffers is still detected.
+type stateChangesState struct {
+	prev  string
+	valid bool
+}
+
+// NewStateChangesTransformation creates a transformation that scans each table
+// in order and keeps only the rows where the level changes from fromLevel to toLevel.
+// The previous level is written to the _prev_level column.
+func NewStateChangesTransformation(spec *StateChangesProcedureSpec, id execute.DatasetID, mem memory.Allocator) (execute.Transformation, execute.Dataset, error) {
+	t := &stateChangesTransformation{
+		fromLevel:   spec.FromLevel,
+		toLevel:     spec.ToLevel,
+		levelColumn: spec.LevelColumn,
+	}
+	return execute.NewNarrowStateTransformation(id, t, mem)
+}
+
+func (t *stateChangesTransformation) Process(chunk table.Chunk, state interface{}, d *execute.TransportDataset, mem arrowmem.Allocator) (interface{}, bool, error) {
+	var s stateChangesState
+	if state != nil {
+		s = state.(stateChangesState)
+	}
+
+	idx := chunk.Index(t.levelColumn)
+	if idx < 0 {
+		// Without a level there is nothing that can change.
+		return s, true, nil
+	}
+	if typ := chunk.Col(idx).Type; typ != flux.TString {
+		return nil, false, errors.Newf(codes.FailedPrecondition, "level column %q must be of type string, but was %v", t.levelColumn, typ)
+	}
+	if chunk.HasCol(prevLevelColumn) {
+		return nil, false, errors.Newf(codes.FailedPrecondition, "column %q already exists", prevLevelColumn)
+	}
+
+	out, ok, err := t.processChunk(chunk, idx, &s, mem)
+	if err != nil {
+		return nil, false, err
+	} else if ok {
+		if err := d.Process(out); err != nil {
+			return nil, false, err
+		}
+	}
+	return s, true, nil
+}
+
+func (t *stateChangesTransformation) processChunk(chunk table.Chunk, idx int, s *stateChangesState, mem arrowmem.Allocator) (table.Chunk, bool, error) {
+	levels := chunk.Strings(idx)
+	l := levels.Len()
+
+	bitset := arrowmem.NewResizableBuffer(mem)
+	bitset.Resize(l)
+	defer bitset.Release()
+
+	prevLevels := array.NewStringBuilder(mem)
+	defer prevLevels.Release()
+
+	for i := 0; i < l; i++ {
+		if levels.IsNull(i) {
+			bitutil.ClearBit(bitset.Buf(), i)
+			continue
+		}
+		cur := levels.Value(i)
+		emit := s.valid && t.matches(s.prev, cur)
+		bitutil.SetBitTo(bitset.Buf(), i, emit)
+		if emit {
+			prevLevels.Append(s.prev)
+		}
+		s.prev, s.valid = cur, true
+	}
+
+	n := bitutil.CountSetBits(bitset.Buf(), 0, l)
+	if n == 0 {
+		return table.Chunk{}, false, nil
+	}
+
+	cols := make([]flux.ColMeta, 0, len(chunk.Cols())+1)
+	vs := make([]array.Array, 0, len(chunk.Cols())+1)
+	for j, col := range chunk.Cols() {
+		arr := chunk.Values(j)
+		if chunk.Key().HasCol(col.Label) {
+			vs = append(vs, arrow.Slice(arr, 0, int64(n)))
+		} else {
+			vs = append(vs, arrowutil.Filter(arr, bitset.Bytes(), mem))
+		}
+		cols = append(cols, col)
+	}
+	cols = append(cols, flux.ColMeta{Label: prevLevelColumn, Type: flux.TString})
+	vs = append(vs, prevLevels.NewArray())
+
+	return table.ChunkFromBuffer(arrow.TableBuffer{
+		GroupKey: chunk.Key(),
+		Columns:  cols,
+		Values:   vs,
+	}), true, nil
+}
+
+// matches reports whether a change from prev to cur is one
+// that was requested.
+func (t *stateChangesTransformation) matches(prev, cur string) bool {
+	if prev == cur {
+		return false
+	}
+	return (t.fromLevel == anyLevel || prev == t.fromLevel) &&
+		(t.toLevel == anyLevel || cur == t.toLevel)
+}
+
+func (t *stateChangesTransformation) Close() error { return nil }
@@ -0,0 +1,187 @@
+package monitor_test
+
+import (
+	"testing"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/execute/executetest"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/stdlib/influxdata/influxdb/monitor"
+)
+
+func TestStateChanges_Process(t *testing.T) {
+	levels := func(vs ...interface{}) *executetest.Table {
+		tbl := &executetest.Table{
+			KeyCols: []string{"host"},
+			ColMeta: []flux.ColMeta{
+				{Label: "_time", Type: flux.TTime},
+				{Label: "host", Type: flux.TString},
+				{Label: "_level", Type: flux.TString},
+			},
+		}
+		for i, v := range vs {
+			tbl.Data = append(tbl.Data, []interface{}{execute.Time(i + 1), "a", v})
+		}
+		return tbl
+	}
+	outCols := []flux.ColMeta{
+		{Label: "_time", Type: flux.TTime},
+		{Label: "host", Type: flux.TString},
+		{Label: "_level", Type: flux.TString},
+		{Label: "_prev_level", Type: flux.TString},
+	}
+
+	testCases := []struct {
+		name    string
+		spec    *monitor.StateChangesProcedureSpec
+		data    []flux.Table
+		want    []*executetest.Table
+		wantErr error
+	}{
+		{
+			name: "any to crit",
+			spec: &monitor.StateChangesProcedureSpec{
+				FromLevel:   "any",
+				ToLevel:     "crit",
+				LevelColumn: "_level",
+			},
+			data: []flux.Table{levels("ok", "crit", "warn", "crit", "crit")},
+			want: []*executetest.Table{{
+				KeyCols: []string{"host"},
+				ColMeta: outCols,
+				Data: [][]interface{}{
+					{execute.Time(2), "a", "crit", "ok"},
+					{execute.Time(4), "a", "crit", "warn"},
+				},
+			}},
+		},
+		{
+			name: "ok to any",
+			spec: &monitor.StateChangesProcedureSpec{
+				FromLevel:   "ok",
+				ToLevel:     "any",
+				LevelColumn: "_level",
+			},
+			data: []flux.Table{levels("ok", "warn", "crit", "ok", "info")},
+			want: []*executetest.Table{{
+				KeyCols: []string{"host"},
+				ColMeta: outCols,
+				Data: [][]interface{}{
+					{execute.Time(2), "a", "warn", "ok"},
+					{execute.Time(5), "a", "info", "ok"},
+				},
+			}},
+		},
+		{
+			name: "consecutive duplicates",
+			spec: &monitor.StateChangesProcedureSpec{
+				FromLevel:   "any",
+				ToLevel:     "any",
+				LevelColumn: "_level",
+			},
+			data: []flux.Table{levels("ok", "ok", "ok")},
+		},
+		{
+			name: "null levels",
+			spec: &monitor.StateChangesProcedureSpec{
+				FromLevel:   "any",
+				ToLevel:     "any",
+				LevelColumn: "_level",
+			},
+			data: []flux.Table{levels("ok", nil, "ok", nil, "warn")},
+			want: []*executetest.Table{{
+				KeyCols: []string{"host"},
+				ColMeta: outCols,
+				Data: [][]interface{}{
+					{execute.Time(5), "a", "warn", "ok"},
+				},
+			}},
+		},
+		{
+			name: "transition across buffers",
+			spec: &monitor.StateChangesProcedureSpec{
+				FromLevel:   "warn",
+				ToLevel:     "crit",
+				LevelColumn: "_level",
+			},
+			data: []flux.Table{&executetest.RowWiseTable{
+				Table: levels("ok", "warn", "crit", "crit", "warn", "crit"),
+			}},
+			want: []*executetest.Table{{
+				KeyCols: []string{"host"},
+				ColMeta: outCols,
+				Data: [][]interface{}{
+					{execute.Time(3), "a", "crit", "warn"},
+					{execute.Time(6), "a", "crit", "warn"},
+				},
+			}},
+		},
+		{
+			name: "custom level column",
+			spec: &monitor.StateChangesProcedureSpec{
+				FromLevel:   "any",
+				ToLevel:     "any",
+				LevelColumn: "status",
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "status", Type: flux.TString},
+				},
+				Data: [][]interface{}{
+					{execute.Time(1), "ok"},
+					{execute.Time(2), "crit"},
+				},
+			}},
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "status", Type: flux.TString},
+					{Label: "_prev_level", Type: flux.TString},
+				},
+				Data: [][]interface{}{
+					{execute.Time(2), "crit", "ok"},
+				},
+			}},
+		},
+		{
+			name: "level column not a string",
+			spec: &monitor.StateChangesProcedureSpec{
+				FromLevel:   "any",
+				ToLevel:     "any",
+				LevelColumn: "_level",
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_level", Type: flux.TInt},
+				},
+				Data: [][]interface{}{
+					{execute.Time(1), int64(0)},
+				},
+			}},
+			wantErr: errors.New(codes.FailedPrecondition, `level column "_level" must be of type string, but was int`),
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			executetest.ProcessTestHelper2(
+				t,
+				tc.data,
+				tc.want,
+				tc.wantErr,
+				func(id execute.DatasetID, alloc memory.Allocator) (execute.Transformation, execute.Dataset) {
+					tr, d, err := monitor.NewStateChangesTransformation(tc.spec, id, alloc)
+					if err != nil {
+						t.Fatal(err)
+					}
+					return tr, d
+				},
+			)
+		})
+	}
+}
@@ -2,9 +2,11 @@ package influxdb
 
 import (
 	"context"
+	"sort"
 
 	"github.com/influxdata/flux/dependencies/influxdb"
 	"github.com/influxdata/flux/plan"
+	"github.com/influxdata/flux/semantic"
 	"github.com/influxdata/flux/stdlib/universe"
 )
 
@@ -111,6 +113,133 @@ func (p MergeRemoteFilterRule) Rewrite(ctx context.Context, node plan.Node) (pla
 	return n, true, nil
 }
 
+// ProjectionPushdownRule attaches the columns that are used after a
+// remote read to the read so that the remote query can avoid sending
+// the columns that are not used.
+//
+// The columns are only known when the read is followed by any number
+// of filters and a map that constructs a new record, since the output
+// of a map that extends its input contains every column. The functions
+// may only access the columns of the record with a member expression
+// and must not contain nested functions. Otherwise, the read is left
+// without a hint and every column is read.
+type ProjectionPushdownRule struct{}
+
+func (p ProjectionPushdownRule) Name() string {
+	return "influxdata/influxdb.ProjectionPushdownRule"
+}
+
+func (p ProjectionPushdownRule) Pattern() plan.Pattern {
+	return plan.Pat(FromRemoteKind)
+}
+
+func (p ProjectionPushdownRule) Rewrite(ctx context.Context, node plan.Node) (plan.Node, bool, error) {
+	fromSpec := node.ProcedureSpec().(*FromRemoteProcedureSpec)
+
+	keepCols, ok := usedColumns(node)
+	if _, isProjection := influxdb.GetProvider(ctx).(influxdb.ProjectionProvider); !ok || !isProjection {
+		keepCols = nil
+	}
+	if equalColumns(fromSpec.KeepCols, keepCols) {
+		return node, false, nil
+	}
+
+	fromSpec = fromSpec.Copy().(*FromRemoteProcedureSpec)
+	fromSpec.KeepCols = keepCols
+	if err := node.ReplaceSpec(fromSpec); err != nil {
+		return nil, false, err
+	}
+	return node, true, nil
+}
+
+// usedColumns returns the sorted list of columns that are
+// used by the filters and map that follow a node.
+func usedColumns(node plan.Node) ([]string, bool) {
+	cols := make(map[string]bool)
+	for {
+		if len(node.Successors()) != 1 {
+			return nil, false
+		}
+		node = node.Successors()[0]
+
+		switch spec := node.ProcedureSpec().(type) {
+		case *universe.FilterProcedureSpec:
+			if !referencedColumns(spec.Fn.Fn, cols) {
+				return nil, false
+			}
+		case *universe.MapProcedureSpec:
+			fn := spec.Fn.Fn
+			if fn == nil || fn.Block == nil {
+				return nil, false
+			}
+			body, ok := fn.GetFunctionBodyExpression()
+			if !ok {
+				return nil, false
+			}
+			if obj, ok := body.(*semantic.ObjectExpression); !ok || obj.With != nil {
+				return nil, false
+			}
+			if !referencedColumns(fn, cols) {
+				return nil, false
+			}
+
+			keepCols := make([]string, 0, len(cols))
+			for col := range cols {
+				keepCols = append(keepCols, col)
+			}
+			sort.Strings(keepCols)
+			return keepCols, true
+		default:
+			return nil, false
+		}
+	}
+}
+
+// referencedColumns adds the columns of the record parameter
+// that are referenced by the function to cols. It returns false
+// if the record is used other than to access one of its columns.
+func referencedColumns(fn *semantic.FunctionExpression, cols map[string]bool) bool {
+	if fn == nil || fn.Block == nil || fn.Parameters == nil || len(fn.Parameters.List) != 1 {
+		return false
+	}
+
+	var (
+		param   = fn.Parameters.List[0].Key.Name.Name()
+		members = make(map[*semantic.IdentifierExpression]bool)
+		ok      = true
+	)
+	semantic.Walk(semantic.CreateVisitor(func(node semantic.Node) {
+		switch e := node.(type) {
+		case *semantic.FunctionExpression:
+			ok = false
+		case *semantic.MemberExpression:
+			id, isIdent := e.Object.(*semantic.IdentifierExpression)
+			if !isIdent || id.Name.Name() != param {
+				return
+			}
+			members[id] = true
+			cols[e.Property.Name()] = true
+		case *semantic.IdentifierExpression:
+			if e.Name.Name() == param && !members[e] {
+				ok = false
+			}
+		}
+	}), fn.Block)
+	return ok
+}
+
+func equalColumns(a, b []string) bool {
+	if (a == nil) != (b == nil) || len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
 type BucketsRemoteRule struct{}
 
 func (p BucketsRemoteRule) Name() string {
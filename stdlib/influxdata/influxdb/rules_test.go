@@ -2,6 +2,7 @@ package influxdb_test
 
 import (
 	"context"
+	"net/url"
 	"testing"
 	"time"
 
@@ -14,6 +15,7 @@ import (
 	"github.com/influxdata/flux/plan"
 	"github.com/influxdata/flux/plan/plantest"
 	"github.com/influxdata/flux/stdlib/influxdata/influxdb"
+	"github.com/influxdata/flux/stdlib/influxdata/influxdb/internal/testutil"
 	"github.com/influxdata/flux/stdlib/universe"
 	"github.com/influxdata/flux/values/valuestest"
 )
@@ -440,3 +442,220 @@ func TestDefaultFromAttributes(t *testing.T) {
 		})
 	}
 }
+
+func TestProjectionPushdownRule(t *testing.T) {
+	deps := flux.NewDefaultDependencies()
+	ctx := deps.Inject(context.Background())
+	ctx = influxdeps.Dependency{
+		Provider: influxdeps.HttpProvider{},
+	}.Inject(ctx)
+
+	fromRemoteSpec := func(keepCols []string) *influxdb.FromRemoteProcedureSpec {
+		return &influxdb.FromRemoteProcedureSpec{
+			Config: influxdb.Config{
+				Bucket: influxdb.NameOrID{Name: "telegraf"},
+				Host:   "http://localhost:8086",
+			},
+			Bounds: flux.Bounds{
+				Start: flux.Time{
+					IsRelative: true,
+					Relative:   -time.Minute,
+				},
+				Stop: flux.Time{
+					IsRelative: true,
+				},
+			},
+			KeepCols: keepCols,
+		}
+	}
+	filterSpec := func(fn string) *universe.FilterProcedureSpec {
+		return &universe.FilterProcedureSpec{
+			Fn: interpreter.ResolvedFunction{
+				Fn:    executetest.FunctionExpression(t, fn),
+				Scope: valuestest.Scope(),
+			},
+		}
+	}
+	mapSpec := func(fn string) *universe.MapProcedureSpec {
+		return &universe.MapProcedureSpec{
+			Fn: interpreter.ResolvedFunction{
+				Fn:    executetest.FunctionExpression(t, fn),
+				Scope: valuestest.Scope(),
+			},
+		}
+	}
+	planSpec := func(keepCols []string, specs ...plan.PhysicalProcedureSpec) *plantest.PlanSpec {
+		ps := &plantest.PlanSpec{
+			Nodes: []plan.Node{
+				plan.CreatePhysicalNode("fromRemote", fromRemoteSpec(keepCols)),
+			},
+		}
+		for i, spec := range specs {
+			ps.Nodes = append(ps.Nodes, plan.CreatePhysicalNode(plan.NodeID(string(spec.Kind())), spec))
+			ps.Edges = append(ps.Edges, [2]int{i, i + 1})
+		}
+		return ps
+	}
+
+	for _, tc := range []plantest.RuleTestCase{
+		{
+			Name:    "map",
+			Context: ctx,
+			Rules:   []plan.Rule{influxdb.ProjectionPushdownRule{}},
+			Before: planSpec(nil,
+				mapSpec(`(r) => ({_value: r._value * 2.0})`),
+			),
+			After: planSpec([]string{"_value"},
+				mapSpec(`(r) => ({_value: r._value * 2.0})`),
+			),
+		},
+		{
+			Name:    "filter and map",
+			Context: ctx,
+			Rules:   []plan.Rule{influxdb.ProjectionPushdownRule{}},
+			Before: planSpec(nil,
+				filterSpec(`(r) => r.host == "a"`),
+				mapSpec(`(r) => ({_time: r._time, v: r._value})`),
+			),
+			After: planSpec([]string{"_time", "_value", "host"},
+				filterSpec(`(r) => r.host == "a"`),
+				mapSpec(`(r) => ({_time: r._time, v: r._value})`),
+			),
+		},
+		{
+			Name:    "map extends the record",
+			Context: ctx,
+			Rules:   []plan.Rule{influxdb.ProjectionPushdownRule{}},
+			Before: planSpec(nil,
+				mapSpec(`(r) => ({r with v: r._value})`),
+			),
+			NoChange: true,
+		},
+		{
+			Name:    "record passed to a function",
+			Context: ctx,
+			Rules:   []plan.Rule{influxdb.ProjectionPushdownRule{}},
+			Before: planSpec(nil,
+				mapSpec(`(r) => ({v: display(v: r)})`),
+			),
+			NoChange: true,
+		},
+		{
+			Name:    "filter without map",
+			Context: ctx,
+			Rules:   []plan.Rule{influxdb.ProjectionPushdownRule{}},
+			Before: planSpec(nil,
+				filterSpec(`(r) => r.host == "a"`),
+			),
+			NoChange: true,
+		},
+		{
+			Name:    "provider without projections",
+			Context: deps.Inject(context.Background()),
+			Rules:   []plan.Rule{influxdb.ProjectionPushdownRule{}},
+			Before: planSpec(nil,
+				mapSpec(`(r) => ({_value: r._value})`),
+			),
+			NoChange: true,
+		},
+	} {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			plantest.PhysicalRuleTestHelper(t, &tc)
+		})
+	}
+}
+
+// TestProjectionPushdownRule_Query checks the query that is
+// sent to influxdb with and without the projection pushdown.
+func TestProjectionPushdownRule_Query(t *testing.T) {
+	deps := flux.NewDefaultDependencies()
+	ctx := deps.Inject(context.Background())
+	ctx = influxdeps.Dependency{
+		Provider: influxdeps.HttpProvider{},
+	}.Inject(ctx)
+
+	now := mustParseTime("2020-10-22T09:30:00Z")
+	fromSpec := &influxdb.FromRemoteProcedureSpec{
+		Config: influxdb.Config{
+			Org:    influxdb.NameOrID{Name: "influxdata"},
+			Bucket: influxdb.NameOrID{Name: "telegraf"},
+			Token:  "mytoken",
+		},
+		Bounds: flux.Bounds{
+			Start: flux.Time{
+				IsRelative: true,
+				Relative:   -time.Minute,
+			},
+			Stop: flux.Time{
+				IsRelative: true,
+			},
+			Now: now,
+		},
+	}
+	fromNode := plan.CreatePhysicalNode("fromRemote", fromSpec.Copy().(*influxdb.FromRemoteProcedureSpec))
+	mapNode := plan.CreatePhysicalNode("map", &universe.MapProcedureSpec{
+		Fn: interpreter.ResolvedFunction{
+			Fn:    executetest.FunctionExpression(t, `(r) => ({_value: r._value * 2.0})`),
+			Scope: valuestest.Scope(),
+		},
+	})
+	fromNode.AddSuccessors(mapNode)
+	mapNode.AddPredecessors(fromNode)
+
+	node, changed, err := influxdb.ProjectionPushdownRule{}.Rewrite(ctx, fromNode)
+	if err != nil {
+		t.Fatal(err)
+	} else if !changed {
+		t.Fatal("expected the projection to be pushed down")
+	}
+
+	tables := func() []*executetest.Table {
+		return []*executetest.Table{{
+			KeyCols: []string{"_measurement", "_field"},
+			ColMeta: []flux.ColMeta{
+				{Label: "_measurement", Type: flux.TString},
+				{Label: "_field", Type: flux.TString},
+				{Label: "_value", Type: flux.TFloat},
+			},
+			Data: [][]interface{}{
+				{"cpu", "usage_user", 2.0},
+				{"cpu", "usage_user", 8.0},
+			},
+		}}
+	}
+	for _, tt := range []struct {
+		name  string
+		spec  plan.PhysicalProcedureSpec
+		query string
+	}{
+		{
+			name: "without rule",
+			spec: fromSpec,
+			query: `package main
+
+
+from(bucket: "telegraf") |> range(start: 2020-10-22T09:29:00Z, stop: 2020-10-22T09:30:00Z)`,
+		},
+		{
+			name: "with rule",
+			spec: node.ProcedureSpec().(plan.PhysicalProcedureSpec),
+			query: `package main
+
+
+from(bucket: "telegraf")
+    |> range(start: 2020-10-22T09:29:00Z, stop: 2020-10-22T09:30:00Z)
+    |> drop(columns: ["_time"])`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			testutil.RunSourceTestHelper(t, tt.spec, testutil.Want{
+				Params: url.Values{
+					"org": []string{"influxdata"},
+				},
+				Query:  tt.query,
+				Tables: tables,
+			})
+		})
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/influxdata/flux/internal/arrowutil"
 	"github.com/influxdata/flux/internal/errors"
 	"github.com/influxdata/flux/interpreter"
+	"github.com/influxdata/flux/metadata"
 	"github.com/influxdata/flux/plan"
 	"github.com/influxdata/flux/runtime"
 	"github.com/influxdata/flux/semantic"
@@ -42,6 +43,13 @@ const (
 	defaultFieldColLabel       = "_field"
 	defaultMeasurementColLabel = "_measurement"
 	toOp                       = "influxdata/influxdb/to"
+
+	// onEmptyWrite opens the writer when the transformation
+	// is created, even if there are no rows to write.
+	onEmptyWrite = "write"
+	// onEmptySkip opens the writer only once there is
+	// a row to write.
+	onEmptySkip = "skip"
 )
 
 func createToTransformation(id execute.DatasetID, mode execute.AccumulationMode, spec plan.ProcedureSpec, a execute.Administration) (execute.Transformation, execute.Dataset, error) {
@@ -61,7 +69,10 @@ type toTransformation struct {
 	spec               *ToOpSpec
 	implicitTagColumns bool
 	tagColumns         []string
+	deps               influxdb.Provider
+	conf               influxdb.Config
 	writer             influxdb.Writer
+	written            bool
 	span               opentracing.Span
 }
 
@@ -90,20 +101,24 @@ func NewToTransformation(ctx context.Context, id execute.DatasetID, spec *ToProc
 		Host:   spec.Spec.Host,
 		Token:  spec.Spec.Token,
 	}
-	writer, err := deps.WriterFor(ctx, conf)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	return execute.NewNarrowTransformation(id, &toTransformation{
+	t := &toTransformation{
 		ctx:                ctx,
 		fn:                 fn,
 		spec:               spec.Spec,
 		implicitTagColumns: spec.Spec.TagColumns == nil,
 		tagColumns:         append([]string(nil), spec.Spec.TagColumns...),
-		writer:             writer,
+		deps:               deps,
+		conf:               conf,
 		span:               span,
-	}, mem)
+	}
+	if spec.Spec.OnEmpty != onEmptySkip {
+		writer, err := deps.WriterFor(ctx, conf)
+		if err != nil {
+			return nil, nil, err
+		}
+		t.writer = writer
+	}
+	return execute.NewNarrowTransformation(id, t, mem)
 }
 
 // Process does the actual work for the ToTransformation.
@@ -289,11 +304,19 @@ outer:
 	}
 
 	// only write if we have any metrics to write
-	if len(metrics) > 0 {
-		err = t.writer.Write(metrics...)
+	if len(metrics) == 0 {
+		return nil
 	}
-
-	return err
+	if t.writer == nil {
+		if t.writer, err = t.deps.WriterFor(t.ctx, t.conf); err != nil {
+			return err
+		}
+	}
+	if err := t.writer.Write(metrics...); err != nil {
+		return err
+	}
+	t.written = true
+	return nil
 }
 
 // filterNulls will filter out the rows where the time is null from the table chunk.
@@ -330,9 +353,19 @@ func (t *toTransformation) filterNulls(chunk table.Chunk, mem memory.Allocator)
 
 func (t *toTransformation) Close() error {
 	defer t.span.Finish()
+	if t.writer == nil {
+		return nil
+	}
 	return t.writer.Close()
 }
 
+// Metadata reports whether any rows were written to InfluxDB.
+func (t *toTransformation) Metadata() metadata.Metadata {
+	md := make(metadata.Metadata)
+	md.Add("influxdb/written", t.written)
+	return md
+}
+
 // fieldFunctionVisitor implements semantic.Visitor.
 // fieldFunctionVisitor is used to walk the the field function expression
 // of the `to` operation and to record all referenced columns. This visitor
@@ -418,6 +451,7 @@ type ToOpSpec struct {
 	MeasurementColumn string                       `json:"measurementColumn"`
 	TagColumns        []string                     `json:"tagColumns"`
 	FieldFn           interpreter.ResolvedFunction `json:"fieldFn"`
+	OnEmpty           string                       `json:"onEmpty"`
 }
 
 // ToProcedureSpec is the procedure spec for the `to` flux function.
@@ -446,6 +480,7 @@ func (o *ToProcedureSpec) Copy() plan.ProcedureSpec {
 			MeasurementColumn: s.MeasurementColumn,
 			TagColumns:        append([]string(nil), s.TagColumns...),
 			FieldFn:           s.FieldFn.Copy(),
+			OnEmpty:           s.OnEmpty,
 		},
 	}
 	return res
@@ -519,6 +554,12 @@ func (o *ToOpSpec) ReadArgs(args flux.Arguments) error {
 		}
 	}
 
+	if o.OnEmpty, ok, _ = args.GetString("onEmpty"); !ok {
+		o.OnEmpty = onEmptyWrite
+	} else if o.OnEmpty != onEmptyWrite && o.OnEmpty != onEmptySkip {
+		return errors.Newf(codes.Invalid, "onEmpty must be %q or %q, but was %q", onEmptyWrite, onEmptySkip, o.OnEmpty)
+	}
+
 	return err
 }
 
@@ -1,8 +1,11 @@
 package influxdb_test
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io/ioutil"
+	"net/http"
 	"sort"
 	"testing"
 	"time"
@@ -10,11 +13,14 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/dependencies/dependenciestest"
 	influxdb2 "github.com/influxdata/flux/dependencies/influxdb"
+	"github.com/influxdata/flux/dependency"
 	"github.com/influxdata/flux/execute"
 	"github.com/influxdata/flux/execute/executetest"
 	"github.com/influxdata/flux/interpreter"
 	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/metadata"
 	"github.com/influxdata/flux/mock"
 	"github.com/influxdata/flux/stdlib/influxdata/influxdb"
 	"github.com/influxdata/flux/values/valuestest"
@@ -768,3 +774,115 @@ func TestTo_Process(t *testing.T) {
 		})
 	}
 }
+
+type requestCounter struct {
+	requests int
+}
+
+func (c *requestCounter) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.requests++
+	return &http.Response{
+		StatusCode: http.StatusNoContent,
+		Body:       ioutil.NopCloser(new(bytes.Buffer)),
+		Header:     make(http.Header),
+	}, nil
+}
+
+func TestTo_OnEmpty(t *testing.T) {
+	cols := []flux.ColMeta{
+		{Label: "_time", Type: flux.TTime},
+		{Label: "_measurement", Type: flux.TString},
+		{Label: "_field", Type: flux.TString},
+		{Label: "_value", Type: flux.TFloat},
+	}
+	testCases := []struct {
+		name         string
+		onEmpty      string
+		data         [][]interface{}
+		wantRequests int
+		wantWritten  bool
+	}{
+		{
+			name:    "skip with no rows",
+			onEmpty: "skip",
+		},
+		{
+			name:    "skip with rows",
+			onEmpty: "skip",
+			data: [][]interface{}{
+				{execute.Time(11), "a", "temperature", 2.0},
+			},
+			wantRequests: 1,
+			wantWritten:  true,
+		},
+		{
+			name:    "write with no rows",
+			onEmpty: "write",
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			counter := &requestCounter{}
+			deps := dependenciestest.Default()
+			deps.Deps.Deps.HTTPClient = &http.Client{Transport: counter}
+			ctx, span := dependency.Inject(context.Background(), deps)
+			defer span.Finish()
+
+			provider := influxdb2.HttpProvider{
+				DefaultConfig: influxdb2.Config{
+					Host:  "http://myhost.com:8086",
+					Token: "mytoken",
+				},
+			}
+			spec := &influxdb.ToProcedureSpec{
+				Spec: &influxdb.ToOpSpec{
+					Org:               "my-org",
+					Bucket:            "my-bucket",
+					TimeColumn:        "_time",
+					MeasurementColumn: "_measurement",
+					OnEmpty:           tc.onEmpty,
+				},
+			}
+			table := func() *executetest.Table {
+				return &executetest.Table{
+					KeyCols:   []string{"_measurement", "_field"},
+					KeyValues: []interface{}{"a", "temperature"},
+					ColMeta:   cols,
+					Data:      tc.data,
+				}
+			}
+
+			var tr execute.Transformation
+			executetest.ProcessTestHelper2(
+				t,
+				[]flux.Table{table()},
+				[]*executetest.Table{table()},
+				nil,
+				func(id execute.DatasetID, alloc memory.Allocator) (execute.Transformation, execute.Dataset) {
+					var (
+						d   execute.Dataset
+						err error
+					)
+					tr, d, err = influxdb.NewToTransformation(ctx, id, spec, provider, alloc)
+					if err != nil {
+						t.Fatal(err)
+					}
+					return tr, d
+				},
+			)
+
+			if want, got := tc.wantRequests, counter.requests; want != got {
+				t.Errorf("unexpected number of write requests -want/+got:\n\t- %d\n\t+ %d", want, got)
+			}
+			mt, ok := tr.(execute.MetadataTransformation)
+			if !ok {
+				t.Fatal("expected to report metadata")
+			}
+			want := metadata.Metadata{"influxdb/written": []interface{}{tc.wantWritten}}
+			if got := mt.Metadata(); !cmp.Equal(want, got) {
+				t.Errorf("unexpected metadata -want/+got:\n%s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
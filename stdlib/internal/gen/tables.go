@@ -145,9 +145,14 @@ func createTablesSource(prSpec plan.ProcedureSpec, dsid execute.DatasetID, a exe
 	if !ok {
 		return nil, errors.Newf(codes.Internal, "invalid spec type %T", prSpec)
 	}
+	schema := spec.Schema
+	if schema.Seed == nil {
+		seed := execute.GetRandom(a.Context(), dsid).Int63()
+		schema.Seed = &seed
+	}
 	return &Source{
 		id:     dsid,
-		schema: spec.Schema,
+		schema: schema,
 		alloc:  a.Allocator(),
 	}, nil
 }
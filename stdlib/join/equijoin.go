@@ -2,25 +2,30 @@ package join
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
 	"github.com/influxdata/flux"
 	"github.com/influxdata/flux/ast"
 	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/execute"
 	"github.com/influxdata/flux/internal/errors"
 	"github.com/influxdata/flux/interpreter"
 	"github.com/influxdata/flux/plan"
 	"github.com/influxdata/flux/semantic"
+	"github.com/influxdata/flux/values"
 )
 
 const EquiJoinKind = "equijoin"
 
 func init() {
 	plan.RegisterPhysicalRules(EquiJoinPredicateRule{})
+	execute.RegisterTransformation(EquiJoinKind, createEquiJoinTransformation)
 }
 
 type ColumnPair struct {
-	Left, Right string
+	Left  string `json:"left"`
+	Right string `json:"right"`
 }
 
 type EquiJoinProcedureSpec struct {
@@ -29,6 +34,8 @@ type EquiJoinProcedureSpec struct {
 	Left   *flux.TableObject
 	Right  *flux.TableObject
 	Method string
+	Limit  int64
+	Within flux.Duration
 }
 
 func (p *EquiJoinProcedureSpec) Kind() plan.ProcedureKind {
@@ -42,9 +49,71 @@ func (p *EquiJoinProcedureSpec) Copy() plan.ProcedureSpec {
 		Left:   p.Left,
 		Right:  p.Right,
 		Method: p.Method,
+		Limit:  p.Limit,
+		Within: p.Within,
 	}
 }
 
+func (p *EquiJoinProcedureSpec) MarshalJSON() ([]byte, error) {
+	asSrc, err := functionSource("as", p.As)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(equiJoinJSON{
+		On:     p.On,
+		As:     asSrc,
+		Method: p.Method,
+		Limit:  p.Limit,
+		Within: p.Within,
+	})
+}
+
+func (p *EquiJoinProcedureSpec) UnmarshalJSON(data []byte) error {
+	var raw equiJoinJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if len(raw.On) == 0 {
+		return errors.New(codes.Invalid, "equi-join must compare at least one pair of columns")
+	}
+	if err := validateMethod(raw.Method); err != nil {
+		return err
+	}
+	if raw.Limit < 0 {
+		return errors.Newf(codes.Invalid, "limit must not be negative, but was %d", raw.Limit)
+	}
+	if err := validateTolerance("within", raw.Within); err != nil {
+		return err
+	}
+
+	as, err := analyzeFunction("as", raw.As)
+	if err != nil {
+		return err
+	}
+	if err := validateAs(as); err != nil {
+		return err
+	}
+	*p = EquiJoinProcedureSpec{
+		On:     raw.On,
+		As:     as,
+		Method: raw.Method,
+		Limit:  raw.Limit,
+		Within: raw.Within,
+	}
+	return nil
+}
+
+// equiJoinJSON is the encoded form of an equi-join. The predicate
+// was already reduced to the pairs of columns it compares, so the
+// pairs are encoded instead of the on function.
+type equiJoinJSON struct {
+	On     []ColumnPair  `json:"on"`
+	As     string        `json:"as"`
+	Method string        `json:"method"`
+	Limit  int64         `json:"limit"`
+	Within flux.Duration `json:"within"`
+}
+
 func (p *EquiJoinProcedureSpec) Cost(inStats []plan.Statistics) (cost plan.Cost, outStats plan.Statistics) {
 	return plan.Cost{}, plan.Statistics{}
 }
@@ -56,7 +125,59 @@ func newEquiJoin(spec *JoinProcedureSpec, cols []ColumnPair) *EquiJoinProcedureS
 		Left:   spec.Left,
 		Right:  spec.Right,
 		Method: spec.Method,
+		Limit:  spec.Limit,
+		Within: spec.Within,
+	}
+}
+
+func createEquiJoinTransformation(
+	id execute.DatasetID,
+	mode execute.AccumulationMode,
+	spec plan.ProcedureSpec,
+	a execute.Administration,
+) (execute.Transformation, execute.Dataset, error) {
+	s, ok := spec.(*EquiJoinProcedureSpec)
+	if !ok {
+		return nil, nil, errors.Newf(codes.Internal, "invalid spec type %T", spec)
+	}
+	parents := a.Parents()
+	if len(parents) != 2 {
+		return nil, nil, errors.New(codes.Internal, "join expects exactly two parents")
+	}
+	cache := execute.NewTableBuilderCache(a.Allocator())
+	d := execute.NewDataset(id, mode, cache)
+	t, err := newJoinTransformation(a.Context(), d, cache, s.Method, equalityFn(s.On), newDynamicFn(s.As), parents[0], parents[1])
+	if err != nil {
+		return nil, nil, err
+	}
+	t.mem = a.Allocator()
+	if s.Limit > 0 {
+		t.limit = s.Limit
 	}
+	t.within = s.Within.Nanoseconds()
+	return t, d, nil
+}
+
+// equalityFn matches rows where each pair of columns has equal values.
+// Null values are not equal to each other. The join transformation
+// uses the column pairs directly to find matches with a hash join.
+type equalityFn []ColumnPair
+
+func (f equalityFn) Eval(ctx context.Context, l, r values.Object) (values.Value, error) {
+	for _, pair := range f {
+		lv, ok := l.Get(pair.Left)
+		if !ok || lv.IsNull() {
+			return values.NewBool(false), nil
+		}
+		rv, ok := r.Get(pair.Right)
+		if !ok || rv.IsNull() {
+			return values.NewBool(false), nil
+		}
+		if !lv.Equal(rv) {
+			return values.NewBool(false), nil
+		}
+	}
+	return values.NewBool(true), nil
 }
 
 type EquiJoinPredicateRule struct{}
@@ -75,113 +196,90 @@ func (EquiJoinPredicateRule) Rewrite(ctx context.Context, n plan.Node) (plan.Nod
 	if !ok {
 		return nil, false, errors.New(codes.Internal, "invalid spec type on join node")
 	}
+	// A cross join does not use the predicate, so there
+	// is nothing to rewrite.
+	if spec.Method == "cross" {
+		return n, false, nil
+	}
 
-	fnBody := spec.On.Fn.Block.Body
+	n.ReplaceSpec(newEquiJoin(spec, spec.Pairs))
+	return n, true, nil
+}
 
-	if len(fnBody) != 1 {
-		return nil, false, wrapErr(
-			codes.Invalid,
-			"function body should be a single logical expression that compares columns from each table",
-		)
+// equalityPairs extracts the pairs of columns that are compared by the
+// on function. The function must be a conjunction of equality expressions
+// that each compare a column of l with a column of r. Anything else is
+// rejected with an error that points at the unsupported expression.
+func equalityPairs(fn *semantic.FunctionExpression) ([]ColumnPair, error) {
+	body := fn.Block.Body
+	if len(body) != 1 {
+		return nil, invalidOn(fn, "function body should be a single logical expression that compares columns from each table")
 	}
-	rs, ok := fnBody[0].(*semantic.ReturnStatement)
+	rs, ok := body[0].(*semantic.ReturnStatement)
 	if !ok {
-		return nil, false, wrapErr(
-			codes.Invalid,
-			"function body should be a single logical expression that compares columns from each table",
-		)
-	}
-
-	cols := []ColumnPair{}
-	expr := rs.Argument
-	var walkErr error
-	semantic.Walk(semantic.CreateVisitor(func(n semantic.Node) {
-		switch e := n.(type) {
-		case *semantic.LogicalExpression:
-			if e.Operator != ast.AndOperator {
-				walkErr = wrapErr(
-					codes.Invalid,
-					fmt.Sprintf("unsupported operator in join predicate: %s", e.Operator.String()),
-				)
-
-				return
-			}
-		case *semantic.BinaryExpression:
-			if e.Operator != ast.EqualOperator {
-				walkErr = wrapErr(
-					codes.Invalid,
-					fmt.Sprintf("unsupported operator in join predicate: %s", e.Operator.String()),
-				)
-				return
-			}
-
-			lhs, ok := e.Left.(*semantic.MemberExpression)
-			if !ok {
-				walkErr = wrapErr(codes.Invalid, "left side of comparison is not a member expression")
-				return
-			}
-			rhs, ok := e.Right.(*semantic.MemberExpression)
-			if !ok {
-				walkErr = wrapErr(codes.Invalid, "right side of comparison is not a member expression")
-				return
-			}
-			lob, err := getObjectName(lhs)
-			if err != nil {
-				walkErr = err
-				return
-			}
-			rob, err := getObjectName(rhs)
-			if err != nil {
-				walkErr = err
-				return
-			}
-
-			// Each side of the binary expression should reference either the `l` or `r` object,
-			// but they should not reference the same object.
-			if !((lob == "l") != (rob == "l") && (lob == "r") != (rob == "r")) {
-				walkErr = wrapErr(
-					codes.Invalid,
-					"binary expression operands must reference `l` or `r` only, and may not reference the same object",
-				)
-				return
-			}
-
-			lcol := lhs.Property.LocalName
-			rcol := rhs.Property.LocalName
-			pair := ColumnPair{}
-
-			if lob == "l" {
-				pair.Left = lcol
-				pair.Right = rcol
-			} else {
-				pair.Left = rcol
-				pair.Right = lcol
-			}
-			cols = append(cols, pair)
-		case *semantic.MemberExpression:
-		case *semantic.IdentifierExpression:
-		default:
-			walkErr = wrapErr(
-				codes.Invalid,
-				fmt.Sprintf("illegal expression type in join predicate: %s", e.NodeType()),
-			)
+		return nil, invalidOn(body[0], "function body should be a single logical expression that compares columns from each table")
+	}
+	return collectEqualityPairs(rs.Argument, nil)
+}
+
+func collectEqualityPairs(expr semantic.Expression, pairs []ColumnPair) ([]ColumnPair, error) {
+	switch e := expr.(type) {
+	case *semantic.LogicalExpression:
+		if e.Operator != ast.AndOperator {
+			return nil, invalidOn(e, "unsupported operator in join predicate: %s, the comparisons must be joined with and", e.Operator)
 		}
-	}), expr)
-	if walkErr != nil {
-		return nil, false, walkErr
+		pairs, err := collectEqualityPairs(e.Left, pairs)
+		if err != nil {
+			return nil, err
+		}
+		return collectEqualityPairs(e.Right, pairs)
+	case *semantic.BinaryExpression:
+		if e.Operator != ast.EqualOperator {
+			return nil, invalidOn(e, "unsupported operator in join predicate: %s, the columns must be compared with ==", e.Operator)
+		}
+
+		lhs, ok := e.Left.(*semantic.MemberExpression)
+		if !ok {
+			return nil, invalidOn(e.Left, "left side of comparison is not a member expression")
+		}
+		rhs, ok := e.Right.(*semantic.MemberExpression)
+		if !ok {
+			return nil, invalidOn(e.Right, "right side of comparison is not a member expression")
+		}
+		lob, err := getObjectName(lhs)
+		if err != nil {
+			return nil, err
+		}
+		rob, err := getObjectName(rhs)
+		if err != nil {
+			return nil, err
+		}
+
+		// Each side of the binary expression should reference either the `l` or `r` object,
+		// but they should not reference the same object.
+		if !((lob == "l") != (rob == "l") && (lob == "r") != (rob == "r")) {
+			return nil, invalidOn(e, "binary expression operands must reference `l` or `r` only, and may not reference the same object")
+		}
+
+		lcol := lhs.Property.LocalName
+		rcol := rhs.Property.LocalName
+		if lob == "l" {
+			return append(pairs, ColumnPair{Left: lcol, Right: rcol}), nil
+		}
+		return append(pairs, ColumnPair{Left: rcol, Right: lcol}), nil
+	default:
+		return nil, invalidOn(e, "illegal expression type in join predicate: %s", e.NodeType())
 	}
-	n.ReplaceSpec(newEquiJoin(spec, cols))
-	return n, true, nil
 }
 
-func wrapErr(code codes.Code, msg string) error {
-	return errors.Newf(code, fmt.Sprintf("error in join function - some expressions are not yet supported in the `on` parameter: %s", msg))
+func invalidOn(n semantic.Node, format string, a ...interface{}) error {
+	return errors.Newf(codes.Invalid, "join.join: invalid on function: %s @%s", fmt.Sprintf(format, a...), n.Location())
 }
 
 func getObjectName(me *semantic.MemberExpression) (string, error) {
 	id, ok := me.Object.(*semantic.IdentifierExpression)
 	if !ok {
-		return "", errors.New(codes.Internal, "invalid member expression")
+		return "", invalidOn(me, "member expression must reference `l` or `r`")
 	}
 	name := id.Name.LocalName
 	return name, nil
@@ -2,6 +2,7 @@ package join_test
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
@@ -34,6 +35,7 @@ func TestEquiJoinPredicateRule(t *testing.T) {
 		flux      string
 		wantErr   error
 		wantPairs []join.ColumnPair
+		wantLimit int64
 		wantPlan  *plantest.PlanSpec
 	}{
 		{
@@ -105,6 +107,37 @@ func TestEquiJoinPredicateRule(t *testing.T) {
 				Now: now,
 			},
 		},
+		{
+			name: "cross join",
+			flux: `import "join"
+			left = from(bucket: "b1", host: "http://localhost:8086")
+				|> filter(fn: (r) => r._measurement == "a")
+			right = from(bucket: "b2", host: "http://localhost:8086")
+				|> filter(fn: (r) => r._measurement == "b")
+			join.join(
+				left: left,
+				right: right,
+				on: (l, r) => false,
+				as: (l, r) => ({l with c: r._value}),
+				method: "cross",
+			)`,
+			wantPlan: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreateLogicalNode("from0", &influxdb.FromProcedureSpec{}),
+					plan.CreateLogicalNode("filter1", &universe.FilterProcedureSpec{}),
+					plan.CreateLogicalNode("from2", &influxdb.FromProcedureSpec{}),
+					plan.CreateLogicalNode("filter3", &universe.FilterProcedureSpec{}),
+					plan.CreateLogicalNode("join.join4", &join.JoinProcedureSpec{}),
+				},
+				Edges: [][2]int{
+					{0, 1},
+					{2, 3},
+					{1, 4},
+					{3, 4},
+				},
+				Now: now,
+			},
+		},
 		{
 			name: "reject or",
 			flux: `import "join"
@@ -121,7 +154,7 @@ func TestEquiJoinPredicateRule(t *testing.T) {
 			)`,
 			wantErr: errors.New(
 				codes.Invalid,
-				"error in join function - some expressions are not yet supported in the `on` parameter: unsupported operator in join predicate: or",
+				"join.join: invalid on function: unsupported operator in join predicate: or, the comparisons must be joined with and @9:19-9:43",
 			),
 		},
 		{
@@ -140,7 +173,7 @@ func TestEquiJoinPredicateRule(t *testing.T) {
 			)`,
 			wantErr: errors.New(
 				codes.Invalid,
-				"error in join function - some expressions are not yet supported in the `on` parameter: binary expression operands must reference `l` or `r` only, and may not reference the same object",
+				"join.join: invalid on function: binary expression operands must reference `l` or `r` only, and may not reference the same object @9:19-9:29",
 			),
 		},
 		{
@@ -228,7 +261,7 @@ func TestEquiJoinPredicateRule(t *testing.T) {
 			)`,
 			wantErr: errors.New(
 				codes.Invalid,
-				"error in join function - some expressions are not yet supported in the `on` parameter: illegal expression type in join predicate: BooleanLiteral",
+				"join.join: invalid on function: illegal expression type in join predicate: BooleanLiteral @9:19-9:23",
 			),
 		},
 		{
@@ -250,7 +283,7 @@ func TestEquiJoinPredicateRule(t *testing.T) {
 			)`,
 			wantErr: errors.New(
 				codes.Invalid,
-				"error in join function - some expressions are not yet supported in the `on` parameter: function body should be a single logical expression that compares columns from each table",
+				"join.join: invalid on function: function body should be a single logical expression that compares columns from each table @9:9-12:6",
 			),
 		},
 		{
@@ -269,18 +302,119 @@ func TestEquiJoinPredicateRule(t *testing.T) {
 			)`,
 			wantErr: errors.New(
 				codes.Invalid,
-				"error in join function - some expressions are not yet supported in the `on` parameter: unsupported operator in join predicate: !=",
+				"join.join: invalid on function: unsupported operator in join predicate: !=, the columns must be compared with == @9:34-9:44",
+			),
+		},
+		{
+			name: "reject inequality",
+			flux: `import "join"
+			left = from(bucket: "b1", host: "http://localhost:8086")
+				|> filter(fn: (r) => r._measurement == "a")
+			right = from(bucket: "b2", host: "http://localhost:8086")
+				|> filter(fn: (r) => r._measurement == "b")
+			join.join(
+				left: left,
+				right: right,
+				on: (l, r) => l.a == r.b and l._time >= r._time,
+				as: (l, r) => ({l with c: r._value}),
+				method: "inner",
+			)`,
+			wantErr: errors.New(
+				codes.Invalid,
+				"join.join: invalid on function: unsupported operator in join predicate: >=, the columns must be compared with == @9:34-9:52",
 			),
 		},
+		{
+			name: "three column conjunction",
+			flux: `import "join"
+			left = from(bucket: "b1", host: "http://localhost:8086")
+				|> filter(fn: (r) => r._measurement == "a")
+			right = from(bucket: "b2", host: "http://localhost:8086")
+				|> filter(fn: (r) => r._measurement == "b")
+			join.join(
+				left: left,
+				right: right,
+				on: (l, r) => l.a == r.b and r.d == l.c and l._time == r._time,
+				as: (l, r) => ({l with c: r._value}),
+				method: "inner",
+			)`,
+			wantPairs: []join.ColumnPair{
+				join.ColumnPair{Left: "a", Right: "b"},
+				join.ColumnPair{Left: "c", Right: "d"},
+				join.ColumnPair{Left: "_time", Right: "_time"},
+			},
+			wantPlan: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreateLogicalNode("from0", &influxdb.FromProcedureSpec{}),
+					plan.CreateLogicalNode("filter1", &universe.FilterProcedureSpec{}),
+					plan.CreateLogicalNode("from2", &influxdb.FromProcedureSpec{}),
+					plan.CreateLogicalNode("filter3", &universe.FilterProcedureSpec{}),
+					plan.CreatePhysicalNode("join.join4", &join.EquiJoinProcedureSpec{}),
+				},
+				Edges: [][2]int{
+					{0, 1},
+					{2, 3},
+					{1, 4},
+					{3, 4},
+				},
+				Now: now,
+			},
+		},
+		{
+			name: "limit",
+			flux: `import "join"
+			left = from(bucket: "b1", host: "http://localhost:8086")
+				|> filter(fn: (r) => r._measurement == "a")
+			right = from(bucket: "b2", host: "http://localhost:8086")
+				|> filter(fn: (r) => r._measurement == "b")
+			join.join(
+				left: left,
+				right: right,
+				on: (l, r) => l.a == r.b,
+				as: (l, r) => ({l with c: r._value}),
+				method: "inner",
+				limit: 10,
+			)`,
+			wantPairs: []join.ColumnPair{
+				join.ColumnPair{Left: "a", Right: "b"},
+			},
+			wantLimit: 10,
+			wantPlan: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreateLogicalNode("from0", &influxdb.FromProcedureSpec{}),
+					plan.CreateLogicalNode("filter1", &universe.FilterProcedureSpec{}),
+					plan.CreateLogicalNode("from2", &influxdb.FromProcedureSpec{}),
+					plan.CreateLogicalNode("filter3", &universe.FilterProcedureSpec{}),
+					plan.CreatePhysicalNode("join.join4", &join.EquiJoinProcedureSpec{}),
+				},
+				Edges: [][2]int{
+					{0, 1},
+					{2, 3},
+					{1, 4},
+					{3, 4},
+				},
+				Now: now,
+			},
+		},
 	}
 	for _, tc := range testCases {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
 
+			// The on function is analyzed when the query is compiled
+			// so an invalid predicate is reported by the interpreter.
 			fluxSpec, err := compile(tc.flux, now)
 			if err != nil {
+				if tc.wantErr != nil {
+					if !strings.Contains(err.Error(), tc.wantErr.Error()) {
+						t.Fatalf("expected error: %s - got %s", tc.wantErr, err)
+					}
+					return
+				}
 				t.Fatalf("could not compile flux query: %v", err)
+			} else if tc.wantErr != nil {
+				t.Fatalf("expected error `%s` - got none", tc.wantErr)
 			}
 
 			logicalPlanner := plan.NewLogicalPlanner()
@@ -295,32 +429,27 @@ func TestEquiJoinPredicateRule(t *testing.T) {
 			physicalPlanner := plan.NewPhysicalPlanner(plan.OnlyPhysicalRules(&join.EquiJoinPredicateRule{}))
 			physicalPlan, err := physicalPlanner.Plan(context.Background(), logicalPlan)
 			if err != nil {
-				if tc.wantErr != nil {
-					if tc.wantErr.Error() != err.Error() {
-						t.Fatalf("expected error: %s - got %s", tc.wantErr, err)
-					}
-					return
-				} else {
-					t.Fatalf("got unexpected error: %s", err)
-				}
-			} else {
-				if tc.wantErr != nil {
-					t.Fatalf("expected error `%s` - got none", tc.wantErr)
-				}
+				t.Fatalf("got unexpected error: %s", err)
 			}
 
-			var pairs []join.ColumnPair
+			var (
+				pairs []join.ColumnPair
+				limit int64
+			)
 			for node := range physicalPlan.Roots {
 				spec, ok := node.ProcedureSpec().(*join.EquiJoinProcedureSpec)
 				if !ok {
 					continue
 				}
-				pairs = spec.On
+				pairs, limit = spec.On, spec.Limit
 				break
 			}
 			if diff := cmp.Diff(tc.wantPairs, pairs); diff != "" {
 				t.Errorf("unexpected column pairs; -want/+got:\n%v", diff)
 			}
+			if tc.wantLimit != 0 && tc.wantLimit != limit {
+				t.Errorf("unexpected limit -want/+got:\n\t- %d\n\t+ %d", tc.wantLimit, limit)
+			}
 
 			wantPlan := plantest.CreatePlanSpec(tc.wantPlan)
 			if err := plantest.ComparePlansShallow(wantPlan, physicalPlan); err != nil {
@@ -329,3 +458,27 @@ func TestEquiJoinPredicateRule(t *testing.T) {
 		})
 	}
 }
+
+func TestEquiJoinPredicateRule_Limit(t *testing.T) {
+	node := plan.CreateLogicalNode("join.join0", &join.JoinProcedureSpec{
+		Pairs:  []join.ColumnPair{{Left: "a", Right: "b"}},
+		Method: "inner",
+		Limit:  10,
+	})
+	got, changed, err := join.EquiJoinPredicateRule{}.Rewrite(context.Background(), node)
+	if err != nil {
+		t.Fatal(err)
+	} else if !changed {
+		t.Fatal("expected the join to be rewritten")
+	}
+
+	spec, ok := got.ProcedureSpec().(*join.EquiJoinProcedureSpec)
+	if !ok {
+		t.Fatalf("unexpected procedure spec %T", got.ProcedureSpec())
+	}
+	// The limit must survive copying the spec when the plan is finalized.
+	spec = spec.Copy().(*join.EquiJoinProcedureSpec)
+	if want, got := int64(10), spec.Limit; want != got {
+		t.Errorf("unexpected limit -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+}
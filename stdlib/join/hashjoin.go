@@ -0,0 +1,149 @@
+package join
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/semantic"
+	"github.com/influxdata/flux/values"
+)
+
+// hashEntrySize is an estimate of the memory used by each entry
+// of a hash table in addition to the size of its key.
+const hashEntrySize = 64
+
+// hashMatches finds the rows from the right table that are equal to
+// each row from the left table on the column pairs. It returns the
+// indices of the matching right rows for each left row in ascending
+// order.
+//
+// A hash table is built from the smaller of the two tables and is
+// probed with the rows of the other table. The memory used by the
+// hash table is accounted against the allocator and is released
+// before returning. Rows with a null or missing value in any of the
+// columns never match.
+func hashMatches(left, right joinTable, on []ColumnPair, mem memory.Allocator) ([][]int, error) {
+	leftCols := make([]string, len(on))
+	rightCols := make([]string, len(on))
+	for i, pair := range on {
+		leftCols[i], rightCols[i] = pair.Left, pair.Right
+	}
+
+	matches := make([][]int, len(left.rows))
+	if len(left.rows) == 0 || len(right.rows) == 0 {
+		return matches, nil
+	}
+
+	buildLeft := len(left.rows) < len(right.rows)
+	build, buildCols := right.rows, rightCols
+	probe, probeCols := left.rows, leftCols
+	if buildLeft {
+		build, buildCols = left.rows, leftCols
+		probe, probeCols = right.rows, rightCols
+	}
+
+	table, size, err := buildHashTable(build, buildCols, mem)
+	defer func() {
+		_ = mem.Account(-size)
+	}()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf []byte
+	for i, row := range probe {
+		var ok bool
+		buf, ok = appendHashKey(buf[:0], row, probeCols)
+		if !ok {
+			continue
+		}
+		for _, j := range table[string(buf)] {
+			// The probe side is visited in order so the right
+			// rows for each left row are already ascending.
+			if buildLeft {
+				matches[j] = append(matches[j], i)
+			} else {
+				matches[i] = append(matches[i], j)
+			}
+		}
+	}
+	return matches, nil
+}
+
+// buildHashTable maps the key of each row to the indices of the rows
+// with that key. It returns the number of bytes accounted against
+// the allocator, which must be released by the caller even if there
+// is an error.
+func buildHashTable(rows []values.Object, cols []string, mem memory.Allocator) (map[string][]int, int, error) {
+	table := make(map[string][]int)
+	size := 0
+	var buf []byte
+	for i, row := range rows {
+		var ok bool
+		buf, ok = appendHashKey(buf[:0], row, cols)
+		if !ok {
+			continue
+		}
+		n := 8
+		if _, exists := table[string(buf)]; !exists {
+			n += len(buf) + hashEntrySize
+		}
+		if err := mem.Account(n); err != nil {
+			return nil, size, err
+		}
+		size += n
+		table[string(buf)] = append(table[string(buf)], i)
+	}
+	return table, size, nil
+}
+
+// appendHashKey appends an encoding of the values in the columns of
+// the row to buf. Values that are equal have the same encoding.
+// It reports false if a value is null, missing or can never be equal
+// to another value.
+func appendHashKey(buf []byte, row values.Object, cols []string) ([]byte, bool) {
+	var scratch [binary.MaxVarintLen64]byte
+	for _, label := range cols {
+		v, ok := row.Get(label)
+		if !ok || v.IsNull() {
+			return buf, false
+		}
+		n := v.Type().Nature()
+		buf = append(buf, byte(n))
+		switch n {
+		case semantic.Bool:
+			if v.Bool() {
+				buf = append(buf, 1)
+			} else {
+				buf = append(buf, 0)
+			}
+		case semantic.Int:
+			buf = append(buf, scratch[:binary.PutVarint(scratch[:], v.Int())]...)
+		case semantic.UInt:
+			buf = append(buf, scratch[:binary.PutUvarint(scratch[:], v.UInt())]...)
+		case semantic.Float:
+			f := v.Float()
+			if math.IsNaN(f) {
+				return buf, false
+			} else if f == 0 {
+				// Negative zero is equal to zero.
+				f = 0
+			}
+			buf = append(buf, scratch[:binary.PutUvarint(scratch[:], math.Float64bits(f))]...)
+		case semantic.Time:
+			buf = append(buf, scratch[:binary.PutVarint(scratch[:], int64(v.Time()))]...)
+		case semantic.String:
+			s := v.Str()
+			buf = append(buf, scratch[:binary.PutUvarint(scratch[:], uint64(len(s)))]...)
+			buf = append(buf, s...)
+		case semantic.Bytes:
+			b := v.Bytes()
+			buf = append(buf, scratch[:binary.PutUvarint(scratch[:], uint64(len(b)))]...)
+			buf = append(buf, b...)
+		default:
+			return buf, false
+		}
+	}
+	return buf, true
+}
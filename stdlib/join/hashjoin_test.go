@@ -0,0 +1,176 @@
+package join
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/semantic"
+	"github.com/influxdata/flux/values"
+)
+
+func TestHashMatches(t *testing.T) {
+	row := func(id, name values.Value) values.Object {
+		return values.NewObjectWithValues(map[string]values.Value{
+			"id":   id,
+			"name": name,
+		})
+	}
+	str := values.NewString
+	nullString := values.NewNull(semantic.BasicString)
+
+	testCases := []struct {
+		name  string
+		on    []ColumnPair
+		left  []values.Object
+		right []values.Object
+		want  [][]int
+	}{
+		{
+			name: "duplicate keys on the build side",
+			on:   []ColumnPair{{Left: "id", Right: "id"}},
+			left: []values.Object{
+				row(values.NewInt(1), str("a")),
+				row(values.NewInt(2), str("b")),
+				row(values.NewInt(3), str("c")),
+			},
+			right: []values.Object{
+				row(values.NewInt(1), str("x")),
+				row(values.NewInt(1), str("y")),
+			},
+			want: [][]int{{0, 1}, nil, nil},
+		},
+		{
+			name: "duplicate keys on the probe side",
+			on:   []ColumnPair{{Left: "id", Right: "id"}},
+			left: []values.Object{
+				row(values.NewInt(1), str("a")),
+				row(values.NewInt(1), str("b")),
+			},
+			right: []values.Object{
+				row(values.NewInt(1), str("x")),
+				row(values.NewInt(1), str("y")),
+				row(values.NewInt(2), str("z")),
+			},
+			want: [][]int{{0, 1}, {0, 1}},
+		},
+		{
+			name: "null keys",
+			on:   []ColumnPair{{Left: "name", Right: "name"}},
+			left: []values.Object{
+				row(values.NewInt(1), nullString),
+				row(values.NewInt(2), str("b")),
+			},
+			right: []values.Object{
+				row(values.NewInt(1), nullString),
+				row(values.NewInt(2), str("b")),
+				row(values.NewInt(3), nullString),
+			},
+			want: [][]int{nil, {1}},
+		},
+		{
+			name: "missing keys",
+			on:   []ColumnPair{{Left: "id", Right: "other"}},
+			left: []values.Object{
+				row(values.NewInt(1), str("a")),
+			},
+			right: []values.Object{
+				row(values.NewInt(1), str("a")),
+			},
+			want: [][]int{nil},
+		},
+		{
+			name: "multiple columns",
+			on: []ColumnPair{
+				{Left: "id", Right: "id"},
+				{Left: "name", Right: "name"},
+			},
+			left: []values.Object{
+				row(values.NewInt(1), str("a")),
+				row(values.NewInt(1), str("b")),
+			},
+			right: []values.Object{
+				row(values.NewInt(1), str("b")),
+				row(values.NewInt(2), str("a")),
+			},
+			want: [][]int{nil, {0}},
+		},
+		{
+			name: "different types",
+			on:   []ColumnPair{{Left: "id", Right: "id"}},
+			left: []values.Object{
+				row(values.NewInt(1), str("a")),
+			},
+			right: []values.Object{
+				row(values.NewUInt(1), str("a")),
+				row(values.NewFloat(1), str("a")),
+			},
+			want: [][]int{nil},
+		},
+		{
+			name: "bytes keys",
+			on:   []ColumnPair{{Left: "id", Right: "id"}},
+			left: []values.Object{
+				row(values.NewBytes([]byte{1, 2}), str("a")),
+				row(values.NewBytes([]byte{1}), str("b")),
+				row(values.NewBytes([]byte{}), str("c")),
+			},
+			right: []values.Object{
+				row(values.NewBytes([]byte{1}), str("x")),
+				row(values.NewBytes([]byte{1, 2}), str("y")),
+				row(values.NewBytes([]byte{1, 2}), str("z")),
+				row(str("\x01"), str("w")),
+			},
+			want: [][]int{{1, 2}, {0}, nil},
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mem := &memory.ResourceAllocator{}
+			got, err := hashMatches(
+				joinTable{rows: tc.left},
+				joinTable{rows: tc.right},
+				tc.on,
+				mem,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !cmp.Equal(tc.want, got) {
+				t.Errorf("unexpected matches -want/+got\n%s", cmp.Diff(tc.want, got))
+			}
+			if got := mem.Allocated(); got != 0 {
+				t.Errorf("expected all memory to be released, but %d bytes are still allocated", got)
+			}
+		})
+	}
+}
+
+func TestHashMatches_MemoryLimit(t *testing.T) {
+	var rows []values.Object
+	for i := 0; i < 100; i++ {
+		rows = append(rows, values.NewObjectWithValues(map[string]values.Value{
+			"id": values.NewInt(int64(i)),
+		}))
+	}
+	limit := int64(1024)
+	mem := &memory.ResourceAllocator{Limit: &limit}
+	_, err := hashMatches(
+		joinTable{rows: rows},
+		joinTable{rows: rows},
+		[]ColumnPair{{Left: "id", Right: "id"}},
+		mem,
+	)
+	if err == nil {
+		t.Fatal("expected error, got none")
+	}
+	if want, got := codes.ResourceExhausted, errors.Code(err); want != got {
+		t.Errorf("unexpected error code -want/+got\n\t- %s\n\t+ %s", want, got)
+	}
+	if got := mem.Allocated(); got != 0 {
+		t.Errorf("expected all memory to be released, but %d bytes are still allocated", got)
+	}
+}
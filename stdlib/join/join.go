@@ -1,13 +1,24 @@
 package join
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sync"
+
 	"github.com/influxdata/flux"
 	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/compiler"
+	"github.com/influxdata/flux/dependencies/filesystem"
 	"github.com/influxdata/flux/execute"
 	"github.com/influxdata/flux/internal/errors"
 	"github.com/influxdata/flux/interpreter"
+	"github.com/influxdata/flux/memory"
 	"github.com/influxdata/flux/plan"
 	"github.com/influxdata/flux/runtime"
+	"github.com/influxdata/flux/semantic"
+	"github.com/influxdata/flux/values"
 )
 
 const Join2Kind = "join.join"
@@ -17,6 +28,10 @@ func init() {
 	runtime.RegisterPackageValue(
 		"join", "join", flux.MustValue(flux.FunctionValue("join", createJoinOpSpec, signature)),
 	)
+	asofSignature := runtime.MustLookupBuiltinType("join", "asof")
+	runtime.RegisterPackageValue(
+		"join", "asof", flux.MustValue(flux.FunctionValue("asof", createAsOfOpSpec, asofSignature)),
+	)
 	flux.RegisterOpSpec(Join2Kind, newJoinOp)
 	plan.RegisterProcedureSpec(Join2Kind, newJoinProcedure, Join2Kind)
 	execute.RegisterTransformation(Join2Kind, createJoinTransformation)
@@ -24,10 +39,13 @@ func init() {
 
 type JoinOpSpec struct {
 	on     interpreter.ResolvedFunction
+	pairs  []ColumnPair
 	as     interpreter.ResolvedFunction
 	left   *flux.TableObject
 	right  *flux.TableObject
 	method string
+	limit  int64
+	within flux.Duration
 }
 
 func (o *JoinOpSpec) Kind() flux.OperationKind {
@@ -38,10 +56,103 @@ func newJoinOp() flux.OperationSpec {
 	return new(JoinOpSpec)
 }
 
+func (o *JoinOpSpec) MarshalJSON() ([]byte, error) {
+	return marshalJoin(o.on, o.as, o.method, o.limit, o.within)
+}
+
+func (o *JoinOpSpec) UnmarshalJSON(data []byte) error {
+	j, err := unmarshalJoin(data)
+	if err != nil {
+		return err
+	}
+	*o = JoinOpSpec{
+		on:     j.on,
+		pairs:  j.pairs,
+		as:     j.as,
+		method: j.method,
+		limit:  j.limit,
+		within: j.within,
+	}
+	return nil
+}
+
 func createJoinOpSpec(args flux.Arguments, p *flux.Administration) (flux.OperationSpec, error) {
-	l, ok := args.Get("left")
-	if !ok {
-		return nil, errors.New(codes.Invalid, "missing required argument 'left'")
+	op, err := joinArgs(args, p)
+	if err != nil {
+		return nil, err
+	}
+
+	method, err := args.GetRequiredString("method")
+	if err != nil {
+		return nil, err
+	}
+	if err := validateMethod(method); err != nil {
+		return nil, err
+	}
+	op.method = method
+
+	// A cross join does not use the predicate.
+	if method != "cross" {
+		if op.pairs, err = equalityPairs(op.on.Fn); err != nil {
+			return nil, err
+		}
+	}
+
+	limit, ok, err := args.GetInt("limit")
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		limit = math.MaxInt64
+	} else if limit <= 0 {
+		return nil, errors.Newf(codes.Invalid, "limit must be positive, but was %d", limit)
+	}
+	op.limit = limit
+
+	within, ok, err := args.GetDuration("within")
+	if err != nil {
+		return nil, err
+	} else if ok {
+		if err := validateTolerance("within", within); err != nil {
+			return nil, err
+		} else if method == "cross" && !within.IsZero() {
+			return nil, errors.New(codes.Invalid, "within cannot be used with a cross join")
+		}
+	}
+	op.within = within
+	return op, nil
+}
+
+// createAsOfOpSpec creates a join with the asof method.
+// The tolerance is the within tolerance of the join.
+func createAsOfOpSpec(args flux.Arguments, p *flux.Administration) (flux.OperationSpec, error) {
+	op, err := joinArgs(args, p)
+	if err != nil {
+		return nil, err
+	}
+	op.method = "asof"
+	op.limit = math.MaxInt64
+	if op.pairs, err = equalityPairs(op.on.Fn); err != nil {
+		return nil, err
+	}
+
+	tolerance, ok, err := args.GetDuration("tolerance")
+	if err != nil {
+		return nil, err
+	} else if ok {
+		if err := validateTolerance("tolerance", tolerance); err != nil {
+			return nil, err
+		}
+	}
+	op.within = tolerance
+	return op, nil
+}
+
+// joinArgs reads the arguments that are shared by all of the joins
+// and adds the left and right streams as parents.
+func joinArgs(args flux.Arguments, p *flux.Administration) (*JoinOpSpec, error) {
+	l, err := args.GetRequired("left")
+	if err != nil {
+		return nil, err
 	}
 	left, ok := l.(*flux.TableObject)
 	if !ok {
@@ -49,9 +160,9 @@ func createJoinOpSpec(args flux.Arguments, p *flux.Administration) (flux.Operati
 	}
 	p.AddParent(left)
 
-	r, ok := args.Get("right")
-	if !ok {
-		return nil, errors.New(codes.Invalid, "missing required argument 'right'")
+	r, err := args.GetRequired("right")
+	if err != nil {
+		return nil, err
 	}
 	right, ok := r.(*flux.TableObject)
 	if !ok {
@@ -76,28 +187,45 @@ func createJoinOpSpec(args flux.Arguments, p *flux.Administration) (flux.Operati
 	if err != nil {
 		return nil, err
 	}
-
-	method, err := args.GetRequiredString("method")
-	if err != nil {
+	if err := validateAs(as); err != nil {
 		return nil, err
 	}
 
-	op := JoinOpSpec{
-		left:   left,
-		right:  right,
-		on:     on,
-		as:     as,
-		method: method,
+	return &JoinOpSpec{
+		left:  left,
+		right: right,
+		on:    on,
+		as:    as,
+	}, nil
+}
+
+// validateTolerance checks that a tolerance for matching the time
+// of the rows is not negative and does not depend on the calendar.
+func validateTolerance(name string, d flux.Duration) error {
+	if d.IsNegative() {
+		return errors.Newf(codes.Invalid, "%s must not be negative, but was %s", name, d)
+	} else if d.Months() != 0 {
+		return errors.Newf(codes.Invalid, "%s must not contain months or years, but was %s", name, d)
 	}
-	return &op, nil
+	return nil
 }
 
 type JoinProcedureSpec struct {
-	On     interpreter.ResolvedFunction
+	On interpreter.ResolvedFunction
+	// Pairs are the columns compared by the On function.
+	// It is nil for a cross join.
+	Pairs  []ColumnPair
 	As     interpreter.ResolvedFunction
 	Left   *flux.TableObject
 	Right  *flux.TableObject
 	Method string
+	// Limit is the maximum number of rows a cross join may produce.
+	// A limit of zero means there is no limit.
+	Limit int64
+	// Within is the tolerance for matching the _time column
+	// of the rows. A tolerance of zero means the rows are
+	// only matched with the predicate.
+	Within flux.Duration
 }
 
 func (p *JoinProcedureSpec) Kind() plan.ProcedureKind {
@@ -107,11 +235,34 @@ func (p *JoinProcedureSpec) Kind() plan.ProcedureKind {
 func (p *JoinProcedureSpec) Copy() plan.ProcedureSpec {
 	return &JoinProcedureSpec{
 		On:     p.On,
+		Pairs:  p.Pairs,
 		As:     p.As,
 		Left:   p.Left,
 		Right:  p.Right,
 		Method: p.Method,
+		Limit:  p.Limit,
+		Within: p.Within,
+	}
+}
+
+func (p *JoinProcedureSpec) MarshalJSON() ([]byte, error) {
+	return marshalJoin(p.On, p.As, p.Method, p.Limit, p.Within)
+}
+
+func (p *JoinProcedureSpec) UnmarshalJSON(data []byte) error {
+	j, err := unmarshalJoin(data)
+	if err != nil {
+		return err
+	}
+	*p = JoinProcedureSpec{
+		On:     j.on,
+		Pairs:  j.pairs,
+		As:     j.as,
+		Method: j.method,
+		Limit:  j.limit,
+		Within: j.within,
 	}
+	return nil
 }
 
 func newJoinProcedure(spec flux.OperationSpec, p plan.Administration) (plan.ProcedureSpec, error) {
@@ -121,10 +272,13 @@ func newJoinProcedure(spec flux.OperationSpec, p plan.Administration) (plan.Proc
 	}
 	proc := JoinProcedureSpec{
 		On:     s.on,
+		Pairs:  s.pairs,
 		As:     s.as,
 		Left:   s.left,
 		Right:  s.right,
 		Method: s.method,
+		Limit:  s.limit,
+		Within: s.within,
 	}
 	return &proc, nil
 }
@@ -135,5 +289,855 @@ func createJoinTransformation(
 	spec plan.ProcedureSpec,
 	a execute.Administration,
 ) (execute.Transformation, execute.Dataset, error) {
-	return nil, nil, errors.New(codes.Invalid, "the join package is not yet implemented")
+	s, ok := spec.(*JoinProcedureSpec)
+	if !ok {
+		return nil, nil, errors.Newf(codes.Internal, "invalid spec type %T", spec)
+	}
+	parents := a.Parents()
+	if len(parents) != 2 {
+		return nil, nil, errors.New(codes.Internal, "join expects exactly two parents")
+	}
+	cache := execute.NewTableBuilderCache(a.Allocator())
+	d := execute.NewDataset(id, mode, cache)
+	t, err := NewJoinTransformation(a.Context(), s, d, cache, parents[0], parents[1])
+	if err != nil {
+		return nil, nil, err
+	}
+	t.mem = a.Allocator()
+	return t, d, nil
+}
+
+// NewJoinTransformation creates a transformation that joins the tables
+// from the left and right parents. Both input streams are buffered
+// and joined once both parents have finished.
+func NewJoinTransformation(
+	ctx context.Context,
+	spec *JoinProcedureSpec,
+	d execute.Dataset,
+	cache execute.TableBuilderCache,
+	leftID, rightID execute.DatasetID,
+) (*joinTransformation, error) {
+	var on rowFn = newDynamicFn(spec.On)
+	if spec.Pairs != nil {
+		on = equalityFn(spec.Pairs)
+	}
+	t, err := newJoinTransformation(ctx, d, cache, spec.Method, on, newDynamicFn(spec.As), leftID, rightID)
+	if err != nil {
+		return nil, err
+	}
+	if spec.Limit > 0 {
+		t.limit = spec.Limit
+	}
+	t.within = spec.Within.Nanoseconds()
+	return t, nil
+}
+
+func validateMethod(method string) error {
+	switch method {
+	case "inner", "left", "right", "full", "cross", "semi", "anti", "asof":
+		return nil
+	default:
+		return errors.Newf(codes.Invalid, "invalid join method %q, must be one of \"inner\", \"left\", \"right\", \"full\", \"cross\", \"semi\", \"anti\" or \"asof\"", method)
+	}
+}
+
+// validateAs checks that the as function takes a record for each of
+// the parameters l and r and returns a record. Types that are not yet
+// known are accepted and are checked when the function is evaluated.
+func validateAs(as interpreter.ResolvedFunction) error {
+	invalid := func(format string, a ...interface{}) error {
+		return errors.Newf(codes.Invalid, "join.join: invalid as function: %s @%s", fmt.Sprintf(format, a...), as.Fn.Location())
+	}
+	isRecord := func(typ semantic.MonoType) bool {
+		return typ.Kind() == semantic.Record || typ.Kind() == semantic.Var
+	}
+
+	typ := as.Fn.TypeOf()
+	n, err := typ.NumArguments()
+	if err != nil {
+		return err
+	} else if n != 2 {
+		return invalid("must take exactly two parameters, l and r, but takes %d", n)
+	}
+	for i := 0; i < n; i++ {
+		arg, err := typ.Argument(i)
+		if err != nil {
+			return err
+		}
+		name := string(arg.Name())
+		if name != "l" && name != "r" {
+			return invalid("unexpected parameter %q, the parameters must be l and r", name)
+		}
+		argType, err := arg.TypeOf()
+		if err != nil {
+			return err
+		} else if !isRecord(argType) {
+			return invalid("parameter %s must be a record, got %s", name, argType)
+		}
+	}
+
+	ret, err := typ.ReturnType()
+	if err != nil {
+		return err
+	} else if !isRecord(ret) {
+		return invalid("must return a record, got %s", ret)
+	}
+	return nil
+}
+
+// joinJSON is the encoded form of a join. The on and as functions
+// are encoded as their Flux source and are analyzed again when
+// they are decoded, so a function may only refer to its parameters
+// and to the values in the prelude.
+type joinJSON struct {
+	On     string        `json:"on"`
+	As     string        `json:"as"`
+	Method string        `json:"method"`
+	Limit  int64         `json:"limit"`
+	Within flux.Duration `json:"within"`
+}
+
+// decodedJoin holds the values of a decoded join.
+type decodedJoin struct {
+	on, as interpreter.ResolvedFunction
+	pairs  []ColumnPair
+	method string
+	limit  int64
+	within flux.Duration
+}
+
+func marshalJoin(on, as interpreter.ResolvedFunction, method string, limit int64, within flux.Duration) ([]byte, error) {
+	onSrc, err := functionSource("on", on)
+	if err != nil {
+		return nil, err
+	}
+	asSrc, err := functionSource("as", as)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(joinJSON{
+		On:     onSrc,
+		As:     asSrc,
+		Method: method,
+		Limit:  limit,
+		Within: within,
+	})
+}
+
+func unmarshalJoin(data []byte) (*decodedJoin, error) {
+	var raw joinJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	if err := validateMethod(raw.Method); err != nil {
+		return nil, err
+	}
+	if raw.Limit < 0 {
+		return nil, errors.Newf(codes.Invalid, "limit must not be negative, but was %d", raw.Limit)
+	}
+	if err := validateTolerance("within", raw.Within); err != nil {
+		return nil, err
+	}
+
+	on, err := analyzeFunction("on", raw.On)
+	if err != nil {
+		return nil, err
+	}
+	as, err := analyzeFunction("as", raw.As)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateAs(as); err != nil {
+		return nil, err
+	}
+
+	j := &decodedJoin{
+		on:     on,
+		as:     as,
+		method: raw.Method,
+		limit:  raw.Limit,
+		within: raw.Within,
+	}
+	if j.method != "cross" {
+		if j.pairs, err = equalityPairs(on.Fn); err != nil {
+			return nil, err
+		}
+	}
+	return j, nil
+}
+
+// functionSource returns the Flux source of a resolved function.
+func functionSource(name string, fn interpreter.ResolvedFunction) (string, error) {
+	if fn.Fn == nil || fn.Fn.Loc.Source == "" {
+		return "", errors.Newf(codes.Internal, "cannot encode the %s function of a join without its source", name)
+	}
+	return fn.Fn.Loc.Source, nil
+}
+
+// analyzeFunction analyzes and evaluates the source of a function
+// in the prelude and resolves it.
+func analyzeFunction(name, src string) (interpreter.ResolvedFunction, error) {
+	ctx := context.Background()
+	pkg, err := runtime.AnalyzeSource(ctx, src)
+	if err != nil {
+		return interpreter.ResolvedFunction{}, errors.Wrapf(err, codes.Invalid, "invalid %s function", name)
+	}
+
+	itrp := interpreter.NewInterpreter(nil, nil)
+	se, err := itrp.Eval(ctx, pkg, runtime.Prelude(), runtime.StdLib())
+	if err != nil {
+		return interpreter.ResolvedFunction{}, errors.Wrapf(err, codes.Invalid, "invalid %s function", name)
+	} else if len(se) != 1 {
+		return interpreter.ResolvedFunction{}, errors.Newf(codes.Invalid, "invalid %s function: expected a single function expression", name)
+	}
+	fn, ok := se[0].Value.(values.Function)
+	if !ok {
+		return interpreter.ResolvedFunction{}, errors.Newf(codes.Invalid, "invalid %s function: got a value of type %s", name, se[0].Value.Type())
+	}
+	return interpreter.ResolveFunction(fn)
+}
+
+// rowFn is a function that is evaluated with a row from
+// the left table and a row from the right table.
+type rowFn interface {
+	Eval(ctx context.Context, l, r values.Object) (values.Value, error)
+}
+
+// dynamicFn compiles a user-defined function of the form
+// (l, r) => ... for the types of the rows it is called with.
+type dynamicFn struct {
+	fn       interpreter.ResolvedFunction
+	compiled map[string]compiler.Func
+}
+
+func newDynamicFn(fn interpreter.ResolvedFunction) *dynamicFn {
+	return &dynamicFn{
+		fn:       fn,
+		compiled: make(map[string]compiler.Func),
+	}
+}
+
+func (f *dynamicFn) Eval(ctx context.Context, l, r values.Object) (values.Value, error) {
+	inType := semantic.NewObjectType([]semantic.PropertyType{
+		{Key: []byte("l"), Value: l.Type()},
+		{Key: []byte("r"), Value: r.Type()},
+	})
+	typeKey := inType.CanonicalString()
+	fn, ok := f.compiled[typeKey]
+	if !ok {
+		var err error
+		fn, err = compiler.Compile(compiler.ToScope(f.fn.Scope), f.fn.Fn, inType)
+		if err != nil {
+			return nil, err
+		}
+		f.compiled[typeKey] = fn
+	}
+
+	args := values.NewObject(inType)
+	args.Set("l", l)
+	args.Set("r", r)
+	return fn.Eval(ctx, args)
+}
+
+// joinTable is a table from one of the parents that
+// has been read into memory as a list of records.
+// When the table is spilled, its rows are written
+// to the file and are read back with withRows.
+type joinTable struct {
+	key  flux.GroupKey
+	cols []flux.ColMeta
+	rows []values.Object
+	n    int
+
+	// size is the number of bytes accounted for the
+	// rows while they are in memory.
+	size int64
+	file filesystem.TempFile
+}
+
+type joinParentState struct {
+	mark       execute.Time
+	processing execute.Time
+	finished   bool
+	tables     []joinTable
+
+	// buffered is the number of bytes held in memory
+	// for each group key when spilling is enabled.
+	buffered *execute.GroupLookup
+}
+
+type joinTransformation struct {
+	execute.ExecutionNode
+	mu sync.Mutex
+
+	ctx   context.Context
+	d     execute.Dataset
+	cache execute.TableBuilderCache
+	mem   memory.Allocator
+
+	method string
+	on, as rowFn
+	limit  int64
+	within int64
+
+	// spillThreshold is the number of bytes buffered for a group
+	// key from a parent before its tables are spilled to disk.
+	// Spilling is disabled when it is zero.
+	spillThreshold int64
+
+	leftID, rightID execute.DatasetID
+	parentState     map[execute.DatasetID]*joinParentState
+	err             error
+}
+
+func newJoinTransformation(
+	ctx context.Context,
+	d execute.Dataset,
+	cache execute.TableBuilderCache,
+	method string,
+	on, as rowFn,
+	leftID, rightID execute.DatasetID,
+) (*joinTransformation, error) {
+	if err := validateMethod(method); err != nil {
+		return nil, err
+	}
+	return &joinTransformation{
+		ctx:            ctx,
+		d:              d,
+		cache:          cache,
+		mem:            memory.DefaultAllocator,
+		method:         method,
+		on:             on,
+		as:             as,
+		limit:          math.MaxInt64,
+		spillThreshold: spillThreshold(ctx),
+		leftID:         leftID,
+		rightID:        rightID,
+		parentState: map[execute.DatasetID]*joinParentState{
+			leftID:  {buffered: execute.NewGroupLookup()},
+			rightID: {buffered: execute.NewGroupLookup()},
+		},
+	}, nil
+}
+
+func (t *joinTransformation) RetractTable(id execute.DatasetID, key flux.GroupKey) error {
+	return errors.New(codes.Unimplemented, "join does not support retracting tables")
+}
+
+// Process reads the table into the buffer for the parent it came from.
+// The memory used by the rows is accounted against the allocator.
+// When spilling is enabled and the rows buffered for the group key
+// of the table use more than the threshold, the tables in memory
+// with that group key are spilled to disk.
+func (t *joinTransformation) Process(id execute.DatasetID, tbl flux.Table) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state, ok := t.parentState[id]
+	if !ok {
+		tbl.Done()
+		return errors.Newf(codes.Internal, "join received a table from an unknown parent %v", id)
+	}
+
+	rows, err := readRows(tbl)
+	if err != nil {
+		return err
+	}
+	return t.buffer(state, joinTable{
+		key:  tbl.Key(),
+		cols: tbl.Cols(),
+		rows: rows,
+		n:    len(rows),
+	})
+}
+
+// buffer adds the table to the tables of the parent.
+func (t *joinTransformation) buffer(state *joinParentState, tbl joinTable) error {
+	size := rowsSize(tbl.cols, tbl.rows)
+	var buffered int64
+	if t.spillThreshold > 0 {
+		if v, ok := state.buffered.Lookup(tbl.key); ok {
+			buffered = v.(int64)
+		}
+		if buffered+size > t.spillThreshold {
+			return t.spillKey(state, tbl)
+		}
+	}
+
+	if err := t.mem.Account(int(size)); err != nil {
+		return err
+	}
+	tbl.size = size
+	state.tables = append(state.tables, tbl)
+	if t.spillThreshold > 0 {
+		state.buffered.Set(tbl.key, buffered+size)
+	}
+	return nil
+}
+
+// spillKey spills the table along with the tables of the parent
+// that are still in memory and have the same group key.
+func (t *joinTransformation) spillKey(state *joinParentState, tbl joinTable) error {
+	state.tables = append(state.tables, tbl)
+	for i := range state.tables {
+		buffered := &state.tables[i]
+		if buffered.file != nil || !buffered.key.Equal(tbl.key) {
+			continue
+		}
+		if err := t.spill(buffered); err != nil {
+			return err
+		}
+		_ = t.mem.Account(-int(buffered.size))
+		buffered.size = 0
+	}
+	state.buffered.Set(tbl.key, int64(0))
+	return nil
+}
+
+// readRows reads each row of the table into a record.
+func readRows(tbl flux.Table) ([]values.Object, error) {
+	cols := tbl.Cols()
+	typ := rowType(cols)
+
+	var rows []values.Object
+	if err := tbl.Do(func(cr flux.ColReader) error {
+		for i, l := 0, cr.Len(); i < l; i++ {
+			row := values.NewObject(typ)
+			for j, c := range cols {
+				row.Set(c.Label, execute.ValueForRow(cr, i, j))
+			}
+			rows = append(rows, row)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (t *joinTransformation) UpdateWatermark(id execute.DatasetID, mark execute.Time) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.parentState[id].mark = mark
+
+	min := execute.Time(math.MaxInt64)
+	for _, state := range t.parentState {
+		if state.mark < min {
+			min = state.mark
+		}
+	}
+	return t.d.UpdateWatermark(min)
+}
+
+func (t *joinTransformation) UpdateProcessingTime(id execute.DatasetID, pt execute.Time) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.parentState[id].processing = pt
+
+	min := execute.Time(math.MaxInt64)
+	for _, state := range t.parentState {
+		if state.processing < min {
+			min = state.processing
+		}
+	}
+	return t.d.UpdateProcessingTime(min)
+}
+
+// Finish joins the buffered tables once both parents have finished.
+// If either parent finishes with an error, the join is skipped and
+// the first error is passed downstream. The files that tables were
+// spilled to are removed whether or not the join succeeds.
+func (t *joinTransformation) Finish(id execute.DatasetID, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// Ignore repeated finish calls from a parent that has
+	// already finished so the dataset is only finished once.
+	if state, ok := t.parentState[id]; !ok || state.finished {
+		return
+	}
+
+	// Only report the first error that occurs.
+	if t.err == nil && err != nil {
+		t.err = err
+	}
+
+	t.parentState[id].finished = true
+	for _, state := range t.parentState {
+		if !state.finished {
+			return
+		}
+	}
+
+	if t.err == nil {
+		t.err = t.join()
+	}
+	t.cleanup()
+	t.parentState = map[execute.DatasetID]*joinParentState{
+		t.leftID:  {finished: true, buffered: execute.NewGroupLookup()},
+		t.rightID: {finished: true, buffered: execute.NewGroupLookup()},
+	}
+	t.d.Finish(t.err)
+}
+
+// join evaluates the predicate for each pair of rows from tables
+// whose group keys agree on the columns they have in common.
+// The records produced by the as function are grouped by those
+// common group key columns. For an outer join, each row without
+// a match on the preserved side is passed to the as function with
+// a record whose properties are all null in place of the other
+// row and is grouped by the group key of its own table. A cross
+// join ignores the predicate and pairs every row from the left
+// table with every row from the right table. A semi join or an
+// anti join does not call the as function and passes through the
+// rows from the left table that have at least one match or no
+// match respectively, each row at most once. An as-of join pairs
+// each row from the left table with the most recent matching row
+// from the right table at or before its time, or with a record of
+// nulls when there is no such row.
+func (t *joinTransformation) join() error {
+	groups := execute.NewGroupLookup()
+	var keys []flux.GroupKey
+	addRecord := func(common []string, key flux.GroupKey, cols []flux.ColMeta, record values.Object) {
+		outKey := outputKey(common, key, record)
+		g, ok := groups.Lookup(outKey)
+		if !ok {
+			g = &joinGroup{cols: cols}
+			groups.Set(outKey, g)
+			keys = append(keys, outKey)
+		}
+		g.(*joinGroup).records = append(g.(*joinGroup).records, record)
+	}
+	add := func(common []string, key flux.GroupKey, l, r values.Object) error {
+		v, err := t.as.Eval(t.ctx, l, r)
+		if err != nil {
+			return err
+		} else if v.Type().Nature() != semantic.Object {
+			return errors.Newf(codes.Invalid, "join as function must return a record, got %s", v.Type())
+		}
+		addRecord(common, key, nil, v.Object())
+		return nil
+	}
+	filter := t.method == "semi" || t.method == "anti"
+
+	leftTables := t.parentState[t.leftID].tables
+	rightTables := t.parentState[t.rightID].tables
+	leftMatched := make([][]bool, len(leftTables))
+	for i, left := range leftTables {
+		leftMatched[i] = make([]bool, left.n)
+	}
+	rightMatched := make([][]bool, len(rightTables))
+	for j, right := range rightTables {
+		rightMatched[j] = make([]bool, right.n)
+	}
+
+	// The rows of a spilled table are only read back while the
+	// table is being joined so the other spilled tables stay on disk.
+	var produced int64
+	for i := range leftTables {
+		if err := t.withRows(leftTables[i], func(left joinTable) error {
+			for j := range rightTables {
+				common, ok := commonKeyColumns(left.key, rightTables[j].key)
+				if !ok {
+					continue
+				}
+				if err := t.withRows(rightTables[j], func(right joinTable) error {
+					if t.method == "cross" {
+						// Check the size of the product before computing it
+						// so a large product fails before using the memory.
+						n := int64(left.n) * int64(right.n)
+						if n > t.limit-produced {
+							return errors.Newf(codes.ResourceExhausted, "cross join produces more than the limit of %d rows", t.limit)
+						}
+						produced += n
+						for _, l := range left.rows {
+							for _, r := range right.rows {
+								if err := add(common, left.key, l, r); err != nil {
+									return err
+								}
+							}
+						}
+						return nil
+					}
+					matches, err := t.match(left, right)
+					if err != nil {
+						return err
+					}
+					for li, ris := range matches {
+						for _, ri := range ris {
+							leftMatched[i][li] = true
+							rightMatched[j][ri] = true
+							if filter {
+								continue
+							}
+							if err := add(common, left.key, left.rows[li], right.rows[ri]); err != nil {
+								return err
+							}
+						}
+					}
+					return nil
+				}); err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	// The unmatched rows can only be known once both sides
+	// have been fully read so they are added last.
+	if t.method == "left" || t.method == "full" || t.method == "asof" {
+		nullRight := nullRecord(rightTables)
+		for i := range leftTables {
+			if err := t.withRows(leftTables[i], func(left joinTable) error {
+				common := keyColumns(left.key)
+				for li, l := range left.rows {
+					if leftMatched[i][li] {
+						continue
+					}
+					if err := add(common, left.key, l, nullRight); err != nil {
+						return err
+					}
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	if filter {
+		keep := t.method == "semi"
+		for i := range leftTables {
+			if err := t.withRows(leftTables[i], func(left joinTable) error {
+				common := keyColumns(left.key)
+				for li, l := range left.rows {
+					if leftMatched[i][li] == keep {
+						addRecord(common, left.key, left.cols, l)
+					}
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	if t.method == "right" || t.method == "full" {
+		nullLeft := nullRecord(leftTables)
+		for j := range rightTables {
+			if err := t.withRows(rightTables[j], func(right joinTable) error {
+				common := keyColumns(right.key)
+				for ri, r := range right.rows {
+					if rightMatched[j][ri] {
+						continue
+					}
+					if err := add(common, right.key, nullLeft, r); err != nil {
+						return err
+					}
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, key := range keys {
+		g, _ := groups.Lookup(key)
+		if err := buildTable(t.cache, key, g.(*joinGroup)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// match returns the indices of the rows from the right table that
+// match each row from the left table. An as-of join only matches the
+// most recent right row for each left row. When there is a tolerance for
+// the time of the rows, only the rows within the tolerance are compared.
+// When the predicate only compares columns for equality the matches
+// are found with a hash join. Otherwise, the predicate is evaluated
+// for every pair of rows.
+func (t *joinTransformation) match(left, right joinTable) ([][]int, error) {
+	if t.method == "asof" {
+		return t.matchAsOf(left, right)
+	}
+	if t.within > 0 {
+		return t.matchWithin(left, right)
+	}
+	if on, ok := t.on.(equalityFn); ok {
+		return hashMatches(left, right, on, t.mem)
+	}
+
+	matches := make([][]int, len(left.rows))
+	for li, l := range left.rows {
+		for ri, r := range right.rows {
+			ok, err := t.predicate(l, r)
+			if err != nil {
+				return nil, err
+			} else if ok {
+				matches[li] = append(matches[li], ri)
+			}
+		}
+	}
+	return matches, nil
+}
+
+// predicate reports whether the on function is true for the rows.
+func (t *joinTransformation) predicate(l, r values.Object) (bool, error) {
+	match, err := t.on.Eval(t.ctx, l, r)
+	if err != nil {
+		return false, err
+	}
+	return !match.IsNull() && match.Type().Nature() == semantic.Bool && match.Bool(), nil
+}
+
+// keyColumns returns the labels of the columns in the group key.
+func keyColumns(key flux.GroupKey) []string {
+	labels := make([]string, len(key.Cols()))
+	for i, c := range key.Cols() {
+		labels[i] = c.Label
+	}
+	return labels
+}
+
+// nullRecord returns a record with a null property for each
+// column in the tables. It is used in place of the row from
+// the other side when a row from the preserved side has no match.
+func nullRecord(tables []joinTable) values.Object {
+	var cols []flux.ColMeta
+	for _, tbl := range tables {
+		for _, c := range tbl.cols {
+			if execute.ColIdx(c.Label, cols) < 0 {
+				cols = append(cols, c)
+			}
+		}
+	}
+
+	properties := make([]semantic.PropertyType, len(cols))
+	for j, c := range cols {
+		properties[j] = semantic.PropertyType{
+			Key:   []byte(c.Label),
+			Value: flux.SemanticType(c.Type),
+		}
+	}
+	record := values.NewObject(semantic.NewObjectType(properties))
+	for _, c := range cols {
+		record.Set(c.Label, values.NewNull(flux.SemanticType(c.Type)))
+	}
+	return record
+}
+
+// joinGroup holds the output records for a single group key.
+// The columns are set when the records are rows passed through
+// from an input table so the output keeps the order of its columns.
+type joinGroup struct {
+	cols    []flux.ColMeta
+	records []values.Object
+}
+
+// commonKeyColumns returns the labels of the columns that are in both
+// group keys. It reports false if the keys have different values
+// for any of those columns.
+func commonKeyColumns(left, right flux.GroupKey) ([]string, bool) {
+	var common []string
+	for j, c := range left.Cols() {
+		k := execute.ColIdx(c.Label, right.Cols())
+		if k < 0 {
+			continue
+		}
+		lv, rv := left.Value(j), right.Value(k)
+		if lv.IsNull() != rv.IsNull() || !lv.IsNull() && !lv.Equal(rv) {
+			return nil, false
+		}
+		common = append(common, c.Label)
+	}
+	return common, true
+}
+
+// outputKey constructs the group key for an output record from the
+// common group key columns that are present in the record.
+func outputKey(common []string, key flux.GroupKey, record values.Object) flux.GroupKey {
+	cols := make([]flux.ColMeta, 0, len(common))
+	vs := make([]values.Value, 0, len(common))
+	for _, label := range common {
+		v, ok := record.Get(label)
+		if !ok {
+			continue
+		}
+		typ := flux.ColumnType(v.Type())
+		if v.IsNull() {
+			typ = key.Cols()[execute.ColIdx(label, key.Cols())].Type
+		}
+		cols = append(cols, flux.ColMeta{Label: label, Type: typ})
+		vs = append(vs, v)
+	}
+	return execute.NewGroupKey(cols, vs)
+}
+
+// buildTable writes the records of the group to the table for the key.
+// The table has the columns of the group followed by a column for each
+// other property found in any of the records and properties missing
+// from a record are filled with nulls.
+func buildTable(cache execute.TableBuilderCache, key flux.GroupKey, g *joinGroup) error {
+	cols := append([]flux.ColMeta(nil), g.cols...)
+	records := g.records
+	for _, record := range records {
+		props, err := record.Type().SortedProperties()
+		if err != nil {
+			return err
+		}
+		for _, prop := range props {
+			label := semantic.NewSymbol(prop.Name()).Name()
+			v, ok := record.Get(label)
+			if !ok {
+				continue
+			}
+			typ := flux.ColumnType(v.Type())
+			if typ == flux.TInvalid {
+				if pt, err := prop.TypeOf(); err == nil {
+					typ = flux.ColumnType(pt)
+				}
+			}
+
+			j := execute.ColIdx(label, cols)
+			if j < 0 {
+				cols = append(cols, flux.ColMeta{Label: label, Type: typ})
+			} else if cols[j].Type == flux.TInvalid {
+				cols[j].Type = typ
+			} else if typ != flux.TInvalid && cols[j].Type != typ {
+				return errors.Newf(codes.Invalid, "join produced column %q with conflicting types %s and %s", label, cols[j].Type, typ)
+			}
+		}
+	}
+
+	builder, created := cache.TableBuilder(key)
+	if !created {
+		return errors.Newf(codes.Internal, "join found duplicate table with key: %v", key)
+	}
+	for _, c := range cols {
+		if c.Type == flux.TInvalid {
+			// The column only ever contained nulls of an unknown type.
+			continue
+		}
+		if _, err := builder.AddCol(c); err != nil {
+			return err
+		}
+	}
+	for _, record := range records {
+		for j, c := range builder.Cols() {
+			v, ok := record.Get(c.Label)
+			if !ok || v.IsNull() {
+				if err := builder.AppendNil(j); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := builder.AppendValue(j, v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
 }
@@ -0,0 +1,17 @@
+package join
+
+import (
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/interpreter"
+)
+
+func NewJoinOpSpec(on interpreter.ResolvedFunction, pairs []ColumnPair, as interpreter.ResolvedFunction, method string, limit int64, within flux.Duration) *JoinOpSpec {
+	return &JoinOpSpec{
+		on:     on,
+		pairs:  pairs,
+		as:     as,
+		method: method,
+		limit:  limit,
+		within: within,
+	}
+}
@@ -0,0 +1,2098 @@
+package join_test
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/dependencies/filesystem"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/execute/executetest"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/interpreter"
+	"github.com/influxdata/flux/plan"
+	"github.com/influxdata/flux/querytest"
+	"github.com/influxdata/flux/semantic/semantictest"
+	"github.com/influxdata/flux/stdlib/join"
+	"github.com/influxdata/flux/values/valuestest"
+)
+
+func TestJoin_Process(t *testing.T) {
+	testCases := []struct {
+		name       string
+		method     string
+		on         string
+		pairs      []join.ColumnPair // the columns compared by on, if it is an equijoin
+		as         string
+		left       []*executetest.Table
+		right      []*executetest.Table
+		rightFirst bool // process and finish the right stream before the left
+		limit      int64
+		within     flux.Duration
+		want       []*executetest.Table
+		wantErr    error
+	}{
+		{
+			name: "inner",
+			on:   `(l, r) => l._time == r._time`,
+			as:   `(l, r) => ({l with v_right: r._value})`,
+			left: []*executetest.Table{
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "t0", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 1.0, "a"},
+						{execute.Time(2), 2.0, "a"},
+						{execute.Time(3), 3.0, "a"},
+					},
+				},
+			},
+			right: []*executetest.Table{
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "t0", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 10.0, "a"},
+						{execute.Time(3), 30.0, "a"},
+						{execute.Time(4), 40.0, "a"},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "t0", Type: flux.TString},
+						{Label: "v_right", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 1.0, "a", 10.0},
+						{execute.Time(3), 3.0, "a", 30.0},
+					},
+				},
+			},
+		},
+		{
+			name: "simple inner",
+			on:   `(l, r) => l._time == r._time`,
+			as:   `(l, r) => ({_time: l._time, _value_a: l._value, _value_b: r._value})`,
+			left: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 1.0},
+						{execute.Time(2), 2.0},
+						{execute.Time(3), 3.0},
+					},
+				},
+			},
+			right: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 10.0},
+						{execute.Time(2), 20.0},
+						{execute.Time(3), 30.0},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value_a", Type: flux.TFloat},
+						{Label: "_value_b", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 1.0, 10.0},
+						{execute.Time(2), 2.0, 20.0},
+						{execute.Time(3), 3.0, 30.0},
+					},
+				},
+			},
+		},
+		{
+			name: "mismatched group keys",
+			on:   `(l, r) => l._time == r._time`,
+			as:   `(l, r) => ({l with t1: r.t1, v_right: r._value})`,
+			left: []*executetest.Table{
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "t0", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 1.0, "a"},
+						{execute.Time(2), 2.0, "a"},
+					},
+				},
+			},
+			right: []*executetest.Table{
+				{
+					KeyCols: []string{"t0", "t1"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "t0", Type: flux.TString},
+						{Label: "t1", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 10.0, "a", "x"},
+					},
+				},
+				{
+					KeyCols: []string{"t0", "t1"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "t0", Type: flux.TString},
+						{Label: "t1", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(2), 20.0, "a", "y"},
+					},
+				},
+				{
+					KeyCols: []string{"t0", "t1"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "t0", Type: flux.TString},
+						{Label: "t1", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 30.0, "b", "x"},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "t0", Type: flux.TString},
+						{Label: "t1", Type: flux.TString},
+						{Label: "v_right", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 1.0, "a", "x", 10.0},
+						{execute.Time(2), 2.0, "a", "y", 20.0},
+					},
+				},
+			},
+		},
+		{
+			name: "disjoint group keys",
+			on:   `(l, r) => l._time == r._time`,
+			as:   `(l, r) => ({_time: l._time, t0: l.t0, t1: r.t1})`,
+			left: []*executetest.Table{
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "t0", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), "a"},
+					},
+				},
+			},
+			right: []*executetest.Table{
+				{
+					KeyCols: []string{"t1"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "t1", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), "x"},
+					},
+				},
+				{
+					KeyCols: []string{"t1"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "t1", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), "y"},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "t0", Type: flux.TString},
+						{Label: "t1", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), "a", "x"},
+						{execute.Time(1), "a", "y"},
+					},
+				},
+			},
+		},
+		{
+			name: "multiple tables",
+			on:   `(l, r) => l._time == r._time`,
+			as:   `(l, r) => ({l with v_right: r._value})`,
+			left: []*executetest.Table{
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TInt},
+						{Label: "t0", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), int64(1), "a"},
+						{execute.Time(2), int64(2), "a"},
+					},
+				},
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TInt},
+						{Label: "t0", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), int64(3), "b"},
+						{execute.Time(2), int64(4), "b"},
+					},
+				},
+			},
+			right: []*executetest.Table{
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TInt},
+						{Label: "t0", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(2), int64(40), "b"},
+					},
+				},
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TInt},
+						{Label: "t0", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), int64(10), "a"},
+						{execute.Time(2), int64(20), "a"},
+					},
+				},
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TInt},
+						{Label: "t0", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), int64(50), "c"},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TInt},
+						{Label: "t0", Type: flux.TString},
+						{Label: "v_right", Type: flux.TInt},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), int64(1), "a", int64(10)},
+						{execute.Time(2), int64(2), "a", int64(20)},
+					},
+				},
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TInt},
+						{Label: "t0", Type: flux.TString},
+						{Label: "v_right", Type: flux.TInt},
+					},
+					Data: [][]interface{}{
+						{execute.Time(2), int64(4), "b", int64(40)},
+					},
+				},
+			},
+		},
+		{
+			name: "different schemas",
+			on:   `(l, r) => l.id == r.id`,
+			as:   `(l, r) => ({id: l.id, name: r.name, _value: l._value})`,
+			left: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "id", Type: flux.TInt},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{int64(1), 1.5},
+						{int64(2), 2.5},
+						{int64(2), 3.5},
+					},
+				},
+			},
+			right: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "id", Type: flux.TInt},
+						{Label: "name", Type: flux.TString},
+						{Label: "active", Type: flux.TBool},
+					},
+					Data: [][]interface{}{
+						{int64(2), "b", true},
+						{int64(3), "c", false},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "id", Type: flux.TInt},
+						{Label: "name", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{2.5, int64(2), "b"},
+						{3.5, int64(2), "b"},
+					},
+				},
+			},
+		},
+		{
+			name: "no matches",
+			on:   `(l, r) => l._value == r._value`,
+			as:   `(l, r) => ({l with right: r._value})`,
+			left: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TInt},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), int64(1)},
+					},
+				},
+			},
+			right: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TInt},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), int64(2)},
+					},
+				},
+			},
+			want: []*executetest.Table(nil),
+		},
+		{
+			name: "empty right",
+			on:   `(l, r) => l._time == r._time`,
+			as:   `(l, r) => ({l with right: r._value})`,
+			left: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TInt},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), int64(1)},
+					},
+				},
+			},
+			want: []*executetest.Table(nil),
+		},
+		{
+			name:   "left",
+			method: "left",
+			on:     `(l, r) => l._time == r._time`,
+			as:     `(l, r) => ({l with v_right: r._value})`,
+			left: []*executetest.Table{
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "t0", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 1.0, "a"},
+						{execute.Time(2), 2.0, "a"},
+						{execute.Time(3), 3.0, "a"},
+					},
+				},
+			},
+			right: []*executetest.Table{
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "t0", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 10.0, "a"},
+						{execute.Time(3), 30.0, "a"},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "t0", Type: flux.TString},
+						{Label: "v_right", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 1.0, "a", 10.0},
+						{execute.Time(3), 3.0, "a", 30.0},
+						{execute.Time(2), 2.0, "a", nil},
+					},
+				},
+			},
+		},
+		{
+			name:   "left with unmatched table",
+			method: "left",
+			on:     `(l, r) => l._time == r._time`,
+			as:     `(l, r) => ({l with v_right: r._value})`,
+			left: []*executetest.Table{
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "t0", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 1.0, "a"},
+					},
+				},
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "t0", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 5.0, "b"},
+					},
+				},
+			},
+			right: []*executetest.Table{
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "t0", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 10.0, "a"},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "t0", Type: flux.TString},
+						{Label: "v_right", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 1.0, "a", 10.0},
+					},
+				},
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "t0", Type: flux.TString},
+						{Label: "v_right", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 5.0, "b", nil},
+					},
+				},
+			},
+		},
+		{
+			// Without any right tables there is no type for the right
+			// columns, so columns that are always null are left out.
+			name:   "left with empty right",
+			method: "left",
+			on:     `(l, r) => l._time == r._time`,
+			as:     `(l, r) => ({l with v_right: r._value})`,
+			left: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TInt},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), int64(1)},
+						{execute.Time(2), int64(2)},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TInt},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), int64(1)},
+						{execute.Time(2), int64(2)},
+					},
+				},
+			},
+		},
+		{
+			// The right group key has a column that is not in the left
+			// group key. It must still be null for unmatched rows,
+			// including rows from a left table with no right counterpart.
+			name:   "left with right group key column",
+			method: "left",
+			on:     `(l, r) => l._time == r._time`,
+			as:     `(l, r) => ({l with t1: r.t1})`,
+			left: []*executetest.Table{
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "t0", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 1.0, "a"},
+						{execute.Time(2), 2.0, "a"},
+					},
+				},
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "t0", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 5.0, "b"},
+					},
+				},
+			},
+			right: []*executetest.Table{
+				{
+					KeyCols: []string{"t0", "t1"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "t0", Type: flux.TString},
+						{Label: "t1", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 10.0, "a", "x"},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "t0", Type: flux.TString},
+						{Label: "t1", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 1.0, "a", "x"},
+						{execute.Time(2), 2.0, "a", nil},
+					},
+				},
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "t0", Type: flux.TString},
+						{Label: "t1", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 5.0, "b", nil},
+					},
+				},
+			},
+		},
+		{
+			name:   "right",
+			method: "right",
+			on:     `(l, r) => l._time == r._time`,
+			as:     `(l, r) => ({r with v_left: l._value})`,
+			left: []*executetest.Table{
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "t0", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 1.0, "a"},
+						{execute.Time(2), 2.0, "a"},
+					},
+				},
+			},
+			right: []*executetest.Table{
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "t0", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 10.0, "a"},
+						{execute.Time(3), 30.0, "a"},
+					},
+				},
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "t0", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 50.0, "b"},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "t0", Type: flux.TString},
+						{Label: "v_left", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 10.0, "a", 1.0},
+						{execute.Time(3), 30.0, "a", nil},
+					},
+				},
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "t0", Type: flux.TString},
+						{Label: "v_left", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 50.0, "b", nil},
+					},
+				},
+			},
+		},
+		{
+			name:   "full",
+			method: "full",
+			on:     `(l, r) => l.id == r.id`,
+			as:     `(l, r) => ({id_left: l.id, id_right: r.id, name: r.name, v: l.v})`,
+			left: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "id", Type: flux.TInt},
+						{Label: "v", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{int64(1), 1.0},
+						{int64(2), 2.0},
+					},
+				},
+			},
+			right: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "id", Type: flux.TInt},
+						{Label: "name", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{int64(1), "one"},
+						{int64(3), "three"},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "id_left", Type: flux.TInt},
+						{Label: "id_right", Type: flux.TInt},
+						{Label: "name", Type: flux.TString},
+						{Label: "v", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{int64(1), int64(1), "one", 1.0},
+						{int64(2), nil, nil, 2.0},
+						{nil, int64(3), "three", nil},
+					},
+				},
+			},
+		},
+		{
+			// Without any left tables there is no type for the left
+			// columns, so columns that are always null are left out.
+			name:   "full with empty left",
+			method: "full",
+			on:     `(l, r) => l.id == r.id`,
+			as:     `(l, r) => ({id_left: l.id, id_right: r.id, name: r.name, v: l.v})`,
+			right: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "id", Type: flux.TInt},
+						{Label: "name", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{int64(1), "one"},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "id_right", Type: flux.TInt},
+						{Label: "name", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{int64(1), "one"},
+					},
+				},
+			},
+		},
+		{
+			name:   "cross",
+			method: "cross",
+			on:     `(l, r) => false`,
+			as:     `(l, r) => ({t0: l.t0, x: l.x, y: r.y})`,
+			left: []*executetest.Table{
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "t0", Type: flux.TString},
+						{Label: "x", Type: flux.TInt},
+					},
+					Data: [][]interface{}{
+						{"a", int64(1)},
+						{"a", int64(2)},
+					},
+				},
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "t0", Type: flux.TString},
+						{Label: "x", Type: flux.TInt},
+					},
+					Data: [][]interface{}{
+						{"b", int64(3)},
+					},
+				},
+			},
+			right: []*executetest.Table{
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "t0", Type: flux.TString},
+						{Label: "y", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{"a", "p"},
+						{"a", "q"},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "t0", Type: flux.TString},
+						{Label: "x", Type: flux.TInt},
+						{Label: "y", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{"a", int64(1), "p"},
+						{"a", int64(1), "q"},
+						{"a", int64(2), "p"},
+						{"a", int64(2), "q"},
+					},
+				},
+			},
+		},
+		{
+			name:   "cross single row",
+			method: "cross",
+			on:     `(l, r) => false`,
+			as:     `(l, r) => ({x: l.x, y: r.y})`,
+			limit:  1,
+			left: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "x", Type: flux.TInt},
+					},
+					Data: [][]interface{}{
+						{int64(1)},
+					},
+				},
+			},
+			right: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "y", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{"p"},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "x", Type: flux.TInt},
+						{Label: "y", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{int64(1), "p"},
+					},
+				},
+			},
+		},
+		{
+			name:   "cross zero rows",
+			method: "cross",
+			on:     `(l, r) => false`,
+			as:     `(l, r) => ({x: l.x, y: r.y})`,
+			limit:  1,
+			left: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "x", Type: flux.TInt},
+					},
+				},
+			},
+			right: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "y", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{"p"},
+						{"q"},
+					},
+				},
+			},
+			want: []*executetest.Table(nil),
+		},
+		{
+			name:   "cross exceeds limit",
+			method: "cross",
+			on:     `(l, r) => false`,
+			as:     `(l, r) => ({x: l.x, y: r.y})`,
+			limit:  3,
+			left: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "x", Type: flux.TInt},
+					},
+					Data: [][]interface{}{
+						{int64(1)},
+						{int64(2)},
+					},
+				},
+			},
+			right: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "y", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{"p"},
+						{"q"},
+					},
+				},
+			},
+			wantErr: errors.New(codes.ResourceExhausted, "cross join produces more than the limit of 3 rows"),
+		},
+		{
+			name:   "semi",
+			method: "semi",
+			on:     `(l, r) => l.id == r.id`,
+			as:     `(l, r) => ({name: r.name})`,
+			left: []*executetest.Table{
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "t0", Type: flux.TString},
+						{Label: "id", Type: flux.TInt},
+						{Label: "v", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{"a", int64(1), 1.5},
+						{"a", int64(2), 2.5},
+						{"a", int64(3), 3.5},
+					},
+				},
+			},
+			right: []*executetest.Table{
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "t0", Type: flux.TString},
+						{Label: "id", Type: flux.TInt},
+						{Label: "name", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{"a", int64(1), "one"},
+						{"a", int64(1), "uno"},
+						{"a", int64(3), "three"},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "t0", Type: flux.TString},
+						{Label: "id", Type: flux.TInt},
+						{Label: "v", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{"a", int64(1), 1.5},
+						{"a", int64(3), 3.5},
+					},
+				},
+			},
+		},
+		{
+			name:   "anti",
+			method: "anti",
+			on:     `(l, r) => l.id == r.id`,
+			as:     `(l, r) => ({name: r.name})`,
+			left: []*executetest.Table{
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "t0", Type: flux.TString},
+						{Label: "id", Type: flux.TInt},
+						{Label: "v", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{"a", int64(1), 1.5},
+						{"a", int64(2), 2.5},
+						{"a", int64(3), 3.5},
+					},
+				},
+			},
+			right: []*executetest.Table{
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "t0", Type: flux.TString},
+						{Label: "id", Type: flux.TInt},
+						{Label: "name", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{"a", int64(1), "one"},
+						{"a", int64(1), "uno"},
+						{"a", int64(3), "three"},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "t0", Type: flux.TString},
+						{Label: "id", Type: flux.TInt},
+						{Label: "v", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{"a", int64(2), 2.5},
+					},
+				},
+			},
+		},
+		{
+			name:   "semi with empty right",
+			method: "semi",
+			on:     `(l, r) => l.id == r.id`,
+			as:     `(l, r) => ({name: r.name})`,
+			left: []*executetest.Table{
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "t0", Type: flux.TString},
+						{Label: "id", Type: flux.TInt},
+						{Label: "v", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{"a", int64(1), 1.5},
+						{"a", int64(2), 2.5},
+						{"a", int64(3), 3.5},
+					},
+				},
+			},
+			want: []*executetest.Table(nil),
+		},
+		{
+			name:   "anti with empty right",
+			method: "anti",
+			on:     `(l, r) => l.id == r.id`,
+			as:     `(l, r) => ({name: r.name})`,
+			left: []*executetest.Table{
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "t0", Type: flux.TString},
+						{Label: "id", Type: flux.TInt},
+						{Label: "v", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{"a", int64(1), 1.5},
+						{"a", int64(2), 2.5},
+						{"a", int64(3), 3.5},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "t0", Type: flux.TString},
+						{Label: "id", Type: flux.TInt},
+						{Label: "v", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{"a", int64(1), 1.5},
+						{"a", int64(2), 2.5},
+						{"a", int64(3), 3.5},
+					},
+				},
+			},
+		},
+		{
+			name:   "within no rows in tolerance",
+			on:     `(l, r) => l.id == r.id`,
+			as:     `(l, r) => ({lt: l._time, rt: r._time})`,
+			within: flux.ConvertDuration(2),
+			left: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "id", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), "a"},
+						{execute.Time(10), "a"},
+					},
+				},
+			},
+			right: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "id", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(20), "a"},
+						{execute.Time(30), "a"},
+					},
+				},
+			},
+			want: []*executetest.Table(nil),
+		},
+		{
+			name:   "within multiple right rows",
+			on:     `(l, r) => l.id == r.id`,
+			as:     `(l, r) => ({lt: l._time, rt: r._time})`,
+			within: flux.ConvertDuration(2),
+			left: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "id", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(10), "a"},
+					},
+				},
+			},
+			right: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "id", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(13), "a"},
+						{execute.Time(8), "a"},
+						{execute.Time(9), "b"},
+						{execute.Time(10), "a"},
+						{execute.Time(12), "a"},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "lt", Type: flux.TTime},
+						{Label: "rt", Type: flux.TTime},
+					},
+					Data: [][]interface{}{
+						{execute.Time(10), execute.Time(8)},
+						{execute.Time(10), execute.Time(10)},
+						{execute.Time(10), execute.Time(12)},
+					},
+				},
+			},
+		},
+		{
+			name:   "within overlapping windows",
+			on:     `(l, r) => l.id == r.id`,
+			as:     `(l, r) => ({lt: l._time, rt: r._time})`,
+			within: flux.ConvertDuration(2),
+			left: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "id", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(11), "a"},
+						{execute.Time(10), "a"},
+					},
+				},
+			},
+			right: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "id", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(12), "a"},
+						{execute.Time(9), "a"},
+						{execute.Time(14), "a"},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "lt", Type: flux.TTime},
+						{Label: "rt", Type: flux.TTime},
+					},
+					Data: [][]interface{}{
+						{execute.Time(11), execute.Time(12)},
+						{execute.Time(11), execute.Time(9)},
+						{execute.Time(10), execute.Time(12)},
+						{execute.Time(10), execute.Time(9)},
+					},
+				},
+			},
+		},
+		{
+			name:       "left after right finished",
+			method:     "left",
+			on:         `(l, r) => l.id == r.id`,
+			as:         `(l, r) => ({id: l.id, name: r.name})`,
+			rightFirst: true,
+			left: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "id", Type: flux.TInt},
+					},
+					Data: [][]interface{}{
+						{int64(1)},
+						{int64(2)},
+					},
+				},
+			},
+			right: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "id", Type: flux.TInt},
+						{Label: "name", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{int64(2), "b"},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "id", Type: flux.TInt},
+						{Label: "name", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{int64(2), "b"},
+						{int64(1), nil},
+					},
+				},
+			},
+		},
+		{
+			name:   "asof left before all right rows",
+			method: "asof",
+			on:     `(l, r) => l.id == r.id`,
+			pairs:  []join.ColumnPair{{Left: "id", Right: "id"}},
+			as:     `(l, r) => ({_time: l._time, price: r.price})`,
+			left: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "id", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), "a"},
+						{execute.Time(5), "a"},
+						{execute.Time(10), "a"},
+					},
+				},
+			},
+			right: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "id", Type: flux.TString},
+						{Label: "price", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(8), "a", 2.0},
+						{execute.Time(3), "a", 1.0},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "price", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(5), 1.0},
+						{execute.Time(10), 2.0},
+						{execute.Time(1), nil},
+					},
+				},
+			},
+		},
+		{
+			name:   "asof multiple left rows share right row",
+			method: "asof",
+			on:     `(l, r) => l.id == r.id`,
+			as:     `(l, r) => ({_time: l._time, price: r.price})`,
+			left: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "id", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(4), "a"},
+						{execute.Time(5), "a"},
+						{execute.Time(6), "a"},
+					},
+				},
+			},
+			right: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "id", Type: flux.TString},
+						{Label: "price", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(3), "a", 1.0},
+						{execute.Time(4), "b", 9.0},
+						{execute.Time(10), "a", 2.0},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "price", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(4), 1.0},
+						{execute.Time(5), 1.0},
+						{execute.Time(6), 1.0},
+					},
+				},
+			},
+		},
+		{
+			name:   "asof duplicate right timestamps",
+			method: "asof",
+			on:     `(l, r) => l.id == r.id`,
+			pairs:  []join.ColumnPair{{Left: "id", Right: "id"}},
+			as:     `(l, r) => ({_time: l._time, price: r.price})`,
+			left: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "id", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(3), "a"},
+						{execute.Time(5), "a"},
+					},
+				},
+			},
+			right: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "id", Type: flux.TString},
+						{Label: "price", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(3), "a", 1.0},
+						{execute.Time(3), "a", 1.5},
+						{execute.Time(6), "a", 2.0},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "price", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(3), 1.5},
+						{execute.Time(5), 1.5},
+					},
+				},
+			},
+		},
+		{
+			name:   "asof tolerance equal to the gap",
+			method: "asof",
+			on:     `(l, r) => l.id == r.id`,
+			pairs:  []join.ColumnPair{{Left: "id", Right: "id"}},
+			as:     `(l, r) => ({_time: l._time, price: r.price})`,
+			within: flux.ConvertDuration(3),
+			left: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "id", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(5), "a"},
+						{execute.Time(10), "a"},
+						{execute.Time(11), "a"},
+					},
+				},
+			},
+			right: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "id", Type: flux.TString},
+						{Label: "price", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(7), "a", 1.0},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "price", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(10), 1.0},
+						{execute.Time(5), nil},
+						{execute.Time(11), nil},
+					},
+				},
+			},
+		},
+		{
+			name:   "asof predicate duplicate right timestamps",
+			method: "asof",
+			on:     `(l, r) => l.id == r.id`,
+			as:     `(l, r) => ({_time: l._time, price: r.price})`,
+			left: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "id", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(3), "a"},
+						{execute.Time(5), "a"},
+					},
+				},
+			},
+			right: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "id", Type: flux.TString},
+						{Label: "price", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(3), "a", 1.0},
+						{execute.Time(3), "a", 1.5},
+						{execute.Time(6), "a", 2.0},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "price", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(3), 1.5},
+						{execute.Time(5), 1.5},
+					},
+				},
+			},
+		},
+		{
+			name:   "asof predicate tolerance equal to the gap",
+			method: "asof",
+			on:     `(l, r) => l.id == r.id`,
+			as:     `(l, r) => ({_time: l._time, price: r.price})`,
+			within: flux.ConvertDuration(3),
+			left: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "id", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(5), "a"},
+						{execute.Time(10), "a"},
+						{execute.Time(11), "a"},
+					},
+				},
+			},
+			right: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "id", Type: flux.TString},
+						{Label: "price", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(7), "a", 1.0},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "price", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(10), 1.0},
+						{execute.Time(5), nil},
+						{execute.Time(11), nil},
+					},
+				},
+			},
+		},
+		{
+			name:       "asof after right finished",
+			method:     "asof",
+			on:         `(l, r) => l.id == r.id`,
+			pairs:      []join.ColumnPair{{Left: "id", Right: "id"}},
+			as:         `(l, r) => ({_time: l._time, price: r.price})`,
+			rightFirst: true,
+			left: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "id", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(5), "a"},
+						{execute.Time(100), "a"},
+					},
+				},
+			},
+			right: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "id", Type: flux.TString},
+						{Label: "price", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), "a", 1.0},
+						{execute.Time(2), "a", 2.0},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "price", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(5), 2.0},
+						{execute.Time(100), 2.0},
+					},
+				},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		// Each case is run again with every table spilled
+		// to disk, which must produce the same result.
+		for _, spill := range []bool{false, true} {
+			spill := spill
+			name := tc.name
+			if spill {
+				name += " spilled"
+			}
+			t.Run(name, func(t *testing.T) {
+				spec := &join.JoinProcedureSpec{
+					On: interpreter.ResolvedFunction{
+						Fn:    executetest.FunctionExpression(t, tc.on),
+						Scope: valuestest.Scope(),
+					},
+					As: interpreter.ResolvedFunction{
+						Fn:    executetest.FunctionExpression(t, tc.as),
+						Scope: valuestest.Scope(),
+					},
+					Pairs:  tc.pairs,
+					Method: tc.method,
+					Limit:  tc.limit,
+					Within: tc.within,
+				}
+				if spec.Method == "" {
+					spec.Method = "inner"
+				}
+				ctx := context.Background()
+				if spill {
+					ctx = spillContext()
+				}
+				got, err := runJoin(ctx, spec, copyTables(tc.left), copyTables(tc.right), tc.rightFirst)
+				if err != nil {
+					if tc.wantErr == nil {
+						t.Fatalf("got unexpected error: %s", err)
+					} else if err.Error() != tc.wantErr.Error() {
+						t.Fatalf("got unexpected error: wanted %q, got %q", tc.wantErr, err)
+					}
+					return
+				} else if tc.wantErr != nil {
+					t.Fatalf("expected error %q, but got none", tc.wantErr)
+				}
+
+				executetest.NormalizeTables(got)
+				executetest.NormalizeTables(tc.want)
+
+				sort.Sort(executetest.SortedTables(got))
+				sort.Sort(executetest.SortedTables(tc.want))
+
+				if !cmp.Equal(tc.want, got) {
+					t.Errorf("unexpected tables -want/+got\n%s", cmp.Diff(tc.want, got))
+				}
+			})
+		}
+	}
+}
+
+func TestJoin_InvalidMethod(t *testing.T) {
+	spec := &join.JoinProcedureSpec{
+		Method: "outer",
+	}
+	d := executetest.NewDataset(executetest.RandomDatasetID())
+	c := execute.NewTableBuilderCache(executetest.UnlimitedAllocator)
+	_, err := join.NewJoinTransformation(context.Background(), spec, d, c, executetest.RandomDatasetID(), executetest.RandomDatasetID())
+	if err == nil {
+		t.Fatal("expected error, got none")
+	}
+	if want, got := `invalid join method "outer", must be one of "inner", "left", "right", "full", "cross", "semi", "anti" or "asof"`, err.Error(); want != got {
+		t.Errorf("unexpected error -want/+got\n\t- %s\n\t+ %s", want, got)
+	}
+}
+
+func TestJoin_InvalidMethodArgument(t *testing.T) {
+	_, err := compile(`import "join"
+		left = from(bucket: "b1", host: "http://localhost:8086")
+		right = from(bucket: "b2", host: "http://localhost:8086")
+		join.join(
+			left: left,
+			right: right,
+			on: (l, r) => l.a == r.b,
+			as: (l, r) => ({l with c: r._value}),
+			method: "outer",
+		)`, time.Now().UTC())
+	if err == nil {
+		t.Fatal("expected error, got none")
+	}
+	if want, got := codes.Invalid, errors.Code(err); want != got {
+		t.Errorf("unexpected error code -want/+got\n\t- %s\n\t+ %s", want, got)
+	}
+	if want := `invalid join method "outer", must be one of "inner", "left", "right", "full", "cross", "semi", "anti" or "asof"`; !strings.Contains(err.Error(), want) {
+		t.Errorf("expected error to contain %q, got %q", want, err)
+	}
+}
+
+func TestJoinOperation_Marshaling(t *testing.T) {
+	fn := func(src string) interpreter.ResolvedFunction {
+		return interpreter.ResolvedFunction{
+			Fn: executetest.FunctionExpression(t, src),
+		}
+	}
+
+	testCases := []struct {
+		name string
+		data []byte
+		spec *join.JoinOpSpec
+	}{
+		{
+			name: "inner",
+			data: []byte(`{
+				"id": "join",
+				"kind": "join.join",
+				"spec": {
+					"on": "(l, r) => l.id == r.id",
+					"as": "(l, r) => ({l with v: r._value})",
+					"method": "inner",
+					"limit": 9223372036854775807,
+					"within": "0ns"
+				}
+			}`),
+			spec: join.NewJoinOpSpec(
+				fn(`(l, r) => l.id == r.id`),
+				[]join.ColumnPair{{Left: "id", Right: "id"}},
+				fn(`(l, r) => ({l with v: r._value})`),
+				"inner",
+				math.MaxInt64,
+				flux.ConvertDuration(0),
+			),
+		},
+		{
+			name: "cross",
+			data: []byte(`{
+				"id": "join",
+				"kind": "join.join",
+				"spec": {
+					"on": "(l, r) => true",
+					"as": "(l, r) => ({l: l._value, r: r._value})",
+					"method": "cross",
+					"limit": 100,
+					"within": "0ns"
+				}
+			}`),
+			spec: join.NewJoinOpSpec(
+				fn(`(l, r) => true`),
+				nil,
+				fn(`(l, r) => ({l: l._value, r: r._value})`),
+				"cross",
+				100,
+				flux.ConvertDuration(0),
+			),
+		},
+		{
+			name: "asof",
+			data: []byte(`{
+				"id": "join",
+				"kind": "join.join",
+				"spec": {
+					"on": "(l, r) => l.id == r.id and l.host == r.name",
+					"as": "(l, r) => ({_time: l._time, price: r.price, id: string(v: l.id)})",
+					"method": "asof",
+					"limit": 9223372036854775807,
+					"within": "5s"
+				}
+			}`),
+			spec: join.NewJoinOpSpec(
+				fn(`(l, r) => l.id == r.id and l.host == r.name`),
+				[]join.ColumnPair{{Left: "id", Right: "id"}, {Left: "host", Right: "name"}},
+				fn(`(l, r) => ({_time: l._time, price: r.price, id: string(v: l.id)})`),
+				"asof",
+				math.MaxInt64,
+				flux.ConvertDuration(5*time.Second),
+			),
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			op := &flux.Operation{
+				ID:   "join",
+				Spec: tc.spec,
+			}
+			querytest.OperationMarshalingTestHelper(t, tc.data, op)
+		})
+	}
+}
+
+func TestJoinProcedureSpec_Marshaling(t *testing.T) {
+	as := interpreter.ResolvedFunction{
+		Fn: executetest.FunctionExpression(t, `(l, r) => ({l with v: r._value})`),
+	}
+	testCases := []struct {
+		name string
+		want plan.ProcedureSpec
+		got  plan.ProcedureSpec
+	}{
+		{
+			name: "join",
+			want: &join.JoinProcedureSpec{
+				On: interpreter.ResolvedFunction{
+					Fn: executetest.FunctionExpression(t, `(l, r) => l.id == r.id`),
+				},
+				Pairs:  []join.ColumnPair{{Left: "id", Right: "id"}},
+				As:     as,
+				Method: "left",
+				Limit:  10,
+				Within: flux.ConvertDuration(time.Minute),
+			},
+			got: new(join.JoinProcedureSpec),
+		},
+		{
+			name: "equijoin",
+			want: &join.EquiJoinProcedureSpec{
+				On: []join.ColumnPair{
+					{Left: "id", Right: "id"},
+					{Left: "host", Right: "hostname"},
+				},
+				As:     as,
+				Method: "inner",
+				Limit:  10,
+				Within: flux.ConvertDuration(time.Minute),
+			},
+			got: new(join.EquiJoinProcedureSpec),
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := json.Marshal(tc.want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal(data, tc.got); err != nil {
+				t.Fatal(err)
+			}
+
+			opts := append(
+				semantictest.CmpOptions,
+				cmpopts.IgnoreFields(interpreter.ResolvedFunction{}, "Scope"),
+			)
+			if !cmp.Equal(tc.want, tc.got, opts...) {
+				t.Errorf("unexpected procedure spec -want/+got:\n%s", cmp.Diff(tc.want, tc.got, opts...))
+			}
+		})
+	}
+}
+
+func TestJoinOperation_UnmarshalError(t *testing.T) {
+	testCases := []struct {
+		name    string
+		data    string
+		wantErr error
+	}{
+		{
+			name:    "invalid method",
+			data:    `{"on": "(l, r) => l.id == r.id", "as": "(l, r) => l", "method": "outer", "limit": 1, "within": "0ns"}`,
+			wantErr: errors.New(codes.Invalid, `invalid join method "outer", must be one of "inner", "left", "right", "full", "cross", "semi", "anti" or "asof"`),
+		},
+		{
+			name:    "negative within",
+			data:    `{"on": "(l, r) => l.id == r.id", "as": "(l, r) => l", "method": "inner", "limit": 1, "within": "-1s"}`,
+			wantErr: errors.New(codes.Invalid, "within must not be negative, but was -1s"),
+		},
+		{
+			name:    "negative limit",
+			data:    `{"on": "(l, r) => l.id == r.id", "as": "(l, r) => l", "method": "inner", "limit": -1, "within": "0ns"}`,
+			wantErr: errors.New(codes.Invalid, "limit must not be negative, but was -1"),
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			err := json.Unmarshal([]byte(tc.data), new(join.JoinOpSpec))
+			if err == nil {
+				t.Fatal("expected error")
+			} else if err.Error() != tc.wantErr.Error() {
+				t.Fatalf("got unexpected error: wanted %q, got %q", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestJoin_NewQuery(t *testing.T) {
+	testCases := []querytest.NewQueryTestCase{
+		{
+			Name: "as with an extra parameter",
+			Raw: `import "join"
+left = from(bucket: "b1", host: "http://localhost:8086")
+right = from(bucket: "b2", host: "http://localhost:8086")
+join.join(
+	left: left,
+	right: right,
+	on: (l, r) => l.a == r.b,
+	as: (l, r, x=1) => ({l with c: r._value}),
+	method: "inner",
+)`,
+			WantErr:    true,
+			WantErrMsg: `join.join: invalid as function: must take exactly two parameters, l and r, but takes 3 @8:6-8:43`,
+		},
+		{
+			Name: "asof with a negative tolerance",
+			Raw: `import "join"
+left = from(bucket: "b1", host: "http://localhost:8086")
+right = from(bucket: "b2", host: "http://localhost:8086")
+join.asof(
+	left: left,
+	right: right,
+	on: (l, r) => l.id == r.id,
+	as: (l, r) => ({l with price: r.price}),
+	tolerance: -1s,
+)`,
+			WantErr:    true,
+			WantErrMsg: `tolerance must not be negative, but was -1s`,
+		},
+		{
+			Name: "asof with a predicate that is not an equality",
+			Raw: `import "join"
+left = from(bucket: "b1", host: "http://localhost:8086")
+right = from(bucket: "b2", host: "http://localhost:8086")
+join.asof(
+	left: left,
+	right: right,
+	on: (l, r) => l.id != r.id,
+	as: (l, r) => ({l with price: r.price}),
+)`,
+			WantErr:    true,
+			WantErrMsg: `unsupported operator in join predicate: !=, the columns must be compared with ==`,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			querytest.NewQueryTestHelper(t, tc)
+		})
+	}
+}
+
+func TestJoin_FinishError(t *testing.T) {
+	table := func() *executetest.Table {
+		return &executetest.Table{
+			ColMeta: []flux.ColMeta{
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_value", Type: flux.TFloat},
+			},
+			Data: [][]interface{}{
+				{execute.Time(1), 1.0},
+			},
+		}
+	}
+	for _, tc := range []struct {
+		name              string
+		leftErr, rightErr error
+		rightFirst        bool
+		wantErr           string
+	}{
+		{
+			name:    "left",
+			leftErr: errors.New(codes.Internal, "left failed"),
+			wantErr: "left failed",
+		},
+		{
+			name:     "right",
+			rightErr: errors.New(codes.Internal, "right failed"),
+			wantErr:  "right failed",
+		},
+		{
+			name:       "right finishes first",
+			leftErr:    errors.New(codes.Internal, "left failed"),
+			rightErr:   errors.New(codes.Internal, "right failed"),
+			rightFirst: true,
+			wantErr:    "right failed",
+		},
+		{
+			name:     "both",
+			leftErr:  errors.New(codes.Internal, "left failed"),
+			rightErr: errors.New(codes.Internal, "right failed"),
+			wantErr:  "left failed",
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			leftID := executetest.RandomDatasetID()
+			rightID := executetest.RandomDatasetID()
+
+			d := executetest.NewDataset(executetest.RandomDatasetID())
+			c := execute.NewTableBuilderCache(executetest.UnlimitedAllocator)
+			c.SetTriggerSpec(plan.DefaultTriggerSpec)
+			jt, err := join.NewJoinTransformation(context.Background(), &join.JoinProcedureSpec{Method: "inner"}, d, c, leftID, rightID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := jt.Process(leftID, table()); err != nil {
+				t.Fatal(err)
+			}
+			if err := jt.Process(rightID, table()); err != nil {
+				t.Fatal(err)
+			}
+			if tc.rightFirst {
+				jt.Finish(rightID, tc.rightErr)
+				jt.Finish(leftID, tc.leftErr)
+			} else {
+				jt.Finish(leftID, tc.leftErr)
+				jt.Finish(rightID, tc.rightErr)
+			}
+			// A repeated finish must not finish the dataset again.
+			jt.Finish(leftID, tc.leftErr)
+
+			if d.FinishedErr == nil {
+				t.Fatalf("expected error %q, got none", tc.wantErr)
+			} else if got := d.FinishedErr.Error(); got != tc.wantErr {
+				t.Errorf("unexpected error -want/+got\n\t- %s\n\t+ %s", tc.wantErr, got)
+			}
+
+			got, err := executetest.TablesFromCache(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != 0 {
+				t.Errorf("expected no tables after an error, got %d", len(got))
+			}
+		})
+	}
+}
+
+// spillContext returns a context that spills every
+// table buffered by a join to a temporary file.
+func spillContext() context.Context {
+	deps := execute.DefaultExecutionDependencies()
+	deps.ExecutionOptions.SpillThreshold = 1
+	ctx := deps.Inject(context.Background())
+	return filesystem.Inject(ctx, filesystem.SystemFS)
+}
+
+// copyTables returns a copy of each table so
+// the tables can be read more than once.
+func copyTables(tables []*executetest.Table) []*executetest.Table {
+	cp := make([]*executetest.Table, len(tables))
+	for i, tbl := range tables {
+		tbl := *tbl
+		cp[i] = &tbl
+	}
+	return cp
+}
+
+// runJoin sends the left and right tables to a join transformation
+// and returns the tables that it produces. If rightFirst is set, the
+// right stream is processed and finished before the left stream.
+func runJoin(ctx context.Context, spec *join.JoinProcedureSpec, left, right []*executetest.Table, rightFirst bool) ([]*executetest.Table, error) {
+	leftID := executetest.RandomDatasetID()
+	rightID := executetest.RandomDatasetID()
+
+	d := executetest.NewDataset(executetest.RandomDatasetID())
+	c := execute.NewTableBuilderCache(executetest.UnlimitedAllocator)
+	c.SetTriggerSpec(plan.DefaultTriggerSpec)
+	jt, err := join.NewJoinTransformation(ctx, spec, d, c, leftID, rightID)
+	if err != nil {
+		return nil, err
+	}
+
+	processLeft := func() {
+		var err error
+		for _, tbl := range left {
+			if err = jt.Process(leftID, tbl); err != nil {
+				break
+			}
+		}
+		jt.Finish(leftID, err)
+	}
+	processRight := func() {
+		var err error
+		for _, tbl := range right {
+			if err = jt.Process(rightID, tbl); err != nil {
+				break
+			}
+		}
+		jt.Finish(rightID, err)
+	}
+	if rightFirst {
+		processRight()
+		processLeft()
+	} else {
+		processLeft()
+		processRight()
+	}
+	if d.FinishedErr != nil {
+		return nil, d.FinishedErr
+	}
+	return executetest.TablesFromCache(c)
+}
@@ -0,0 +1,234 @@
+package join
+
+import (
+	"context"
+
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/plan"
+	"github.com/influxdata/flux/semantic"
+	"github.com/influxdata/flux/stdlib/universe"
+)
+
+func init() {
+	plan.RegisterLogicalRules(PushFilterBelowJoinRule{})
+}
+
+// PushFilterBelowJoinRule pushes the predicates of a filter that follows
+// a join onto the input of the join that the filtered columns come from.
+//
+//     L   R          L        R
+//      \ /           |        |
+//      join    =>  filter  filter
+//       |             \      /
+//     filter            join
+//
+// The filter is split into the predicates that are joined with and.
+// A predicate is pushed when every column that it references is a copy
+// of a column with the same name from one side of the join, and when
+// removing rows from that side before the join removes the same rows from
+// the output. That is either side of an inner or cross join, the left side
+// of a left, as-of, semi or anti join, and the right side of a right join.
+// The predicates that cannot be pushed remain in a filter after the join.
+type PushFilterBelowJoinRule struct{}
+
+func (PushFilterBelowJoinRule) Name() string {
+	return "pushFilterBelowJoin"
+}
+
+func (PushFilterBelowJoinRule) Pattern() plan.Pattern {
+	return plan.Pat(universe.FilterKind, plan.Pat(Join2Kind, plan.Any(), plan.Any()))
+}
+
+func (PushFilterBelowJoinRule) Rewrite(ctx context.Context, n plan.Node) (plan.Node, bool, error) {
+	filterSpec, ok := n.ProcedureSpec().(*universe.FilterProcedureSpec)
+	if !ok {
+		return nil, false, errors.New(codes.Internal, "invalid spec type on filter node")
+	}
+	joinNode := n.Predecessors()[0]
+	joinSpec, ok := joinNode.ProcedureSpec().(*JoinProcedureSpec)
+	if !ok {
+		return nil, false, errors.New(codes.Internal, "invalid spec type on join node")
+	}
+
+	// Filtering the input can only keep an empty table
+	// from the input, which is not the same as keeping
+	// the empty tables of the output. The join must also
+	// not be used by anything other than the filter.
+	if filterSpec.KeepEmptyTables || len(joinNode.Successors()) != 1 {
+		return n, false, nil
+	}
+	fn := filterSpec.Fn.Fn
+	if fn == nil || fn.Block == nil || fn.Parameters == nil || len(fn.Parameters.List) != 1 {
+		return n, false, nil
+	}
+	body, ok := fn.GetFunctionBodyExpression()
+	if !ok {
+		return n, false, nil
+	}
+	sides := pushableSides(joinSpec.Method)
+	if len(sides) == 0 {
+		return n, false, nil
+	}
+	copies, ok := copiedColumns(joinSpec)
+	if !ok {
+		return n, false, nil
+	}
+
+	param := fn.Parameters.List[0].Key.Name.Name()
+	pushed := make(map[string][]semantic.Expression)
+	var kept []semantic.Expression
+	for _, expr := range semantic.ConjunctionsToExprSlice(body) {
+		side, ok := predicateSide(expr, param, copies)
+		if ok && sides[side] {
+			pushed[side] = append(pushed[side], expr)
+		} else {
+			kept = append(kept, expr)
+		}
+	}
+	if len(pushed) == 0 {
+		return n, false, nil
+	}
+
+	for i, side := range []struct{ param, name string }{
+		{param: "l", name: "left"},
+		{param: "r", name: "right"},
+	} {
+		exprs, ok := pushed[side.param]
+		if !ok {
+			continue
+		}
+		spec := &universe.FilterProcedureSpec{
+			Fn: filterSpec.Fn.Copy(),
+		}
+		spec.Fn.Fn.Block.Body[0].(*semantic.ReturnStatement).Argument = semantic.ExprsToConjunction(exprs...)
+		insertBefore(joinNode, i, plan.CreateLogicalNode(n.ID()+plan.NodeID("_"+side.name), spec))
+	}
+
+	if len(kept) == 0 {
+		return joinNode, true, nil
+	}
+	spec := filterSpec.Copy().(*universe.FilterProcedureSpec)
+	spec.Fn.Fn.Block.Body[0].(*semantic.ReturnStatement).Argument = semantic.ExprsToConjunction(kept...)
+	if err := n.ReplaceSpec(spec); err != nil {
+		return nil, false, err
+	}
+	return n, true, nil
+}
+
+// pushableSides returns the parameters of the as function for the
+// sides of a join that a filter may be applied to before the join.
+// The rows of a side that is preserved by an outer join are joined
+// with nulls when they have no match, so a side that is not preserved
+// by the join cannot be filtered first. A full join preserves both
+// sides, so neither of them can be filtered first.
+func pushableSides(method string) map[string]bool {
+	switch method {
+	case "inner", "cross":
+		return map[string]bool{"l": true, "r": true}
+	case "left", "asof", "semi", "anti":
+		return map[string]bool{"l": true}
+	case "right":
+		return map[string]bool{"r": true}
+	default:
+		return nil
+	}
+}
+
+// copiedColumns returns a function that reports which side of the join
+// an output column is copied from without being changed or renamed.
+// A semi or anti join outputs the rows from the left side, so every
+// column is a copy of a left column. Otherwise, the as function must
+// return a record expression. A property that is a member of l or r
+// with the same name is a copy, and the columns that are not replaced
+// in a record that extends l or r are copies from that side.
+func copiedColumns(spec *JoinProcedureSpec) (func(col string) (string, bool), bool) {
+	if spec.Method == "semi" || spec.Method == "anti" {
+		return func(string) (string, bool) { return "l", true }, true
+	}
+	if spec.As.Fn == nil || spec.As.Fn.Block == nil {
+		return nil, false
+	}
+	body, ok := spec.As.Fn.GetFunctionBodyExpression()
+	if !ok {
+		return nil, false
+	}
+	obj, ok := body.(*semantic.ObjectExpression)
+	if !ok {
+		return nil, false
+	}
+
+	with := ""
+	if obj.With != nil {
+		with = obj.With.Name.Name()
+	}
+	props := make(map[string]string, len(obj.Properties))
+	for _, prop := range obj.Properties {
+		side := ""
+		if m, ok := prop.Value.(*semantic.MemberExpression); ok {
+			if id, ok := m.Object.(*semantic.IdentifierExpression); ok && m.Property.Name() == prop.Key.Key() {
+				side = id.Name.Name()
+			}
+		}
+		props[prop.Key.Key()] = side
+	}
+	return func(col string) (string, bool) {
+		side, ok := props[col]
+		if !ok {
+			side = with
+		}
+		return side, side == "l" || side == "r"
+	}, true
+}
+
+// predicateSide returns the side of the join that all of the
+// columns referenced by the predicate are copied from. The
+// predicate must only reference the record with the name of the
+// parameter to access its columns and must reference at least one
+// column. Nested functions are not inspected because they may
+// declare a parameter with the same name.
+func predicateSide(expr semantic.Expression, param string, copies func(col string) (string, bool)) (string, bool) {
+	var (
+		side    string
+		members = make(map[*semantic.IdentifierExpression]bool)
+		ok      = true
+	)
+	semantic.Walk(semantic.CreateVisitor(func(node semantic.Node) {
+		switch e := node.(type) {
+		case *semantic.FunctionExpression:
+			ok = false
+		case *semantic.MemberExpression:
+			id, isIdent := e.Object.(*semantic.IdentifierExpression)
+			if !isIdent || id.Name.Name() != param {
+				return
+			}
+			members[id] = true
+			s, copied := copies(e.Property.Name())
+			if !copied || side != "" && side != s {
+				ok = false
+			}
+			side = s
+		case *semantic.IdentifierExpression:
+			if e.Name.Name() == param && !members[e] {
+				ok = false
+			}
+		}
+	}), expr)
+	return side, ok && side != ""
+}
+
+// insertBefore inserts a node between a node and its predecessor
+// at index i. Only one edge is replaced so a predecessor that is
+// used for both sides of the join keeps its other edge.
+func insertBefore(node plan.Node, i int, inserted plan.Node) {
+	pred := node.Predecessors()[i]
+	for j, succ := range pred.Successors() {
+		if succ == node {
+			pred.Successors()[j] = inserted
+			break
+		}
+	}
+	inserted.AddPredecessors(pred)
+	inserted.AddSuccessors(node)
+	node.Predecessors()[i] = inserted
+}
@@ -0,0 +1,382 @@
+package join_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andreyvit/diff"
+	"github.com/google/go-cmp/cmp"
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/csv"
+	"github.com/influxdata/flux/dependency"
+	"github.com/influxdata/flux/execute/executetest"
+	"github.com/influxdata/flux/lang"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/plan"
+	"github.com/influxdata/flux/plan/plantest"
+	"github.com/influxdata/flux/runtime"
+	"github.com/influxdata/flux/semantic"
+	"github.com/influxdata/flux/stdlib/influxdata/influxdb"
+	"github.com/influxdata/flux/stdlib/join"
+	"github.com/influxdata/flux/stdlib/universe"
+)
+
+func TestPushFilterBelowJoinRule(t *testing.T) {
+	now := time.Now().UTC()
+	query := func(method, as, filter string) string {
+		return fmt.Sprintf(`import "join"
+			left = from(bucket: "b1", host: "http://localhost:8086")
+			right = from(bucket: "b2", host: "http://localhost:8086")
+			join.join(
+				left: left,
+				right: right,
+				on: (l, r) => l._time == r._time,
+				as: %s,
+				method: %q,
+			)
+				|> %s`, as, method, filter)
+	}
+	unchanged := func() *plantest.PlanSpec {
+		return &plantest.PlanSpec{
+			Nodes: []plan.Node{
+				plan.CreateLogicalNode("from0", &influxdb.FromProcedureSpec{}),
+				plan.CreateLogicalNode("from1", &influxdb.FromProcedureSpec{}),
+				plan.CreateLogicalNode("join.join2", &join.JoinProcedureSpec{}),
+				plan.CreateLogicalNode("filter3", &universe.FilterProcedureSpec{}),
+			},
+			Edges: [][2]int{
+				{0, 2},
+				{1, 2},
+				{2, 3},
+			},
+		}
+	}
+	pushedLeft := func() *plantest.PlanSpec {
+		return &plantest.PlanSpec{
+			Nodes: []plan.Node{
+				plan.CreateLogicalNode("from0", &influxdb.FromProcedureSpec{}),
+				plan.CreateLogicalNode("filter3_left", &universe.FilterProcedureSpec{}),
+				plan.CreateLogicalNode("from1", &influxdb.FromProcedureSpec{}),
+				plan.CreateLogicalNode("join.join2", &join.JoinProcedureSpec{}),
+			},
+			Edges: [][2]int{
+				{0, 1},
+				{1, 3},
+				{2, 3},
+			},
+		}
+	}
+	pushedRight := func() *plantest.PlanSpec {
+		return &plantest.PlanSpec{
+			Nodes: []plan.Node{
+				plan.CreateLogicalNode("from0", &influxdb.FromProcedureSpec{}),
+				plan.CreateLogicalNode("from1", &influxdb.FromProcedureSpec{}),
+				plan.CreateLogicalNode("filter3_right", &universe.FilterProcedureSpec{}),
+				plan.CreateLogicalNode("join.join2", &join.JoinProcedureSpec{}),
+			},
+			Edges: [][2]int{
+				{0, 3},
+				{1, 2},
+				{2, 3},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name        string
+		flux        string
+		wantPlan    *plantest.PlanSpec
+		wantFilters map[string]string
+	}{
+		{
+			name: "left column",
+			flux: query("inner",
+				`(l, r) => ({l with v_right: r._value})`,
+				`filter(fn: (r) => r.host == "h1")`,
+			),
+			wantPlan: pushedLeft(),
+			wantFilters: map[string]string{
+				"filter3_left": `r.host == "h1"`,
+			},
+		},
+		{
+			name: "right column",
+			flux: query("inner",
+				`(l, r) => ({r with v_left: l._value})`,
+				`filter(fn: (r) => r.host == "h1" and r._value > 0.0)`,
+			),
+			wantPlan: pushedRight(),
+			wantFilters: map[string]string{
+				"filter3_right": `r.host == "h1" and r._value > 0.000000`,
+			},
+		},
+		{
+			name: "split between sides",
+			flux: query("inner",
+				`(l, r) => ({_time: l._time, host: l.host, region: r.region, total: l._value + r._value})`,
+				`filter(fn: (r) => r.host == "h1" and r.region == "west" and r.total > 1.0)`,
+			),
+			wantPlan: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreateLogicalNode("from0", &influxdb.FromProcedureSpec{}),
+					plan.CreateLogicalNode("filter3_left", &universe.FilterProcedureSpec{}),
+					plan.CreateLogicalNode("from1", &influxdb.FromProcedureSpec{}),
+					plan.CreateLogicalNode("filter3_right", &universe.FilterProcedureSpec{}),
+					plan.CreateLogicalNode("join.join2", &join.JoinProcedureSpec{}),
+					plan.CreateLogicalNode("filter3", &universe.FilterProcedureSpec{}),
+				},
+				Edges: [][2]int{
+					{0, 1},
+					{2, 3},
+					{1, 4},
+					{3, 4},
+					{4, 5},
+				},
+			},
+			wantFilters: map[string]string{
+				"filter3_left":  `r.host == "h1"`,
+				"filter3_right": `r.region == "west"`,
+				"filter3":       `r.total > 1.000000`,
+			},
+		},
+		{
+			name: "columns from both sides",
+			flux: query("inner",
+				`(l, r) => ({l with region: r.region})`,
+				`filter(fn: (r) => r.host == r.region)`,
+			),
+			wantPlan: unchanged(),
+			wantFilters: map[string]string{
+				"filter3": `r.host == r.region`,
+			},
+		},
+		{
+			name: "computed column",
+			flux: query("inner",
+				`(l, r) => ({l with v_right: r._value, host: l.host + "-" + r.host})`,
+				`filter(fn: (r) => r.v_right > 1.0 and r.host == "h1")`,
+			),
+			wantPlan: unchanged(),
+			wantFilters: map[string]string{
+				"filter3": `r.v_right > 1.000000 and r.host == "h1"`,
+			},
+		},
+		{
+			name: "record passed to a function",
+			flux: query("inner",
+				`(l, r) => ({l with v_right: r._value})`,
+				`filter(fn: (r) => r.host == "h1" and length(arr: [r]) > 0)`,
+			),
+			wantPlan: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreateLogicalNode("from0", &influxdb.FromProcedureSpec{}),
+					plan.CreateLogicalNode("filter3_left", &universe.FilterProcedureSpec{}),
+					plan.CreateLogicalNode("from1", &influxdb.FromProcedureSpec{}),
+					plan.CreateLogicalNode("join.join2", &join.JoinProcedureSpec{}),
+					plan.CreateLogicalNode("filter3", &universe.FilterProcedureSpec{}),
+				},
+				Edges: [][2]int{
+					{0, 1},
+					{1, 3},
+					{2, 3},
+					{3, 4},
+				},
+			},
+			// The remaining predicate cannot be formatted.
+			wantFilters: map[string]string{
+				"filter3_left": `r.host == "h1"`,
+			},
+		},
+		{
+			name: "left join",
+			flux: query("left",
+				`(l, r) => ({l with region: r.region})`,
+				`filter(fn: (r) => r.host == "h1" and r.region == "west")`,
+			),
+			wantPlan: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreateLogicalNode("from0", &influxdb.FromProcedureSpec{}),
+					plan.CreateLogicalNode("filter3_left", &universe.FilterProcedureSpec{}),
+					plan.CreateLogicalNode("from1", &influxdb.FromProcedureSpec{}),
+					plan.CreateLogicalNode("join.join2", &join.JoinProcedureSpec{}),
+					plan.CreateLogicalNode("filter3", &universe.FilterProcedureSpec{}),
+				},
+				Edges: [][2]int{
+					{0, 1},
+					{1, 3},
+					{2, 3},
+					{3, 4},
+				},
+			},
+			wantFilters: map[string]string{
+				"filter3_left": `r.host == "h1"`,
+				"filter3":      `r.region == "west"`,
+			},
+		},
+		{
+			name: "right join",
+			flux: query("right",
+				`(l, r) => ({r with host: l.host})`,
+				`filter(fn: (r) => r.host == "h1")`,
+			),
+			wantPlan: unchanged(),
+			wantFilters: map[string]string{
+				"filter3": `r.host == "h1"`,
+			},
+		},
+		{
+			name: "full join",
+			flux: query("full",
+				`(l, r) => ({l with region: r.region})`,
+				`filter(fn: (r) => r.host == "h1")`,
+			),
+			wantPlan: unchanged(),
+			wantFilters: map[string]string{
+				"filter3": `r.host == "h1"`,
+			},
+		},
+		{
+			name: "semi join",
+			flux: query("semi",
+				`(l, r) => ({r with host: "h2"})`,
+				`filter(fn: (r) => r.host == "h1")`,
+			),
+			wantPlan: pushedLeft(),
+			wantFilters: map[string]string{
+				"filter3_left": `r.host == "h1"`,
+			},
+		},
+		{
+			name: "keep empty tables",
+			flux: query("inner",
+				`(l, r) => ({l with v_right: r._value})`,
+				`filter(fn: (r) => r.host == "h1", onEmpty: "keep")`,
+			),
+			wantPlan: unchanged(),
+			wantFilters: map[string]string{
+				"filter3": `r.host == "h1"`,
+			},
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fluxSpec, err := compile(tc.flux, now)
+			if err != nil {
+				t.Fatalf("could not compile flux query: %v", err)
+			}
+
+			logicalPlanner := plan.NewLogicalPlanner(plan.OnlyLogicalRules(join.PushFilterBelowJoinRule{}))
+			initPlan, err := logicalPlanner.CreateInitialPlan(fluxSpec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			logicalPlan, err := logicalPlanner.Plan(context.Background(), initPlan)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			wantPlan := *tc.wantPlan
+			wantPlan.Now = now
+			if err := plantest.ComparePlansShallow(plantest.CreatePlanSpec(&wantPlan), logicalPlan); err != nil {
+				t.Error(err)
+			}
+
+			filters := make(map[string]string)
+			_ = logicalPlan.TopDownWalk(func(node plan.Node) error {
+				if _, ok := tc.wantFilters[string(node.ID())]; !ok {
+					return nil
+				}
+				if spec, ok := node.ProcedureSpec().(*universe.FilterProcedureSpec); ok {
+					body, _ := spec.Fn.Fn.GetFunctionBodyExpression()
+					filters[string(node.ID())] = fmt.Sprintf("%v", semantic.Formatted(body))
+				}
+				return nil
+			})
+			if !cmp.Equal(tc.wantFilters, filters) {
+				t.Errorf("unexpected filters -want/+got:\n%s", cmp.Diff(tc.wantFilters, filters))
+			}
+		})
+	}
+}
+
+func TestPushFilterBelowJoinRule_Execute(t *testing.T) {
+	const data = `import "array"
+import "join"
+
+left =
+    array.from(
+        rows: [
+            {_time: 2021-01-01T00:00:00Z, host: "h1", _value: 1.0},
+            {_time: 2021-01-01T00:00:01Z, host: "h2", _value: 2.0},
+            {_time: 2021-01-01T00:00:02Z, host: "h1", _value: 3.0},
+            {_time: 2021-01-01T00:00:03Z, host: "h2", _value: 4.0},
+            {_time: 2021-01-01T00:00:05Z, host: "h1", _value: 5.0},
+        ],
+    )
+right =
+    array.from(
+        rows: [
+            {_time: 2021-01-01T00:00:00Z, region: "west", _value: 10.0},
+            {_time: 2021-01-01T00:00:01Z, region: "east", _value: 20.0},
+            {_time: 2021-01-01T00:00:02Z, region: "east", _value: 30.0},
+            {_time: 2021-01-01T00:00:03Z, region: "west", _value: 40.0},
+            {_time: 2021-01-01T00:00:04Z, region: "west", _value: 50.0},
+        ],
+    )
+`
+	for _, method := range []string{"inner", "left", "right", "full", "semi", "anti"} {
+		method := method
+		t.Run(method, func(t *testing.T) {
+			src := data + fmt.Sprintf(`
+join.join(
+    left: left,
+    right: right,
+    on: (l, r) => l._time == r._time,
+    as: (l, r) => ({l with region: r.region, rv: r._value}),
+    method: %q,
+)
+    |> filter(fn: (r) => r.host == "h1" and r.region == "west")
+`, method)
+			got := runQuery(t, src)
+			want := runQuery(t, src, lang.WithLogPlanOpts(plan.RemoveLogicalRules("pushFilterBelowJoin")))
+			if got != want {
+				t.Errorf("unexpected output -want/+got:\n%s", diff.LineDiff(want, got))
+			}
+			// A semi or anti join outputs the left rows, which have no region.
+			if method != "semi" && method != "anti" && !strings.Contains(got, "h1") {
+				t.Errorf("expected rows for h1, got:\n%s", got)
+			}
+		})
+	}
+}
+
+// runQuery runs the query and encodes the results as CSV.
+func runQuery(t *testing.T, src string, opts ...lang.CompileOption) string {
+	t.Helper()
+	program, err := lang.Compile(src, runtime.Default, time.Now(), opts...)
+	if err != nil {
+		t.Fatalf("failed to compile script: %v", err)
+	}
+
+	ctx, deps := dependency.Inject(context.Background(), executetest.NewTestExecuteDependencies())
+	defer deps.Finish()
+	q, err := program.Start(ctx, &memory.ResourceAllocator{})
+	if err != nil {
+		t.Fatalf("failed to start program: %v", err)
+	}
+	results := flux.NewResultIteratorFromQuery(q)
+	defer results.Release()
+
+	var b strings.Builder
+	enc := csv.NewMultiResultEncoder(csv.DefaultEncoderConfig())
+	if _, err := enc.Encode(&b, results); err != nil {
+		t.Fatalf("unexpected encode error: %s", err)
+	}
+	return b.String()
+}
@@ -0,0 +1,265 @@
+package join
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"math"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/dependencies/filesystem"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/semantic"
+	"github.com/influxdata/flux/values"
+)
+
+// spillPattern is the pattern used to name the temporary
+// files that the buffered tables are spilled to.
+const spillPattern = "flux-join-*"
+
+// rowValueSize is an estimate of the memory used by each value
+// of a buffered row in addition to the bytes of a string.
+const rowValueSize = 16
+
+// spillThreshold returns the number of bytes that may be buffered
+// for a group key before it is spilled to disk. It is zero when
+// spilling is disabled.
+func spillThreshold(ctx context.Context) int64 {
+	if !execute.HaveExecutionDependencies(ctx) {
+		return 0
+	}
+	opts := execute.GetExecutionDependencies(ctx).ExecutionOptions
+	if opts == nil || opts.SpillThreshold < 0 {
+		return 0
+	}
+	return opts.SpillThreshold
+}
+
+// rowsSize estimates the number of bytes used by the rows of a table.
+func rowsSize(cols []flux.ColMeta, rows []values.Object) int64 {
+	size := int64(len(rows)) * int64(len(cols)) * rowValueSize
+	for _, c := range cols {
+		if c.Type != flux.TString {
+			continue
+		}
+		for _, row := range rows {
+			if v, ok := row.Get(c.Label); ok && !v.IsNull() {
+				size += int64(len(v.Str()))
+			}
+		}
+	}
+	return size
+}
+
+// spill writes the rows of the table to a temporary file created
+// with the filesystem dependency and removes them from memory.
+// The file is set on the table before anything is written so it is
+// removed by cleanup even if writing to it fails.
+func (t *joinTransformation) spill(tbl *joinTable) error {
+	f, err := filesystem.CreateTemp(t.ctx, spillPattern)
+	if err != nil {
+		return errors.Wrap(err, codes.Inherit, "failed to create a file to spill the join to")
+	}
+	tbl.file = f
+
+	w := bufio.NewWriter(f)
+	if err := encodeRows(w, tbl.cols, tbl.rows); err != nil {
+		return errors.Wrap(err, codes.Internal, "failed to spill the join")
+	}
+	if err := w.Flush(); err != nil {
+		return errors.Wrap(err, codes.Internal, "failed to spill the join")
+	}
+	tbl.rows = nil
+	return nil
+}
+
+// withRows calls fn with the table and its rows. If the table was
+// spilled, its rows are read back from the file and the memory they
+// use is accounted for until fn returns.
+func (t *joinTransformation) withRows(tbl joinTable, fn func(tbl joinTable) error) error {
+	if tbl.file == nil {
+		return fn(tbl)
+	}
+
+	if _, err := tbl.file.Seek(0, io.SeekStart); err != nil {
+		return errors.Wrap(err, codes.Internal, "failed to read the spilled join")
+	}
+	rows, err := decodeRows(bufio.NewReader(tbl.file), tbl.cols, tbl.n)
+	if err != nil {
+		return errors.Wrap(err, codes.Internal, "failed to read the spilled join")
+	}
+	size := int(rowsSize(tbl.cols, rows))
+	if err := t.mem.Account(size); err != nil {
+		return err
+	}
+	defer func() {
+		_ = t.mem.Account(-size)
+	}()
+
+	tbl.rows = rows
+	return fn(tbl)
+}
+
+// cleanup removes the files that tables were spilled to and releases
+// the memory accounted for the tables that are still in memory.
+func (t *joinTransformation) cleanup() {
+	for _, state := range t.parentState {
+		for _, tbl := range state.tables {
+			if tbl.file != nil {
+				_ = tbl.file.Close()
+				_ = filesystem.Remove(t.ctx, tbl.file.Name())
+			}
+			_ = t.mem.Account(-int(tbl.size))
+		}
+	}
+}
+
+// encodeRows writes the rows to w. Each value is written as a byte
+// that is zero for a null followed by the value if it is not null.
+func encodeRows(w io.Writer, cols []flux.ColMeta, rows []values.Object) error {
+	var (
+		buf     []byte
+		scratch [binary.MaxVarintLen64]byte
+	)
+	for _, row := range rows {
+		buf = buf[:0]
+		for _, c := range cols {
+			v, ok := row.Get(c.Label)
+			if !ok || v.IsNull() {
+				buf = append(buf, 0)
+				continue
+			}
+			buf = append(buf, 1)
+			switch c.Type {
+			case flux.TBool:
+				if v.Bool() {
+					buf = append(buf, 1)
+				} else {
+					buf = append(buf, 0)
+				}
+			case flux.TInt:
+				buf = append(buf, scratch[:binary.PutVarint(scratch[:], v.Int())]...)
+			case flux.TUInt:
+				buf = append(buf, scratch[:binary.PutUvarint(scratch[:], v.UInt())]...)
+			case flux.TFloat:
+				binary.LittleEndian.PutUint64(scratch[:8], math.Float64bits(v.Float()))
+				buf = append(buf, scratch[:8]...)
+			case flux.TString:
+				s := v.Str()
+				buf = append(buf, scratch[:binary.PutUvarint(scratch[:], uint64(len(s)))]...)
+				buf = append(buf, s...)
+			case flux.TBytes:
+				b := v.Bytes()
+				buf = append(buf, scratch[:binary.PutUvarint(scratch[:], uint64(len(b)))]...)
+				buf = append(buf, b...)
+			case flux.TTime:
+				buf = append(buf, scratch[:binary.PutVarint(scratch[:], int64(v.Time()))]...)
+			default:
+				return errors.Newf(codes.Internal, "cannot spill column %q of type %s", c.Label, c.Type)
+			}
+		}
+		if _, err := w.Write(buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeRows reads n rows written by encodeRows from r.
+func decodeRows(r *bufio.Reader, cols []flux.ColMeta, n int) ([]values.Object, error) {
+	typ := rowType(cols)
+	rows := make([]values.Object, 0, n)
+	var buf []byte
+	for i := 0; i < n; i++ {
+		row := values.NewObject(typ)
+		for _, c := range cols {
+			valid, err := r.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+			if valid == 0 {
+				row.Set(c.Label, values.NewNull(flux.SemanticType(c.Type)))
+				continue
+			}
+
+			var v values.Value
+			switch c.Type {
+			case flux.TBool:
+				b, err := r.ReadByte()
+				if err != nil {
+					return nil, err
+				}
+				v = values.NewBool(b != 0)
+			case flux.TInt:
+				i, err := binary.ReadVarint(r)
+				if err != nil {
+					return nil, err
+				}
+				v = values.NewInt(i)
+			case flux.TUInt:
+				u, err := binary.ReadUvarint(r)
+				if err != nil {
+					return nil, err
+				}
+				v = values.NewUInt(u)
+			case flux.TFloat:
+				if cap(buf) < 8 {
+					buf = make([]byte, 8)
+				}
+				if _, err := io.ReadFull(r, buf[:8]); err != nil {
+					return nil, err
+				}
+				v = values.NewFloat(math.Float64frombits(binary.LittleEndian.Uint64(buf[:8])))
+			case flux.TString:
+				l, err := binary.ReadUvarint(r)
+				if err != nil {
+					return nil, err
+				}
+				if uint64(cap(buf)) < l {
+					buf = make([]byte, l)
+				}
+				if _, err := io.ReadFull(r, buf[:l]); err != nil {
+					return nil, err
+				}
+				v = values.NewString(string(buf[:l]))
+			case flux.TBytes:
+				l, err := binary.ReadUvarint(r)
+				if err != nil {
+					return nil, err
+				}
+				// The value keeps the slice so it cannot share buf.
+				b := make([]byte, l)
+				if _, err := io.ReadFull(r, b); err != nil {
+					return nil, err
+				}
+				v = values.NewBytes(b)
+			case flux.TTime:
+				i, err := binary.ReadVarint(r)
+				if err != nil {
+					return nil, err
+				}
+				v = values.NewTime(values.Time(i))
+			default:
+				return nil, errors.Newf(codes.Internal, "cannot read spilled column %q of type %s", c.Label, c.Type)
+			}
+			row.Set(c.Label, v)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// rowType returns the type of a record with a property for each column.
+func rowType(cols []flux.ColMeta) semantic.MonoType {
+	properties := make([]semantic.PropertyType, len(cols))
+	for j, c := range cols {
+		properties[j] = semantic.PropertyType{
+			Key:   []byte(c.Label),
+			Value: flux.SemanticType(c.Type),
+		}
+	}
+	return semantic.NewObjectType(properties)
+}
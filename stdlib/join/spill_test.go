@@ -0,0 +1,284 @@
+package join
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/dependencies/filesystem"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/execute/executetest"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/plan"
+	"github.com/influxdata/flux/semantic"
+	"github.com/influxdata/flux/values"
+)
+
+// recordFn is a rowFn implemented in Go so the join
+// can be tested without compiling a Flux function.
+type recordFn func(l, r values.Object) values.Value
+
+func (f recordFn) Eval(ctx context.Context, l, r values.Object) (values.Value, error) {
+	return f(l, r), nil
+}
+
+// tempFS records the temporary files created with the system filesystem.
+type tempFS struct {
+	filesystem.Service
+	names []string
+}
+
+func (fs *tempFS) CreateTemp(pattern string) (filesystem.TempFile, error) {
+	f, err := filesystem.SystemFS.(filesystem.TempService).CreateTemp(pattern)
+	if err != nil {
+		return nil, err
+	}
+	fs.names = append(fs.names, f.Name())
+	return f, nil
+}
+
+func (fs *tempFS) Remove(fpath string) error {
+	return filesystem.SystemFS.(filesystem.TempService).Remove(fpath)
+}
+
+// checkRemoved fails the test if any of the temporary files still exist.
+func (fs *tempFS) checkRemoved(t *testing.T) {
+	t.Helper()
+	for _, name := range fs.names {
+		if _, err := os.Stat(name); !os.IsNotExist(err) {
+			t.Errorf("expected spill file %s to be removed", name)
+		}
+	}
+}
+
+func TestSpill_EncodeRows(t *testing.T) {
+	cols := []flux.ColMeta{
+		{Label: "b", Type: flux.TBool},
+		{Label: "i", Type: flux.TInt},
+		{Label: "u", Type: flux.TUInt},
+		{Label: "f", Type: flux.TFloat},
+		{Label: "s", Type: flux.TString},
+		{Label: "t", Type: flux.TTime},
+		{Label: "x", Type: flux.TBytes},
+	}
+	typ := rowType(cols)
+	row := func(vs ...values.Value) values.Object {
+		o := values.NewObject(typ)
+		for j, c := range cols {
+			o.Set(c.Label, vs[j])
+		}
+		return o
+	}
+	rows := []values.Object{
+		row(
+			values.NewBool(true),
+			values.NewInt(-42),
+			values.NewUInt(42),
+			values.NewFloat(-1.5),
+			values.NewString("a"),
+			values.NewTime(values.Time(7)),
+			values.NewBytes([]byte{0, 1, 0xff}),
+		),
+		row(
+			values.NewNull(semantic.BasicBool),
+			values.NewNull(semantic.BasicInt),
+			values.NewNull(semantic.BasicUint),
+			values.NewNull(semantic.BasicFloat),
+			values.NewNull(semantic.BasicString),
+			values.NewNull(semantic.BasicTime),
+			values.NewNull(semantic.BasicBytes),
+		),
+		row(
+			values.NewBool(false),
+			values.NewInt(0),
+			values.NewUInt(0),
+			values.NewFloat(0),
+			values.NewString(""),
+			values.NewTime(values.Time(-1)),
+			values.NewBytes([]byte{}),
+		),
+	}
+
+	var buf bytes.Buffer
+	if err := encodeRows(&buf, cols, rows); err != nil {
+		t.Fatal(err)
+	}
+	got, err := decodeRows(bufio.NewReader(&buf), cols, len(rows))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(rows) {
+		t.Fatalf("unexpected number of rows -want/+got:\n\t- %d\n\t+ %d", len(rows), len(got))
+	}
+	for i := range rows {
+		for _, c := range cols {
+			want, _ := rows[i].Get(c.Label)
+			v, _ := got[i].Get(c.Label)
+			if want.IsNull() != v.IsNull() || !want.IsNull() && !want.Equal(v) {
+				t.Errorf("unexpected value for %s in row %d -want/+got:\n\t- %v\n\t+ %v", c.Label, i, want, v)
+			}
+		}
+	}
+}
+
+func TestJoin_Spill(t *testing.T) {
+	cols := []flux.ColMeta{
+		{Label: "_time", Type: flux.TTime},
+		{Label: "_value", Type: flux.TFloat},
+		{Label: "t0", Type: flux.TString},
+	}
+	tables := func(vs ...float64) []*executetest.Table {
+		var tbls []*executetest.Table
+		for _, t0 := range []string{"a", "b", "c"} {
+			tbl := &executetest.Table{KeyCols: []string{"t0"}, ColMeta: cols}
+			for i, v := range vs {
+				tbl.Data = append(tbl.Data, []interface{}{execute.Time(i), v, t0})
+			}
+			tbls = append(tbls, tbl)
+		}
+		return tbls
+	}
+	on := equalityFn{{Left: "_time", Right: "_time"}}
+	as := recordFn(func(l, r values.Object) values.Value {
+		get := func(o values.Object, label string) values.Value {
+			v, _ := o.Get(label)
+			return v
+		}
+		time, t0 := get(l, "_time"), get(l, "t0")
+		if time.IsNull() {
+			time, t0 = get(r, "_time"), get(r, "t0")
+		}
+		return values.NewObjectWithValues(map[string]values.Value{
+			"_time": time,
+			"t0":    t0,
+			"lv":    get(l, "_value"),
+			"rv":    get(r, "_value"),
+		})
+	})
+
+	for _, method := range []string{"inner", "full", "semi", "anti"} {
+		method := method
+		t.Run(method, func(t *testing.T) {
+			run := func(threshold int64) ([]*executetest.Table, *tempFS) {
+				fs := &tempFS{Service: filesystem.SystemFS}
+				mem := &memory.ResourceAllocator{}
+				ctx := filesystem.Inject(context.Background(), fs)
+
+				leftID := executetest.RandomDatasetID()
+				rightID := executetest.RandomDatasetID()
+				d := executetest.NewDataset(executetest.RandomDatasetID())
+				c := execute.NewTableBuilderCache(executetest.UnlimitedAllocator)
+				c.SetTriggerSpec(plan.DefaultTriggerSpec)
+				jt, err := newJoinTransformation(ctx, d, c, method, on, as, leftID, rightID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				jt.mem = mem
+				jt.spillThreshold = threshold
+
+				for _, tbl := range tables(1, 2, 3, 4) {
+					if err := jt.Process(leftID, tbl); err != nil {
+						t.Fatal(err)
+					}
+				}
+				for _, tbl := range tables(10, 30) {
+					if err := jt.Process(rightID, tbl); err != nil {
+						t.Fatal(err)
+					}
+				}
+				jt.Finish(leftID, nil)
+				jt.Finish(rightID, nil)
+				if d.FinishedErr != nil {
+					t.Fatal(d.FinishedErr)
+				}
+
+				if got := mem.Allocated(); got != 0 {
+					t.Errorf("expected all memory to be released, got %d bytes", got)
+				}
+				fs.checkRemoved(t)
+
+				got, err := executetest.TablesFromCache(c)
+				if err != nil {
+					t.Fatal(err)
+				}
+				executetest.NormalizeTables(got)
+				sort.Sort(executetest.SortedTables(got))
+				return got, fs
+			}
+
+			want, fs := run(0)
+			if len(fs.names) != 0 {
+				t.Fatalf("expected no spill files without a threshold, got %d", len(fs.names))
+			}
+			got, fs := run(1)
+			if len(fs.names) == 0 {
+				t.Fatal("expected the tables to be spilled")
+			}
+			if !cmp.Equal(want, got) {
+				t.Errorf("unexpected tables -want/+got\n%s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
+
+func TestJoin_SpillFinishError(t *testing.T) {
+	fs := &tempFS{Service: filesystem.SystemFS}
+	mem := &memory.ResourceAllocator{}
+	ctx := filesystem.Inject(context.Background(), fs)
+
+	leftID := executetest.RandomDatasetID()
+	rightID := executetest.RandomDatasetID()
+	d := executetest.NewDataset(executetest.RandomDatasetID())
+	c := execute.NewTableBuilderCache(executetest.UnlimitedAllocator)
+	c.SetTriggerSpec(plan.DefaultTriggerSpec)
+	jt, err := newJoinTransformation(ctx, d, c, "inner", equalityFn{{Left: "_time", Right: "_time"}}, nil, leftID, rightID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jt.mem = mem
+	jt.spillThreshold = 64
+
+	table := func(n int) *executetest.Table {
+		tbl := &executetest.Table{
+			ColMeta: []flux.ColMeta{
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_value", Type: flux.TFloat},
+			},
+		}
+		for i := 0; i < n; i++ {
+			tbl.Data = append(tbl.Data, []interface{}{execute.Time(i), float64(i)})
+		}
+		return tbl
+	}
+	// The left table is large enough to be spilled
+	// while the right table stays in memory.
+	if err := jt.Process(leftID, table(10)); err != nil {
+		t.Fatal(err)
+	}
+	if err := jt.Process(rightID, table(1)); err != nil {
+		t.Fatal(err)
+	}
+	if len(fs.names) != 1 {
+		t.Fatalf("expected one spill file, got %d", len(fs.names))
+	}
+	if mem.Allocated() == 0 {
+		t.Fatal("expected the right table to be accounted for")
+	}
+
+	jt.Finish(leftID, errors.New(codes.Canceled, "query canceled"))
+	jt.Finish(rightID, nil)
+	if d.FinishedErr == nil {
+		t.Fatal("expected an error, got none")
+	}
+	if got := mem.Allocated(); got != 0 {
+		t.Errorf("expected all memory to be released, got %d bytes", got)
+	}
+	fs.checkRemoved(t)
+}
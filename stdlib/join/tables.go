@@ -0,0 +1,555 @@
+package join
+
+import (
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/interpreter"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/plan"
+	"github.com/influxdata/flux/runtime"
+	"github.com/influxdata/flux/semantic"
+	"github.com/influxdata/flux/values"
+)
+
+const TablesKind = "join.tables"
+
+func init() {
+	signature := runtime.MustLookupBuiltinType("join", "tables")
+	runtime.RegisterPackageValue(
+		"join", "tables", flux.MustValue(flux.FunctionValue("tables", createTablesOpSpec, signature)),
+	)
+	flux.RegisterOpSpec(TablesKind, newTablesOp)
+	plan.RegisterProcedureSpec(TablesKind, newTablesProcedure, TablesKind)
+	execute.RegisterTransformation(TablesKind, createTablesTransformation)
+}
+
+type TablesOpSpec struct {
+	names  []string
+	tables []*flux.TableObject
+	on     []string
+	method string
+}
+
+func (o *TablesOpSpec) Kind() flux.OperationKind {
+	return flux.OperationKind(TablesKind)
+}
+
+func newTablesOp() flux.OperationSpec {
+	return new(TablesOpSpec)
+}
+
+func createTablesOpSpec(args flux.Arguments, p *flux.Administration) (flux.OperationSpec, error) {
+	tables, err := args.GetRequiredObject("tables")
+	if err != nil {
+		return nil, err
+	}
+	streams := make(map[string]*flux.TableObject, tables.Len())
+	names := make([]string, 0, tables.Len())
+	tables.Range(func(name string, v values.Value) {
+		if err != nil {
+			return
+		}
+		table, ok := v.(*flux.TableObject)
+		if !ok {
+			err = errors.Newf(codes.Invalid, "argument 'tables' must be a record of table streams, but %q is of type %s", name, v.Type())
+			return
+		}
+		streams[name] = table
+		names = append(names, name)
+	})
+	if err != nil {
+		return nil, err
+	} else if len(names) < 2 {
+		return nil, errors.Newf(codes.Invalid, "argument 'tables' must contain at least two table streams, but has %d", len(names))
+	}
+
+	// Add the parents in the order of their names so
+	// the transformation can match them to the names.
+	sort.Strings(names)
+	ops := make([]*flux.TableObject, len(names))
+	for i, name := range names {
+		ops[i] = streams[name]
+		p.AddParent(ops[i])
+	}
+
+	array, err := args.GetRequiredArray("on", semantic.String)
+	if err != nil {
+		return nil, err
+	} else if array.Len() == 0 {
+		return nil, errors.New(codes.Invalid, "argument 'on' must contain at least one column")
+	}
+	on, err := interpreter.ToStringArray(array)
+	if err != nil {
+		return nil, err
+	}
+
+	method, ok, err := args.GetString("method")
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		method = "inner"
+	} else if err := validateTablesMethod(method); err != nil {
+		return nil, err
+	}
+
+	return &TablesOpSpec{
+		names:  names,
+		tables: ops,
+		on:     on,
+		method: method,
+	}, nil
+}
+
+type TablesProcedureSpec struct {
+	plan.DefaultCost
+	// TableNames are the names of the tables in the order of the parents.
+	TableNames []string
+	On         []string
+	Method     string
+}
+
+func (p *TablesProcedureSpec) Kind() plan.ProcedureKind {
+	return plan.ProcedureKind(TablesKind)
+}
+
+func (p *TablesProcedureSpec) Copy() plan.ProcedureSpec {
+	return &TablesProcedureSpec{
+		TableNames: append([]string(nil), p.TableNames...),
+		On:         append([]string(nil), p.On...),
+		Method:     p.Method,
+	}
+}
+
+func newTablesProcedure(spec flux.OperationSpec, p plan.Administration) (plan.ProcedureSpec, error) {
+	s, ok := spec.(*TablesOpSpec)
+	if !ok {
+		return nil, errors.New(codes.Internal, "invalid op spec for join.tables procedure")
+	}
+	return &TablesProcedureSpec{
+		TableNames: append([]string(nil), s.names...),
+		On:         append([]string(nil), s.on...),
+		Method:     s.method,
+	}, nil
+}
+
+func createTablesTransformation(
+	id execute.DatasetID,
+	mode execute.AccumulationMode,
+	spec plan.ProcedureSpec,
+	a execute.Administration,
+) (execute.Transformation, execute.Dataset, error) {
+	s, ok := spec.(*TablesProcedureSpec)
+	if !ok {
+		return nil, nil, errors.Newf(codes.Internal, "invalid spec type %T", spec)
+	}
+	parents := a.Parents()
+	if len(parents) != len(s.TableNames) {
+		return nil, nil, errors.Newf(codes.Internal, "join.tables expects %d parents, but has %d", len(s.TableNames), len(parents))
+	}
+	cache := execute.NewTableBuilderCache(a.Allocator())
+	d := execute.NewDataset(id, mode, cache)
+	t, err := NewTablesTransformation(s, d, cache, parents)
+	if err != nil {
+		return nil, nil, err
+	}
+	t.mem = a.Allocator()
+	return t, d, nil
+}
+
+func validateTablesMethod(method string) error {
+	switch method {
+	case "inner", "full":
+		return nil
+	default:
+		return errors.Newf(codes.Invalid, "invalid join.tables method %q, must be one of \"inner\" or \"full\"", method)
+	}
+}
+
+// tablesTransformation joins the tables from any number of parents
+// on a list of columns. Each input stream is buffered and the streams
+// are joined once every parent has finished.
+type tablesTransformation struct {
+	execute.ExecutionNode
+	mu sync.Mutex
+
+	d     execute.Dataset
+	cache execute.TableBuilderCache
+	mem   memory.Allocator
+
+	on     []string
+	method string
+
+	// parents and names are the parents and the names
+	// of their streams in the order of the names.
+	parents     []execute.DatasetID
+	names       []string
+	parentState map[execute.DatasetID]*joinParentState
+	err         error
+}
+
+// NewTablesTransformation creates a transformation that joins the tables
+// from the parents. The names of the tables in the spec are the names
+// of the parents in the same order.
+func NewTablesTransformation(
+	spec *TablesProcedureSpec,
+	d execute.Dataset,
+	cache execute.TableBuilderCache,
+	parents []execute.DatasetID,
+) (*tablesTransformation, error) {
+	if err := validateTablesMethod(spec.Method); err != nil {
+		return nil, err
+	}
+	if len(parents) != len(spec.TableNames) {
+		return nil, errors.Newf(codes.Internal, "join.tables expects %d parents, but has %d", len(spec.TableNames), len(parents))
+	}
+	if len(spec.On) == 0 {
+		return nil, errors.New(codes.Invalid, "join.tables requires at least one column to join on")
+	}
+	parentState := make(map[execute.DatasetID]*joinParentState, len(parents))
+	for _, id := range parents {
+		parentState[id] = new(joinParentState)
+	}
+	return &tablesTransformation{
+		d:           d,
+		cache:       cache,
+		mem:         memory.DefaultAllocator,
+		on:          spec.On,
+		method:      spec.Method,
+		parents:     parents,
+		names:       spec.TableNames,
+		parentState: parentState,
+	}, nil
+}
+
+func (t *tablesTransformation) RetractTable(id execute.DatasetID, key flux.GroupKey) error {
+	return errors.New(codes.Unimplemented, "join.tables does not support retracting tables")
+}
+
+// Process reads the table into the buffer for the parent it came from.
+func (t *tablesTransformation) Process(id execute.DatasetID, tbl flux.Table) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state, ok := t.parentState[id]
+	if !ok {
+		tbl.Done()
+		return errors.Newf(codes.Internal, "join.tables received a table from an unknown parent %v", id)
+	}
+
+	rows, err := readRows(tbl)
+	if err != nil {
+		return err
+	}
+	state.tables = append(state.tables, joinTable{
+		key:  tbl.Key(),
+		cols: tbl.Cols(),
+		rows: rows,
+		n:    len(rows),
+	})
+	return nil
+}
+
+func (t *tablesTransformation) UpdateWatermark(id execute.DatasetID, mark execute.Time) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.parentState[id].mark = mark
+
+	min := execute.Time(math.MaxInt64)
+	for _, state := range t.parentState {
+		if state.mark < min {
+			min = state.mark
+		}
+	}
+	return t.d.UpdateWatermark(min)
+}
+
+func (t *tablesTransformation) UpdateProcessingTime(id execute.DatasetID, pt execute.Time) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.parentState[id].processing = pt
+
+	min := execute.Time(math.MaxInt64)
+	for _, state := range t.parentState {
+		if state.processing < min {
+			min = state.processing
+		}
+	}
+	return t.d.UpdateProcessingTime(min)
+}
+
+// Finish joins the buffered tables once every parent has finished.
+// If any parent finishes with an error, the join is skipped and
+// the first error is passed downstream.
+func (t *tablesTransformation) Finish(id execute.DatasetID, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// Ignore repeated finish calls from a parent that has
+	// already finished so the dataset is only finished once.
+	if state, ok := t.parentState[id]; !ok || state.finished {
+		return
+	}
+
+	// Only report the first error that occurs.
+	if t.err == nil && err != nil {
+		t.err = err
+	}
+
+	t.parentState[id].finished = true
+	for _, state := range t.parentState {
+		if !state.finished {
+			return
+		}
+	}
+
+	if t.err == nil {
+		t.err = t.join()
+	}
+	for _, id := range t.parents {
+		t.parentState[id] = &joinParentState{finished: true}
+	}
+	t.d.Finish(t.err)
+}
+
+// rowRef is the position of a row in the buffered tables of a parent.
+type rowRef struct {
+	table, row int
+}
+
+// join groups the rows of every parent by the values of the on columns
+// and produces a row for each combination of rows with the same values
+// that has one row from each parent. For a full join, a parent without
+// a row for the values is filled with nulls and a row with a null or
+// missing value in any of the on columns is produced on its own.
+// The output group key is the union of the group keys of the joined rows.
+func (t *tablesTransformation) join() error {
+	cols, labels, err := t.schema()
+	if err != nil {
+		return err
+	}
+
+	size := 0
+	defer func() {
+		_ = t.mem.Account(-size)
+	}()
+
+	// Index the rows of each parent by the values of the on columns.
+	// The order of the keys is the order they are first seen in.
+	index := make(map[string][][]rowRef)
+	var order []string
+	var unmatched [][]*rowRef
+	var buf []byte
+	for i, id := range t.parents {
+		for ti, tbl := range t.parentState[id].tables {
+			for ri, row := range tbl.rows {
+				var ok bool
+				buf, ok = appendHashKey(buf[:0], row, t.on)
+				if !ok {
+					if t.method == "full" {
+						refs := make([]*rowRef, len(t.parents))
+						refs[i] = &rowRef{table: ti, row: ri}
+						unmatched = append(unmatched, refs)
+					}
+					continue
+				}
+				n := 8
+				refs, exists := index[string(buf)]
+				if !exists {
+					n += len(buf) + hashEntrySize
+					refs = make([][]rowRef, len(t.parents))
+					order = append(order, string(buf))
+				}
+				if err := t.mem.Account(n); err != nil {
+					return err
+				}
+				size += n
+				refs[i] = append(refs[i], rowRef{table: ti, row: ri})
+				index[string(buf)] = refs
+			}
+		}
+	}
+
+	groups := execute.NewGroupLookup()
+	var keys []flux.GroupKey
+	add := func(refs []*rowRef) {
+		record, key := t.joinRow(cols, labels, refs)
+		g, ok := groups.Lookup(key)
+		if !ok {
+			g = &joinGroup{cols: cols}
+			groups.Set(key, g)
+			keys = append(keys, key)
+		}
+		g.(*joinGroup).records = append(g.(*joinGroup).records, record)
+	}
+
+	for _, k := range order {
+		refs := index[k]
+		if t.method == "inner" {
+			missing := false
+			for _, rs := range refs {
+				if len(rs) == 0 {
+					missing = true
+					break
+				}
+			}
+			if missing {
+				continue
+			}
+		}
+		product(refs, add)
+	}
+	for _, refs := range unmatched {
+		add(refs)
+	}
+
+	for _, key := range keys {
+		g, _ := groups.Lookup(key)
+		if err := buildTable(t.cache, key, g.(*joinGroup)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// product calls fn with every combination of one row from each parent.
+// A parent without any rows is passed as nil in every combination.
+func product(refs [][]rowRef, fn func(refs []*rowRef)) {
+	combo := make([]*rowRef, len(refs))
+	var visit func(i int)
+	visit = func(i int) {
+		if i == len(refs) {
+			fn(append([]*rowRef(nil), combo...))
+			return
+		}
+		if len(refs[i]) == 0 {
+			combo[i] = nil
+			visit(i + 1)
+			return
+		}
+		for j := range refs[i] {
+			combo[i] = &refs[i][j]
+			visit(i + 1)
+		}
+	}
+	visit(0)
+}
+
+// schema returns the columns of the output and the output label of each
+// column of each parent. The on columns come first and are shared by all
+// of the parents. The other columns follow in the order of the parents
+// and a column found in more than one parent is suffixed with the name
+// of the parent.
+func (t *tablesTransformation) schema() ([]flux.ColMeta, []map[string]string, error) {
+	isOn := make(map[string]bool, len(t.on))
+	for _, label := range t.on {
+		isOn[label] = true
+	}
+
+	// Count the number of parents with each column.
+	count := make(map[string]int)
+	for _, id := range t.parents {
+		seen := make(map[string]bool)
+		for _, tbl := range t.parentState[id].tables {
+			for _, c := range tbl.cols {
+				if !isOn[c.Label] && !seen[c.Label] {
+					seen[c.Label] = true
+					count[c.Label]++
+				}
+			}
+		}
+	}
+
+	var cols []flux.ColMeta
+	addCol := func(c flux.ColMeta) error {
+		if j := execute.ColIdx(c.Label, cols); j < 0 {
+			cols = append(cols, c)
+		} else if cols[j].Type != c.Type {
+			return errors.Newf(codes.Invalid, "join.tables found column %q with conflicting types %s and %s", c.Label, cols[j].Type, c.Type)
+		}
+		return nil
+	}
+	for _, label := range t.on {
+		for _, id := range t.parents {
+			for _, tbl := range t.parentState[id].tables {
+				if j := execute.ColIdx(label, tbl.cols); j >= 0 {
+					if err := addCol(tbl.cols[j]); err != nil {
+						return nil, nil, err
+					}
+				}
+			}
+		}
+	}
+
+	labels := make([]map[string]string, len(t.parents))
+	for i, id := range t.parents {
+		labels[i] = make(map[string]string)
+		for _, tbl := range t.parentState[id].tables {
+			for _, c := range tbl.cols {
+				label := c.Label
+				if !isOn[label] && count[label] > 1 {
+					label += "_" + t.names[i]
+				}
+				labels[i][c.Label] = label
+				if err := addCol(flux.ColMeta{Label: label, Type: c.Type}); err != nil {
+					return nil, nil, err
+				}
+			}
+		}
+	}
+	return cols, labels, nil
+}
+
+// joinRow returns the output record for a combination of rows and its
+// group key. The properties of the record are null unless they are set
+// by one of the rows. The on columns are set by the first row with a value.
+func (t *tablesTransformation) joinRow(cols []flux.ColMeta, labels []map[string]string, refs []*rowRef) (values.Object, flux.GroupKey) {
+	properties := make([]semantic.PropertyType, len(cols))
+	for j, c := range cols {
+		properties[j] = semantic.PropertyType{
+			Key:   []byte(c.Label),
+			Value: flux.SemanticType(c.Type),
+		}
+	}
+	record := values.NewObject(semantic.NewObjectType(properties))
+	for _, c := range cols {
+		record.Set(c.Label, values.NewNull(flux.SemanticType(c.Type)))
+	}
+
+	inKey := make(map[string]bool)
+	for i, ref := range refs {
+		if ref == nil {
+			continue
+		}
+		tbl := t.parentState[t.parents[i]].tables[ref.table]
+		row := tbl.rows[ref.row]
+		for _, c := range tbl.cols {
+			label := labels[i][c.Label]
+			v, _ := row.Get(c.Label)
+			if cur, _ := record.Get(label); !cur.IsNull() || v.IsNull() {
+				continue
+			}
+			record.Set(label, v)
+		}
+		for _, c := range tbl.key.Cols() {
+			inKey[labels[i][c.Label]] = true
+		}
+	}
+
+	var keyCols []flux.ColMeta
+	var keyValues []values.Value
+	for _, c := range cols {
+		if !inKey[c.Label] {
+			continue
+		}
+		v, _ := record.Get(c.Label)
+		keyCols = append(keyCols, c)
+		keyValues = append(keyValues, v)
+	}
+	return record, execute.NewGroupKey(keyCols, keyValues)
+}
@@ -0,0 +1,417 @@
+package join_test
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/execute/executetest"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/plan"
+	"github.com/influxdata/flux/stdlib/join"
+)
+
+func TestTables_Process(t *testing.T) {
+	// input returns a table with a row for each time and value.
+	input := func(host string, rows ...[]interface{}) *executetest.Table {
+		tbl := &executetest.Table{
+			KeyCols: []string{"host"},
+			ColMeta: []flux.ColMeta{
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_value", Type: flux.TFloat},
+				{Label: "host", Type: flux.TString},
+			},
+		}
+		for _, row := range rows {
+			tbl.Data = append(tbl.Data, []interface{}{row[0], row[1], host})
+		}
+		return tbl
+	}
+
+	testCases := []struct {
+		name    string
+		method  string
+		on      []string
+		tables  map[string][]*executetest.Table
+		want    []*executetest.Table
+		wantErr error
+	}{
+		{
+			name: "three inputs",
+			on:   []string{"_time", "host"},
+			tables: map[string][]*executetest.Table{
+				"a": {
+					input("h1",
+						[]interface{}{execute.Time(1), 1.0},
+						[]interface{}{execute.Time(2), 2.0},
+						[]interface{}{execute.Time(3), 3.0},
+					),
+					input("h2",
+						[]interface{}{execute.Time(1), 4.0},
+					),
+				},
+				"b": {
+					input("h1",
+						[]interface{}{execute.Time(1), 10.0},
+						[]interface{}{execute.Time(3), 30.0},
+					),
+					input("h2",
+						[]interface{}{execute.Time(1), 40.0},
+					),
+				},
+				"c": {
+					input("h1",
+						[]interface{}{execute.Time(1), 100.0},
+						[]interface{}{execute.Time(2), 200.0},
+						[]interface{}{execute.Time(3), 300.0},
+					),
+				},
+			},
+			want: []*executetest.Table{
+				{
+					KeyCols: []string{"host"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "host", Type: flux.TString},
+						{Label: "_value_a", Type: flux.TFloat},
+						{Label: "_value_b", Type: flux.TFloat},
+						{Label: "_value_c", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), "h1", 1.0, 10.0, 100.0},
+						{execute.Time(3), "h1", 3.0, 30.0, 300.0},
+					},
+				},
+			},
+		},
+		{
+			name: "three inputs with unique columns",
+			on:   []string{"_time"},
+			tables: map[string][]*executetest.Table{
+				"cpu": {{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "usage", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 0.5},
+						{execute.Time(2), 0.6},
+					},
+				}},
+				"mem": {{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "used", Type: flux.TInt},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), int64(512)},
+						{execute.Time(1), int64(768)},
+						{execute.Time(2), int64(1024)},
+					},
+				}},
+				"disk": {{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "free", Type: flux.TInt},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), int64(10)},
+						{execute.Time(2), nil},
+					},
+				}},
+			},
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "usage", Type: flux.TFloat},
+					{Label: "free", Type: flux.TInt},
+					{Label: "used", Type: flux.TInt},
+				},
+				Data: [][]interface{}{
+					{execute.Time(1), 0.5, int64(10), int64(512)},
+					{execute.Time(1), 0.5, int64(10), int64(768)},
+					{execute.Time(2), 0.6, nil, int64(1024)},
+				},
+			}},
+		},
+		{
+			name: "four inputs",
+			on:   []string{"_time", "host"},
+			tables: map[string][]*executetest.Table{
+				"a": {input("h1", []interface{}{execute.Time(1), 1.0}, []interface{}{execute.Time(2), 2.0})},
+				"b": {input("h1", []interface{}{execute.Time(1), 10.0}, []interface{}{execute.Time(2), 20.0})},
+				"c": {input("h1", []interface{}{execute.Time(1), 100.0})},
+				"d": {input("h1", []interface{}{execute.Time(1), 1000.0}, []interface{}{execute.Time(2), 2000.0})},
+			},
+			want: []*executetest.Table{{
+				KeyCols: []string{"host"},
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "host", Type: flux.TString},
+					{Label: "_value_a", Type: flux.TFloat},
+					{Label: "_value_b", Type: flux.TFloat},
+					{Label: "_value_c", Type: flux.TFloat},
+					{Label: "_value_d", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{execute.Time(1), "h1", 1.0, 10.0, 100.0, 1000.0},
+				},
+			}},
+		},
+		{
+			name: "four inputs with an empty input",
+			on:   []string{"_time", "host"},
+			tables: map[string][]*executetest.Table{
+				"a": {input("h1", []interface{}{execute.Time(1), 1.0})},
+				"b": {input("h1", []interface{}{execute.Time(1), 10.0})},
+				"c": nil,
+				"d": {input("h1", []interface{}{execute.Time(1), 1000.0})},
+			},
+			want: []*executetest.Table(nil),
+		},
+		{
+			name:   "full with an empty input",
+			method: "full",
+			on:     []string{"_time", "host"},
+			tables: map[string][]*executetest.Table{
+				"a": {input("h1", []interface{}{execute.Time(1), 1.0}, []interface{}{execute.Time(2), 2.0})},
+				"b": {input("h1", []interface{}{execute.Time(1), 10.0})},
+				"c": nil,
+				"d": {input("h2", []interface{}{execute.Time(1), 1000.0})},
+			},
+			want: []*executetest.Table{
+				{
+					KeyCols: []string{"host"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "host", Type: flux.TString},
+						{Label: "_value_a", Type: flux.TFloat},
+						{Label: "_value_b", Type: flux.TFloat},
+						{Label: "_value_d", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), "h1", 1.0, 10.0, nil},
+						{execute.Time(2), "h1", 2.0, nil, nil},
+					},
+				},
+				{
+					KeyCols: []string{"host"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "host", Type: flux.TString},
+						{Label: "_value_a", Type: flux.TFloat},
+						{Label: "_value_b", Type: flux.TFloat},
+						{Label: "_value_d", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), "h2", nil, nil, 1000.0},
+					},
+				},
+			},
+		},
+		{
+			name:   "full with null on values",
+			method: "full",
+			on:     []string{"_time"},
+			tables: map[string][]*executetest.Table{
+				"a": {{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TInt},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), int64(1)},
+						{nil, int64(2)},
+					},
+				}},
+				"b": {{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TInt},
+					},
+					Data: [][]interface{}{
+						{nil, int64(10)},
+					},
+				}},
+				"c": {{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TInt},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), int64(100)},
+					},
+				}},
+			},
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value_a", Type: flux.TInt},
+					{Label: "_value_b", Type: flux.TInt},
+					{Label: "_value_c", Type: flux.TInt},
+				},
+				Data: [][]interface{}{
+					{execute.Time(1), int64(1), nil, int64(100)},
+					{nil, int64(2), nil, nil},
+					{nil, nil, int64(10), nil},
+				},
+			}},
+		},
+		{
+			name: "conflicting on column types",
+			on:   []string{"id"},
+			tables: map[string][]*executetest.Table{
+				"a": {{
+					ColMeta: []flux.ColMeta{{Label: "id", Type: flux.TInt}},
+					Data:    [][]interface{}{{int64(1)}},
+				}},
+				"b": {{
+					ColMeta: []flux.ColMeta{{Label: "id", Type: flux.TString}},
+					Data:    [][]interface{}{{"1"}},
+				}},
+				"c": {{
+					ColMeta: []flux.ColMeta{{Label: "id", Type: flux.TInt}},
+					Data:    [][]interface{}{{int64(1)}},
+				}},
+			},
+			wantErr: errors.New(codes.Invalid, `join.tables found column "id" with conflicting types int and string`),
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			names := make([]string, 0, len(tc.tables))
+			for name := range tc.tables {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			inputs := make([][]*executetest.Table, len(names))
+			for i, name := range names {
+				inputs[i] = tc.tables[name]
+			}
+
+			spec := &join.TablesProcedureSpec{
+				TableNames: names,
+				On:         tc.on,
+				Method:     tc.method,
+			}
+			if spec.Method == "" {
+				spec.Method = "inner"
+			}
+			got, err := runTables(spec, inputs, nil)
+			if err != nil {
+				if tc.wantErr == nil {
+					t.Fatalf("got unexpected error: %s", err)
+				} else if err.Error() != tc.wantErr.Error() {
+					t.Fatalf("got unexpected error: wanted %q, got %q", tc.wantErr, err)
+				}
+				return
+			} else if tc.wantErr != nil {
+				t.Fatalf("expected error %q, but got none", tc.wantErr)
+			}
+
+			executetest.NormalizeTables(got)
+			executetest.NormalizeTables(tc.want)
+
+			sort.Sort(executetest.SortedTables(got))
+			sort.Sort(executetest.SortedTables(tc.want))
+
+			if !cmp.Equal(tc.want, got) {
+				t.Errorf("unexpected tables -want/+got\n%s", cmp.Diff(tc.want, got))
+			}
+		})
+	}
+}
+
+func TestTables_InvalidMethod(t *testing.T) {
+	spec := &join.TablesProcedureSpec{
+		TableNames: []string{"a", "b", "c"},
+		On:         []string{"_time"},
+		Method:     "left",
+	}
+	d := executetest.NewDataset(executetest.RandomDatasetID())
+	c := execute.NewTableBuilderCache(executetest.UnlimitedAllocator)
+	parents := []execute.DatasetID{
+		executetest.RandomDatasetID(),
+		executetest.RandomDatasetID(),
+		executetest.RandomDatasetID(),
+	}
+	_, err := join.NewTablesTransformation(spec, d, c, parents)
+	if err == nil {
+		t.Fatal("expected error, got none")
+	}
+	if want, got := `invalid join.tables method "left", must be one of "inner" or "full"`, err.Error(); want != got {
+		t.Errorf("unexpected error -want/+got\n\t- %s\n\t+ %s", want, got)
+	}
+}
+
+func TestTables_FinishError(t *testing.T) {
+	table := func() *executetest.Table {
+		return &executetest.Table{
+			ColMeta: []flux.ColMeta{
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_value", Type: flux.TFloat},
+			},
+			Data: [][]interface{}{
+				{execute.Time(1), 1.0},
+			},
+		}
+	}
+	spec := &join.TablesProcedureSpec{
+		TableNames: []string{"a", "b", "c"},
+		On:         []string{"_time"},
+		Method:     "inner",
+	}
+	inputs := [][]*executetest.Table{{table()}, {table()}, {table()}}
+	errs := []error{
+		nil,
+		errors.New(codes.Internal, "b failed"),
+		errors.New(codes.Internal, "c failed"),
+	}
+	_, err := runTables(spec, inputs, errs)
+	if err == nil {
+		t.Fatal("expected error, got none")
+	} else if want, got := "b failed", err.Error(); got != want {
+		t.Errorf("unexpected error -want/+got\n\t- %s\n\t+ %s", want, got)
+	}
+}
+
+// runTables sends the tables of each input to a join.tables transformation
+// in order and finishes each input with its error, if any. It returns the
+// tables that the transformation produces.
+func runTables(spec *join.TablesProcedureSpec, inputs [][]*executetest.Table, errs []error) ([]*executetest.Table, error) {
+	parents := make([]execute.DatasetID, len(inputs))
+	for i := range parents {
+		parents[i] = executetest.RandomDatasetID()
+	}
+
+	d := executetest.NewDataset(executetest.RandomDatasetID())
+	c := execute.NewTableBuilderCache(executetest.UnlimitedAllocator)
+	c.SetTriggerSpec(plan.DefaultTriggerSpec)
+	tt, err := join.NewTablesTransformation(spec, d, c, parents)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, tables := range inputs {
+		var err error
+		for _, tbl := range tables {
+			if err = tt.Process(parents[i], tbl); err != nil {
+				break
+			}
+		}
+		if err == nil && errs != nil {
+			err = errs[i]
+		}
+		tt.Finish(parents[i], err)
+		// A repeated finish must not finish the dataset again.
+		tt.Finish(parents[i], err)
+	}
+	if d.FinishedErr != nil {
+		return nil, d.FinishedErr
+	}
+	return executetest.TablesFromCache(c)
+}
@@ -0,0 +1,186 @@
+package join
+
+import (
+	"sort"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/values"
+)
+
+// timedRow is the index of a row and the value of its time column.
+type timedRow struct {
+	index int
+	time  values.Time
+}
+
+// matchWithin returns the indices of the rows from the right table
+// whose time is within the tolerance of the time of each row from the
+// left table and that match the predicate.
+//
+// Both tables are sorted by time so each left row only needs to be
+// compared with a window of right rows. The start of the window only
+// moves forward as the left rows are visited in order.
+func (t *joinTransformation) matchWithin(left, right joinTable) ([][]int, error) {
+	leftRows, err := sortByTime(left)
+	if err != nil {
+		return nil, err
+	}
+	rightRows, err := sortByTime(right)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make([][]int, len(left.rows))
+	within := values.Time(t.within)
+	start := 0
+	for _, l := range leftRows {
+		for start < len(rightRows) && rightRows[start].time < l.time-within {
+			start++
+		}
+		for _, r := range rightRows[start:] {
+			if r.time > l.time+within {
+				break
+			}
+			ok, err := t.predicate(left.rows[l.index], right.rows[r.index])
+			if err != nil {
+				return nil, err
+			} else if ok {
+				matches[l.index] = append(matches[l.index], r.index)
+			}
+		}
+		// Keep the right rows in the order of the table.
+		sort.Ints(matches[l.index])
+	}
+	return matches, nil
+}
+
+// matchAsOf returns the index of the most recent row from the right
+// table for each row from the left table. The most recent row is the
+// last row that matches the predicate and whose time is at or before
+// the time of the left row. When there is a tolerance, the right row
+// must also be no older than the tolerance. Left rows without such a
+// row have no match.
+func (t *joinTransformation) matchAsOf(left, right joinTable) ([][]int, error) {
+	leftRows, err := sortByTime(left)
+	if err != nil {
+		return nil, err
+	}
+	rightRows, err := sortByTime(right)
+	if err != nil {
+		return nil, err
+	}
+	if on, ok := t.on.(equalityFn); ok {
+		return t.matchAsOfEqual(left, right, leftRows, rightRows, on)
+	}
+
+	matches := make([][]int, len(left.rows))
+	within := values.Time(t.within)
+	for _, l := range leftRows {
+		// Search backwards from the last right row
+		// that is not after the left row.
+		end := sort.Search(len(rightRows), func(i int) bool {
+			return rightRows[i].time > l.time
+		})
+		for k := end - 1; k >= 0; k-- {
+			r := rightRows[k]
+			if within > 0 && l.time-r.time > within {
+				break
+			}
+			ok, err := t.predicate(left.rows[l.index], right.rows[r.index])
+			if err != nil {
+				return nil, err
+			} else if ok {
+				matches[l.index] = []int{r.index}
+				break
+			}
+		}
+	}
+	return matches, nil
+}
+
+// matchAsOfEqual finds the as-of matches when the predicate only compares
+// columns for equality. The right rows are read in time order while the
+// left rows advance and the last row seen for each key is remembered.
+// The memory used by the last seen rows is accounted against the allocator
+// and is released before returning.
+func (t *joinTransformation) matchAsOfEqual(left, right joinTable, leftRows, rightRows []timedRow, on equalityFn) ([][]int, error) {
+	leftCols := make([]string, len(on))
+	rightCols := make([]string, len(on))
+	for i, pair := range on {
+		leftCols[i], rightCols[i] = pair.Left, pair.Right
+	}
+
+	size := 0
+	defer func() {
+		_ = t.mem.Account(-size)
+	}()
+
+	matches := make([][]int, len(left.rows))
+	within := values.Time(t.within)
+	lastSeen := make(map[string]timedRow)
+	next := 0
+	var buf []byte
+	for _, l := range leftRows {
+		for ; next < len(rightRows) && rightRows[next].time <= l.time; next++ {
+			r := rightRows[next]
+			var ok bool
+			buf, ok = appendHashKey(buf[:0], right.rows[r.index], rightCols)
+			if !ok {
+				continue
+			}
+			if _, exists := lastSeen[string(buf)]; !exists {
+				n := len(buf) + hashEntrySize
+				if err := t.mem.Account(n); err != nil {
+					return nil, err
+				}
+				size += n
+			}
+			lastSeen[string(buf)] = r
+		}
+
+		var ok bool
+		buf, ok = appendHashKey(buf[:0], left.rows[l.index], leftCols)
+		if !ok {
+			continue
+		}
+		r, ok := lastSeen[string(buf)]
+		if !ok || within > 0 && l.time-r.time > within {
+			continue
+		}
+		matches[l.index] = []int{r.index}
+	}
+	return matches, nil
+}
+
+// sortByTime returns the rows of the table that have a time sorted
+// by time. Rows with the same time keep the order of the table.
+// Rows with a null or missing time are left out as they never match.
+func sortByTime(tbl joinTable) ([]timedRow, error) {
+	j := execute.ColIdx(execute.DefaultTimeColLabel, tbl.cols)
+	if j < 0 {
+		return nil, nil
+	} else if typ := tbl.cols[j].Type; typ != flux.TTime {
+		return nil, errors.Newf(codes.Invalid, "join requires column %q to be of type %s to match rows by time, but it is of type %s", execute.DefaultTimeColLabel, flux.TTime, typ)
+	}
+
+	rows := make([]timedRow, 0, len(tbl.rows))
+	for i, row := range tbl.rows {
+		v, ok := row.Get(execute.DefaultTimeColLabel)
+		if !ok || v.IsNull() {
+			continue
+		}
+		rows = append(rows, timedRow{index: i, time: v.Time()})
+	}
+	less := func(i, j int) bool {
+		return rows[i].time < rows[j].time
+	}
+	// Tables from a source are usually already sorted
+	// by time so the sort can be skipped.
+	if !sort.SliceIsSorted(rows, less) {
+		sort.SliceStable(rows, less)
+	}
+	return rows, nil
+}
@@ -1,9 +1,11 @@
 package kafka
 
 import (
-	"bufio"
+	"bytes"
 	"context"
 	"encoding/binary"
+	"encoding/json"
+	"fmt"
 	"io"
 	"net/url"
 	"sort"
@@ -14,7 +16,7 @@ import (
 	"github.com/influxdata/flux/codes"
 	"github.com/influxdata/flux/execute"
 	"github.com/influxdata/flux/internal/errors"
-	"github.com/influxdata/flux/internal/pkg/syncutil"
+	"github.com/influxdata/flux/metadata"
 	"github.com/influxdata/flux/plan"
 	"github.com/influxdata/flux/runtime"
 	"github.com/influxdata/flux/semantic"
@@ -28,6 +30,18 @@ const (
 	ToKafkaKind = "toKafka"
 )
 
+const (
+	// defaultBatchSize is the number of messages sent to kafka in a
+	// single request when batchSize is not set.
+	defaultBatchSize = 100
+
+	formatLineProtocol = "lp"
+	formatJSON         = "json"
+
+	acksOne = "one"
+	acksAll = "all"
+)
+
 type ToKafkaOpSpec struct {
 	Brokers      []string `json:"brokers"`
 	Topic        string   `json:"topic"`
@@ -37,7 +51,10 @@ type ToKafkaOpSpec struct {
 	TimeColumn   string   `json:"timeColumn"`
 	TagColumns   []string `json:"tagColumns"`
 	ValueColumns []string `json:"valueColumns"`
-	MsgBufSize   int      `json:"msgBufferSize"` // the maximim number of messages to buffer before sending to kafka, the library we use defaults to 100
+	KeyColumns   []string `json:"keyColumns"` // the columns used to build the message key, if empty the key is a hash of the message.
+	Format       string   `json:"format"`     // the serialization format of each message, either "lp" or "json".
+	BatchSize    int      `json:"batchSize"`  // the maximum number of messages to send to kafka in a single request.
+	Acks         string   `json:"acks"`       // the acknowledgements required for a write, either "one" or "all".
 }
 
 func init() {
@@ -59,6 +76,22 @@ type KafkaWriter interface {
 	WriteMessages(context.Context, ...kafka.Message) error
 }
 
+// DeliveryError is returned by a KafkaWriter when the delivery report
+// of a batch identifies the partition and offset the delivery failed at.
+type DeliveryError struct {
+	Partition int
+	Offset    int64
+	Err       error
+}
+
+func (e *DeliveryError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *DeliveryError) Unwrap() error {
+	return e.Err
+}
+
 // ReadArgs loads a flux.Arguments into ToKafkaOpSpec.  It sets several default values.
 // If the time_column isn't set, it defaults to execute.TimeColLabel.
 // If the value_column isn't set it defaults to a []string{execute.DefaultValueColLabel}.
@@ -133,21 +166,61 @@ func (o *ToKafkaOpSpec) ReadArgs(args flux.Arguments) error {
 		o.ValueColumns = append(o.ValueColumns, execute.DefaultValueColLabel)
 	} else {
 		for i := 0; i < valueColumns.Len(); i++ {
-			o.TagColumns = append(o.ValueColumns, valueColumns.Get(i).Str())
+			o.ValueColumns = append(o.ValueColumns, valueColumns.Get(i).Str())
 		}
-		sort.Strings(o.TagColumns)
+		sort.Strings(o.ValueColumns)
 	}
 
-	msgBufSize, ok, err := args.GetInt("msgBufferSize")
-	o.MsgBufSize = int(msgBufSize)
+	// The key columns are kept in the order they were given
+	// since that is the order their values appear in the key.
+	keyColumns, ok, err := args.GetArray("keyColumns", semantic.String)
 	if err != nil {
 		return err
 	}
-	if o.MsgBufSize < 0 || !ok {
-		o.MsgBufSize = 0 // so the library will set it  to the default
+	o.KeyColumns = o.KeyColumns[:0]
+	if ok {
+		for i := 0; i < keyColumns.Len(); i++ {
+			o.KeyColumns = append(o.KeyColumns, keyColumns.Get(i).Str())
+		}
 	}
 
-	return err
+	o.Format, ok, err = args.GetString("format")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		o.Format = formatLineProtocol
+	}
+	switch o.Format {
+	case formatLineProtocol, formatJSON:
+	default:
+		return errors.Newf(codes.Invalid, "invalid format %q, must be one of %q or %q", o.Format, formatLineProtocol, formatJSON)
+	}
+
+	batchSize, ok, err := args.GetInt("batchSize")
+	if err != nil {
+		return err
+	}
+	if ok {
+		if batchSize <= 0 {
+			return errors.Newf(codes.Invalid, "batchSize must be a positive integer, but was %d", batchSize)
+		}
+		o.BatchSize = int(batchSize)
+	}
+
+	o.Acks, ok, err = args.GetString("acks")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		o.Acks = acksAll
+	}
+	switch o.Acks {
+	case acksOne, acksAll:
+	default:
+		return errors.Newf(codes.Invalid, "invalid acks %q, must be one of %q or %q", o.Acks, acksOne, acksAll)
+	}
+	return nil
 }
 func createToKafkaOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
 	if err := a.AddParentFromArgs(args); err != nil {
@@ -185,6 +258,10 @@ func (o *ToKafkaProcedureSpec) Copy() plan.ProcedureSpec {
 			TimeColumn:   s.TimeColumn,
 			TagColumns:   append([]string(nil), s.TagColumns...),
 			ValueColumns: append([]string(nil), s.ValueColumns...),
+			KeyColumns:   append([]string(nil), s.KeyColumns...),
+			Format:       s.Format,
+			BatchSize:    s.BatchSize,
+			Acks:         s.Acks,
 		},
 	}
 	switch s.Balancer {
@@ -223,6 +300,11 @@ type ToKafkaTransformation struct {
 	d     execute.Dataset
 	cache execute.TableBuilderCache
 	spec  *ToKafkaProcedureSpec
+
+	// messages and batches count what has been
+	// delivered to kafka and are reported as metadata.
+	messages int64
+	batches  int64
 }
 
 func (t *ToKafkaTransformation) RetractTable(id execute.DatasetID, key flux.GroupKey) error {
@@ -273,18 +355,92 @@ func (m *toKafkaMetric) Time() time.Time {
 	return m.t
 }
 
+// metricEncoder serializes a metric into the value of a kafka message.
+type metricEncoder interface {
+	Encode(m *toKafkaMetric) ([]byte, error)
+}
+
+func newMetricEncoder(format string) metricEncoder {
+	if format == formatJSON {
+		return jsonEncoder{}
+	}
+	e := &lineProtocolEncoder{}
+	e.enc = protocol.NewEncoder(&e.buf)
+	e.enc.FailOnFieldErr(true)
+	e.enc.SetFieldSortOrder(protocol.SortFields)
+	return e
+}
+
+type lineProtocolEncoder struct {
+	buf bytes.Buffer
+	enc *protocol.Encoder
+}
+
+func (e *lineProtocolEncoder) Encode(m *toKafkaMetric) ([]byte, error) {
+	e.buf.Reset()
+	if _, err := e.enc.Encode(m); err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), bytes.TrimSuffix(e.buf.Bytes(), []byte("\n"))...), nil
+}
+
+type jsonEncoder struct{}
+
+type jsonMetric struct {
+	Name      string                 `json:"name"`
+	Tags      map[string]string      `json:"tags"`
+	Fields    map[string]interface{} `json:"fields"`
+	Timestamp int64                  `json:"timestamp"`
+}
+
+func (jsonEncoder) Encode(m *toKafkaMetric) ([]byte, error) {
+	jm := jsonMetric{
+		Name:      m.name,
+		Tags:      make(map[string]string, len(m.tags)),
+		Fields:    make(map[string]interface{}, len(m.fields)),
+		Timestamp: m.t.UnixNano(),
+	}
+	for _, tag := range m.tags {
+		jm.Tags[tag.Key] = tag.Value
+	}
+	for _, field := range m.fields {
+		if v, ok := field.Value.(values.Time); ok {
+			jm.Fields[field.Key] = v.Time().Format(time.RFC3339Nano)
+			continue
+		}
+		jm.Fields[field.Key] = field.Value
+	}
+	return json.Marshal(jm)
+}
+
 type idxType struct {
 	Idx  int
 	Type flux.ColType
 }
 
+func (t *ToKafkaTransformation) requiredAcks() int {
+	if t.spec.Spec.Acks == acksOne {
+		return 1
+	}
+	return -1
+}
+
+func (t *ToKafkaTransformation) batchSize() int {
+	if t.spec.Spec.BatchSize > 0 {
+		return t.spec.Spec.BatchSize
+	}
+	return defaultBatchSize
+}
+
 func (t *ToKafkaTransformation) Process(id execute.DatasetID, tbl flux.Table) (err error) {
+	batchSize := t.batchSize()
 	w := DefaultKafkaWriterFactory(kafka.WriterConfig{
 		Brokers:       t.spec.Spec.Brokers,
 		Topic:         t.spec.Spec.Topic,
 		Balancer:      t.spec.balancer,
-		BatchSize:     t.spec.Spec.MsgBufSize,
-		QueueCapacity: t.spec.Spec.MsgBufSize,
+		BatchSize:     batchSize,
+		QueueCapacity: batchSize,
+		RequiredAcks:  t.requiredAcks(),
 	})
 
 	defer func() {
@@ -299,12 +455,8 @@ func (t *ToKafkaTransformation) Process(id execute.DatasetID, tbl flux.Table) (e
 			return
 		}
 	}()
-	pr, pw := io.Pipe() // TODO: replce the pipe with something faster
-	// I'd like a linereader in line-protocol
 	m := &toKafkaMetric{}
-	e := protocol.NewEncoder(pw)
-	e.FailOnFieldErr(true)
-	e.SetFieldSortOrder(protocol.SortFields)
+	e := newMetricEncoder(t.spec.Spec.Format)
 	cols := tbl.Cols()
 	labels := make(map[string]idxType, len(cols))
 	for i, col := range cols {
@@ -319,6 +471,14 @@ func (t *ToKafkaTransformation) Process(id execute.DatasetID, tbl flux.Table) (e
 	if timeColIdx.Type != flux.TTime {
 		return errors.Newf(codes.FailedPrecondition, "column %s is not of type %s", timeColLabel, timeColIdx.Type)
 	}
+	keyColIdxs := make([]int, len(t.spec.Spec.KeyColumns))
+	for i, label := range t.spec.Spec.KeyColumns {
+		idx, ok := labels[label]
+		if !ok {
+			return errors.Newf(codes.FailedPrecondition, "could not get key column %q", label)
+		}
+		keyColIdxs[i] = idx.Idx
+	}
 	var measurementNameCol string
 	if t.spec.Spec.Name == "" {
 		measurementNameCol = t.spec.Spec.NameColumn
@@ -340,91 +500,109 @@ func (t *ToKafkaTransformation) Process(id execute.DatasetID, tbl flux.Table) (e
 		}
 	}
 
-	var wg syncutil.WaitGroup
-	wg.Do(func() error {
-		if err := tbl.Do(func(er flux.ColReader) error {
-			l := er.Len()
-			for i := 0; i < l; i++ {
-				m.truncateTagsAndFields()
-				for j, col := range er.Cols() {
-					switch {
-					case col.Label == timeColLabel:
-						m.t = values.Time(er.Times(j).Value(i)).Time()
-					case measurementNameCol != "" && measurementNameCol == col.Label:
-						if col.Type != flux.TString {
-							return errors.New(codes.FailedPrecondition, "invalid type for measurement column")
-						}
-						m.name = er.Strings(j).Value(i)
-					case isTag[j]:
-						if col.Type != flux.TString {
-							return errors.New(codes.FailedPrecondition, "invalid type for measurement column")
-						}
-						m.tags = append(m.tags, &protocol.Tag{Key: col.Label, Value: er.Strings(j).Value(i)})
-					case isValue[j]:
-						switch col.Type {
-						case flux.TFloat:
-							m.fields = append(m.fields, &protocol.Field{Key: col.Label, Value: er.Floats(j).Value(i)})
-						case flux.TInt:
-							m.fields = append(m.fields, &protocol.Field{Key: col.Label, Value: er.Ints(j).Value(i)})
-						case flux.TUInt:
-							m.fields = append(m.fields, &protocol.Field{Key: col.Label, Value: er.UInts(j).Value(i)})
-						case flux.TString:
-							m.fields = append(m.fields, &protocol.Field{Key: col.Label, Value: er.Strings(j).Value(i)})
-						case flux.TTime:
-							m.fields = append(m.fields, &protocol.Field{Key: col.Label, Value: values.Time(er.Times(j).Value(i))})
-						case flux.TBool:
-							m.fields = append(m.fields, &protocol.Field{Key: col.Label, Value: er.Bools(j).Value(i)})
-						default:
-							return errors.Newf(codes.FailedPrecondition, "invalid type for column %s", col.Label)
-						}
+	// write the messages to kafka in batches
+	msgs := make([]kafka.Message, 0, batchSize)
+	flush := func() error {
+		if len(msgs) == 0 {
+			return nil
+		}
+		if err := w.WriteMessages(context.Background(), msgs...); err != nil {
+			var de *DeliveryError
+			if errors.As(err, &de) {
+				return errors.Wrapf(err, codes.Unavailable, "failed to deliver %d messages to kafka topic %q partition %d at offset %d", len(msgs), t.spec.Spec.Topic, de.Partition, de.Offset)
+			}
+			return errors.Wrapf(err, codes.Unavailable, "failed to deliver %d messages to kafka topic %q", len(msgs), t.spec.Spec.Topic)
+		}
+		t.messages += int64(len(msgs))
+		t.batches++
+		// The writer may hold on to the messages so
+		// allocate a new buffer rather than reuse it.
+		msgs = make([]kafka.Message, 0, batchSize)
+		return nil
+	}
+
+	if err := tbl.Do(func(er flux.ColReader) error {
+		l := er.Len()
+		for i := 0; i < l; i++ {
+			m.truncateTagsAndFields()
+			for j, col := range er.Cols() {
+				switch {
+				case col.Label == timeColLabel:
+					m.t = values.Time(er.Times(j).Value(i)).Time()
+				case measurementNameCol != "" && measurementNameCol == col.Label:
+					if col.Type != flux.TString {
+						return errors.New(codes.FailedPrecondition, "invalid type for measurement column")
+					}
+					m.name = er.Strings(j).Value(i)
+				case isTag[j]:
+					if col.Type != flux.TString {
+						return errors.New(codes.FailedPrecondition, "invalid type for measurement column")
+					}
+					m.tags = append(m.tags, &protocol.Tag{Key: col.Label, Value: er.Strings(j).Value(i)})
+				case isValue[j]:
+					switch col.Type {
+					case flux.TFloat:
+						m.fields = append(m.fields, &protocol.Field{Key: col.Label, Value: er.Floats(j).Value(i)})
+					case flux.TInt:
+						m.fields = append(m.fields, &protocol.Field{Key: col.Label, Value: er.Ints(j).Value(i)})
+					case flux.TUInt:
+						m.fields = append(m.fields, &protocol.Field{Key: col.Label, Value: er.UInts(j).Value(i)})
+					case flux.TString:
+						m.fields = append(m.fields, &protocol.Field{Key: col.Label, Value: er.Strings(j).Value(i)})
+					case flux.TTime:
+						m.fields = append(m.fields, &protocol.Field{Key: col.Label, Value: values.Time(er.Times(j).Value(i))})
+					case flux.TBool:
+						m.fields = append(m.fields, &protocol.Field{Key: col.Label, Value: er.Bools(j).Value(i)})
+					default:
+						return errors.Newf(codes.FailedPrecondition, "invalid type for column %s", col.Label)
 					}
-				}
-				_, err := e.Encode(m)
-				if err != nil {
-					return err
-				}
-				if err := execute.AppendRecord(i, er, builder); err != nil {
-					return err
 				}
 			}
-			return nil
-		}); err != nil {
-			_ = pw.Close()
-			return err
-		}
-		return pw.Close()
-	})
-	// write the data to kafka
-	{
-		scan := bufio.NewScanner(pr)
-		msgBuf := make([]kafka.Message, 128)
-		i := 0
-		// todo, make this a little more async
-		for scan.Scan() {
-			v := append([]byte(nil), scan.Bytes()...) // we do this since scan.Bytes()'s result can be overwritten by calls to Scan()
-			if cap(msgBuf[i].Key) != 8 {
-				msgBuf[i].Key = make([]byte, 8)
+			v, err := e.Encode(m)
+			if err != nil {
+				return err
 			}
-			binary.LittleEndian.PutUint64(msgBuf[i].Key, xxhash.Sum64(v))
-			msgBuf[i].Value = v
-			if i == t.spec.Spec.MsgBufSize-1 {
-				if err = w.WriteMessages(context.Background(), msgBuf...); err != nil {
+			msgs = append(msgs, kafka.Message{
+				Key:   messageKey(er, i, keyColIdxs, v),
+				Value: v,
+			})
+			if len(msgs) >= batchSize {
+				if err := flush(); err != nil {
 					return err
 				}
-				msgBuf = msgBuf[:0]
-				i = 0
 			}
-			i++
-		}
-		// send the remainder of the messages
-		if len(msgBuf) > 0 {
-			err = w.WriteMessages(context.Background(), msgBuf[:i]...)
+			if err := execute.AppendRecord(i, er, builder); err != nil {
+				return err
+			}
 		}
-	}
-	if err := wg.Wait(); err != nil {
+		return nil
+	}); err != nil {
 		return err
 	}
-	return err
+	// send the remainder of the messages
+	return flush()
+}
+
+// messageKey returns the key for the message with the given value.
+// If there are no key columns, the key is a hash of the value.
+// Otherwise, the key is the values of the key columns joined by a comma.
+func messageKey(cr flux.ColReader, i int, keyColIdxs []int, v []byte) []byte {
+	if len(keyColIdxs) == 0 {
+		key := make([]byte, 8)
+		binary.LittleEndian.PutUint64(key, xxhash.Sum64(v))
+		return key
+	}
+
+	var key []byte
+	for n, j := range keyColIdxs {
+		if n > 0 {
+			key = append(key, ',')
+		}
+		if kv := execute.ValueForRow(cr, i, j); !kv.IsNull() {
+			key = append(key, fmt.Sprint(values.Unwrap(kv))...)
+		}
+	}
+	return key
 }
 
 func (t *ToKafkaTransformation) UpdateWatermark(id execute.DatasetID, pt execute.Time) error {
@@ -438,3 +616,11 @@ func (t *ToKafkaTransformation) UpdateProcessingTime(id execute.DatasetID, pt ex
 func (t *ToKafkaTransformation) Finish(id execute.DatasetID, err error) {
 	t.d.Finish(err)
 }
+
+// Metadata reports the number of messages and batches delivered to kafka.
+func (t *ToKafkaTransformation) Metadata() metadata.Metadata {
+	md := make(metadata.Metadata)
+	md.Add("kafka/messages", t.messages)
+	md.Add("kafka/batches", t.batches)
+	return md
+}
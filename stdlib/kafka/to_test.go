@@ -2,9 +2,12 @@ package kafka_test
 
 import (
 	"context"
+	"encoding/binary"
+	"errors"
 	"sync"
 	"testing"
 
+	"github.com/cespare/xxhash/v2"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/influxdata/flux"
@@ -43,6 +46,8 @@ func TestToKafka_NewQuery(t *testing.T) {
 							Name:         "series1",
 							TimeColumn:   execute.DefaultTimeColLabel,
 							ValueColumns: []string{execute.DefaultValueColLabel},
+							Format:       "lp",
+							Acks:         "all",
 						},
 					},
 				},
@@ -64,6 +69,7 @@ func TestToKafka_NewQuery(t *testing.T) {
 type kafkaMock struct {
 	sync.Mutex
 	data [][]kafka.Message
+	err  error
 }
 
 func (k *kafkaMock) reset() {
@@ -76,8 +82,11 @@ func (k *kafkaMock) Close() error { return nil }
 
 func (k *kafkaMock) WriteMessages(_ context.Context, msgs ...kafka.Message) error {
 	k.Lock()
+	defer k.Unlock()
+	if k.err != nil {
+		return k.err
+	}
 	k.data = append(k.data, msgs)
-	k.Unlock()
 	return nil
 }
 
@@ -593,3 +602,169 @@ func TestToKafka_NewTransformation(t *testing.T) {
 	}
 	test.Run(t)
 }
+
+// hashKey returns the default message key for a message value.
+func hashKey(v string) []byte {
+	key := make([]byte, 8)
+	binary.LittleEndian.PutUint64(key, xxhash.Sum64String(v))
+	return key
+}
+
+func TestToKafka_ProcessOptions(t *testing.T) {
+	data := &kafkaMock{}
+	var config kafka.WriterConfig
+	fkafka.DefaultKafkaWriterFactory = func(conf kafka.WriterConfig) fkafka.KafkaWriter {
+		config = conf
+		return data
+	}
+
+	newTable := func() flux.Table {
+		return &executetest.Table{
+			ColMeta: []flux.ColMeta{
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_measurement", Type: flux.TString},
+				{Label: "_value", Type: flux.TFloat},
+				{Label: "host", Type: flux.TString},
+				{Label: "region", Type: flux.TString},
+			},
+			Data: [][]interface{}{
+				{execute.Time(11), "cpu", 2.0, "a", "east"},
+				{execute.Time(21), "cpu", 1.0, "b", "west"},
+				{execute.Time(31), "cpu", 3.0, "a", "east"},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name      string
+		spec      *fkafka.ToKafkaOpSpec
+		writeErr  error
+		want      [][]kafka.Message
+		wantAcks  int
+		wantBatch int
+		wantErr   string
+		wantMeta  []int64
+	}{
+		{
+			name: "batch size",
+			spec: &fkafka.ToKafkaOpSpec{
+				BatchSize: 2,
+			},
+			want: [][]kafka.Message{{
+				{Value: []byte("cpu,host=a _value=2 11"), Key: hashKey("cpu,host=a _value=2 11")},
+				{Value: []byte("cpu,host=b _value=1 21"), Key: hashKey("cpu,host=b _value=1 21")},
+			}, {
+				{Value: []byte("cpu,host=a _value=3 31"), Key: hashKey("cpu,host=a _value=3 31")},
+			}},
+			wantAcks:  -1,
+			wantBatch: 2,
+			wantMeta:  []int64{3, 2},
+		},
+		{
+			name: "key columns",
+			spec: &fkafka.ToKafkaOpSpec{
+				KeyColumns: []string{"region", "host"},
+				Acks:       "one",
+			},
+			want: [][]kafka.Message{{
+				{Value: []byte("cpu,host=a _value=2 11"), Key: []byte("east,a")},
+				{Value: []byte("cpu,host=b _value=1 21"), Key: []byte("west,b")},
+				{Value: []byte("cpu,host=a _value=3 31"), Key: []byte("east,a")},
+			}},
+			wantAcks:  1,
+			wantBatch: 100,
+			wantMeta:  []int64{3, 1},
+		},
+		{
+			name: "json",
+			spec: &fkafka.ToKafkaOpSpec{
+				KeyColumns: []string{"host"},
+				Format:     "json",
+			},
+			want: [][]kafka.Message{{
+				{Value: []byte(`{"name":"cpu","tags":{"host":"a"},"fields":{"_value":2},"timestamp":11}`), Key: []byte("a")},
+				{Value: []byte(`{"name":"cpu","tags":{"host":"b"},"fields":{"_value":1},"timestamp":21}`), Key: []byte("b")},
+				{Value: []byte(`{"name":"cpu","tags":{"host":"a"},"fields":{"_value":3},"timestamp":31}`), Key: []byte("a")},
+			}},
+			wantAcks:  -1,
+			wantBatch: 100,
+			wantMeta:  []int64{3, 1},
+		},
+		{
+			name: "missing key column",
+			spec: &fkafka.ToKafkaOpSpec{
+				KeyColumns: []string{"dc"},
+			},
+			wantErr:  `could not get key column "dc"`,
+			wantMeta: []int64{0, 0},
+		},
+		{
+			name:     "delivery failure",
+			spec:     &fkafka.ToKafkaOpSpec{},
+			writeErr: errors.New("leader not available"),
+			wantErr:  `failed to deliver 3 messages to kafka topic "totallynotfaketopic": leader not available`,
+			wantMeta: []int64{0, 0},
+		},
+		{
+			name: "delivery failure with report",
+			spec: &fkafka.ToKafkaOpSpec{},
+			writeErr: &fkafka.DeliveryError{
+				Partition: 2,
+				Offset:    41,
+				Err:       errors.New("leader not available"),
+			},
+			wantErr:  `failed to deliver 3 messages to kafka topic "totallynotfaketopic" partition 2 at offset 41: leader not available`,
+			wantMeta: []int64{0, 0},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			defer data.reset()
+			data.err = tc.writeErr
+
+			spec := tc.spec
+			spec.Brokers = []string{"brokerurl:8989"}
+			spec.Topic = "totallynotfaketopic"
+			spec.NameColumn = "_measurement"
+			spec.TimeColumn = execute.DefaultTimeColLabel
+			spec.TagColumns = []string{"host"}
+			spec.ValueColumns = []string{"_value"}
+
+			d := executetest.NewDataset(executetest.RandomDatasetID())
+			c := execute.NewTableBuilderCache(executetest.UnlimitedAllocator)
+			c.SetTriggerSpec(plan.DefaultTriggerSpec)
+			tr, err := fkafka.NewToKafkaTransformation(d, dependenciestest.Default(), c, &fkafka.ToKafkaProcedureSpec{Spec: spec})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = tr.Process(executetest.RandomDatasetID(), newTable())
+			if tc.wantErr != "" {
+				if err == nil {
+					t.Fatalf("expected error %q, got none", tc.wantErr)
+				} else if got := err.Error(); got != tc.wantErr {
+					t.Fatalf("unexpected error -want/+got:\n\t- %s\n\t+ %s", tc.wantErr, got)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			} else {
+				if !cmp.Equal(tc.want, data.data) {
+					t.Errorf("unexpected messages -want/+got:\n%s", cmp.Diff(tc.want, data.data))
+				}
+				if got, want := config.RequiredAcks, tc.wantAcks; got != want {
+					t.Errorf("unexpected required acks -want/+got:\n\t- %d\n\t+ %d", want, got)
+				}
+				if got, want := config.BatchSize, tc.wantBatch; got != want {
+					t.Errorf("unexpected batch size -want/+got:\n\t- %d\n\t+ %d", want, got)
+				}
+			}
+
+			md := tr.Metadata()
+			if got, want := []int64{md["kafka/messages"][0].(int64), md["kafka/batches"][0].(int64)}, tc.wantMeta; !cmp.Equal(want, got) {
+				t.Errorf("unexpected metadata -want/+got:\n%s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
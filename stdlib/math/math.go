@@ -6,6 +6,7 @@ import (
 	"math"
 
 	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/internal/decimal"
 	"github.com/influxdata/flux/internal/errors"
 	"github.com/influxdata/flux/runtime"
 	"github.com/influxdata/flux/semantic"
@@ -471,6 +472,32 @@ func init() {
 				return values.NewFloat(math.Pow10(int(v1.Int()))), nil
 			}, false,
 		),
+		// (float, int) --> float
+		"roundTo": values.NewFunction(
+			"roundTo",
+			runtime.MustLookupBuiltinType("math", "roundTo"),
+			func(ctx context.Context, args values.Object) (values.Value, error) {
+				names := []string{"x", "places"}
+				v1, ok := args.Get(names[0])
+				if !ok {
+					return nil, errors.Newf(codes.Invalid, "missing argument %s", names[0])
+				}
+				v2, ok := args.Get(names[1])
+				if !ok {
+					return nil, errors.Newf(codes.Invalid, "missing argument %s", names[1])
+				}
+				if v1.Type().Nature() != semantic.Float {
+					return nil, fmt.Errorf("cannot convert argument %s of type %v to float", names[0], v1.Type().Nature())
+				}
+				if v2.Type().Nature() != semantic.Int {
+					return nil, fmt.Errorf("cannot convert argument %s of type %v to int", names[1], v2.Type().Nature())
+				}
+				if v1.IsNull() || v2.IsNull() {
+					return values.NewNull(semantic.BasicFloat), nil
+				}
+				return values.NewFloat(decimal.Round(v1.Float(), int(v2.Int()))), nil
+			}, false,
+		),
 	}
 
 	// special case args and/or return types not worth generalizing
@@ -490,4 +517,5 @@ func init() {
 	runtime.RegisterPackageValue("math", "yn", SpecialFns["yn"])
 	runtime.RegisterPackageValue("math", "ldexp", SpecialFns["ldexp"])
 	runtime.RegisterPackageValue("math", "pow10", SpecialFns["pow10"])
+	runtime.RegisterPackageValue("math", "roundTo", SpecialFns["roundTo"])
 }
@@ -538,3 +538,39 @@ func TestPow10(t *testing.T) {
 func floatsNotEqual(want, got float64) bool {
 	return want != got && !(math.IsNaN(want) && math.IsNaN(got))
 }
+
+func TestRoundTo(t *testing.T) {
+	fluxFunc := SpecialFns["roundTo"]
+	testCases := []struct {
+		x      float64
+		places int64
+		want   float64
+	}{
+		{x: 0.30000000000000004, places: 2, want: 0.3},
+		{x: 3.14159, places: 2, want: 3.14},
+		{x: 0.125, places: 2, want: 0.12},
+		{x: 0.135, places: 2, want: 0.14},
+		{x: 2.675, places: 2, want: 2.68},
+		{x: -2.5, places: 0, want: -2},
+		{x: 3.5, places: 0, want: 4},
+		{x: 0.5, places: 0, want: 0},
+		{x: 1250, places: -2, want: 1200},
+		{x: 1350, places: -2, want: 1400},
+		{x: 1.5, places: 10, want: 1.5},
+		{x: math.Inf(1), places: 2, want: math.Inf(1)},
+		{x: math.Inf(-1), places: 2, want: math.Inf(-1)},
+		{x: math.NaN(), places: 2, want: math.NaN()},
+	}
+	ctx, deps := dependency.Inject(context.Background(), dependenciestest.Default())
+	defer deps.Finish()
+	for _, tc := range testCases {
+		fluxArg := values.NewObjectWithValues(map[string]values.Value{"x": values.NewFloat(tc.x), "places": values.NewInt(tc.places)})
+		got, err := fluxFunc.Call(ctx, fluxArg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if floatsNotEqual(tc.want, got.Float()) {
+			t.Errorf("math.roundTo function result input %v, %d: expected %v, got %v", tc.x, tc.places, tc.want, got)
+		}
+	}
+}
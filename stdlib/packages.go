@@ -4,6 +4,7 @@ package stdlib
 
 import (
 	_ "github.com/influxdata/flux/stdlib/array"
+	_ "github.com/influxdata/flux/stdlib/bytes"
 	_ "github.com/influxdata/flux/stdlib/contrib/RohanSreerama5/naiveBayesClassifier"
 	_ "github.com/influxdata/flux/stdlib/contrib/anaisdg/anomalydetection"
 	_ "github.com/influxdata/flux/stdlib/contrib/anaisdg/statsmodels"
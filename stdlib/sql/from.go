@@ -3,6 +3,7 @@ package sql
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/influxdata/flux"
 	"github.com/influxdata/flux/codes"
@@ -11,6 +12,7 @@ import (
 	"github.com/influxdata/flux/memory"
 	"github.com/influxdata/flux/plan"
 	"github.com/influxdata/flux/runtime"
+	"github.com/influxdata/flux/values"
 	_ "github.com/lib/pq"
 	_ "github.com/vertica/vertica-sql-go"
 )
@@ -21,9 +23,10 @@ const FromSQLKind = "fromSQL"
 const layout = "2006-01-02 15:04:05.999999999"
 
 type FromSQLOpSpec struct {
-	DriverName     string `json:"driverName,omitempty"`
-	DataSourceName string `json:"dataSourceName,omitempty"`
-	Query          string `json:"query,omitempty"`
+	DriverName     string        `json:"driverName,omitempty"`
+	DataSourceName string        `json:"dataSourceName,omitempty"`
+	Query          string        `json:"query,omitempty"`
+	QueryTimeout   flux.Duration `json:"queryTimeout,omitempty"`
 }
 
 func init() {
@@ -52,6 +55,14 @@ func createFromSQLOpSpec(args flux.Arguments, administration *flux.Administratio
 	} else {
 		spec.Query = query
 	}
+	if queryTimeout, ok, err := args.GetDuration("queryTimeout"); err != nil {
+		return nil, err
+	} else if ok {
+		if !queryTimeout.IsPositive() {
+			return nil, errors.New(codes.Invalid, "queryTimeout must be positive")
+		}
+		spec.QueryTimeout = queryTimeout
+	}
 	return spec, nil
 }
 
@@ -68,6 +79,9 @@ type FromSQLProcedureSpec struct {
 	DriverName     string
 	DataSourceName string
 	Query          string
+	// QueryTimeout is the maximum time that the query may run
+	// in the database. There is no timeout when it is zero.
+	QueryTimeout flux.Duration
 }
 
 func newFromSQLProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.ProcedureSpec, error) {
@@ -80,6 +94,7 @@ func newFromSQLProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.Pr
 		DriverName:     spec.DriverName,
 		DataSourceName: spec.DataSourceName,
 		Query:          spec.Query,
+		QueryTimeout:   spec.QueryTimeout,
 	}, nil
 }
 
@@ -92,6 +107,7 @@ func (s *FromSQLProcedureSpec) Copy() plan.ProcedureSpec {
 	ns.DriverName = s.DriverName
 	ns.DataSourceName = s.DataSourceName
 	ns.Query = s.Query
+	ns.QueryTimeout = s.QueryTimeout
 	return ns
 }
 
@@ -161,7 +177,7 @@ func (c *sqlIterator) connect(ctx context.Context) (*sql.DB, error) {
 	if err != nil {
 		return nil, err
 	}
-	if err := db.Ping(); err != nil {
+	if err := db.PingContext(ctx); err != nil {
 		_ = db.Close()
 		return nil, err
 	}
@@ -169,24 +185,106 @@ func (c *sqlIterator) connect(ctx context.Context) (*sql.DB, error) {
 }
 
 func (c *sqlIterator) Do(ctx context.Context, f func(flux.Table) error) error {
+	// The query is cancelled in the database when the
+	// timeout is exceeded or the Flux query is cancelled.
+	if timeout := values.Duration(c.spec.QueryTimeout).Duration(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	table, err := c.query(ctx)
+	if err != nil {
+		return queryError(ctx, err)
+	}
+	return f(table)
+}
+
+func (c *sqlIterator) query(ctx context.Context) (flux.Table, error) {
 	// Connect to the database so we can execute the query.
 	db, err := c.connect(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer func() { _ = db.Close() }()
 
-	rows, err := db.QueryContext(ctx, c.spec.Query)
+	// The query runs on a single connection so that
+	// the driver can identify it to kill it.
+	conn, err := db.Conn(ctx)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	defer func() { _ = conn.Close() }()
+
+	if newKill, ok := queryKillers[c.spec.DriverName]; ok {
+		kill, err := newKill(ctx, conn)
+		if err != nil {
+			return nil, err
+		}
+		stop := killOnCancel(ctx, db, kill)
+		defer stop()
 	}
-	defer func() { _ = rows.Close() }()
 
-	table, err := c.read(ctx, rows)
+	rows, err := conn.QueryContext(ctx, c.spec.Query)
 	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	return c.read(ctx, rows)
+}
+
+// queryError returns the error of a query that was stopped
+// because the context is done with the code of the context.
+func queryError(ctx context.Context, err error) error {
+	switch ctx.Err() {
+	case context.Canceled:
+		return errors.Wrap(err, codes.Canceled, "sql query was cancelled")
+	case context.DeadlineExceeded:
+		return errors.Wrap(err, codes.DeadlineExceeded, "sql query timed out")
+	default:
 		return err
 	}
-	return f(table)
+}
+
+// queryKillTimeout is the maximum time to kill a query in the database.
+const queryKillTimeout = 10 * time.Second
+
+// A queryKiller kills the query that runs on a connection.
+type queryKiller func(ctx context.Context, db *sql.DB) error
+
+// queryKillers creates the queryKiller of a connection for the drivers
+// that do not stop a query in the database when its context is done.
+// Other drivers, such as postgres, which sends a cancel request to the
+// database, stop the query by themselves.
+var queryKillers = map[string]func(ctx context.Context, conn *sql.Conn) (queryKiller, error){
+	"mysql": newMySQLQueryKiller,
+}
+
+// killOnCancel kills the query in the database when the context is
+// done before stop is called. The query is killed with another
+// connection from the database because its own connection is busy.
+func killOnCancel(ctx context.Context, db *sql.DB, kill queryKiller) (stop func()) {
+	done, finished := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(finished)
+		select {
+		case <-ctx.Done():
+		case <-done:
+			// The driver may return as soon as the context is done,
+			// which leaves the query running in the database.
+			if ctx.Err() == nil {
+				return
+			}
+		}
+		killCtx, cancel := context.WithTimeout(context.Background(), queryKillTimeout)
+		defer cancel()
+		_ = kill(killCtx, db)
+	}()
+	return func() {
+		close(done)
+		<-finished
+	}
 }
 
 // read will use the RowReader to construct a flux.Table.
@@ -203,6 +301,11 @@ func read(ctx context.Context, reader execute.RowReader, alloc memory.Allocator)
 		}
 	}
 	for reader.Next() {
+		// Stop reading promptly when the query is cancelled
+		// even if the driver keeps returning buffered rows.
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		row, err := reader.GetNextRow()
 		if err != nil {
 			return nil, err
@@ -1,6 +1,7 @@
 package sql
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"strconv"
@@ -197,3 +198,18 @@ func MysqlColumnTranslateFunc() translationFunc {
 func mysqlQuoteIdent(name string) string {
 	return fmt.Sprintf("`%s`", strings.ReplaceAll(name, "`", "``"))
 }
+
+// newMySQLQueryKiller creates a queryKiller that kills the query that runs on
+// the connection with KILL QUERY. The mysql driver only closes the connection
+// when the context of a query is done, which leaves the query running in the
+// database.
+func newMySQLQueryKiller(ctx context.Context, conn *sql.Conn) (queryKiller, error) {
+	var id int64
+	if err := conn.QueryRowContext(ctx, "SELECT CONNECTION_ID()").Scan(&id); err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, db *sql.DB) error {
+		_, err := db.ExecContext(ctx, fmt.Sprintf("KILL QUERY %d", id))
+		return err
+	}, nil
+}
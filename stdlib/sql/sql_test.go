@@ -10,6 +10,7 @@ import (
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/go-cmp/cmp"
 	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
 	"github.com/influxdata/flux/execute"
 	"github.com/influxdata/flux/execute/executetest"
 	"github.com/influxdata/flux/memory"
@@ -134,6 +135,153 @@ func TestFromRowReader(t *testing.T) {
 	})
 }
 
+// endlessRowReader returns rows until the query is cancelled.
+// It cancels the query after it returns the given number of rows.
+type endlessRowReader struct {
+	MockRowReader
+	cancel func()
+	n      int
+}
+
+func (r *endlessRowReader) Next() bool {
+	return true
+}
+
+func (r *endlessRowReader) GetNextRow() ([]values.Value, error) {
+	r.n--
+	if r.n == 0 {
+		r.cancel()
+	}
+	return []values.Value{values.NewInt(1), values.NewFloat(1), values.NewBool(true), values.NewTime(0)}, nil
+}
+
+func TestFromRowReader_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	rr := &endlessRowReader{cancel: cancel, n: 3}
+	rr.InitColumnTypes(nil)
+	if _, err := read(ctx, rr, &memory.ResourceAllocator{}); err != context.Canceled {
+		t.Fatalf("unexpected error, want %v, got %v", context.Canceled, err)
+	}
+	if rr.n != 0 {
+		t.Errorf("unexpected rows read after cancel: %d", -rr.n)
+	}
+}
+
+func TestSQLIterator_Cancel(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		timeout  time.Duration
+		cancel   bool
+		wantCode codes.Code
+	}{
+		{
+			name:     "query timeout",
+			timeout:  10 * time.Millisecond,
+			wantCode: codes.DeadlineExceeded,
+		},
+		{
+			name:     "cancelled query",
+			cancel:   true,
+			wantCode: codes.Canceled,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			dsn := "sqlmock_" + t.Name()
+			db, mock, err := sqlmock.NewWithDSN(dsn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = db.Close() }()
+
+			// The query only returns once the delay is over
+			// or the context of the query is done.
+			mock.ExpectQuery("SELECT").
+				WillDelayFor(time.Minute).
+				WillReturnRows(sqlmock.NewRows([]string{"column"}).AddRow(int64(1)))
+
+			iterator := &sqlIterator{
+				spec: &FromSQLProcedureSpec{
+					DriverName:     "sqlmock",
+					DataSourceName: dsn,
+					Query:          "SELECT column FROM table",
+					QueryTimeout:   flux.ConvertDuration(tc.timeout),
+				},
+				read: func(ctx context.Context, rows *sql.Rows) (flux.Table, error) {
+					reader, err := NewPostgresRowReader(rows)
+					if err != nil {
+						return nil, err
+					}
+					return read(ctx, reader, &memory.ResourceAllocator{})
+				},
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tc.cancel {
+				time.AfterFunc(10*time.Millisecond, cancel)
+			}
+
+			start := time.Now()
+			err = iterator.Do(ctx, func(flux.Table) error {
+				t.Error("unexpected table from a cancelled query")
+				return nil
+			})
+			if err == nil {
+				t.Fatal("expected an error, got none")
+			}
+			if got := flux.ErrorCode(err); got != tc.wantCode {
+				t.Errorf("unexpected error code, want %v, got %v: %s", tc.wantCode, got, err)
+			}
+			if elapsed := time.Since(start); elapsed > 10*time.Second {
+				t.Errorf("the query was not cancelled promptly, it took %v", elapsed)
+			}
+		})
+	}
+}
+
+func TestMySQLQueryKiller(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("SELECT CONNECTION_ID()").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(42)))
+	mock.ExpectExec("KILL QUERY 42").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	kill, err := newMySQLQueryKiller(context.Background(), conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The query is not killed when it is done
+	// before the context is cancelled.
+	ctx, cancel := context.WithCancel(context.Background())
+	stop := killOnCancel(ctx, db, kill)
+	stop()
+	cancel()
+
+	ctx, cancel = context.WithCancel(context.Background())
+	stop = killOnCancel(ctx, db, kill)
+	cancel()
+	stop()
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
 func TestMySqlParsing(t *testing.T) {
 	// here we want to build a mocked representation of what's in our MySql db, and then run our RowReader over it, then verify that the results
 	// are as expected.
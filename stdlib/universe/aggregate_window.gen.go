@@ -23,9 +23,21 @@ func (a *aggregateWindowSumInt) Aggregate(ts *array.Int, vs array.Array, start,
 
 	values := vs.(*array.Int)
 	aggregateWindows(ts, start, stop, func(i, j int) {
-		var sum int64
+		// A window without any non-null values has a null sum.
+		var (
+			sum int64
+			ok  bool
+		)
 		for ; i < j; i++ {
+			if values.IsNull(i) {
+				continue
+			}
 			sum += values.Value(i)
+			ok = true
+		}
+		if !ok {
+			b.AppendNull()
+			return
 		}
 		b.Append(sum)
 	})
@@ -40,12 +52,20 @@ func (a *aggregateWindowSumInt) Aggregate(ts *array.Int, vs array.Array, start,
 		merged := array.NewIntBuilder(mem)
 		merged.Resize(ts.Len())
 		mergeWindowValues(ts, prev, next, func(i, j int) {
+			if i >= 0 && a.vs.IsNull(i) {
+				i = -1
+			}
+			if j >= 0 && result.IsNull(j) {
+				j = -1
+			}
 			if i >= 0 && j >= 0 {
 				merged.Append(a.vs.Value(i) + result.Value(j))
 			} else if i >= 0 {
 				merged.Append(a.vs.Value(i))
-			} else {
+			} else if j >= 0 {
 				merged.Append(result.Value(j))
+			} else {
+				merged.AppendNull()
 			}
 		})
 		a.vs.Release()
@@ -59,7 +79,7 @@ func (a *aggregateWindowSumInt) Compute(mem memory.Allocator) (*array.Int, flux.
 		b.Resize(n)
 
 		append = func(i int) {
-			if i < 0 {
+			if i < 0 || a.vs.IsNull(i) {
 				b.AppendNull()
 			} else {
 				b.Append(a.vs.Value(i))
@@ -90,11 +110,19 @@ func (a *aggregateWindowMeanInt) Aggregate(ts *array.Int, vs array.Array, start,
 
 	values := vs.(*array.Int)
 	aggregateWindows(ts, start, stop, func(i, j int) {
-		countsB.Append(int64(j - i))
-		var sum int64
+		// Null values are not part of the mean.
+		var (
+			count int64
+			sum   int64
+		)
 		for ; i < j; i++ {
+			if values.IsNull(i) {
+				continue
+			}
+			count++
 			sum += values.Value(i)
 		}
+		countsB.Append(count)
 		sumsB.Append(sum)
 	})
 
@@ -136,6 +164,10 @@ func (a *aggregateWindowMeanInt) Compute(mem memory.Allocator) (*array.Int, flux
 	b := array.NewFloatBuilder(mem)
 	b.Resize(a.ts.Len())
 	for i, n := 0, a.sums.Len(); i < n; i++ {
+		if a.counts.Value(i) == 0 {
+			b.AppendNull()
+			continue
+		}
 		v := float64(a.sums.Value(i)) / float64(a.counts.Value(i))
 		b.Append(v)
 	}
@@ -146,7 +178,7 @@ func (a *aggregateWindowMeanInt) Compute(mem memory.Allocator) (*array.Int, flux
 		b.Resize(n)
 
 		append = func(i int) {
-			if i < 0 {
+			if i < 0 || vs.IsNull(i) {
 				b.AppendNull()
 			} else {
 				b.Append(vs.Value(i))
@@ -173,9 +205,21 @@ func (a *aggregateWindowSumUint) Aggregate(ts *array.Int, vs array.Array, start,
 
 	values := vs.(*array.Uint)
 	aggregateWindows(ts, start, stop, func(i, j int) {
-		var sum uint64
+		// A window without any non-null values has a null sum.
+		var (
+			sum uint64
+			ok  bool
+		)
 		for ; i < j; i++ {
+			if values.IsNull(i) {
+				continue
+			}
 			sum += values.Value(i)
+			ok = true
+		}
+		if !ok {
+			b.AppendNull()
+			return
 		}
 		b.Append(sum)
 	})
@@ -190,12 +234,20 @@ func (a *aggregateWindowSumUint) Aggregate(ts *array.Int, vs array.Array, start,
 		merged := array.NewUintBuilder(mem)
 		merged.Resize(ts.Len())
 		mergeWindowValues(ts, prev, next, func(i, j int) {
+			if i >= 0 && a.vs.IsNull(i) {
+				i = -1
+			}
+			if j >= 0 && result.IsNull(j) {
+				j = -1
+			}
 			if i >= 0 && j >= 0 {
 				merged.Append(a.vs.Value(i) + result.Value(j))
 			} else if i >= 0 {
 				merged.Append(a.vs.Value(i))
-			} else {
+			} else if j >= 0 {
 				merged.Append(result.Value(j))
+			} else {
+				merged.AppendNull()
 			}
 		})
 		a.vs.Release()
@@ -209,7 +261,7 @@ func (a *aggregateWindowSumUint) Compute(mem memory.Allocator) (*array.Int, flux
 		b.Resize(n)
 
 		append = func(i int) {
-			if i < 0 {
+			if i < 0 || a.vs.IsNull(i) {
 				b.AppendNull()
 			} else {
 				b.Append(a.vs.Value(i))
@@ -240,11 +292,19 @@ func (a *aggregateWindowMeanUint) Aggregate(ts *array.Int, vs array.Array, start
 
 	values := vs.(*array.Uint)
 	aggregateWindows(ts, start, stop, func(i, j int) {
-		countsB.Append(int64(j - i))
-		var sum uint64
+		// Null values are not part of the mean.
+		var (
+			count int64
+			sum   uint64
+		)
 		for ; i < j; i++ {
+			if values.IsNull(i) {
+				continue
+			}
+			count++
 			sum += values.Value(i)
 		}
+		countsB.Append(count)
 		sumsB.Append(sum)
 	})
 
@@ -286,6 +346,10 @@ func (a *aggregateWindowMeanUint) Compute(mem memory.Allocator) (*array.Int, flu
 	b := array.NewFloatBuilder(mem)
 	b.Resize(a.ts.Len())
 	for i, n := 0, a.sums.Len(); i < n; i++ {
+		if a.counts.Value(i) == 0 {
+			b.AppendNull()
+			continue
+		}
 		v := float64(a.sums.Value(i)) / float64(a.counts.Value(i))
 		b.Append(v)
 	}
@@ -296,7 +360,7 @@ func (a *aggregateWindowMeanUint) Compute(mem memory.Allocator) (*array.Int, flu
 		b.Resize(n)
 
 		append = func(i int) {
-			if i < 0 {
+			if i < 0 || vs.IsNull(i) {
 				b.AppendNull()
 			} else {
 				b.Append(vs.Value(i))
@@ -323,9 +387,21 @@ func (a *aggregateWindowSumFloat) Aggregate(ts *array.Int, vs array.Array, start
 
 	values := vs.(*array.Float)
 	aggregateWindows(ts, start, stop, func(i, j int) {
-		var sum float64
+		// A window without any non-null values has a null sum.
+		var (
+			sum float64
+			ok  bool
+		)
 		for ; i < j; i++ {
+			if values.IsNull(i) {
+				continue
+			}
 			sum += values.Value(i)
+			ok = true
+		}
+		if !ok {
+			b.AppendNull()
+			return
 		}
 		b.Append(sum)
 	})
@@ -340,12 +416,20 @@ func (a *aggregateWindowSumFloat) Aggregate(ts *array.Int, vs array.Array, start
 		merged := array.NewFloatBuilder(mem)
 		merged.Resize(ts.Len())
 		mergeWindowValues(ts, prev, next, func(i, j int) {
+			if i >= 0 && a.vs.IsNull(i) {
+				i = -1
+			}
+			if j >= 0 && result.IsNull(j) {
+				j = -1
+			}
 			if i >= 0 && j >= 0 {
 				merged.Append(a.vs.Value(i) + result.Value(j))
 			} else if i >= 0 {
 				merged.Append(a.vs.Value(i))
-			} else {
+			} else if j >= 0 {
 				merged.Append(result.Value(j))
+			} else {
+				merged.AppendNull()
 			}
 		})
 		a.vs.Release()
@@ -359,7 +443,7 @@ func (a *aggregateWindowSumFloat) Compute(mem memory.Allocator) (*array.Int, flu
 		b.Resize(n)
 
 		append = func(i int) {
-			if i < 0 {
+			if i < 0 || a.vs.IsNull(i) {
 				b.AppendNull()
 			} else {
 				b.Append(a.vs.Value(i))
@@ -390,11 +474,19 @@ func (a *aggregateWindowMeanFloat) Aggregate(ts *array.Int, vs array.Array, star
 
 	values := vs.(*array.Float)
 	aggregateWindows(ts, start, stop, func(i, j int) {
-		countsB.Append(int64(j - i))
-		var sum float64
+		// Null values are not part of the mean.
+		var (
+			count int64
+			sum   float64
+		)
 		for ; i < j; i++ {
+			if values.IsNull(i) {
+				continue
+			}
+			count++
 			sum += values.Value(i)
 		}
+		countsB.Append(count)
 		sumsB.Append(sum)
 	})
 
@@ -436,6 +528,10 @@ func (a *aggregateWindowMeanFloat) Compute(mem memory.Allocator) (*array.Int, fl
 	b := array.NewFloatBuilder(mem)
 	b.Resize(a.ts.Len())
 	for i, n := 0, a.sums.Len(); i < n; i++ {
+		if a.counts.Value(i) == 0 {
+			b.AppendNull()
+			continue
+		}
 		v := float64(a.sums.Value(i)) / float64(a.counts.Value(i))
 		b.Append(v)
 	}
@@ -446,7 +542,7 @@ func (a *aggregateWindowMeanFloat) Compute(mem memory.Allocator) (*array.Int, fl
 		b.Resize(n)
 
 		append = func(i int) {
-			if i < 0 {
+			if i < 0 || vs.IsNull(i) {
 				b.AppendNull()
 			} else {
 				b.Append(vs.Value(i))
@@ -2,6 +2,7 @@ package universe
 
 import (
 	"context"
+	"fmt"
 	"math"
 	"sort"
 
@@ -39,6 +40,7 @@ type AggregateWindowProcedureSpec struct {
 	plan.DefaultCost
 	spec       *WindowProcedureSpec
 	initialize aggregateWindowInitializer
+	aggregate  plan.ProcedureKind
 	valueCol   string
 	useStart   bool
 }
@@ -78,6 +80,12 @@ type aggregateWindowTransformation struct {
 	valueCol    string
 	useStart    bool
 	initialize  aggregateWindowInitializer
+	aggregate   plan.ProcedureKind
+
+	// The state store is only used by incremental aggregates.
+	ctx     context.Context
+	store   execute.StateStore
+	stateID string
 }
 
 func createAggregateWindowTransformation(id execute.DatasetID, mode execute.AccumulationMode, spec plan.ProcedureSpec, a execute.Administration) (execute.Transformation, execute.Dataset, error) {
@@ -92,10 +100,10 @@ func createAggregateWindowTransformation(id execute.DatasetID, mode execute.Accu
 		return nil, nil, errors.New(codes.Invalid, "nil bounds passed to window; use range to set the window range").
 			WithDocURL(docURL)
 	}
-	return newAggregateWindowTransformation(id, s, bounds, a.Allocator())
+	return newAggregateWindowTransformation(a.Context(), id, s, bounds, a.Allocator())
 }
 
-func newAggregateWindowTransformation(id execute.DatasetID, s *AggregateWindowProcedureSpec, bounds *execute.Bounds, mem memory.Allocator) (execute.Transformation, execute.Dataset, error) {
+func newAggregateWindowTransformation(ctx context.Context, id execute.DatasetID, s *AggregateWindowProcedureSpec, bounds *execute.Bounds, mem memory.Allocator) (execute.Transformation, execute.Dataset, error) {
 	loc, err := s.spec.Window.LoadLocation()
 	if err != nil {
 		return nil, nil, err
@@ -119,6 +127,21 @@ func newAggregateWindowTransformation(id execute.DatasetID, s *AggregateWindowPr
 		valueCol:    s.valueCol,
 		useStart:    s.useStart,
 		initialize:  s.initialize,
+		aggregate:   s.aggregate,
+	}
+	if s.spec.Incremental {
+		store, ok := execute.GetStateStore(ctx)
+		if !ok {
+			return nil, nil, errors.New(codes.Invalid, "incremental aggregateWindow requires a state store")
+		}
+		tr.ctx = ctx
+		tr.store = store
+		tr.stateID = fmt.Sprintf("aggregateWindow/%s/%s/%v/%v/%v/%s",
+			s.aggregate, s.valueCol,
+			s.spec.Window.Every, s.spec.Window.Period, s.spec.Window.Offset,
+			s.spec.Window.Location.Name,
+		)
+		tr.initialize = newIncrementalAggregateWindow
 	}
 	return execute.NewAggregateTransformation(id, tr, mem)
 }
@@ -134,6 +157,11 @@ func (a *aggregateWindowTransformation) Aggregate(chunk table.Chunk, state inter
 
 func (a *aggregateWindowTransformation) Compute(key flux.GroupKey, state interface{}, d *execute.TransportDataset, mem memory.Allocator) error {
 	ws := state.(*aggregateWindowState)
+	if inc, ok := ws.state.(*incrementalAggregateWindow); ok {
+		if err := inc.merge(key, mem); err != nil {
+			return err
+		}
+	}
 	key = a.recomputeKey(key)
 	buffer := a.computeFromState(key, ws, mem)
 	if err := buffer.Validate(); err != nil {
@@ -759,10 +787,6 @@ func (a AggregateWindowRule) Pattern() plan.Pattern {
 }
 
 func (a AggregateWindowRule) Rewrite(ctx context.Context, node plan.Node) (plan.Node, bool, error) {
-	if !feature.OptimizeAggregateWindow().Enabled(ctx) {
-		return node, false, nil
-	}
-
 	windowInfSpec := node.ProcedureSpec().(*WindowProcedureSpec)
 	if !a.isValidWindowInfSpec(windowInfSpec) {
 		return node, false, nil
@@ -787,12 +811,19 @@ func (a AggregateWindowRule) Rewrite(ctx context.Context, node plan.Node) (plan.
 	if !a.isValidWindowSpec(windowSpec) {
 		return node, false, nil
 	}
+
+	// Incremental aggregates can only be computed by the aggregateWindow
+	// transformation so they are rewritten even without the feature flag.
+	if !windowSpec.Incremental && !feature.OptimizeAggregateWindow().Enabled(ctx) {
+		return node, false, nil
+	}
 	parentNode := windowNode.Predecessors()[0]
 
 	parentNode.ClearSuccessors()
 	newNode := plan.CreateUniquePhysicalNode(ctx, "aggregateWindow", &AggregateWindowProcedureSpec{
 		spec:       windowSpec,
 		initialize: aggregate,
+		aggregate:  aggregateNode.ProcedureSpec().Kind(),
 		valueCol:   valueCol,
 		useStart:   useStart,
 	})
@@ -880,10 +911,6 @@ func (a AggregateWindowCreateEmptyRule) Pattern() plan.Pattern {
 }
 
 func (a AggregateWindowCreateEmptyRule) Rewrite(ctx context.Context, node plan.Node) (plan.Node, bool, error) {
-	if !feature.OptimizeAggregateWindow().Enabled(ctx) {
-		return node, false, nil
-	}
-
 	windowInfSpec := node.ProcedureSpec().(*WindowProcedureSpec)
 	if !a.isValidWindowInfSpec(windowInfSpec) {
 		return node, false, nil
@@ -909,12 +936,19 @@ func (a AggregateWindowCreateEmptyRule) Rewrite(ctx context.Context, node plan.N
 	if !a.isValidWindowSpec(windowSpec) {
 		return node, false, nil
 	}
+
+	// Incremental aggregates can only be computed by the aggregateWindow
+	// transformation so they are rewritten even without the feature flag.
+	if !windowSpec.Incremental && !feature.OptimizeAggregateWindow().Enabled(ctx) {
+		return node, false, nil
+	}
 	parentNode := windowNode.Predecessors()[0]
 
 	parentNode.ClearSuccessors()
 	newNode := plan.CreateUniquePhysicalNode(ctx, "aggregateWindow", &AggregateWindowProcedureSpec{
 		spec:       windowSpec,
 		initialize: aggregate,
+		aggregate:  aggregateNode.ProcedureSpec().Kind(),
 		valueCol:   valueCol,
 		useStart:   useStart,
 	})
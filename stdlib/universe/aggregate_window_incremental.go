@@ -0,0 +1,271 @@
+package universe
+
+import (
+	"encoding/json"
+
+	"github.com/apache/arrow/go/v7/arrow/memory"
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/array"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/values"
+)
+
+// partialAggregate is the state of a decomposable aggregate for
+// a single window. It is stored between executions so that the
+// window can be completed by a later execution.
+type partialAggregate struct {
+	Type string `json:"type"`
+	// Count is the number of rows including null values
+	// and NonNull is the number of rows with a value.
+	Count   int64   `json:"count"`
+	NonNull int64   `json:"nonNull"`
+	Int     int64   `json:"int,omitempty"`
+	Uint    uint64  `json:"uint,omitempty"`
+	Float   float64 `json:"float,omitempty"`
+}
+
+func (p *partialAggregate) add(vs array.Array, i int) {
+	p.Count++
+	if vs.IsNull(i) {
+		return
+	}
+	p.NonNull++
+	switch vs := vs.(type) {
+	case *array.Int:
+		p.Int += vs.Value(i)
+	case *array.Uint:
+		p.Uint += vs.Value(i)
+	case *array.Float:
+		p.Float += vs.Value(i)
+	}
+}
+
+func (p *partialAggregate) merge(o *partialAggregate) {
+	p.Count += o.Count
+	p.NonNull += o.NonNull
+	p.Int += o.Int
+	p.Uint += o.Uint
+	p.Float += o.Float
+}
+
+// incrementalAggregateWindow computes count, sum, or mean for each window
+// and merges the result with the partial aggregates from the state store.
+// Unlike the other aggregateWindow implementations, it tracks the complete
+// window boundaries rather than the boundaries truncated by the range so
+// a window that spans two executions is stored under the same key.
+type incrementalAggregateWindow struct {
+	a       *aggregateWindowTransformation
+	inType  flux.ColType
+	windows map[execute.Bounds]*partialAggregate
+
+	ts *array.Int
+	vt flux.ColType
+	vs array.Array
+}
+
+func newIncrementalAggregateWindow(a *aggregateWindowTransformation, valueType flux.ColType) (aggregateWindow, error) {
+	if a.aggregate != CountKind {
+		switch valueType {
+		case flux.TInt, flux.TUInt, flux.TFloat:
+		default:
+			return nil, errors.Newf(codes.FailedPrecondition, "unsupported aggregate column type %v", valueType)
+		}
+	}
+	return &incrementalAggregateWindow{
+		a:       a,
+		inType:  valueType,
+		windows: make(map[execute.Bounds]*partialAggregate),
+	}, nil
+}
+
+func (a *incrementalAggregateWindow) Aggregate(ts *array.Int, vs array.Array, start, stop *array.Int, mem memory.Allocator) {
+	for i, n := 0, ts.Len(); i < n; i++ {
+		t := values.Time(ts.Value(i))
+		if !a.a.bounds.Contains(t) {
+			continue
+		}
+
+		bound := a.a.w.GetLatestBounds(t)
+		for ; bound.Contains(t); bound = a.a.w.PrevBounds(bound) {
+			b := execute.Bounds{
+				Start: bound.Start(),
+				Stop:  bound.Stop(),
+			}
+			p, ok := a.windows[b]
+			if !ok {
+				p = &partialAggregate{Type: a.inType.String()}
+				a.windows[b] = p
+			}
+			p.add(vs, i)
+		}
+	}
+}
+
+// merge loads the stored state for every window that overlaps the range,
+// merges it with the values read by this execution, and stores the result.
+// The merged values are returned by Compute.
+func (a *incrementalAggregateWindow) merge(key flux.GroupKey, mem memory.Allocator) error {
+	// The start and stop columns hold the range of the execution
+	// so they are not part of the key for the stored state.
+	cols := make([]flux.ColMeta, 0, len(key.Cols()))
+	vals := make([]values.Value, 0, len(key.Cols()))
+	for j, c := range key.Cols() {
+		if c.Label == execute.DefaultStartColLabel || c.Label == execute.DefaultStopColLabel {
+			continue
+		}
+		cols = append(cols, c)
+		vals = append(vals, key.Value(j))
+	}
+	stateKey := execute.StateKey{
+		ID:       a.a.stateID,
+		GroupKey: execute.NewGroupKey(cols, vals),
+	}
+
+	var (
+		times  []int64
+		merged []*partialAggregate
+	)
+
+	bound := a.a.w.GetLatestBounds(a.a.bounds.Start)
+	for ; bound.Stop() > a.a.bounds.Start; bound = a.a.w.PrevBounds(bound) {
+		// Do nothing.
+	}
+	bound = a.a.w.NextBounds(bound)
+	for ; bound.Start() < a.a.bounds.Stop; bound = a.a.w.NextBounds(bound) {
+		stateKey.Bounds = execute.Bounds{
+			Start: bound.Start(),
+			Stop:  bound.Stop(),
+		}
+		p := a.windows[stateKey.Bounds]
+
+		state, ok, err := a.a.store.Get(a.a.ctx, stateKey)
+		if err != nil {
+			return err
+		} else if ok {
+			var prev partialAggregate
+			if err := json.Unmarshal(state, &prev); err != nil {
+				return errors.Wrap(err, codes.Internal, "invalid aggregateWindow state")
+			}
+			if prev.Type != a.inType.String() {
+				return errors.Newf(codes.FailedPrecondition, "schema collision detected: column %q is both of type %s and %s", a.a.valueCol, prev.Type, a.inType)
+			}
+			if p != nil {
+				prev.merge(p)
+				if err := a.put(stateKey, &prev); err != nil {
+					return err
+				}
+			}
+			p = &prev
+		} else if p != nil {
+			if err := a.put(stateKey, p); err != nil {
+				return err
+			}
+		}
+
+		if p == nil && !a.a.createEmpty {
+			continue
+		}
+
+		b := a.a.bounds.Intersect(stateKey.Bounds)
+		tv := int64(b.Stop)
+		if a.a.useStart {
+			tv = int64(b.Start)
+		}
+		times = append(times, tv)
+		merged = append(merged, p)
+	}
+
+	tb := array.NewIntBuilder(mem)
+	tb.Resize(len(times))
+	for _, tv := range times {
+		tb.Append(tv)
+	}
+	a.ts = tb.NewIntArray()
+	a.vt, a.vs = a.values(merged, mem)
+	return nil
+}
+
+func (a *incrementalAggregateWindow) put(key execute.StateKey, p *partialAggregate) error {
+	state, err := json.Marshal(p)
+	if err != nil {
+		return errors.Wrap(err, codes.Internal, "invalid aggregateWindow state")
+	}
+	return a.a.store.Put(a.a.ctx, key, state)
+}
+
+// values computes the final aggregate for each window.
+// A nil entry is a window without any values.
+func (a *incrementalAggregateWindow) values(merged []*partialAggregate, mem memory.Allocator) (flux.ColType, array.Array) {
+	switch {
+	case a.a.aggregate == CountKind:
+		b := array.NewIntBuilder(mem)
+		b.Resize(len(merged))
+		for _, p := range merged {
+			if p == nil {
+				b.Append(0)
+				continue
+			}
+			b.Append(p.Count)
+		}
+		return flux.TInt, b.NewIntArray()
+	case a.a.aggregate == MeanKind:
+		b := array.NewFloatBuilder(mem)
+		b.Resize(len(merged))
+		for _, p := range merged {
+			if p == nil || p.NonNull == 0 {
+				b.AppendNull()
+				continue
+			}
+			var sum float64
+			switch a.inType {
+			case flux.TInt:
+				sum = float64(p.Int)
+			case flux.TUInt:
+				sum = float64(p.Uint)
+			default:
+				sum = p.Float
+			}
+			b.Append(sum / float64(p.NonNull))
+		}
+		return flux.TFloat, b.NewFloatArray()
+	case a.inType == flux.TInt:
+		b := array.NewIntBuilder(mem)
+		b.Resize(len(merged))
+		for _, p := range merged {
+			if p == nil || p.NonNull == 0 {
+				b.AppendNull()
+				continue
+			}
+			b.Append(p.Int)
+		}
+		return flux.TInt, b.NewIntArray()
+	case a.inType == flux.TUInt:
+		b := array.NewUintBuilder(mem)
+		b.Resize(len(merged))
+		for _, p := range merged {
+			if p == nil || p.NonNull == 0 {
+				b.AppendNull()
+				continue
+			}
+			b.Append(p.Uint)
+		}
+		return flux.TUInt, b.NewUintArray()
+	default:
+		b := array.NewFloatBuilder(mem)
+		b.Resize(len(merged))
+		for _, p := range merged {
+			if p == nil || p.NonNull == 0 {
+				b.AppendNull()
+				continue
+			}
+			b.Append(p.Float)
+		}
+		return flux.TFloat, b.NewFloatArray()
+	}
+}
+
+func (a *incrementalAggregateWindow) Compute(mem memory.Allocator) (*array.Int, flux.ColType, array.Array) {
+	return a.ts, a.vt, a.vs
+}
@@ -0,0 +1,37 @@
+package universe
+
+import (
+	"context"
+
+	"github.com/apache/arrow/go/v7/arrow/memory"
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/plan"
+)
+
+// NewAggregateWindowTransformation is exposed so the tests can create
+// the aggregateWindow transformation without going through the planner.
+func NewAggregateWindowTransformation(ctx context.Context, id execute.DatasetID, aggregate plan.ProcedureKind, every flux.Duration, incremental bool, bounds *execute.Bounds, mem memory.Allocator) (execute.Transformation, execute.Dataset, error) {
+	initialize := map[plan.ProcedureKind]aggregateWindowInitializer{
+		CountKind: newAggregateWindowCount,
+		SumKind:   newAggregateWindowSum,
+		MeanKind:  newAggregateWindowMean,
+	}[aggregate]
+	spec := &AggregateWindowProcedureSpec{
+		spec: &WindowProcedureSpec{
+			Window: plan.WindowSpec{
+				Every:  every,
+				Period: every,
+			},
+			TimeColumn:  execute.DefaultTimeColLabel,
+			StartColumn: execute.DefaultStartColLabel,
+			StopColumn:  execute.DefaultStopColLabel,
+			CreateEmpty: true,
+			Incremental: incremental,
+		},
+		initialize: initialize,
+		aggregate:  aggregate,
+		valueCol:   execute.DefaultValueColLabel,
+	}
+	return newAggregateWindowTransformation(ctx, id, spec, bounds, mem)
+}
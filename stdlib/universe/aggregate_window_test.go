@@ -0,0 +1,204 @@
+package universe_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/execute/executetest"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/plan"
+	"github.com/influxdata/flux/stdlib/universe"
+)
+
+func TestAggregateWindow_Incremental(t *testing.T) {
+	// One row per second with the number of seconds as the value.
+	// With nulls, the odd seconds and every second in the window
+	// [20s, 30s) have a null value.
+	data := func(start, stop int, nulls bool) []flux.Table {
+		tbl := &executetest.Table{
+			KeyCols: []string{"_start", "_stop", "t0"},
+			ColMeta: []flux.ColMeta{
+				{Label: "_start", Type: flux.TTime},
+				{Label: "_stop", Type: flux.TTime},
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_value", Type: flux.TInt},
+				{Label: "t0", Type: flux.TString},
+			},
+		}
+		for i := start; i < stop; i++ {
+			var v interface{} = int64(i)
+			if nulls && (i%2 == 1 || i >= 20) {
+				v = nil
+			}
+			tbl.Data = append(tbl.Data, []interface{}{
+				execute.Time(start) * execute.Time(time.Second),
+				execute.Time(stop) * execute.Time(time.Second),
+				execute.Time(i) * execute.Time(time.Second),
+				v,
+				"a",
+			})
+		}
+		return []flux.Table{tbl}
+	}
+	result := func(start, stop int, typ flux.ColType, rows ...[]interface{}) []*executetest.Table {
+		tbl := &executetest.Table{
+			KeyCols: []string{"_start", "_stop", "t0"},
+			ColMeta: []flux.ColMeta{
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_start", Type: flux.TTime},
+				{Label: "_stop", Type: flux.TTime},
+				{Label: "t0", Type: flux.TString},
+				{Label: "_value", Type: typ},
+			},
+		}
+		for _, row := range rows {
+			tbl.Data = append(tbl.Data, []interface{}{
+				execute.Time(row[0].(int)) * execute.Time(time.Second),
+				execute.Time(start) * execute.Time(time.Second),
+				execute.Time(stop) * execute.Time(time.Second),
+				"a",
+				row[1],
+			})
+		}
+		return []*executetest.Table{tbl}
+	}
+
+	testCases := []struct {
+		aggregate plan.ProcedureKind
+		nulls     bool
+		typ       flux.ColType
+		// The values of the windows [0s, 10s), [10s, 20s), and [20s, 30s)
+		// and the partial value of [10s, 15s).
+		windows []interface{}
+		partial interface{}
+	}{
+		{
+			aggregate: universe.CountKind,
+			typ:       flux.TInt,
+			windows:   []interface{}{int64(10), int64(10), int64(10)},
+			partial:   int64(5),
+		},
+		{
+			aggregate: universe.SumKind,
+			typ:       flux.TInt,
+			windows:   []interface{}{int64(45), int64(145), int64(245)},
+			partial:   int64(60),
+		},
+		{
+			aggregate: universe.MeanKind,
+			typ:       flux.TFloat,
+			windows:   []interface{}{4.5, 14.5, 24.5},
+			partial:   12.0,
+		},
+		{
+			aggregate: universe.CountKind,
+			nulls:     true,
+			typ:       flux.TInt,
+			windows:   []interface{}{int64(10), int64(10), int64(10)},
+			partial:   int64(5),
+		},
+		{
+			aggregate: universe.SumKind,
+			nulls:     true,
+			typ:       flux.TInt,
+			windows:   []interface{}{int64(20), int64(70), nil},
+			partial:   int64(36),
+		},
+		{
+			aggregate: universe.MeanKind,
+			nulls:     true,
+			typ:       flux.TFloat,
+			windows:   []interface{}{4.0, 14.0, nil},
+			partial:   12.0,
+		},
+	}
+	stores := []struct {
+		name string
+		new  func(t *testing.T) execute.StateStore
+	}{
+		{
+			name: "memory",
+			new: func(t *testing.T) execute.StateStore {
+				return execute.NewMemoryStateStore()
+			},
+		},
+		{
+			name: "file",
+			new: func(t *testing.T) execute.StateStore {
+				return execute.NewFileStateStore(t.TempDir())
+			},
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		for _, store := range stores {
+			store := store
+			name := string(tc.aggregate) + "/" + store.name
+			if tc.nulls {
+				name += "/nulls"
+			}
+			t.Run(name, func(t *testing.T) {
+				ss := store.new(t)
+				process := func(start, stop int, incremental bool, want []*executetest.Table) {
+					t.Helper()
+					executetest.ProcessTestHelper2(
+						t,
+						data(start, stop, tc.nulls),
+						want,
+						nil,
+						func(id execute.DatasetID, alloc memory.Allocator) (execute.Transformation, execute.Dataset) {
+							ctx := execute.StateStoreDependency{StateStore: ss}.Inject(context.Background())
+							bounds := &execute.Bounds{
+								Start: execute.Time(start) * execute.Time(time.Second),
+								Stop:  execute.Time(stop) * execute.Time(time.Second),
+							}
+							tr, d, err := universe.NewAggregateWindowTransformation(ctx, id, tc.aggregate, flux.ConvertDuration(10*time.Second), incremental, bounds, alloc)
+							if err != nil {
+								t.Fatal(err)
+							}
+							return tr, d
+						},
+					)
+				}
+
+				// A full recomputation of the entire range.
+				process(0, 30, false, result(0, 30, tc.typ,
+					[]interface{}{10, tc.windows[0]},
+					[]interface{}{20, tc.windows[1]},
+					[]interface{}{30, tc.windows[2]},
+				))
+
+				// The first run only sees part of the second window.
+				process(0, 15, true, result(0, 15, tc.typ,
+					[]interface{}{10, tc.windows[0]},
+					[]interface{}{15, tc.partial},
+				))
+
+				// The second run completes the second window by merging
+				// the stored state and produces the same values as the
+				// full recomputation.
+				process(15, 30, true, result(15, 30, tc.typ,
+					[]interface{}{20, tc.windows[1]},
+					[]interface{}{30, tc.windows[2]},
+				))
+			})
+		}
+	}
+}
+
+func TestAggregateWindow_IncrementalRequiresStateStore(t *testing.T) {
+	bounds := &execute.Bounds{
+		Start: execute.Time(0),
+		Stop:  execute.Time(30 * time.Second),
+	}
+	_, _, err := universe.NewAggregateWindowTransformation(context.Background(), executetest.RandomDatasetID(), universe.SumKind, flux.ConvertDuration(10*time.Second), true, bounds, &memory.ResourceAllocator{})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if want, got := "incremental aggregateWindow requires a state store", err.Error(); got != want {
+		t.Fatalf("unexpected error -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+}
@@ -0,0 +1,196 @@
+package universe
+
+import (
+	"context"
+
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/interpreter"
+	"github.com/influxdata/flux/plan"
+	"github.com/influxdata/flux/semantic"
+	"github.com/influxdata/flux/values"
+)
+
+func init() {
+	plan.RegisterLogicalRules(ConstantFoldingRule{})
+}
+
+// ConstantFoldingRule evaluates the sub-expressions of the function
+// of a filter, map or reduce whose operands are all literals and
+// replaces them with the literal they evaluate to, so that
+// filter(fn: (r) => r._value > 1 + 2) compares each row with 3.
+//
+// Only unary, binary and logical expressions are folded. Calls are
+// never folded since their result may depend on when they are called.
+// An expression that fails to evaluate, such as a division by zero,
+// is left as it is so the query reports the error when it runs.
+type ConstantFoldingRule struct{}
+
+func (ConstantFoldingRule) Name() string {
+	return "ConstantFoldingRule"
+}
+
+func (ConstantFoldingRule) Pattern() plan.Pattern {
+	return plan.OneOf([]plan.ProcedureKind{FilterKind, MapKind, ReduceKind}, plan.Any())
+}
+
+func (ConstantFoldingRule) Rewrite(ctx context.Context, n plan.Node) (plan.Node, bool, error) {
+	spec := n.ProcedureSpec().Copy()
+	var fn *interpreter.ResolvedFunction
+	switch spec := spec.(type) {
+	case *FilterProcedureSpec:
+		fn = &spec.Fn
+	case *MapProcedureSpec:
+		fn = &spec.Fn
+	case *ReduceProcedureSpec:
+		fn = &spec.Fn
+	default:
+		return nil, false, errors.Newf(codes.Internal, "invalid spec type %T for constant folding", spec)
+	}
+	if fn.Fn == nil || fn.Fn.Block == nil {
+		return n, false, nil
+	}
+
+	f := constantFolder{
+		ctx:  ctx,
+		itrp: interpreter.NewInterpreter(nil, nil),
+	}
+	f.foldBlock(fn.Fn.Block)
+	if !f.changed {
+		return n, false, nil
+	}
+	if err := n.ReplaceSpec(spec); err != nil {
+		return nil, false, err
+	}
+	return n, true, nil
+}
+
+// constantFolder replaces the constant sub-expressions
+// of a function body with literals.
+type constantFolder struct {
+	ctx     context.Context
+	itrp    *interpreter.Interpreter
+	changed bool
+}
+
+func (f *constantFolder) foldBlock(b *semantic.Block) {
+	for _, stmt := range b.Body {
+		switch s := stmt.(type) {
+		case *semantic.ReturnStatement:
+			s.Argument, _ = f.fold(s.Argument)
+		case *semantic.ExpressionStatement:
+			s.Expression, _ = f.fold(s.Expression)
+		case *semantic.NativeVariableAssignment:
+			s.Init, _ = f.fold(s.Init)
+		}
+	}
+}
+
+// fold folds the sub-expressions of e and returns the expression
+// that replaces it and whether that expression is a literal.
+func (f *constantFolder) fold(e semantic.Expression) (semantic.Expression, bool) {
+	switch e := e.(type) {
+	case semantic.Literal:
+		return e, true
+	case *semantic.UnaryExpression:
+		var ok bool
+		if e.Argument, ok = f.fold(e.Argument); ok {
+			return f.eval(e)
+		}
+	case *semantic.BinaryExpression:
+		var lok, rok bool
+		e.Left, lok = f.fold(e.Left)
+		e.Right, rok = f.fold(e.Right)
+		if lok && rok {
+			return f.eval(e)
+		}
+	case *semantic.LogicalExpression:
+		var lok, rok bool
+		e.Left, lok = f.fold(e.Left)
+		e.Right, rok = f.fold(e.Right)
+		if lok && rok {
+			return f.eval(e)
+		}
+	case *semantic.ConditionalExpression:
+		e.Test, _ = f.fold(e.Test)
+		e.Consequent, _ = f.fold(e.Consequent)
+		e.Alternate, _ = f.fold(e.Alternate)
+	case *semantic.CallExpression:
+		f.foldObject(e.Arguments)
+	case *semantic.ObjectExpression:
+		f.foldObject(e)
+	case *semantic.ArrayExpression:
+		for i := range e.Elements {
+			e.Elements[i], _ = f.fold(e.Elements[i])
+		}
+	case *semantic.IndexExpression:
+		e.Array, _ = f.fold(e.Array)
+		e.Index, _ = f.fold(e.Index)
+	case *semantic.MemberExpression:
+		e.Object, _ = f.fold(e.Object)
+	case *semantic.StringExpression:
+		for _, part := range e.Parts {
+			if p, ok := part.(*semantic.InterpolatedPart); ok {
+				p.Expression, _ = f.fold(p.Expression)
+			}
+		}
+	case *semantic.FunctionExpression:
+		if e.Block != nil {
+			f.foldBlock(e.Block)
+		}
+	}
+	return e, false
+}
+
+func (f *constantFolder) foldObject(o *semantic.ObjectExpression) {
+	if o == nil {
+		return
+	}
+	for _, p := range o.Properties {
+		p.Value, _ = f.fold(p.Value)
+	}
+}
+
+// eval evaluates an expression whose operands are literals
+// and returns the literal for its value. The expression is
+// returned as it is when it cannot be represented by a literal.
+func (f *constantFolder) eval(e semantic.Expression) (semantic.Expression, bool) {
+	pkg := &semantic.Package{
+		Package: interpreter.PackageMain,
+		Files: []*semantic.File{{
+			Body: []semantic.Statement{
+				&semantic.ExpressionStatement{Expression: e},
+			},
+		}},
+	}
+	sideEffects, err := f.itrp.Eval(f.ctx, pkg, values.NewScope(), nil)
+	if err != nil || len(sideEffects) != 1 {
+		return e, false
+	}
+
+	v := sideEffects[0].Value
+	if v.IsNull() {
+		return e, false
+	}
+	var lit semantic.Literal
+	switch v.Type().Nature() {
+	case semantic.Int:
+		lit = &semantic.IntegerLiteral{Value: v.Int()}
+	case semantic.UInt:
+		lit = &semantic.UnsignedIntegerLiteral{Value: v.UInt()}
+	case semantic.Float:
+		lit = &semantic.FloatLiteral{Value: v.Float()}
+	case semantic.String:
+		lit = &semantic.StringLiteral{Value: v.Str()}
+	case semantic.Bool:
+		lit = &semantic.BooleanLiteral{Value: v.Bool()}
+	case semantic.Duration:
+		lit = &semantic.DurationLiteral{Values: v.Duration().AsValues()}
+	case semantic.Time:
+		lit = &semantic.DateTimeLiteral{Value: v.Time().Time()}
+	default:
+		return e, false
+	}
+	f.changed = true
+	return lit, true
+}
@@ -0,0 +1,138 @@
+package universe_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/execute/executetest"
+	"github.com/influxdata/flux/interpreter"
+	"github.com/influxdata/flux/plan"
+	"github.com/influxdata/flux/plan/plantest"
+	"github.com/influxdata/flux/semantic"
+	"github.com/influxdata/flux/stdlib/influxdata/influxdb"
+	"github.com/influxdata/flux/stdlib/universe"
+)
+
+func TestConstantFoldingRule(t *testing.T) {
+	var (
+		from   = &influxdb.FromProcedureSpec{}
+		filter = func(fn string) *universe.FilterProcedureSpec {
+			return &universe.FilterProcedureSpec{
+				Fn: interpreter.ResolvedFunction{
+					Fn: executetest.FunctionExpression(t, fn),
+				},
+			}
+		}
+		mapFn = func(fn string) *universe.MapProcedureSpec {
+			return &universe.MapProcedureSpec{
+				Fn: interpreter.ResolvedFunction{
+					Fn: executetest.FunctionExpression(t, fn),
+				},
+			}
+		}
+		planSpec = func(spec plan.ProcedureSpec) *plantest.PlanSpec {
+			return &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreateLogicalNode("from", from),
+					plan.CreateLogicalNode("fn", spec),
+				},
+				Edges: [][2]int{{0, 1}},
+			}
+		}
+	)
+
+	tests := []plantest.RuleTestCase{
+		{
+			Name:   "integer arithmetic",
+			Rules:  []plan.Rule{universe.ConstantFoldingRule{}},
+			Before: planSpec(filter(`(r) => r._value > 1 + 2`)),
+			After:  planSpec(filter(`(r) => r._value > 3`)),
+		},
+		{
+			Name:   "string concatenation",
+			Rules:  []plan.Rule{universe.ConstantFoldingRule{}},
+			Before: planSpec(mapFn(`(r) => ({r with s: "foo" + "bar"})`)),
+			After:  planSpec(mapFn(`(r) => ({r with s: "foobar"})`)),
+		},
+		{
+			Name:   "boolean constants",
+			Rules:  []plan.Rule{universe.ConstantFoldingRule{}},
+			Before: planSpec(filter(`(r) => r._value > 0 and (true and not false)`)),
+			After:  planSpec(filter(`(r) => r._value > 0 and true`)),
+		},
+		{
+			Name:   "duration arithmetic",
+			Rules:  []plan.Rule{universe.ConstantFoldingRule{}},
+			Before: planSpec(mapFn(`(r) => ({r with d: 1h + 1h})`)),
+			After:  planSpec(mapFn(`(r) => ({r with d: 2h})`)),
+		},
+		{
+			Name:     "idempotent",
+			Rules:    []plan.Rule{universe.ConstantFoldingRule{}},
+			Before:   planSpec(filter(`(r) => r._value > 3`)),
+			NoChange: true,
+		},
+		{
+			Name:     "division by zero",
+			Rules:    []plan.Rule{universe.ConstantFoldingRule{}},
+			Before:   planSpec(filter(`(r) => r._value > 1 / 0`)),
+			NoChange: true,
+		},
+		{
+			Name:     "column reference",
+			Rules:    []plan.Rule{universe.ConstantFoldingRule{}},
+			Before:   planSpec(filter(`(r) => r._value + 1 > 2`)),
+			NoChange: true,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			plantest.LogicalRuleTestHelper(t, &tc)
+		})
+	}
+}
+
+func TestConstantFoldingRule_CopiesSpec(t *testing.T) {
+	// (r) => 1 + 2
+	sum := &semantic.BinaryExpression{
+		Operator: ast.AdditionOperator,
+		Left:     &semantic.IntegerLiteral{Value: 1},
+		Right:    &semantic.IntegerLiteral{Value: 2},
+	}
+	spec := &universe.FilterProcedureSpec{
+		Fn: interpreter.ResolvedFunction{
+			Fn: &semantic.FunctionExpression{
+				Block: &semantic.Block{
+					Body: []semantic.Statement{
+						&semantic.ReturnStatement{Argument: sum},
+					},
+				},
+			},
+		},
+	}
+	node := plan.CreateLogicalNode("filter", spec)
+
+	got, changed, err := universe.ConstantFoldingRule{}.Rewrite(context.Background(), node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed {
+		t.Fatal("expected the rule to change the node")
+	}
+
+	// The spec that was planned must be left as it was.
+	if ret := spec.Fn.Fn.Block.Body[0].(*semantic.ReturnStatement); ret.Argument != sum {
+		t.Errorf("the original spec was modified: %v", ret.Argument)
+	}
+	if got.ProcedureSpec() == spec {
+		t.Fatal("expected the node to have a new spec")
+	}
+	folded := got.ProcedureSpec().(*universe.FilterProcedureSpec)
+	ret := folded.Fn.Fn.Block.Body[0].(*semantic.ReturnStatement)
+	if lit, ok := ret.Argument.(*semantic.IntegerLiteral); !ok || lit.Value != 3 {
+		t.Errorf("unexpected folded expression: %v", ret.Argument)
+	}
+}
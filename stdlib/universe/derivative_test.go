@@ -3,6 +3,7 @@ package universe_test
 import (
 	"context"
 	"fmt"
+	"math"
 	"testing"
 	"time"
 
@@ -647,6 +648,69 @@ func TestDerivative_Process(t *testing.T) {
 				},
 			}},
 		},
+		{
+			name: "uint above max int",
+			spec: &universe.DerivativeProcedureSpec{
+				Columns:    []string{execute.DefaultValueColLabel},
+				TimeColumn: execute.DefaultTimeColLabel,
+				Unit:       flux.ConvertDuration(1),
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TUInt},
+				},
+				Data: [][]interface{}{
+					{execute.Time(1), uint64(0)},
+					{execute.Time(2), uint64(math.MaxUint64)},
+					{execute.Time(3), uint64(math.MaxUint64 - 10)},
+					{execute.Time(4), uint64(0)},
+				},
+			}},
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{execute.Time(2), float64(math.MaxUint64)},
+					{execute.Time(3), -10.0},
+					{execute.Time(4), -float64(math.MaxUint64 - 10)},
+				},
+			}},
+		},
+		{
+			name: "uint non negative with nulls",
+			spec: &universe.DerivativeProcedureSpec{
+				Columns:     []string{execute.DefaultValueColLabel},
+				TimeColumn:  execute.DefaultTimeColLabel,
+				Unit:        flux.ConvertDuration(1),
+				NonNegative: true,
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TUInt},
+				},
+				Data: [][]interface{}{
+					{execute.Time(1), uint64(math.MaxUint64)},
+					{execute.Time(2), nil},
+					{execute.Time(3), uint64(0)},
+					{execute.Time(4), uint64(6)},
+				},
+			}},
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{execute.Time(2), nil},
+					{execute.Time(3), nil},
+					{execute.Time(4), 6.0},
+				},
+			}},
+		},
 		{
 			name: "uint with tags",
 			spec: &universe.DerivativeProcedureSpec{
@@ -0,0 +1,344 @@
+package universe
+
+import (
+	"math"
+	"sort"
+
+	"github.com/apache/arrow/go/v7/arrow/memory"
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/array"
+	"github.com/influxdata/flux/arrow"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/execute/table"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/internal/hll"
+	"github.com/influxdata/flux/interpreter"
+	"github.com/influxdata/flux/plan"
+	"github.com/influxdata/flux/runtime"
+	"github.com/influxdata/flux/semantic"
+)
+
+const DescribeKind = "describe"
+
+// The columns of the summary produced by describe.
+const (
+	describeColumnLabel    = "_column"
+	describeCountLabel     = "count"
+	describeNullCountLabel = "nullCount"
+	describeMinLabel       = "min"
+	describeMaxLabel       = "max"
+	describeMeanLabel      = "mean"
+	describeStddevLabel    = "stddev"
+	describeDistinctLabel  = "distinct"
+	describeTopValueLabel  = "topValue"
+)
+
+// DescribeOpSpec summarizes the values of columns in each table.
+type DescribeOpSpec struct {
+	Columns []string `json:"columns"`
+}
+
+func init() {
+	describeSignature := runtime.MustLookupBuiltinType("universe", DescribeKind)
+
+	runtime.RegisterPackageValue("universe", DescribeKind, flux.MustValue(flux.FunctionValue(DescribeKind, createDescribeOpSpec, describeSignature)))
+	flux.RegisterOpSpec(DescribeKind, newDescribeOp)
+	plan.RegisterProcedureSpec(DescribeKind, newDescribeProcedure, DescribeKind)
+	execute.RegisterTransformation(DescribeKind, createDescribeTransformation)
+}
+
+func createDescribeOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
+	if err := a.AddParentFromArgs(args); err != nil {
+		return nil, err
+	}
+
+	spec := new(DescribeOpSpec)
+	if cols, ok, err := args.GetArray("columns", semantic.String); err != nil {
+		return nil, err
+	} else if ok {
+		columns, err := interpreter.ToStringArray(cols)
+		if err != nil {
+			return nil, err
+		}
+		spec.Columns = columns
+	} else {
+		spec.Columns = []string{execute.DefaultValueColLabel}
+	}
+	return spec, nil
+}
+
+func newDescribeOp() flux.OperationSpec {
+	return new(DescribeOpSpec)
+}
+
+func (s *DescribeOpSpec) Kind() flux.OperationKind {
+	return DescribeKind
+}
+
+type DescribeProcedureSpec struct {
+	plan.DefaultCost
+	Columns []string `json:"columns"`
+}
+
+func newDescribeProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.ProcedureSpec, error) {
+	spec, ok := qs.(*DescribeOpSpec)
+	if !ok {
+		return nil, errors.Newf(codes.Internal, "invalid spec type %T", qs)
+	}
+	return &DescribeProcedureSpec{
+		Columns: spec.Columns,
+	}, nil
+}
+
+func (s *DescribeProcedureSpec) Kind() plan.ProcedureKind {
+	return DescribeKind
+}
+func (s *DescribeProcedureSpec) Copy() plan.ProcedureSpec {
+	ns := new(DescribeProcedureSpec)
+	*ns = *s
+	ns.Columns = make([]string, len(s.Columns))
+	copy(ns.Columns, s.Columns)
+	return ns
+}
+
+func createDescribeTransformation(id execute.DatasetID, mode execute.AccumulationMode, spec plan.ProcedureSpec, a execute.Administration) (execute.Transformation, execute.Dataset, error) {
+	s, ok := spec.(*DescribeProcedureSpec)
+	if !ok {
+		return nil, nil, errors.Newf(codes.Internal, "invalid spec type %T", spec)
+	}
+	return NewDescribeTransformation(id, s, a.Allocator())
+}
+
+type describeTransformation struct {
+	columns []string
+}
+
+// NewDescribeTransformation creates a transformation that outputs
+// one row per selected column of each table with a summary of
+// the values in that column.
+func NewDescribeTransformation(id execute.DatasetID, spec *DescribeProcedureSpec, mem memory.Allocator) (execute.Transformation, execute.Dataset, error) {
+	t := &describeTransformation{
+		columns: spec.Columns,
+	}
+	return execute.NewAggregateTransformation(id, t, mem)
+}
+
+// describeState holds the summary of the selected columns
+// in the order they were selected.
+type describeState struct {
+	columns []*describeColumn
+}
+
+// describeColumn accumulates the summary of one column.
+// Numeric columns use a sample stddev aggregate for the mean
+// and standard deviation. String columns count the occurrences
+// of each value to find the most frequent one.
+type describeColumn struct {
+	label     string
+	typ       flux.ColType
+	count     int64
+	nullCount int64
+	min, max  float64
+	stddev    *StddevAgg
+	distinct  *hll.Sketch
+	top       map[string]int64
+}
+
+func (t *describeTransformation) Aggregate(chunk table.Chunk, state interface{}, mem memory.Allocator) (interface{}, bool, error) {
+	var s *describeState
+	if state != nil {
+		s = state.(*describeState)
+	} else {
+		s = &describeState{
+			columns: make([]*describeColumn, len(t.columns)),
+		}
+	}
+
+	for i, label := range t.columns {
+		j := chunk.Index(label)
+		if j < 0 {
+			return nil, false, errors.Newf(codes.FailedPrecondition, "column %q does not exist", label)
+		}
+		typ := chunk.Col(j).Type
+
+		c := s.columns[i]
+		if c == nil {
+			var err error
+			if c, err = newDescribeColumn(label, typ); err != nil {
+				return nil, false, err
+			}
+			s.columns[i] = c
+		} else if c.typ != typ {
+			return nil, false, errors.Newf(codes.FailedPrecondition, "schema collision detected: column %q is both of type %s and %s", label, c.typ, typ)
+		}
+		c.add(chunk.Values(j))
+	}
+	return s, true, nil
+}
+
+func newDescribeColumn(label string, typ flux.ColType) (*describeColumn, error) {
+	c := &describeColumn{
+		label:    label,
+		typ:      typ,
+		min:      math.Inf(1),
+		max:      math.Inf(-1),
+		distinct: hll.New(),
+	}
+	switch typ {
+	case flux.TInt, flux.TUInt, flux.TFloat:
+		c.stddev = &StddevAgg{Mode: modeSample}
+	case flux.TString:
+		c.top = make(map[string]int64)
+	default:
+		return nil, errors.Newf(codes.FailedPrecondition, "cannot describe column %q of type %s", label, typ)
+	}
+	return c, nil
+}
+
+func (c *describeColumn) add(arr array.Array) {
+	c.nullCount += int64(arr.NullN())
+	c.count += int64(arr.Len() - arr.NullN())
+
+	switch vs := arr.(type) {
+	case *array.Int:
+		c.stddev.DoInt(vs)
+		for i, n := 0, vs.Len(); i < n; i++ {
+			if vs.IsValid(i) {
+				c.observe(float64(vs.Value(i)))
+				c.distinct.AddUint64(uint64(vs.Value(i)))
+			}
+		}
+	case *array.Uint:
+		c.stddev.DoUInt(vs)
+		for i, n := 0, vs.Len(); i < n; i++ {
+			if vs.IsValid(i) {
+				c.observe(float64(vs.Value(i)))
+				c.distinct.AddUint64(vs.Value(i))
+			}
+		}
+	case *array.Float:
+		c.stddev.DoFloat(vs)
+		for i, n := 0, vs.Len(); i < n; i++ {
+			if vs.IsValid(i) {
+				c.observe(vs.Value(i))
+				c.distinct.AddUint64(math.Float64bits(vs.Value(i)))
+			}
+		}
+	case *array.String:
+		for i, n := 0, vs.Len(); i < n; i++ {
+			if vs.IsValid(i) {
+				v := vs.Value(i)
+				c.top[v]++
+				c.distinct.AddString(v)
+			}
+		}
+	}
+}
+
+func (c *describeColumn) observe(v float64) {
+	if v < c.min {
+		c.min = v
+	}
+	if v > c.max {
+		c.max = v
+	}
+}
+
+// topValue returns the most frequent value of a string column.
+// Ties are broken by choosing the smallest value.
+func (c *describeColumn) topValue() (string, bool) {
+	values := make([]string, 0, len(c.top))
+	for v := range c.top {
+		values = append(values, v)
+	}
+	sort.Strings(values)
+
+	var (
+		top   string
+		count int64
+	)
+	for _, v := range values {
+		if c.top[v] > count {
+			top, count = v, c.top[v]
+		}
+	}
+	return top, count > 0
+}
+
+func (t *describeTransformation) Compute(key flux.GroupKey, state interface{}, d *execute.TransportDataset, mem memory.Allocator) error {
+	s := state.(*describeState)
+	n := len(s.columns)
+
+	var (
+		columnB    = array.NewStringBuilder(mem)
+		countB     = array.NewIntBuilder(mem)
+		nullCountB = array.NewIntBuilder(mem)
+		minB       = array.NewFloatBuilder(mem)
+		maxB       = array.NewFloatBuilder(mem)
+		meanB      = array.NewFloatBuilder(mem)
+		stddevB    = array.NewFloatBuilder(mem)
+		distinctB  = array.NewIntBuilder(mem)
+		topValueB  = array.NewStringBuilder(mem)
+	)
+	for _, c := range s.columns {
+		columnB.Append(c.label)
+		countB.Append(c.count)
+		nullCountB.Append(c.nullCount)
+		distinctB.Append(int64(c.distinct.Count()))
+
+		if c.stddev != nil && !c.stddev.IsNull() {
+			minB.Append(c.min)
+			maxB.Append(c.max)
+			meanB.Append(c.stddev.mean)
+			stddevB.Append(c.stddev.ValueFloat())
+		} else {
+			minB.AppendNull()
+			maxB.AppendNull()
+			meanB.AppendNull()
+			stddevB.AppendNull()
+		}
+
+		if v, ok := c.topValue(); ok {
+			topValueB.Append(v)
+		} else {
+			topValueB.AppendNull()
+		}
+	}
+
+	buffer := arrow.TableBuffer{
+		GroupKey: key,
+		Columns:  make([]flux.ColMeta, 0, len(key.Cols())+9),
+	}
+	buffer.Values = make([]array.Array, 0, cap(buffer.Columns))
+	for j, col := range key.Cols() {
+		buffer.Columns = append(buffer.Columns, col)
+		buffer.Values = append(buffer.Values, arrow.Repeat(col.Type, key.Value(j), n, mem))
+	}
+	for _, col := range []struct {
+		label string
+		typ   flux.ColType
+		b     array.Builder
+	}{
+		{label: describeColumnLabel, typ: flux.TString, b: columnB},
+		{label: describeCountLabel, typ: flux.TInt, b: countB},
+		{label: describeNullCountLabel, typ: flux.TInt, b: nullCountB},
+		{label: describeMinLabel, typ: flux.TFloat, b: minB},
+		{label: describeMaxLabel, typ: flux.TFloat, b: maxB},
+		{label: describeMeanLabel, typ: flux.TFloat, b: meanB},
+		{label: describeStddevLabel, typ: flux.TFloat, b: stddevB},
+		{label: describeDistinctLabel, typ: flux.TInt, b: distinctB},
+		{label: describeTopValueLabel, typ: flux.TString, b: topValueB},
+	} {
+		buffer.Columns = append(buffer.Columns, flux.ColMeta{Label: col.label, Type: col.typ})
+		buffer.Values = append(buffer.Values, col.b.NewArray())
+	}
+	if err := buffer.Validate(); err != nil {
+		return err
+	}
+	return d.Process(table.ChunkFromBuffer(buffer))
+}
+
+func (t *describeTransformation) Close() error {
+	return nil
+}
@@ -0,0 +1,217 @@
+package universe_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/execute/executetest"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/stdlib/universe"
+)
+
+func TestDescribe_Process(t *testing.T) {
+	describeCols := []flux.ColMeta{
+		{Label: "t0", Type: flux.TString},
+		{Label: "_column", Type: flux.TString},
+		{Label: "count", Type: flux.TInt},
+		{Label: "nullCount", Type: flux.TInt},
+		{Label: "min", Type: flux.TFloat},
+		{Label: "max", Type: flux.TFloat},
+		{Label: "mean", Type: flux.TFloat},
+		{Label: "stddev", Type: flux.TFloat},
+		{Label: "distinct", Type: flux.TInt},
+		{Label: "topValue", Type: flux.TString},
+	}
+	testCases := []struct {
+		name    string
+		spec    *universe.DescribeProcedureSpec
+		data    []flux.Table
+		want    []*executetest.Table
+		wantErr error
+	}{
+		{
+			name: "numeric and string columns",
+			spec: &universe.DescribeProcedureSpec{
+				Columns: []string{"_value", "host"},
+			},
+			data: []flux.Table{&executetest.Table{
+				KeyCols: []string{"t0"},
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TInt},
+					{Label: "host", Type: flux.TString},
+					{Label: "other", Type: flux.TFloat},
+					{Label: "t0", Type: flux.TString},
+				},
+				Data: [][]interface{}{
+					{execute.Time(1), int64(1), "a", 10.0, "a"},
+					{execute.Time(2), int64(2), "b", 20.0, "a"},
+					{execute.Time(3), nil, "a", 30.0, "a"},
+					{execute.Time(4), int64(4), nil, 40.0, "a"},
+					{execute.Time(5), int64(3), "c", 50.0, "a"},
+					{execute.Time(6), int64(2), "a", 60.0, "a"},
+				},
+			}},
+			want: []*executetest.Table{{
+				KeyCols: []string{"t0"},
+				ColMeta: describeCols,
+				Data: [][]interface{}{
+					// mean = 12 / 5 and the sum of the squared
+					// deviations from the mean is 5.2.
+					{"a", "_value", int64(5), int64(1), 1.0, 4.0, 2.4, math.Sqrt(5.2 / 4), int64(4), nil},
+					{"a", "host", int64(5), int64(1), nil, nil, nil, nil, int64(3), "a"},
+				},
+			}},
+		},
+		{
+			name: "default column",
+			spec: &universe.DescribeProcedureSpec{
+				Columns: []string{"_value"},
+			},
+			data: []flux.Table{&executetest.Table{
+				KeyCols: []string{"t0"},
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TFloat},
+					{Label: "host", Type: flux.TString},
+					{Label: "t0", Type: flux.TString},
+				},
+				Data: [][]interface{}{
+					{execute.Time(1), 2.0, "a", "a"},
+					{execute.Time(2), 4.0, "b", "a"},
+					{execute.Time(3), 4.0, "c", "a"},
+					{execute.Time(4), 6.0, "d", "a"},
+				},
+			}},
+			want: []*executetest.Table{{
+				KeyCols: []string{"t0"},
+				ColMeta: describeCols,
+				Data: [][]interface{}{
+					{"a", "_value", int64(4), int64(0), 2.0, 6.0, 4.0, math.Sqrt(8.0 / 3), int64(3), nil},
+				},
+			}},
+		},
+		{
+			name: "multiple tables",
+			spec: &universe.DescribeProcedureSpec{
+				Columns: []string{"_value"},
+			},
+			data: []flux.Table{
+				&executetest.Table{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_value", Type: flux.TUInt},
+						{Label: "t0", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{uint64(5), "a"},
+					},
+				},
+				&executetest.Table{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_value", Type: flux.TUInt},
+						{Label: "t0", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{nil, "b"},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: describeCols,
+					Data: [][]interface{}{
+						{"a", "_value", int64(1), int64(0), 5.0, 5.0, 5.0, math.NaN(), int64(1), nil},
+					},
+				},
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: describeCols,
+					Data: [][]interface{}{
+						{"b", "_value", int64(0), int64(1), nil, nil, nil, nil, int64(0), nil},
+					},
+				},
+			},
+		},
+		{
+			name: "top value ties",
+			spec: &universe.DescribeProcedureSpec{
+				Columns: []string{"host"},
+			},
+			data: []flux.Table{&executetest.Table{
+				KeyCols: []string{"t0"},
+				ColMeta: []flux.ColMeta{
+					{Label: "host", Type: flux.TString},
+					{Label: "t0", Type: flux.TString},
+				},
+				Data: [][]interface{}{
+					{"b", "a"},
+					{"a", "a"},
+					{"b", "a"},
+					{"a", "a"},
+				},
+			}},
+			want: []*executetest.Table{{
+				KeyCols: []string{"t0"},
+				ColMeta: describeCols,
+				Data: [][]interface{}{
+					{"a", "host", int64(4), int64(0), nil, nil, nil, nil, int64(2), "a"},
+				},
+			}},
+		},
+		{
+			name: "missing column",
+			spec: &universe.DescribeProcedureSpec{
+				Columns: []string{"host"},
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_value", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{1.0},
+				},
+			}},
+			wantErr: errors.New(codes.FailedPrecondition, `column "host" does not exist`),
+		},
+		{
+			name: "unsupported type",
+			spec: &universe.DescribeProcedureSpec{
+				Columns: []string{"_value"},
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_value", Type: flux.TBool},
+				},
+				Data: [][]interface{}{
+					{true},
+				},
+			}},
+			wantErr: errors.New(codes.FailedPrecondition, `cannot describe column "_value" of type bool`),
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			executetest.ProcessTestHelper2(
+				t,
+				tc.data,
+				tc.want,
+				tc.wantErr,
+				func(id execute.DatasetID, alloc memory.Allocator) (execute.Transformation, execute.Dataset) {
+					tr, d, err := universe.NewDescribeTransformation(id, tc.spec, alloc)
+					if err != nil {
+						t.Fatal(err)
+					}
+					return tr, d
+				},
+			)
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package universe
 
 import (
+	"math"
+
 	"github.com/apache/arrow/go/v7/arrow/memory"
 
 	"github.com/influxdata/flux"
@@ -269,7 +271,10 @@ func (t *differenceTransformation) Process(id execute.DatasetID, tbl flux.Table)
 					continue
 				}
 				for i := 0; i < l; i++ {
-					v, ok := d.updateUInt(values.Value(i), values.IsValid(i))
+					v, ok, err := d.updateUInt(values.Value(i), values.IsValid(i))
+					if err != nil {
+						return err
+					}
 					if i < firstIdx {
 						continue
 					}
@@ -484,7 +489,10 @@ func (t *differenceTransformation) processChunk(differences []*difference, first
 				out = processInts(d, l, values, firstIdx, mem)
 			case flux.TUInt:
 				values := chunk.Uints(j)
-				out = processUints(d, l, values, firstIdx, mem)
+				var err error
+				if out, err = processUints(d, l, values, firstIdx, mem); err != nil {
+					return err
+				}
 			case flux.TFloat:
 				values := chunk.Floats(j)
 				out = processFloats(d, l, values, firstIdx, mem)
@@ -522,15 +530,19 @@ func processInts(d *difference, l int, values *array.Int, firstIdx int, mem memo
 	return b.NewIntArray()
 }
 
-func processUints(d *difference, l int, values *array.Uint, firstIdx int, mem memory.Allocator) array.Array {
+func processUints(d *difference, l int, values *array.Uint, firstIdx int, mem memory.Allocator) (array.Array, error) {
 	if d == nil {
-		return arrow.UintSlice(values, firstIdx, l)
+		return arrow.UintSlice(values, firstIdx, l), nil
 	}
 
 	b := arrowutil.NewIntBuilder(mem)
+	defer b.Release()
 	b.Resize(l)
 	for i := 0; i < l; i++ {
-		v, ok := d.updateUInt(values.Value(i), values.IsValid(i))
+		v, ok, err := d.updateUInt(values.Value(i), values.IsValid(i))
+		if err != nil {
+			return nil, err
+		}
 		if i < firstIdx {
 			continue
 		}
@@ -540,7 +552,7 @@ func processUints(d *difference, l int, values *array.Uint, firstIdx int, mem me
 			b.AppendNull()
 		}
 	}
-	return b.NewIntArray()
+	return b.NewIntArray(), nil
 }
 
 func processFloats(d *difference, l int, values *array.Float, firstIdx int, mem memory.Allocator) *array.Float {
@@ -606,29 +618,41 @@ func (d *difference) updateInt(v int64, valid bool) (int64, bool) {
 	return 0, false
 }
 
-func (d *difference) updateUInt(v uint64, valid bool) (int64, bool) {
+func (d *difference) updateUInt(v uint64, valid bool) (int64, bool, error) {
 	if !valid {
-		return 0, false
+		return 0, false, nil
 	}
 	prev := d.pUIntValue
 	d.pUIntValue = v
 	if !d.valid && d.keepFirst && d.initialZero {
 		d.valid = true
-		return 0, true
+		return 0, true, nil
 	}
 	if !d.valid {
 		d.valid = true
-		return 0, false
-	}
-	// Note: the unsigned substraction works correctly even for negative differences
-	// because of two's-complement arithmetic.
-	diff := int64(v - prev)
-	if diff >= 0 || !d.nonNegative {
-		return diff, true
-	} else if d.nonNegative && d.initialZero && int64(v) >= 0 {
-		return int64(v), true
+		return 0, false, nil
+	}
+	// The difference of unsigned values is an int. A difference that
+	// does not fit into an int is an error instead of wrapping around.
+	if v >= prev {
+		diff := v - prev
+		if diff > math.MaxInt64 {
+			return 0, false, errors.Newf(codes.Invalid, "difference between unsigned values %d and %d overflows int", v, prev)
+		}
+		return int64(diff), true, nil
+	} else if !d.nonNegative {
+		diff := prev - v
+		if diff > -math.MinInt64 {
+			return 0, false, errors.Newf(codes.Invalid, "difference between unsigned values %d and %d overflows int", v, prev)
+		}
+		return int64(-diff), true, nil
+	} else if d.initialZero {
+		if v > math.MaxInt64 {
+			return 0, false, errors.Newf(codes.Invalid, "unsigned value %d overflows int", v)
+		}
+		return int64(v), true, nil
 	}
-	return 0, false
+	return 0, false, nil
 }
 
 func (d *difference) updateFloat(v float64, valid bool) (float64, bool) {
@@ -1,6 +1,7 @@
 package universe_test
 
 import (
+	"math"
 	"testing"
 
 	"github.com/influxdata/flux"
@@ -1039,6 +1040,151 @@ func TestDifference_Process_With_NonNegative_KeepFirst_InitialZero(t *testing.T)
 	}
 }
 
+func TestDifference_Process_UIntRange(t *testing.T) {
+	// The tables are read once by each transformation,
+	// so they are created from the values for each run.
+	uintTable := func(vs ...interface{}) []flux.Table {
+		tbl := &executetest.Table{
+			ColMeta: []flux.ColMeta{
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_value", Type: flux.TUInt},
+			},
+		}
+		for i, v := range vs {
+			tbl.Data = append(tbl.Data, []interface{}{execute.Time(i + 1), v})
+		}
+		return []flux.Table{tbl}
+	}
+	intTable := func(first int, vs ...interface{}) []*executetest.Table {
+		tbl := &executetest.Table{
+			ColMeta: []flux.ColMeta{
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_value", Type: flux.TInt},
+			},
+		}
+		for i, v := range vs {
+			tbl.Data = append(tbl.Data, []interface{}{execute.Time(first + i), v})
+		}
+		return []*executetest.Table{tbl}
+	}
+	testCases := []struct {
+		name    string
+		spec    *universe.DifferenceProcedureSpec
+		data    []interface{}
+		want    []*executetest.Table
+		wantErr error
+	}{
+		{
+			name: "values above max int",
+			spec: &universe.DifferenceProcedureSpec{
+				Columns: []string{execute.DefaultValueColLabel},
+			},
+			data: []interface{}{uint64(math.MaxUint64 - 10), uint64(math.MaxUint64), uint64(math.MaxUint64 - 5)},
+			want: intTable(2, int64(10), int64(-5)),
+		},
+		{
+			name: "decrease to zero",
+			spec: &universe.DifferenceProcedureSpec{
+				Columns: []string{execute.DefaultValueColLabel},
+			},
+			data: []interface{}{uint64(5), uint64(0), uint64(3)},
+			want: intTable(2, int64(-5), int64(3)),
+		},
+		{
+			name: "largest differences",
+			spec: &universe.DifferenceProcedureSpec{
+				Columns: []string{execute.DefaultValueColLabel},
+			},
+			data: []interface{}{uint64(math.MaxInt64 + 1), uint64(0), uint64(math.MaxInt64), uint64(0)},
+			want: intTable(2, int64(math.MinInt64), int64(math.MaxInt64), int64(-math.MaxInt64)),
+		},
+		{
+			name: "with null",
+			spec: &universe.DifferenceProcedureSpec{
+				Columns: []string{execute.DefaultValueColLabel},
+			},
+			data: []interface{}{uint64(10), nil, uint64(4)},
+			want: intTable(2, nil, int64(-6)),
+		},
+		{
+			name: "increase overflows",
+			spec: &universe.DifferenceProcedureSpec{
+				Columns: []string{execute.DefaultValueColLabel},
+			},
+			data:    []interface{}{uint64(0), uint64(math.MaxUint64)},
+			wantErr: errors.New(codes.Invalid, "difference between unsigned values 18446744073709551615 and 0 overflows int"),
+		},
+		{
+			name: "decrease overflows",
+			spec: &universe.DifferenceProcedureSpec{
+				Columns: []string{execute.DefaultValueColLabel},
+			},
+			data:    []interface{}{uint64(math.MaxUint64), uint64(0)},
+			wantErr: errors.New(codes.Invalid, "difference between unsigned values 0 and 18446744073709551615 overflows int"),
+		},
+		{
+			name: "non negative decrease does not overflow",
+			spec: &universe.DifferenceProcedureSpec{
+				Columns:     []string{execute.DefaultValueColLabel},
+				NonNegative: true,
+			},
+			data: []interface{}{uint64(math.MaxUint64), uint64(0), uint64(7)},
+			want: intTable(2, nil, int64(7)),
+		},
+		{
+			name: "non negative with initial zero",
+			spec: &universe.DifferenceProcedureSpec{
+				Columns:     []string{execute.DefaultValueColLabel},
+				NonNegative: true,
+				KeepFirst:   true,
+				InitialZero: true,
+			},
+			data: []interface{}{uint64(math.MaxUint64), uint64(3), uint64(math.MaxInt64)},
+			want: intTable(1, int64(0), int64(3), int64(math.MaxInt64-3)),
+		},
+		{
+			name: "non negative with initial zero overflows",
+			spec: &universe.DifferenceProcedureSpec{
+				Columns:     []string{execute.DefaultValueColLabel},
+				NonNegative: true,
+				KeepFirst:   true,
+				InitialZero: true,
+			},
+			data:    []interface{}{uint64(math.MaxUint64), uint64(math.MaxInt64 + 1)},
+			wantErr: errors.New(codes.Invalid, "unsigned value 9223372036854775808 overflows int"),
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			executetest.ProcessTestHelper(
+				t,
+				uintTable(tc.data...),
+				tc.want,
+				tc.wantErr,
+				func(d execute.Dataset, c execute.TableBuilderCache) execute.Transformation {
+					return universe.NewDifferenceTransformation(d, c, tc.spec)
+				},
+			)
+		})
+		t.Run(tc.name+" narrow", func(t *testing.T) {
+			executetest.ProcessTestHelper2(
+				t,
+				uintTable(tc.data...),
+				tc.want,
+				tc.wantErr,
+				func(id execute.DatasetID, alloc memory.Allocator) (execute.Transformation, execute.Dataset) {
+					tr, d, err := universe.NewNarrowDifferenceTransformation(tc.spec, id, alloc)
+					if err != nil {
+						t.Fatal(err)
+					}
+					return tr, d
+				},
+			)
+		})
+	}
+}
+
 func TestDifference_Process_Narrow(t *testing.T) {
 	testCases := []struct {
 		name    string
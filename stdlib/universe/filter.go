@@ -3,6 +3,7 @@ package universe
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	"github.com/apache/arrow/go/v7/arrow/bitutil"
 	arrowmem "github.com/apache/arrow/go/v7/arrow/memory"
@@ -115,6 +116,18 @@ func (s *FilterProcedureSpec) Copy() plan.ProcedureSpec {
 	return ns
 }
 
+// PassThroughAttribute implements plan.PassThroughAttributer.
+// Filter only removes rows so the order of the rows is preserved.
+func (s *FilterProcedureSpec) PassThroughAttribute(attrKey string) bool {
+	return attrKey == plan.CollationKey
+}
+
+// OutputSchema implements plan.OutputSchemer.
+// Filter only removes rows so the columns are those of its input.
+func (s *FilterProcedureSpec) OutputSchema(input flux.ResultSchema) flux.ResultSchema {
+	return input
+}
+
 // TriggerSpec implements plan.TriggerAwareProcedureSpec
 func (s *FilterProcedureSpec) TriggerSpec() plan.TriggerSpec {
 	return plan.NarrowTransformationTriggerSpec{}
@@ -149,16 +162,29 @@ func NewFilterTransformation(ctx context.Context, spec *FilterProcedureSpec, id
 	return execute.NewNarrowTransformation(id, t, alloc)
 }
 
+// filterTransformation filters each table on its own, so the
+// tables may be filtered concurrently when the execution options
+// allow it. Only preparing the function is shared between the
+// tables and it is guarded by mu.
 type filterTransformation struct {
 	ctx             context.Context
+	mu              sync.Mutex
 	fn              *execute.RowPredicateFn
 	keepEmptyTables bool
+	concurrency     int
+}
+
+// Concurrency implements execute.ParallelTransformation.
+func (t *filterTransformation) Concurrency() int {
+	return t.concurrency
 }
 
 func (t *filterTransformation) Process(chunk table.Chunk, d *execute.TransportDataset, mem arrowmem.Allocator) error {
 	// Prepare the function for the column types.
 	cols := chunk.Cols()
+	t.mu.Lock()
 	fn, err := t.fn.Prepare(cols)
+	t.mu.Unlock()
 	if err != nil {
 		// TODO(nathanielc): Should we not fail the query for failed compilation?
 		return err
@@ -214,6 +240,9 @@ func (t *filterTransformation) filterChunk(fn *execute.RowPredicatePreparedFn, c
 		GroupKey: chunk.Key(),
 		Columns:  chunk.Cols(),
 		Values:   vs,
+		// The key columns are sliced from the input so
+		// they are constant if the input columns are.
+		KeyConstant: buffer.KeyConstant,
 	}), true, nil
 }
 
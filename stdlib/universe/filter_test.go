@@ -713,6 +713,126 @@ func TestFilter_Process(t *testing.T) {
 				},
 			}},
 		},
+		{
+			name: "bytes column",
+			spec: &universe.FilterProcedureSpec{
+				Fn: interpreter.ResolvedFunction{
+					Fn:    executetest.FunctionExpression(t, `(r) => r._value > 5.0`),
+					Scope: valuestest.Scope(),
+				},
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TFloat},
+					{Label: "payload", Type: flux.TBytes},
+				},
+				Data: [][]interface{}{
+					{execute.Time(1), 1.0, []byte{0x01}},
+					{execute.Time(2), 6.0, []byte{0xde, 0xad}},
+					{execute.Time(3), 7.0, nil},
+				},
+			}},
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TFloat},
+					{Label: "payload", Type: flux.TBytes},
+				},
+				Data: [][]interface{}{
+					{execute.Time(2), 6.0, []byte{0xde, 0xad}},
+					{execute.Time(3), 7.0, nil},
+				},
+			}},
+		},
+		{
+			name: "exists with column missing from some tables",
+			spec: &universe.FilterProcedureSpec{
+				Fn: interpreter.ResolvedFunction{
+					Fn:    executetest.FunctionExpression(t, `(r) => exists r.region and r.region != "west"`),
+					Scope: valuestest.Scope(),
+				},
+			},
+			data: []flux.Table{
+				&executetest.Table{
+					KeyCols: []string{"host"},
+					ColMeta: []flux.ColMeta{
+						{Label: "host", Type: flux.TString},
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{"a", execute.Time(1), 1.0},
+						{"a", execute.Time(2), 2.0},
+					},
+				},
+				&executetest.Table{
+					KeyCols: []string{"host", "region"},
+					ColMeta: []flux.ColMeta{
+						{Label: "host", Type: flux.TString},
+						{Label: "region", Type: flux.TString},
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{"b", "east", execute.Time(1), 3.0},
+						{"b", "east", execute.Time(2), 4.0},
+					},
+				},
+				&executetest.Table{
+					KeyCols: []string{"host"},
+					ColMeta: []flux.ColMeta{
+						{Label: "host", Type: flux.TString},
+						{Label: "region", Type: flux.TString},
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{"c", "west", execute.Time(1), 5.0},
+						{"c", nil, execute.Time(2), 6.0},
+						{"c", "north", execute.Time(3), 7.0},
+					},
+				},
+				&executetest.Table{
+					KeyCols: []string{"host"},
+					ColMeta: []flux.ColMeta{
+						{Label: "host", Type: flux.TString},
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{"d", execute.Time(1), 8.0},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					KeyCols: []string{"host", "region"},
+					ColMeta: []flux.ColMeta{
+						{Label: "host", Type: flux.TString},
+						{Label: "region", Type: flux.TString},
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{"b", "east", execute.Time(1), 3.0},
+						{"b", "east", execute.Time(2), 4.0},
+					},
+				},
+				{
+					KeyCols: []string{"host"},
+					ColMeta: []flux.ColMeta{
+						{Label: "host", Type: flux.TString},
+						{Label: "region", Type: flux.TString},
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{"c", "north", execute.Time(3), 7.0},
+					},
+				},
+			},
+		},
 	}
 	for _, tc := range testCases {
 		tc := tc
@@ -933,6 +1053,10 @@ func BenchmarkFilter_Values(b *testing.B) {
 		fn := executetest.FunctionExpression(b, `(r) => r._value > 0.0`)
 		benchmarkFilter(b, 1000, fn)
 	})
+	b.Run("1000000", func(b *testing.B) {
+		fn := executetest.FunctionExpression(b, `(r) => r._value > 0.0`)
+		benchmarkFilter(b, 1000000, fn)
+	})
 }
 
 func benchmarkFilter(b *testing.B, n int, fn *semantic.FunctionExpression) {
@@ -242,6 +242,11 @@ func (t *groupTransformation) getTableKey(key flux.GroupKey, cols []flux.ColMeta
 		indices = make([]int, 0, len(t.keys))
 		for _, label := range t.keys {
 			if execute.ColIdx(label, cols) < 0 {
+				// Composite values such as records cannot be stored
+				// in a column so they are only part of the group key.
+				if idx := execute.ColIdx(label, key.Cols()); idx >= 0 && key.Cols()[idx].Type == flux.TInvalid {
+					indices = append(indices, idx)
+				}
 				// Skip past this label since it doesn't exist in the table.
 				continue
 			}
@@ -269,6 +274,11 @@ func (t *groupTransformation) getTableKey(key flux.GroupKey, cols []flux.ColMeta
 			}
 			indices = append(indices, idx)
 		}
+		for idx, c := range key.Cols() {
+			if c.Type == flux.TInvalid && !execute.ContainsStr(t.keys, c.Label) {
+				indices = append(indices, idx)
+			}
+		}
 	default:
 		panic(errors.Newf(codes.Internal, "unsupported group mode: %v", t.mode))
 	}
@@ -288,21 +298,7 @@ func (t *groupTransformation) appendTable(ab *table.BufferedBuilder, tbl flux.Ta
 }
 
 func (t *groupTransformation) groupChunkByRow(tbl table.Chunk, d *execute.TransportDataset, mem arrowmem.Allocator) error {
-	var on map[string]bool
-	switch t.mode {
-	case flux.GroupModeBy:
-		on = make(map[string]bool, len(t.keys))
-		for _, key := range t.keys {
-			on[key] = true
-		}
-	case flux.GroupModeExcept:
-		on = make(map[string]bool, len(tbl.Cols()))
-		for _, c := range tbl.Cols() {
-			if !execute.ContainsStr(t.keys, c.Label) {
-				on[c.Label] = true
-			}
-		}
-	}
+	on := t.groupOn(tbl.Key(), tbl.Cols())
 
 	// Construct a builder cache for the built tables.
 	cache := table.BuilderCache{
@@ -311,19 +307,8 @@ func (t *groupTransformation) groupChunkByRow(tbl table.Chunk, d *execute.Transp
 		},
 	}
 	buffer := tbl.Buffer()
-	for i, l := 0, buffer.Len(); i < l; i++ {
-		key := execute.GroupKeyForRowOn(i, &buffer, on)
-		ab, created := table.GetArrowBuilder(key, &cache)
-		if created {
-			for _, c := range buffer.Cols() {
-				_, _ = ab.AddCol(c)
-			}
-		}
-		for j := range buffer.Cols() {
-			if err := t.appendValueFromRow(ab.Builders[j], &buffer, i, j); err != nil {
-				return err
-			}
-		}
+	if err := t.appendRows(&buffer, on, &cache); err != nil {
+		return err
 	}
 
 	// Pass a view of each table we grouped to the downstream datasets.
@@ -339,21 +324,7 @@ func (t *groupTransformation) groupChunkByRow(tbl table.Chunk, d *execute.Transp
 // groupByRow will determine which table each row belongs to
 // and to append them to that table.
 func (t *groupTransformation) groupByRow(tbl flux.Table) error {
-	var on map[string]bool
-	switch t.mode {
-	case flux.GroupModeBy:
-		on = make(map[string]bool, len(t.keys))
-		for _, key := range t.keys {
-			on[key] = true
-		}
-	case flux.GroupModeExcept:
-		on = make(map[string]bool, len(tbl.Cols()))
-		for _, c := range tbl.Cols() {
-			if !execute.ContainsStr(t.keys, c.Label) {
-				on[c.Label] = true
-			}
-		}
-	}
+	on := t.groupOn(tbl.Key(), tbl.Cols())
 
 	// Construct a builder cache for the built tables.
 	cache := table.BuilderCache{
@@ -362,21 +333,7 @@ func (t *groupTransformation) groupByRow(tbl flux.Table) error {
 		},
 	}
 	if err := tbl.Do(func(cr flux.ColReader) error {
-		for i, l := 0, cr.Len(); i < l; i++ {
-			key := execute.GroupKeyForRowOn(i, cr, on)
-			ab, created := table.GetArrowBuilder(key, &cache)
-			if created {
-				for _, c := range cr.Cols() {
-					_, _ = ab.AddCol(c)
-				}
-			}
-			for j := range cr.Cols() {
-				if err := t.appendValueFromRow(ab.Builders[j], cr, i, j); err != nil {
-					return err
-				}
-			}
-		}
-		return nil
+		return t.appendRows(cr, on, &cache)
 	}); err != nil {
 		return err
 	}
@@ -392,6 +349,83 @@ func (t *groupTransformation) groupByRow(tbl flux.Table) error {
 	})
 }
 
+// groupOn returns the labels of the columns that
+// the rows of a table with the key and columns are grouped on.
+func (t *groupTransformation) groupOn(key flux.GroupKey, cols []flux.ColMeta) map[string]bool {
+	var on map[string]bool
+	switch t.mode {
+	case flux.GroupModeBy:
+		on = make(map[string]bool, len(t.keys))
+		for _, label := range t.keys {
+			on[label] = true
+		}
+	case flux.GroupModeExcept:
+		on = make(map[string]bool, len(cols))
+		for _, c := range cols {
+			if !execute.ContainsStr(t.keys, c.Label) {
+				on[c.Label] = true
+			}
+		}
+		for _, c := range key.Cols() {
+			if c.Type == flux.TInvalid && !execute.ContainsStr(t.keys, c.Label) {
+				on[c.Label] = true
+			}
+		}
+	}
+	return on
+}
+
+// appendRows appends each row of the column reader to the
+// builder for its group key. The rows are counted per group key
+// before any are appended so each builder is sized for the rows
+// it receives instead of growing one row at a time.
+func (t *groupTransformation) appendRows(cr flux.ColReader, on map[string]bool, cache *table.BuilderCache) error {
+	// Composite values such as records cannot be stored in a column
+	// so they are only part of the group key and are the same for
+	// every row of the table.
+	var keyCols []flux.ColMeta
+	var keyValues []values.Value
+	for j, c := range cr.Key().Cols() {
+		if c.Type == flux.TInvalid && on[c.Label] {
+			keyCols = append(keyCols, c)
+			keyValues = append(keyValues, cr.Key().Value(j))
+		}
+	}
+
+	l := cr.Len()
+	builders := make([]*table.ArrowBuilder, l)
+	counts := make(map[*table.ArrowBuilder]int)
+	for i := 0; i < l; i++ {
+		key := execute.GroupKeyForRowOn(i, cr, on)
+		if len(keyCols) > 0 {
+			key = execute.NewGroupKey(append(key.Cols(), keyCols...), append(key.Values(), keyValues...))
+		}
+		ab, _ := table.GetArrowBuilder(key, cache)
+		builders[i] = ab
+		counts[ab]++
+	}
+
+	for ab, n := range counts {
+		if ab.Columns == nil {
+			ab.SizeHint = n
+			for _, c := range cr.Cols() {
+				_, _ = ab.AddCol(c)
+			}
+		} else {
+			ab.Reserve(n)
+		}
+	}
+
+	for i, ab := range builders {
+		for j := range cr.Cols() {
+			if err := t.appendValueFromRow(ab.Builders[j], cr, i, j); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func (t *groupTransformation) appendValueFromRow(b array.Builder, cr flux.ColReader, i, j int) error {
 	switch cr.Cols()[j].Type {
 	case flux.TInt:
@@ -1,12 +1,17 @@
 package universe_test
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/csv"
 	"github.com/influxdata/flux/execute"
 	"github.com/influxdata/flux/execute/executetest"
 	"github.com/influxdata/flux/internal/gen"
@@ -782,6 +787,104 @@ func TestGroup_Process(t *testing.T) {
 	}
 }
 
+func TestGroup_CompositeKeyCSV(t *testing.T) {
+	// meta builds a record value with the properties in the given order.
+	meta := func(props ...string) values.Value {
+		obj, err := values.BuildObject(func(set values.ObjectSetter) error {
+			for i := 0; i < len(props); i += 2 {
+				set(props[i], values.NewString(props[i+1]))
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return obj
+	}
+	table := func(m values.Value, host string, vs ...float64) flux.Table {
+		tbl := &executetest.Table{
+			GroupKey: execute.NewGroupKey(
+				[]flux.ColMeta{
+					{Label: "host", Type: flux.TString},
+					{Label: "meta", Type: flux.TInvalid},
+				},
+				[]values.Value{values.NewString(host), m},
+			),
+			ColMeta: []flux.ColMeta{
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_value", Type: flux.TFloat},
+				{Label: "host", Type: flux.TString},
+			},
+		}
+		for i, v := range vs {
+			tbl.Data = append(tbl.Data, []interface{}{execute.Time(i + 1), v, host})
+		}
+		return tbl
+	}
+
+	data := []flux.Table{
+		table(meta("region", "west", "zone", "a"), "A", 1),
+		table(meta("zone", "a", "region", "west"), "B", 2),
+		table(meta("region", "east", "zone", "b"), "A", 3),
+	}
+
+	spec := &universe.GroupProcedureSpec{
+		GroupMode: flux.GroupModeBy,
+		GroupKeys: []string{"meta"},
+	}
+	store := executetest.NewDataStore()
+	tx, d, err := universe.NewGroupTransformation(context.Background(), spec, executetest.RandomDatasetID(), &memory.ResourceAllocator{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.SetTriggerSpec(plan.DefaultTriggerSpec)
+	d.AddTransformation(store)
+
+	parentID := executetest.RandomDatasetID()
+	for _, tbl := range data {
+		if err := tx.Process(parentID, tbl); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tx.Finish(parentID, nil)
+
+	got, err := executetest.TablesFromCache(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 2; len(got) != want {
+		t.Fatalf("unexpected number of tables -want/+got:\n\t- %d\n\t+ %d", want, len(got))
+	}
+	sort.Slice(got, func(i, j int) bool {
+		return got[i].Data[0][1].(float64) < got[j].Data[0][1].(float64)
+	})
+
+	result := &executetest.Result{Nm: "_result", Tbls: got}
+	var buf bytes.Buffer
+	encoder := csv.NewResultEncoder(csv.ResultEncoderConfig{
+		Annotations: []string{"datatype", "group"},
+		JSONCells:   true,
+	})
+	if _, err := encoder.Encode(&buf, result); err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.ReplaceAll(`#datatype,string,long,dateTime:RFC3339,double,string,string
+#group,false,false,false,false,false,true
+,result,table,_time,_value,host,meta
+,_result,0,1970-01-01T00:00:00.000000001Z,1,A,"{""region"":""west"",""zone"":""a""}"
+,_result,0,1970-01-01T00:00:00.000000001Z,2,B,"{""region"":""west"",""zone"":""a""}"
+
+#datatype,string,long,dateTime:RFC3339,double,string,string
+#group,false,false,false,false,false,true
+,result,table,_time,_value,host,meta
+,_result,1,1970-01-01T00:00:00.000000001Z,3,A,"{""region"":""east"",""zone"":""b""}"
+`, "\n", "\r\n")
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected encoding -want/+got:\n%s", cmp.Diff(want, got))
+	}
+}
+
 func TestMergeGroupRule(t *testing.T) {
 	var (
 		from      = &influxdb.FromProcedureSpec{}
@@ -985,6 +1088,15 @@ func BenchmarkGroup_ByKey_1000(b *testing.B) {
 	benchmarkGroupByKey(b, 1000)
 }
 
+// BenchmarkGroup_ByTime_100000 groups by a column that is not part
+// of the group key so each row is appended to one of many small tables.
+func BenchmarkGroup_ByTime_100000(b *testing.B) {
+	benchmarkGroup(b, 100000, &universe.GroupProcedureSpec{
+		GroupMode: flux.GroupModeBy,
+		GroupKeys: []string{"_time"},
+	})
+}
+
 func benchmarkGroupByKey(b *testing.B, n int) {
 	spec := &universe.GroupProcedureSpec{
 		GroupMode: flux.GroupModeBy,
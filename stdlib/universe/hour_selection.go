@@ -4,6 +4,7 @@ import (
 	"github.com/influxdata/flux"
 	"github.com/influxdata/flux/codes"
 	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/internal/date"
 	"github.com/influxdata/flux/internal/errors"
 	"github.com/influxdata/flux/plan"
 	"github.com/influxdata/flux/runtime"
@@ -12,9 +13,10 @@ import (
 const HourSelectionKind = "hourSelection"
 
 type HourSelectionOpSpec struct {
-	Start      int64  `json:"start"`
-	Stop       int64  `json:"stop"`
-	TimeColumn string `json:"timeColumn"`
+	Start      int64          `json:"start"`
+	Stop       int64          `json:"stop"`
+	Location   *plan.Location `json:"location,omitempty"`
+	TimeColumn string         `json:"timeColumn"`
 }
 
 func init() {
@@ -45,6 +47,16 @@ func createHourSelectionOpSpec(args flux.Arguments, a *flux.Administration) (flu
 	}
 	spec.Stop = stop
 
+	if location, ok, err := args.GetObject("location"); err != nil {
+		return nil, err
+	} else if ok {
+		loc, err := plan.LocationFromObject(location)
+		if err != nil {
+			return nil, err
+		}
+		spec.Location = &loc
+	}
+
 	if label, ok, err := args.GetString("timeColumn"); err != nil {
 		return nil, err
 	} else if ok {
@@ -66,9 +78,12 @@ func (s *HourSelectionOpSpec) Kind() flux.OperationKind {
 
 type HourSelectionProcedureSpec struct {
 	plan.DefaultCost
-	Start      int64  `json:"start"`
-	Stop       int64  `json:"stop"`
-	TimeColumn string `json:"timeColumn"`
+	Start int64 `json:"start"`
+	Stop  int64 `json:"stop"`
+	// Location is the location of the hours. When it is nil,
+	// the location option of the query is used.
+	Location   *plan.Location `json:"location,omitempty"`
+	TimeColumn string         `json:"timeColumn"`
 }
 
 func newHourSelectionProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.ProcedureSpec, error) {
@@ -80,6 +95,7 @@ func newHourSelectionProcedure(qs flux.OperationSpec, pa plan.Administration) (p
 	return &HourSelectionProcedureSpec{
 		Start:      spec.Start,
 		Stop:       spec.Stop,
+		Location:   spec.Location,
 		TimeColumn: spec.TimeColumn,
 	}, nil
 }
@@ -92,6 +108,10 @@ func (s *HourSelectionProcedureSpec) Copy() plan.ProcedureSpec {
 	ns := new(HourSelectionProcedureSpec)
 
 	*ns = *s
+	if s.Location != nil {
+		loc := *s.Location
+		ns.Location = &loc
+	}
 
 	return ns
 }
@@ -108,6 +128,9 @@ func createHourSelectionTransformation(id execute.DatasetID, mode execute.Accumu
 	cache := execute.NewTableBuilderCache(a.Allocator())
 	d := execute.NewDataset(id, mode, cache)
 	t := NewHourSelectionTransformation(d, cache, s)
+	if s.Location == nil {
+		t.location = execute.GetLocation(a.Context())
+	}
 	return t, d, nil
 }
 
@@ -116,19 +139,24 @@ type hourSelectionTransformation struct {
 	d     execute.Dataset
 	cache execute.TableBuilderCache
 
-	start   int64
-	stop    int64
-	timeCol string
+	start    int64
+	stop     int64
+	location plan.Location
+	timeCol  string
 }
 
 func NewHourSelectionTransformation(d execute.Dataset, cache execute.TableBuilderCache, spec *HourSelectionProcedureSpec) *hourSelectionTransformation {
-	return &hourSelectionTransformation{
+	t := &hourSelectionTransformation{
 		d:       d,
 		cache:   cache,
 		start:   spec.Start,
 		stop:    spec.Stop,
 		timeCol: spec.TimeColumn,
 	}
+	if spec.Location != nil {
+		t.location = *spec.Location
+	}
+	return t
 }
 
 func (t *hourSelectionTransformation) RetractTable(id execute.DatasetID, key flux.GroupKey) error {
@@ -156,13 +184,22 @@ func (t *hourSelectionTransformation) Process(id execute.DatasetID, tbl flux.Tab
 		return errors.Newf(codes.Invalid, "stop must be between 0 and 23")
 	}
 
+	zone := t.location.Name
+	if zone == "" {
+		zone = "UTC"
+	}
+
 	return tbl.Do(func(cr flux.ColReader) error {
 		l := cr.Len()
 		for i := 0; i < l; i++ {
 			if nullCheck := cr.Times(colIdx); nullCheck.IsNull(i) {
 				continue
 			}
-			curr := execute.Time(cr.Times(colIdx).Value(i)).Time().Hour()
+			tm, err := date.GetTimeInLocation(execute.Time(cr.Times(colIdx).Value(i)), zone, t.location.Offset)
+			if err != nil {
+				return err
+			}
+			curr := tm.Time().Time().Hour()
 			if int64(curr) >= t.start && int64(curr) <= t.stop {
 				for k := range cr.Cols() {
 					if err := builder.AppendValue(k, execute.ValueForRow(cr, i, k)); err != nil {
@@ -0,0 +1,5 @@
+package universe
+
+// CreateHourSelectionTransformation is exposed so the tests can create
+// the hourSelection transformation with an execution context.
+var CreateHourSelectionTransformation = createHourSelectionTransformation
@@ -1,12 +1,17 @@
 package universe_test
 
 import (
+	"context"
 	"fmt"
 	"testing"
+	"time"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/influxdata/flux"
 	"github.com/influxdata/flux/execute"
 	"github.com/influxdata/flux/execute/executetest"
+	"github.com/influxdata/flux/mock"
+	"github.com/influxdata/flux/plan"
 	"github.com/influxdata/flux/stdlib/universe"
 )
 
@@ -451,3 +456,102 @@ func TestHourSelection_Process(t *testing.T) {
 		})
 	}
 }
+
+func TestHourSelection_Location(t *testing.T) {
+	ts := func(s string) execute.Time {
+		tm, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return execute.Time(tm.UnixNano())
+	}
+	// The hours of the rows are 6, 8 and 10 in UTC
+	// and 7, 9 and 11 in Europe/Berlin.
+	newData := func() flux.Table {
+		return &executetest.Table{
+			ColMeta: []flux.ColMeta{
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_value", Type: flux.TInt},
+			},
+			Data: [][]interface{}{
+				{ts("2022-01-01T06:00:00Z"), int64(1)},
+				{ts("2022-01-01T08:00:00Z"), int64(2)},
+				{ts("2022-01-01T10:00:00Z"), int64(3)},
+			},
+		}
+	}
+
+	for _, tc := range []struct {
+		name     string
+		option   plan.Location
+		location *plan.Location
+		want     []int64
+	}{
+		{
+			name: "default",
+			want: []int64{3},
+		},
+		{
+			name:   "location option",
+			option: plan.Location{Name: "Europe/Berlin"},
+			want:   []int64{2},
+		},
+		{
+			name:   "location option with offset",
+			option: plan.Location{Name: "UTC", Offset: flux.ConvertDuration(3 * time.Hour)},
+			want:   []int64{1},
+		},
+		{
+			name:     "explicit location",
+			option:   plan.Location{Name: "UTC"},
+			location: &plan.Location{Name: "Europe/Berlin"},
+			want:     []int64{2},
+		},
+		{
+			name:     "explicit utc overrides the option",
+			option:   plan.Location{Name: "Europe/Berlin"},
+			location: &plan.Location{Name: "UTC"},
+			want:     []int64{3},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			deps := execute.DefaultExecutionDependencies()
+			deps.ExecutionOptions.Location = tc.option
+			ctx := deps.Inject(context.Background())
+
+			spec := &universe.HourSelectionProcedureSpec{
+				Start:      9,
+				Stop:       10,
+				Location:   tc.location,
+				TimeColumn: execute.DefaultTimeColLabel,
+			}
+			tr, d, err := universe.CreateHourSelectionTransformation(executetest.RandomDatasetID(), execute.DiscardingMode, spec, mock.AdministrationWithContext(ctx))
+			if err != nil {
+				t.Fatal(err)
+			}
+			store := executetest.NewDataStore()
+			d.SetTriggerSpec(plan.DefaultTriggerSpec)
+			d.AddTransformation(store)
+
+			parentID := executetest.RandomDatasetID()
+			if err := tr.Process(parentID, newData()); err != nil {
+				t.Fatal(err)
+			}
+			tr.Finish(parentID, nil)
+
+			got, err := executetest.TablesFromCache(store)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var values []int64
+			for _, tbl := range got {
+				for _, row := range tbl.Data {
+					values = append(values, row[1].(int64))
+				}
+			}
+			if !cmp.Equal(tc.want, values) {
+				t.Errorf("unexpected values -want/+got:\n%s", cmp.Diff(tc.want, values))
+			}
+		})
+	}
+}
@@ -1,14 +1,19 @@
 package universe
 
 import (
-	"fmt"
+	"context"
+	"encoding/binary"
 	"math"
 	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/influxdata/flux"
 	"github.com/influxdata/flux/codes"
 	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/execute/table"
 	"github.com/influxdata/flux/internal/errors"
 	"github.com/influxdata/flux/interpreter"
 	"github.com/influxdata/flux/memory"
@@ -28,11 +33,24 @@ func init() {
 	// TODO(nathanielc): Allow for other types of join implementations
 	plan.RegisterProcedureSpec(MergeJoinKind, newMergeJoinProcedure, JoinKind)
 	execute.RegisterTransformation(MergeJoinKind, createMergeJoinTransformation)
+	plan.RegisterPhysicalRules(MergeJoinSortedInputsRule{}, MergeJoinHashStrategyRule{}, FilterThroughJoinRule{})
 }
 
+// CrossJoinMethod is the join method that joins every row
+// of a table with every row of the table it is paired with.
+const CrossJoinMethod = "cross"
+
+// DefaultCrossRowLimit is the maximum number of rows that a cross
+// join may output for a pair of tables unless another limit is set.
+const DefaultCrossRowLimit = 1000000
+
 // All supported join types in Flux
 var methods = map[string]bool{
-	"inner": true,
+	"inner":         true,
+	"left":          true,
+	"right":         true,
+	"full":          true,
+	CrossJoinMethod: true,
 }
 
 // JoinOpSpec specifies a particular join operation
@@ -40,6 +58,31 @@ type JoinOpSpec struct {
 	TableNames map[flux.OperationID]string `json:"tableNames"`
 	On         []string                    `json:"on"`
 	Method     string                      `json:"method"`
+	// Suffixes are appended to the names of the columns that are
+	// in both tables and are not joined on, in the order of the
+	// table names. They default to "_" followed by the table name.
+	Suffixes []string `json:"suffixes"`
+	// AllowMissing disables the check that the first table
+	// from each input has all of the on columns.
+	AllowMissing bool `json:"allowMissing"`
+	// BufferSize is the number of rows of each table that
+	// the join retains. It is unlimited when zero or less.
+	BufferSize int `json:"bufferSize"`
+	// OnOverflow is the policy applied when a table
+	// has more than BufferSize rows.
+	OnOverflow string `json:"onOverflow"`
+	// OnDuplicate is the policy applied when a table has more
+	// than one row with the same values in the on columns.
+	OnDuplicate string `json:"onDuplicate"`
+	// OnNullKey is the policy applied to rows
+	// with a null value in an on column.
+	OnNullKey string `json:"onNullKey"`
+	// CrossRowLimit is the maximum number of rows that a cross
+	// join may output for a pair of tables.
+	CrossRowLimit int `json:"crossRowLimit"`
+	// MissingColumnType is the type of an on column that is
+	// missing from the tables of an outer join.
+	MissingColumnType string `json:"missingColumnType"`
 
 	// Note: this field below is non-exported and is not part of the public Flux.Spec
 	// interface (used by the transpiler).  It should not be assumed to be populated
@@ -77,8 +120,28 @@ func (params *joinParams) Less(i, j int) bool {
 func createJoinOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
 	spec := new(JoinOpSpec)
 
-	// On specifies the columns to join on, and is required.
-	if array, err := args.GetRequiredArray("on", semantic.String); err != nil {
+	// Method is an optional parameter that when not specified defaults to
+	// the inner join type.
+	if joinType, ok, err := args.GetString("method"); err != nil {
+		return nil, err
+	} else if ok && !methods[joinType] {
+		return nil, errors.Newf(codes.Invalid, "%s is not a valid join type", joinType)
+	} else if ok && methods[joinType] {
+		spec.Method = joinType
+	} else {
+		spec.Method = "inner"
+	}
+
+	// On specifies the columns to join on, and is required
+	// unless the method is cross. It is not valid to specify
+	// a list of 'on' columns for a cross product.
+	if spec.Method == CrossJoinMethod {
+		if array, ok, err := args.GetArrayAllowEmpty("on", semantic.String); err != nil {
+			return nil, err
+		} else if ok && array.Len() > 0 {
+			return nil, errors.New(codes.Invalid, "cross product and 'on' are mutually exclusive")
+		}
+	} else if array, err := args.GetRequiredArray("on", semantic.String); err != nil {
 		return nil, err
 	} else if array.Len() == 0 {
 		return nil, errors.New(codes.Invalid, "at least one column in 'on' column list is required")
@@ -89,21 +152,58 @@ func createJoinOpSpec(args flux.Arguments, a *flux.Administration) (flux.Operati
 		}
 	}
 
-	// Method is an optional parameter that when not specified defaults to
-	// the inner join type.
-	if joinType, ok, err := args.GetString("method"); err != nil {
+	if array, ok, err := args.GetArray("suffixes", semantic.String); err != nil {
 		return nil, err
-	} else if ok && !methods[joinType] {
-		return nil, errors.Newf(codes.Invalid, "%s is not a valid join type", joinType)
-	} else if ok && methods[joinType] {
-		spec.Method = joinType
-	} else {
-		spec.Method = "inner"
+	} else if ok {
+		spec.Suffixes, err = interpreter.ToStringArray(array)
+		if err != nil {
+			return nil, err
+		}
+		if err := validateSuffixes(spec.Suffixes); err != nil {
+			return nil, err
+		}
+	}
+
+	if allowMissing, ok, err := args.GetBool("allowMissing"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.AllowMissing = allowMissing
+	}
+
+	if bufferSize, ok, err := args.GetInt("bufferSize"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.BufferSize = int(bufferSize)
+	}
+
+	if onOverflow, ok, err := args.GetString("onOverflow"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.OnOverflow = onOverflow
+	}
+
+	if onDuplicate, ok, err := args.GetString("onDuplicate"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.OnDuplicate = onDuplicate
+	}
+
+	if onNullKey, ok, err := args.GetString("onNullKey"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.OnNullKey = onNullKey
+	}
+
+	if crossRowLimit, ok, err := args.GetInt("crossRowLimit"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.CrossRowLimit = int(crossRowLimit)
 	}
 
-	// It is not valid to specify a list of 'on' columns for a cross product
-	if spec.Method == "cross" && spec.On != nil {
-		return nil, errors.New(codes.Invalid, "cross product and 'on' are mutually exclusive")
+	if missingColumnType, ok, err := args.GetString("missingColumnType"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.MissingColumnType = missingColumnType
 	}
 
 	tables, err := args.GetRequiredObject("tables")
@@ -144,6 +244,21 @@ func createJoinOpSpec(args flux.Arguments, a *flux.Administration) (flux.Operati
 	return spec, nil
 }
 
+// validateSuffixes checks that there are two suffixes
+// that are distinct and not empty.
+func validateSuffixes(suffixes []string) error {
+	if len(suffixes) != 2 {
+		return errors.Newf(codes.Invalid, "join suffixes must have 2 elements, got %d", len(suffixes))
+	}
+	if suffixes[0] == "" || suffixes[1] == "" {
+		return errors.New(codes.Invalid, "join suffixes must not be empty")
+	}
+	if suffixes[0] == suffixes[1] {
+		return errors.Newf(codes.Invalid, "join suffixes must be distinct, got %q twice", suffixes[0])
+	}
+	return nil
+}
+
 func (t *JoinOpSpec) IDer(ider flux.IDer) {
 	for i, name := range t.params.names {
 		operation := t.params.operations[i]
@@ -159,10 +274,112 @@ func (s *JoinOpSpec) Kind() flux.OperationKind {
 	return JoinKind
 }
 
+// The strategies that may be used to execute a join.
+const (
+	// MergeJoinStrategy sorts the tables from both inputs
+	// by the on columns and merges them.
+	MergeJoinStrategy = "merge"
+	// HashJoinStrategy builds a hash table from the rows of
+	// the smaller table and probes it with the rows of the
+	// other table so the inputs do not need to be sorted.
+	HashJoinStrategy = "hash"
+)
+
+// The policies applied when a table has more rows than the join buffer size.
+const (
+	// ErrorOnOverflow fails the join when a table
+	// has more rows than the buffer size.
+	ErrorOnOverflow = "error"
+	// EvictOldestOnOverflow drops the oldest rows of a table
+	// so only the last buffer size rows are joined.
+	EvictOldestOnOverflow = "evict-oldest"
+)
+
+// The policies applied when a table has more than one
+// row with the same values in the on columns.
+const (
+	// ExpandOnDuplicate joins every duplicate row with
+	// every matching row of the other table.
+	ExpandOnDuplicate = "expand"
+	// ErrorOnDuplicate fails the join when
+	// a table has duplicate rows.
+	ErrorOnDuplicate = "error"
+	// KeepFirstOnDuplicate joins only the first
+	// of the duplicate rows of a table.
+	KeepFirstOnDuplicate = "keep-first"
+	// KeepLastOnDuplicate joins only the last
+	// of the duplicate rows of a table.
+	KeepLastOnDuplicate = "keep-last"
+)
+
+// The policies applied to rows with a null value in an on column.
+const (
+	// SkipOnNullKey does not join rows with a null value
+	// because a null value is not equal to any value.
+	SkipOnNullKey = "skip"
+	// ErrorOnNullKey fails the join when a row
+	// has a null value.
+	ErrorOnNullKey = "error"
+	// TreatAsEqualOnNullKey joins rows with a null value
+	// with the rows from the other table with a null value
+	// in the same column, as if null values were equal.
+	TreatAsEqualOnNullKey = "treat-as-equal"
+)
+
 type MergeJoinProcedureSpec struct {
 	plan.DefaultCost
 	TableNames []string `json:"table_names"`
 	On         []string `json:"keys"`
+	// Sorted is set when the rows of the tables from both inputs
+	// are known to be sorted by the on columns so the join does
+	// not need to sort them.
+	Sorted bool `json:"sorted"`
+	// Strategy is the strategy used to execute the join.
+	// It is either "merge" or "hash" and defaults to "merge".
+	// A merge join is used when the inputs are sorted.
+	Strategy string `json:"strategy"`
+	// BufferSize is the number of rows of each table that
+	// the join retains. The number of rows is unlimited
+	// when it is zero or less.
+	BufferSize int `json:"buffer_size"`
+	// OnOverflow is the policy applied when a table has more
+	// than BufferSize rows. It is either "error" or "evict-oldest"
+	// and defaults to "error".
+	OnOverflow string `json:"on_overflow"`
+	// Method is the join method. It is "inner", "left", "right",
+	// "full" or "cross" and defaults to "inner". The outer methods
+	// also output the rows of the left, right or both inputs that do
+	// not match a row of the other input, with nulls in the columns of
+	// the other input. The cross method has no on columns and joins
+	// every row of a table with every row of the other table.
+	Method string `json:"method"`
+	// CrossRowLimit is the maximum number of rows that a cross join
+	// may output for a pair of tables. It defaults to
+	// DefaultCrossRowLimit when zero and is unlimited when negative.
+	CrossRowLimit int `json:"cross_row_limit"`
+	// OnDuplicate is the policy applied when a table has more
+	// than one row with the same values in the on columns. It is
+	// "expand", "error", "keep-first" or "keep-last" and defaults
+	// to "expand".
+	OnDuplicate string `json:"on_duplicate"`
+	// OnNullKey is the policy applied to rows with a null value
+	// in an on column. It is "skip", "error" or "treat-as-equal"
+	// and defaults to "skip".
+	OnNullKey string `json:"on_null_key"`
+	// Suffixes are appended to the names of the columns that are
+	// in both tables and are not joined on, in the order of the
+	// table names. They default to "_" followed by the table name.
+	Suffixes []string `json:"suffixes"`
+	// AllowMissing is set when the first table from an input may
+	// be missing some of the on columns. Otherwise, the join fails
+	// because the on columns are likely misspelled.
+	AllowMissing bool `json:"allow_missing"`
+	// MissingColumnType is the type of an on column that is missing
+	// from a table of a preserved input and from every table of the
+	// other input, so the type of its null values cannot be observed.
+	// It is "bool", "int", "uint", "float", "string" or "time" and
+	// defaults to "string".
+	MissingColumnType string `json:"missing_column_type"`
 }
 
 func newMergeJoinProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.ProcedureSpec, error) {
@@ -187,8 +404,17 @@ func newMergeJoinProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.
 	sort.Strings(on)
 
 	return &MergeJoinProcedureSpec{
-		On:         on,
-		TableNames: tableNames,
+		On:                on,
+		TableNames:        tableNames,
+		Method:            spec.Method,
+		Suffixes:          spec.Suffixes,
+		AllowMissing:      spec.AllowMissing,
+		BufferSize:        spec.BufferSize,
+		OnOverflow:        spec.OnOverflow,
+		OnDuplicate:       spec.OnDuplicate,
+		OnNullKey:         spec.OnNullKey,
+		CrossRowLimit:     spec.CrossRowLimit,
+		MissingColumnType: spec.MissingColumnType,
 	}, nil
 }
 
@@ -198,12 +424,44 @@ func (s *MergeJoinProcedureSpec) Kind() plan.ProcedureKind {
 func (s *MergeJoinProcedureSpec) Copy() plan.ProcedureSpec {
 	ns := new(MergeJoinProcedureSpec)
 
+	ns.TableNames = make([]string, len(s.TableNames))
+	copy(ns.TableNames, s.TableNames)
+
 	ns.On = make([]string, len(s.On))
 	copy(ns.On, s.On)
+	ns.Sorted = s.Sorted
+	ns.Strategy = s.Strategy
+	ns.BufferSize = s.BufferSize
+	ns.OnOverflow = s.OnOverflow
+	ns.Method = s.Method
+	ns.CrossRowLimit = s.CrossRowLimit
+	ns.OnDuplicate = s.OnDuplicate
+	ns.OnNullKey = s.OnNullKey
+	ns.AllowMissing = s.AllowMissing
+	ns.MissingColumnType = s.MissingColumnType
+	if s.Suffixes != nil {
+		ns.Suffixes = make([]string, len(s.Suffixes))
+		copy(ns.Suffixes, s.Suffixes)
+	}
 
 	return ns
 }
 
+// Validate checks that the join has on columns unless
+// it is a cross join, which must not have any.
+func (s *MergeJoinProcedureSpec) Validate() error {
+	if s.Method == CrossJoinMethod {
+		if len(s.On) > 0 {
+			return errors.New(codes.Invalid, "cross product and 'on' are mutually exclusive")
+		}
+		return nil
+	}
+	if len(s.On) == 0 {
+		return errors.New(codes.Invalid, "at least one column in 'on' column list is required")
+	}
+	return nil
+}
+
 func createMergeJoinTransformation(id execute.DatasetID, mode execute.AccumulationMode, spec plan.ProcedureSpec, a execute.Administration) (execute.Transformation, execute.Dataset, error) {
 	s, ok := spec.(*MergeJoinProcedureSpec)
 	if !ok {
@@ -220,8 +478,35 @@ func createMergeJoinTransformation(id execute.DatasetID, mode execute.Accumulati
 		tableNames[parents[i]] = name
 	}
 
-	cache := NewMergeJoinCache(a.Allocator(), parents, tableNames, s.On)
+	cache := NewMergeJoinCache(a.Allocator(), parents, tableNames, s.On, s.BufferSize)
+	if err := cache.SetOnOverflow(s.OnOverflow); err != nil {
+		return nil, nil, err
+	}
+	if err := cache.SetOnDuplicate(s.OnDuplicate); err != nil {
+		return nil, nil, err
+	}
+	if err := cache.SetOnNullKey(s.OnNullKey); err != nil {
+		return nil, nil, err
+	}
+	if err := cache.SetSuffixes(s.Suffixes); err != nil {
+		return nil, nil, err
+	}
+	if err := cache.SetMissingColumnType(s.MissingColumnType); err != nil {
+		return nil, nil, err
+	}
+	cache.SetCrossRowLimit(s.CrossRowLimit)
 	d := execute.NewDataset(id, mode, cache)
+	switch s.Strategy {
+	case "", MergeJoinStrategy:
+	case HashJoinStrategy:
+		// There is nothing to gain from a hash join
+		// when the inputs do not need to be sorted.
+		if !s.Sorted {
+			return NewHashJoinTransformation(d, cache, s, parents, tableNames), d, nil
+		}
+	default:
+		return nil, nil, errors.Newf(codes.Invalid, "invalid join strategy %q, must be %q or %q", s.Strategy, MergeJoinStrategy, HashJoinStrategy)
+	}
 	t := NewMergeJoinTransformation(d, cache, s, parents, tableNames)
 	return t, d, nil
 }
@@ -239,30 +524,50 @@ type mergeJoinTransformation struct {
 	parentState map[execute.DatasetID]*mergeJoinParentState
 	err         error
 
-	keys []string
+	keys         []string
+	allowMissing bool
 }
 
 func NewMergeJoinTransformation(d execute.Dataset, cache *MergeJoinCache, spec *MergeJoinProcedureSpec, parents []execute.DatasetID, tableNames map[execute.DatasetID]string) *mergeJoinTransformation {
 	t := &mergeJoinTransformation{
-		d:         d,
-		cache:     cache,
-		keys:      spec.On,
-		leftID:    parents[0],
-		rightID:   parents[1],
-		leftName:  tableNames[parents[0]],
-		rightName: tableNames[parents[1]],
+		d:            d,
+		cache:        cache,
+		keys:         spec.On,
+		allowMissing: spec.AllowMissing,
+		leftID:       parents[0],
+		rightID:      parents[1],
+		leftName:     tableNames[parents[0]],
+		rightName:    tableNames[parents[1]],
 	}
 	t.parentState = make(map[execute.DatasetID]*mergeJoinParentState)
 	for _, id := range parents {
 		t.parentState[id] = new(mergeJoinParentState)
 	}
+	cache.sorted = spec.Sorted
+	cache.method = spec.Method
 	return t
 }
 
+// hashJoinTransformation buffers the tables from its inputs in the
+// same way as a merge join, but joins them with a hash table so the
+// rows do not need to be sorted.
+type hashJoinTransformation struct {
+	*mergeJoinTransformation
+}
+
+func NewHashJoinTransformation(d execute.Dataset, cache *MergeJoinCache, spec *MergeJoinProcedureSpec, parents []execute.DatasetID, tableNames map[execute.DatasetID]string) *hashJoinTransformation {
+	t := NewMergeJoinTransformation(d, cache, spec, parents, tableNames)
+	cache.hash = true
+	return &hashJoinTransformation{mergeJoinTransformation: t}
+}
+
 type mergeJoinParentState struct {
 	mark       execute.Time
 	processing execute.Time
 	finished   bool
+	// checked is set once the on columns
+	// of the first table have been checked.
+	checked bool
 }
 
 func (t *mergeJoinTransformation) RetractTable(id execute.DatasetID, key flux.GroupKey) error {
@@ -276,16 +581,29 @@ func (t *mergeJoinTransformation) Process(id execute.DatasetID, tbl flux.Table)
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
+	// The first table from each parent must have all of the "on" columns
+	// so that a misspelled column fails the join instead of every table
+	// being discarded below.
+	if state := t.parentState[id]; !state.checked {
+		state.checked = true
+		if err := t.checkOnColumns(id, tbl); err != nil {
+			tbl.Done()
+			return err
+		}
+	}
+
 	// If a table is missing any of the "on" columns, then it won't be part of the output:
 	//   - A missing column is treated as a null value
 	//   - Null values are not considered as equal to each other in joins
+	// The outer join methods still output the rows of a table from a
+	// preserved stream, with null values in the missing columns.
 	numOnCols := 0
 	for _, c := range tbl.Cols() {
 		if t.cache.on[c.Label] {
 			numOnCols++
 		}
 	}
-	if numOnCols < len(t.cache.on) {
+	if numOnCols < len(t.cache.on) && !t.cache.preserves(id) {
 		// Discard this table
 		tbl.Done()
 		return nil
@@ -297,7 +615,9 @@ func (t *mergeJoinTransformation) Process(id execute.DatasetID, tbl flux.Table)
 
 	// Check if enough data sources have been seen to produce an output schema
 	if !t.cache.isBufferEmpty(t.leftID) && !t.cache.isBufferEmpty(t.rightID) && !t.cache.postJoinSchemaBuilt() {
-		t.cache.buildPostJoinSchema(t.cache.schemas[t.cache.leftID].columns, t.cache.schemas[t.cache.rightID].columns)
+		if err := t.cache.buildPostJoinSchema(t.cache.schemas[t.cache.leftID].columns, t.cache.schemas[t.cache.rightID].columns); err != nil {
+			return err
+		}
 	}
 
 	// Register any new output group keys that can be constructed from the new table
@@ -305,6 +625,30 @@ func (t *mergeJoinTransformation) Process(id execute.DatasetID, tbl flux.Table)
 	return nil
 }
 
+// checkOnColumns checks that a table from the parent with the id
+// has all of the on columns unless missing columns are allowed.
+func (t *mergeJoinTransformation) checkOnColumns(id execute.DatasetID, tbl flux.Table) error {
+	if t.allowMissing {
+		return nil
+	}
+	cols := tbl.Cols()
+	for _, label := range t.keys {
+		if execute.ColIdx(label, cols) >= 0 {
+			continue
+		}
+		name := t.leftName
+		if id == t.rightID {
+			name = t.rightName
+		}
+		labels := make([]string, len(cols))
+		for j, col := range cols {
+			labels[j] = col.Label
+		}
+		return errors.Newf(codes.Invalid, "join column %q is missing from table %q, available columns: %s", label, name, strings.Join(labels, ", "))
+	}
+	return nil
+}
+
 func (t *mergeJoinTransformation) UpdateWatermark(id execute.DatasetID, mark execute.Time) error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -317,6 +661,9 @@ func (t *mergeJoinTransformation) UpdateWatermark(id execute.DatasetID, mark exe
 		}
 	}
 
+	// The cache flushes the tables that are complete at the new
+	// watermark when the dataset evaluates its triggers.
+	t.cache.setWatermark(min)
 	return t.d.UpdateWatermark(min)
 }
 
@@ -339,6 +686,12 @@ func (t *mergeJoinTransformation) Finish(id execute.DatasetID, err error) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
+	// Ignore repeated finish calls from a parent that has
+	// already finished so the dataset is only finished once.
+	if state, ok := t.parentState[id]; !ok || state.finished {
+		return
+	}
+
 	// Only report the first error that occurs.
 	if t.err == nil && err != nil {
 		t.err = err
@@ -351,6 +704,9 @@ func (t *mergeJoinTransformation) Finish(id execute.DatasetID, err error) {
 	}
 
 	if finished {
+		if t.err == nil {
+			t.err = t.cache.registerUnpairedKeys()
+		}
 		t.d.Finish(t.err)
 	}
 }
@@ -372,7 +728,13 @@ func (t *mergeJoinTransformation) Finish(id execute.DatasetID, err error) {
 //
 // tables:          All output tables are materialized and stored in this
 //                  map before being sent to downstream operators.
+//
+// stats:           Counters that describe the work done by the join.
+//                  They are the first field so that they are 64-bit
+//                  aligned for atomic operations.
 type MergeJoinCache struct {
+	stats MergeJoinCacheStats
+
 	leftID  execute.DatasetID
 	rightID execute.DatasetID
 
@@ -380,10 +742,42 @@ type MergeJoinCache struct {
 	schemas map[execute.DatasetID]schema
 	buffers map[execute.DatasetID]*streamBuffer
 
+	// suffixes are appended to the names of the columns
+	// from each stream that are shared but not joined on.
+	suffixes map[execute.DatasetID]string
+
 	on           map[string]bool
 	order        []string
 	intersection map[string]bool
 
+	// sorted is set when the input tables are already
+	// sorted by the columns in order.
+	sorted bool
+	// hash is set when the tables are joined with
+	// a hash table instead of being sorted and merged.
+	hash bool
+	// method is the join method. An empty
+	// method is the same as "inner".
+	method string
+	// onDuplicate is the policy applied to the rows
+	// of a table with the same values in the on columns.
+	onDuplicate string
+	// onNullKey is the policy applied to the rows
+	// with a null value in an on column.
+	onNullKey string
+	// crossRowLimit is the maximum number of rows that a cross
+	// join outputs for a pair of tables. It is unlimited when
+	// zero or less.
+	crossRowLimit int
+	// missingColumnType is the type of an on column that is
+	// missing from an unpaired table and from every table of
+	// the other stream.
+	missingColumnType flux.ColType
+
+	// observed holds each distinct column of
+	// the tables buffered from each stream.
+	observed map[execute.DatasetID][]flux.ColMeta
+
 	schema    schema
 	colIndex  map[flux.ColMeta]int
 	schemaMap map[tableCol]flux.ColMeta
@@ -394,6 +788,27 @@ type MergeJoinCache struct {
 	tables      map[flux.GroupKey]flux.Table
 	alloc       memory.Allocator
 	triggerSpec plan.TriggerSpec
+
+	// watermark is the smallest watermark of the two streams.
+	// An output table is complete once the watermark is past
+	// the time of every row of the tables it is joined from.
+	watermark execute.Time
+}
+
+// MergeJoinCacheStats counts the rows and tables handled by a MergeJoinCache.
+type MergeJoinCacheStats struct {
+	// RowsBuffered is the number of rows that were added to the
+	// buffers, including the rows that were later evicted.
+	RowsBuffered int64
+	// RowsEvicted is the number of rows that were removed from the
+	// buffers, either because a table exceeded the buffer size or
+	// because a table was no longer needed after it was joined.
+	RowsEvicted int64
+	// TablePairsJoined is the number of pairs of tables that were joined.
+	TablePairsJoined int64
+	// EmptyMatches is the number of pairs of tables that were joined
+	// without any matching rows.
+	EmptyMatches int64
 }
 
 type streamBuffer struct {
@@ -403,15 +818,50 @@ type streamBuffer struct {
 	stale    map[flux.GroupKey]bool
 	last     values.Value
 	alloc    memory.Allocator
+
+	// name is the name of the stream in the join.
+	name string
+
+	// bytes is the size of the string values of each buffered
+	// table. The table builders only account for the string
+	// headers so the values are accounted separately.
+	bytes map[flux.GroupKey]int
+
+	// paired records the tables that were paired
+	// with a table from the other stream.
+	paired map[flux.GroupKey]bool
+
+	// maxTime is the largest time of each buffered
+	// table with a time column.
+	maxTime map[flux.GroupKey]values.Time
+
+	// refs counts the output tables that were registered
+	// for each buffered table and have not been expired.
+	refs map[flux.GroupKey]int
+
+	// size is the maximum number of rows retained
+	// for each table. It is unlimited when zero or less.
+	size       int
+	onOverflow string
+
+	stats *MergeJoinCacheStats
 }
 
-func newStreamBuffer(alloc memory.Allocator) *streamBuffer {
+func newStreamBuffer(alloc memory.Allocator, name string, size int, stats *MergeJoinCacheStats) *streamBuffer {
 	return &streamBuffer{
-		data:     make(map[flux.GroupKey]*execute.ColListTableBuilder),
-		consumed: make(map[values.Value]int),
-		ready:    make(map[values.Value]bool),
-		stale:    make(map[flux.GroupKey]bool),
-		alloc:    alloc,
+		data:       make(map[flux.GroupKey]*execute.ColListTableBuilder),
+		bytes:      make(map[flux.GroupKey]int),
+		consumed:   make(map[values.Value]int),
+		ready:      make(map[values.Value]bool),
+		stale:      make(map[flux.GroupKey]bool),
+		paired:     make(map[flux.GroupKey]bool),
+		maxTime:    make(map[flux.GroupKey]values.Time),
+		refs:       make(map[flux.GroupKey]int),
+		alloc:      alloc,
+		name:       name,
+		size:       size,
+		onOverflow: ErrorOnOverflow,
+		stats:      stats,
 	}
 }
 
@@ -422,19 +872,50 @@ func (buf *streamBuffer) table(key flux.GroupKey) *execute.ColListTableBuilder {
 func (buf *streamBuffer) insert(table flux.Table) error {
 	// Construct a new table builder with same schema as input table
 	builder := execute.NewColListTableBuilder(table.Key(), buf.alloc)
-	// this will only error if we try to add a duplicate column to the builder.
-	// since this is a new table, that won't happen.
-	if err := execute.AddTableCols(table, builder); err != nil {
+	if err := buf.catchLimit(table.Key(), func() error {
+		// this will only error if we try to add a duplicate column to the builder.
+		// since this is a new table, that won't happen.
+		if err := execute.AddTableCols(table, builder); err != nil {
+			return err
+		}
+
+		// Append the input table to this builder, safe to ignore errors
+		return execute.AppendTable(table, builder)
+	}); err != nil {
+		builder.Release()
 		return err
 	}
+	atomic.AddInt64(&buf.stats.RowsBuffered, int64(builder.NRows()))
+
+	if buf.size > 0 && builder.NRows() > buf.size {
+		if err := buf.catchLimit(table.Key(), func() (err error) {
+			builder, err = buf.overflow(builder)
+			return err
+		}); err != nil {
+			return err
+		}
+	}
 
-	// Append the input table to this builder, safe to ignore errors
-	if err := execute.AppendTable(table, builder); err != nil {
-		return err
+	// Account for the string values held by the builder.
+	n := 0
+	for j, col := range builder.Cols() {
+		if col.Type == flux.TString {
+			for _, v := range builder.Strings(j) {
+				n += len(v)
+			}
+		}
+	}
+	if err := buf.alloc.Account(n); err != nil {
+		builder.Release()
+		return buf.limitError(table.Key(), err)
 	}
 
 	// Insert this table into the buffer
 	buf.data[table.Key()] = builder
+	buf.bytes[table.Key()] = n
+	if t, ok := builderMaxTime(builder); ok {
+		buf.maxTime[table.Key()] = t
+	}
 
 	if len(table.Key().Cols()) > 0 {
 		leftKeyValue := table.Key().Value(0)
@@ -454,8 +935,94 @@ func (buf *streamBuffer) insert(table flux.Table) error {
 	return nil
 }
 
+// catchLimit calls f and returns an error if the allocator refuses
+// memory while f runs. The table builders panic when that happens.
+func (buf *streamBuffer) catchLimit(key flux.GroupKey, f func() error) (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			perr, ok := e.(error)
+			if !ok || errors.Code(perr) != codes.ResourceExhausted {
+				panic(e)
+			}
+			err = buf.limitError(key, perr)
+		}
+	}()
+	return f()
+}
+
+// limitError names the join and the stream in an error
+// returned when the allocator refuses to buffer a table.
+func (buf *streamBuffer) limitError(key flux.GroupKey, err error) error {
+	return errors.Wrapf(err, codes.ResourceExhausted, "join failed to buffer table %v of %q", key, buf.name)
+}
+
+// overflow applies the overflow policy to a table with more rows
+// than the buffer size. With the evict-oldest policy, it returns
+// a builder with only the last rows of the table.
+func (buf *streamBuffer) overflow(builder *execute.ColListTableBuilder) (*execute.ColListTableBuilder, error) {
+	defer builder.Release()
+	if buf.onOverflow != EvictOldestOnOverflow {
+		return nil, errors.Newf(codes.ResourceExhausted, "join buffer for table %v exceeded the limit of %d rows", builder.Key(), buf.size)
+	}
+
+	tbl, err := builder.Table()
+	if err != nil {
+		return nil, err
+	}
+	kept := execute.NewColListTableBuilder(builder.Key(), buf.alloc)
+	if err := execute.AddTableCols(tbl, kept); err != nil {
+		return nil, err
+	}
+
+	// Skip the rows before the last size rows of the table.
+	skip := builder.NRows() - buf.size
+	if err := tbl.Do(func(cr flux.ColReader) error {
+		start := skip
+		if start > cr.Len() {
+			start = cr.Len()
+		}
+		skip -= start
+		return appendSlicedCols(cr, kept, start, cr.Len())
+	}); err != nil {
+		kept.Release()
+		return nil, err
+	}
+	atomic.AddInt64(&buf.stats.RowsEvicted, int64(builder.NRows()-buf.size))
+	return kept, nil
+}
+
+// builderMaxTime returns the largest value in the time column of
+// the builder. Null times are stored as zero, so they can only
+// delay the point at which a table is considered complete.
+func builderMaxTime(builder *execute.ColListTableBuilder) (values.Time, bool) {
+	j := execute.ColIdx(execute.DefaultTimeColLabel, builder.Cols())
+	if j < 0 || builder.Cols()[j].Type != flux.TTime || builder.NRows() == 0 {
+		return 0, false
+	}
+	times := builder.Times(j)[:builder.NRows()]
+	max := times[0]
+	for _, t := range times[1:] {
+		if t > max {
+			max = t
+		}
+	}
+	return max, true
+}
+
+// complete reports whether the table with the key will not be joined
+// with rows that arrive after the watermark has reached mark. A nil key
+// is the missing side of an unpaired table and is always complete.
+func (buf *streamBuffer) complete(key flux.GroupKey, mark execute.Time) bool {
+	if key == nil {
+		return true
+	}
+	t, ok := buf.maxTime[key]
+	return ok && mark > t
+}
+
 func (buf *streamBuffer) expire(key flux.GroupKey) {
-	if !buf.stale[key] && len(key.Cols()) > 0 {
+	// The key is nil for the missing side of an unpaired table.
+	if key != nil && !buf.stale[key] && len(key.Cols()) > 0 {
 		leftKeyValue := key.Value(0)
 		consumedTables := buf.consumed[leftKeyValue]
 		buf.consumed[leftKeyValue] = consumedTables - 1
@@ -465,9 +1032,25 @@ func (buf *streamBuffer) expire(key flux.GroupKey) {
 
 func (buf *streamBuffer) evict(key flux.GroupKey) {
 	if builder, ok := buf.data[key]; ok {
-		builder.ClearData()
+		atomic.AddInt64(&buf.stats.RowsEvicted, int64(builder.NRows()))
+		builder.Release()
+		_ = buf.alloc.Account(-buf.bytes[key])
 		delete(buf.data, key)
+		delete(buf.bytes, key)
+		delete(buf.maxTime, key)
+		delete(buf.refs, key)
+	}
+}
+
+// evictComplete evicts the table with the key if it is complete
+// at mark and every output table that uses it was expired.
+func (buf *streamBuffer) evictComplete(key flux.GroupKey, mark execute.Time) {
+	if key == nil || buf.refs[key] > 0 || !buf.complete(key, mark) {
+		return
 	}
+	buf.expire(key)
+	buf.evict(key)
+	delete(buf.stale, key)
 }
 
 func (buf *streamBuffer) clear(f func(flux.GroupKey) bool) {
@@ -510,8 +1093,10 @@ func (s schema) Swap(i int, j int) {
 	s.columns[i], s.columns[j] = s.columns[j], s.columns[i]
 }
 
-// NewMergeJoinCache constructs a new instance of a MergeJoinCache
-func NewMergeJoinCache(alloc memory.Allocator, datasetIDs []execute.DatasetID, tableNames map[execute.DatasetID]string, key []string) *MergeJoinCache {
+// NewMergeJoinCache constructs a new instance of a MergeJoinCache.
+// Each table retains at most bufferSize rows, or every row
+// if bufferSize is zero or less.
+func NewMergeJoinCache(alloc memory.Allocator, datasetIDs []execute.DatasetID, tableNames map[execute.DatasetID]string, key []string, bufferSize int) *MergeJoinCache {
 	// Join currently only accepts two data sources(streams) as input
 	if len(datasetIDs) != 2 {
 		panic("Join only accepts two data sources")
@@ -521,9 +1106,26 @@ func NewMergeJoinCache(alloc memory.Allocator, datasetIDs []execute.DatasetID, t
 	schemas := make(map[execute.DatasetID]schema, len(datasetIDs))
 	buffers := make(map[execute.DatasetID]*streamBuffer, len(datasetIDs))
 
+	c := &MergeJoinCache{
+		leftID:        datasetIDs[0],
+		rightID:       datasetIDs[1],
+		names:         names,
+		schemas:       schemas,
+		buffers:       buffers,
+		suffixes:      make(map[execute.DatasetID]string, len(datasetIDs)),
+		reverseLookup: make(map[flux.GroupKey]preJoinGroupKeys),
+		postJoinKeys:  execute.NewGroupLookup(),
+		tables:        make(map[flux.GroupKey]flux.Table),
+		alloc:         alloc,
+		crossRowLimit: DefaultCrossRowLimit,
+
+		missingColumnType: flux.TString,
+		observed:          make(map[execute.DatasetID][]flux.ColMeta, len(datasetIDs)),
+	}
 	for _, datasetID := range datasetIDs {
 		names[datasetID] = tableNames[datasetID]
-		buffers[datasetID] = newStreamBuffer(alloc)
+		c.suffixes[datasetID] = "_" + tableNames[datasetID]
+		buffers[datasetID] = newStreamBuffer(alloc, tableNames[datasetID], bufferSize, &c.stats)
 	}
 
 	on := make(map[string]bool, len(key))
@@ -534,20 +1136,10 @@ func NewMergeJoinCache(alloc memory.Allocator, datasetIDs []execute.DatasetID, t
 		intersection[k] = true
 	}
 
-	return &MergeJoinCache{
-		on:            on,
-		order:         key,
-		intersection:  intersection,
-		leftID:        datasetIDs[0],
-		rightID:       datasetIDs[1],
-		names:         names,
-		schemas:       schemas,
-		buffers:       buffers,
-		reverseLookup: make(map[flux.GroupKey]preJoinGroupKeys),
-		postJoinKeys:  execute.NewGroupLookup(),
-		tables:        make(map[flux.GroupKey]flux.Table),
-		alloc:         alloc,
-	}
+	c.on = on
+	c.order = key
+	c.intersection = intersection
+	return c
 }
 
 // Table joins the two tables associated with a single output group key and returns the resulting table
@@ -561,12 +1153,12 @@ func (c *MergeJoinCache) Table(key flux.GroupKey) (flux.Table, error) {
 	if _, ok := c.tables[key]; !ok {
 
 		left := c.buffers[c.leftID].table(preJoinGroupKeys.left)
-		if left == nil {
+		if left == nil && preJoinGroupKeys.left != nil {
 			return nil, errors.Newf(codes.FailedPrecondition, "no table in left join buffer with key: %v", key)
 		}
 
 		right := c.buffers[c.rightID].table(preJoinGroupKeys.right)
-		if right == nil {
+		if right == nil && preJoinGroupKeys.right != nil {
 			return nil, errors.Newf(codes.FailedPrecondition, "no table in right join buffer with key: %v", key)
 		}
 
@@ -608,6 +1200,9 @@ func (c *MergeJoinCache) ForEach(f func(flux.GroupKey) error) error {
 // ForEachWithContext iterates over each table in the output stream
 func (c *MergeJoinCache) ForEachWithContext(f func(flux.GroupKey, execute.Trigger, execute.TableContext) error) error {
 	trigger := execute.NewTriggerFromSpec(c.triggerSpec)
+	// Complete tables are sent downstream right away
+	// regardless of the trigger of the cache.
+	complete := execute.NewTriggerFromSpec(plan.NarrowTransformationTriggerSpec{})
 
 	return c.postJoinKeys.Range(func(key flux.GroupKey, value interface{}) error {
 		preJoinGroupKeys := c.reverseLookup[key]
@@ -630,14 +1225,22 @@ func (c *MergeJoinCache) ForEachWithContext(f func(flux.GroupKey, execute.Trigge
 			c.tables[key] = table
 		}
 
-		leftsize := leftBuilder.NRows()
-		rightsize := rightBuilder.NRows()
+		var leftsize, rightsize int
+		if leftBuilder != nil {
+			leftsize = leftBuilder.NRows()
+		}
+		if rightBuilder != nil {
+			rightsize = rightBuilder.NRows()
+		}
 
 		ctx := execute.TableContext{
 			Key:   key,
 			Count: leftsize + rightsize,
 		}
 
+		if c.complete(preJoinGroupKeys) {
+			return f(key, complete, ctx)
+		}
 		return f(key, trigger, ctx)
 	})
 }
@@ -664,6 +1267,17 @@ func (c *MergeJoinCache) ExpireTable(key flux.GroupKey) {
 
 	leftBuffer.expire(preJoinGroupKeys.left)
 	rightBuffer.expire(preJoinGroupKeys.right)
+	if preJoinGroupKeys.left != nil {
+		leftBuffer.refs[preJoinGroupKeys.left]--
+	}
+	if preJoinGroupKeys.right != nil {
+		rightBuffer.refs[preJoinGroupKeys.right]--
+	}
+
+	if c.canEvictCompleteTables() {
+		leftBuffer.evictComplete(preJoinGroupKeys.left, c.watermark)
+		rightBuffer.evictComplete(preJoinGroupKeys.right, c.watermark)
+	}
 
 	if c.canEvictTables() {
 
@@ -679,11 +1293,185 @@ func (c *MergeJoinCache) ExpireTable(key flux.GroupKey) {
 	}
 }
 
+// Stats returns the current values of the counters of the cache.
+// It is safe to call while the join is running.
+func (c *MergeJoinCache) Stats() MergeJoinCacheStats {
+	return MergeJoinCacheStats{
+		RowsBuffered:     atomic.LoadInt64(&c.stats.RowsBuffered),
+		RowsEvicted:      atomic.LoadInt64(&c.stats.RowsEvicted),
+		TablePairsJoined: atomic.LoadInt64(&c.stats.TablePairsJoined),
+		EmptyMatches:     atomic.LoadInt64(&c.stats.EmptyMatches),
+	}
+}
+
+// ForEachBuffered calls f with the group key of each table held in the
+// buffers of the cache and the buffered tables with that key, the table
+// from the left stream before the one from the right. The group keys are
+// visited in sorted order and the tables are only valid until f returns.
+// Like the other methods of the cache, it must not be called concurrently
+// with Process unless the lock of the transformation is held.
+func (c *MergeJoinCache) ForEachBuffered(f func(flux.GroupKey, []flux.Table) error) error {
+	type entry struct {
+		key     flux.GroupKey
+		builder *execute.ColListTableBuilder
+	}
+	var entries []entry
+	for _, id := range []execute.DatasetID{c.leftID, c.rightID} {
+		buf := c.buffers[id]
+		buf.iterate(func(key flux.GroupKey) {
+			entries = append(entries, entry{key: key, builder: buf.table(key)})
+		})
+	}
+	// The left entries come first, so a stable sort
+	// keeps the left table before the right one.
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].key.Less(entries[j].key)
+	})
+
+	for i := 0; i < len(entries); {
+		key := entries[i].key
+		var tables []flux.Table
+		for ; i < len(entries) && entries[i].key.Equal(key); i++ {
+			tbl, err := entries[i].builder.Table()
+			if err != nil {
+				return err
+			}
+			tables = append(tables, tbl)
+		}
+		err := f(key, tables)
+		for _, tbl := range tables {
+			tbl.Done()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TableCount returns the number of tables held
+// in the buffers of both streams of the cache.
+func (c *MergeJoinCache) TableCount() int {
+	return len(c.buffers[c.leftID].data) + len(c.buffers[c.rightID].data)
+}
+
 // SetTriggerSpec sets the trigger rule for this cache
 func (c *MergeJoinCache) SetTriggerSpec(spec plan.TriggerSpec) {
 	c.triggerSpec = spec
 }
 
+// SetOnOverflow sets the policy applied when a table has more rows
+// than the buffer size. An empty policy is the same as "error".
+func (c *MergeJoinCache) SetOnOverflow(policy string) error {
+	switch policy {
+	case "":
+		policy = ErrorOnOverflow
+	case ErrorOnOverflow, EvictOldestOnOverflow:
+	default:
+		return errors.Newf(codes.Invalid, "invalid join overflow policy %q, must be %q or %q", policy, ErrorOnOverflow, EvictOldestOnOverflow)
+	}
+	for _, buf := range c.buffers {
+		buf.onOverflow = policy
+	}
+	return nil
+}
+
+// SetOnDuplicate sets the policy applied when a table has more than
+// one row with the same values in the on columns. An empty policy is
+// the same as "expand".
+func (c *MergeJoinCache) SetOnDuplicate(policy string) error {
+	switch policy {
+	case "":
+		policy = ExpandOnDuplicate
+	case ExpandOnDuplicate, ErrorOnDuplicate, KeepFirstOnDuplicate, KeepLastOnDuplicate:
+	default:
+		return errors.Newf(codes.Invalid, "invalid join duplicate policy %q, must be %q, %q, %q or %q",
+			policy, ExpandOnDuplicate, ErrorOnDuplicate, KeepFirstOnDuplicate, KeepLastOnDuplicate)
+	}
+	c.onDuplicate = policy
+	return nil
+}
+
+// SetOnNullKey sets the policy applied to rows with a null value
+// in an on column. An empty policy is the same as "skip".
+func (c *MergeJoinCache) SetOnNullKey(policy string) error {
+	switch policy {
+	case "":
+		policy = SkipOnNullKey
+	case SkipOnNullKey, ErrorOnNullKey, TreatAsEqualOnNullKey:
+	default:
+		return errors.Newf(codes.Invalid, "invalid join null key policy %q, must be %q, %q or %q",
+			policy, SkipOnNullKey, ErrorOnNullKey, TreatAsEqualOnNullKey)
+	}
+	c.onNullKey = policy
+	return nil
+}
+
+// SetCrossRowLimit sets the maximum number of rows that a cross join
+// may output for a pair of tables. A limit of zero is the same as
+// DefaultCrossRowLimit and a negative limit removes the limit.
+func (c *MergeJoinCache) SetCrossRowLimit(limit int) {
+	if limit == 0 {
+		limit = DefaultCrossRowLimit
+	}
+	c.crossRowLimit = limit
+}
+
+// SetMissingColumnType sets the type of an on column that is missing
+// from a table of a preserved stream and from every table of the
+// other stream. An empty type is the same as "string".
+func (c *MergeJoinCache) SetMissingColumnType(typ string) error {
+	if typ == "" {
+		c.missingColumnType = flux.TString
+		return nil
+	}
+	for _, t := range []flux.ColType{flux.TBool, flux.TInt, flux.TUInt, flux.TFloat, flux.TString, flux.TTime} {
+		if t.String() == typ {
+			c.missingColumnType = t
+			return nil
+		}
+	}
+	return errors.Newf(codes.Invalid, "invalid join missing column type %q, must be %q, %q, %q, %q, %q or %q",
+		typ, flux.TBool, flux.TInt, flux.TUInt, flux.TFloat, flux.TString, flux.TTime)
+}
+
+// nullsEqual reports whether null values in the
+// on columns are equal to each other.
+func (c *MergeJoinCache) nullsEqual() bool {
+	return c.onNullKey == TreatAsEqualOnNullKey
+}
+
+// SetSuffixes sets the suffixes appended to the names of the columns
+// from the left and right streams that are in both streams and are
+// not joined on. The suffixes default to "_" followed by the table
+// name when there are none.
+func (c *MergeJoinCache) SetSuffixes(suffixes []string) error {
+	if len(suffixes) == 0 {
+		return nil
+	}
+	if err := validateSuffixes(suffixes); err != nil {
+		return err
+	}
+	c.suffixes[c.leftID] = suffixes[0]
+	c.suffixes[c.rightID] = suffixes[1]
+	return nil
+}
+
+// preserves reports whether the join outputs the rows from the
+// stream with the id that do not match a row from the other stream.
+func (c *MergeJoinCache) preserves(id execute.DatasetID) bool {
+	switch c.method {
+	case "left":
+		return id == c.leftID
+	case "right":
+		return id == c.rightID
+	case "full":
+		return true
+	default:
+		return false
+	}
+}
+
 // Currently tables are the smallest unit of data that can be evicted from the join's internal
 // buffers. This is the rule that specifies whether a data cache can early evict tables.
 func (c *MergeJoinCache) canEvictTables() bool {
@@ -693,6 +1481,43 @@ func (c *MergeJoinCache) canEvictTables() bool {
 		leftKey[0].Label == rightKey[0].Label && c.on[leftKey[0].Label]
 }
 
+// canEvictCompleteTables reports whether the buffered tables that are
+// complete can be evicted before the join finishes. The rows that arrive
+// later have a greater time, so they cannot match the rows of a complete
+// table when the tables are joined on time. The tables of a preserved
+// stream are kept, since they are output even when they do not match.
+func (c *MergeJoinCache) canEvictCompleteTables() bool {
+	return c.on[execute.DefaultTimeColLabel] &&
+		!c.preserves(c.leftID) && !c.preserves(c.rightID)
+}
+
+// complete reports whether the output table joined from the
+// tables with the pre-join group keys will not change as more
+// tables arrive, so it can be sent downstream.
+func (c *MergeJoinCache) complete(keys preJoinGroupKeys) bool {
+	return c.buffers[c.leftID].complete(keys.left, c.watermark) &&
+		c.buffers[c.rightID].complete(keys.right, c.watermark)
+}
+
+// setWatermark sets the smallest watermark of the two streams.
+// The buffered tables that are complete and are not used by an
+// output table are evicted.
+func (c *MergeJoinCache) setWatermark(mark execute.Time) {
+	c.watermark = mark
+	if !c.canEvictCompleteTables() {
+		return
+	}
+	for _, buf := range c.buffers {
+		var keys []flux.GroupKey
+		buf.iterate(func(key flux.GroupKey) {
+			keys = append(keys, key)
+		})
+		for _, key := range keys {
+			buf.evictComplete(key, mark)
+		}
+	}
+}
+
 // insertIntoBuffer adds the rows of an incoming table to one of the Join's internal buffers
 func (c *MergeJoinCache) insertIntoBuffer(id execute.DatasetID, tbl flux.Table) error {
 	// Initialize schema if tbl is first from its stream
@@ -717,13 +1542,19 @@ func (c *MergeJoinCache) insertIntoBuffer(id execute.DatasetID, tbl flux.Table)
 
 		c.intersection = intersection
 	}
+	c.observe(id, tbl.Cols())
 
 	// Optimization: if any group key columns overlap join key columns,
 	// and there are any nulls in those columns, we can discard this table,
-	// since null != null for joining purposes.
+	// since null != null for joining purposes. The rows of a preserved
+	// stream are output even though they do not match, and the table is
+	// kept unless null values are skipped.
 	k := tbl.Key()
 	for j, col := range k.Cols() {
-		if c.on[col.Label] {
+		if c.on[col.Label] && k.IsNull(j) && c.onNullKey == ErrorOnNullKey {
+			return errors.Newf(codes.Invalid, "join column %q has a null value in table %v of %q", col.Label, k, c.names[id])
+		}
+		if c.on[col.Label] && !c.preserves(id) && !c.nullsEqual() {
 			if k.IsNull(j) {
 				// Discard the table and return.  Note: we need to iterate over the
 				// table at least once:
@@ -737,25 +1568,88 @@ func (c *MergeJoinCache) insertIntoBuffer(id execute.DatasetID, tbl flux.Table)
 	return c.buffers[id].insert(tbl)
 }
 
-// registerKey takes a group key from the input stream associated with id and joins
-// it with all other group keys from the opposing input stream. If it is determined
-// that two group keys will not join (due to having different values on a join column)
-// they are skipped.
-func (c *MergeJoinCache) registerKey(id execute.DatasetID, key flux.GroupKey) {
-	var empty struct{}
-	switch id {
-
-	case c.leftID:
-		c.buffers[c.rightID].iterate(func(groupKey flux.GroupKey) {
+// observe adds the columns of a table from the stream
+// with the id to the columns observed from the stream.
+func (c *MergeJoinCache) observe(id execute.DatasetID, cols []flux.ColMeta) {
+	observed := c.observed[id]
+	for _, col := range cols {
+		found := false
+		for _, o := range observed {
+			if o == col {
+				found = true
+				break
+			}
+		}
+		if !found {
+			observed = append(observed, col)
+		}
+	}
+	c.observed[id] = observed
+}
+
+// unpairedColumns returns the columns of the stream with the id that
+// a table from the other stream with the columns cols is joined with
+// when it is not paired. They are the union of the columns observed
+// from the stream, so the columns that only that stream has are output
+// with the same types whichever table is not paired. An on column that
+// the table and every table of the stream are missing has the missing
+// column type. A column observed with two types is an error, since the
+// type of its null values is ambiguous.
+func (c *MergeJoinCache) unpairedColumns(id execute.DatasetID, cols []flux.ColMeta) ([]flux.ColMeta, error) {
+	union := make([]flux.ColMeta, 0, len(c.observed[id]))
+	for _, col := range c.observed[id] {
+		if j := execute.ColIdx(col.Label, union); j >= 0 {
+			return nil, errors.Newf(codes.Invalid, "column %q has type %s and type %s in the tables of %q",
+				col.Label, union[j].Type, col.Type, c.names[id])
+		}
+		union = append(union, col)
+	}
+	for _, label := range c.order {
+		if execute.ColIdx(label, cols) < 0 && execute.ColIdx(label, union) < 0 {
+			union = append(union, flux.ColMeta{Label: label, Type: c.missingColumnType})
+		}
+	}
+	return union, nil
+}
+
+// hasOnColumns reports whether the table of the stream with
+// the id and the group key has all of the on columns. The
+// rows of a table that is missing one of them never match.
+// A table that was discarded is left to pairable.
+func (c *MergeJoinCache) hasOnColumns(id execute.DatasetID, key flux.GroupKey) bool {
+	table := c.buffers[id].table(key)
+	if table == nil {
+		return true
+	}
+	cols := table.Cols()
+	for _, label := range c.order {
+		if execute.ColIdx(label, cols) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// registerKey takes a group key from the input stream associated with id and joins
+// it with all other group keys from the opposing input stream. If it is determined
+// that two group keys will not join (due to having different values on a join column)
+// they are skipped.
+func (c *MergeJoinCache) registerKey(id execute.DatasetID, key flux.GroupKey) {
+	var empty struct{}
+	if !c.hasOnColumns(id, key) {
+		return
+	}
+	switch id {
+
+	case c.leftID:
+		c.buffers[c.rightID].iterate(func(groupKey flux.GroupKey) {
 			keys := map[execute.DatasetID]flux.GroupKey{
 				c.leftID:  key,
 				c.rightID: groupKey,
 			}
 
-			for k := range c.intersection {
-				if !key.LabelValue(k).Equal(groupKey.LabelValue(k)) {
-					return
-				}
+			if !c.pairable(key, groupKey) || !c.hasOnColumns(c.rightID, groupKey) {
+				return
 			}
 
 			outputGroupKey := c.postJoinGroupKey(keys)
@@ -765,6 +1659,10 @@ func (c *MergeJoinCache) registerKey(id execute.DatasetID, key flux.GroupKey) {
 				left:  key,
 				right: groupKey,
 			}
+			c.buffers[c.leftID].paired[key] = true
+			c.buffers[c.rightID].paired[groupKey] = true
+			c.buffers[c.leftID].refs[key]++
+			c.buffers[c.rightID].refs[groupKey]++
 		})
 
 	case c.rightID:
@@ -774,10 +1672,8 @@ func (c *MergeJoinCache) registerKey(id execute.DatasetID, key flux.GroupKey) {
 				c.rightID: key,
 			}
 
-			for k := range c.intersection {
-				if !key.LabelValue(k).Equal(groupKey.LabelValue(k)) {
-					return
-				}
+			if !c.pairable(groupKey, key) || !c.hasOnColumns(c.leftID, groupKey) {
+				return
 			}
 
 			outputGroupKey := c.postJoinGroupKey(keys)
@@ -787,10 +1683,91 @@ func (c *MergeJoinCache) registerKey(id execute.DatasetID, key flux.GroupKey) {
 				left:  groupKey,
 				right: key,
 			}
+			c.buffers[c.leftID].paired[groupKey] = true
+			c.buffers[c.rightID].paired[key] = true
+			c.buffers[c.leftID].refs[groupKey]++
+			c.buffers[c.rightID].refs[key]++
 		})
 	}
 }
 
+// pairable reports whether a table from the left stream is joined with
+// a table from the right stream. The tables must have equal values in
+// the on columns of their group keys. A cross join has no on columns,
+// so the tables must have equal values in the group key columns they
+// have in common instead.
+func (c *MergeJoinCache) pairable(left, right flux.GroupKey) bool {
+	if c.method == CrossJoinMethod {
+		for j, col := range left.Cols() {
+			if k := execute.ColIdx(col.Label, right.Cols()); k >= 0 && !left.Value(j).Equal(right.Value(k)) {
+				return false
+			}
+		}
+		return true
+	}
+	for k := range c.intersection {
+		if !c.equalValues(left.LabelValue(k), right.LabelValue(k)) {
+			return false
+		}
+	}
+	return true
+}
+
+// registerUnpairedKeys registers an output group key for each table
+// from a preserved stream that was not paired with a table from the
+// other stream, so that its rows are output with nulls in the columns
+// of the other stream. The columns of the other stream are those
+// returned by unpairedColumns. It must only be called once both streams have
+// finished, when it is known that the tables will not be paired.
+func (c *MergeJoinCache) registerUnpairedKeys() error {
+	var empty struct{}
+	var err error
+	for _, id := range []execute.DatasetID{c.leftID, c.rightID} {
+		if !c.preserves(id) {
+			continue
+		}
+		buf := c.buffers[id]
+		buf.iterate(func(key flux.GroupKey) {
+			if err != nil || buf.paired[key] {
+				return
+			}
+			var pre preJoinGroupKeys
+			cols := buf.table(key).Cols()
+			if id == c.leftID {
+				var right []flux.ColMeta
+				if right, err = c.unpairedColumns(c.rightID, cols); err == nil {
+					err = c.buildPostJoinSchema(cols, right)
+				}
+				pre.left = key
+			} else {
+				var left []flux.ColMeta
+				if left, err = c.unpairedColumns(c.leftID, cols); err == nil {
+					err = c.buildPostJoinSchema(left, cols)
+				}
+				pre.right = key
+			}
+			if err != nil {
+				return
+			}
+			outputGroupKey := c.postJoinGroupKey(map[execute.DatasetID]flux.GroupKey{id: key})
+			c.postJoinKeys.Set(outputGroupKey, empty)
+			c.reverseLookup[outputGroupKey] = pre
+			buf.paired[key] = true
+			buf.refs[key]++
+		})
+	}
+	return err
+}
+
+// equalValues reports whether the values of an on column
+// are equal according to the null key policy.
+func (c *MergeJoinCache) equalValues(l, r values.Value) bool {
+	if c.nullsEqual() && l.IsNull() && r.IsNull() {
+		return l.Type().Nature() == r.Type().Nature()
+	}
+	return l.Equal(r)
+}
+
 func (c *MergeJoinCache) isBufferEmpty(id execute.DatasetID) bool {
 	return len(c.buffers[id].data) == 0
 }
@@ -799,7 +1776,10 @@ func (c *MergeJoinCache) postJoinSchemaBuilt() bool {
 	return c.schemaMap != nil
 }
 
-func (c *MergeJoinCache) buildPostJoinSchema(left, right []flux.ColMeta) {
+func (c *MergeJoinCache) buildPostJoinSchema(left, right []flux.ColMeta) error {
+	if err := c.checkOnTypes(left, right); err != nil {
+		return err
+	}
 
 	// Find column names shared between the two tables
 	shared := make(map[string]bool, len(left))
@@ -824,34 +1804,523 @@ func (c *MergeJoinCache) buildPostJoinSchema(left, right []flux.ColMeta) {
 	c.schemaMap = make(map[tableCol]flux.ColMeta, ncols)
 	added := make(map[string]bool, ncols-len(c.on))
 
+	// Reserve the labels of the columns that are not renamed
+	// so that a renamed column cannot take one of them.
+	taken := make(map[string]bool, ncols)
+	for _, columns := range [][]flux.ColMeta{left, right} {
+		for _, column := range columns {
+			if !shared[column.Label] || c.on[column.Label] {
+				taken[column.Label] = true
+			}
+		}
+	}
+
 	// Build schema for output table
-	addColumnsToSchema(c.names[c.leftID], left, added, shared, c.on, &c.schema, c.schemaMap)
-	addColumnsToSchema(c.names[c.rightID], right, added, shared, c.on, &c.schema, c.schemaMap)
+	if err := addColumnsToSchema(c.names[c.leftID], c.suffixes[c.leftID], left, added, shared, c.on, taken, &c.schema, c.schemaMap); err != nil {
+		return err
+	}
+	if err := addColumnsToSchema(c.names[c.rightID], c.suffixes[c.rightID], right, added, shared, c.on, taken, &c.schema, c.schemaMap); err != nil {
+		return err
+	}
 
 	// Give schema an order
 	sort.Sort(c.schema)
 	for j, column := range c.schema.columns {
 		c.colIndex[column] = j
 	}
+	return nil
 }
 
+// checkOnTypes checks that each on column has the same type in the
+// left and right columns. Values of different types are never equal,
+// so a join on columns of different types, even numeric ones, would
+// silently output no matches.
+func (c *MergeJoinCache) checkOnTypes(left, right []flux.ColMeta) error {
+	for _, label := range c.order {
+		l, r := execute.ColIdx(label, left), execute.ColIdx(label, right)
+		if l < 0 || r < 0 || left[l].Type == right[r].Type {
+			continue
+		}
+		return errors.Newf(codes.Invalid, "join column %q has type %s in table %q and type %s in table %q",
+			label, left[l].Type, c.names[c.leftID], right[r].Type, c.names[c.rightID])
+	}
+	return nil
+}
+
+// join joins the rows of two tables with the same values
+// in the on columns and counts the pair of tables as joined.
 func (c *MergeJoinCache) join(left, right *execute.ColListTableBuilder) (flux.Table, error) {
-	// Sort input tables
-	left.Sort(c.order, false)
-	right.Sort(c.order, false)
+	if c.onNullKey == ErrorOnNullKey {
+		if err := c.checkNullKeys(c.leftID, left); err != nil {
+			return nil, err
+		}
+		if err := c.checkNullKeys(c.rightID, right); err != nil {
+			return nil, err
+		}
+	}
+
+	left, releaseLeft, err := c.deduplicate(c.leftID, left)
+	if err != nil {
+		return nil, err
+	}
+	defer releaseLeft()
+	right, releaseRight, err := c.deduplicate(c.rightID, right)
+	if err != nil {
+		return nil, err
+	}
+	defer releaseRight()
+
+	if left == nil || right == nil {
+		return c.joinUnpaired(left, right)
+	}
+	if c.method == CrossJoinMethod {
+		if err := c.checkCrossRows(left, right); err != nil {
+			return nil, err
+		}
+	}
+
+	var table flux.Table
+	if c.hash {
+		table, err = c.hashJoin(left, right)
+	} else {
+		table, err = c.mergeJoin(left, right)
+	}
+	if err != nil {
+		return nil, err
+	}
+	atomic.AddInt64(&c.stats.TablePairsJoined, 1)
+	if table.Empty() {
+		atomic.AddInt64(&c.stats.EmptyMatches, 1)
+	}
+	return table, nil
+}
+
+// checkCrossRows returns an error when the cross product
+// of two tables has more rows than the cross row limit.
+func (c *MergeJoinCache) checkCrossRows(left, right *execute.ColListTableBuilder) error {
+	if c.crossRowLimit <= 0 {
+		return nil
+	}
+	if n := int64(left.NRows()) * int64(right.NRows()); n > int64(c.crossRowLimit) {
+		return errors.Newf(codes.ResourceExhausted, "cross join of table %v of %q and table %v of %q would output %d rows, which exceeds the limit of %d rows",
+			left.Key(), c.names[c.leftID], right.Key(), c.names[c.rightID], n, c.crossRowLimit)
+	}
+	return nil
+}
+
+// checkNullKeys returns an error naming the first on column
+// with a null value in a table from the stream with the id.
+func (c *MergeJoinCache) checkNullKeys(id execute.DatasetID, builder *execute.ColListTableBuilder) error {
+	if builder == nil {
+		return nil
+	}
+	tbl, err := builder.Table()
+	if err != nil {
+		return err
+	}
+	cr := tbl.(flux.ColReader)
+	defer cr.Release()
+	for _, label := range c.order {
+		j := execute.ColIdx(label, cr.Cols())
+		if j < 0 {
+			continue
+		}
+		if n := table.Values(cr, j).NullN(); n > 0 {
+			return errors.Newf(codes.Invalid, "join column %q has %d null values in table %v of %q", label, n, builder.Key(), c.names[id])
+		}
+	}
+	return nil
+}
+
+// deduplicate applies the duplicate policy to the rows of a table
+// from the stream with the id that have the same values in the on
+// columns. Rows with a null value are never duplicates unless
+// null values are treated as equal. The table is returned as is when it has no duplicate
+// rows. Otherwise, it returns a new table with the rows that are
+// kept in their original order and a function that releases it.
+// A cross join has no on columns, so it has no duplicate rows.
+func (c *MergeJoinCache) deduplicate(id execute.DatasetID, table *execute.ColListTableBuilder) (*execute.ColListTableBuilder, func(), error) {
+	release := func() {}
+	if table == nil || c.onDuplicate == "" || c.onDuplicate == ExpandOnDuplicate || c.method == CrossJoinMethod {
+		return table, release, nil
+	}
+
+	tbl, err := table.Table()
+	if err != nil {
+		return nil, release, err
+	}
+	cr := tbl.(flux.ColReader)
+	defer cr.Release()
+
+	// rows maps the encoded key of each row to the row that is
+	// kept so far and keep marks the rows that are kept.
+	rows := make(map[string]int, cr.Len())
+	keep := make([]bool, cr.Len())
+	cols := c.onColumns(cr)
+	duplicates := false
+	var buf []byte
+	for i := 0; i < cr.Len(); i++ {
+		keep[i] = true
+		var ok bool
+		if buf, ok = appendRowKey(buf[:0], cr, cols, i, c.nullsEqual()); !ok {
+			continue
+		}
+		j, ok := rows[string(buf)]
+		if !ok {
+			rows[string(buf)] = i
+			continue
+		}
+		duplicates = true
+		switch c.onDuplicate {
+		case ErrorOnDuplicate:
+			return nil, release, errors.Newf(codes.Invalid, "duplicate join key %v in table %v of %q",
+				execute.GroupKeyForRowOn(i, cr, c.on), table.Key(), c.names[id])
+		case KeepFirstOnDuplicate:
+			keep[i] = false
+		case KeepLastOnDuplicate:
+			keep[j] = false
+			rows[string(buf)] = i
+		}
+	}
+	if !duplicates {
+		return table, release, nil
+	}
+
+	kept := execute.NewColListTableBuilder(table.Key(), c.alloc)
+	if err := execute.AddTableCols(tbl, kept); err != nil {
+		kept.Release()
+		return nil, release, err
+	}
+	for i := range keep {
+		if !keep[i] {
+			continue
+		}
+		if err := execute.AppendRecord(i, cr, kept); err != nil {
+			kept.Release()
+			return nil, release, err
+		}
+	}
+	return kept, kept.Release, nil
+}
+
+// equalKeys reports whether the on columns of two rows
+// are equal according to the null key policy.
+func (c *MergeJoinCache) equalKeys(l, r flux.GroupKey) bool {
+	if c.nullsEqual() {
+		return l.Equal(r)
+	}
+	return l.EqualTrueNulls(r)
+}
+
+// mergeJoin joins the tables by sorting them by the on
+// columns and merging the rows with the same values.
+func (c *MergeJoinCache) mergeJoin(left, right *execute.ColListTableBuilder) (flux.Table, error) {
+	// Sort input tables unless they are known to be sorted
+	if !c.sorted {
+		left.Sort(c.order, false)
+		right.Sort(c.order, false)
+	}
+
+	lt, _ := left.Table()
+	lcr := lt.(flux.ColReader)
+	defer lcr.Release()
+	rt, _ := right.Table()
+	rcr := rt.(flux.ColReader)
+	defer rcr.Release()
 
 	var leftSet, rightSet subset
 	var leftKey, rightKey flux.GroupKey
 
-	leftSet, leftKey = c.advance(leftSet.Stop, left)
-	rightSet, rightKey = c.advance(rightSet.Stop, right)
+	leftSet, leftKey = c.advance(leftSet.Stop, lcr)
+	rightSet, rightKey = c.advance(rightSet.Stop, rcr)
 
-	// Build the output table, this will deal with the cases where tables in stream have different schemas
-	c.buildPostJoinSchema(left.Cols(), right.Cols())
+	builder, err := c.newJoinBuilder(left, right)
+	if err != nil {
+		return nil, err
+	}
+	leftRows, err := c.newJoinRows(c.leftID, lcr.Cols(), false)
+	if err != nil {
+		return nil, err
+	}
+	rightRows, err := c.newJoinRows(c.rightID, rcr.Cols(), true)
+	if err != nil {
+		return nil, err
+	}
+
+	// Perform sort merge join
+	for !leftSet.Empty() && !rightSet.Empty() {
+		if c.equalKeys(leftKey, rightKey) {
+			leftRows.read(lcr, leftSet)
+			rightRows.read(rcr, rightSet)
+			if err := c.appendJoinedRows(builder, leftRows, rightRows); err != nil {
+				return nil, err
+			}
+			leftSet, leftKey = c.advance(leftSet.Stop, lcr)
+			rightSet, rightKey = c.advance(rightSet.Stop, rcr)
+		} else if leftKey.Less(rightKey) {
+			if err := c.appendUnmatchedRows(builder, c.leftID, left, leftSet, right.Cols()); err != nil {
+				return nil, err
+			}
+			leftSet, leftKey = c.advance(leftSet.Stop, lcr)
+		} else {
+			if err := c.appendUnmatchedRows(builder, c.rightID, right, rightSet, left.Cols()); err != nil {
+				return nil, err
+			}
+			rightSet, rightKey = c.advance(rightSet.Stop, rcr)
+		}
+	}
+
+	// The rows that remain in either table do not match any row.
+	for ; !leftSet.Empty(); leftSet, _ = c.advance(leftSet.Stop, lcr) {
+		if err := c.appendUnmatchedRows(builder, c.leftID, left, leftSet, right.Cols()); err != nil {
+			return nil, err
+		}
+	}
+	for ; !rightSet.Empty(); rightSet, _ = c.advance(rightSet.Stop, rcr) {
+		if err := c.appendUnmatchedRows(builder, c.rightID, right, rightSet, left.Cols()); err != nil {
+			return nil, err
+		}
+	}
+
+	return builder.Table()
+}
+
+// hashJoinEntrySize is an estimate of the memory used by each entry
+// of the hash table of a hash join in addition to the size of its key.
+const hashJoinEntrySize = 64
+
+// hashJoin joins the tables by building a hash table from the rows of
+// the smaller table and probing it with the rows of the other table.
+// The joined rows are in the order of the rows of the left table.
+// The memory used by the hash table is accounted against the allocator
+// and is released once the tables are joined.
+func (c *MergeJoinCache) hashJoin(left, right *execute.ColListTableBuilder) (flux.Table, error) {
+	builder, err := c.newJoinBuilder(left, right)
+	if err != nil {
+		return nil, err
+	}
+
+	lt, _ := left.Table()
+	lcr := lt.(flux.ColReader)
+	defer lcr.Release()
+	rt, _ := right.Table()
+	rcr := rt.(flux.ColReader)
+	defer rcr.Release()
+
+	buildLeft := lcr.Len() < rcr.Len()
+	build, probe := rcr, lcr
+	if buildLeft {
+		build, probe = lcr, rcr
+	}
+
+	table, size, err := c.buildHashTable(build)
+	defer func() {
+		_ = c.alloc.Account(-size)
+	}()
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make([][]int, lcr.Len())
+	cols := c.onColumns(probe)
+	var buf []byte
+	for i := 0; i < probe.Len(); i++ {
+		var ok bool
+		if buf, ok = appendRowKey(buf[:0], probe, cols, i, c.nullsEqual()); !ok {
+			continue
+		}
+		for _, j := range table[string(buf)] {
+			// The probe side is visited in order so the right
+			// rows for each left row are already ascending.
+			if buildLeft {
+				matches[j] = append(matches[j], i)
+			} else {
+				matches[i] = append(matches[i], j)
+			}
+		}
+	}
+
+	leftRows, err := c.newJoinRows(c.leftID, lcr.Cols(), false)
+	if err != nil {
+		return nil, err
+	}
+	rightRows, err := c.newJoinRows(c.rightID, rcr.Cols(), true)
+	if err != nil {
+		return nil, err
+	}
+	rightRows.read(rcr, subset{Start: 0, Stop: rcr.Len()})
+
+	// The unmatched rows of the right table follow
+	// the rows of the left table.
+	var matchedRight []bool
+	if c.preserves(c.rightID) {
+		matchedRight = make([]bool, rcr.Len())
+	}
+	for l, rows := range matches {
+		if len(rows) == 0 {
+			if c.preserves(c.leftID) {
+				if err := c.appendUnmatchedRow(builder, c.leftID, left.GetRow(l), right.Cols()); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+		leftRows.read(lcr, subset{Start: l, Stop: l + 1})
+		for _, r := range rows {
+			if err := leftRows.append(builder, 0); err != nil {
+				return nil, err
+			}
+			if err := rightRows.append(builder, r); err != nil {
+				return nil, err
+			}
+			if matchedRight != nil {
+				matchedRight[r] = true
+			}
+		}
+	}
+	for r, matched := range matchedRight {
+		if !matched {
+			if err := c.appendUnmatchedRow(builder, c.rightID, right.GetRow(r), left.Cols()); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return builder.Table()
+}
+
+// buildHashTable maps the values of the on columns of each row to the
+// indices of the rows with those values. It returns the number of bytes
+// accounted against the allocator, which must be released by the caller
+// even if there is an error.
+func (c *MergeJoinCache) buildHashTable(cr flux.ColReader) (map[string][]int, int, error) {
+	table := make(map[string][]int)
+	cols := c.onColumns(cr)
+	size := 0
+	var buf []byte
+	for i := 0; i < cr.Len(); i++ {
+		var ok bool
+		if buf, ok = appendRowKey(buf[:0], cr, cols, i, c.nullsEqual()); !ok {
+			continue
+		}
+		n := 8
+		if _, exists := table[string(buf)]; !exists {
+			n += len(buf) + hashJoinEntrySize
+		}
+		if err := c.alloc.Account(n); err != nil {
+			return nil, size, err
+		}
+		size += n
+		table[string(buf)] = append(table[string(buf)], i)
+	}
+	return table, size, nil
+}
+
+// onColumns returns the index of each of the on columns in the order
+// they are joined on. The index is -1 if the table does not have the column.
+func (c *MergeJoinCache) onColumns(cr flux.ColReader) []int {
+	cols := make([]int, len(c.order))
+	for i, label := range c.order {
+		cols[i] = execute.ColIdx(label, cr.Cols())
+	}
+	return cols
+}
+
+// appendRowKey appends an encoding of the values of the on columns of
+// row i to buf. Rows that are equal on the on columns have the same
+// encoding. It reports false if a value is missing or if it is null and
+// null values are not equal, since those values never join.
+func appendRowKey(buf []byte, cr flux.ColReader, cols []int, i int, nullsEqual bool) ([]byte, bool) {
+	var scratch [binary.MaxVarintLen64]byte
+	for _, j := range cols {
+		if j < 0 {
+			return buf, false
+		}
+		typ := cr.Cols()[j].Type
+		buf = append(buf, byte(typ))
+		// Each value is preceded by a flag so that
+		// a null value has a distinct encoding.
+		if table.Values(cr, j).IsNull(i) {
+			if !nullsEqual {
+				return buf, false
+			}
+			buf = append(buf, 0)
+			continue
+		}
+		buf = append(buf, 1)
+		switch typ {
+		case flux.TBool:
+			vs := cr.Bools(j)
+			if vs.Value(i) {
+				buf = append(buf, 1)
+			} else {
+				buf = append(buf, 0)
+			}
+		case flux.TInt:
+			vs := cr.Ints(j)
+			binary.BigEndian.PutUint64(scratch[:8], uint64(vs.Value(i)))
+			buf = append(buf, scratch[:8]...)
+		case flux.TUInt:
+			vs := cr.UInts(j)
+			binary.BigEndian.PutUint64(scratch[:8], vs.Value(i))
+			buf = append(buf, scratch[:8]...)
+		case flux.TFloat:
+			vs := cr.Floats(j)
+			v := vs.Value(i)
+			if math.IsNaN(v) {
+				// NaN is not equal to any value.
+				return buf, false
+			} else if v == 0 {
+				// Negative zero is equal to zero.
+				v = 0
+			}
+			binary.BigEndian.PutUint64(scratch[:8], math.Float64bits(v))
+			buf = append(buf, scratch[:8]...)
+		case flux.TString:
+			vs := cr.Strings(j)
+			v := vs.Value(i)
+			n := binary.PutUvarint(scratch[:], uint64(len(v)))
+			buf = append(buf, scratch[:n]...)
+			buf = append(buf, v...)
+		case flux.TTime:
+			vs := cr.Times(j)
+			binary.BigEndian.PutUint64(scratch[:8], uint64(vs.Value(i)))
+			buf = append(buf, scratch[:8]...)
+		default:
+			execute.PanicUnknownType(typ)
+		}
+	}
+	return buf, true
+}
+
+// newJoinBuilder builds the schema of the joined table and
+// returns a builder for the joined rows.
+// A table that was not paired with a table from the other stream
+// is nil, and the columns of the other stream are those of its
+// first table.
+func (c *MergeJoinCache) newJoinBuilder(left, right *execute.ColListTableBuilder) (*execute.ColListTableBuilder, error) {
+	keys := make(map[execute.DatasetID]flux.GroupKey, 2)
+	var leftCols, rightCols []flux.ColMeta
+	if left != nil {
+		leftCols = left.Cols()
+		keys[c.leftID] = left.Key()
+	}
+	if right != nil {
+		rightCols = right.Cols()
+		keys[c.rightID] = right.Key()
+	}
+	var err error
+	if left == nil {
+		leftCols, err = c.unpairedColumns(c.leftID, rightCols)
+	} else if right == nil {
+		rightCols, err = c.unpairedColumns(c.rightID, leftCols)
+	}
+	if err != nil {
+		return nil, err
+	}
 
-	keys := map[execute.DatasetID]flux.GroupKey{
-		c.leftID:  left.Key(),
-		c.rightID: right.Key(),
+	// Build the output table, this will deal with the cases where tables in stream have different schemas
+	if err := c.buildPostJoinSchema(leftCols, rightCols); err != nil {
+		return nil, err
 	}
 
 	// Instantiate a builder for the output table
@@ -864,77 +2333,190 @@ func (c *MergeJoinCache) join(left, right *execute.ColListTableBuilder) (flux.Ta
 			return nil, err
 		}
 	}
+	return builder, nil
+}
+
+// joinRows holds the values of a set of rows of a table that is being
+// joined. The values of each row are read once however many rows of the
+// other table it matches, and the buffer is reused for each set of rows,
+// so the memory used by a join is proportional to its input and not to
+// the number of joined rows.
+type joinRows struct {
+	// cols holds the index in the joined table of each column
+	// of the table, or -1 if the values of the column are skipped.
+	cols []int
+	vals []values.Value
+}
 
-	// Perform sort merge join
-	for !leftSet.Empty() && !rightSet.Empty() {
-		if leftKey.EqualTrueNulls(rightKey) {
-			for l := leftSet.Start; l < leftSet.Stop; l++ {
-				for r := rightSet.Start; r < rightSet.Stop; r++ {
-
-					leftRecord := left.GetRow(l)
-					rightRecord := right.GetRow(r)
-
-					var err error
-					leftRecord.Range(func(columnName string, columnVal values.Value) {
-						column := tableCol{
-							table: c.names[c.leftID],
-							col:   columnName,
-						}
-						newColumn, ok := c.schemaMap[column]
-						if !ok {
-							err = errors.Newf(codes.Internal, "column '%s' not found in join schema", columnName)
-							return
-						}
-						newColumnIdx, ok := c.colIndex[newColumn]
-						if !ok {
-							err = errors.Newf(codes.Internal, "could not find index for column '%s' in column index map", columnName)
-							return
-						}
-						err = builder.AppendValue(newColumnIdx, columnVal)
-					})
-					if err != nil {
-						return nil, err
-					}
-
-					rightRecord.Range(func(columnName string, columnVal values.Value) {
-						column := tableCol{
-							table: c.names[c.rightID],
-							col:   columnName,
-						}
-						newColumn, ok := c.schemaMap[column]
-						if !ok {
-							err = errors.Newf(codes.Internal, "column '%s' not found in schema", columnName)
-							return
-						}
-						newColumnIdx, ok := c.colIndex[newColumn]
-						if !ok {
-							err = errors.Newf(codes.Internal, "could not find index for column '%s'", columnName)
-							return
-						}
-
-						// No need to append value if column is part of the join key.
-						// Because value already appended when iterating over left record.
-						if !c.on[newColumn.Label] {
-							err = builder.AppendValue(newColumnIdx, columnVal)
-						}
-					})
-					if err != nil {
-						return nil, err
-					}
-				}
+// newJoinRows returns a buffer for the rows of the stream with the id.
+// The values of the join key are skipped when skipOn is set because
+// they are appended from the other stream.
+func (c *MergeJoinCache) newJoinRows(id execute.DatasetID, cols []flux.ColMeta, skipOn bool) (*joinRows, error) {
+	rows := &joinRows{cols: make([]int, len(cols))}
+	for j, col := range cols {
+		newColumn, ok := c.schemaMap[tableCol{table: c.names[id], col: col.Label}]
+		if !ok {
+			return nil, errors.Newf(codes.Internal, "column '%s' not found in join schema", col.Label)
+		}
+		newColumnIdx, ok := c.colIndex[newColumn]
+		if !ok {
+			return nil, errors.Newf(codes.Internal, "could not find index for column '%s' in column index map", col.Label)
+		}
+		if skipOn && c.on[newColumn.Label] {
+			newColumnIdx = -1
+		}
+		rows.cols[j] = newColumnIdx
+	}
+	return rows, nil
+}
+
+// read replaces the rows in the buffer with the subset of rows.
+func (r *joinRows) read(cr flux.ColReader, rows subset) {
+	r.vals = r.vals[:0]
+	for i := rows.Start; i < rows.Stop; i++ {
+		for j, idx := range r.cols {
+			var v values.Value
+			if idx >= 0 {
+				v = execute.ValueForRow(cr, i, j)
 			}
-			leftSet, leftKey = c.advance(leftSet.Stop, left)
-			rightSet, rightKey = c.advance(rightSet.Stop, right)
-		} else if leftKey.Less(rightKey) {
-			leftSet, leftKey = c.advance(leftSet.Stop, left)
-		} else {
-			rightSet, rightKey = c.advance(rightSet.Stop, right)
+			r.vals = append(r.vals, v)
 		}
 	}
+}
+
+// len returns the number of rows in the buffer.
+func (r *joinRows) len() int {
+	if len(r.cols) == 0 {
+		return 0
+	}
+	return len(r.vals) / len(r.cols)
+}
 
+// append appends the values of the ith row in the buffer to the builder.
+func (r *joinRows) append(builder *execute.ColListTableBuilder, i int) error {
+	row := r.vals[i*len(r.cols) : (i+1)*len(r.cols)]
+	for j, idx := range r.cols {
+		if idx < 0 {
+			continue
+		}
+		if err := builder.AppendValue(idx, row[j]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendJoinedRows appends a row that joins each left row
+// with each right row. The rows are appended as they are
+// joined, so the joined rows are never buffered.
+func (c *MergeJoinCache) appendJoinedRows(builder *execute.ColListTableBuilder, left, right *joinRows) error {
+	for l := 0; l < left.len(); l++ {
+		for r := 0; r < right.len(); r++ {
+			if err := left.append(builder, l); err != nil {
+				return err
+			}
+			if err := right.append(builder, r); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// appendUnmatchedRow appends a row from the stream with the id that
+// does not match a row from the other stream. The columns of the other
+// stream, other than the join key, are null.
+func (c *MergeJoinCache) appendUnmatchedRow(builder *execute.ColListTableBuilder, id execute.DatasetID, record values.Object, otherCols []flux.ColMeta) error {
+	if err := c.appendRecord(builder, id, record, false); err != nil {
+		return err
+	}
+	otherID := c.leftID
+	if id == c.leftID {
+		otherID = c.rightID
+	}
+	for _, col := range otherCols {
+		newColumn, ok := c.schemaMap[tableCol{table: c.names[otherID], col: col.Label}]
+		if !ok {
+			return errors.Newf(codes.Internal, "column '%s' not found in join schema", col.Label)
+		}
+		if _, ok := record.Get(col.Label); ok && c.on[newColumn.Label] {
+			continue
+		}
+		if err := builder.AppendNil(c.colIndex[newColumn]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendUnmatchedRows appends the rows of a subset of a table when the
+// stream with the id is preserved by the join.
+func (c *MergeJoinCache) appendUnmatchedRows(builder *execute.ColListTableBuilder, id execute.DatasetID, table *execute.ColListTableBuilder, rows subset, otherCols []flux.ColMeta) error {
+	if !c.preserves(id) {
+		return nil
+	}
+	for i := rows.Start; i < rows.Stop; i++ {
+		if err := c.appendUnmatchedRow(builder, id, table.GetRow(i), otherCols); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// joinUnpaired outputs the rows of a table from a preserved stream
+// that was not paired with a table from the other stream. The other
+// table is nil.
+func (c *MergeJoinCache) joinUnpaired(left, right *execute.ColListTableBuilder) (flux.Table, error) {
+	builder, err := c.newJoinBuilder(left, right)
+	if err != nil {
+		return nil, err
+	}
+	id, table, otherID := c.leftID, left, c.rightID
+	if left == nil {
+		id, table, otherID = c.rightID, right, c.leftID
+	}
+	otherCols, err := c.unpairedColumns(otherID, table.Cols())
+	if err != nil {
+		return nil, err
+	}
+	rows := subset{Start: 0, Stop: table.NRows()}
+	if err := c.appendUnmatchedRows(builder, id, table, rows, otherCols); err != nil {
+		return nil, err
+	}
 	return builder.Table()
 }
 
+// appendRecord appends the values of a record from the stream with the id
+// to the columns of the joined row. The values of the join key are skipped
+// when skipOn is set because they were appended from the other record.
+func (c *MergeJoinCache) appendRecord(builder *execute.ColListTableBuilder, id execute.DatasetID, record values.Object, skipOn bool) error {
+	var err error
+	record.Range(func(columnName string, columnVal values.Value) {
+		if err != nil {
+			return
+		}
+		column := tableCol{
+			table: c.names[id],
+			col:   columnName,
+		}
+		newColumn, ok := c.schemaMap[column]
+		if !ok {
+			err = errors.Newf(codes.Internal, "column '%s' not found in join schema", columnName)
+			return
+		}
+		newColumnIdx, ok := c.colIndex[newColumn]
+		if !ok {
+			err = errors.Newf(codes.Internal, "could not find index for column '%s' in column index map", columnName)
+			return
+		}
+		if skipOn && c.on[newColumn.Label] {
+			return
+		}
+		err = builder.AppendValue(newColumnIdx, columnVal)
+	})
+	return err
+}
+
 // postJoinGroupKey produces a new group key value from a left and a right group key value
 func (c *MergeJoinCache) postJoinGroupKey(keys map[execute.DatasetID]flux.GroupKey) flux.GroupKey {
 	key := groupKey{
@@ -970,10 +2552,7 @@ func (c *MergeJoinCache) postJoinGroupKey(keys map[execute.DatasetID]flux.GroupK
 }
 
 // advance advances the row pointer of a sorted table that is being joined
-func (c *MergeJoinCache) advance(offset int, table *execute.ColListTableBuilder) (subset, flux.GroupKey) {
-	tbl, _ := table.Table()
-	cr := tbl.(flux.ColReader)
-	defer cr.Release()
+func (c *MergeJoinCache) advance(offset int, cr flux.ColReader) (subset, flux.GroupKey) {
 	if n := cr.Len(); n == offset {
 		return subset{Start: n, Stop: n}, nil
 	}
@@ -1004,6 +2583,15 @@ func equalRowKeys(x, y int, cr flux.ColReader, on map[string]bool) bool {
 		if !on[c.Label] {
 			continue
 		}
+		// Null values are grouped together and
+		// apart from the values of the column.
+		vs := table.Values(cr, j)
+		if xn, yn := vs.IsNull(x), vs.IsNull(y); xn || yn {
+			if xn != yn {
+				return false
+			}
+			continue
+		}
 		switch c.Type {
 		case flux.TBool:
 			if xv, yv := cr.Bools(j).Value(x), cr.Bools(j).Value(y); xv != yv {
@@ -1036,7 +2624,7 @@ func equalRowKeys(x, y int, cr flux.ColReader, on map[string]bool) bool {
 	return true
 }
 
-func addColumnsToSchema(name string, columns []flux.ColMeta, added, shared, on map[string]bool, schema *schema, schemaMap map[tableCol]flux.ColMeta) {
+func addColumnsToSchema(name, suffix string, columns []flux.ColMeta, added, shared, on, taken map[string]bool, schema *schema, schemaMap map[tableCol]flux.ColMeta) error {
 	for _, column := range columns {
 
 		tableAndColumn := tableCol{
@@ -1044,7 +2632,10 @@ func addColumnsToSchema(name string, columns []flux.ColMeta, added, shared, on m
 			col:   column.Label,
 		}
 
-		newLabel := renameColumn(tableAndColumn, shared, on)
+		newLabel, err := renameColumn(tableAndColumn, suffix, shared, on, taken)
+		if err != nil {
+			return err
+		}
 		newColumn := flux.ColMeta{
 			Label: newLabel,
 			Type:  column.Type,
@@ -1058,15 +2649,32 @@ func addColumnsToSchema(name string, columns []flux.ColMeta, added, shared, on m
 
 		added[newLabel] = true
 	}
+	return nil
 }
 
-func renameColumn(col tableCol, share, on map[string]bool) string {
+// renameColumn returns the label of a column in the joined table.
+// A column that is shared by both tables and not joined on is renamed
+// by appending the suffix of its table. When the suffixed label is
+// already taken by another column, "_1", "_2" and so on is appended
+// until the label is unique. The new label is marked as taken.
+func renameColumn(col tableCol, suffix string, share, on, taken map[string]bool) (string, error) {
 	columnName := col.col
 
-	if share[columnName] && !on[columnName] {
-		return fmt.Sprintf("%s_%s", columnName, col.table)
+	if !share[columnName] || on[columnName] {
+		return columnName, nil
+	}
+
+	label := columnName + suffix
+	// There are at most len(taken) labels to collide
+	// with, so one of these candidates must be unique.
+	for i, n := 1, len(taken); taken[label]; i++ {
+		if i > n+1 {
+			return "", errors.Newf(codes.Invalid, "cannot find a unique name for column %q of table %q", columnName, col.table)
+		}
+		label = columnName + suffix + "_" + strconv.Itoa(i)
 	}
-	return columnName
+	taken[label] = true
+	return label, nil
 }
 
 type groupKey struct {
@@ -1086,3 +2694,329 @@ func (k groupKey) Swap(i, j int) {
 	k.cols[i], k.cols[j] = k.cols[j], k.cols[i]
 	k.vals[i], k.vals[j] = k.vals[j], k.vals[i]
 }
+
+// MergeJoinSortedInputsRule marks a merge join as having sorted inputs
+// when both of its predecessors guarantee that the rows within each
+// table are sorted by the on columns, so the join can skip its sort.
+type MergeJoinSortedInputsRule struct{}
+
+func (MergeJoinSortedInputsRule) Name() string {
+	return "MergeJoinSortedInputsRule"
+}
+
+func (MergeJoinSortedInputsRule) Pattern() plan.Pattern {
+	return plan.Pat(MergeJoinKind, plan.Any(), plan.Any())
+}
+
+func (MergeJoinSortedInputsRule) Rewrite(ctx context.Context, node plan.Node) (plan.Node, bool, error) {
+	spec := node.ProcedureSpec().(*MergeJoinProcedureSpec)
+	if spec.Sorted {
+		return node, false, nil
+	}
+	collation := &plan.CollationAttr{Columns: spec.On}
+	for _, pred := range node.Predecessors() {
+		if !collation.SatisfiedBy(plan.GetOutputAttribute(pred, plan.CollationKey)) {
+			return node, false, nil
+		}
+	}
+	spec.Sorted = true
+	return node, true, nil
+}
+
+// MergeJoinHashStrategyRule chooses the hash join strategy for a merge
+// join when neither of its predecessors guarantees that the rows within
+// each table are sorted by the on columns, since a merge join would need
+// to sort both of its inputs.
+type MergeJoinHashStrategyRule struct{}
+
+func (MergeJoinHashStrategyRule) Name() string {
+	return "MergeJoinHashStrategyRule"
+}
+
+func (MergeJoinHashStrategyRule) Pattern() plan.Pattern {
+	return plan.Pat(MergeJoinKind, plan.Any(), plan.Any())
+}
+
+func (MergeJoinHashStrategyRule) Rewrite(ctx context.Context, node plan.Node) (plan.Node, bool, error) {
+	spec := node.ProcedureSpec().(*MergeJoinProcedureSpec)
+	if spec.Sorted || spec.Strategy != "" {
+		return node, false, nil
+	}
+	collation := &plan.CollationAttr{Columns: spec.On}
+	for _, pred := range node.Predecessors() {
+		if collation.SatisfiedBy(plan.GetOutputAttribute(pred, plan.CollationKey)) {
+			return node, false, nil
+		}
+	}
+	spec.Strategy = HashJoinStrategy
+	return node, true, nil
+}
+
+// FilterThroughJoinRule pushes the predicates of a filter that follows
+// a join onto the inputs of the join that the filtered columns come from,
+// so fewer rows need to be buffered and matched.
+//
+//     A   B          A        B
+//      \ /           |        |
+//      join    =>  filter  filter
+//       |             \      /
+//     filter            join
+//
+// The filter is split into the predicates that are joined with and.
+// A column of the output that is the name of a column followed by the
+// suffix of an input is that column of the input, and a column that is
+// joined on has the same value in both inputs. A predicate that only
+// references the columns of one input is pushed onto it with the
+// original column names, and a predicate that only references the
+// columns that are joined on is pushed onto both inputs. The predicates
+// that reference the columns of both inputs, or columns that may come
+// from either of them, remain in a filter after the join.
+//
+// Removing rows from an input before the join must remove the same rows
+// from the output. That is either input of an inner or cross join, the
+// left input of a left join and the right input of a right join.
+type FilterThroughJoinRule struct{}
+
+func (FilterThroughJoinRule) Name() string {
+	return "FilterThroughJoinRule"
+}
+
+func (FilterThroughJoinRule) Pattern() plan.Pattern {
+	return plan.Pat(FilterKind, plan.Pat(MergeJoinKind, plan.Any(), plan.Any()))
+}
+
+func (FilterThroughJoinRule) Rewrite(ctx context.Context, node plan.Node) (plan.Node, bool, error) {
+	filterSpec := node.ProcedureSpec().(*FilterProcedureSpec)
+	joinNode := node.Predecessors()[0]
+	joinSpec := joinNode.ProcedureSpec().(*MergeJoinProcedureSpec)
+
+	// Filtering an input can only keep its empty tables,
+	// which is not the same as keeping the empty tables of
+	// the output. The join must also not be used by anything
+	// other than the filter.
+	if filterSpec.KeepEmptyTables || len(joinNode.Successors()) != 1 {
+		return node, false, nil
+	}
+	// Which rows are kept by these policies depends on
+	// the other rows of the table, so removing rows
+	// first may keep rows that would have been dropped.
+	if joinSpec.OnDuplicate == KeepFirstOnDuplicate || joinSpec.OnDuplicate == KeepLastOnDuplicate ||
+		joinSpec.BufferSize > 0 && joinSpec.OnOverflow == EvictOldestOnOverflow {
+		return node, false, nil
+	}
+	sides := filterableSides(joinSpec.Method)
+	if len(sides) == 0 || len(joinSpec.TableNames) != len(sides) {
+		return node, false, nil
+	}
+	fn := filterSpec.Fn.Fn
+	if fn == nil || fn.Block == nil || fn.Parameters == nil || len(fn.Parameters.List) != 1 {
+		return node, false, nil
+	}
+	body, ok := fn.GetFunctionBodyExpression()
+	if !ok {
+		return node, false, nil
+	}
+
+	columns := joinColumns(joinSpec, sides)
+	param := fn.Parameters.List[0].Key.Name.Name()
+	pushed := make([][]semantic.Expression, len(sides))
+	var kept []semantic.Expression
+	for _, expr := range semantic.ConjunctionsToExprSlice(body) {
+		onSides := predicateSides(expr, param, columns)
+		for i, ok := range onSides {
+			if ok {
+				pushed[i] = append(pushed[i], renameColumns(expr, param, columns, i))
+			}
+		}
+		if onSides == nil {
+			kept = append(kept, expr)
+		}
+	}
+
+	changed := false
+	for i, exprs := range pushed {
+		if len(exprs) == 0 {
+			continue
+		}
+		spec := &FilterProcedureSpec{
+			Fn: filterSpec.Fn.Copy(),
+		}
+		spec.Fn.Fn.Block.Body[0].(*semantic.ReturnStatement).Argument = semantic.ExprsToConjunction(exprs...)
+		id := node.ID() + plan.NodeID("_"+joinSpec.TableNames[i])
+		insertBeforeJoin(joinNode, i, plan.CreatePhysicalNode(id, spec))
+		changed = true
+	}
+	if !changed {
+		return node, false, nil
+	}
+
+	if len(kept) == 0 {
+		return joinNode, true, nil
+	}
+	spec := filterSpec.Copy().(*FilterProcedureSpec)
+	spec.Fn.Fn.Block.Body[0].(*semantic.ReturnStatement).Argument = semantic.ExprsToConjunction(kept...)
+	if err := node.ReplaceSpec(spec); err != nil {
+		return nil, false, err
+	}
+	return node, true, nil
+}
+
+// filterableSides reports which inputs of a join may be filtered before
+// the join. The rows of an input that is preserved by an outer join are
+// joined with nulls when they have no match, so an input that is not
+// preserved cannot be filtered first. A full join preserves both inputs,
+// so neither of them can be filtered first.
+func filterableSides(method string) []bool {
+	switch method {
+	case "", "inner", CrossJoinMethod:
+		return []bool{true, true}
+	case "left":
+		return []bool{true, false}
+	case "right":
+		return []bool{false, true}
+	default:
+		return nil
+	}
+}
+
+// joinColumn is a column of the output of a join
+// and the columns of the inputs that it is copied from.
+type joinColumn struct {
+	// sides reports which inputs the column
+	// can be filtered on before the join.
+	sides []bool
+	// label is the label of the column in the inputs.
+	label string
+}
+
+// joinColumns returns a function that finds the inputs that a column
+// of the output of the join is copied from. A column that is joined on
+// is in both inputs. A column that ends with the suffix of an input is
+// the column of that input without the suffix, unless it also ends with
+// the suffix of the other input. The inputs of any other column cannot
+// be known until the join reads their tables.
+func joinColumns(spec *MergeJoinProcedureSpec, filterable []bool) func(label string) (joinColumn, bool) {
+	suffixes := spec.Suffixes
+	if len(suffixes) != len(spec.TableNames) {
+		suffixes = make([]string, len(spec.TableNames))
+		for i, name := range spec.TableNames {
+			suffixes[i] = "_" + name
+		}
+	}
+	on := make(map[string]bool, len(spec.On))
+	for _, label := range spec.On {
+		on[label] = true
+	}
+	return func(label string) (joinColumn, bool) {
+		if on[label] {
+			return joinColumn{sides: filterable, label: label}, true
+		}
+		col := joinColumn{sides: make([]bool, len(suffixes))}
+		found := false
+		for i, suffix := range suffixes {
+			if !strings.HasSuffix(label, suffix) || len(label) == len(suffix) {
+				continue
+			}
+			if found {
+				return joinColumn{}, false
+			}
+			col.sides[i] = filterable[i]
+			col.label = strings.TrimSuffix(label, suffix)
+			found = true
+		}
+		return col, found
+	}
+}
+
+// predicateSides reports which inputs of the join a predicate may be
+// applied to. It returns nil when the predicate cannot be pushed through
+// the join. The predicate must only reference the record with the name
+// of the parameter to access its columns and must reference at least one
+// column. Nested functions are not inspected because they may declare a
+// parameter with the same name.
+func predicateSides(expr semantic.Expression, param string, columns func(label string) (joinColumn, bool)) []bool {
+	var (
+		sides   []bool
+		members = make(map[*semantic.IdentifierExpression]bool)
+		ok      = true
+	)
+	semantic.Walk(semantic.CreateVisitor(func(node semantic.Node) {
+		switch e := node.(type) {
+		case *semantic.FunctionExpression:
+			ok = false
+		case *semantic.MemberExpression:
+			id, isIdent := e.Object.(*semantic.IdentifierExpression)
+			if !isIdent || id.Name.Name() != param {
+				return
+			}
+			members[id] = true
+			col, found := columns(e.Property.Name())
+			if !found {
+				ok = false
+				return
+			}
+			// The type of a column that is not inferred from the
+			// predicate is found from the column with the same label
+			// in the input, so the column must not be renamed.
+			if col.label != e.Property.Name() && e.TypeOf().Kind() == semantic.Var {
+				ok = false
+				return
+			}
+			if sides == nil {
+				sides = append([]bool(nil), col.sides...)
+				return
+			}
+			for i := range sides {
+				sides[i] = sides[i] && col.sides[i]
+			}
+		case *semantic.IdentifierExpression:
+			if e.Name.Name() == param && !members[e] {
+				ok = false
+			}
+		}
+	}), expr)
+	if !ok {
+		return nil
+	}
+	for _, side := range sides {
+		if side {
+			return sides
+		}
+	}
+	return nil
+}
+
+// renameColumns returns a copy of the predicate that references
+// the columns of the input at index i by their labels in that input.
+func renameColumns(expr semantic.Expression, param string, columns func(label string) (joinColumn, bool), i int) semantic.Expression {
+	expr = expr.Copy().(semantic.Expression)
+	semantic.Walk(semantic.CreateVisitor(func(node semantic.Node) {
+		m, ok := node.(*semantic.MemberExpression)
+		if !ok {
+			return
+		}
+		if id, ok := m.Object.(*semantic.IdentifierExpression); !ok || id.Name.Name() != param {
+			return
+		}
+		if col, ok := columns(m.Property.Name()); ok && col.sides[i] {
+			m.Property = semantic.NewSymbol(col.label)
+		}
+	}), expr)
+	return expr
+}
+
+// insertBeforeJoin inserts a node between a join and its predecessor
+// at index i. Only one edge is replaced so a predecessor that is used
+// for both inputs of the join keeps its other edge.
+func insertBeforeJoin(join plan.Node, i int, inserted plan.Node) {
+	pred := join.Predecessors()[i]
+	for j, succ := range pred.Successors() {
+		if succ == join {
+			pred.Successors()[j] = inserted
+			break
+		}
+	}
+	inserted.AddPredecessors(pred)
+	inserted.AddSuccessors(join)
+	join.Predecessors()[i] = inserted
+}
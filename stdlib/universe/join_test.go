@@ -1,19 +1,32 @@
 package universe_test
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"sort"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/dependencies/dependenciestest"
+	"github.com/influxdata/flux/dependency"
 	"github.com/influxdata/flux/execute"
 	"github.com/influxdata/flux/execute/executetest"
+	"github.com/influxdata/flux/internal/spec"
+	"github.com/influxdata/flux/memory"
 	"github.com/influxdata/flux/plan"
+	"github.com/influxdata/flux/plan/plantest"
 	"github.com/influxdata/flux/querytest"
+	"github.com/influxdata/flux/runtime"
+	"github.com/influxdata/flux/semantic"
 	"github.com/influxdata/flux/stdlib/influxdata/influxdb"
 	"github.com/influxdata/flux/stdlib/universe"
+	"github.com/influxdata/flux/values"
 )
 
 func TestJoin_NewQuery(t *testing.T) {
@@ -160,7 +173,8 @@ func TestJoin_NewQuery(t *testing.T) {
 				b = from(bucket:"flux") |> range(start:-1h)
 				join(tables:{a:a,b:b})
 			`,
-			WantErr: true,
+			WantErr:    true,
+			WantErrMsg: `join: missing required keyword argument "on" @4:5-4:28`,
 		},
 		{
 			Name: "zero-length on list",
@@ -171,6 +185,43 @@ func TestJoin_NewQuery(t *testing.T) {
 			`,
 			WantErr: true,
 		},
+		{
+			Name: "cross join with on columns",
+			Raw: `
+				a = from(bucket:"flux") |> range(start:-1h)
+				b = from(bucket:"flux") |> range(start:-1h)
+				join(tables:{a:a,b:b}, on: ["t1"], method: "cross")
+			`,
+			WantErr:    true,
+			WantErrMsg: `join: cross product and 'on' are mutually exclusive @4:5-4:56`,
+		},
+		{
+			Name: "one suffix",
+			Raw: `
+				a = from(bucket:"flux") |> range(start:-1h)
+				b = from(bucket:"flux") |> range(start:-1h)
+				join(tables:{a:a,b:b}, on: ["t1"], suffixes: ["_left"])
+			`,
+			WantErr: true,
+		},
+		{
+			Name: "empty suffix",
+			Raw: `
+				a = from(bucket:"flux") |> range(start:-1h)
+				b = from(bucket:"flux") |> range(start:-1h)
+				join(tables:{a:a,b:b}, on: ["t1"], suffixes: ["_left", ""])
+			`,
+			WantErr: true,
+		},
+		{
+			Name: "same suffixes",
+			Raw: `
+				a = from(bucket:"flux") |> range(start:-1h)
+				b = from(bucket:"flux") |> range(start:-1h)
+				join(tables:{a:a,b:b}, on: ["t1"], suffixes: ["_x", "_x"])
+			`,
+			WantErr: true,
+		},
 	}
 	for _, tc := range tests {
 		tc := tc
@@ -200,6 +251,107 @@ func TestJoinOperation_Marshaling(t *testing.T) {
 	querytest.OperationMarshalingTestHelper(t, data, op)
 }
 
+func TestJoinOperation_MarshalingOptions(t *testing.T) {
+	data := []byte(`{
+		"id":"join",
+		"kind":"join",
+		"spec":{
+			"on":["t1"],
+			"tableNames":{"sum1":"a","count3":"b"},
+			"suffixes":["_left","_right"],
+			"allowMissing":true,
+			"bufferSize":100,
+			"onOverflow":"evict-oldest",
+			"onDuplicate":"keep-last",
+			"onNullKey":"treat-as-equal",
+			"crossRowLimit":-1,
+			"missingColumnType":"float"
+		}
+	}`)
+	op := &flux.Operation{
+		ID: "join",
+		Spec: &universe.JoinOpSpec{
+			On:                []string{"t1"},
+			TableNames:        map[flux.OperationID]string{"sum1": "a", "count3": "b"},
+			Suffixes:          []string{"_left", "_right"},
+			AllowMissing:      true,
+			BufferSize:        100,
+			OnOverflow:        universe.EvictOldestOnOverflow,
+			OnDuplicate:       universe.KeepLastOnDuplicate,
+			OnNullKey:         universe.TreatAsEqualOnNullKey,
+			CrossRowLimit:     -1,
+			MissingColumnType: "float",
+		},
+	}
+	querytest.OperationMarshalingTestHelper(t, data, op)
+}
+
+func TestJoinOperation_ProcedureSpec(t *testing.T) {
+	fspec := &flux.Spec{
+		Operations: []*flux.Operation{
+			{
+				ID:   "from0",
+				Spec: &influxdb.FromOpSpec{Bucket: influxdb.NameOrID{Name: "dbA"}},
+			},
+			{
+				ID:   "from1",
+				Spec: &influxdb.FromOpSpec{Bucket: influxdb.NameOrID{Name: "dbB"}},
+			},
+			{
+				ID: "join2",
+				Spec: &universe.JoinOpSpec{
+					On:                []string{"t1"},
+					TableNames:        map[flux.OperationID]string{"from0": "a", "from1": "b"},
+					Method:            "left",
+					Suffixes:          []string{"_left", "_right"},
+					AllowMissing:      true,
+					BufferSize:        100,
+					OnOverflow:        universe.EvictOldestOnOverflow,
+					OnDuplicate:       universe.KeepLastOnDuplicate,
+					OnNullKey:         universe.TreatAsEqualOnNullKey,
+					CrossRowLimit:     10,
+					MissingColumnType: "float",
+				},
+			},
+		},
+		Edges: []flux.Edge{
+			{Parent: "from0", Child: "join2"},
+			{Parent: "from1", Child: "join2"},
+		},
+	}
+	ps, err := plan.NewLogicalPlanner().CreateInitialPlan(fspec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ps.Roots) != 1 {
+		t.Fatalf("expected one root, got %d", len(ps.Roots))
+	}
+	var got plan.ProcedureSpec
+	for root := range ps.Roots {
+		got = root.ProcedureSpec()
+	}
+
+	want := &universe.MergeJoinProcedureSpec{
+		TableNames:        []string{"a", "b"},
+		On:                []string{"t1"},
+		Method:            "left",
+		Suffixes:          []string{"_left", "_right"},
+		AllowMissing:      true,
+		BufferSize:        100,
+		OnOverflow:        universe.EvictOldestOnOverflow,
+		OnDuplicate:       universe.KeepLastOnDuplicate,
+		OnNullKey:         universe.TreatAsEqualOnNullKey,
+		CrossRowLimit:     10,
+		MissingColumnType: "float",
+	}
+	if !cmp.Equal(want, got) {
+		t.Errorf("unexpected procedure spec -want/+got:\n%s", cmp.Diff(want, got))
+	}
+	if !cmp.Equal(want, got.Copy()) {
+		t.Errorf("unexpected procedure spec copy -want/+got:\n%s", cmp.Diff(want, got.Copy()))
+	}
+}
+
 func TestMergeJoin_Process(t *testing.T) {
 	tableNames := []string{"a", "b"}
 
@@ -210,6 +362,9 @@ func TestMergeJoin_Process(t *testing.T) {
 		data1   []*executetest.Table // data from parent 1
 		want    []*executetest.Table
 		wantErr error // expected error
+		// wantBuffered are the tables left in the buffers
+		// after the join. They are only checked when set.
+		wantBuffered []*executetest.Table
 	}{
 		{
 			name: "simple inner",
@@ -357,6 +512,59 @@ func TestMergeJoin_Process(t *testing.T) {
 			spec: &universe.MergeJoinProcedureSpec{
 				On:         []string{"_time"},
 				TableNames: tableNames,
+				OnNullKey:  universe.SkipOnNullKey,
+			},
+			data0: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{nil, 100.0},
+						{execute.Time(1), 1.0},
+						{execute.Time(2), 2.0},
+						{nil, 200.0},
+						{execute.Time(3), 3.0},
+					},
+				},
+			},
+			data1: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 10.0},
+						{nil, 300.0},
+						{execute.Time(2), 20.0},
+						{execute.Time(3), 30.0},
+						{nil, 400.0},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value_a", Type: flux.TFloat},
+						{Label: "_value_b", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 1.0, 10.0},
+						{execute.Time(2), 2.0, 20.0},
+						{execute.Time(3), 3.0, 30.0},
+					},
+				},
+			},
+		},
+		{
+			name: "inner with nulls in join columns treated as equal",
+			spec: &universe.MergeJoinProcedureSpec{
+				On:         []string{"_time"},
+				TableNames: tableNames,
+				OnNullKey:  universe.TreatAsEqualOnNullKey,
 			},
 			data0: []*executetest.Table{
 				{
@@ -396,6 +604,10 @@ func TestMergeJoin_Process(t *testing.T) {
 						{Label: "_value_b", Type: flux.TFloat},
 					},
 					Data: [][]interface{}{
+						{nil, 100.0, 300.0},
+						{nil, 100.0, 400.0},
+						{nil, 200.0, 300.0},
+						{nil, 200.0, 400.0},
 						{execute.Time(1), 1.0, 10.0},
 						{execute.Time(2), 2.0, 20.0},
 						{execute.Time(3), 3.0, 30.0},
@@ -403,6 +615,126 @@ func TestMergeJoin_Process(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "inner with nulls in join columns as error",
+			spec: &universe.MergeJoinProcedureSpec{
+				On:         []string{"_time"},
+				TableNames: tableNames,
+				OnNullKey:  universe.ErrorOnNullKey,
+			},
+			data0: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{nil, 100.0},
+						{execute.Time(1), 1.0},
+						{execute.Time(2), 2.0},
+						{nil, 200.0},
+						{execute.Time(3), 3.0},
+					},
+				},
+			},
+			data1: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 10.0},
+						{nil, 300.0},
+						{execute.Time(2), 20.0},
+						{execute.Time(3), 30.0},
+						{nil, 400.0},
+					},
+				},
+			},
+			wantErr: errors.New(`join column "_time" has 2 null values in table {} of "a"`),
+		},
+		{
+			name: "empty on",
+			spec: &universe.MergeJoinProcedureSpec{
+				On:         []string{},
+				TableNames: tableNames,
+			},
+			data0: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 1.0},
+					},
+				},
+			},
+			data1: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 10.0},
+					},
+				},
+			},
+			wantErr: errors.New("at least one column in 'on' column list is required"),
+		},
+		{
+			name: "nulls in group key treated as equal",
+			spec: &universe.MergeJoinProcedureSpec{
+				On:         []string{"_time", "t"},
+				TableNames: tableNames,
+				OnNullKey:  universe.TreatAsEqualOnNullKey,
+			},
+			data0: []*executetest.Table{
+				{
+					KeyCols: []string{"t"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "t", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 1.0, nil},
+						{execute.Time(2), 2.0, nil},
+					},
+				},
+			},
+			data1: []*executetest.Table{
+				{
+					KeyCols: []string{"t"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "t", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 10.0, nil},
+						{execute.Time(2), 20.0, nil},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					KeyCols: []string{"t"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value_a", Type: flux.TFloat},
+						{Label: "_value_b", Type: flux.TFloat},
+						{Label: "t", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 1.0, 10.0, nil},
+						{execute.Time(2), 2.0, 20.0, nil},
+					},
+				},
+			},
+		},
 		{
 			name: "disjoint join and group columns with nulls",
 			spec: &universe.MergeJoinProcedureSpec{
@@ -642,6 +974,58 @@ func TestMergeJoin_Process(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "inner with existing suffixed column",
+			spec: &universe.MergeJoinProcedureSpec{
+				On:         []string{"_time", "t1"},
+				TableNames: tableNames,
+			},
+			data0: []*executetest.Table{
+				{
+					KeyCols: []string{"t1"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "_value_a", Type: flux.TFloat},
+						{Label: "t1", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 1.0, 100.0, "a"},
+						{execute.Time(2), 2.0, 200.0, "a"},
+					},
+				},
+			},
+			data1: []*executetest.Table{
+				{
+					KeyCols: []string{"t1"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "t1", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 10.0, "a"},
+						{execute.Time(2), 20.0, "a"},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					KeyCols: []string{"t1"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value_a", Type: flux.TFloat},
+						{Label: "_value_a_1", Type: flux.TFloat},
+						{Label: "_value_b", Type: flux.TFloat},
+						{Label: "t1", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 100.0, 1.0, 10.0, "a"},
+						{execute.Time(2), 200.0, 2.0, 20.0, "a"},
+					},
+				},
+			},
+		},
 		{
 			name: "inner with common tags and nulls",
 			spec: &universe.MergeJoinProcedureSpec{
@@ -797,7 +1181,83 @@ func TestMergeJoin_Process(t *testing.T) {
 			},
 		},
 		{
-			name: "join with mismatched schemas with null in group key",
+			name: "join with mismatched schemas and suffixes",
+			spec: &universe.MergeJoinProcedureSpec{
+				On:         []string{"_time"},
+				TableNames: tableNames,
+				Suffixes:   []string{"_left", "_right"},
+			},
+			data0: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "key", Type: flux.TString},
+					},
+					KeyCols: []string{"key"},
+					Data: [][]interface{}{
+						{execute.Time(1), 1.0, "foo"},
+						{execute.Time(2), 2.0, "foo"},
+					},
+				},
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					KeyCols: []string{},
+					Data: [][]interface{}{
+						{execute.Time(1), 1.5},
+						{execute.Time(2), 2.5},
+					},
+				},
+			},
+			data1: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "key", Type: flux.TString},
+					},
+					KeyCols: []string{"key"},
+					Data: [][]interface{}{
+						{execute.Time(1), 10.0, "bar"},
+						{execute.Time(2), 20.0, "bar"},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value_left", Type: flux.TFloat},
+						{Label: "_value_right", Type: flux.TFloat},
+						{Label: "key_left", Type: flux.TString},
+						{Label: "key_right", Type: flux.TString},
+					},
+					KeyCols: []string{"key_left", "key_right"},
+					Data: [][]interface{}{
+						{execute.Time(1), 1.0, 10.0, "foo", "bar"},
+						{execute.Time(2), 2.0, 20.0, "foo", "bar"},
+					},
+				},
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value_left", Type: flux.TFloat},
+						{Label: "_value_right", Type: flux.TFloat},
+						{Label: "key", Type: flux.TString},
+					},
+					KeyCols: []string{"key"},
+					Data: [][]interface{}{
+						{execute.Time(1), 1.5, 10.0, "bar"},
+						{execute.Time(2), 2.5, 20.0, "bar"},
+					},
+				},
+			},
+		},
+		{
+			name: "join with mismatched schemas with null in group key",
 			spec: &universe.MergeJoinProcedureSpec{
 				On:         []string{"_time"},
 				TableNames: tableNames,
@@ -1622,6 +2082,32 @@ func TestMergeJoin_Process(t *testing.T) {
 					},
 				},
 			},
+			// The tables for "a" and "b" are evicted once the tables
+			// for the next tag are seen and they have been joined.
+			wantBuffered: []*executetest.Table{
+				{
+					KeyCols: []string{"tag"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "tag", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(3), 3.0, "c"},
+					},
+				},
+				{
+					KeyCols: []string{"tag"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "tag", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(3), 3.0, "c"},
+					},
+				},
+			},
 		},
 		{
 			name: "two failures",
@@ -1747,67 +2233,2767 @@ func TestMergeJoin_Process(t *testing.T) {
 				},
 			},
 		},
-	}
-	for _, tc := range testCases {
-		tc := tc
-		t.Run(tc.name, func(t *testing.T) {
-			id0 := executetest.RandomDatasetID()
-			id1 := executetest.RandomDatasetID()
-
-			parents := []execute.DatasetID{
-				execute.DatasetID(id0),
-				execute.DatasetID(id1),
-			}
-
-			tableNames := make(map[execute.DatasetID]string, len(tc.spec.TableNames))
-			for i, name := range tc.spec.TableNames {
-				tableNames[parents[i]] = name
-			}
-
-			d := executetest.NewDataset(executetest.RandomDatasetID())
-			c := universe.NewMergeJoinCache(executetest.UnlimitedAllocator, parents, tableNames, tc.spec.On)
-			c.SetTriggerSpec(plan.DefaultTriggerSpec)
-			jt := universe.NewMergeJoinTransformation(d, c, tc.spec, parents, tableNames)
-
-			l := len(tc.data0)
-			if len(tc.data1) > l {
-				l = len(tc.data1)
-			}
-			var err error
-			for i := 0; i < l; i++ {
-				if i < len(tc.data0) {
-					if err = jt.Process(parents[0], tc.data0[i]); err != nil {
-						break
-					}
-				}
-				if i < len(tc.data1) {
-					if err = jt.Process(parents[1], tc.data1[i]); err != nil {
-						break
-					}
-				}
-			}
-			jt.Finish(parents[0], err)
-			jt.Finish(parents[1], err)
-
-			got, err := executetest.TablesFromCache(c)
-			if err != nil {
-				if tc.wantErr == nil {
-					t.Fatalf("got unexpected error: '%s'", err)
-				} else if err.Error() != tc.wantErr.Error() {
-					t.Fatalf("got unexpected error: wanted '%s', got '%s'", tc.wantErr, err)
-				}
-			} else if tc.wantErr != nil {
-				t.Fatalf("expected error '%s', but got none", tc.wantErr)
-			}
-
-			executetest.NormalizeTables(got)
-			executetest.NormalizeTables(tc.want)
-
-			sort.Sort(executetest.SortedTables(got))
-			sort.Sort(executetest.SortedTables(tc.want))
-
-			if !cmp.Equal(tc.want, got) {
-				t.Errorf("unexpected tables -want/+got\n%s", cmp.Diff(tc.want, got))
+		{
+			name: "unsorted inputs",
+			spec: &universe.MergeJoinProcedureSpec{
+				On:         []string{"_time"},
+				TableNames: tableNames,
+			},
+			data0: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(3), 3.0},
+						{execute.Time(1), 1.0},
+						{execute.Time(2), 2.0},
+					},
+				},
+			},
+			data1: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(2), 20.0},
+						{execute.Time(3), 30.0},
+						{execute.Time(1), 10.0},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value_a", Type: flux.TFloat},
+						{Label: "_value_b", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 1.0, 10.0},
+						{execute.Time(2), 2.0, 20.0},
+						{execute.Time(3), 3.0, 30.0},
+					},
+				},
+			},
+		},
+		{
+			name: "sorted inputs",
+			spec: &universe.MergeJoinProcedureSpec{
+				On:         []string{"_time"},
+				TableNames: tableNames,
+				Sorted:     true,
+			},
+			data0: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 1.0},
+						{execute.Time(2), 2.0},
+						{execute.Time(3), 3.0},
+					},
+				},
+			},
+			data1: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 10.0},
+						{execute.Time(3), 30.0},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value_a", Type: flux.TFloat},
+						{Label: "_value_b", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 1.0, 10.0},
+						{execute.Time(3), 3.0, 30.0},
+					},
+				},
+			},
+		},
+		{
+			name: "left with times missing on the right",
+			spec: &universe.MergeJoinProcedureSpec{
+				On:         []string{"_time"},
+				TableNames: tableNames,
+				Method:     "left",
+			},
+			data0: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 1.0},
+						{execute.Time(2), 2.0},
+						{execute.Time(3), 3.0},
+						{execute.Time(4), 4.0},
+					},
+				},
+			},
+			data1: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 10.0},
+						{execute.Time(3), 30.0},
+						{execute.Time(5), 50.0},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value_a", Type: flux.TFloat},
+						{Label: "_value_b", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 1.0, 10.0},
+						{execute.Time(2), 2.0, nil},
+						{execute.Time(3), 3.0, 30.0},
+						{execute.Time(4), 4.0, nil},
+					},
+				},
+			},
+		},
+		{
+			name: "right with times missing on the left",
+			spec: &universe.MergeJoinProcedureSpec{
+				On:         []string{"_time"},
+				TableNames: tableNames,
+				Method:     "right",
+			},
+			data0: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 1.0},
+						{execute.Time(2), 2.0},
+					},
+				},
+			},
+			data1: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(0), 0.0},
+						{execute.Time(2), 20.0},
+						{execute.Time(3), 30.0},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value_a", Type: flux.TFloat},
+						{Label: "_value_b", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(0), nil, 0.0},
+						{execute.Time(2), 2.0, 20.0},
+						{execute.Time(3), nil, 30.0},
+					},
+				},
+			},
+		},
+		{
+			name: "full where neither side covers the other",
+			spec: &universe.MergeJoinProcedureSpec{
+				On:         []string{"_time", "t1"},
+				TableNames: tableNames,
+				Method:     "full",
+			},
+			data0: []*executetest.Table{
+				{
+					KeyCols: []string{"t1"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "t1", Type: flux.TString},
+						{Label: "t2", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 1.0, "a", "x"},
+						{execute.Time(2), 2.0, "a", "x"},
+						{execute.Time(3), 3.0, "a", "x"},
+					},
+				},
+			},
+			data1: []*executetest.Table{
+				{
+					KeyCols: []string{"t1"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "t1", Type: flux.TString},
+						{Label: "t2", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(2), 20.0, "a", "y"},
+						{execute.Time(3), 30.0, "a", "y"},
+						{execute.Time(4), 40.0, "a", "y"},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					KeyCols: []string{"t1"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value_a", Type: flux.TFloat},
+						{Label: "_value_b", Type: flux.TFloat},
+						{Label: "t1", Type: flux.TString},
+						{Label: "t2_a", Type: flux.TString},
+						{Label: "t2_b", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 1.0, nil, "a", "x", nil},
+						{execute.Time(2), 2.0, 20.0, "a", "x", "y"},
+						{execute.Time(3), 3.0, 30.0, "a", "x", "y"},
+						{execute.Time(4), nil, 40.0, "a", nil, "y"},
+					},
+				},
+			},
+		},
+		{
+			name: "full with unpaired tables",
+			spec: &universe.MergeJoinProcedureSpec{
+				On:         []string{"_time", "t1"},
+				TableNames: tableNames,
+				Method:     "full",
+			},
+			data0: []*executetest.Table{
+				{
+					KeyCols: []string{"t1"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "t1", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 1.0, "a"},
+						{execute.Time(2), 2.0, "a"},
+					},
+				},
+				{
+					KeyCols: []string{"t1"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "t1", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 1.5, "b"},
+					},
+				},
+			},
+			data1: []*executetest.Table{
+				{
+					KeyCols: []string{"t1"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "t1", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(2), 20.0, "a"},
+					},
+				},
+				{
+					KeyCols: []string{"t1"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "t1", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 10.0, "c"},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					KeyCols: []string{"t1"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value_a", Type: flux.TFloat},
+						{Label: "_value_b", Type: flux.TFloat},
+						{Label: "t1", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 1.0, nil, "a"},
+						{execute.Time(2), 2.0, 20.0, "a"},
+					},
+				},
+				{
+					KeyCols: []string{"t1"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value_a", Type: flux.TFloat},
+						{Label: "_value_b", Type: flux.TFloat},
+						{Label: "t1", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 1.5, nil, "b"},
+					},
+				},
+				{
+					KeyCols: []string{"t1"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value_a", Type: flux.TFloat},
+						{Label: "_value_b", Type: flux.TFloat},
+						{Label: "t1", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), nil, 10.0, "c"},
+					},
+				},
+			},
+		},
+		{
+			name: "left with right only columns",
+			spec: &universe.MergeJoinProcedureSpec{
+				On:         []string{"_time", "t1"},
+				TableNames: tableNames,
+				Method:     "left",
+			},
+			data0: []*executetest.Table{
+				{
+					KeyCols: []string{"t1"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "t1", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), "a"},
+					},
+				},
+			},
+			data1: []*executetest.Table{
+				{
+					KeyCols: []string{"t1"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "t1", Type: flux.TString},
+						{Label: "x", Type: flux.TInt},
+					},
+					Data: [][]interface{}{
+						{execute.Time(2), "b", int64(1)},
+					},
+				},
+				{
+					KeyCols: []string{"t1"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "t1", Type: flux.TString},
+						{Label: "y", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(2), "c", 1.0},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					KeyCols: []string{"t1"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "t1", Type: flux.TString},
+						{Label: "x", Type: flux.TInt},
+						{Label: "y", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), "a", nil, nil},
+					},
+				},
+			},
+		},
+		{
+			name: "left with conflicting right column types",
+			spec: &universe.MergeJoinProcedureSpec{
+				On:         []string{"_time", "t1"},
+				TableNames: tableNames,
+				Method:     "left",
+			},
+			data0: []*executetest.Table{
+				{
+					KeyCols: []string{"t1"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "t1", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), "a"},
+					},
+				},
+			},
+			data1: []*executetest.Table{
+				{
+					KeyCols: []string{"t1"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "t1", Type: flux.TString},
+						{Label: "x", Type: flux.TInt},
+					},
+					Data: [][]interface{}{
+						{execute.Time(2), "b", int64(1)},
+					},
+				},
+				{
+					KeyCols: []string{"t1"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "t1", Type: flux.TString},
+						{Label: "x", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(2), "c", "one"},
+					},
+				},
+			},
+			wantErr: errors.New(`column "x" has type int and type string in the tables of "b"`),
+		},
+		{
+			name: "left with on column missing from both streams",
+			spec: &universe.MergeJoinProcedureSpec{
+				On:           []string{"_time", "host"},
+				TableNames:   tableNames,
+				Method:       "left",
+				AllowMissing: true,
+			},
+			data0: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 1.0},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "host", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 1.0, nil},
+					},
+				},
+			},
+		},
+		{
+			name: "left with on column missing from both streams and a missing column type",
+			spec: &universe.MergeJoinProcedureSpec{
+				On:                []string{"_time", "host"},
+				TableNames:        tableNames,
+				Method:            "left",
+				AllowMissing:      true,
+				MissingColumnType: "int",
+			},
+			data0: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 1.0},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "host", Type: flux.TInt},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 1.0, nil},
+					},
+				},
+			},
+		},
+		{
+			name: "left with on column missing on the left",
+			spec: &universe.MergeJoinProcedureSpec{
+				On:           []string{"_time", "host"},
+				TableNames:   tableNames,
+				Method:       "left",
+				AllowMissing: true,
+			},
+			data0: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 1.0},
+					},
+				},
+			},
+			data1: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "host", Type: flux.TInt},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 10.0, int64(1)},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value_a", Type: flux.TFloat},
+						{Label: "_value_b", Type: flux.TFloat},
+						{Label: "host", Type: flux.TInt},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), 1.0, nil, nil},
+					},
+				},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		for _, strategy := range []string{universe.MergeJoinStrategy, universe.HashJoinStrategy} {
+			tc, strategy := tc, strategy
+			t.Run(tc.name+"/"+strategy, func(t *testing.T) {
+				// The planner validates the spec, so an invalid
+				// spec is never used to create the transformation.
+				if err := tc.spec.Validate(); err != nil {
+					if tc.wantErr == nil {
+						t.Fatalf("got unexpected planning error: '%s'", err)
+					} else if err.Error() != tc.wantErr.Error() {
+						t.Fatalf("got unexpected planning error: wanted '%s', got '%s'", tc.wantErr, err)
+					}
+					return
+				}
+
+				id0 := executetest.RandomDatasetID()
+				id1 := executetest.RandomDatasetID()
+
+				parents := []execute.DatasetID{
+					execute.DatasetID(id0),
+					execute.DatasetID(id1),
+				}
+
+				tableNames := make(map[execute.DatasetID]string, len(tc.spec.TableNames))
+				for i, name := range tc.spec.TableNames {
+					tableNames[parents[i]] = name
+				}
+
+				d := executetest.NewDataset(executetest.RandomDatasetID())
+				c := universe.NewMergeJoinCache(executetest.UnlimitedAllocator, parents, tableNames, tc.spec.On, 0)
+				if err := c.SetSuffixes(tc.spec.Suffixes); err != nil {
+					t.Fatal(err)
+				}
+				if err := c.SetOnNullKey(tc.spec.OnNullKey); err != nil {
+					t.Fatal(err)
+				}
+				if err := c.SetMissingColumnType(tc.spec.MissingColumnType); err != nil {
+					t.Fatal(err)
+				}
+				c.SetTriggerSpec(plan.DefaultTriggerSpec)
+				var jt execute.Transformation
+				if strategy == universe.HashJoinStrategy {
+					jt = universe.NewHashJoinTransformation(d, c, tc.spec, parents, tableNames)
+				} else {
+					jt = universe.NewMergeJoinTransformation(d, c, tc.spec, parents, tableNames)
+				}
+
+				// Each test case is run with every strategy
+				// and a table can only be read once.
+				process := func(id execute.DatasetID, tbl *executetest.Table) error {
+					cpy := *tbl
+					return jt.Process(id, &cpy)
+				}
+
+				l := len(tc.data0)
+				if len(tc.data1) > l {
+					l = len(tc.data1)
+				}
+				var err error
+				for i := 0; i < l; i++ {
+					if i < len(tc.data0) {
+						if err = process(parents[0], tc.data0[i]); err != nil {
+							break
+						}
+					}
+					if i < len(tc.data1) {
+						if err = process(parents[1], tc.data1[i]); err != nil {
+							break
+						}
+					}
+				}
+				jt.Finish(parents[0], err)
+				jt.Finish(parents[1], err)
+				// The error of finishing the join is only
+				// checked when the tables were processed.
+				finishErr := d.FinishedErr
+				if err != nil {
+					finishErr = nil
+				}
+
+				got, err := executetest.TablesFromCache(c)
+				if err == nil {
+					err = finishErr
+				}
+				if err != nil {
+					if tc.wantErr == nil {
+						t.Fatalf("got unexpected error: '%s'", err)
+					} else if err.Error() != tc.wantErr.Error() {
+						t.Fatalf("got unexpected error: wanted '%s', got '%s'", tc.wantErr, err)
+					}
+				} else if tc.wantErr != nil {
+					t.Fatalf("expected error '%s', but got none", tc.wantErr)
+				}
+
+				executetest.NormalizeTables(got)
+				executetest.NormalizeTables(tc.want)
+
+				sort.Sort(executetest.SortedTables(got))
+				sort.Sort(executetest.SortedTables(tc.want))
+
+				want := tc.want
+				if strategy == universe.HashJoinStrategy {
+					// A hash join does not sort the joined rows.
+					want = sortRows(want)
+					got = sortRows(got)
+				}
+				if !cmp.Equal(want, got) {
+					t.Errorf("unexpected tables -want/+got\n%s", cmp.Diff(want, got))
+				}
+
+				if tc.wantBuffered != nil {
+					var buffered []*executetest.Table
+					if err := c.ForEachBuffered(func(key flux.GroupKey, tables []flux.Table) error {
+						for _, tbl := range tables {
+							cb, err := executetest.ConvertTable(tbl)
+							if err != nil {
+								return err
+							}
+							buffered = append(buffered, cb)
+						}
+						return nil
+					}); err != nil {
+						t.Fatal(err)
+					}
+					if got, want := c.TableCount(), len(tc.wantBuffered); got != want {
+						t.Errorf("unexpected table count -want/+got:\n\t- %d\n\t+ %d", want, got)
+					}
+
+					executetest.NormalizeTables(buffered)
+					executetest.NormalizeTables(tc.wantBuffered)
+					if !cmp.Equal(tc.wantBuffered, buffered) {
+						t.Errorf("unexpected buffered tables -want/+got\n%s", cmp.Diff(tc.wantBuffered, buffered))
+					}
+				}
+			})
+		}
+	}
+}
+
+// sortRows returns a copy of the tables with the rows of each table
+// in the order of their formatted values.
+func sortRows(tables []*executetest.Table) []*executetest.Table {
+	sorted := make([]*executetest.Table, len(tables))
+	for i, tbl := range tables {
+		cpy := *tbl
+		cpy.Data = make([][]interface{}, len(tbl.Data))
+		copy(cpy.Data, tbl.Data)
+		sort.SliceStable(cpy.Data, func(i, j int) bool {
+			return fmt.Sprint(cpy.Data[i]...) < fmt.Sprint(cpy.Data[j]...)
+		})
+		sorted[i] = &cpy
+	}
+	return sorted
+}
+
+func TestMergeJoin_FinishError(t *testing.T) {
+	leftErr := errors.New("left error")
+	rightErr := errors.New("right error")
+
+	type finish struct {
+		parent int
+		err    error
+	}
+	testCases := []struct {
+		name    string
+		finish  []finish
+		wantErr error
+	}{
+		{
+			name:   "no errors",
+			finish: []finish{{0, nil}, {1, nil}},
+		},
+		{
+			name:    "left error first",
+			finish:  []finish{{0, leftErr}, {1, nil}},
+			wantErr: leftErr,
+		},
+		{
+			name:    "left error last",
+			finish:  []finish{{1, nil}, {0, leftErr}},
+			wantErr: leftErr,
+		},
+		{
+			name:    "right error first",
+			finish:  []finish{{1, rightErr}, {0, nil}},
+			wantErr: rightErr,
+		},
+		{
+			name:    "right error last",
+			finish:  []finish{{0, nil}, {1, rightErr}},
+			wantErr: rightErr,
+		},
+		{
+			name:    "both errors left first",
+			finish:  []finish{{0, leftErr}, {1, rightErr}},
+			wantErr: leftErr,
+		},
+		{
+			name:    "both errors right first",
+			finish:  []finish{{1, rightErr}, {0, leftErr}},
+			wantErr: rightErr,
+		},
+		{
+			name:    "repeated finish",
+			finish:  []finish{{0, leftErr}, {0, rightErr}, {1, nil}, {1, rightErr}},
+			wantErr: leftErr,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			spec := &universe.MergeJoinProcedureSpec{
+				On:         []string{"_time"},
+				TableNames: []string{"a", "b"},
+			}
+			parents := []execute.DatasetID{
+				executetest.RandomDatasetID(),
+				executetest.RandomDatasetID(),
+			}
+			tableNames := map[execute.DatasetID]string{
+				parents[0]: "a",
+				parents[1]: "b",
+			}
+
+			// The test dataset panics if it is finished more than once.
+			d := executetest.NewDataset(executetest.RandomDatasetID())
+			c := universe.NewMergeJoinCache(executetest.UnlimitedAllocator, parents, tableNames, spec.On, 0)
+			c.SetTriggerSpec(plan.DefaultTriggerSpec)
+			jt := universe.NewMergeJoinTransformation(d, c, spec, parents, tableNames)
+
+			for _, f := range tc.finish {
+				jt.Finish(parents[f.parent], f.err)
+			}
+
+			if !d.Finished {
+				t.Fatal("expected dataset to be finished")
+			}
+			if got, want := d.FinishedErr, tc.wantErr; got != want {
+				t.Errorf("unexpected error -want/+got:\n\t- %v\n\t+ %v", want, got)
+			}
+		})
+	}
+}
+
+func TestHashJoin_MemoryLimit(t *testing.T) {
+	// The rows never match so a merge join only
+	// uses the memory to buffer the tables.
+	table := func(start int) *executetest.Table {
+		tbl := &executetest.Table{
+			ColMeta: []flux.ColMeta{
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_value", Type: flux.TFloat},
+			},
+		}
+		for i := start; i < start+1000; i++ {
+			tbl.Data = append(tbl.Data, []interface{}{execute.Time(i), float64(i)})
+		}
+		return tbl
+	}
+
+	join := func(strategy string, mem *memory.ResourceAllocator) error {
+		spec := &universe.MergeJoinProcedureSpec{
+			On:         []string{"_time"},
+			TableNames: []string{"a", "b"},
+			Strategy:   strategy,
+		}
+		parents := []execute.DatasetID{
+			executetest.RandomDatasetID(),
+			executetest.RandomDatasetID(),
+		}
+		tableNames := map[execute.DatasetID]string{
+			parents[0]: "a",
+			parents[1]: "b",
+		}
+
+		d := executetest.NewDataset(executetest.RandomDatasetID())
+		c := universe.NewMergeJoinCache(mem, parents, tableNames, spec.On, 0)
+		c.SetTriggerSpec(plan.DefaultTriggerSpec)
+		var jt execute.Transformation
+		if strategy == universe.HashJoinStrategy {
+			jt = universe.NewHashJoinTransformation(d, c, spec, parents, tableNames)
+		} else {
+			jt = universe.NewMergeJoinTransformation(d, c, spec, parents, tableNames)
+		}
+		if err := jt.Process(parents[0], table(0)); err != nil {
+			return err
+		}
+		if err := jt.Process(parents[1], table(1000)); err != nil {
+			return err
+		}
+		jt.Finish(parents[0], nil)
+		jt.Finish(parents[1], nil)
+
+		_, err := executetest.TablesFromCache(c)
+		return err
+	}
+
+	// Measure the memory used to buffer the tables.
+	mem := &memory.ResourceAllocator{}
+	if err := join(universe.MergeJoinStrategy, mem); err != nil {
+		t.Fatal(err)
+	}
+	limit := mem.MaxAllocated() + 32*1024
+
+	if err := join(universe.MergeJoinStrategy, &memory.ResourceAllocator{Limit: &limit}); err != nil {
+		t.Fatalf("unexpected error from merge join: %s", err)
+	}
+	err := join(universe.HashJoinStrategy, &memory.ResourceAllocator{Limit: &limit})
+	if err == nil {
+		t.Fatal("expected error from hash join, got none")
+	}
+	if want, got := codes.ResourceExhausted, flux.ErrorCode(err); want != got {
+		t.Errorf("unexpected error code -want/+got\n\t- %s\n\t+ %s", want, got)
+	}
+}
+
+func TestMergeJoin_MemoryLimit(t *testing.T) {
+	// Each table has a different tag and a long string value
+	// so the size of the strings dominates the size of a table.
+	table := func(tag string, n int) *executetest.Table {
+		tbl := &executetest.Table{
+			KeyCols: []string{"t"},
+			ColMeta: []flux.ColMeta{
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_value", Type: flux.TString},
+				{Label: "t", Type: flux.TString},
+			},
+		}
+		for i := 0; i < n; i++ {
+			tbl.Data = append(tbl.Data, []interface{}{
+				execute.Time(i), strings.Repeat("v", 64) + strconv.Itoa(i), tag,
+			})
+		}
+		return tbl
+	}
+
+	join := func(mem *memory.ResourceAllocator, tags []string, n int) error {
+		spec := &universe.MergeJoinProcedureSpec{
+			On:         []string{"_time", "t"},
+			TableNames: []string{"a", "b"},
+		}
+		parents := []execute.DatasetID{
+			executetest.RandomDatasetID(),
+			executetest.RandomDatasetID(),
+		}
+		tableNames := map[execute.DatasetID]string{
+			parents[0]: "a",
+			parents[1]: "b",
+		}
+
+		d := executetest.NewDataset(executetest.RandomDatasetID())
+		c := universe.NewMergeJoinCache(mem, parents, tableNames, spec.On, 0)
+		c.SetTriggerSpec(plan.DefaultTriggerSpec)
+		jt := universe.NewMergeJoinTransformation(d, c, spec, parents, tableNames)
+		for _, parent := range parents {
+			for _, tag := range tags {
+				if err := jt.Process(parent, table(tag, n)); err != nil {
+					return err
+				}
+			}
+		}
+		jt.Finish(parents[0], nil)
+		jt.Finish(parents[1], nil)
+
+		_, err := executetest.TablesFromCache(c)
+		return err
+	}
+
+	t.Run("oversized join", func(t *testing.T) {
+		limit := int64(64 * 1024)
+		err := join(&memory.ResourceAllocator{Limit: &limit}, []string{"a"}, 1000)
+		if err == nil {
+			t.Fatal("expected error from join, got none")
+		}
+		if want, got := codes.ResourceExhausted, flux.ErrorCode(err); want != got {
+			t.Errorf("unexpected error code -want/+got\n\t- %s\n\t+ %s", want, got)
+		}
+		if want, got := `join failed to buffer table {t=a} of "a"`, err.Error(); !strings.HasPrefix(got, want) {
+			t.Errorf("unexpected error -want/+got\n\t- %s\n\t+ %s", want, got)
+		}
+	})
+
+	t.Run("evicted tables are released", func(t *testing.T) {
+		// The tables of every tag but the last are evicted once they
+		// are joined, so only the last pair of tables is still buffered
+		// along with the joined tables.
+		mem := &memory.ResourceAllocator{}
+		tags := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+		if err := join(mem, tags, 100); err != nil {
+			t.Fatal(err)
+		}
+		if got, max := mem.Allocated(), mem.MaxAllocated(); got > max/2 {
+			t.Errorf("expected the evicted tables to be released, %d of %d bytes are still allocated", got, max)
+		}
+	})
+}
+
+func TestMergeJoin_Watermark(t *testing.T) {
+	// Each tag has ten rows with times after those of the previous tag.
+	table := func(tag string, start int, value float64) *executetest.Table {
+		tbl := &executetest.Table{
+			KeyCols: []string{"t"},
+			ColMeta: []flux.ColMeta{
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_value", Type: flux.TFloat},
+				{Label: "t", Type: flux.TString},
+			},
+		}
+		for i := start; i < start+10; i++ {
+			tbl.Data = append(tbl.Data, []interface{}{execute.Time(i), value, tag})
+		}
+		return tbl
+	}
+
+	key := func(tag string) flux.GroupKey {
+		return execute.NewGroupKey(
+			[]flux.ColMeta{{Label: "t", Type: flux.TString}},
+			[]values.Value{values.NewString(tag)},
+		)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		method string
+		// evicted is set when the joined tables
+		// are evicted once they are complete.
+		evicted bool
+	}{
+		{
+			name:    "inner",
+			evicted: true,
+		},
+		{
+			name:   "left",
+			method: "left",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			spec := &universe.MergeJoinProcedureSpec{
+				On:         []string{"_time", "t"},
+				TableNames: []string{"a", "b"},
+				Method:     tc.method,
+			}
+			parents := []execute.DatasetID{
+				executetest.RandomDatasetID(),
+				executetest.RandomDatasetID(),
+			}
+			tableNames := map[execute.DatasetID]string{
+				parents[0]: "a",
+				parents[1]: "b",
+			}
+
+			c := universe.NewMergeJoinCache(executetest.UnlimitedAllocator, parents, tableNames, spec.On, 0)
+			d := execute.NewDataset(executetest.RandomDatasetID(), execute.DiscardingMode, c)
+			d.SetTriggerSpec(plan.DefaultTriggerSpec)
+			store := executetest.NewDataStore()
+			d.AddTransformation(store)
+			jt := universe.NewMergeJoinTransformation(d, c, spec, parents, tableNames)
+
+			process := func(tag string, start int) {
+				t.Helper()
+				for i, parent := range parents {
+					if err := jt.Process(parent, table(tag, start, float64(i))); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+			updateWatermark := func(parent execute.DatasetID, mark execute.Time) {
+				t.Helper()
+				if err := jt.UpdateWatermark(parent, mark); err != nil {
+					t.Fatal(err)
+				}
+			}
+			materialized := func(tag string) bool {
+				_, err := store.Table(key(tag))
+				return err == nil
+			}
+
+			process("a", 0)
+			if materialized("a") {
+				t.Fatal("table {t=a} was materialized before the watermark was updated")
+			}
+
+			// The watermark of the join is the smallest watermark
+			// of its parents, so both parents must advance.
+			updateWatermark(parents[0], 10)
+			if materialized("a") {
+				t.Fatal("table {t=a} was materialized before the watermark of both parents was updated")
+			}
+			updateWatermark(parents[1], 10)
+			if !materialized("a") {
+				t.Fatal("expected table {t=a} to be materialized once the watermark passed its rows")
+			}
+
+			// The tables of a preserved stream are kept until the
+			// join finishes in case they are not paired.
+			wantCount := 2
+			if tc.evicted {
+				wantCount = 0
+			}
+			if got := c.TableCount(); got != wantCount {
+				t.Errorf("unexpected number of buffered tables -want/+got\n\t- %d\n\t+ %d", wantCount, got)
+			}
+
+			process("b", 10)
+			process("c", 20)
+			updateWatermark(parents[0], 20)
+			updateWatermark(parents[1], 20)
+			if !materialized("b") {
+				t.Fatal("expected table {t=b} to be materialized once the watermark passed its rows")
+			}
+			if materialized("c") {
+				t.Fatal("table {t=c} was materialized before the watermark passed its rows")
+			}
+
+			jt.Finish(parents[0], nil)
+			jt.Finish(parents[1], nil)
+			if err := store.Err(); err != nil {
+				t.Fatal(err)
+			}
+
+			var got []*executetest.Table
+			if err := store.ForEach(func(key flux.GroupKey) error {
+				tbl, err := store.Table(key)
+				if err != nil {
+					return err
+				}
+				et, err := executetest.ConvertTable(tbl)
+				if err != nil {
+					return err
+				}
+				got = append(got, et)
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			var want []*executetest.Table
+			for i, tag := range []string{"a", "b", "c"} {
+				tbl := &executetest.Table{
+					KeyCols: []string{"t"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value_a", Type: flux.TFloat},
+						{Label: "_value_b", Type: flux.TFloat},
+						{Label: "t", Type: flux.TString},
+					},
+				}
+				for j := i * 10; j < i*10+10; j++ {
+					tbl.Data = append(tbl.Data, []interface{}{execute.Time(j), 0.0, 1.0, tag})
+				}
+				want = append(want, tbl)
+			}
+			executetest.NormalizeTables(got)
+			executetest.NormalizeTables(want)
+			sort.Sort(executetest.SortedTables(got))
+			sort.Sort(executetest.SortedTables(want))
+			if !cmp.Equal(want, got) {
+				t.Errorf("unexpected tables -want/+got\n%s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
+
+func TestMergeJoinHashStrategyRule(t *testing.T) {
+	var (
+		from  = &influxdb.FromRemoteProcedureSpec{}
+		count = &universe.CountProcedureSpec{}
+		join  = func(strategy string) *universe.MergeJoinProcedureSpec {
+			return &universe.MergeJoinProcedureSpec{
+				TableNames: []string{"a", "b"},
+				On:         []string{"_time"},
+				Strategy:   strategy,
+			}
+		}
+	)
+
+	tests := []plantest.RuleTestCase{
+		{
+			Name:  "neither input sorted",
+			Rules: []plan.Rule{universe.MergeJoinHashStrategyRule{}},
+			Before: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("from0", from),
+					plan.CreatePhysicalNode("count0", count),
+					plan.CreatePhysicalNode("from1", from),
+					plan.CreatePhysicalNode("count1", count),
+					plan.CreatePhysicalNode("join", join("")),
+				},
+				Edges: [][2]int{{0, 1}, {2, 3}, {1, 4}, {3, 4}},
+			},
+			After: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("from0", from),
+					plan.CreatePhysicalNode("count0", count),
+					plan.CreatePhysicalNode("from1", from),
+					plan.CreatePhysicalNode("count1", count),
+					plan.CreatePhysicalNode("join", join(universe.HashJoinStrategy)),
+				},
+				Edges: [][2]int{{0, 1}, {2, 3}, {1, 4}, {3, 4}},
+			},
+		},
+		{
+			Name:  "one input sorted",
+			Rules: []plan.Rule{universe.MergeJoinHashStrategyRule{}},
+			Before: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("from0", from),
+					plan.CreatePhysicalNode("from1", from),
+					plan.CreatePhysicalNode("count", count),
+					plan.CreatePhysicalNode("join", join("")),
+				},
+				Edges: [][2]int{{0, 3}, {1, 2}, {2, 3}},
+			},
+			NoChange: true,
+		},
+		{
+			Name:  "both inputs sorted",
+			Rules: []plan.Rule{universe.MergeJoinHashStrategyRule{}},
+			Before: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("from0", from),
+					plan.CreatePhysicalNode("from1", from),
+					plan.CreatePhysicalNode("join", join("")),
+				},
+				Edges: [][2]int{{0, 2}, {1, 2}},
+			},
+			NoChange: true,
+		},
+		{
+			Name:  "strategy already chosen",
+			Rules: []plan.Rule{universe.MergeJoinHashStrategyRule{}},
+			Before: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("from0", from),
+					plan.CreatePhysicalNode("count0", count),
+					plan.CreatePhysicalNode("from1", from),
+					plan.CreatePhysicalNode("count1", count),
+					plan.CreatePhysicalNode("join", join(universe.MergeJoinStrategy)),
+				},
+				Edges: [][2]int{{0, 1}, {2, 3}, {1, 4}, {3, 4}},
+			},
+			NoChange: true,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			plantest.PhysicalRuleTestHelper(t, &tc)
+		})
+	}
+}
+
+func TestMergeJoinSortedInputsRule(t *testing.T) {
+	var (
+		from  = &influxdb.FromRemoteProcedureSpec{}
+		count = &universe.CountProcedureSpec{}
+		join  = func(sorted bool, on ...string) *universe.MergeJoinProcedureSpec {
+			return &universe.MergeJoinProcedureSpec{
+				TableNames: []string{"a", "b"},
+				On:         on,
+				Sorted:     sorted,
+			}
+		}
+	)
+
+	tests := []plantest.RuleTestCase{
+		{
+			Name:  "inputs sorted by time",
+			Rules: []plan.Rule{universe.MergeJoinSortedInputsRule{}},
+			Before: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("from0", from),
+					plan.CreatePhysicalNode("from1", from),
+					plan.CreatePhysicalNode("join", join(false, "_time")),
+				},
+				Edges: [][2]int{{0, 2}, {1, 2}},
+			},
+			After: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("from0", from),
+					plan.CreatePhysicalNode("from1", from),
+					plan.CreatePhysicalNode("join", join(true, "_time")),
+				},
+				Edges: [][2]int{{0, 2}, {1, 2}},
+			},
+		},
+		{
+			Name:  "inputs not sorted by all of the on columns",
+			Rules: []plan.Rule{universe.MergeJoinSortedInputsRule{}},
+			Before: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("from0", from),
+					plan.CreatePhysicalNode("from1", from),
+					plan.CreatePhysicalNode("join", join(false, "_time", "host")),
+				},
+				Edges: [][2]int{{0, 2}, {1, 2}},
+			},
+			NoChange: true,
+		},
+		{
+			Name:  "one input not sorted",
+			Rules: []plan.Rule{universe.MergeJoinSortedInputsRule{}},
+			Before: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("from0", from),
+					plan.CreatePhysicalNode("from1", from),
+					plan.CreatePhysicalNode("count", count),
+					plan.CreatePhysicalNode("join", join(false, "_time")),
+				},
+				Edges: [][2]int{{0, 3}, {1, 2}, {2, 3}},
+			},
+			NoChange: true,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			plantest.PhysicalRuleTestHelper(t, &tc)
+		})
+	}
+}
+
+func TestFilterThroughJoinRule(t *testing.T) {
+	now := time.Now().UTC()
+	query := func(join, filter string) string {
+		return fmt.Sprintf(`a = from(bucket: "b1")
+			b = from(bucket: "b2")
+			join(tables: {a: a, b: b}, on: ["_time", "host"], %s)
+				|> %s`, join, filter)
+	}
+	unchanged := func() *plantest.PlanSpec {
+		return &plantest.PlanSpec{
+			Nodes: []plan.Node{
+				plan.CreatePhysicalNode("from0", &influxdb.FromProcedureSpec{}),
+				plan.CreatePhysicalNode("from1", &influxdb.FromProcedureSpec{}),
+				plan.CreatePhysicalNode("join2", &universe.MergeJoinProcedureSpec{}),
+				plan.CreatePhysicalNode("filter3", &universe.FilterProcedureSpec{}),
+			},
+			Edges: [][2]int{{0, 2}, {1, 2}, {2, 3}},
+		}
+	}
+	pushedBoth := func() *plantest.PlanSpec {
+		return &plantest.PlanSpec{
+			Nodes: []plan.Node{
+				plan.CreatePhysicalNode("from0", &influxdb.FromProcedureSpec{}),
+				plan.CreatePhysicalNode("filter3_a", &universe.FilterProcedureSpec{}),
+				plan.CreatePhysicalNode("from1", &influxdb.FromProcedureSpec{}),
+				plan.CreatePhysicalNode("filter3_b", &universe.FilterProcedureSpec{}),
+				plan.CreatePhysicalNode("join2", &universe.MergeJoinProcedureSpec{}),
+			},
+			Edges: [][2]int{{0, 1}, {2, 3}, {1, 4}, {3, 4}},
+		}
+	}
+
+	testCases := []struct {
+		name        string
+		flux        string
+		wantPlan    *plantest.PlanSpec
+		wantFilters map[string]string
+	}{
+		{
+			name:     "columns of each side",
+			flux:     query("", `filter(fn: (r) => r._value_a > 0.0 and r._value_b > 0.0)`),
+			wantPlan: pushedBoth(),
+			wantFilters: map[string]string{
+				"filter3_a": `r._value > 0.000000`,
+				"filter3_b": `r._value > 0.000000`,
+			},
+		},
+		{
+			name: "columns of both sides",
+			flux: query("", `filter(fn: (r) => r._value_a > 0.0 and r._value_a > r._value_b)`),
+			wantPlan: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("from0", &influxdb.FromProcedureSpec{}),
+					plan.CreatePhysicalNode("filter3_a", &universe.FilterProcedureSpec{}),
+					plan.CreatePhysicalNode("from1", &influxdb.FromProcedureSpec{}),
+					plan.CreatePhysicalNode("join2", &universe.MergeJoinProcedureSpec{}),
+					plan.CreatePhysicalNode("filter3", &universe.FilterProcedureSpec{}),
+				},
+				Edges: [][2]int{{0, 1}, {1, 3}, {2, 3}, {3, 4}},
+			},
+			wantFilters: map[string]string{
+				"filter3_a": `r._value > 0.000000`,
+				"filter3":   `r._value_a > r._value_b`,
+			},
+		},
+		{
+			name:     "on column",
+			flux:     query("", `filter(fn: (r) => r.host == "h1")`),
+			wantPlan: pushedBoth(),
+			wantFilters: map[string]string{
+				"filter3_a": `r.host == "h1"`,
+				"filter3_b": `r.host == "h1"`,
+			},
+		},
+		{
+			name:     "column without a suffix",
+			flux:     query("", `filter(fn: (r) => r.region == "west")`),
+			wantPlan: unchanged(),
+			wantFilters: map[string]string{
+				"filter3": `r.region == "west"`,
+			},
+		},
+		{
+			name: "left join",
+			flux: query(`method: "left"`, `filter(fn: (r) => r._value_a > 0.0 and r._value_b > 0.0)`),
+			wantPlan: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("from0", &influxdb.FromProcedureSpec{}),
+					plan.CreatePhysicalNode("filter3_a", &universe.FilterProcedureSpec{}),
+					plan.CreatePhysicalNode("from1", &influxdb.FromProcedureSpec{}),
+					plan.CreatePhysicalNode("join2", &universe.MergeJoinProcedureSpec{}),
+					plan.CreatePhysicalNode("filter3", &universe.FilterProcedureSpec{}),
+				},
+				Edges: [][2]int{{0, 1}, {1, 3}, {2, 3}, {3, 4}},
+			},
+			wantFilters: map[string]string{
+				"filter3_a": `r._value > 0.000000`,
+				"filter3":   `r._value_b > 0.000000`,
+			},
+		},
+		{
+			name:     "full join",
+			flux:     query(`method: "full"`, `filter(fn: (r) => r._value_a > 0.0)`),
+			wantPlan: unchanged(),
+			wantFilters: map[string]string{
+				"filter3": `r._value_a > 0.000000`,
+			},
+		},
+		{
+			name:     "custom suffixes",
+			flux:     query(`suffixes: ["_left", "_right"]`, `filter(fn: (r) => r._value_left > 0.0 and r._value_right > 0.0)`),
+			wantPlan: pushedBoth(),
+			wantFilters: map[string]string{
+				"filter3_a": `r._value > 0.000000`,
+				"filter3_b": `r._value > 0.000000`,
+			},
+		},
+		{
+			name:     "keep empty tables",
+			flux:     query("", `filter(fn: (r) => r._value_a > 0.0, onEmpty: "keep")`),
+			wantPlan: unchanged(),
+			wantFilters: map[string]string{
+				"filter3": `r._value_a > 0.000000`,
+			},
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, deps := dependency.Inject(context.Background(), dependenciestest.Default())
+			defer deps.Finish()
+			fluxSpec, err := spec.FromScript(ctx, runtime.Default, now, tc.flux)
+			if err != nil {
+				t.Fatalf("could not compile flux query: %v", err)
+			}
+
+			logicalPlanner := plan.NewLogicalPlanner(plan.OnlyLogicalRules())
+			initPlan, err := logicalPlanner.CreateInitialPlan(fluxSpec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			logicalPlan, err := logicalPlanner.Plan(context.Background(), initPlan)
+			if err != nil {
+				t.Fatal(err)
+			}
+			physicalPlanner := plan.NewPhysicalPlanner(
+				plan.OnlyPhysicalRules(universe.FilterThroughJoinRule{}),
+				plan.DisableValidation(),
+			)
+			physicalPlan, err := physicalPlanner.Plan(context.Background(), logicalPlan)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			wantPlan := *tc.wantPlan
+			wantPlan.Now = now
+			if err := plantest.ComparePlansShallow(plantest.CreatePlanSpec(&wantPlan), physicalPlan); err != nil {
+				t.Error(err)
+			}
+
+			filters := make(map[string]string)
+			_ = physicalPlan.TopDownWalk(func(node plan.Node) error {
+				if spec, ok := node.ProcedureSpec().(*universe.FilterProcedureSpec); ok {
+					body, _ := spec.Fn.Fn.GetFunctionBodyExpression()
+					filters[string(node.ID())] = fmt.Sprintf("%v", semantic.Formatted(body))
+				}
+				return nil
+			})
+			if !cmp.Equal(tc.wantFilters, filters) {
+				t.Errorf("unexpected filters -want/+got:\n%s", cmp.Diff(tc.wantFilters, filters))
+			}
+		})
+	}
+}
+
+func TestMergeJoin_BufferSize(t *testing.T) {
+	table := func(vs ...interface{}) *executetest.Table {
+		tbl := &executetest.Table{
+			ColMeta: []flux.ColMeta{
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_value", Type: flux.TFloat},
+			},
+		}
+		for i, v := range vs {
+			tbl.Data = append(tbl.Data, []interface{}{execute.Time(i + 1), v})
+		}
+		return tbl
+	}
+	testCases := []struct {
+		name       string
+		bufferSize int
+		onOverflow string
+		want       []*executetest.Table
+		wantErr    string
+	}{
+		{
+			name: "unlimited",
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value_a", Type: flux.TFloat},
+					{Label: "_value_b", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{execute.Time(1), 1.0, 10.0},
+					{execute.Time(2), 2.0, 20.0},
+					{execute.Time(3), nil, 30.0},
+					{execute.Time(4), 4.0, 40.0},
+				},
+			}},
+		},
+		{
+			name:       "negative buffer size is unlimited",
+			bufferSize: -1,
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value_a", Type: flux.TFloat},
+					{Label: "_value_b", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{execute.Time(1), 1.0, 10.0},
+					{execute.Time(2), 2.0, 20.0},
+					{execute.Time(3), nil, 30.0},
+					{execute.Time(4), 4.0, 40.0},
+				},
+			}},
+		},
+		{
+			name:       "evict oldest",
+			bufferSize: 2,
+			onOverflow: universe.EvictOldestOnOverflow,
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value_a", Type: flux.TFloat},
+					{Label: "_value_b", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{execute.Time(3), nil, 30.0},
+					{execute.Time(4), 4.0, 40.0},
+				},
+			}},
+		},
+		{
+			name:       "buffer size not exceeded",
+			bufferSize: 4,
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value_a", Type: flux.TFloat},
+					{Label: "_value_b", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{execute.Time(1), 1.0, 10.0},
+					{execute.Time(2), 2.0, 20.0},
+					{execute.Time(3), nil, 30.0},
+					{execute.Time(4), 4.0, 40.0},
+				},
+			}},
+		},
+		{
+			name:       "error",
+			bufferSize: 2,
+			onOverflow: universe.ErrorOnOverflow,
+			wantErr:    "join buffer for table {} exceeded the limit of 2 rows",
+		},
+		{
+			name:       "error by default",
+			bufferSize: 3,
+			wantErr:    "join buffer for table {} exceeded the limit of 3 rows",
+		},
+	}
+	for _, tc := range testCases {
+		for _, strategy := range []string{universe.MergeJoinStrategy, universe.HashJoinStrategy} {
+			tc, strategy := tc, strategy
+			t.Run(tc.name+" "+strategy, func(t *testing.T) {
+				spec := &universe.MergeJoinProcedureSpec{
+					On:         []string{"_time"},
+					TableNames: []string{"a", "b"},
+					Strategy:   strategy,
+					BufferSize: tc.bufferSize,
+					OnOverflow: tc.onOverflow,
+				}
+				parents := []execute.DatasetID{
+					executetest.RandomDatasetID(),
+					executetest.RandomDatasetID(),
+				}
+				tableNames := map[execute.DatasetID]string{
+					parents[0]: "a",
+					parents[1]: "b",
+				}
+
+				d := executetest.NewDataset(executetest.RandomDatasetID())
+				c := universe.NewMergeJoinCache(executetest.UnlimitedAllocator, parents, tableNames, spec.On, spec.BufferSize)
+				if err := c.SetOnOverflow(spec.OnOverflow); err != nil {
+					t.Fatal(err)
+				}
+				c.SetTriggerSpec(plan.DefaultTriggerSpec)
+				var jt execute.Transformation
+				if strategy == universe.HashJoinStrategy {
+					jt = universe.NewHashJoinTransformation(d, c, spec, parents, tableNames)
+				} else {
+					jt = universe.NewMergeJoinTransformation(d, c, spec, parents, tableNames)
+				}
+
+				err := jt.Process(parents[0], table(1.0, 2.0, nil, 4.0))
+				if err == nil {
+					err = jt.Process(parents[1], table(10.0, 20.0, 30.0, 40.0))
+				}
+				if tc.wantErr != "" {
+					if err == nil {
+						t.Fatalf("expected error %q, got none", tc.wantErr)
+					} else if got, want := err.Error(), tc.wantErr; got != want {
+						t.Fatalf("unexpected error -want/+got:\n\t- %s\n\t+ %s", want, got)
+					} else if got, want := flux.ErrorCode(err), codes.ResourceExhausted; got != want {
+						t.Fatalf("unexpected error code -want/+got:\n\t- %s\n\t+ %s", want, got)
+					}
+					return
+				} else if err != nil {
+					t.Fatal(err)
+				}
+				jt.Finish(parents[0], nil)
+				jt.Finish(parents[1], nil)
+
+				got, err := executetest.TablesFromCache(c)
+				if err != nil {
+					t.Fatal(err)
+				}
+				executetest.NormalizeTables(got)
+				executetest.NormalizeTables(tc.want)
+				got = sortRows(got)
+				if !cmp.Equal(tc.want, got) {
+					t.Errorf("unexpected tables -want/+got\n%s", cmp.Diff(tc.want, got))
+				}
+			})
+		}
+	}
+}
+
+func TestMergeJoin_Cross(t *testing.T) {
+	params := &executetest.Table{
+		ColMeta: []flux.ColMeta{
+			{Label: "_value", Type: flux.TFloat},
+			{Label: "level", Type: flux.TString},
+		},
+		Data: [][]interface{}{
+			{1.0, "low"},
+			{2.0, "mid"},
+			{3.0, "high"},
+		},
+	}
+	series := func(host string, vs ...float64) *executetest.Table {
+		tbl := &executetest.Table{
+			KeyCols: []string{"host"},
+			ColMeta: []flux.ColMeta{
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_value", Type: flux.TFloat},
+				{Label: "host", Type: flux.TString},
+			},
+		}
+		for i, v := range vs {
+			tbl.Data = append(tbl.Data, []interface{}{execute.Time(i + 1), v, host})
+		}
+		return tbl
+	}
+	cols := []flux.ColMeta{
+		{Label: "_time", Type: flux.TTime},
+		{Label: "_value_a", Type: flux.TFloat},
+		{Label: "_value_b", Type: flux.TFloat},
+		{Label: "host", Type: flux.TString},
+		{Label: "level", Type: flux.TString},
+	}
+	// cross returns the rows of the cross product of the
+	// params table and a series with the host.
+	cross := func(host string, vs ...float64) [][]interface{} {
+		var rows [][]interface{}
+		for _, p := range params.Data {
+			for i, v := range vs {
+				rows = append(rows, []interface{}{execute.Time(i + 1), p[0], v, host, p[1]})
+			}
+		}
+		return rows
+	}
+
+	testCases := []struct {
+		name     string
+		series   []*executetest.Table
+		rowLimit int
+		want     []*executetest.Table
+		wantErr  string
+	}{
+		{
+			name:   "3x4",
+			series: []*executetest.Table{series("a", 10, 20, 30, 40)},
+			want: []*executetest.Table{{
+				KeyCols: []string{"host"},
+				ColMeta: cols,
+				Data:    cross("a", 10, 20, 30, 40),
+			}},
+		},
+		{
+			name:   "each table pair",
+			series: []*executetest.Table{series("a", 10, 20), series("b", 30)},
+			want: []*executetest.Table{
+				{
+					KeyCols: []string{"host"},
+					ColMeta: cols,
+					Data:    cross("a", 10, 20),
+				},
+				{
+					KeyCols: []string{"host"},
+					ColMeta: cols,
+					Data:    cross("b", 30),
+				},
+			},
+		},
+		{
+			name:     "limit equal to product",
+			series:   []*executetest.Table{series("a", 10, 20, 30, 40)},
+			rowLimit: 12,
+			want: []*executetest.Table{{
+				KeyCols: []string{"host"},
+				ColMeta: cols,
+				Data:    cross("a", 10, 20, 30, 40),
+			}},
+		},
+		{
+			name:     "negative limit is unlimited",
+			series:   []*executetest.Table{series("a", 10, 20, 30, 40)},
+			rowLimit: -1,
+			want: []*executetest.Table{{
+				KeyCols: []string{"host"},
+				ColMeta: cols,
+				Data:    cross("a", 10, 20, 30, 40),
+			}},
+		},
+		{
+			name:     "limit exceeded",
+			series:   []*executetest.Table{series("a", 10, 20, 30, 40)},
+			rowLimit: 11,
+			wantErr:  `cross join of table {} of "a" and table {host=a} of "b" would output 12 rows, which exceeds the limit of 11 rows`,
+		},
+	}
+	for _, tc := range testCases {
+		for _, strategy := range []string{universe.MergeJoinStrategy, universe.HashJoinStrategy} {
+			tc, strategy := tc, strategy
+			t.Run(tc.name+" "+strategy, func(t *testing.T) {
+				spec := &universe.MergeJoinProcedureSpec{
+					TableNames:    []string{"a", "b"},
+					Strategy:      strategy,
+					Method:        universe.CrossJoinMethod,
+					CrossRowLimit: tc.rowLimit,
+				}
+				parents := []execute.DatasetID{
+					executetest.RandomDatasetID(),
+					executetest.RandomDatasetID(),
+				}
+				tableNames := map[execute.DatasetID]string{
+					parents[0]: "a",
+					parents[1]: "b",
+				}
+
+				d := executetest.NewDataset(executetest.RandomDatasetID())
+				c := universe.NewMergeJoinCache(executetest.UnlimitedAllocator, parents, tableNames, spec.On, 0)
+				c.SetCrossRowLimit(spec.CrossRowLimit)
+				c.SetTriggerSpec(plan.DefaultTriggerSpec)
+				var jt execute.Transformation
+				if strategy == universe.HashJoinStrategy {
+					jt = universe.NewHashJoinTransformation(d, c, spec, parents, tableNames)
+				} else {
+					jt = universe.NewMergeJoinTransformation(d, c, spec, parents, tableNames)
+				}
+
+				cpy := *params
+				if err := jt.Process(parents[0], &cpy); err != nil {
+					t.Fatal(err)
+				}
+				for _, tbl := range tc.series {
+					cpy := *tbl
+					if err := jt.Process(parents[1], &cpy); err != nil {
+						t.Fatal(err)
+					}
+				}
+				jt.Finish(parents[0], nil)
+				jt.Finish(parents[1], nil)
+
+				got, err := executetest.TablesFromCache(c)
+				if tc.wantErr != "" {
+					if err == nil {
+						t.Fatalf("expected error %q, got none", tc.wantErr)
+					} else if got, want := err.Error(), tc.wantErr; got != want {
+						t.Fatalf("unexpected error -want/+got:\n\t- %s\n\t+ %s", want, got)
+					} else if got, want := flux.ErrorCode(err), codes.ResourceExhausted; got != want {
+						t.Fatalf("unexpected error code -want/+got:\n\t- %s\n\t+ %s", want, got)
+					}
+					return
+				} else if err != nil {
+					t.Fatal(err)
+				}
+				executetest.NormalizeTables(got)
+				executetest.NormalizeTables(tc.want)
+				got = sortRows(got)
+				want := sortRows(tc.want)
+				if !cmp.Equal(want, got) {
+					t.Errorf("unexpected tables -want/+got\n%s", cmp.Diff(want, got))
+				}
+			})
+		}
+	}
+}
+
+func TestMergeJoin_DuplicateKeys(t *testing.T) {
+	// Every row has the same host, so each left row
+	// is joined with each right row.
+	const n = 1000
+	table := func() *executetest.Table {
+		tbl := &executetest.Table{
+			ColMeta: []flux.ColMeta{
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_value", Type: flux.TFloat},
+				{Label: "host", Type: flux.TString},
+			},
+			Data: make([][]interface{}, n),
+		}
+		for i := range tbl.Data {
+			tbl.Data[i] = []interface{}{execute.Time(i), float64(i), "a"}
+		}
+		return tbl
+	}
+
+	for _, strategy := range []string{universe.MergeJoinStrategy, universe.HashJoinStrategy} {
+		strategy := strategy
+		t.Run(strategy, func(t *testing.T) {
+			spec := &universe.MergeJoinProcedureSpec{
+				On:         []string{"host"},
+				TableNames: []string{"a", "b"},
+				Strategy:   strategy,
+			}
+			parents := []execute.DatasetID{
+				executetest.RandomDatasetID(),
+				executetest.RandomDatasetID(),
+			}
+			tableNames := map[execute.DatasetID]string{
+				parents[0]: "a",
+				parents[1]: "b",
+			}
+
+			d := executetest.NewDataset(executetest.RandomDatasetID())
+			c := universe.NewMergeJoinCache(executetest.UnlimitedAllocator, parents, tableNames, spec.On, spec.BufferSize)
+			c.SetTriggerSpec(plan.DefaultTriggerSpec)
+			var jt execute.Transformation
+			if strategy == universe.HashJoinStrategy {
+				jt = universe.NewHashJoinTransformation(d, c, spec, parents, tableNames)
+			} else {
+				jt = universe.NewMergeJoinTransformation(d, c, spec, parents, tableNames)
+			}
+			if err := jt.Process(parents[0], table()); err != nil {
+				t.Fatal(err)
+			}
+			if err := jt.Process(parents[1], table()); err != nil {
+				t.Fatal(err)
+			}
+
+			// The joined rows are in the order of the left rows
+			// and then in the order of the right rows.
+			rows := 0
+			if err := c.ForEach(func(key flux.GroupKey) error {
+				tbl, err := c.Table(key)
+				if err != nil {
+					return err
+				}
+				want := []flux.ColMeta{
+					{Label: "_time_a", Type: flux.TTime},
+					{Label: "_time_b", Type: flux.TTime},
+					{Label: "_value_a", Type: flux.TFloat},
+					{Label: "_value_b", Type: flux.TFloat},
+					{Label: "host", Type: flux.TString},
+				}
+				if !cmp.Equal(want, tbl.Cols()) {
+					return fmt.Errorf("unexpected columns -want/+got\n%s", cmp.Diff(want, tbl.Cols()))
+				}
+				return tbl.Do(func(cr flux.ColReader) error {
+					for i := 0; i < cr.Len(); i++ {
+						l, r := rows/n, rows%n
+						if got := cr.Times(0).Value(i); got != int64(l) {
+							return fmt.Errorf("row %d: expected _time_a %d, got %d", rows, l, got)
+						}
+						if got := cr.Times(1).Value(i); got != int64(r) {
+							return fmt.Errorf("row %d: expected _time_b %d, got %d", rows, r, got)
+						}
+						if got := cr.Floats(2).Value(i); got != float64(l) {
+							return fmt.Errorf("row %d: expected _value_a %v, got %v", rows, l, got)
+						}
+						if got := cr.Floats(3).Value(i); got != float64(r) {
+							return fmt.Errorf("row %d: expected _value_b %v, got %v", rows, r, got)
+						}
+						if got := cr.Strings(4).Value(i); got != "a" {
+							return fmt.Errorf("row %d: expected host %q, got %q", rows, "a", got)
+						}
+						rows++
+					}
+					return nil
+				})
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if want := n * n; rows != want {
+				t.Fatalf("expected %d rows, got %d", want, rows)
+			}
+		})
+	}
+}
+
+func TestMergeJoinCache_SetOnOverflow(t *testing.T) {
+	parents := []execute.DatasetID{
+		executetest.RandomDatasetID(),
+		executetest.RandomDatasetID(),
+	}
+	tableNames := map[execute.DatasetID]string{
+		parents[0]: "a",
+		parents[1]: "b",
+	}
+	c := universe.NewMergeJoinCache(executetest.UnlimitedAllocator, parents, tableNames, []string{"_time"}, 1)
+	err := c.SetOnOverflow("drop")
+	if err == nil {
+		t.Fatal("expected error, got none")
+	}
+	if want, got := `invalid join overflow policy "drop", must be "error" or "evict-oldest"`, err.Error(); want != got {
+		t.Errorf("unexpected error -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+}
+
+func TestMergeJoin_OnDuplicate(t *testing.T) {
+	table := func(rows ...[]interface{}) *executetest.Table {
+		return &executetest.Table{
+			ColMeta: []flux.ColMeta{
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_value", Type: flux.TFloat},
+			},
+			Data: rows,
+		}
+	}
+	row := func(ts interface{}, v float64) []interface{} {
+		if ts != nil {
+			ts = execute.Time(ts.(int))
+		}
+		return []interface{}{ts, v}
+	}
+	joined := func(rows ...[]interface{}) []*executetest.Table {
+		return []*executetest.Table{{
+			ColMeta: []flux.ColMeta{
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_value_a", Type: flux.TFloat},
+				{Label: "_value_b", Type: flux.TFloat},
+			},
+			Data: rows,
+		}}
+	}
+	joinedRow := func(ts int, a, b float64) []interface{} {
+		return []interface{}{execute.Time(ts), a, b}
+	}
+
+	// The duplicates of the left table are interleaved with rows
+	// that are not duplicated and are not all adjacent.
+	duplicates := table(
+		row(1, 1.0),
+		row(1, 1.1),
+		row(2, 2.0),
+		row(3, 3.0),
+		row(1, 1.2),
+		row(3, 3.1),
+	)
+	right := table(
+		row(1, 10.0),
+		row(2, 20.0),
+		row(3, 30.0),
+	)
+	testCases := []struct {
+		name        string
+		onDuplicate string
+		left        *executetest.Table
+		right       *executetest.Table
+		want        []*executetest.Table
+		wantErr     string
+	}{
+		{
+			name: "expand by default",
+			left: duplicates,
+			want: joined(
+				joinedRow(1, 1.0, 10.0),
+				joinedRow(1, 1.1, 10.0),
+				joinedRow(1, 1.2, 10.0),
+				joinedRow(2, 2.0, 20.0),
+				joinedRow(3, 3.0, 30.0),
+				joinedRow(3, 3.1, 30.0),
+			),
+		},
+		{
+			name:        "expand",
+			onDuplicate: universe.ExpandOnDuplicate,
+			left:        duplicates,
+			want: joined(
+				joinedRow(1, 1.0, 10.0),
+				joinedRow(1, 1.1, 10.0),
+				joinedRow(1, 1.2, 10.0),
+				joinedRow(2, 2.0, 20.0),
+				joinedRow(3, 3.0, 30.0),
+				joinedRow(3, 3.1, 30.0),
+			),
+		},
+		{
+			name:        "keep first",
+			onDuplicate: universe.KeepFirstOnDuplicate,
+			left:        duplicates,
+			want: joined(
+				joinedRow(1, 1.0, 10.0),
+				joinedRow(2, 2.0, 20.0),
+				joinedRow(3, 3.0, 30.0),
+			),
+		},
+		{
+			name:        "keep last",
+			onDuplicate: universe.KeepLastOnDuplicate,
+			left:        duplicates,
+			want: joined(
+				joinedRow(1, 1.2, 10.0),
+				joinedRow(2, 2.0, 20.0),
+				joinedRow(3, 3.1, 30.0),
+			),
+		},
+		{
+			name:        "keep last on the right",
+			onDuplicate: universe.KeepLastOnDuplicate,
+			left:        right,
+			right:       duplicates,
+			want: joined(
+				joinedRow(1, 10.0, 1.2),
+				joinedRow(2, 20.0, 2.0),
+				joinedRow(3, 30.0, 3.1),
+			),
+		},
+		{
+			name:        "error",
+			onDuplicate: universe.ErrorOnDuplicate,
+			left:        duplicates,
+			wantErr:     `duplicate join key {_time=1970-01-01T00:00:00.000000001Z} in table {} of "a"`,
+		},
+		{
+			name:        "error without duplicates",
+			onDuplicate: universe.ErrorOnDuplicate,
+			left: table(
+				row(1, 1.0),
+				row(2, 2.0),
+				row(3, 3.0),
+			),
+			want: joined(
+				joinedRow(1, 1.0, 10.0),
+				joinedRow(2, 2.0, 20.0),
+				joinedRow(3, 3.0, 30.0),
+			),
+		},
+		{
+			name:        "nulls are not duplicates",
+			onDuplicate: universe.ErrorOnDuplicate,
+			left: table(
+				row(nil, 0.0),
+				row(1, 1.0),
+				row(nil, 0.1),
+			),
+			want: joined(
+				joinedRow(1, 1.0, 10.0),
+			),
+		},
+	}
+	for _, tc := range testCases {
+		for _, strategy := range []string{universe.MergeJoinStrategy, universe.HashJoinStrategy} {
+			tc, strategy := tc, strategy
+			t.Run(tc.name+" "+strategy, func(t *testing.T) {
+				spec := &universe.MergeJoinProcedureSpec{
+					On:          []string{"_time"},
+					TableNames:  []string{"a", "b"},
+					Strategy:    strategy,
+					OnDuplicate: tc.onDuplicate,
+				}
+				parents := []execute.DatasetID{
+					executetest.RandomDatasetID(),
+					executetest.RandomDatasetID(),
+				}
+				tableNames := map[execute.DatasetID]string{
+					parents[0]: "a",
+					parents[1]: "b",
+				}
+
+				d := executetest.NewDataset(executetest.RandomDatasetID())
+				c := universe.NewMergeJoinCache(executetest.UnlimitedAllocator, parents, tableNames, spec.On, 0)
+				if err := c.SetOnDuplicate(spec.OnDuplicate); err != nil {
+					t.Fatal(err)
+				}
+				c.SetTriggerSpec(plan.DefaultTriggerSpec)
+				var jt execute.Transformation
+				if strategy == universe.HashJoinStrategy {
+					jt = universe.NewHashJoinTransformation(d, c, spec, parents, tableNames)
+				} else {
+					jt = universe.NewMergeJoinTransformation(d, c, spec, parents, tableNames)
+				}
+
+				// Each test case is run with every strategy
+				// and a table can only be read once.
+				left, right := *tc.left, *right
+				if tc.right != nil {
+					right = *tc.right
+				}
+				if err := jt.Process(parents[0], &left); err != nil {
+					t.Fatal(err)
+				}
+				if err := jt.Process(parents[1], &right); err != nil {
+					t.Fatal(err)
+				}
+				jt.Finish(parents[0], nil)
+				jt.Finish(parents[1], nil)
+
+				got, err := executetest.TablesFromCache(c)
+				if tc.wantErr != "" {
+					if err == nil {
+						t.Fatalf("expected error %q, got none", tc.wantErr)
+					} else if got, want := err.Error(), tc.wantErr; got != want {
+						t.Fatalf("unexpected error -want/+got:\n\t- %s\n\t+ %s", want, got)
+					} else if got, want := flux.ErrorCode(err), codes.Invalid; got != want {
+						t.Fatalf("unexpected error code -want/+got:\n\t- %s\n\t+ %s", want, got)
+					}
+					return
+				} else if err != nil {
+					t.Fatal(err)
+				}
+				executetest.NormalizeTables(got)
+				executetest.NormalizeTables(tc.want)
+				got = sortRows(got)
+				want := sortRows(tc.want)
+				if !cmp.Equal(want, got) {
+					t.Errorf("unexpected tables -want/+got\n%s", cmp.Diff(want, got))
+				}
+			})
+		}
+	}
+}
+
+func TestMergeJoinCache_SetOnDuplicate(t *testing.T) {
+	parents := []execute.DatasetID{
+		executetest.RandomDatasetID(),
+		executetest.RandomDatasetID(),
+	}
+	tableNames := map[execute.DatasetID]string{
+		parents[0]: "a",
+		parents[1]: "b",
+	}
+	c := universe.NewMergeJoinCache(executetest.UnlimitedAllocator, parents, tableNames, []string{"_time"}, 0)
+	err := c.SetOnDuplicate("drop")
+	if err == nil {
+		t.Fatal("expected error, got none")
+	}
+	if want, got := `invalid join duplicate policy "drop", must be "expand", "error", "keep-first" or "keep-last"`, err.Error(); want != got {
+		t.Errorf("unexpected error -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+}
+
+func TestMergeJoin_MissingOnColumns(t *testing.T) {
+	table := func(host string) *executetest.Table {
+		tbl := &executetest.Table{
+			ColMeta: []flux.ColMeta{
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_value", Type: flux.TFloat},
+			},
+			Data: [][]interface{}{
+				{execute.Time(1), 1.0},
+				{execute.Time(2), 2.0},
+			},
+		}
+		if host != "" {
+			tbl.KeyCols = []string{"host"}
+			tbl.ColMeta = append(tbl.ColMeta, flux.ColMeta{Label: "host", Type: flux.TString})
+			for i := range tbl.Data {
+				tbl.Data[i] = append(tbl.Data[i], host)
+			}
+		}
+		return tbl
+	}
+	testCases := []struct {
+		name         string
+		on           []string
+		allowMissing bool
+		left         []*executetest.Table
+		right        []*executetest.Table
+		want         []*executetest.Table
+		wantErr      string
+	}{
+		{
+			name:    "missing on the left",
+			on:      []string{"_time", "host"},
+			left:    []*executetest.Table{table("")},
+			right:   []*executetest.Table{table("a")},
+			wantErr: `join column "host" is missing from table "a", available columns: _time, _value`,
+		},
+		{
+			name:    "missing on the right",
+			on:      []string{"_time", "host"},
+			left:    []*executetest.Table{table("a")},
+			right:   []*executetest.Table{table("")},
+			wantErr: `join column "host" is missing from table "b", available columns: _time, _value`,
+		},
+		{
+			name:    "missing on both",
+			on:      []string{"hosst"},
+			left:    []*executetest.Table{table("a")},
+			right:   []*executetest.Table{table("a")},
+			wantErr: `join column "hosst" is missing from table "a", available columns: _time, _value, host`,
+		},
+		{
+			name:  "missing after the first table",
+			on:    []string{"_time", "host"},
+			left:  []*executetest.Table{table("a"), table("")},
+			right: []*executetest.Table{table("a")},
+			want: []*executetest.Table{{
+				KeyCols: []string{"host"},
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value_a", Type: flux.TFloat},
+					{Label: "_value_b", Type: flux.TFloat},
+					{Label: "host", Type: flux.TString},
+				},
+				Data: [][]interface{}{
+					{execute.Time(1), 1.0, 1.0, "a"},
+					{execute.Time(2), 2.0, 2.0, "a"},
+				},
+			}},
+		},
+		{
+			name:         "allow missing",
+			on:           []string{"_time", "host"},
+			allowMissing: true,
+			left:         []*executetest.Table{table(""), table("a")},
+			right:        []*executetest.Table{table("a")},
+			want: []*executetest.Table{{
+				KeyCols: []string{"host"},
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value_a", Type: flux.TFloat},
+					{Label: "_value_b", Type: flux.TFloat},
+					{Label: "host", Type: flux.TString},
+				},
+				Data: [][]interface{}{
+					{execute.Time(1), 1.0, 1.0, "a"},
+					{execute.Time(2), 2.0, 2.0, "a"},
+				},
+			}},
+		},
+	}
+	for _, tc := range testCases {
+		for _, strategy := range []string{universe.MergeJoinStrategy, universe.HashJoinStrategy} {
+			tc, strategy := tc, strategy
+			t.Run(tc.name+" "+strategy, func(t *testing.T) {
+				spec := &universe.MergeJoinProcedureSpec{
+					On:           tc.on,
+					TableNames:   []string{"a", "b"},
+					Strategy:     strategy,
+					AllowMissing: tc.allowMissing,
+				}
+				parents := []execute.DatasetID{
+					executetest.RandomDatasetID(),
+					executetest.RandomDatasetID(),
+				}
+				tableNames := map[execute.DatasetID]string{
+					parents[0]: "a",
+					parents[1]: "b",
+				}
+
+				d := executetest.NewDataset(executetest.RandomDatasetID())
+				c := universe.NewMergeJoinCache(executetest.UnlimitedAllocator, parents, tableNames, spec.On, 0)
+				c.SetTriggerSpec(plan.DefaultTriggerSpec)
+				var jt execute.Transformation
+				if strategy == universe.HashJoinStrategy {
+					jt = universe.NewHashJoinTransformation(d, c, spec, parents, tableNames)
+				} else {
+					jt = universe.NewMergeJoinTransformation(d, c, spec, parents, tableNames)
+				}
+
+				// Each test case is run with every strategy
+				// and a table can only be read once.
+				process := func(id execute.DatasetID, tables []*executetest.Table) error {
+					for _, tbl := range tables {
+						cpy := *tbl
+						if err := jt.Process(id, &cpy); err != nil {
+							return err
+						}
+					}
+					return nil
+				}
+				err := process(parents[0], tc.left)
+				if err == nil {
+					err = process(parents[1], tc.right)
+				}
+				if tc.wantErr != "" {
+					if err == nil {
+						t.Fatalf("expected error %q, got none", tc.wantErr)
+					} else if got, want := err.Error(), tc.wantErr; got != want {
+						t.Fatalf("unexpected error -want/+got:\n\t- %s\n\t+ %s", want, got)
+					} else if got, want := flux.ErrorCode(err), codes.Invalid; got != want {
+						t.Fatalf("unexpected error code -want/+got:\n\t- %s\n\t+ %s", want, got)
+					}
+					return
+				} else if err != nil {
+					t.Fatal(err)
+				}
+				jt.Finish(parents[0], nil)
+				jt.Finish(parents[1], nil)
+
+				got, err := executetest.TablesFromCache(c)
+				if err != nil {
+					t.Fatal(err)
+				}
+				executetest.NormalizeTables(got)
+				executetest.NormalizeTables(tc.want)
+				if !cmp.Equal(tc.want, got) {
+					t.Errorf("unexpected tables -want/+got\n%s", cmp.Diff(tc.want, got))
+				}
+			})
+		}
+	}
+}
+
+func TestMergeJoin_MismatchedOnTypes(t *testing.T) {
+	table := func(typ flux.ColType, v interface{}) *executetest.Table {
+		return &executetest.Table{
+			ColMeta: []flux.ColMeta{
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_value", Type: typ},
+			},
+			Data: [][]interface{}{
+				{execute.Time(1), v},
+			},
+		}
+	}
+	testCases := []struct {
+		name    string
+		left    *executetest.Table
+		right   *executetest.Table
+		wantErr string
+	}{
+		{
+			name:    "int and float",
+			left:    table(flux.TInt, int64(1)),
+			right:   table(flux.TFloat, 1.0),
+			wantErr: `join column "_value" has type int in table "a" and type float in table "b"`,
+		},
+		{
+			name:    "int and uint",
+			left:    table(flux.TInt, int64(1)),
+			right:   table(flux.TUInt, uint64(1)),
+			wantErr: `join column "_value" has type int in table "a" and type uint in table "b"`,
+		},
+		{
+			name:    "float and int",
+			left:    table(flux.TFloat, 1.0),
+			right:   table(flux.TInt, int64(1)),
+			wantErr: `join column "_value" has type float in table "a" and type int in table "b"`,
+		},
+	}
+	for _, tc := range testCases {
+		for _, strategy := range []string{universe.MergeJoinStrategy, universe.HashJoinStrategy} {
+			tc, strategy := tc, strategy
+			t.Run(tc.name+" "+strategy, func(t *testing.T) {
+				spec := &universe.MergeJoinProcedureSpec{
+					On:         []string{"_value"},
+					TableNames: []string{"a", "b"},
+					Strategy:   strategy,
+				}
+				parents := []execute.DatasetID{
+					executetest.RandomDatasetID(),
+					executetest.RandomDatasetID(),
+				}
+				tableNames := map[execute.DatasetID]string{
+					parents[0]: "a",
+					parents[1]: "b",
+				}
+
+				d := executetest.NewDataset(executetest.RandomDatasetID())
+				c := universe.NewMergeJoinCache(executetest.UnlimitedAllocator, parents, tableNames, spec.On, 0)
+				c.SetTriggerSpec(plan.DefaultTriggerSpec)
+				var jt execute.Transformation
+				if strategy == universe.HashJoinStrategy {
+					jt = universe.NewHashJoinTransformation(d, c, spec, parents, tableNames)
+				} else {
+					jt = universe.NewMergeJoinTransformation(d, c, spec, parents, tableNames)
+				}
+
+				// The join fails as soon as there
+				// is a table from both streams.
+				// Each test case is run with every strategy
+				// and a table can only be read once.
+				left, right := *tc.left, *tc.right
+				if err := jt.Process(parents[0], &left); err != nil {
+					t.Fatal(err)
+				}
+				err := jt.Process(parents[1], &right)
+				if err == nil {
+					t.Fatalf("expected error %q, got none", tc.wantErr)
+				} else if got, want := err.Error(), tc.wantErr; got != want {
+					t.Fatalf("unexpected error -want/+got:\n\t- %s\n\t+ %s", want, got)
+				} else if got, want := flux.ErrorCode(err), codes.Invalid; got != want {
+					t.Fatalf("unexpected error code -want/+got:\n\t- %s\n\t+ %s", want, got)
+				}
+			})
+		}
+	}
+}
+
+func TestMergeJoin_NullKeyInGroupKey(t *testing.T) {
+	parents := []execute.DatasetID{
+		executetest.RandomDatasetID(),
+		executetest.RandomDatasetID(),
+	}
+	tableNames := map[execute.DatasetID]string{
+		parents[0]: "a",
+		parents[1]: "b",
+	}
+	spec := &universe.MergeJoinProcedureSpec{
+		On:         []string{"_time", "t"},
+		TableNames: []string{"a", "b"},
+		OnNullKey:  universe.ErrorOnNullKey,
+	}
+	d := executetest.NewDataset(executetest.RandomDatasetID())
+	c := universe.NewMergeJoinCache(executetest.UnlimitedAllocator, parents, tableNames, spec.On, 0)
+	if err := c.SetOnNullKey(spec.OnNullKey); err != nil {
+		t.Fatal(err)
+	}
+	c.SetTriggerSpec(plan.DefaultTriggerSpec)
+	jt := universe.NewMergeJoinTransformation(d, c, spec, parents, tableNames)
+
+	err := jt.Process(parents[1], &executetest.Table{
+		KeyCols: []string{"t"},
+		ColMeta: []flux.ColMeta{
+			{Label: "_time", Type: flux.TTime},
+			{Label: "_value", Type: flux.TFloat},
+			{Label: "t", Type: flux.TString},
+		},
+		Data: [][]interface{}{
+			{execute.Time(1), 1.0, nil},
+		},
+	})
+	if err == nil {
+		t.Fatal("expected error, got none")
+	}
+	if want, got := `join column "t" has a null value in table {t=<nil>} of "b"`, err.Error(); want != got {
+		t.Errorf("unexpected error -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+	if want, got := codes.Invalid, flux.ErrorCode(err); want != got {
+		t.Errorf("unexpected error code -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+}
+
+func TestMergeJoinCache_SetOnNullKey(t *testing.T) {
+	parents := []execute.DatasetID{
+		executetest.RandomDatasetID(),
+		executetest.RandomDatasetID(),
+	}
+	tableNames := map[execute.DatasetID]string{
+		parents[0]: "a",
+		parents[1]: "b",
+	}
+	c := universe.NewMergeJoinCache(executetest.UnlimitedAllocator, parents, tableNames, []string{"_time"}, 0)
+	err := c.SetOnNullKey("match")
+	if err == nil {
+		t.Fatal("expected error, got none")
+	}
+	if want, got := `invalid join null key policy "match", must be "skip", "error" or "treat-as-equal"`, err.Error(); want != got {
+		t.Errorf("unexpected error -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+}
+
+func TestMergeJoinCache_SetSuffixes(t *testing.T) {
+	parents := []execute.DatasetID{
+		executetest.RandomDatasetID(),
+		executetest.RandomDatasetID(),
+	}
+	tableNames := map[execute.DatasetID]string{
+		parents[0]: "a",
+		parents[1]: "b",
+	}
+	for _, tc := range []struct {
+		suffixes []string
+		wantErr  string
+	}{
+		{suffixes: nil},
+		{suffixes: []string{"_left", "_right"}},
+		{suffixes: []string{"_left"}, wantErr: "join suffixes must have 2 elements, got 1"},
+		{suffixes: []string{"_a", "_b", "_c"}, wantErr: "join suffixes must have 2 elements, got 3"},
+		{suffixes: []string{"", "_right"}, wantErr: "join suffixes must not be empty"},
+		{suffixes: []string{"_x", "_x"}, wantErr: `join suffixes must be distinct, got "_x" twice`},
+	} {
+		c := universe.NewMergeJoinCache(executetest.UnlimitedAllocator, parents, tableNames, []string{"_time"}, 0)
+		err := c.SetSuffixes(tc.suffixes)
+		if tc.wantErr == "" {
+			if err != nil {
+				t.Errorf("unexpected error for suffixes %q: %s", tc.suffixes, err)
+			}
+		} else if err == nil {
+			t.Errorf("expected error for suffixes %q, got none", tc.suffixes)
+		} else if got, want := err.Error(), tc.wantErr; got != want {
+			t.Errorf("unexpected error -want/+got:\n\t- %s\n\t+ %s", want, got)
+		}
+	}
+}
+
+func TestMergeJoinCache_Stats(t *testing.T) {
+	table := func(tag string, times ...int) *executetest.Table {
+		tbl := &executetest.Table{
+			KeyCols: []string{"t"},
+			ColMeta: []flux.ColMeta{
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_value", Type: flux.TFloat},
+				{Label: "t", Type: flux.TString},
+			},
+		}
+		for _, ts := range times {
+			tbl.Data = append(tbl.Data, []interface{}{execute.Time(ts), float64(ts), tag})
+		}
+		return tbl
+	}
+	left := func() []*executetest.Table {
+		return []*executetest.Table{
+			table("a", 1, 2, 3),
+			table("b", 10, 11),
+		}
+	}
+	right := func() []*executetest.Table {
+		return []*executetest.Table{
+			table("a", 2, 3, 4),
+			table("c", 11),
+		}
+	}
+
+	testCases := []struct {
+		name       string
+		on         []string
+		bufferSize int
+		onOverflow string
+		want       universe.MergeJoinCacheStats
+	}{
+		{
+			// Every table is joined with every table from the other
+			// side. Only a-a and b-c have rows with the same time.
+			name: "all pairs of tables",
+			on:   []string{"_time"},
+			want: universe.MergeJoinCacheStats{
+				RowsBuffered:     9,
+				TablePairsJoined: 4,
+				EmptyMatches:     2,
+			},
+		},
+		{
+			// Only the a tables have the same tag. They are evicted
+			// once they are joined because the tables that follow
+			// them have a different tag.
+			name: "tables evicted after join",
+			on:   []string{"_time", "t"},
+			want: universe.MergeJoinCacheStats{
+				RowsBuffered:     9,
+				RowsEvicted:      6,
+				TablePairsJoined: 1,
+			},
+		},
+		{
+			name:       "rows evicted on overflow",
+			on:         []string{"_time"},
+			bufferSize: 2,
+			onOverflow: universe.EvictOldestOnOverflow,
+			want: universe.MergeJoinCacheStats{
+				RowsBuffered:     9,
+				RowsEvicted:      2,
+				TablePairsJoined: 4,
+				EmptyMatches:     2,
+			},
+		},
+	}
+	for _, tc := range testCases {
+		for _, strategy := range []string{universe.MergeJoinStrategy, universe.HashJoinStrategy} {
+			tc, strategy := tc, strategy
+			t.Run(tc.name+" "+strategy, func(t *testing.T) {
+				spec := &universe.MergeJoinProcedureSpec{
+					On:         tc.on,
+					TableNames: []string{"a", "b"},
+					Strategy:   strategy,
+					BufferSize: tc.bufferSize,
+					OnOverflow: tc.onOverflow,
+				}
+				parents := []execute.DatasetID{
+					executetest.RandomDatasetID(),
+					executetest.RandomDatasetID(),
+				}
+				tableNames := map[execute.DatasetID]string{
+					parents[0]: "a",
+					parents[1]: "b",
+				}
+
+				d := executetest.NewDataset(executetest.RandomDatasetID())
+				c := universe.NewMergeJoinCache(executetest.UnlimitedAllocator, parents, tableNames, spec.On, spec.BufferSize)
+				if err := c.SetOnOverflow(spec.OnOverflow); err != nil {
+					t.Fatal(err)
+				}
+				c.SetTriggerSpec(plan.DefaultTriggerSpec)
+				var jt execute.Transformation
+				if strategy == universe.HashJoinStrategy {
+					jt = universe.NewHashJoinTransformation(d, c, spec, parents, tableNames)
+				} else {
+					jt = universe.NewMergeJoinTransformation(d, c, spec, parents, tableNames)
+				}
+
+				for i, tables := range [][]*executetest.Table{left(), right()} {
+					for _, tbl := range tables {
+						if err := jt.Process(parents[i], tbl); err != nil {
+							t.Fatal(err)
+						}
+					}
+				}
+				jt.Finish(parents[0], nil)
+				jt.Finish(parents[1], nil)
+
+				if _, err := executetest.TablesFromCache(c); err != nil {
+					t.Fatal(err)
+				}
+				if got := c.Stats(); !cmp.Equal(tc.want, got) {
+					t.Errorf("unexpected stats -want/+got\n%s", cmp.Diff(tc.want, got))
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkMergeJoin measures the throughput of joining two tables.
+// Compare the results with those of a previous revision using
+// benchstat to measure the cost of a change to the join.
+func BenchmarkMergeJoin(b *testing.B) {
+	const n = 1000
+	table := func() *executetest.Table {
+		tbl := &executetest.Table{
+			ColMeta: []flux.ColMeta{
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_value", Type: flux.TFloat},
+			},
+			Data: make([][]interface{}, n),
+		}
+		for i := range tbl.Data {
+			tbl.Data[i] = []interface{}{execute.Time(i), float64(i)}
+		}
+		return tbl
+	}
+
+	for _, strategy := range []string{universe.MergeJoinStrategy, universe.HashJoinStrategy} {
+		strategy := strategy
+		b.Run(strategy, func(b *testing.B) {
+			spec := &universe.MergeJoinProcedureSpec{
+				On:         []string{"_time"},
+				TableNames: []string{"a", "b"},
+				Strategy:   strategy,
+			}
+			parents := []execute.DatasetID{
+				executetest.RandomDatasetID(),
+				executetest.RandomDatasetID(),
+			}
+			tableNames := map[execute.DatasetID]string{
+				parents[0]: "a",
+				parents[1]: "b",
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				left, right := table(), table()
+				b.StartTimer()
+
+				d := executetest.NewDataset(executetest.RandomDatasetID())
+				c := universe.NewMergeJoinCache(executetest.UnlimitedAllocator, parents, tableNames, spec.On, spec.BufferSize)
+				c.SetTriggerSpec(plan.DefaultTriggerSpec)
+				var jt execute.Transformation
+				if strategy == universe.HashJoinStrategy {
+					jt = universe.NewHashJoinTransformation(d, c, spec, parents, tableNames)
+				} else {
+					jt = universe.NewMergeJoinTransformation(d, c, spec, parents, tableNames)
+				}
+				if err := jt.Process(parents[0], left); err != nil {
+					b.Fatal(err)
+				}
+				if err := jt.Process(parents[1], right); err != nil {
+					b.Fatal(err)
+				}
+				if _, err := executetest.TablesFromCache(c); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkMergeJoin_DuplicateKeys measures joining two tables whose
+// rows all have the same value of the on column, so that every row
+// of the left table matches every row of the right table.
+func BenchmarkMergeJoin_DuplicateKeys(b *testing.B) {
+	const n = 500
+	table := func() *executetest.Table {
+		tbl := &executetest.Table{
+			ColMeta: []flux.ColMeta{
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_value", Type: flux.TFloat},
+				{Label: "host", Type: flux.TString},
+			},
+			Data: make([][]interface{}, n),
+		}
+		for i := range tbl.Data {
+			tbl.Data[i] = []interface{}{execute.Time(i), float64(i), "a"}
+		}
+		return tbl
+	}
+
+	for _, strategy := range []string{universe.MergeJoinStrategy, universe.HashJoinStrategy} {
+		strategy := strategy
+		b.Run(strategy, func(b *testing.B) {
+			spec := &universe.MergeJoinProcedureSpec{
+				On:         []string{"host"},
+				TableNames: []string{"a", "b"},
+				Strategy:   strategy,
+			}
+			parents := []execute.DatasetID{
+				executetest.RandomDatasetID(),
+				executetest.RandomDatasetID(),
+			}
+			tableNames := map[execute.DatasetID]string{
+				parents[0]: "a",
+				parents[1]: "b",
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				left, right := table(), table()
+				b.StartTimer()
+
+				d := executetest.NewDataset(executetest.RandomDatasetID())
+				c := universe.NewMergeJoinCache(executetest.UnlimitedAllocator, parents, tableNames, spec.On, spec.BufferSize)
+				c.SetTriggerSpec(plan.DefaultTriggerSpec)
+				var jt execute.Transformation
+				if strategy == universe.HashJoinStrategy {
+					jt = universe.NewHashJoinTransformation(d, c, spec, parents, tableNames)
+				} else {
+					jt = universe.NewMergeJoinTransformation(d, c, spec, parents, tableNames)
+				}
+				if err := jt.Process(parents[0], left); err != nil {
+					b.Fatal(err)
+				}
+				if err := jt.Process(parents[1], right); err != nil {
+					b.Fatal(err)
+				}
+				if err := c.ForEach(func(key flux.GroupKey) error {
+					tbl, err := c.Table(key)
+					if err != nil {
+						return err
+					}
+					tbl.Done()
+					return nil
+				}); err != nil {
+					b.Fatal(err)
+				}
 			}
 		})
 	}
@@ -91,6 +91,12 @@ func (s *LimitProcedureSpec) Copy() plan.ProcedureSpec {
 	return ns
 }
 
+// PassThroughAttribute implements plan.PassThroughAttributer.
+// Limit only removes rows so the order of the rows is preserved.
+func (s *LimitProcedureSpec) PassThroughAttribute(attrKey string) bool {
+	return attrKey == plan.CollationKey
+}
+
 // TriggerSpec implements plan.TriggerAwareProcedureSpec
 func (s *LimitProcedureSpec) TriggerSpec() plan.TriggerSpec {
 	return plan.NarrowTransformationTriggerSpec{}
@@ -176,7 +182,20 @@ func (t *limitTransformation) limitTable(ctx context.Context, w *table.StreamWri
 			}
 			vs[j] = arr
 		}
-		return w.Write(vs)
+
+		// The rows are sliced from the input so the key
+		// columns are constant if the input columns are.
+		buffer := &arrow.TableBuffer{
+			GroupKey:    w.Key(),
+			Columns:     w.Cols(),
+			Values:      vs,
+			KeyConstant: arrow.IsKeyConstant(cr),
+		}
+		if err := buffer.Validate(); err != nil {
+			buffer.Release()
+			return err
+		}
+		return w.UnsafeWriteBuffer(buffer)
 	})
 }
 
@@ -0,0 +1,254 @@
+package universe
+
+import (
+	"github.com/apache/arrow/go/v7/arrow/bitutil"
+	arrowmem "github.com/apache/arrow/go/v7/arrow/memory"
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/array"
+	"github.com/influxdata/flux/arrow"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/internal/arrowutil"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/internal/execute/table"
+	"github.com/influxdata/flux/interval"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/plan"
+	"github.com/influxdata/flux/runtime"
+	"github.com/influxdata/flux/values"
+)
+
+const LimitPerWindowKind = "limitPerWindow"
+
+// LimitPerWindowOpSpec limits the number of rows returned
+// per time window within each table.
+type LimitPerWindowOpSpec struct {
+	N      int64         `json:"n"`
+	Every  flux.Duration `json:"every"`
+	Offset flux.Duration `json:"offset"`
+}
+
+func init() {
+	limitPerWindowSignature := runtime.MustLookupBuiltinType("universe", LimitPerWindowKind)
+
+	runtime.RegisterPackageValue("universe", LimitPerWindowKind, flux.MustValue(flux.FunctionValue(LimitPerWindowKind, createLimitPerWindowOpSpec, limitPerWindowSignature)))
+	flux.RegisterOpSpec(LimitPerWindowKind, newLimitPerWindowOp)
+	plan.RegisterProcedureSpec(LimitPerWindowKind, newLimitPerWindowProcedure, LimitPerWindowKind)
+	execute.RegisterTransformation(LimitPerWindowKind, createLimitPerWindowTransformation)
+}
+
+func createLimitPerWindowOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
+	if err := a.AddParentFromArgs(args); err != nil {
+		return nil, err
+	}
+
+	spec := new(LimitPerWindowOpSpec)
+
+	n, err := args.GetRequiredInt("n")
+	if err != nil {
+		return nil, err
+	}
+	spec.N = n
+
+	every, err := args.GetRequiredDuration("every")
+	if err != nil {
+		return nil, err
+	}
+	if every.IsNegative() || every.IsZero() {
+		return nil, errors.New(codes.Invalid, `parameter "every" must be positive`)
+	}
+	spec.Every = every
+
+	if offset, ok, err := args.GetDuration("offset"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.Offset = offset
+	}
+
+	return spec, nil
+}
+
+func newLimitPerWindowOp() flux.OperationSpec {
+	return new(LimitPerWindowOpSpec)
+}
+
+func (s *LimitPerWindowOpSpec) Kind() flux.OperationKind {
+	return LimitPerWindowKind
+}
+
+type LimitPerWindowProcedureSpec struct {
+	plan.DefaultCost
+	N      int64         `json:"n"`
+	Every  flux.Duration `json:"every"`
+	Offset flux.Duration `json:"offset"`
+}
+
+func newLimitPerWindowProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.ProcedureSpec, error) {
+	spec, ok := qs.(*LimitPerWindowOpSpec)
+	if !ok {
+		return nil, errors.Newf(codes.Internal, "invalid spec type %T", qs)
+	}
+	return &LimitPerWindowProcedureSpec{
+		N:      spec.N,
+		Every:  spec.Every,
+		Offset: spec.Offset,
+	}, nil
+}
+
+func (s *LimitPerWindowProcedureSpec) Kind() plan.ProcedureKind {
+	return LimitPerWindowKind
+}
+func (s *LimitPerWindowProcedureSpec) Copy() plan.ProcedureSpec {
+	ns := new(LimitPerWindowProcedureSpec)
+	*ns = *s
+	return ns
+}
+
+// PassThroughAttribute implements plan.PassThroughAttributer.
+// LimitPerWindow only removes rows so the order of the rows is preserved.
+func (s *LimitPerWindowProcedureSpec) PassThroughAttribute(attrKey string) bool {
+	return attrKey == plan.CollationKey
+}
+
+// OutputSchema implements plan.OutputSchemer.
+// LimitPerWindow only removes rows so the columns are those of its input.
+func (s *LimitPerWindowProcedureSpec) OutputSchema(input flux.ResultSchema) flux.ResultSchema {
+	return input
+}
+
+// TriggerSpec implements plan.TriggerAwareProcedureSpec
+func (s *LimitPerWindowProcedureSpec) TriggerSpec() plan.TriggerSpec {
+	return plan.NarrowTransformationTriggerSpec{}
+}
+
+func createLimitPerWindowTransformation(id execute.DatasetID, mode execute.AccumulationMode, spec plan.ProcedureSpec, a execute.Administration) (execute.Transformation, execute.Dataset, error) {
+	s, ok := spec.(*LimitPerWindowProcedureSpec)
+	if !ok {
+		return nil, nil, errors.Newf(codes.Internal, "invalid spec type %T", spec)
+	}
+	return NewLimitPerWindowTransformation(s, id, a.Allocator())
+}
+
+// NewLimitPerWindowTransformation creates a transformation that keeps
+// the first n rows of each time window within each table.
+// The windows are never materialized so the group key of each
+// table is preserved.
+func NewLimitPerWindowTransformation(
+	spec *LimitPerWindowProcedureSpec,
+	id execute.DatasetID,
+	mem memory.Allocator,
+) (execute.Transformation, execute.Dataset, error) {
+	w, err := interval.NewWindow(spec.Every, spec.Every, spec.Offset)
+	if err != nil {
+		return nil, nil, err
+	}
+	t := &limitPerWindowTransformation{
+		n:       int(spec.N),
+		w:       w,
+		timeCol: execute.DefaultTimeColLabel,
+	}
+	return execute.NewNarrowStateTransformation(id, t, mem)
+}
+
+type limitPerWindowTransformation struct {
+	n       int
+	w       interval.Window
+	timeCol string
+}
+
+// limitPerWindowState tracks the window of the most recent row
+// within a table and how many rows have been kept from it.
+type limitPerWindowState struct {
+	bounds interval.Bounds
+	count  int
+	last   values.Time
+	seen   bool
+}
+
+func (t *limitPerWindowTransformation) Process(
+	chunk table.Chunk,
+	state interface{},
+	dataset *execute.TransportDataset,
+	mem arrowmem.Allocator,
+) (interface{}, bool, error) {
+	var s *limitPerWindowState
+	if state == nil {
+		s = &limitPerWindowState{}
+	} else {
+		s = state.(*limitPerWindowState)
+	}
+
+	timeIdx := execute.ColIdx(t.timeCol, chunk.Cols())
+	if timeIdx < 0 {
+		return nil, false, errors.Newf(codes.FailedPrecondition, "missing time column %q", t.timeCol)
+	}
+	if typ := chunk.Col(timeIdx).Type; typ != flux.TTime {
+		return nil, false, errors.Newf(codes.FailedPrecondition, "time column %q must be of type %s, got %s", t.timeCol, flux.TTime, typ)
+	}
+
+	out, err := t.limitChunk(chunk, timeIdx, s, mem)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := dataset.Process(out); err != nil {
+		return nil, false, err
+	}
+	return s, true, nil
+}
+
+func (t *limitPerWindowTransformation) limitChunk(chunk table.Chunk, timeIdx int, state *limitPerWindowState, mem arrowmem.Allocator) (table.Chunk, error) {
+	times := chunk.Ints(timeIdx)
+	l := times.Len()
+
+	bitset := arrowmem.NewResizableBuffer(mem)
+	bitset.Resize(l)
+	defer bitset.Release()
+
+	for i := 0; i < l; i++ {
+		// Rows without a time do not belong to any window.
+		if times.IsNull(i) {
+			bitutil.ClearBit(bitset.Buf(), i)
+			continue
+		}
+
+		tm := values.Time(times.Value(i))
+		if state.seen && tm < state.last {
+			return table.Chunk{}, errors.Newf(codes.FailedPrecondition, "limitPerWindow requires input sorted by %q; found %s after %s", t.timeCol, tm, state.last)
+		}
+		if !state.seen || !state.bounds.Contains(tm) {
+			state.bounds = t.w.GetLatestBounds(tm)
+			state.count = 0
+		}
+		state.last, state.seen = tm, true
+
+		keep := state.count < t.n
+		if keep {
+			state.count++
+		}
+		bitutil.SetBitTo(bitset.Buf(), i, keep)
+	}
+
+	n := bitutil.CountSetBits(bitset.Buf(), 0, l)
+	vs := make([]array.Array, len(chunk.Cols()))
+	for j, col := range chunk.Cols() {
+		arr := chunk.Values(j)
+		if n == l {
+			arr.Retain()
+			vs[j] = arr
+			continue
+		}
+		if chunk.Key().HasCol(col.Label) {
+			vs[j] = arrow.Slice(arr, 0, int64(n))
+			continue
+		}
+		vs[j] = arrowutil.Filter(arr, bitset.Bytes(), mem)
+	}
+
+	return table.ChunkFromBuffer(arrow.TableBuffer{
+		GroupKey: chunk.Key(),
+		Columns:  chunk.Cols(),
+		Values:   vs,
+	}), nil
+}
+
+func (*limitPerWindowTransformation) Close() error { return nil }
@@ -0,0 +1,440 @@
+package universe_test
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/execute/executetest"
+	"github.com/influxdata/flux/internal/errors"
+	"github.com/influxdata/flux/internal/gen"
+	"github.com/influxdata/flux/interval"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/plan"
+	"github.com/influxdata/flux/stdlib/universe"
+	"github.com/influxdata/flux/values"
+)
+
+func TestLimitPerWindow_Process(t *testing.T) {
+	testCases := []struct {
+		name    string
+		spec    *universe.LimitPerWindowProcedureSpec
+		data    []flux.Table
+		want    []*executetest.Table
+		wantErr error
+	}{
+		{
+			name: "one table",
+			spec: &universe.LimitPerWindowProcedureSpec{
+				N:     2,
+				Every: flux.ConvertDuration(10 * time.Nanosecond),
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{execute.Time(1), 1.0},
+					{execute.Time(2), 2.0},
+					{execute.Time(3), 3.0},
+					{execute.Time(10), 4.0},
+					{execute.Time(25), 5.0},
+					{execute.Time(26), 6.0},
+					{execute.Time(29), 7.0},
+				},
+			}},
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{execute.Time(1), 1.0},
+					{execute.Time(2), 2.0},
+					{execute.Time(10), 4.0},
+					{execute.Time(25), 5.0},
+					{execute.Time(26), 6.0},
+				},
+			}},
+		},
+		{
+			name: "offset",
+			spec: &universe.LimitPerWindowProcedureSpec{
+				N:      1,
+				Every:  flux.ConvertDuration(10 * time.Nanosecond),
+				Offset: flux.ConvertDuration(5 * time.Nanosecond),
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{execute.Time(1), 1.0},
+					{execute.Time(4), 2.0},
+					{execute.Time(5), 3.0},
+					{execute.Time(14), 4.0},
+					{execute.Time(15), 5.0},
+				},
+			}},
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{execute.Time(1), 1.0},
+					{execute.Time(5), 3.0},
+					{execute.Time(15), 5.0},
+				},
+			}},
+		},
+		{
+			name: "null times",
+			spec: &universe.LimitPerWindowProcedureSpec{
+				N:     1,
+				Every: flux.ConvertDuration(10 * time.Nanosecond),
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{nil, 1.0},
+					{execute.Time(2), 2.0},
+					{nil, 3.0},
+					{execute.Time(12), 4.0},
+				},
+			}},
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{execute.Time(2), 2.0},
+					{execute.Time(12), 4.0},
+				},
+			}},
+		},
+		{
+			name: "multiple tables",
+			spec: &universe.LimitPerWindowProcedureSpec{
+				N:     1,
+				Every: flux.ConvertDuration(10 * time.Nanosecond),
+			},
+			data: []flux.Table{
+				&executetest.Table{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "t0", Type: flux.TString},
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{"a", execute.Time(1), 1.0},
+						{"a", execute.Time(2), 2.0},
+						{"a", execute.Time(11), 3.0},
+					},
+				},
+				&executetest.Table{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "t0", Type: flux.TString},
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{"b", execute.Time(3), 4.0},
+						{"b", execute.Time(4), 5.0},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "t0", Type: flux.TString},
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{"a", execute.Time(1), 1.0},
+						{"a", execute.Time(11), 3.0},
+					},
+				},
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "t0", Type: flux.TString},
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{"b", execute.Time(3), 4.0},
+					},
+				},
+			},
+		},
+		{
+			name: "unsorted",
+			spec: &universe.LimitPerWindowProcedureSpec{
+				N:     1,
+				Every: flux.ConvertDuration(10 * time.Nanosecond),
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{execute.Time(2), 1.0},
+					{execute.Time(1), 2.0},
+				},
+			}},
+			wantErr: errors.Newf(codes.FailedPrecondition, "limitPerWindow requires input sorted by %q; found %s after %s", "_time", values.Time(1), values.Time(2)),
+		},
+		{
+			name: "missing time column",
+			spec: &universe.LimitPerWindowProcedureSpec{
+				N:     1,
+				Every: flux.ConvertDuration(10 * time.Nanosecond),
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_value", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{1.0},
+				},
+			}},
+			wantErr: errors.New(codes.FailedPrecondition, `missing time column "_time"`),
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			executetest.ProcessTestHelper2(
+				t,
+				tc.data,
+				tc.want,
+				tc.wantErr,
+				func(id execute.DatasetID, alloc memory.Allocator) (execute.Transformation, execute.Dataset) {
+					tr, d, err := universe.NewLimitPerWindowTransformation(tc.spec, id, alloc)
+					if err != nil {
+						t.Fatal(err)
+					}
+					return tr, d
+				},
+			)
+		})
+	}
+}
+
+// TestLimitPerWindow_Unfused checks that limitPerWindow produces the same
+// result as window |> limit |> window(every: inf).
+func TestLimitPerWindow_Unfused(t *testing.T) {
+	bounds := interval.NewBounds(0, values.Time(10*time.Minute))
+	for _, tc := range []struct {
+		name string
+		spec *universe.LimitPerWindowProcedureSpec
+	}{
+		{
+			name: "every 1m",
+			spec: &universe.LimitPerWindowProcedureSpec{
+				N:     3,
+				Every: flux.ConvertDuration(time.Minute),
+			},
+		},
+		{
+			name: "every 45s offset 10s",
+			spec: &universe.LimitPerWindowProcedureSpec{
+				N:      2,
+				Every:  flux.ConvertDuration(45 * time.Second),
+				Offset: flux.ConvertDuration(10 * time.Second),
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			alloc := &memory.ResourceAllocator{}
+			store := executetest.NewDataStore()
+			tr, d := newUnfusedLimitPerWindow(tc.spec, bounds, executetest.RandomDatasetID(), alloc, new(int))
+			d.SetTriggerSpec(plan.DefaultTriggerSpec)
+			d.AddTransformation(store)
+
+			parentID := executetest.RandomDatasetID()
+			for _, tbl := range limitPerWindowTables(bounds) {
+				if err := tr.Process(parentID, tbl); err != nil {
+					t.Fatal(err)
+				}
+			}
+			tr.Finish(parentID, nil)
+			if err := store.Err(); err != nil {
+				t.Fatal(err)
+			}
+
+			want, err := executetest.TablesFromCache(store)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			executetest.ProcessTestHelper2(
+				t,
+				limitPerWindowTables(bounds),
+				want,
+				nil,
+				func(id execute.DatasetID, alloc memory.Allocator) (execute.Transformation, execute.Dataset) {
+					tr, d, err := universe.NewLimitPerWindowTransformation(tc.spec, id, alloc)
+					if err != nil {
+						t.Fatal(err)
+					}
+					return tr, d
+				},
+			)
+		})
+	}
+}
+
+// limitPerWindowTables generates tables with irregularly spaced, sorted
+// times that fall within bounds, as they would be after a call to range.
+func limitPerWindowTables(bounds interval.Bounds) []flux.Table {
+	r := rand.New(rand.NewSource(1))
+	tables := make([]flux.Table, 0, 3)
+	for _, tag := range []string{"a", "b", "c"} {
+		tbl := &executetest.Table{
+			KeyCols: []string{"_start", "_stop", "t0"},
+			ColMeta: []flux.ColMeta{
+				{Label: "_start", Type: flux.TTime},
+				{Label: "_stop", Type: flux.TTime},
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_value", Type: flux.TFloat},
+				{Label: "t0", Type: flux.TString},
+			},
+		}
+		for ts := bounds.Start(); ts < bounds.Stop(); ts += values.Time(r.Int63n(int64(20 * time.Second))) {
+			tbl.Data = append(tbl.Data, []interface{}{
+				bounds.Start(), bounds.Stop(), ts, r.Float64(), tag,
+			})
+		}
+		tables = append(tables, tbl)
+	}
+	return tables
+}
+
+// newUnfusedLimitPerWindow creates the window |> limit |> window(every: inf)
+// pipeline that limitPerWindow replaces. The number of tables produced
+// by the first window is added to windowTables.
+func newUnfusedLimitPerWindow(spec *universe.LimitPerWindowProcedureSpec, bounds interval.Bounds, id execute.DatasetID, alloc memory.Allocator, windowTables *int) (execute.Transformation, execute.Dataset) {
+	w, err := interval.NewWindow(spec.Every, spec.Every, spec.Offset)
+	if err != nil {
+		panic(err)
+	}
+	windowCache := execute.NewTableBuilderCache(alloc)
+	windowDataset := execute.NewDataset(executetest.RandomDatasetID(), execute.DiscardingMode, windowCache)
+	windowDataset.SetTriggerSpec(plan.DefaultTriggerSpec)
+	window := universe.NewFixedWindowTransformation(
+		windowDataset,
+		windowCache,
+		bounds,
+		w,
+		execute.DefaultTimeColLabel,
+		execute.DefaultStartColLabel,
+		execute.DefaultStopColLabel,
+		false,
+	)
+
+	limit, limitDataset := universe.NewLimitTransformation(&universe.LimitProcedureSpec{N: spec.N}, executetest.RandomDatasetID())
+	windowDataset.AddTransformation(&countingTransformation{Transformation: limit, n: windowTables})
+
+	inf := values.ConvertDurationNsecs(math.MaxInt64)
+	infWindow, err := interval.NewWindow(inf, inf, values.ConvertDurationNsecs(0))
+	if err != nil {
+		panic(err)
+	}
+	cache := execute.NewTableBuilderCache(alloc)
+	d := execute.NewDataset(id, execute.DiscardingMode, cache)
+	limitDataset.AddTransformation(universe.NewFixedWindowTransformation(
+		d,
+		cache,
+		bounds,
+		infWindow,
+		execute.DefaultTimeColLabel,
+		execute.DefaultStartColLabel,
+		execute.DefaultStopColLabel,
+		false,
+	))
+	return window, d
+}
+
+// countingTransformation counts the tables passed to a transformation.
+type countingTransformation struct {
+	execute.Transformation
+	n *int
+}
+
+func (t *countingTransformation) Process(id execute.DatasetID, tbl flux.Table) error {
+	*t.n++
+	return t.Transformation.Process(id, tbl)
+}
+
+func BenchmarkLimitPerWindow(b *testing.B) {
+	spec := &universe.LimitPerWindowProcedureSpec{
+		N:     2,
+		Every: flux.ConvertDuration(time.Minute),
+	}
+	executetest.ProcessBenchmarkHelper(b,
+		genLimitPerWindowInput,
+		func(id execute.DatasetID, alloc memory.Allocator) (execute.Transformation, execute.Dataset) {
+			tr, d, err := universe.NewLimitPerWindowTransformation(spec, id, alloc)
+			if err != nil {
+				b.Fatal(err)
+			}
+			return tr, d
+		},
+	)
+}
+
+// BenchmarkLimitPerWindow_Unfused measures the window |> limit |> window(every: inf)
+// pipeline and reports how many intermediate window tables it creates.
+func BenchmarkLimitPerWindow_Unfused(b *testing.B) {
+	spec := &universe.LimitPerWindowProcedureSpec{
+		N:     2,
+		Every: flux.ConvertDuration(time.Minute),
+	}
+	bounds := interval.NewBounds(
+		values.ConvertTime(limitPerWindowStart),
+		values.ConvertTime(limitPerWindowStart.Add(10000*10*time.Second)),
+	)
+	var windowTables int
+	executetest.ProcessBenchmarkHelper(b,
+		genLimitPerWindowInput,
+		func(id execute.DatasetID, alloc memory.Allocator) (execute.Transformation, execute.Dataset) {
+			return newUnfusedLimitPerWindow(spec, bounds, id, alloc, &windowTables)
+		},
+	)
+	b.ReportMetric(float64(windowTables)/float64(b.N), "tables/op")
+}
+
+var limitPerWindowStart = time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func genLimitPerWindowInput(alloc memory.Allocator) (flux.TableIterator, error) {
+	schema := gen.Schema{
+		Start:     limitPerWindowStart,
+		NumPoints: 10000,
+		Alloc:     alloc,
+		Tags: []gen.Tag{
+			{Name: "_measurement", Cardinality: 1},
+			{Name: "_field", Cardinality: 2},
+			{Name: "t0", Cardinality: 10},
+		},
+	}
+	return gen.Input(context.Background(), schema)
+}
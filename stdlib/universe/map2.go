@@ -32,6 +32,12 @@ func newMapTransformation2(ctx context.Context, id execute.DatasetID, spec *MapP
 				spec.Fn.Fn.Vectorized,
 				compiler.ToScope(spec.Fn.Scope),
 			),
+			row: &mapRowFunc{
+				fn: execute.NewRowMapFn(
+					spec.Fn.Fn,
+					compiler.ToScope(spec.Fn.Scope),
+				),
+			},
 		}
 	} else {
 		fn = &mapRowFunc{
@@ -384,9 +390,18 @@ func (m *mapRowPreparedFunc) Eval(ctx context.Context, chunk table.Chunk, mem me
 
 type mapVectorFunc struct {
 	fn *execute.VectorMapFn
+	// row is used for tables with columns that have
+	// no vector representation such as bytes.
+	row *mapRowFunc
 }
 
 func (m *mapVectorFunc) Prepare(cols []flux.ColMeta) (mapPreparedFunc, error) {
+	for _, col := range cols {
+		if col.Type == flux.TBytes {
+			return m.row.Prepare(cols)
+		}
+	}
+
 	fn, err := m.fn.Prepare(cols)
 	if err != nil {
 		return nil, err
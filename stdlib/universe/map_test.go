@@ -1000,6 +1000,38 @@ f
 			},
 			wantErr: errors.New(`map regroups data such that column "_value" would include values of two different data types: string, float`),
 		},
+		{
+			name: `bytes column`,
+			spec: &universe.MapProcedureSpec{
+				Fn: interpreter.ResolvedFunction{
+					Scope: builtIns,
+					Fn:    executetest.FunctionExpression(t, `(r) => ({r with raw: bytes(v: r.host)})`),
+				},
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "host", Type: flux.TString},
+					{Label: "payload", Type: flux.TBytes},
+				},
+				Data: [][]interface{}{
+					{execute.Time(1), "a", []byte{0xde, 0xad}},
+					{execute.Time(2), "b", nil},
+				},
+			}},
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "host", Type: flux.TString},
+					{Label: "payload", Type: flux.TBytes},
+					{Label: "raw", Type: flux.TBytes},
+				},
+				Data: [][]interface{}{
+					{execute.Time(1), "a", []byte{0xde, 0xad}, []byte("a")},
+					{execute.Time(2), "b", nil, []byte("b")},
+				},
+			}},
+		},
 	}
 	for _, tc := range testCases {
 		tc := tc
@@ -70,6 +70,28 @@ func (s *MeanProcedureSpec) Copy() plan.ProcedureSpec {
 	}
 }
 
+// OutputSchema implements plan.OutputSchemer.
+// Mean keeps the columns of the group key and
+// replaces each aggregated column with its mean.
+func (s *MeanProcedureSpec) OutputSchema(input flux.ResultSchema) flux.ResultSchema {
+	if input.Dynamic {
+		return input
+	}
+	out := flux.ResultSchema{
+		Key:     append([]string(nil), input.Key...),
+		Partial: input.Partial,
+	}
+	for _, c := range input.Columns {
+		if input.IsKey(c.Label) {
+			out.Columns = append(out.Columns, c)
+		}
+	}
+	for _, label := range s.Columns {
+		out.SetColumn(flux.ColMeta{Label: label, Type: flux.TFloat}, false)
+	}
+	return out
+}
+
 // TriggerSpec implements plan.TriggerAwareProcedureSpec
 func (s *MeanProcedureSpec) TriggerSpec() plan.TriggerSpec {
 	return plan.NarrowTransformationTriggerSpec{}
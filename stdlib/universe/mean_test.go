@@ -3,12 +3,16 @@ package universe_test
 import (
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/influxdata/flux"
 	"github.com/influxdata/flux/array"
 	"github.com/influxdata/flux/arrow"
+	"github.com/influxdata/flux/execute"
 	"github.com/influxdata/flux/execute/executetest"
 	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/plan"
 	"github.com/influxdata/flux/querytest"
+	"github.com/influxdata/flux/stdlib/influxdata/influxdb"
 	"github.com/influxdata/flux/stdlib/universe"
 )
 
@@ -100,3 +104,36 @@ func BenchmarkMean(b *testing.B) {
 		9.99847267384332,
 	)
 }
+
+func TestMean_OutputSchema(t *testing.T) {
+	// from |> range |> mean
+	from := plan.CreatePhysicalNode("from", &influxdb.FromRemoteProcedureSpec{})
+	rng := plan.CreatePhysicalNode("range", &universe.RangeProcedureSpec{
+		TimeColumn:  execute.DefaultTimeColLabel,
+		StartColumn: execute.DefaultStartColLabel,
+		StopColumn:  execute.DefaultStopColLabel,
+	})
+	mean := plan.CreatePhysicalNode("mean", &universe.MeanProcedureSpec{
+		SimpleAggregateConfig: execute.DefaultSimpleAggregateConfig,
+	})
+	from.AddSuccessors(rng)
+	rng.AddPredecessors(from)
+	rng.AddSuccessors(mean)
+	mean.AddPredecessors(rng)
+
+	// The tags are not known from the plan so the schema is partial.
+	want := flux.ResultSchema{
+		Columns: []flux.ColMeta{
+			{Label: "_start", Type: flux.TTime},
+			{Label: "_stop", Type: flux.TTime},
+			{Label: "_field", Type: flux.TString},
+			{Label: "_measurement", Type: flux.TString},
+			{Label: "_value", Type: flux.TFloat},
+		},
+		Key:     []string{"_start", "_stop", "_field", "_measurement"},
+		Partial: true,
+	}
+	if got := plan.GetOutputSchema(mean); !cmp.Equal(want, got) {
+		t.Errorf("unexpected schema -want/+got:\n%s", cmp.Diff(want, got))
+	}
+}
@@ -153,6 +153,13 @@ func (s *PivotProcedureSpec) Copy() plan.ProcedureSpec {
 	return ns
 }
 
+// OutputSchema implements plan.OutputSchemer.
+// The columns created by pivot are named after
+// the values of the column key, so they are dynamic.
+func (s *PivotProcedureSpec) OutputSchema(flux.ResultSchema) flux.ResultSchema {
+	return flux.ResultSchema{Dynamic: true}
+}
+
 func createPivotTransformation(id execute.DatasetID, mode execute.AccumulationMode, spec plan.ProcedureSpec, a execute.Administration) (execute.Transformation, execute.Dataset, error) {
 	s, ok := spec.(*PivotProcedureSpec)
 	if !ok {
@@ -436,6 +443,13 @@ func (s *SortedPivotProcedureSpec) Copy() plan.ProcedureSpec {
 	return ns
 }
 
+// OutputSchema implements plan.OutputSchemer.
+// The columns created by pivot are named after
+// the values of the column key, so they are dynamic.
+func (s *SortedPivotProcedureSpec) OutputSchema(flux.ResultSchema) flux.ResultSchema {
+	return flux.ResultSchema{Dynamic: true}
+}
+
 func createSortedPivotTransformation(id execute.DatasetID, mode execute.AccumulationMode, spec plan.ProcedureSpec, a execute.Administration) (execute.Transformation, execute.Dataset, error) {
 	s, ok := spec.(*SortedPivotProcedureSpec)
 	if !ok {
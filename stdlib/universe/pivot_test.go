@@ -12,6 +12,7 @@ import (
 	"github.com/influxdata/flux/internal/errors"
 	"github.com/influxdata/flux/internal/gen"
 	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/plan"
 	"github.com/influxdata/flux/querytest"
 	"github.com/influxdata/flux/stdlib/influxdata/influxdb"
 	"github.com/influxdata/flux/stdlib/universe"
@@ -1641,3 +1642,28 @@ func benchmarkPivot(b *testing.B, n int) {
 		},
 	)
 }
+
+func TestPivot_OutputSchema(t *testing.T) {
+	for _, spec := range []plan.PhysicalProcedureSpec{
+		&universe.PivotProcedureSpec{
+			RowKey:      []string{"_time"},
+			ColumnKey:   []string{"_field"},
+			ValueColumn: "_value",
+		},
+		&universe.SortedPivotProcedureSpec{
+			RowKey:      []string{"_time"},
+			ColumnKey:   []string{"_field"},
+			ValueColumn: "_value",
+		},
+	} {
+		from := plan.CreatePhysicalNode("from", &influxdb.FromRemoteProcedureSpec{})
+		pivot := plan.CreatePhysicalNode("pivot", spec)
+		from.AddSuccessors(pivot)
+		pivot.AddPredecessors(from)
+
+		// The columns depend on the values of the column key.
+		if got := plan.GetOutputSchema(pivot); !got.Dynamic {
+			t.Errorf("expected dynamic schema for %s, got %+v", spec.Kind(), got)
+		}
+	}
+}
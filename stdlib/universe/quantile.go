@@ -32,6 +32,10 @@ type QuantileOpSpec struct {
 	Quantile    float64 `json:"quantile"`
 	Compression float64 `json:"compression"`
 	Method      string  `json:"method"`
+	// KeepRow reports whether the exact selector outputs every
+	// column of the selected row or only the group key and the
+	// selected column.
+	KeepRow bool `json:"keepRow"`
 	// quantile is either an aggregate, or a selector based on the options
 	execute.SimpleAggregateConfig
 	execute.SelectorConfig
@@ -88,12 +92,21 @@ func CreateQuantileOpSpec(args flux.Arguments, a *flux.Administration) (flux.Ope
 		spec.Compression = 1000
 	}
 
+	keepRow, keepRowOk, err := args.GetBool("keepRow")
+	if err != nil {
+		return nil, err
+	}
+
 	switch spec.Method {
 	case methodExactSelector:
 		if err := spec.SelectorConfig.ReadArgs(args); err != nil {
 			return nil, err
 		}
+		spec.KeepRow = !keepRowOk || keepRow
 	case methodEstimateTdigest, methodExactMean:
+		if keepRowOk {
+			return nil, errors.New(codes.Invalid, "keepRow parameter is only valid for method exact_selector")
+		}
 		if err := spec.SimpleAggregateConfig.ReadArgs(args); err != nil {
 			return nil, err
 		}
@@ -153,6 +166,7 @@ func (s *ExactQuantileAggProcedureSpec) TriggerSpec() plan.TriggerSpec {
 
 type ExactQuantileSelectProcedureSpec struct {
 	Quantile float64 `json:"quantile"`
+	KeepRow  bool    `json:"keepRow"`
 	execute.SelectorConfig
 }
 
@@ -160,7 +174,11 @@ func (s *ExactQuantileSelectProcedureSpec) Kind() plan.ProcedureKind {
 	return ExactQuantileSelectKind
 }
 func (s *ExactQuantileSelectProcedureSpec) Copy() plan.ProcedureSpec {
-	return &ExactQuantileSelectProcedureSpec{Quantile: s.Quantile}
+	return &ExactQuantileSelectProcedureSpec{
+		Quantile:       s.Quantile,
+		KeepRow:        s.KeepRow,
+		SelectorConfig: s.SelectorConfig,
+	}
 }
 
 // TriggerSpec implements plan.TriggerAwareProcedureSpec
@@ -182,7 +200,9 @@ func newQuantileProcedure(qs flux.OperationSpec, a plan.Administration) (plan.Pr
 		}, nil
 	case methodExactSelector:
 		return &ExactQuantileSelectProcedureSpec{
-			Quantile: spec.Quantile,
+			Quantile:       spec.Quantile,
+			KeepRow:        spec.KeepRow,
+			SelectorConfig: spec.SelectorConfig,
 		}, nil
 	case methodEstimateTdigest:
 		fallthrough
@@ -656,8 +676,17 @@ func (t *ExactQuantileSelectorTransformation) Process(id execute.DatasetID, tbl
 	if !created {
 		return errors.Newf(codes.FailedPrecondition, "found duplicate table with key: %v", tbl.Key())
 	}
-	if err := execute.AddTableCols(tbl, builder); err != nil {
-		return err
+
+	// Without keepRow, only the group key columns and the
+	// selected column are copied from the selected row.
+	cols := make([]int, 0, len(tbl.Cols()))
+	for j, col := range tbl.Cols() {
+		if t.spec.KeepRow || j == valueIdx || tbl.Key().HasCol(col.Label) {
+			if _, err := builder.AddCol(col); err != nil {
+				return err
+			}
+			cols = append(cols, j)
+		}
 	}
 
 	for j, col := range builder.Cols() {
@@ -675,7 +704,7 @@ func (t *ExactQuantileSelectorTransformation) Process(id execute.DatasetID, tbl
 			continue
 		}
 
-		v := values.New(row.Values[j])
+		v := values.New(row.Values[cols[j]])
 		if err := builder.AppendValue(j, v); err != nil {
 			return err
 		}
@@ -684,6 +713,10 @@ func (t *ExactQuantileSelectorTransformation) Process(id execute.DatasetID, tbl
 	return nil
 }
 
+// getQuantileIndex returns the index of the sorted row at the quantile.
+// It is the smallest index for which at least quantile * len rows are
+// less than or equal to the row. No value is interpolated, so when the
+// quantile falls between two rows the lower row is selected.
 func getQuantileIndex(quantile float64, len int) int {
 	x := quantile * float64(len)
 	index := int(math.Ceil(x))
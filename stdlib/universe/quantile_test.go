@@ -1,6 +1,7 @@
 package universe_test
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
@@ -131,6 +132,7 @@ func TestQuantile_NewQuery(t *testing.T) {
 						Spec: &universe.QuantileOpSpec{
 							Quantile:       0.99,
 							Method:         "exact_selector",
+							KeepRow:        true,
 							SelectorConfig: execute.DefaultSelectorConfig,
 						},
 					},
@@ -172,6 +174,7 @@ func TestQuantile_NewQuery(t *testing.T) {
 						Spec: &universe.QuantileOpSpec{
 							Quantile: 0.99,
 							Method:   "exact_selector",
+							KeepRow:  true,
 							SelectorConfig: execute.SelectorConfig{
 								Column: "foo",
 							},
@@ -285,6 +288,11 @@ func TestQuantile_NewQuery(t *testing.T) {
 			Raw:     `from(bucket:"testdb") |> range(start: -1h) |> quantile(q: 0.99, method: "exact_selector", columns: ["1", "2"])`,
 			WantErr: true,
 		},
+		{
+			Name:    "aggregate with keepRow",
+			Raw:     `from(bucket:"testdb") |> range(start: -1h) |> quantile(q: 0.99, method: "exact_mean", keepRow: true)`,
+			WantErr: true,
+		},
 	}
 	for _, tc := range tests {
 		tc := tc
@@ -776,13 +784,185 @@ func TestQuantileSelector_Process(t *testing.T) {
 				tc.want,
 				nil,
 				func(d execute.Dataset, c execute.TableBuilderCache) execute.Transformation {
-					return universe.NewExactQuantileSelectorTransformation(d, c, &universe.ExactQuantileSelectProcedureSpec{Quantile: tc.quantile}, executetest.UnlimitedAllocator)
+					return universe.NewExactQuantileSelectorTransformation(d, c, &universe.ExactQuantileSelectProcedureSpec{Quantile: tc.quantile, KeepRow: true}, executetest.UnlimitedAllocator)
 				},
 			)
 		})
 	}
 }
 
+func TestQuantileSelector_KeepRow(t *testing.T) {
+	cols := []flux.ColMeta{
+		{Label: "_time", Type: flux.TTime},
+		{Label: "_value", Type: flux.TFloat},
+		{Label: "host", Type: flux.TString},
+		{Label: "id", Type: flux.TInt},
+	}
+	// newTable returns a table with n rows whose values are shuffled
+	// so that the rank of a row differs from its position.
+	newTable := func(n int) *executetest.Table {
+		tbl := &executetest.Table{
+			KeyCols: []string{"host"},
+			ColMeta: cols,
+		}
+		for i := 0; i < n; i++ {
+			v := (i * 7) % n
+			tbl.Data = append(tbl.Data, []interface{}{
+				execute.Time(i * 10), float64(v), "a", int64(i),
+			})
+		}
+		return tbl
+	}
+	// rowAtRank returns the source row with the value at the given rank.
+	rowAtRank := func(tbl *executetest.Table, rank int) []interface{} {
+		for _, row := range tbl.Data {
+			if row[1].(float64) == float64(rank) {
+				return row
+			}
+		}
+		t.Fatalf("no row with rank %d", rank)
+		return nil
+	}
+
+	for _, tc := range []struct {
+		n    int
+		q    float64
+		rank int
+	}{
+		{n: 1, q: 0.5, rank: 0},
+		{n: 5, q: 0, rank: 0},
+		{n: 5, q: 0.5, rank: 2},
+		{n: 5, q: 0.9, rank: 4},
+		{n: 5, q: 1, rank: 4},
+		// The lower row is selected when q falls between two rows.
+		{n: 4, q: 0.5, rank: 1},
+		{n: 10, q: 0.25, rank: 2},
+		{n: 10, q: 0.5, rank: 4},
+		{n: 10, q: 0.99, rank: 9},
+		{n: 100, q: 0.95, rank: 94},
+	} {
+		for _, keepRow := range []bool{true, false} {
+			tc, keepRow := tc, keepRow
+			t.Run(fmt.Sprintf("n=%d/q=%v/keepRow=%v", tc.n, tc.q, keepRow), func(t *testing.T) {
+				data := newTable(tc.n)
+				row := rowAtRank(data, tc.rank)
+				want := &executetest.Table{
+					KeyCols: []string{"host"},
+					ColMeta: cols,
+					Data:    [][]interface{}{row},
+				}
+				if !keepRow {
+					want.ColMeta = []flux.ColMeta{cols[1], cols[2]}
+					want.Data = [][]interface{}{{row[1], row[2]}}
+				}
+				executetest.ProcessTestHelper(
+					t,
+					[]flux.Table{data},
+					[]*executetest.Table{want},
+					nil,
+					func(d execute.Dataset, c execute.TableBuilderCache) execute.Transformation {
+						spec := &universe.ExactQuantileSelectProcedureSpec{
+							Quantile: tc.q,
+							KeepRow:  keepRow,
+						}
+						return universe.NewExactQuantileSelectorTransformation(d, c, spec, executetest.UnlimitedAllocator)
+					},
+				)
+			})
+		}
+	}
+}
+
+func TestQuantileSelector_KeepRowGroups(t *testing.T) {
+	// Each table is a group, so each selected row
+	// comes from the table with the same group key.
+	data := []flux.Table{
+		&executetest.Table{
+			KeyCols: []string{"host"},
+			ColMeta: []flux.ColMeta{
+				{Label: "_time", Type: flux.TTime},
+				{Label: "host", Type: flux.TString},
+				{Label: "x", Type: flux.TInt},
+			},
+			Data: [][]interface{}{
+				{execute.Time(0), "a", int64(3)},
+				{execute.Time(10), "a", int64(1)},
+				{execute.Time(20), "a", int64(2)},
+			},
+		},
+		&executetest.Table{
+			KeyCols: []string{"host"},
+			ColMeta: []flux.ColMeta{
+				{Label: "_time", Type: flux.TTime},
+				{Label: "host", Type: flux.TString},
+				{Label: "x", Type: flux.TInt},
+			},
+			Data: [][]interface{}{
+				{execute.Time(5), "b", int64(8)},
+				{execute.Time(15), "b", int64(6)},
+			},
+		},
+		&executetest.Table{
+			KeyCols:   []string{"host"},
+			KeyValues: []interface{}{"c"},
+			ColMeta: []flux.ColMeta{
+				{Label: "_time", Type: flux.TTime},
+				{Label: "host", Type: flux.TString},
+				{Label: "x", Type: flux.TInt},
+			},
+		},
+	}
+	want := []*executetest.Table{
+		{
+			KeyCols: []string{"host"},
+			ColMeta: []flux.ColMeta{
+				{Label: "_time", Type: flux.TTime},
+				{Label: "host", Type: flux.TString},
+				{Label: "x", Type: flux.TInt},
+			},
+			Data: [][]interface{}{
+				{execute.Time(20), "a", int64(2)},
+			},
+		},
+		{
+			KeyCols: []string{"host"},
+			ColMeta: []flux.ColMeta{
+				{Label: "_time", Type: flux.TTime},
+				{Label: "host", Type: flux.TString},
+				{Label: "x", Type: flux.TInt},
+			},
+			Data: [][]interface{}{
+				{execute.Time(15), "b", int64(6)},
+			},
+		},
+		{
+			KeyCols: []string{"host"},
+			ColMeta: []flux.ColMeta{
+				{Label: "_time", Type: flux.TTime},
+				{Label: "host", Type: flux.TString},
+				{Label: "x", Type: flux.TInt},
+			},
+			Data: [][]interface{}{
+				{nil, "c", nil},
+			},
+		},
+	}
+	executetest.ProcessTestHelper(
+		t,
+		data,
+		want,
+		nil,
+		func(d execute.Dataset, c execute.TableBuilderCache) execute.Transformation {
+			spec := &universe.ExactQuantileSelectProcedureSpec{
+				Quantile:       0.5,
+				KeepRow:        true,
+				SelectorConfig: execute.SelectorConfig{Column: "x"},
+			}
+			return universe.NewExactQuantileSelectorTransformation(d, c, spec, executetest.UnlimitedAllocator)
+		},
+	)
+}
+
 func BenchmarkQuantile(b *testing.B) {
 	data := arrow.NewFloat(NormalData, &memory.ResourceAllocator{})
 	executetest.AggFuncBenchmarkHelper(
@@ -128,6 +128,25 @@ func (s *RangeProcedureSpec) Copy() plan.ProcedureSpec {
 	return ns
 }
 
+// PassThroughAttribute implements plan.PassThroughAttributer.
+// Range only removes rows so the order of the rows is preserved.
+func (s *RangeProcedureSpec) PassThroughAttribute(attrKey string) bool {
+	return attrKey == plan.CollationKey
+}
+
+// OutputSchema implements plan.OutputSchemer.
+// Range adds the start and stop columns to the group key
+// of its input when they are missing.
+func (s *RangeProcedureSpec) OutputSchema(input flux.ResultSchema) flux.ResultSchema {
+	out := input.Copy()
+	if out.Dynamic {
+		out = flux.ResultSchema{Partial: true}
+	}
+	out.SetColumn(flux.ColMeta{Label: s.StartColumn, Type: flux.TTime}, true)
+	out.SetColumn(flux.ColMeta{Label: s.StopColumn, Type: flux.TTime}, true)
+	return out
+}
+
 // TriggerSpec implements plan.TriggerAwareProcedureSpec
 func (s *RangeProcedureSpec) TriggerSpec() plan.TriggerSpec {
 	return plan.NarrowTransformationTriggerSpec{}
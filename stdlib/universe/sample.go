@@ -107,6 +107,9 @@ func (s *SampleProcedureSpec) TriggerSpec() plan.TriggerSpec {
 type SampleSelector struct {
 	N   int
 	Pos int
+	// Rand is used to choose the starting offset when Pos is negative.
+	// If it is nil, the global source is used.
+	Rand *rand.Rand
 
 	offset   int
 	selected []int
@@ -119,8 +122,9 @@ func createSampleTransformation(id execute.DatasetID, mode execute.AccumulationM
 	}
 
 	ss := &SampleSelector{
-		N:   int(ps.N),
-		Pos: int(ps.Pos),
+		N:    int(ps.N),
+		Pos:  int(ps.Pos),
+		Rand: execute.GetRandom(a.Context(), id),
 	}
 	t, d := execute.NewIndexSelectorTransformationAndDataset(id, mode, ss, ps.SelectorConfig, a.Allocator())
 	return t, d, nil
@@ -129,7 +133,11 @@ func createSampleTransformation(id execute.DatasetID, mode execute.AccumulationM
 func (s *SampleSelector) reset() {
 	pos := s.Pos
 	if pos < 0 {
-		pos = rand.Intn(s.N)
+		if s.Rand != nil {
+			pos = s.Rand.Intn(s.N)
+		} else {
+			pos = rand.Intn(s.N)
+		}
 	}
 	s.offset = pos
 }
@@ -2,6 +2,7 @@ package universe
 
 import (
 	"container/heap"
+	"context"
 	"sort"
 
 	"github.com/apache/arrow/go/v7/arrow/memory"
@@ -34,6 +35,7 @@ func init() {
 	flux.RegisterOpSpec(SortKind, newSortOp)
 	plan.RegisterProcedureSpec(SortKind, newSortProcedure, SortKind)
 	execute.RegisterTransformation(SortKind, createSortTransformation)
+	plan.RegisterPhysicalRules(RemoveRedundantSortRule{})
 }
 
 func createSortOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
@@ -105,6 +107,13 @@ func (s *SortProcedureSpec) TriggerSpec() plan.TriggerSpec {
 	return plan.NarrowTransformationTriggerSpec{}
 }
 
+// OutputAttributes implements plan.OutputAttributer.
+func (s *SortProcedureSpec) OutputAttributes() plan.PhysicalAttributes {
+	return plan.PhysicalAttributes{
+		plan.CollationKey: &plan.CollationAttr{Columns: s.Columns, Desc: s.Desc},
+	}
+}
+
 func createSortTransformation(id execute.DatasetID, mode execute.AccumulationMode, spec plan.ProcedureSpec, a execute.Administration) (execute.Transformation, execute.Dataset, error) {
 	s, ok := spec.(*SortProcedureSpec)
 	if !ok {
@@ -467,3 +476,30 @@ func (s *sortTableMergeHeap) NextBuffer(builders []array.Builder, keys []array.A
 	}
 	return buffer
 }
+
+// RemoveRedundantSortRule removes sort nodes whose predecessor already
+// guarantees that the rows within each table are sorted by the columns.
+// The sort is stable, so sorting rows that are already in order is a no-op.
+type RemoveRedundantSortRule struct{}
+
+func (RemoveRedundantSortRule) Name() string {
+	return "RemoveRedundantSortRule"
+}
+
+func (RemoveRedundantSortRule) Pattern() plan.Pattern {
+	return plan.Pat(SortKind, plan.Any())
+}
+
+func (RemoveRedundantSortRule) Rewrite(ctx context.Context, node plan.Node) (plan.Node, bool, error) {
+	spec := node.ProcedureSpec().(*SortProcedureSpec)
+	pred := node.Predecessors()[0]
+	attr := plan.GetOutputAttribute(pred, plan.CollationKey)
+	if attr == nil {
+		return node, false, nil
+	}
+	collation := &plan.CollationAttr{Columns: spec.Columns, Desc: spec.Desc}
+	if !collation.SatisfiedBy(attr) {
+		return node, false, nil
+	}
+	return pred, true, nil
+}
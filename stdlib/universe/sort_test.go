@@ -7,7 +7,10 @@ import (
 	"github.com/influxdata/flux/execute"
 	"github.com/influxdata/flux/execute/executetest"
 	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/plan"
+	"github.com/influxdata/flux/plan/plantest"
 	"github.com/influxdata/flux/querytest"
+	"github.com/influxdata/flux/stdlib/influxdata/influxdb"
 	"github.com/influxdata/flux/stdlib/universe"
 )
 
@@ -397,3 +400,157 @@ func TestSort_Process(t *testing.T) {
 		})
 	}
 }
+
+func TestRemoveRedundantSortRule(t *testing.T) {
+	var (
+		from        = &influxdb.FromRemoteProcedureSpec{}
+		count       = &universe.CountProcedureSpec{}
+		rangeSpec   = &universe.RangeProcedureSpec{}
+		limit       = &universe.LimitProcedureSpec{N: 10}
+		sortTime    = &universe.SortProcedureSpec{Columns: []string{"_time"}}
+		sortDesc    = &universe.SortProcedureSpec{Columns: []string{"_time"}, Desc: true}
+		sortValue   = &universe.SortProcedureSpec{Columns: []string{"_value"}}
+		sortTimeTag = &universe.SortProcedureSpec{Columns: []string{"_time", "host"}}
+	)
+
+	tests := []plantest.RuleTestCase{
+		{
+			Name: "source sorted by time",
+			// from -> sort => from
+			Rules: []plan.Rule{universe.RemoveRedundantSortRule{}},
+			Before: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("from", from),
+					plan.CreatePhysicalNode("sort", sortTime),
+				},
+				Edges: [][2]int{{0, 1}},
+			},
+			After: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("from", from),
+				},
+			},
+		},
+		{
+			Name: "order preserving transformations",
+			// from -> range -> limit -> sort -> count => from -> range -> limit -> count
+			Rules: []plan.Rule{universe.RemoveRedundantSortRule{}},
+			Before: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("from", from),
+					plan.CreatePhysicalNode("range", rangeSpec),
+					plan.CreatePhysicalNode("limit", limit),
+					plan.CreatePhysicalNode("sort", sortTime),
+					plan.CreatePhysicalNode("count", count),
+				},
+				Edges: [][2]int{{0, 1}, {1, 2}, {2, 3}, {3, 4}},
+			},
+			After: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("from", from),
+					plan.CreatePhysicalNode("range", rangeSpec),
+					plan.CreatePhysicalNode("limit", limit),
+					plan.CreatePhysicalNode("count", count),
+				},
+				Edges: [][2]int{{0, 1}, {1, 2}, {2, 3}},
+			},
+		},
+		{
+			Name: "sort by a prefix of a previous sort",
+			// from -> sort(_time, host) -> sort(_time) => from -> sort(_time, host)
+			Rules: []plan.Rule{universe.RemoveRedundantSortRule{}},
+			Before: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("from", count),
+					plan.CreatePhysicalNode("sort0", sortTimeTag),
+					plan.CreatePhysicalNode("sort1", sortTime),
+				},
+				Edges: [][2]int{{0, 1}, {1, 2}},
+			},
+			After: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("from", count),
+					plan.CreatePhysicalNode("sort0", sortTimeTag),
+				},
+				Edges: [][2]int{{0, 1}},
+			},
+		},
+		{
+			Name: "sort by more columns than a previous sort",
+			// from -> sort(_time) -> sort(_time, host)
+			Rules: []plan.Rule{universe.RemoveRedundantSortRule{}},
+			Before: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("from", count),
+					plan.CreatePhysicalNode("sort0", sortTime),
+					plan.CreatePhysicalNode("sort1", sortTimeTag),
+				},
+				Edges: [][2]int{{0, 1}, {1, 2}},
+			},
+			NoChange: true,
+		},
+		{
+			Name: "sort by another column",
+			// from -> sort(_value)
+			Rules: []plan.Rule{universe.RemoveRedundantSortRule{}},
+			Before: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("from", from),
+					plan.CreatePhysicalNode("sort", sortValue),
+				},
+				Edges: [][2]int{{0, 1}},
+			},
+			NoChange: true,
+		},
+		{
+			Name: "sort descending",
+			// from -> sort(_time, desc: true)
+			Rules: []plan.Rule{universe.RemoveRedundantSortRule{}},
+			Before: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("from", from),
+					plan.CreatePhysicalNode("sort", sortDesc),
+				},
+				Edges: [][2]int{{0, 1}},
+			},
+			NoChange: true,
+		},
+		{
+			Name: "transformation does not preserve order",
+			// from -> count -> sort
+			Rules: []plan.Rule{universe.RemoveRedundantSortRule{}},
+			Before: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("from", from),
+					plan.CreatePhysicalNode("count", count),
+					plan.CreatePhysicalNode("sort", sortTime),
+				},
+				Edges: [][2]int{{0, 1}, {1, 2}},
+			},
+			NoChange: true,
+		},
+		{
+			Name: "source with multiple successors",
+			// from -> sort
+			//     \-> count
+			Rules: []plan.Rule{universe.RemoveRedundantSortRule{}},
+			Before: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("from", from),
+					plan.CreatePhysicalNode("sort", sortTime),
+					plan.CreatePhysicalNode("count", count),
+				},
+				Edges: [][2]int{{0, 1}, {0, 2}},
+			},
+			NoChange: true,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			plantest.PhysicalRuleTestHelper(t, &tc)
+		})
+	}
+}
@@ -3,6 +3,8 @@ package universe
 import (
 	"context"
 	"math"
+	"strings"
+	"time"
 
 	"github.com/influxdata/flux"
 	"github.com/influxdata/flux/codes"
@@ -11,7 +13,6 @@ import (
 	"github.com/influxdata/flux/interval"
 	"github.com/influxdata/flux/plan"
 	"github.com/influxdata/flux/runtime"
-	"github.com/influxdata/flux/semantic"
 	"github.com/influxdata/flux/values"
 )
 
@@ -22,14 +23,28 @@ type WindowOpSpec struct {
 	Period      flux.Duration
 	Offset      flux.Duration
 	Location    plan.Location
+	WeekStart   string
 	TimeColumn  string
 	StopColumn  string
 	StartColumn string
 	CreateEmpty bool
+	Incremental bool
 }
 
 var infinityVar = values.NewDuration(values.ConvertDurationNsecs(math.MaxInt64))
 
+// weekdays maps the names accepted by the weekStart parameter
+// to the day of the week.
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
 func init() {
 	windowSignature := runtime.MustLookupBuiltinType("universe", "_window")
 
@@ -80,21 +95,18 @@ func CreateWindowOpSpec(args flux.Arguments, a *flux.Administration) (flux.Opera
 
 	if location, err := args.GetRequiredObject("location"); err != nil {
 		return nil, err
-	} else {
-		name, ok := location.Get("zone")
-		if !ok {
-			return nil, errors.New(codes.Invalid, "zone property missing from location record")
-		} else if got := name.Type().Nature(); got != semantic.String {
-			return nil, errors.Newf(codes.Invalid, "zone property for location must be of type %s, got %s", semantic.String, got)
-		}
-		spec.Location.Name = name.Str()
+	} else if spec.Location, err = plan.LocationFromObject(location); err != nil {
+		return nil, err
+	}
 
-		if offset, ok := location.Get("offset"); ok {
-			if got := offset.Type().Nature(); got != semantic.Duration {
-				return nil, errors.Newf(codes.Invalid, "offset property for location must be of type %s, got %s", semantic.Duration, got)
-			}
-			spec.Location.Offset = offset.Duration()
+	if weekStart, ok, err := args.GetString("weekStart"); err != nil {
+		return nil, err
+	} else if ok {
+		name := strings.ToLower(weekStart)
+		if _, ok := weekdays[name]; !ok {
+			return nil, errors.Newf(codes.Invalid, "invalid weekStart %q, must be the name of a day of the week such as \"monday\" or \"sunday\"", weekStart)
 		}
+		spec.WeekStart = name
 	}
 
 	if spec.Every.IsZero() && spec.Period.IsZero() {
@@ -129,6 +141,11 @@ func CreateWindowOpSpec(args flux.Arguments, a *flux.Administration) (flux.Opera
 	} else {
 		spec.CreateEmpty = createEmpty
 	}
+	if incremental, ok, err := args.GetBool("incremental"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.Incremental = incremental
+	}
 
 	// Apply defaults
 	if spec.Every.IsZero() {
@@ -156,6 +173,10 @@ type WindowProcedureSpec struct {
 	StopColumn string
 	CreateEmpty bool
 
+	// Incremental is set when the window is part of an aggregateWindow
+	// that merges its results with the state of previous executions.
+	Incremental bool
+
 	// Exposed for a test case. Do not use.
 	Optimize bool
 }
@@ -165,17 +186,25 @@ func newWindowProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.Pro
 	if !ok {
 		return nil, errors.Newf(codes.Internal, "invalid spec type %T", qs)
 	}
+	// Weeks are aligned to the epoch so a different week start
+	// is applied as an additional offset. This keeps the window
+	// spec the same for every consumer of it.
+	offset := s.Offset
+	if weekStart, ok := weekdays[s.WeekStart]; ok {
+		offset = interval.WeekStartOffset(s.Every, offset, weekStart)
+	}
 	p := &WindowProcedureSpec{
 		Window: plan.WindowSpec{
 			Every:    s.Every,
 			Period:   s.Period,
-			Offset:   s.Offset,
+			Offset:   offset,
 			Location: s.Location,
 		},
 		TimeColumn:  s.TimeColumn,
 		StartColumn: s.StartColumn,
 		StopColumn:  s.StopColumn,
 		CreateEmpty: s.CreateEmpty,
+		Incremental: s.Incremental,
 	}
 	return p, nil
 }
@@ -194,6 +223,13 @@ func createWindowTransformation(id execute.DatasetID, mode execute.AccumulationM
 		return nil, nil, errors.Newf(codes.Internal, "invalid spec type %T", spec)
 	}
 
+	// Incremental windows are only executed by the aggregateWindow
+	// transformation. Reaching this point means the aggregate could
+	// not be merged with previous results.
+	if s.Incremental {
+		return nil, nil, errors.New(codes.Invalid, "incremental aggregateWindow requires a decomposable aggregate: count, sum or mean")
+	}
+
 	if s.Optimize {
 		return newWindowTransformation2(id, s, a.StreamContext().Bounds(), a)
 	}
@@ -42,6 +42,7 @@ func TestWindow_NewQuery(t *testing.T) {
 							Location: plan.Location{
 								Name: "UTC",
 							},
+							WeekStart:   "thursday",
 							TimeColumn:  execute.DefaultTimeColLabel,
 							StartColumn: execute.DefaultStartColLabel,
 							StopColumn:  execute.DefaultStopColLabel,
@@ -53,6 +54,42 @@ func TestWindow_NewQuery(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name: "from with weekly window",
+			Raw:  `from(bucket:"mybucket") |> window(every:1w, weekStart: "Monday")`,
+			Want: &flux.Spec{
+				Operations: []*flux.Operation{
+					{
+						ID: "from0",
+						Spec: &influxdb.FromOpSpec{
+							Bucket: influxdb.NameOrID{Name: "mybucket"},
+						},
+					},
+					{
+						ID: "window1",
+						Spec: &universe.WindowOpSpec{
+							Every:  flux.ConvertDuration(7 * 24 * time.Hour),
+							Period: flux.ConvertDuration(7 * 24 * time.Hour),
+							Location: plan.Location{
+								Name: "UTC",
+							},
+							WeekStart:   "monday",
+							TimeColumn:  execute.DefaultTimeColLabel,
+							StartColumn: execute.DefaultStartColLabel,
+							StopColumn:  execute.DefaultStopColLabel,
+						},
+					},
+				},
+				Edges: []flux.Edge{
+					{Parent: "from0", Child: "window1"},
+				},
+			},
+		},
+		{
+			Name:    "invalid week start",
+			Raw:     `from(bucket:"mybucket") |> window(every:1w, weekStart: "someday")`,
+			WantErr: true,
+		},
 		{
 			Name:    "negative every window",
 			Raw:     `from(bucket:"mybucket") |> window(every:-1h)`,
@@ -6,12 +6,24 @@ import (
 	"github.com/influxdata/flux/internal/errors"
 	"github.com/influxdata/flux/plan"
 	"github.com/influxdata/flux/runtime"
+	"github.com/influxdata/flux/semantic"
+	"github.com/influxdata/flux/values"
 )
 
 const YieldKind = "yield"
 
+const (
+	// maxYieldMetadataEntries is the most entries that
+	// the metadata of a yield can have.
+	maxYieldMetadataEntries = 64
+	// maxYieldMetadataSize is the most bytes that the keys and
+	// values of the metadata of a yield can have together.
+	maxYieldMetadataSize = 4096
+)
+
 type YieldOpSpec struct {
-	Name string `json:"name"`
+	Name     string            `json:"name"`
+	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
 func init() {
@@ -38,9 +50,48 @@ func createYieldOpSpec(args flux.Arguments, a *flux.Administration) (flux.Operat
 		spec.Name = plan.DefaultYieldName
 	}
 
+	if metadata, ok, err := args.GetObject("metadata"); err != nil {
+		return nil, err
+	} else if ok {
+		if spec.Metadata, err = yieldMetadata(metadata); err != nil {
+			return nil, err
+		}
+	}
+
 	return spec, nil
 }
 
+// yieldMetadata converts the metadata object of a yield into a map.
+// The values of the object must be strings.
+func yieldMetadata(obj values.Object) (map[string]string, error) {
+	if obj.Len() > maxYieldMetadataEntries {
+		return nil, errors.Newf(codes.Invalid, "yield metadata has %d entries, the limit is %d", obj.Len(), maxYieldMetadataEntries)
+	}
+	var (
+		err  error
+		size int
+	)
+	metadata := make(map[string]string, obj.Len())
+	obj.Range(func(key string, v values.Value) {
+		if err != nil {
+			return
+		}
+		if v.IsNull() || v.Type().Nature() != semantic.String {
+			err = errors.Newf(codes.Invalid, "yield metadata %q must be a string, got %s", key, v.Type())
+			return
+		}
+		metadata[key] = v.Str()
+		size += len(key) + len(v.Str())
+	})
+	if err != nil {
+		return nil, err
+	}
+	if size > maxYieldMetadataSize {
+		return nil, errors.Newf(codes.Invalid, "yield metadata has %d bytes, the limit is %d", size, maxYieldMetadataSize)
+	}
+	return metadata, nil
+}
+
 func newYieldOp() flux.OperationSpec {
 	return new(YieldOpSpec)
 }
@@ -51,12 +102,13 @@ func (s *YieldOpSpec) Kind() flux.OperationKind {
 
 type YieldProcedureSpec struct {
 	plan.DefaultCost
-	Name string `json:"name"`
+	Name     string            `json:"name"`
+	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
 func newYieldProcedure(qs flux.OperationSpec, _ plan.Administration) (plan.ProcedureSpec, error) {
 	if spec, ok := qs.(*YieldOpSpec); ok {
-		return &YieldProcedureSpec{Name: spec.Name}, nil
+		return &YieldProcedureSpec{Name: spec.Name, Metadata: spec.Metadata}, nil
 	}
 
 	return nil, errors.Newf(codes.Internal, "invalid spec type %T", qs)
@@ -67,9 +119,27 @@ func (s *YieldProcedureSpec) Kind() plan.ProcedureKind {
 }
 
 func (s *YieldProcedureSpec) Copy() plan.ProcedureSpec {
-	return &YieldProcedureSpec{Name: s.Name}
+	ns := &YieldProcedureSpec{Name: s.Name}
+	if s.Metadata != nil {
+		ns.Metadata = make(map[string]string, len(s.Metadata))
+		for k, v := range s.Metadata {
+			ns.Metadata[k] = v
+		}
+	}
+	return ns
 }
 
 func (s *YieldProcedureSpec) YieldName() string {
 	return s.Name
 }
+
+// YieldMetadata implements plan.YieldMetadataSpec.
+func (s *YieldProcedureSpec) YieldMetadata() map[string]string {
+	return s.Metadata
+}
+
+// OutputSchema implements plan.OutputSchemer.
+// Yield outputs the tables of its input.
+func (s *YieldProcedureSpec) OutputSchema(input flux.ResultSchema) flux.ResultSchema {
+	return input
+}
@@ -216,6 +216,40 @@ func TestYield_NewQuery(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name: "yield with metadata",
+			Raw: `
+				from(bucket: "foo") |> yield(name: "1", metadata: {unit: "count", source: "foo"})
+			`,
+			Want: &flux.Spec{
+				Operations: []*flux.Operation{
+					{
+						ID: "from0",
+						Spec: &influxdb.FromOpSpec{
+							Bucket: influxdb.NameOrID{Name: "foo"},
+						},
+					},
+					{
+						ID: "yield1",
+						Spec: &universe.YieldOpSpec{
+							Name:     "1",
+							Metadata: map[string]string{"unit": "count", "source": "foo"},
+						},
+					},
+				},
+				Edges: []flux.Edge{
+					{
+						Parent: flux.OperationID("from0"),
+						Child:  flux.OperationID("yield1"),
+					},
+				},
+			},
+		},
+		{
+			Name:    "yield with non-string metadata",
+			Raw:     `from(bucket: "foo") |> yield(metadata: {unit: 1})`,
+			WantErr: true,
+		},
 	}
 	for _, tc := range testcases {
 		tc := tc
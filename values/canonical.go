@@ -0,0 +1,144 @@
+package values
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/influxdata/flux/semantic"
+)
+
+// WriteCanonical writes an encoding of the value to w that is
+// the same for any two values that are DeepEqual.
+// Records are encoded with their properties sorted by label so
+// the order the properties were declared in does not change the
+// encoding. This can be used to hash composite values such as
+// records and arrays.
+func WriteCanonical(w io.Writer, v Value) {
+	var buf [binary.MaxVarintLen64]byte
+	if v.IsNull() {
+		_, _ = w.Write([]byte{^byte(0)})
+		return
+	}
+
+	nature := v.Type().Nature()
+	_, _ = w.Write([]byte{byte(nature)})
+	switch nature {
+	case semantic.Int:
+		n := binary.PutVarint(buf[:], v.Int())
+		_, _ = w.Write(buf[:n])
+	case semantic.UInt:
+		n := binary.PutUvarint(buf[:], v.UInt())
+		_, _ = w.Write(buf[:n])
+	case semantic.Float:
+		f := v.Float()
+		if f == 0 {
+			// Normalize negative zero so it encodes the same as zero.
+			f = 0
+		}
+		n := binary.PutUvarint(buf[:], math.Float64bits(f))
+		_, _ = w.Write(buf[:n])
+	case semantic.Time:
+		n := binary.PutVarint(buf[:], int64(v.Time()))
+		_, _ = w.Write(buf[:n])
+	case semantic.Bool:
+		if v.Bool() {
+			_, _ = w.Write([]byte{1})
+		} else {
+			_, _ = w.Write([]byte{0})
+		}
+	case semantic.String:
+		writeCanonicalBytes(w, []byte(v.Str()))
+	case semantic.Bytes:
+		writeCanonicalBytes(w, v.Bytes())
+	case semantic.Duration:
+		writeCanonicalBytes(w, []byte(v.Duration().String()))
+	case semantic.Regexp:
+		writeCanonicalBytes(w, []byte(v.Regexp().String()))
+	case semantic.Array:
+		arr := v.Array()
+		n := binary.PutUvarint(buf[:], uint64(arr.Len()))
+		_, _ = w.Write(buf[:n])
+		arr.Range(func(i int, v Value) {
+			WriteCanonical(w, v)
+		})
+	case semantic.Object:
+		obj := v.Object()
+		labels := make([]string, 0, obj.Len())
+		obj.Range(func(name string, _ Value) {
+			labels = append(labels, name)
+		})
+		sort.Strings(labels)
+
+		n := binary.PutUvarint(buf[:], uint64(len(labels)))
+		_, _ = w.Write(buf[:n])
+		for _, label := range labels {
+			v, _ := obj.Get(label)
+			writeCanonicalBytes(w, []byte(label))
+			WriteCanonical(w, v)
+		}
+	case semantic.Dictionary:
+		dict := v.Dict()
+		n := binary.PutUvarint(buf[:], uint64(dict.Len()))
+		_, _ = w.Write(buf[:n])
+		dict.Range(func(key, value Value) {
+			WriteCanonical(w, key)
+			WriteCanonical(w, value)
+		})
+	}
+}
+
+func writeCanonicalBytes(w io.Writer, b []byte) {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], uint64(len(b)))
+	_, _ = w.Write(buf[:n])
+	_, _ = w.Write(b)
+}
+
+// DeepEqual reports whether two values are equal.
+// Unlike Equal, a null value is equal to another null value.
+// Records are equal when they have the same set of properties
+// with DeepEqual values regardless of the order the properties
+// were declared in, and arrays are equal when their elements are
+// pairwise DeepEqual.
+func DeepEqual(l, r Value) bool {
+	if lnull, rnull := l.IsNull(), r.IsNull(); lnull || rnull {
+		return lnull && rnull
+	}
+
+	nature := l.Type().Nature()
+	if nature != r.Type().Nature() {
+		return false
+	}
+
+	switch nature {
+	case semantic.Array:
+		la, ra := l.Array(), r.Array()
+		if la.Len() != ra.Len() {
+			return false
+		}
+		for i, n := 0, la.Len(); i < n; i++ {
+			if !DeepEqual(la.Get(i), ra.Get(i)) {
+				return false
+			}
+		}
+		return true
+	case semantic.Object:
+		lo, ro := l.Object(), r.Object()
+		if lo.Len() != ro.Len() {
+			return false
+		}
+		equal := true
+		lo.Range(func(name string, lv Value) {
+			if !equal {
+				return
+			}
+			rv, ok := ro.Get(name)
+			equal = ok && DeepEqual(lv, rv)
+		})
+		return equal
+	default:
+		return l.Equal(r)
+	}
+}
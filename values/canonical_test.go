@@ -0,0 +1,94 @@
+package values_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/influxdata/flux/semantic"
+	"github.com/influxdata/flux/values"
+)
+
+func TestDeepEqual(t *testing.T) {
+	newRecord := func(kvs ...interface{}) values.Value {
+		obj, err := values.BuildObject(func(set values.ObjectSetter) error {
+			for i := 0; i < len(kvs); i += 2 {
+				set(kvs[i].(string), kvs[i+1].(values.Value))
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return obj
+	}
+
+	for _, tt := range []struct {
+		name string
+		l, r values.Value
+		want bool
+	}{
+		{
+			name: "record different order",
+			l:    newRecord("a", values.NewInt(1), "b", values.NewString("x")),
+			r:    newRecord("b", values.NewString("x"), "a", values.NewInt(1)),
+			want: true,
+		},
+		{
+			name: "record different value",
+			l:    newRecord("a", values.NewInt(1), "b", values.NewString("x")),
+			r:    newRecord("a", values.NewInt(1), "b", values.NewString("y")),
+			want: false,
+		},
+		{
+			name: "record different labels",
+			l:    newRecord("a", values.NewInt(1)),
+			r:    newRecord("b", values.NewInt(1)),
+			want: false,
+		},
+		{
+			name: "record null property",
+			l:    newRecord("a", values.NewNull(semantic.BasicInt)),
+			r:    newRecord("a", values.NewNull(semantic.BasicInt)),
+			want: true,
+		},
+		{
+			name: "nested record",
+			l:    newRecord("a", newRecord("x", values.NewInt(1), "y", values.NewInt(2))),
+			r:    newRecord("a", newRecord("y", values.NewInt(2), "x", values.NewInt(1))),
+			want: true,
+		},
+		{
+			name: "array",
+			l: values.NewArrayWithBacking(semantic.NewArrayType(semantic.BasicInt), []values.Value{
+				values.NewInt(1), values.NewInt(2),
+			}),
+			r: values.NewArrayWithBacking(semantic.NewArrayType(semantic.BasicInt), []values.Value{
+				values.NewInt(1), values.NewInt(2),
+			}),
+			want: true,
+		},
+		{
+			name: "array different order",
+			l: values.NewArrayWithBacking(semantic.NewArrayType(semantic.BasicInt), []values.Value{
+				values.NewInt(1), values.NewInt(2),
+			}),
+			r: values.NewArrayWithBacking(semantic.NewArrayType(semantic.BasicInt), []values.Value{
+				values.NewInt(2), values.NewInt(1),
+			}),
+			want: false,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := values.DeepEqual(tt.l, tt.r); got != tt.want {
+				t.Errorf("unexpected DeepEqual result: want=%v got=%v", tt.want, got)
+			}
+
+			var lb, rb bytes.Buffer
+			values.WriteCanonical(&lb, tt.l)
+			values.WriteCanonical(&rb, tt.r)
+			if got := bytes.Equal(lb.Bytes(), rb.Bytes()); got != tt.want {
+				t.Errorf("unexpected canonical encoding comparison: want=%v got=%v", tt.want, got)
+			}
+		})
+	}
+}